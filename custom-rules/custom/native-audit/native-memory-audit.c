@@ -0,0 +1,95 @@
+// Test cases for native-memory-audit rules
+#include <stdio.h>
+#include <stdlib.h>
+#include <string.h>
+#include <syslog.h>
+
+// =============================================================================
+// TRUE POSITIVES - Should be detected
+// =============================================================================
+
+void copy_name(const char *name)
+{
+    char buf[64];
+    // ruleid: native-unbounded-copy-stack-buffer-audit
+    strcpy(buf, name);
+    puts(buf);
+}
+
+void build_path(const char *dir, const char *file)
+{
+    char path[256];
+    // ruleid: native-unbounded-copy-stack-buffer-audit
+    sprintf(path, "%s/%s", dir, file);
+    puts(path);
+}
+
+void log_request(const char *msg)
+{
+    // ruleid: native-nonliteral-format-string-audit
+    syslog(LOG_INFO, msg);
+    // ruleid: native-nonliteral-format-string-audit
+    printf(msg);
+}
+
+void format_header(char *out, size_t n, const char *user_fmt)
+{
+    // ruleid: native-nonliteral-format-string-audit
+    snprintf(out, n, user_fmt);
+}
+
+struct record *read_records(FILE *f)
+{
+    unsigned int count;
+    fread(&count, sizeof(count), 1, f);
+    // ruleid: native-alloc-size-overflow-audit
+    struct record *r = malloc(count * sizeof(struct record));
+    fread(r, sizeof(struct record), count, f);
+    return r;
+}
+
+unsigned char *grow(unsigned char *p, size_t width, size_t height)
+{
+    // ruleid: native-alloc-size-overflow-audit
+    return realloc(p, width * height);
+}
+
+// =============================================================================
+// TRUE NEGATIVES - Should NOT be detected
+// =============================================================================
+
+void copy_name_safe(const char *name)
+{
+    char buf[64];
+    // ok: native-unbounded-copy-stack-buffer-audit
+    strcpy(buf, "anonymous");
+    // ok: native-unbounded-copy-stack-buffer-audit
+    snprintf(buf, sizeof(buf), "%s", name);
+    puts(buf);
+}
+
+void log_request_safe(const char *msg)
+{
+    // ok: native-nonliteral-format-string-audit
+    syslog(LOG_INFO, "%s", msg);
+    // ok: native-nonliteral-format-string-audit
+    printf("%s\n", msg);
+}
+
+struct record *read_records_safe(FILE *f)
+{
+    unsigned int count;
+    fread(&count, sizeof(count), 1, f);
+    if (count > SIZE_MAX / sizeof(struct record)) {
+        return NULL;
+    }
+    // ok: native-alloc-size-overflow-audit
+    struct record *r = malloc(count * sizeof(struct record));
+    return r;
+}
+
+void *table(void)
+{
+    // ok: native-alloc-size-overflow-audit
+    return malloc(16 * sizeof(int));
+}
@@ -0,0 +1,107 @@
+// Test cases for Go os.Root migration rules
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// === TRUE POSITIVES: go-open-joined-path-use-openinroot ===
+
+func openUpload(dir, name string) (*os.File, error) {
+	// ruleid: go-open-joined-path-use-openinroot
+	return os.Open(filepath.Join(dir, name))
+}
+
+// === TRUE NEGATIVES: go-open-joined-path-use-openinroot ===
+
+func openConstant(dir string) (*os.File, error) {
+	// ok: go-open-joined-path-use-openinroot
+	return os.Open(filepath.Join(dir, "config.json"))
+}
+
+func openBase(dir, name string) (*os.File, error) {
+	// ok: go-open-joined-path-use-openinroot
+	return os.Open(filepath.Join(dir, filepath.Base(name)))
+}
+
+func openIsLocal(dir, name string) (*os.File, error) {
+	if !filepath.IsLocal(name) {
+		return nil, errors.New("invalid name")
+	}
+	// ok: go-open-joined-path-use-openinroot
+	return os.Open(filepath.Join(dir, name))
+}
+
+func openLocalized(dir, slashed string) (*os.File, error) {
+	name, err := filepath.Localize(slashed)
+	if err != nil {
+		return nil, err
+	}
+	// ok: go-open-joined-path-use-openinroot
+	return os.Open(filepath.Join(dir, name))
+}
+
+func openInRoot(dir, name string) (*os.File, error) {
+	// ok: go-open-joined-path-use-openinroot
+	return os.OpenInRoot(dir, name)
+}
+
+// === TRUE POSITIVES: go-joined-path-use-root, go-joined-path-write-use-root, and their -go125 rules ===
+
+func copyWithinRoot(dir, src, dst string) error {
+	root, err := os.OpenRoot(dir)
+	if err != nil {
+		return err
+	}
+	defer root.Close()
+	// ruleid: go-joined-path-use-root-go125
+	data, err := os.ReadFile(filepath.Join(dir, src))
+	if err != nil {
+		return err
+	}
+	// ruleid: go-joined-path-use-root
+	if _, err := os.Lstat(filepath.Join(dir, dst)); err == nil {
+		return errors.New("exists")
+	}
+	// ruleid: go-joined-path-write-use-root-go125
+	return os.WriteFile(filepath.Join(dir, dst), data, 0o644)
+}
+
+func openFileWithinRoot(dir, name string) (*os.File, error) {
+	root, err := os.OpenRoot(dir)
+	if err != nil {
+		return nil, err
+	}
+	defer root.Close()
+	// ruleid: go-joined-path-write-use-root
+	return os.OpenFile(filepath.Join(dir, name), os.O_WRONLY|os.O_CREATE, 0o600)
+}
+
+// === TRUE NEGATIVES: go-joined-path-use-root, go-joined-path-write-use-root, and their -go125 rules ===
+
+func copyThroughRoot(dir, src, dst string) error {
+	root, err := os.OpenRoot(dir)
+	if err != nil {
+		return err
+	}
+	defer root.Close()
+	// ok: go-joined-path-use-root-go125
+	data, err := root.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	// ok: go-joined-path-write-use-root-go125
+	return root.WriteFile(dst, data, 0o644)
+}
+
+func otherDirectory(dir, other, name string) ([]byte, error) {
+	root, err := os.OpenRoot(dir)
+	if err != nil {
+		return nil, err
+	}
+	defer root.Close()
+	// ok: go-joined-path-use-root-go125
+	return os.ReadFile(filepath.Join(other, name))
+}
@@ -7,6 +7,8 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+
+	"github.com/pkg/sftp"
 )
 
 type Repository struct {
@@ -36,6 +38,16 @@ func (r *Repository) VulnerableCreate(path string) (*os.File, error) {
 	return os.Create(fullPath)
 }
 
+func (r *Repository) VulnerableRemoteLstat(client *sftp.Client, path string, content []byte) error {
+	// Lstat on the remote host, not on the file written below
+	info, err := client.Lstat(path)
+	if err == nil && info.Mode()&os.ModeSymlink != 0 {
+		return errors.New("symlink not allowed")
+	}
+	// ruleid: go-repo-write-no-symlink-check
+	return os.WriteFile(filepath.Join(r.Path, path), content, 0644)
+}
+
 // === TRUE NEGATIVES: go-repo-write-no-symlink-check ===
 
 func (r *Repository) SafeWithLstat(path string, content []byte) error {
@@ -58,6 +70,39 @@ func (r *Repository) SafeWithEval(path string, content []byte) error {
 	return os.WriteFile(realPath, content, 0644)
 }
 
+func (r *Repository) SafeWithRootLstat(path string, content []byte) error {
+	root, err := os.OpenRoot(r.Path)
+	if err != nil {
+		return err
+	}
+	defer root.Close()
+	info, err := root.Lstat(path)
+	if err == nil && info.Mode()&os.ModeSymlink != 0 {
+		return errors.New("symlink not allowed")
+	}
+	// ok: go-repo-write-no-symlink-check
+	return os.WriteFile(filepath.Join(r.Path, path), content, 0644)
+}
+
+func (r *Repository) SafeWithRootParam(root *os.Root, path string, content []byte) error {
+	info, err := root.Lstat(path)
+	if err == nil && info.Mode()&os.ModeSymlink != 0 {
+		return errors.New("symlink not allowed")
+	}
+	// ok: go-repo-write-no-symlink-check
+	return os.WriteFile(filepath.Join(r.Path, path), content, 0644)
+}
+
+func (r *Repository) SafeThroughRoot(path string, content []byte) error {
+	root, err := os.OpenRoot(r.Path)
+	if err != nil {
+		return err
+	}
+	defer root.Close()
+	// ok: go-repo-write-no-symlink-check
+	return root.WriteFile(path, content, 0644)
+}
+
 // === TRUE POSITIVES: go-write-after-join-audit ===
 
 func vulnerableWriteAfterJoin(base, user string, data []byte) error {
@@ -72,6 +117,16 @@ func vulnerableIoutilAfterJoin(base, user string, data []byte) error {
 	return ioutil.WriteFile(fullPath, data, 0644)
 }
 
+func vulnerableWriteAfterRemoteLstat(client *sftp.Client, base, user string, data []byte) error {
+	fullPath := filepath.Join(base, user)
+	info, err := client.Lstat(fullPath)
+	if err == nil && info.Mode()&os.ModeSymlink != 0 {
+		return errors.New("symlink")
+	}
+	// ruleid: go-write-after-join-audit
+	return os.WriteFile(fullPath, data, 0644)
+}
+
 // === TRUE NEGATIVES: go-write-after-join-audit ===
 
 func safeWriteWithLstat(base, user string, data []byte) error {
@@ -84,6 +139,17 @@ func safeWriteWithLstat(base, user string, data []byte) error {
 	return os.WriteFile(fullPath, data, 0644)
 }
 
+func safeWriteAfterOpenInRoot(base, user string, data []byte) error {
+	fullPath := filepath.Join(base, user)
+	f, err := os.OpenInRoot(base, user)
+	if err != nil {
+		return err
+	}
+	f.Close()
+	// ok: go-write-after-join-audit
+	return os.WriteFile(fullPath, data, 0644)
+}
+
 func safeWriteWithEval(base, user string, data []byte) error {
 	fullPath := filepath.Join(base, user)
 	realPath, err := filepath.EvalSymlinks(fullPath)
@@ -0,0 +1,60 @@
+// Test cases for cloud-sdk-misuse rules (Go)
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// =============================================================================
+// TRUE POSITIVES
+// =============================================================================
+
+func shareLink(ctx context.Context, presigner *s3.PresignClient, key string) {
+	// ruleid: go-cloud-presigned-url-long-expiry
+	presigner.PresignGetObject(ctx, &s3.GetObjectInput{Bucket: aws.String("files"), Key: aws.String(key)}, s3.WithPresignExpires(7*24*time.Hour))
+}
+
+func uploadAvatar(ctx context.Context, client *s3.Client, key string, body io.Reader) {
+	// ruleid: go-cloud-storage-public-acl
+	client.PutObject(ctx, &s3.PutObjectInput{Bucket: aws.String("uploads"), Key: aws.String(key), Body: body, ACL: types.ObjectCannedACLPublicRead})
+}
+
+func publish(ctx context.Context, obj *storage.ObjectHandle) {
+	// ruleid: go-cloud-storage-public-acl, go-iam-wildcard-policy-document
+	obj.ACL().Set(ctx, storage.AllUsers, storage.RoleReader)
+}
+
+// ruleid: go-iam-wildcard-policy-document
+const tenantPolicy = `{"Version": "2012-10-17", "Statement": [{"Effect": "Allow", "Action": "s3:*", "Resource": "*"}]}`
+
+func metadataProxy(w http.ResponseWriter, r *http.Request) {
+	path := r.URL.Query().Get("path")
+	// ruleid: cloud-metadata-url-user-input
+	resp, _ := http.Get("http://169.254.169.254/latest/meta-data/" + path)
+	io.Copy(w, resp.Body)
+}
+
+// =============================================================================
+// TRUE NEGATIVES - Should NOT be detected
+// =============================================================================
+
+func downloadLink(ctx context.Context, presigner *s3.PresignClient, key string) {
+	// ok: go-cloud-presigned-url-long-expiry
+	presigner.PresignGetObject(ctx, &s3.GetObjectInput{Bucket: aws.String("files"), Key: aws.String(key)}, s3.WithPresignExpires(15*time.Minute))
+}
+
+func uploadPrivate(ctx context.Context, client *s3.Client, key string, body io.Reader) {
+	// ok: go-cloud-storage-public-acl
+	client.PutObject(ctx, &s3.PutObjectInput{Bucket: aws.String("uploads"), Key: aws.String(key), Body: body, ACL: types.ObjectCannedACLPrivate})
+}
+
+// ok: go-iam-wildcard-policy-document
+const readPolicy = `{"Version": "2012-10-17", "Statement": [{"Effect": "Allow", "Action": "s3:GetObject", "Resource": "arn:aws:s3:::files/*"}]}`
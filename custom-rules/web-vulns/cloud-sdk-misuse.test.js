@@ -0,0 +1,46 @@
+// Test cases for cloud-sdk-misuse rules (JavaScript)
+const { S3Client, PutObjectCommand, GetObjectCommand } = require('@aws-sdk/client-s3');
+const { getSignedUrl } = require('@aws-sdk/s3-request-presigner');
+
+const client = new S3Client({});
+
+// =============================================================================
+// TRUE POSITIVES
+// =============================================================================
+
+async function shareLink(key) {
+    // ruleid: js-cloud-presigned-url-long-expiry
+    return getSignedUrl(client, new GetObjectCommand({ Bucket: 'files', Key: key }), { expiresIn: 60 * 60 * 24 * 7 });
+}
+
+async function uploadAvatar(userId, body) {
+    // ruleid: js-cloud-storage-public-acl
+    await client.send(new PutObjectCommand({ Bucket: 'uploads', Key: `avatars/${userId}`, Body: body, ACL: "public-read" }));
+}
+
+function tenantPolicy(bucket) {
+    return {
+        Version: '2012-10-17',
+        // ruleid: js-iam-wildcard-policy-document
+        Statement: [{ Effect: 'Allow', Action: '*', Resource: `arn:aws:s3:::${bucket}/*` }],
+    };
+}
+
+// =============================================================================
+// TRUE NEGATIVES - Should NOT be detected
+// =============================================================================
+
+async function downloadLink(key) {
+    // ok: js-cloud-presigned-url-long-expiry
+    return getSignedUrl(client, new GetObjectCommand({ Bucket: 'files', Key: key }), { expiresIn: 900 });
+}
+
+async function uploadPrivate(userId, body) {
+    // ok: js-cloud-storage-public-acl
+    await client.send(new PutObjectCommand({ Bucket: 'uploads', Key: `avatars/${userId}`, Body: body }));
+}
+
+function readPolicy(bucket) {
+    // ok: js-iam-wildcard-policy-document
+    return { Effect: 'Allow', Action: ['s3:GetObject'], Resource: `arn:aws:s3:::${bucket}/*` };
+}
@@ -0,0 +1,80 @@
+# Test cases for cloud-sdk-misuse rules (Python)
+import json
+from datetime import timedelta
+
+import boto3
+import requests
+from flask import request
+
+s3 = boto3.client("s3")
+
+# =============================================================================
+# TRUE POSITIVES
+# =============================================================================
+
+
+def share_link(key):
+    # ruleid: python-cloud-presigned-url-long-expiry
+    return s3.generate_presigned_url("get_object", Params={"Bucket": "files", "Key": key}, ExpiresIn=7 * 24 * 3600)
+
+
+def gcs_link(blob):
+    # ruleid: python-cloud-presigned-url-long-expiry
+    return blob.generate_signed_url(version="v4", expiration=timedelta(days=30))
+
+
+def upload_avatar(key, body):
+    # ruleid: python-cloud-storage-public-acl
+    s3.put_object(Bucket="uploads", Key=key, Body=body, ACL="public-read")
+
+
+def upload_export(fileobj, key):
+    # ruleid: python-cloud-storage-public-acl
+    s3.upload_fileobj(fileobj, "exports", key, ExtraArgs={"ContentType": "text/csv", "ACL": "public-read"})
+
+
+def publish(blob):
+    # ruleid: python-cloud-storage-public-acl
+    blob.make_public()
+
+
+def tenant_role_policy(bucket):
+    return json.dumps({
+        "Version": "2012-10-17",
+        # ruleid: python-iam-wildcard-policy-document
+        "Statement": [{"Effect": "Allow", "Action": "s3:*", "Resource": f"arn:aws:s3:::{bucket}/*"}],
+    })
+
+
+def metadata_proxy():
+    path = request.args.get("path")
+    # ruleid: cloud-metadata-url-user-input
+    return requests.get("http://169.254.169.254/latest/meta-data/" + path).text
+
+
+# =============================================================================
+# TRUE NEGATIVES - Should NOT be detected
+# =============================================================================
+
+
+def download_link(key):
+    # ok: python-cloud-presigned-url-long-expiry
+    return s3.generate_presigned_url("get_object", Params={"Bucket": "files", "Key": key}, ExpiresIn=300)
+
+
+def upload_private(key, body):
+    # ok: python-cloud-storage-public-acl
+    s3.put_object(Bucket="uploads", Key=key, Body=body, ACL="private")
+
+
+def read_policy(bucket):
+    return json.dumps({
+        "Version": "2012-10-17",
+        # ok: python-iam-wildcard-policy-document
+        "Statement": [{"Effect": "Allow", "Action": ["s3:GetObject"], "Resource": f"arn:aws:s3:::{bucket}/*"}],
+    })
+
+
+def instance_region():
+    # ok: cloud-metadata-url-user-input
+    return requests.get("http://169.254.169.254/latest/meta-data/placement/region").text
@@ -0,0 +1,88 @@
+// Test cases for csharp-injection rules
+
+using System.Data.SqlClient;
+using System.IO;
+using System.Runtime.Serialization.Formatters.Binary;
+using Dapper;
+using Microsoft.AspNetCore.Mvc;
+using Microsoft.EntityFrameworkCore;
+
+public class FilesController : Controller
+{
+    // =========================================================================
+    // TRUE POSITIVES - Should be detected
+    // =========================================================================
+
+    public IActionResult Save([FromQuery] string name, [FromForm] string content)
+    {
+        var path = Path.Combine("/var/app/uploads", name);
+        // ruleid: csharp-path-combine-write
+        System.IO.File.WriteAllText(path, content);
+        return Ok();
+    }
+
+    public IActionResult Upload(IFormFile file)
+    {
+        var dest = Path.Combine(_uploadRoot, file.FileName);
+        // ruleid: csharp-path-combine-write
+        using var stream = new FileStream(dest, FileMode.Create);
+        file.CopyTo(stream);
+        return Ok();
+    }
+
+    public IActionResult Restore()
+    {
+        var formatter = new BinaryFormatter();
+        // ruleid: csharp-binaryformatter-taint
+        var state = formatter.Deserialize(Request.Body);
+        return Ok(state);
+    }
+
+    public IActionResult Find([FromQuery] string name)
+    {
+        // ruleid: csharp-sql-concatenation
+        var cmd = new SqlCommand("SELECT * FROM Users WHERE Name = '" + name + "'", _conn);
+        return Ok(cmd.ExecuteScalar());
+    }
+
+    public IActionResult Search([FromQuery] string term)
+    {
+        // ruleid: csharp-sql-concatenation
+        var posts = _db.Posts.FromSqlRaw($"SELECT * FROM Posts WHERE Title LIKE '%{term}%'").ToList();
+        return Ok(posts);
+    }
+
+    public IActionResult Orders([FromRoute] string status)
+    {
+        // ruleid: csharp-sql-concatenation
+        var orders = _conn.Query<Order>("SELECT * FROM Orders WHERE Status = '" + status + "'");
+        return Ok(orders);
+    }
+
+    // =========================================================================
+    // TRUE NEGATIVES - Should NOT be detected
+    // =========================================================================
+
+    public IActionResult SaveSafe([FromQuery] string name, [FromForm] string content)
+    {
+        var path = Path.Combine("/var/app/uploads", Path.GetFileName(name));
+        // ok: csharp-path-combine-write
+        System.IO.File.WriteAllText(path, content);
+        return Ok();
+    }
+
+    public IActionResult FindSafe([FromQuery] string name)
+    {
+        // ok: csharp-sql-concatenation
+        var cmd = new SqlCommand("SELECT * FROM Users WHERE Name = @name", _conn);
+        cmd.Parameters.AddWithValue("@name", name);
+        return Ok(cmd.ExecuteScalar());
+    }
+
+    public IActionResult OrdersSafe([FromRoute] string status)
+    {
+        // ok: csharp-sql-concatenation
+        var orders = _conn.Query<Order>("SELECT * FROM Orders WHERE Status = @status", new { status });
+        return Ok(orders);
+    }
+}
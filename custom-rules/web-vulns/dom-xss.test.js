@@ -0,0 +1,78 @@
+// Test cases for dom-xss rules (JavaScript)
+import DOMPurify from "dompurify";
+import { useSearchParams } from "react-router-dom";
+
+// =============================================================================
+// TRUE POSITIVES - HTML sinks
+// =============================================================================
+
+function showWelcome() {
+  const name = new URLSearchParams(location.search).get("name");
+  // ruleid: dom-xss-html-sink
+  document.getElementById("welcome").innerHTML = "Hello, " + name;
+}
+
+function showTab() {
+  const tab = decodeURIComponent(location.hash.slice(1));
+  // ruleid: dom-xss-html-sink
+  document.write("<h2>" + tab + "</h2>");
+}
+
+window.addEventListener("message", (event) => {
+  // ruleid: dom-xss-html-sink
+  $("#notice").html(event.data.message);
+});
+
+// =============================================================================
+// TRUE POSITIVES - React
+// =============================================================================
+
+function SearchResults() {
+  const [params] = useSearchParams();
+  const query = params.get("q");
+  // ruleid: react-dangerously-set-inner-html-url-input
+  return <h1 dangerouslySetInnerHTML={{ __html: `Results for ${query}` }} />;
+}
+
+// =============================================================================
+// TRUE POSITIVES - eval family
+// =============================================================================
+
+function runCallback() {
+  const cb = new URL(document.URL).searchParams.get("callback");
+  // ruleid: dom-xss-eval-sink
+  eval(cb + "()");
+}
+
+function delayedRedirect() {
+  // ruleid: dom-xss-eval-sink
+  setTimeout("location = '" + window.name + "'", 1000);
+}
+
+// =============================================================================
+// TRUE NEGATIVES - Should NOT be detected
+// =============================================================================
+
+function showWelcomeText() {
+  const name = new URLSearchParams(location.search).get("name");
+  // ok: dom-xss-html-sink
+  document.getElementById("welcome").textContent = "Hello, " + name;
+}
+
+function showWelcomeSanitized() {
+  const name = new URLSearchParams(location.search).get("name");
+  // ok: dom-xss-html-sink
+  document.getElementById("welcome").innerHTML = DOMPurify.sanitize("Hello, " + name);
+}
+
+function SearchResultsSafe() {
+  const [params] = useSearchParams();
+  const query = params.get("q");
+  // ok: react-dangerously-set-inner-html-url-input
+  return <h1 dangerouslySetInnerHTML={{ __html: DOMPurify.sanitize(query) }} />;
+}
+
+function delayedRefresh() {
+  // ok: dom-xss-eval-sink
+  setTimeout(() => location.reload(), 1000);
+}
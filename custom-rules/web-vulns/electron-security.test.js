@@ -0,0 +1,54 @@
+// Test cases for electron-security rules (JavaScript)
+const { app, BrowserWindow, ipcMain, shell } = require("electron");
+const path = require("path");
+
+// =============================================================================
+// TRUE POSITIVES
+// =============================================================================
+
+function createLegacyWindow() {
+  return new BrowserWindow({
+    width: 1200,
+    height: 800,
+    // ruleid: electron-node-integration-enabled, electron-context-isolation-disabled
+    webPreferences: { nodeIntegration: true, contextIsolation: false },
+  });
+}
+
+function createPreviewWindow() {
+  return new BrowserWindow({
+    // ruleid: electron-web-security-disabled, electron-webview-tag-enabled
+    webPreferences: { preload: path.join(__dirname, "preload.js"), webSecurity: false, webviewTag: true },
+  });
+}
+
+ipcMain.handle("open-link", (event, url) => {
+  // ruleid: electron-shell-openexternal-untrusted-url
+  shell.openExternal(url);
+});
+
+// =============================================================================
+// TRUE NEGATIVES - Should NOT be detected
+// =============================================================================
+
+function createWindow() {
+  const win = new BrowserWindow({
+    // ok: electron-node-integration-enabled, electron-context-isolation-disabled, electron-web-security-disabled
+    webPreferences: { preload: path.join(__dirname, "preload.js"), nodeIntegration: false, contextIsolation: true, sandbox: true },
+  });
+
+  win.webContents.setWindowOpenHandler(({ url }) => {
+    const parsed = new URL(url);
+    if (parsed.protocol === "https:") {
+      // ok: electron-shell-openexternal-untrusted-url
+      shell.openExternal(url);
+    }
+    return { action: "deny" };
+  });
+  return win;
+}
+
+function openDocs() {
+  // ok: electron-shell-openexternal-untrusted-url
+  shell.openExternal("https://example.com/docs");
+}
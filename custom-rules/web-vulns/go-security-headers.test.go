@@ -0,0 +1,144 @@
+// Test cases for go-security-headers rules
+package main
+
+import (
+	"html/template"
+	"net/http"
+
+	"github.com/gin-contrib/secure"
+	"github.com/gin-gonic/gin"
+	"github.com/labstack/echo/v4"
+)
+
+var page = template.Must(template.New("page").Parse("<p>{{.}}</p>"))
+
+// =============================================================================
+// TRUE POSITIVES - net/http cookies
+// =============================================================================
+
+func loginInsecure(w http.ResponseWriter, r *http.Request) {
+	// ruleid: go-session-cookie-missing-secure, go-session-cookie-missing-httponly, go-session-cookie-missing-samesite
+	http.SetCookie(w, &http.Cookie{Name: "session_id", Value: newToken(), Path: "/"})
+}
+
+func loginNoHttpOnly(w http.ResponseWriter, r *http.Request) {
+	// ruleid: go-session-cookie-missing-httponly
+	cookie := &http.Cookie{
+		Name:     "auth_token",
+		Value:    newToken(),
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+	}
+	http.SetCookie(w, cookie)
+}
+
+func loginSameSiteNone(w http.ResponseWriter, r *http.Request) {
+	// ruleid: go-session-cookie-missing-samesite
+	http.SetCookie(w, &http.Cookie{
+		Name:     "sid",
+		Value:    newToken(),
+		Secure:   true,
+		HttpOnly: true,
+		SameSite: http.SameSiteNoneMode,
+	})
+}
+
+// =============================================================================
+// TRUE POSITIVES - gin
+// =============================================================================
+
+func ginLogin(c *gin.Context) {
+	// ruleid: go-gin-cookie-insecure-flags
+	c.SetCookie("session", newToken(), 3600, "/", "", false, true)
+}
+
+func ginLoginScriptReadable(c *gin.Context) {
+	// ruleid: go-gin-cookie-insecure-flags
+	c.SetCookie("jwt", newToken(), 3600, "/", "", true, false)
+}
+
+// =============================================================================
+// TRUE POSITIVES - HTML without security headers
+// =============================================================================
+
+func profilePage(w http.ResponseWriter, r *http.Request) {
+	// ruleid: go-html-handler-missing-security-headers
+	page.Execute(w, r.URL.Query().Get("name"))
+}
+
+func rawHTML(w http.ResponseWriter, r *http.Request) {
+	// ruleid: go-html-handler-missing-security-headers
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write([]byte("<h1>hi</h1>"))
+}
+
+func ginDashboard(c *gin.Context) {
+	// ruleid: go-framework-html-missing-security-headers
+	c.HTML(http.StatusOK, "dashboard.tmpl", gin.H{"user": c.Query("user")})
+}
+
+func echoSettings(c echo.Context) error {
+	// ruleid: go-framework-html-missing-security-headers
+	return c.Render(http.StatusOK, "settings.html", nil)
+}
+
+// =============================================================================
+// TRUE POSITIVES - middleware configuration
+// =============================================================================
+
+func ginMiddleware(r *gin.Engine) {
+	// ruleid: go-secure-middleware-development-mode
+	r.Use(secure.New(secure.Config{FrameDeny: true, IsDevelopment: true}))
+}
+
+// =============================================================================
+// TRUE NEGATIVES - Should NOT be detected
+// =============================================================================
+
+func loginHardened(w http.ResponseWriter, r *http.Request) {
+	// ok: go-session-cookie-missing-secure, go-session-cookie-missing-httponly, go-session-cookie-missing-samesite
+	http.SetCookie(w, &http.Cookie{
+		Name:     "session_id",
+		Value:    newToken(),
+		Path:     "/",
+		Secure:   true,
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+	})
+}
+
+func preferenceCookie(w http.ResponseWriter, r *http.Request) {
+	// ok: go-session-cookie-missing-secure, go-session-cookie-missing-httponly
+	http.SetCookie(w, &http.Cookie{Name: "theme", Value: "dark"})
+}
+
+func ginLoginHardened(c *gin.Context) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	// ok: go-gin-cookie-insecure-flags
+	c.SetCookie("session", newToken(), 3600, "/", "", true, true)
+}
+
+func profilePageHardened(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Security-Policy", "default-src 'self'; frame-ancestors 'none'")
+	// ok: go-html-handler-missing-security-headers
+	page.Execute(w, r.URL.Query().Get("name"))
+}
+
+func jsonAPI(w http.ResponseWriter, r *http.Request) {
+	// ok: go-html-handler-missing-security-headers
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(`{"ok":true}`))
+}
+
+func ginDashboardHardened(c *gin.Context) {
+	c.Header("X-Frame-Options", "DENY")
+	// ok: go-framework-html-missing-security-headers
+	c.HTML(http.StatusOK, "dashboard.tmpl", nil)
+}
+
+func ginMiddlewareProduction(r *gin.Engine) {
+	// ok: go-secure-middleware-development-mode
+	r.Use(secure.New(secure.Config{FrameDeny: true, IsDevelopment: false}))
+}
+
+func newToken() string { return "" }
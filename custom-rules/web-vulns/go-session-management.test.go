@@ -0,0 +1,127 @@
+// Test cases for go-session-management rules
+package main
+
+import (
+	"encoding/hex"
+	"log"
+	"math/rand"
+	"net/http"
+	"time"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/gorilla/securecookie"
+	"github.com/gorilla/sessions"
+)
+
+var (
+	store          = sessions.NewFilesystemStore("", []byte("key"))
+	sessionManager = scs.New()
+)
+
+// =============================================================================
+// TRUE POSITIVES - weak randomness
+// =============================================================================
+
+func newSessionToken() string {
+	b := make([]byte, 16)
+	for i := range b {
+		// ruleid: go-session-token-weak-randomness
+		b[i] = byte(rand.Intn(256))
+	}
+	return hex.EncodeToString(b)
+}
+
+// =============================================================================
+// TRUE POSITIVES - session IDs in logs
+// =============================================================================
+
+func logSession(w http.ResponseWriter, r *http.Request) {
+	session, _ := store.Get(r, "app")
+	// ruleid: go-session-id-logged
+	log.Printf("loaded session %s", session.ID)
+
+	cookie, err := r.Cookie("session_id")
+	if err == nil {
+		// ruleid: go-session-id-logged
+		log.Println("cookie", cookie.Value)
+	}
+
+	// ruleid: go-session-id-logged
+	log.Printf("scs token %s", sessionManager.Token(r.Context()))
+}
+
+// =============================================================================
+// TRUE POSITIVES - no regeneration after login
+// =============================================================================
+
+func scsLogin(w http.ResponseWriter, r *http.Request) {
+	userID := authenticate(r)
+	// ruleid: go-scs-session-not-renewed-after-login
+	sessionManager.Put(r.Context(), "userID", userID)
+}
+
+func gorillaLogin(w http.ResponseWriter, r *http.Request) {
+	session, _ := store.Get(r, "app")
+	// ruleid: go-gorilla-session-not-regenerated-after-login
+	session.Values["user_id"] = authenticate(r)
+	session.Save(r, w)
+}
+
+// =============================================================================
+// TRUE POSITIVES - unlimited lifetime
+// =============================================================================
+
+func configureSessions(sc *securecookie.SecureCookie) {
+	// ruleid: go-session-unlimited-lifetime
+	sc.MaxAge(0)
+	// ruleid: go-session-unlimited-lifetime
+	store.Options = &sessions.Options{Path: "/", MaxAge: 86400 * 365 * 10, HttpOnly: true}
+	// ruleid: go-session-unlimited-lifetime
+	sessionManager.Lifetime = 8760 * time.Hour
+}
+
+// =============================================================================
+// TRUE NEGATIVES - Should NOT be detected
+// =============================================================================
+
+func retryJitter() time.Duration {
+	// ok: go-session-token-weak-randomness
+	return time.Duration(rand.Intn(100)) * time.Millisecond
+}
+
+func logRequest(w http.ResponseWriter, r *http.Request) {
+	session, _ := store.Get(r, "app")
+	// ok: go-session-id-logged
+	log.Printf("user %v loaded page %s", session.Values["user_id"], r.URL.Path)
+}
+
+func scsLoginRenewed(w http.ResponseWriter, r *http.Request) {
+	userID := authenticate(r)
+	if err := sessionManager.RenewToken(r.Context()); err != nil {
+		return
+	}
+	// ok: go-scs-session-not-renewed-after-login
+	sessionManager.Put(r.Context(), "userID", userID)
+}
+
+func scsPreferences(w http.ResponseWriter, r *http.Request) {
+	// ok: go-scs-session-not-renewed-after-login
+	sessionManager.Put(r.Context(), "theme", "dark")
+}
+
+func gorillaLoginRegenerated(w http.ResponseWriter, r *http.Request) {
+	session, _ := store.Get(r, "app")
+	session.ID = ""
+	// ok: go-gorilla-session-not-regenerated-after-login
+	session.Values["user_id"] = authenticate(r)
+	session.Save(r, w)
+}
+
+func configureSessionsBounded() {
+	// ok: go-session-unlimited-lifetime
+	store.Options = &sessions.Options{Path: "/", MaxAge: 86400 * 7, HttpOnly: true}
+	// ok: go-session-unlimited-lifetime
+	sessionManager.Lifetime = 12 * time.Hour
+}
+
+func authenticate(r *http.Request) string { return "" }
@@ -0,0 +1,41 @@
+// Test cases for idor-audit rules (Express)
+const express = require('express');
+
+const router = express.Router();
+
+// =============================================================================
+// TRUE POSITIVES - authenticated handler, lookup by ID only
+// =============================================================================
+
+router.get('/orders/:id', requireAuth, async (req, res) => {
+    // ruleid: express-idor-lookup-by-request-id
+    const order = await Order.findById(req.params.id);
+    res.json(order);
+});
+
+router.delete('/files/:fileId', async (req, res) => {
+    audit(req.user.id, 'delete');
+    // ruleid: express-idor-lookup-by-request-id
+    await File.destroy({ where: { id: req.params.fileId } });
+    res.sendStatus(204);
+});
+
+router.get('/invoices/:id', passport.authenticate('jwt'), async (req, res) => {
+    // ruleid: express-idor-lookup-by-request-id
+    res.json(await prisma.invoice.findUnique({ where: { id: req.params.id } }));
+});
+
+// =============================================================================
+// TRUE NEGATIVES - Should NOT be detected
+// =============================================================================
+
+router.get('/orders/:id/mine', requireAuth, async (req, res) => {
+    // ok: express-idor-lookup-by-request-id
+    const order = await Order.findOne({ _id: req.params.id, owner: req.user.id });
+    res.json(order);
+});
+
+router.get('/products/:id', async (req, res) => {
+    // ok: express-idor-lookup-by-request-id
+    res.json(await Product.findById(req.params.id));
+});
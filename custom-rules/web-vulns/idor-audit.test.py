@@ -0,0 +1,82 @@
+# Test cases for idor-audit rules (Django, Flask-SQLAlchemy)
+from django.contrib.auth.decorators import login_required
+from django.shortcuts import get_object_or_404
+from flask_login import current_user
+from flask_sqlalchemy import SQLAlchemy
+
+db = SQLAlchemy()
+
+# =============================================================================
+# TRUE POSITIVES - Django
+# =============================================================================
+
+
+@login_required
+def invoice_detail(request, pk):
+    # ruleid: django-idor-lookup-by-request-id
+    invoice = Invoice.objects.get(pk=pk)
+    return render(request, "invoice.html", {"invoice": invoice})
+
+
+def delete_address(request):
+    if not request.user.is_authenticated:
+        return redirect("login")
+    # ruleid: django-idor-lookup-by-request-id
+    get_object_or_404(Address, id=request.POST["address_id"]).delete()
+    return redirect("addresses")
+
+
+# =============================================================================
+# TRUE POSITIVES - Flask-SQLAlchemy
+# =============================================================================
+
+
+@login_required
+def download_report(report_id):
+    # ruleid: sqlalchemy-idor-lookup-by-request-id
+    report = Report.query.get_or_404(report_id)
+    return send_file(report.path)
+
+
+def update_note(note_id):
+    log_access(current_user.id)
+    # ruleid: sqlalchemy-idor-lookup-by-request-id
+    note = db.session.get(Note, note_id)
+    note.body = request.json["body"]
+    db.session.commit()
+
+
+# =============================================================================
+# TRUE NEGATIVES - Should NOT be detected
+# =============================================================================
+
+
+@login_required
+def invoice_detail_scoped(request, pk):
+    # ok: django-idor-lookup-by-request-id
+    invoice = Invoice.objects.get(pk=pk, owner=request.user)
+    return render(request, "invoice.html", {"invoice": invoice})
+
+
+@login_required
+def invoice_via_relation(request, pk):
+    # ok: django-idor-lookup-by-request-id
+    return render(request, "invoice.html", {"invoice": request.user.invoices.get(pk=pk)})
+
+
+@login_required
+def invoice_chained(request, pk):
+    # ok: django-idor-lookup-by-request-id
+    return Invoice.objects.filter(pk=pk).filter(owner=request.user).first()
+
+
+def public_article(request, slug):
+    # ok: django-idor-lookup-by-request-id
+    return Article.objects.get(slug=slug)
+
+
+@login_required
+def download_report_scoped(report_id):
+    # ok: sqlalchemy-idor-lookup-by-request-id
+    report = Report.query.filter_by(id=report_id, owner_id=current_user.id).first_or_404()
+    return send_file(report.path)
@@ -0,0 +1,55 @@
+// Test cases for pagination-abuse rules (Go)
+package main
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const maxPageSize = 100
+
+// =============================================================================
+// TRUE POSITIVES
+// =============================================================================
+
+func listOrders(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+		var orders []Order
+		// ruleid: go-pagination-limit-unbounded
+		db.Limit(limit).Find(&orders)
+		c.JSON(http.StatusOK, orders)
+	}
+}
+
+func listUsers(db *sql.DB, w http.ResponseWriter, r *http.Request) {
+	limit := r.URL.Query().Get("per_page")
+	// ruleid: go-pagination-limit-unbounded
+	rows, _ := db.Query("SELECT id, email FROM users ORDER BY id LIMIT $1", limit)
+	defer rows.Close()
+}
+
+// =============================================================================
+// TRUE NEGATIVES - Should NOT be detected
+// =============================================================================
+
+func listOrdersClamped(db *gorm.DB, c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	var orders []Order
+	// ok: go-pagination-limit-unbounded
+	db.Limit(min(limit, maxPageSize)).Find(&orders)
+}
+
+func listOrdersChecked(db *gorm.DB, c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if limit <= 0 || limit > maxPageSize {
+		limit = maxPageSize
+	}
+	var orders []Order
+	// ok: go-pagination-limit-unbounded
+	db.Limit(limit).Find(&orders)
+}
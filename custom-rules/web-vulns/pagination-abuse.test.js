@@ -0,0 +1,52 @@
+// Test cases for pagination-abuse rules (JavaScript)
+const express = require("express");
+
+const app = express();
+const MAX_PAGE_SIZE = 100;
+
+// =============================================================================
+// TRUE POSITIVES
+// =============================================================================
+
+app.get("/api/orders", async (req, res) => {
+  const limit = parseInt(req.query.limit, 10) || 20;
+  // ruleid: js-pagination-limit-unbounded
+  const orders = await Order.find({ userId: req.user.id }).limit(limit);
+  res.json(orders);
+});
+
+app.get("/api/users", async (req, res) => {
+  // ruleid: js-pagination-limit-unbounded
+  const users = await prisma.user.findMany({ take: Number(req.query.pageSize), orderBy: { id: "asc" } });
+  res.json(users);
+});
+
+const resolvers = {
+  Query: {
+    posts: (parent, args, context) => {
+      // ruleid: js-pagination-limit-unbounded
+      return context.db.post.findMany({ take: args.first, skip: 0 });
+    },
+  },
+};
+
+// =============================================================================
+// TRUE NEGATIVES - Should NOT be detected
+// =============================================================================
+
+app.get("/api/orders/clamped", async (req, res) => {
+  const limit = Math.min(parseInt(req.query.limit, 10) || 20, MAX_PAGE_SIZE);
+  // ok: js-pagination-limit-unbounded
+  const orders = await Order.find({ userId: req.user.id }).limit(limit);
+  res.json(orders);
+});
+
+app.get("/api/orders/checked", async (req, res) => {
+  const limit = parseInt(req.query.limit, 10) || 20;
+  if (limit > MAX_PAGE_SIZE) {
+    return res.status(400).json({ error: "limit too large" });
+  }
+  // ok: js-pagination-limit-unbounded
+  const orders = await Order.find({ userId: req.user.id }).limit(limit);
+  res.json(orders);
+});
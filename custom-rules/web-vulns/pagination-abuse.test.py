@@ -0,0 +1,57 @@
+# Test cases for pagination-abuse rules (Python)
+import graphene
+from django.core.paginator import Paginator
+from flask import request
+
+from .models import Order, User, db
+
+MAX_PAGE_SIZE = 100
+
+# =============================================================================
+# TRUE POSITIVES
+# =============================================================================
+
+
+def list_orders():
+    limit = int(request.args.get("limit", 20))
+    # ruleid: python-pagination-limit-unbounded
+    return Order.query.order_by(Order.id).limit(limit).all()
+
+
+def list_users(request):
+    page_size = int(request.GET.get("page_size", 25))
+    # ruleid: python-pagination-limit-unbounded
+    paginator = Paginator(User.objects.all(), page_size)
+    return paginator.page(request.GET.get("page", 1))
+
+
+class Query(graphene.ObjectType):
+    users = graphene.List(graphene.String, first=graphene.Int())
+
+    def resolve_users(self, info, first=10):
+        # ruleid: python-pagination-limit-unbounded
+        return User.objects.all()[0:first]
+
+
+# =============================================================================
+# TRUE NEGATIVES - Should NOT be detected
+# =============================================================================
+
+
+def list_orders_clamped():
+    limit = min(int(request.args.get("limit", 20)), MAX_PAGE_SIZE)
+    # ok: python-pagination-limit-unbounded
+    return Order.query.order_by(Order.id).limit(limit).all()
+
+
+def list_orders_checked():
+    limit = int(request.args.get("limit", 20))
+    if limit > MAX_PAGE_SIZE:
+        return {"error": "limit too large"}, 400
+    # ok: python-pagination-limit-unbounded
+    return Order.query.order_by(Order.id).limit(limit).all()
+
+
+def list_orders_fixed():
+    # ok: python-pagination-limit-unbounded
+    return db.session.query(Order).limit(MAX_PAGE_SIZE).all()
@@ -0,0 +1,77 @@
+<?php
+// Test cases for php-injection rules
+
+// =============================================================================
+// TRUE POSITIVES - Should be detected
+// =============================================================================
+
+function page_vulnerable() {
+    $page = $_GET['page'];
+    // ruleid: php-include-injection
+    include $page . '.php';
+}
+
+function lang_vulnerable() {
+    $lang = $_COOKIE['lang'];
+    // ruleid: php-include-injection
+    require_once "lang/" . $lang . "/messages.php";
+}
+
+function user_vulnerable($mysqli) {
+    $id = $_GET['id'];
+    // ruleid: php-sql-concatenation
+    return mysqli_query($mysqli, "SELECT * FROM users WHERE id = " . $id);
+}
+
+function search_vulnerable($pdo) {
+    $term = $_POST['q'];
+    // ruleid: php-sql-concatenation
+    return $pdo->query("SELECT * FROM posts WHERE title LIKE '%$term%'");
+}
+
+function wp_vulnerable() {
+    global $wpdb;
+    $slug = $_REQUEST['slug'];
+    // ruleid: php-sql-concatenation
+    return $wpdb->get_row("SELECT * FROM {$wpdb->posts} WHERE post_name = '$slug'");
+}
+
+function cache_vulnerable($cache) {
+    $blob = $cache->get('session');
+    // ruleid: php-unserialize-no-allowed-classes-audit
+    return unserialize($blob);
+}
+
+// =============================================================================
+// TRUE NEGATIVES - Should NOT be detected
+// =============================================================================
+
+function page_safe() {
+    $page = $_GET['page'];
+    if (in_array($page, ['home', 'about'], true)) {
+        // ok: php-include-injection
+        include $page . '.php';
+    }
+}
+
+function lang_safe() {
+    // ok: php-include-injection
+    require_once "lang/" . basename($_COOKIE['lang']) . "/messages.php";
+}
+
+function user_safe($mysqli) {
+    $id = intval($_GET['id']);
+    // ok: php-sql-concatenation
+    return mysqli_query($mysqli, "SELECT * FROM users WHERE id = " . $id);
+}
+
+function wp_safe() {
+    global $wpdb;
+    // ok: php-sql-concatenation
+    return $wpdb->get_row($wpdb->prepare("SELECT * FROM {$wpdb->posts} WHERE post_name = %s", $_REQUEST['slug']));
+}
+
+function cache_safe($cache) {
+    // ok: php-unserialize-no-allowed-classes-audit
+    return unserialize($cache->get('session'), ['allowed_classes' => false]);
+}
@@ -0,0 +1,84 @@
+// Test cases for prototype-pollution rules (JavaScript)
+const _ = require("lodash");
+const deepmerge = require("deepmerge");
+const express = require("express");
+
+const app = express();
+const defaults = { theme: "light", notifications: { email: true } };
+
+// =============================================================================
+// TRUE POSITIVES - merge / set helpers
+// =============================================================================
+
+app.put("/api/settings", (req, res) => {
+  // ruleid: js-prototype-pollution-merge-user-input
+  const settings = _.merge({}, defaults, req.body);
+  res.json(settings);
+});
+
+app.post("/api/profile", (req, res) => {
+  // ruleid: js-prototype-pollution-merge-user-input
+  const profile = deepmerge(req.user.profile, req.body.profile);
+  res.json(profile);
+});
+
+app.patch("/api/config", (req, res) => {
+  // ruleid: js-prototype-pollution-merge-user-input
+  _.set(config, req.body.path, req.body.value);
+  res.sendStatus(204);
+});
+
+// =============================================================================
+// TRUE POSITIVES - nested dynamic keys
+// =============================================================================
+
+app.post("/api/prefs", (req, res) => {
+  const { section, key, value } = req.body;
+  // ruleid: js-prototype-pollution-dynamic-key
+  prefs[section][key] = value;
+  res.sendStatus(204);
+});
+
+// =============================================================================
+// TRUE POSITIVES - hand-written recursive merge
+// =============================================================================
+
+// ruleid: js-recursive-merge-missing-proto-guard
+function extend(target, source) {
+  for (const key in source) {
+    if (typeof source[key] === "object") {
+      target[key] = extend(target[key] || {}, source[key]);
+    } else {
+      target[key] = source[key];
+    }
+  }
+  return target;
+}
+
+// =============================================================================
+// TRUE NEGATIVES - Should NOT be detected
+// =============================================================================
+
+app.put("/api/settings/validated", (req, res) => {
+  const body = settingsSchema.parse(req.body);
+  // ok: js-prototype-pollution-merge-user-input
+  const settings = _.merge({}, defaults, body);
+  res.json(settings);
+});
+
+app.put("/api/settings/static", (req, res) => {
+  // ok: js-prototype-pollution-merge-user-input
+  const settings = _.merge({}, defaults, { theme: "dark" });
+  res.json(settings);
+});
+
+// ok: js-recursive-merge-missing-proto-guard
+function safeExtend(target, source) {
+  for (const key in source) {
+    if (key === "__proto__" || key === "constructor" || !Object.prototype.hasOwnProperty.call(source, key)) {
+      continue;
+    }
+    target[key] = typeof source[key] === "object" ? safeExtend(target[key] || {}, source[key]) : source[key];
+  }
+  return target;
+}
@@ -0,0 +1,88 @@
+// Test cases for race-condition-audit rules
+package main
+
+import (
+	"database/sql"
+	"net/http"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+var db *sql.DB
+
+var redeemCount = map[string]int{}
+var attemptsMu sync.Mutex
+var loginAttempts = map[string]int{}
+
+// =============================================================================
+// TRUE POSITIVES
+// =============================================================================
+
+func withdraw(w http.ResponseWriter, r *http.Request) {
+	var balance int
+	err := db.QueryRow("SELECT balance FROM accounts WHERE id = $1", r.FormValue("id")).Scan(&balance)
+	if err != nil || balance < 100 {
+		return
+	}
+	// ruleid: go-sql-read-modify-write-no-transaction
+	db.Exec("UPDATE accounts SET balance = $1 WHERE id = $2", balance-100, r.FormValue("id"))
+}
+
+func redeemCoupon(c *gin.Context) {
+	var uses int
+	if err := db.QueryRow("SELECT uses FROM coupons WHERE code = ?", c.Param("code")).Scan(&uses); err != nil {
+		return
+	}
+	if uses >= 1 {
+		return
+	}
+	// ruleid: go-sql-read-modify-write-no-transaction
+	db.Exec("UPDATE coupons SET uses = ? WHERE code = ?", uses+1, c.Param("code"))
+}
+
+func redeemInMemory(w http.ResponseWriter, r *http.Request) {
+	code := r.FormValue("code")
+	if redeemCount[code] > 0 {
+		return
+	}
+	// ruleid: go-handler-map-read-modify-write-no-lock
+	redeemCount[code]++
+}
+
+// =============================================================================
+// TRUE NEGATIVES - Should NOT be detected
+// =============================================================================
+
+func withdrawAtomic(w http.ResponseWriter, r *http.Request) {
+	var balance int
+	err := db.QueryRow("SELECT balance FROM accounts WHERE id = $1", r.FormValue("id")).Scan(&balance)
+	if err != nil {
+		return
+	}
+	// ok: go-sql-read-modify-write-no-transaction
+	db.Exec("UPDATE accounts SET balance = balance - $1 WHERE id = $2 AND balance >= $1", 100, r.FormValue("id"))
+}
+
+func withdrawLocked(w http.ResponseWriter, r *http.Request) {
+	tx, err := db.Begin()
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+	var balance int
+	err = tx.QueryRow("SELECT balance FROM accounts WHERE id = $1 FOR UPDATE", r.FormValue("id")).Scan(&balance)
+	if err != nil {
+		return
+	}
+	// ok: go-sql-read-modify-write-no-transaction
+	tx.Exec("UPDATE accounts SET balance = $1 WHERE id = $2", balance-100, r.FormValue("id"))
+	tx.Commit()
+}
+
+func countLogin(w http.ResponseWriter, r *http.Request) {
+	attemptsMu.Lock()
+	defer attemptsMu.Unlock()
+	// ok: go-handler-map-read-modify-write-no-lock
+	loginAttempts[r.FormValue("user")]++
+}
@@ -0,0 +1,51 @@
+// Test cases for race-condition-audit rules
+
+// =============================================================================
+// TRUE POSITIVES - Should be detected
+// =============================================================================
+
+app.post('/withdraw', async (req, res) => {
+    const user = await User.findById(req.user.id);
+    if (user.balance < req.body.amount) {
+        return res.status(400).end();
+    }
+    // ruleid: js-orm-read-modify-write-no-lock
+    user.balance -= req.body.amount;
+    await user.save();
+});
+
+app.post('/gift-cards/:code/redeem', async (req, res) => {
+    const card = await GiftCard.findOne({ code: req.params.code });
+    // ruleid: js-orm-read-modify-write-no-lock
+    card.remaining = card.remaining - req.body.amount;
+    await card.save();
+});
+
+// =============================================================================
+// TRUE NEGATIVES - Should NOT be detected
+// =============================================================================
+
+app.post('/withdraw-atomic', async (req, res) => {
+    // ok: js-orm-read-modify-write-no-lock
+    const result = await User.updateOne(
+        { _id: req.user.id, balance: { $gte: req.body.amount } },
+        { $inc: { balance: -req.body.amount } }
+    );
+    res.json({ ok: result.modifiedCount === 1 });
+});
+
+app.post('/withdraw-locked', async (req, res) => {
+    await sequelize.transaction(async (t) => {
+        const account = await Account.findByPk(req.user.id, { lock: t.LOCK.UPDATE, transaction: t });
+        // ok: js-orm-read-modify-write-no-lock
+        account.balance -= req.body.amount;
+        await account.save({ transaction: t });
+    });
+});
+
+app.post('/profile', async (req, res) => {
+    const user = await User.findById(req.user.id);
+    // ok: js-orm-read-modify-write-no-lock
+    user.displayName = req.body.name;
+    await user.save();
+});
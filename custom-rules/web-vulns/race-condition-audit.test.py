@@ -0,0 +1,52 @@
+# Test cases for race-condition-audit rules
+from django.db import transaction
+from django.db.models import F
+
+
+# =============================================================================
+# TRUE POSITIVES - Should be detected
+# =============================================================================
+
+def transfer(request):
+    account = Account.objects.get(user=request.user)
+    amount = int(request.POST["amount"])
+    if account.balance < amount:
+        return error("insufficient funds")
+    # ruleid: python-orm-read-modify-write-no-lock
+    account.balance -= amount
+    account.save()
+
+
+def redeem(request, code):
+    with transaction.atomic():
+        coupon = Coupon.objects.filter(code=code).first()
+        if coupon.uses >= coupon.max_uses:
+            return error("used")
+        # ruleid: python-orm-read-modify-write-no-lock
+        coupon.uses = coupon.uses + 1
+        coupon.save()
+
+
+# =============================================================================
+# TRUE NEGATIVES - Should NOT be detected
+# =============================================================================
+
+def transfer_locked(request):
+    with transaction.atomic():
+        account = Account.objects.select_for_update().get(user=request.user)
+        # ok: python-orm-read-modify-write-no-lock
+        account.balance -= int(request.POST["amount"])
+        account.save()
+
+
+def transfer_f_expression(request):
+    amount = int(request.POST["amount"])
+    # ok: python-orm-read-modify-write-no-lock
+    Account.objects.filter(user=request.user, balance__gte=amount).update(balance=F("balance") - amount)
+
+
+def rename(request):
+    profile = Profile.objects.get(user=request.user)
+    # ok: python-orm-read-modify-write-no-lock
+    profile.display_name = request.POST["name"]
+    profile.save()
@@ -0,0 +1,71 @@
+// Test cases for rate-limit-audit rules (Go routers)
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/httprate"
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+// =============================================================================
+// TRUE POSITIVES - no limiter in the middleware chain
+// =============================================================================
+
+func routesStdlib(mux *http.ServeMux) {
+	// ruleid: go-auth-route-no-rate-limit
+	mux.HandleFunc("/login", loginHandler)
+	// ruleid: go-auth-route-no-rate-limit
+	mux.Handle("/password/reset", requireCSRF(http.HandlerFunc(resetHandler)))
+}
+
+func routesGin(r *gin.Engine) {
+	auth := r.Group("/auth")
+	auth.Use(gin.Logger())
+	// ruleid: go-auth-route-no-rate-limit
+	auth.POST("/otp/verify", verifyOTP)
+}
+
+func routesChi(r chi.Router) {
+	// ruleid: go-auth-route-no-rate-limit
+	r.Post("/signup", signupHandler)
+}
+
+// =============================================================================
+// TRUE NEGATIVES - Should NOT be detected
+// =============================================================================
+
+func routesChiLimited(r chi.Router) {
+	// ok: go-auth-route-no-rate-limit
+	r.With(httprate.LimitByIP(5, time.Minute)).Post("/login", loginHandler)
+	// ok: go-auth-route-no-rate-limit
+	r.Post("/comments", commentHandler)
+}
+
+func routesEcho(e *echo.Echo) {
+	e.Use(middleware.RateLimiter(middleware.NewRateLimiterMemoryStore(5)))
+	// ok: go-auth-route-no-rate-limit
+	e.POST("/login", echoLogin)
+}
+
+func routesGinLimited(r *gin.Engine) {
+	// ok: go-auth-route-no-rate-limit
+	r.POST("/forgot-password", loginLimiter(), forgotPassword)
+	// ok: go-auth-route-no-rate-limit
+	r.GET("/login", loginPage)
+}
+
+func loginHandler(w http.ResponseWriter, r *http.Request)   {}
+func resetHandler(w http.ResponseWriter, r *http.Request)   {}
+func signupHandler(w http.ResponseWriter, r *http.Request)  {}
+func commentHandler(w http.ResponseWriter, r *http.Request) {}
+func requireCSRF(h http.Handler) http.Handler               { return h }
+func verifyOTP(c *gin.Context)                              {}
+func forgotPassword(c *gin.Context)                         {}
+func loginPage(c *gin.Context)                              {}
+func loginLimiter() gin.HandlerFunc                         { return func(c *gin.Context) {} }
+func echoLogin(c echo.Context) error                        { return nil }
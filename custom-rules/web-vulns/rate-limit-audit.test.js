@@ -0,0 +1,52 @@
+// Test cases for rate-limit-audit rules (Express)
+const express = require('express');
+const rateLimit = require('express-rate-limit');
+
+const app = express();
+const router = express.Router();
+
+// =============================================================================
+// TRUE POSITIVES - no limiter in the middleware chain
+// =============================================================================
+
+// ruleid: express-auth-route-no-rate-limit
+app.post('/api/login', async (req, res) => {
+    const user = await checkPassword(req.body.email, req.body.password);
+    res.json({ ok: Boolean(user) });
+});
+
+// ruleid: express-auth-route-no-rate-limit
+router.post('/verify-otp', requireSession, async (req, res) => {
+    res.json({ ok: await verifyCode(req.session.userId, req.body.code) });
+});
+
+// ruleid: express-auth-route-no-rate-limit
+app.put('/password/reset', async (req, res) => {
+    await resetPassword(req.body.token, req.body.password);
+    res.sendStatus(204);
+});
+
+// =============================================================================
+// TRUE NEGATIVES - Should NOT be detected
+// =============================================================================
+
+// ok: express-auth-route-no-rate-limit
+app.get('/login', (req, res) => res.render('login'));
+
+// ok: express-auth-route-no-rate-limit
+app.post('/api/comments', async (req, res) => res.json(await addComment(req.body)));
+
+// ok: express-auth-route-no-rate-limit
+router.post('/forgot-password', resetLimiter, async (req, res) => {
+    await sendResetEmail(req.body.email);
+    res.sendStatus(202);
+});
+
+// Limiter created at module scope: later routes in this file are covered
+const loginLimiter = rateLimit({ windowMs: 15 * 60 * 1000, max: 5 });
+app.use('/api/auth', loginLimiter);
+
+// ok: express-auth-route-no-rate-limit
+app.post('/api/auth/signin', async (req, res) => {
+    res.json({ ok: Boolean(await checkPassword(req.body.email, req.body.password)) });
+});
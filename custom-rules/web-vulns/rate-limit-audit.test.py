@@ -0,0 +1,53 @@
+# Test cases for rate-limit-audit rules (Flask / FastAPI)
+from fastapi import Depends, FastAPI
+from flask import Flask, request
+from flask_limiter import Limiter
+from flask_limiter.util import get_remote_address
+
+app = Flask(__name__)
+api = FastAPI()
+limiter = Limiter(get_remote_address, app=app)
+
+# =============================================================================
+# TRUE POSITIVES - no limiter decorator
+# =============================================================================
+
+# ruleid: python-auth-route-no-rate-limit
+@app.route("/login", methods=["GET", "POST"])
+def login():
+    return check_password(request.form["username"], request.form["password"])
+
+
+# ruleid: python-auth-route-no-rate-limit
+@app.post("/account/2fa/verify")
+def verify_2fa():
+    return verify_code(request.json["code"])
+
+
+# ruleid: python-auth-route-no-rate-limit
+@api.post("/auth/reset-password")
+async def reset_password(body: dict):
+    return await reset(body["token"], body["password"])
+
+
+# =============================================================================
+# TRUE NEGATIVES - Should NOT be detected
+# =============================================================================
+
+# ok: python-auth-route-no-rate-limit
+@app.route("/login", methods=["GET"])
+def login_form():
+    return render_login()
+
+
+# ok: python-auth-route-no-rate-limit
+@app.route("/signin", methods=["POST"])
+@limiter.limit("5 per minute")
+def signin():
+    return check_password(request.form["username"], request.form["password"])
+
+
+# ok: python-auth-route-no-rate-limit
+@app.post("/comments")
+def add_comment():
+    return save_comment(request.json)
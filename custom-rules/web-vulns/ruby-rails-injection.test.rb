@@ -0,0 +1,74 @@
+# Test cases for ruby-rails-injection rules
+
+# =============================================================================
+# TRUE POSITIVES - Should be detected
+# =============================================================================
+
+class UsersController < ApplicationController
+  def create
+    # ruleid: ruby-rails-mass-assignment
+    @user = User.new(params[:user])
+    @user.save
+  end
+
+  def update
+    user = User.find(params[:id])
+    # ruleid: ruby-rails-mass-assignment
+    user.update(params.require(:user).permit!)
+  end
+
+  def bulk
+    attrs = params.to_unsafe_h
+    # ruleid: ruby-rails-mass-assignment
+    Account.create!(attrs)
+  end
+
+  def sort
+    # ruleid: ruby-send-user-input
+    @users = User.send(params[:scope])
+  end
+
+  def export
+    # ruleid: ruby-send-user-input
+    klass = params[:type].constantize
+    klass.all
+  end
+
+  def preview
+    # ruleid: ruby-rails-render-inline-injection
+    render inline: params[:template]
+  end
+
+  def greeting
+    name = params[:name]
+    # ruleid: ruby-rails-html-safe-user-input
+    render html: "<p>Hello #{name}</p>".html_safe
+  end
+
+# =============================================================================
+# TRUE NEGATIVES - Should NOT be detected
+# =============================================================================
+
+  def create_safe
+    # ok: ruby-rails-mass-assignment
+    @user = User.new(params.require(:user).permit(:name, :email))
+  end
+
+  def sort_safe
+    scope = params[:scope]
+    if %w[active archived].include?(scope)
+      # ok: ruby-send-user-input
+      @users = User.public_send(scope)
+    end
+  end
+
+  def preview_safe
+    # ok: ruby-rails-render-inline-injection
+    render inline: "<p><%= name %></p>", locals: { name: params[:name] }
+  end
+
+  def greeting_safe
+    # ok: ruby-rails-html-safe-user-input
+    render html: h(params[:name])
+  end
+end
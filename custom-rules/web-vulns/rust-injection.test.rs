@@ -0,0 +1,105 @@
+// Test cases for rust-injection rules
+
+use std::fs;
+use std::fs::File;
+use std::path::{Path as FsPath, PathBuf};
+use std::process::Command;
+
+use actix_web::{web, HttpRequest, HttpResponse};
+use axum::extract::{Json, Multipart, Path, Query};
+
+// =============================================================================
+// TRUE POSITIVES - Should be detected
+// =============================================================================
+
+async fn ping(query: web::Query<PingParams>) -> HttpResponse {
+    let host = query.into_inner().host;
+    let output = Command::new("sh")
+        .arg("-c")
+        // ruleid: rust-command-shell-injection
+        .arg(format!("ping -c 1 {}", host))
+        .output()
+        .unwrap();
+    HttpResponse::Ok().body(output.stdout)
+}
+
+async fn convert(Query(params): Query<ConvertParams>) -> String {
+    // ruleid: rust-command-shell-injection
+    let out = Command::new("bash").args(["-c", &format!("convert {} out.png", params.file)]).output();
+    String::new()
+}
+
+async fn upload(Path(name): Path<String>, body: String) -> &'static str {
+    let dest = FsPath::new("/var/uploads").join(&name);
+    // ruleid: rust-path-join-write
+    fs::write(dest, body).unwrap();
+    "ok"
+}
+
+async fn save(req: HttpRequest, body: web::Bytes) -> HttpResponse {
+    let name = req.match_info().get("name").unwrap();
+    let mut path = PathBuf::from("/srv/data");
+    path.push(name);
+    // ruleid: rust-path-join-write
+    File::create(path).unwrap();
+    HttpResponse::Ok().finish()
+}
+
+async fn upload_file(mut multipart: Multipart) -> &'static str {
+    while let Some(field) = multipart.next_field().await.unwrap() {
+        let name = field.file_name().unwrap().to_string();
+        let data = field.bytes().await.unwrap();
+        // ruleid: rust-path-join-write
+        fs::write(FsPath::new("/var/uploads").join(&name), data).unwrap();
+    }
+    "ok"
+}
+
+async fn record(body: web::Bytes) -> HttpResponse {
+    let len = u32::from_be_bytes([body[0], body[1], body[2], body[3]]) as usize;
+    let data = unsafe {
+        // ruleid: rust-unsafe-untrusted-input-audit
+        std::slice::from_raw_parts(body.as_ptr().add(4), len)
+    };
+    HttpResponse::Ok().body(data.to_vec())
+}
+
+// =============================================================================
+// TRUE NEGATIVES - Should NOT be detected
+// =============================================================================
+
+async fn ping_safe(query: web::Query<PingParams>) -> HttpResponse {
+    let host = query.into_inner().host;
+    // ok: rust-command-shell-injection
+    let output = Command::new("ping").arg("-c").arg("1").arg(&host).output().unwrap();
+    HttpResponse::Ok().body(output.stdout)
+}
+
+async fn upload_safe(Path(name): Path<String>, body: String) -> &'static str {
+    let base = FsPath::new("/var/uploads");
+    let dest = base.join(std::path::Path::new(&name).file_name().unwrap());
+    // ok: rust-path-join-write
+    fs::write(dest, body).unwrap();
+    "ok"
+}
+
+async fn upload_file_safe(mut multipart: Multipart) -> &'static str {
+    while let Some(field) = multipart.next_field().await.unwrap() {
+        let name = field.file_name().unwrap().to_string();
+        let data = field.bytes().await.unwrap();
+        let dest = FsPath::new("/var/uploads").join(std::path::Path::new(&name).file_name().unwrap());
+        // ok: rust-path-join-write
+        fs::write(dest, data).unwrap();
+    }
+    "ok"
+}
+
+async fn record_safe(body: web::Bytes) -> HttpResponse {
+    let len = u32::from_be_bytes([body[0], body[1], body[2], body[3]]) as usize;
+    let len = len.min(body.len() - 4);
+    let data = unsafe {
+        // ok: rust-unsafe-untrusted-input-audit
+        std::slice::from_raw_parts(body.as_ptr().add(4), len)
+    };
+    HttpResponse::Ok().body(data.to_vec())
+}
@@ -0,0 +1,3 @@
+module example.com/ssrf-fixture
+
+go 1.22
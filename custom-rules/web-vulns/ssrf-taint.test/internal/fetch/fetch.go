@@ -0,0 +1,29 @@
+// Package fetch is the sink side of the fixture: the URL reaches http.Get
+// here, one package away from the request it came from.
+package fetch
+
+import (
+	"io"
+	"net/http"
+)
+
+// Remote fetches whatever URL it is given.
+func Remote(target string) ([]byte, error) {
+	// ruleid: go-ssrf-http
+	resp, err := http.Get(target)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return io.ReadAll(resp.Body)
+}
+
+// Status fetches a fixed health endpoint.
+func Status() error {
+	// ok: go-ssrf-http
+	resp, err := http.Get("https://status.example.com/health")
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
@@ -0,0 +1,27 @@
+// Package handler is the source side of the fixture: the URL comes from the
+// query string and is handed to another package.
+package handler
+
+import (
+	"net/http"
+
+	"example.com/ssrf-fixture/internal/fetch"
+)
+
+// Proxy fetches the URL named by ?url= on the caller's behalf.
+func Proxy(w http.ResponseWriter, r *http.Request) {
+	target := r.URL.Query().Get("url")
+	body, err := fetch.Remote(target)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	w.Write(body)
+}
+
+// Health reports the upstream status without taking any input.
+func Health(w http.ResponseWriter, r *http.Request) {
+	if err := fetch.Status(); err != nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+}
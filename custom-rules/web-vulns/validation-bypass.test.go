@@ -0,0 +1,92 @@
+// Test cases for validation-bypass rules (Go)
+package main
+
+import (
+	"net/mail"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// =============================================================================
+// TRUE POSITIVES - email checks
+// =============================================================================
+
+func canResetPassword(email string) bool {
+	// ruleid: go-email-validation-bypass
+	return strings.HasSuffix(email, "example.com")
+}
+
+func isCorporateSSO(userEmail string) bool {
+	// ruleid: go-email-validation-bypass
+	return strings.Contains(strings.ToLower(userEmail), "@example.com")
+}
+
+func looksLikeEmail(email string) bool {
+	// ruleid: go-email-validation-bypass
+	return strings.Contains(email, "@")
+}
+
+func ssoDomain(email string) string {
+	// ruleid: go-email-validation-bypass
+	return strings.Split(email, "@")[1]
+}
+
+func validEmail(email string) bool {
+	// ruleid: go-email-validation-bypass
+	ok, _ := regexp.MatchString(`[a-z0-9.]+@[a-z0-9.]+`, email)
+	return ok
+}
+
+// =============================================================================
+// TRUE POSITIVES - URL allowlists
+// =============================================================================
+
+func safeRedirect(redirectURL string) bool {
+	// ruleid: go-url-allowlist-string-match
+	return strings.HasPrefix(redirectURL, "https://app.example.com")
+}
+
+func allowedCallback(callback string) bool {
+	// ruleid: go-url-allowlist-string-match
+	return strings.Contains(callback, "example.com")
+}
+
+func allowedOrigin(u *url.URL) bool {
+	// ruleid: go-url-allowlist-string-match
+	return strings.HasSuffix(u.Host, "example.com")
+}
+
+// =============================================================================
+// TRUE NEGATIVES - Should NOT be detected
+// =============================================================================
+
+func canResetPasswordParsed(email string) bool {
+	addr, err := mail.ParseAddress(email)
+	if err != nil {
+		return false
+	}
+	domain := addr.Address[strings.LastIndex(addr.Address, "@")+1:]
+	// ok: go-email-validation-bypass
+	return strings.HasSuffix(addr.Address, "@example.com") && domain == "example.com"
+}
+
+func validEmailAnchored(email string) bool {
+	// ok: go-email-validation-bypass
+	ok, _ := regexp.MatchString(`^[a-z0-9.]+@[a-z0-9.]+$`, email)
+	return ok
+}
+
+func safeRedirectParsed(redirectURL string) bool {
+	u, err := url.Parse(redirectURL)
+	if err != nil {
+		return false
+	}
+	// ok: go-url-allowlist-string-match
+	return u.Hostname() == "app.example.com" || strings.HasSuffix(u.Hostname(), ".example.com")
+}
+
+func safeRedirectPath(redirectURL string) bool {
+	// ok: go-url-allowlist-string-match
+	return strings.HasPrefix(redirectURL, "https://app.example.com/")
+}
@@ -0,0 +1,53 @@
+// Test cases for validation-bypass rules (JavaScript)
+
+// =============================================================================
+// TRUE POSITIVES - email checks
+// =============================================================================
+
+function canResetPassword(email) {
+    // ruleid: js-email-validation-bypass
+    return email.endsWith('example.com');
+}
+
+function isCorporate(userEmail) {
+    // ruleid: js-email-validation-bypass
+    return userEmail.toLowerCase().includes('@example.com');
+}
+
+function ssoDomain(email) {
+    // ruleid: js-email-validation-bypass
+    return email.split('@')[1];
+}
+
+// =============================================================================
+// TRUE POSITIVES - URL allowlists
+// =============================================================================
+
+function safeRedirect(returnUrl) {
+    // ruleid: js-url-allowlist-string-match
+    return returnUrl.startsWith('https://app.example.com');
+}
+
+function trustedOrigin(origin) {
+    // ruleid: js-url-allowlist-string-match
+    return origin.indexOf('example.com') !== -1;
+}
+
+// =============================================================================
+// TRUE NEGATIVES - Should NOT be detected
+// =============================================================================
+
+function canResetPasswordParsed(email) {
+    // ok: js-email-validation-bypass
+    return email.slice(email.lastIndexOf('@') + 1).toLowerCase() === 'example.com';
+}
+
+function safeRedirectParsed(returnUrl) {
+    // ok: js-url-allowlist-string-match
+    return new URL(returnUrl, 'https://app.example.com').hostname === 'app.example.com';
+}
+
+function trustedOriginSuffix(originHost) {
+    // ok: js-url-allowlist-string-match
+    return originHost.endsWith('.example.com');
+}
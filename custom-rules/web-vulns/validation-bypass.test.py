@@ -0,0 +1,69 @@
+# Test cases for validation-bypass rules (Python)
+import re
+from email.utils import parseaddr
+from urllib.parse import urlparse
+
+# =============================================================================
+# TRUE POSITIVES - email checks
+# =============================================================================
+
+
+def can_reset_password(email):
+    # ruleid: python-email-validation-bypass
+    return email.lower().endswith("example.com")
+
+
+def is_corporate(email):
+    # ruleid: python-email-validation-bypass
+    return "@example.com" in email
+
+
+def sso_domain(email):
+    # ruleid: python-email-validation-bypass
+    return email.split("@")[1]
+
+
+def valid_email(email):
+    # ruleid: python-email-validation-bypass
+    return re.match(r"[^@]+@[^@]+\.[a-z]+", email)
+
+
+# =============================================================================
+# TRUE POSITIVES - URL allowlists
+# =============================================================================
+
+
+def safe_next(next_url):
+    # ruleid: python-url-allowlist-string-match
+    return next_url.startswith("https://app.example.com")
+
+
+def allowed_redirect(redirect_uri):
+    # ruleid: python-url-allowlist-string-match
+    return "example.com" in redirect_uri
+
+
+# =============================================================================
+# TRUE NEGATIVES - Should NOT be detected
+# =============================================================================
+
+
+def can_reset_password_parsed(email):
+    _, address = parseaddr(email)
+    # ok: python-email-validation-bypass
+    return address.rsplit("@", 1)[-1].lower() == "example.com"
+
+
+def valid_email_anchored(email):
+    # ok: python-email-validation-bypass
+    return re.match(r"[^@]+@[^@]+\.[a-z]+$", email)
+
+
+def safe_next_parsed(next_url):
+    # ok: python-url-allowlist-string-match
+    return urlparse(next_url).hostname == "app.example.com"
+
+
+def safe_next_path(next_url):
+    # ok: python-url-allowlist-string-match
+    return next_url.startswith("https://app.example.com/")
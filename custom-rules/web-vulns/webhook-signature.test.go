@@ -0,0 +1,72 @@
+// Test cases for webhook-signature rules (Go)
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stripe/stripe-go/v76/webhook"
+)
+
+// =============================================================================
+// TRUE POSITIVES
+// =============================================================================
+
+func stripeWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	var evt StripeEvent
+	json.NewDecoder(r.Body).Decode(&evt)
+	if evt.Type == "checkout.session.completed" {
+		// ruleid: go-webhook-signature-not-verified
+		fulfillOrder(evt.Data.Object.ID)
+	}
+}
+
+func githubHookHandler(c *gin.Context) {
+	body, _ := c.GetRawData()
+	var push PushEvent
+	json.Unmarshal(body, &push)
+	// ruleid: go-webhook-signature-not-verified
+	db.Exec("UPDATE repos SET head = ? WHERE name = ?", push.After, push.Repository.FullName)
+}
+
+// =============================================================================
+// TRUE NEGATIVES - Should NOT be detected
+// =============================================================================
+
+func stripeWebhookVerified(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	evt, err := webhook.ConstructEvent(body, r.Header.Get("Stripe-Signature"), secret)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	// ok: go-webhook-signature-not-verified
+	fulfillOrder(evt.ID)
+}
+
+func githubHookVerified(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	expected := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(r.Header.Get("X-Hub-Signature-256"))) {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	var push PushEvent
+	json.Unmarshal(body, &push)
+	// ok: go-webhook-signature-not-verified
+	db.Exec("UPDATE repos SET head = ? WHERE name = ?", push.After, push.Repository.FullName)
+}
+
+func updateProfile(w http.ResponseWriter, r *http.Request) {
+	var p Profile
+	json.NewDecoder(r.Body).Decode(&p)
+	// ok: go-webhook-signature-not-verified
+	db.Save(&p)
+}
@@ -0,0 +1,55 @@
+// Test cases for webhook-signature rules (JavaScript)
+const crypto = require("crypto");
+const express = require("express");
+const Stripe = require("stripe");
+
+const app = express();
+const stripe = Stripe(process.env.STRIPE_KEY);
+
+// =============================================================================
+// TRUE POSITIVES
+// =============================================================================
+
+app.post("/webhooks/stripe", express.json(), async (req, res) => {
+  const event = req.body;
+  if (event.type === "invoice.paid") {
+    // ruleid: express-webhook-signature-not-verified
+    await Invoice.updateOne({ stripeId: event.data.object.id }, { status: "paid" });
+  }
+  res.sendStatus(200);
+});
+
+async function handleGithubWebhook(req, res) {
+  // ruleid: express-webhook-signature-not-verified
+  await deployQueue.add("deploy", { repo: req.body.repository.full_name, sha: req.body.after });
+  res.sendStatus(202);
+}
+
+// =============================================================================
+// TRUE NEGATIVES - Should NOT be detected
+// =============================================================================
+
+app.post("/webhooks/stripe/verified", express.raw({ type: "application/json" }), async (req, res) => {
+  const event = stripe.webhooks.constructEvent(req.body, req.headers["stripe-signature"], process.env.STRIPE_WEBHOOK_SECRET);
+  // ok: express-webhook-signature-not-verified
+  await Invoice.updateOne({ stripeId: event.data.object.id }, { status: "paid" });
+  res.sendStatus(200);
+});
+
+app.post("/webhooks/github/verified", express.raw({ type: "application/json" }), async (req, res) => {
+  const expected = "sha256=" + crypto.createHmac("sha256", process.env.GITHUB_SECRET).update(req.body).digest("hex");
+  const given = req.headers["x-hub-signature-256"] || "";
+  if (given.length !== expected.length || !crypto.timingSafeEqual(Buffer.from(given), Buffer.from(expected))) {
+    return res.sendStatus(401);
+  }
+  const payload = JSON.parse(req.body);
+  // ok: express-webhook-signature-not-verified
+  await deployQueue.add("deploy", { repo: payload.repository.full_name, sha: payload.after });
+  res.sendStatus(202);
+});
+
+app.post("/api/profile", async (req, res) => {
+  // ok: express-webhook-signature-not-verified
+  await User.updateOne({ _id: req.user.id }, { name: req.body.name });
+  res.sendStatus(204);
+});
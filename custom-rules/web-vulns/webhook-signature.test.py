@@ -0,0 +1,70 @@
+# Test cases for webhook-signature rules (Python)
+import hashlib
+import hmac
+
+import stripe
+from flask import Flask, abort, request
+
+from .models import Invoice, Subscription
+from .tasks import deploy_repo
+
+app = Flask(__name__)
+
+# =============================================================================
+# TRUE POSITIVES
+# =============================================================================
+
+
+@app.route("/webhooks/stripe", methods=["POST"])
+def stripe_events():
+    event = request.get_json()
+    if event["type"] == "invoice.paid":
+        # ruleid: python-webhook-signature-not-verified
+        Invoice.objects.filter(stripe_id=event["data"]["object"]["id"]).update(status="paid")
+    return "", 200
+
+
+def github_webhook(request):
+    payload = request.json
+    # ruleid: python-webhook-signature-not-verified
+    deploy_repo.delay(payload["repository"]["full_name"], payload["after"])
+    return "ok"
+
+
+@app.post("/slack/commands")
+def commands():
+    form = request.form
+    # ruleid: python-webhook-signature-not-verified
+    handle_slash_command(form["user_id"], form["text"])
+    return ""
+
+
+# =============================================================================
+# TRUE NEGATIVES - Should NOT be detected
+# =============================================================================
+
+
+@app.route("/webhooks/stripe/verified", methods=["POST"])
+def stripe_events_verified():
+    payload = request.data
+    sig = request.headers.get("Stripe-Signature")
+    event = stripe.Webhook.construct_event(payload, sig, app.config["STRIPE_WEBHOOK_SECRET"])
+    # ok: python-webhook-signature-not-verified
+    Subscription.objects.create(stripe_id=event["data"]["object"]["id"])
+    return "", 200
+
+
+def github_webhook_verified(request):
+    expected = "sha256=" + hmac.new(app.config["GITHUB_SECRET"], request.data, hashlib.sha256).hexdigest()
+    if not hmac.compare_digest(expected, request.headers.get("X-Hub-Signature-256", "")):
+        abort(401)
+    payload = request.json
+    # ok: python-webhook-signature-not-verified
+    deploy_repo.delay(payload["repository"]["full_name"], payload["after"])
+    return "ok"
+
+
+def update_profile(request):
+    data = request.get_json()
+    # ok: python-webhook-signature-not-verified
+    handle_profile_update(data)
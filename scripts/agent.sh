@@ -0,0 +1,329 @@
+#!/usr/bin/env bash
+# Scanner agent: pull targets and schedules from a central server, hunt,
+# and push results back
+#
+# Usage: ./scripts/agent.sh [options]
+#
+# Each poll the agent fetches the central configuration (see
+# scripts/lib/agent-utils.sh for the format) and queues a hunt for every
+# target whose interval has passed, along with the jobs the server lists
+# (PR scans). Workers take jobs from the queue by priority, within the
+# per-tenant limits, and upload each new scan directory (with its
+# provenance.json) to the results endpoint. Rule packs from the config are
+# applied through RULE_PACKS; with "self_update": true the agent installs
+# new signed releases while no job is running.
+#
+# Examples:
+#   ./scripts/agent.sh --once                                   # One poll, then exit (cron)
+#   ./scripts/agent.sh                                          # Run continuously
+#   ./scripts/agent.sh --dry-run --config-url ./agent-config.json  # Show due targets
+
+set -euo pipefail
+
+SCRIPT_DIR="$(cd "$(dirname "${BASH_SOURCE[0]}")" && pwd)"
+source "$SCRIPT_DIR/lib/catalog-utils.sh"
+source "$SCRIPT_DIR/lib/agent-utils.sh"
+
+# Server URLs and token from .env
+if [[ -f "$CATALOG_ROOT/.env" ]]; then
+    set -a
+    # shellcheck disable=SC1091
+    source "$CATALOG_ROOT/.env"
+    set +a
+fi
+
+usage() {
+    cat << EOF
+Usage: $0 [options]
+
+Run as a scanner node: pull targets, rule packs, and schedules from a
+central server, run hunts when they are due, and push results back.
+
+Options:
+    --config-url <url>   Central configuration (HTTP(S) URL or file path)
+                         (default: AGENT_CONFIG_URL from .env)
+    --results-url <url>  Endpoint scan archives are PUT to
+                         (default: AGENT_RESULTS_URL; unset = keep results local)
+    --token <token>      Bearer token for both endpoints (default: AGENT_TOKEN)
+    --node <name>        Node name used in result paths (default: AGENT_NODE_NAME,
+                         else the hostname)
+    --once               Run one poll, work off the queue, and exit
+                         (for cron or systemd timers)
+    --dry-run            Print the targets that are due and the queue, and exit
+    -h, --help           Show this help message
+
+State (last good config, last run per target, the job queue) is kept in
+cache/agent/. A failed job is retried with a backoff; after max_attempts
+it is moved to the dead-letter queue (see ./scripts/jobs.sh).
+EOF
+    exit 1
+}
+
+CONFIG_URL="${AGENT_CONFIG_URL:-}"
+RESULTS_URL="${AGENT_RESULTS_URL:-}"
+TOKEN="${AGENT_TOKEN:-}"
+NODE="${AGENT_NODE_NAME:-$(hostname -s 2>/dev/null || hostname)}"
+RUN_ONCE=false
+DRY_RUN=false
+ORIGINAL_ARGS=("$@")
+
+while [[ $# -gt 0 ]]; do
+    case "$1" in
+        --config-url)
+            CONFIG_URL="$2"
+            shift 2
+            ;;
+        --results-url)
+            RESULTS_URL="$2"
+            shift 2
+            ;;
+        --token)
+            TOKEN="$2"
+            shift 2
+            ;;
+        --node)
+            NODE="$2"
+            shift 2
+            ;;
+        --once)
+            RUN_ONCE=true
+            shift
+            ;;
+        --dry-run)
+            DRY_RUN=true
+            shift
+            ;;
+        -h|--help)
+            usage
+            ;;
+        *)
+            echo "Unknown option: $1"
+            usage
+            ;;
+    esac
+done
+
+if [[ -z "$CONFIG_URL" ]]; then
+    echo "Error: No configuration URL (set AGENT_CONFIG_URL or pass --config-url)"
+    exit 1
+fi
+require_jq || exit 1
+
+# One agent per checkout: two would clone and scan the same repos
+LOCK_DIR="$AGENT_STATE_DIR/lock"
+mkdir -p "$AGENT_STATE_DIR"
+if [[ "$DRY_RUN" != true ]]; then
+    if ! mkdir "$LOCK_DIR" 2>/dev/null; then
+        echo "Error: Another agent is running (remove $LOCK_DIR if it is stale)"
+        exit 1
+    fi
+    # Workers stop with the agent; their jobs are requeued on the next start
+    trap 'kill $(jobs -p) 2>/dev/null || true; rmdir "$LOCK_DIR" 2>/dev/null || true' EXIT
+    trap 'exit 130' INT
+    trap 'exit 143' TERM
+    job_recover
+fi
+
+# Run hunt.sh for one target from the config
+# Args: $1 = target JSON, $2 = default profile
+run_target() {
+    local target="$1"
+    local default_profile="$2"
+    local org platform github_orgs repos profile
+    local args=()
+
+    org=$(jq -r '.org' <<< "$target")
+    platform=$(jq -r '.platform' <<< "$target")
+    github_orgs=$(jq -r '(.github_orgs // []) | join(",")' <<< "$target")
+    repos=$(jq -r '(.repos // []) | join(",")' <<< "$target")
+    profile=$(jq -r --arg d "$default_profile" '.profile // $d' <<< "$target")
+
+    validate_org_name "$org" || return 1
+    args=("$org" "$platform")
+    [[ -n "$github_orgs" ]] && args+=(--github-org "$github_orgs")
+    [[ -n "$repos" ]] && args+=(--repos "$repos")
+    [[ -n "$profile" ]] && args+=(--profile "$profile")
+
+    "$SCRIPT_DIR/hunt.sh" "${args[@]}"
+}
+
+# Review a pull request with pr-review.sh in the agent's checkout of its repo
+# Args: $1 = job JSON
+run_pr_scan() {
+    local job="$1"
+    local gh_repo pr fail_on checkout
+    local args=()
+
+    gh_repo=$(jq -r '.gh_repo // empty' <<< "$job")
+    pr=$(jq -r '.pr // empty' <<< "$job")
+    fail_on=$(jq -r '.fail_on // empty' <<< "$job")
+    if [[ ! "$gh_repo" =~ ^[A-Za-z0-9._-]+/[A-Za-z0-9._-]+$ || ! "$pr" =~ ^[0-9]+$ ]]; then
+        echo "Error: PR job needs gh_repo (owner/repo) and a pr number"
+        return 1
+    fi
+
+    checkout="$CATALOG_ROOT/cache/agent-checkouts/${gh_repo//\//-}"
+    if [[ ! -d "$checkout/.git" ]]; then
+        mkdir -p "$(dirname "$checkout")"
+        gh repo clone "$gh_repo" "$checkout" -- -q || return 1
+    fi
+    (cd "$checkout" && git fetch -q origin && gh pr checkout "$pr" --force > /dev/null) || return 1
+
+    args=(--pr "$pr" --gh-repo "$gh_repo" --repo-dir "$checkout" -q)
+    [[ -n "$fail_on" ]] && args+=(--fail-on "$fail_on")
+    "$SCRIPT_DIR/pr-review.sh" "${args[@]}"
+}
+
+# Run one claimed job and finish it in the queue; runs as a worker in the
+# background, logging to the queue's logs/ directory. A tenant's job runs
+# in the tenant's workspace with its rule packs, token, and results URL.
+# Args: $1 = running job file
+run_job() {
+    local file="$1"
+    local job id kind org tenant settings run_key scan_dir state token_env error=""
+    local log
+
+    job=$(cat "$file")
+    id=$(jq -r '.id' <<< "$job")
+    kind=$(jq -r '.kind' <<< "$job")
+    org=$(jq -r '.org // empty' <<< "$job")
+    run_key="$org"
+    mkdir -p "$AGENT_QUEUE_DIR/logs"
+    log="$AGENT_QUEUE_DIR/logs/$id.log"
+    echo "[$NODE] Starting $id ($(jq -r '.priority' <<< "$job"), attempt $(($(jq -r '.attempts' <<< "$job") + 1)))"
+
+    tenant=$(jq -r '.tenant // empty' <<< "$job")
+    if ! settings=$(tenant_settings "$CONFIG" "$tenant"); then
+        error="tenant '$tenant' is not in the config"
+    elif [[ "$settings" != "{}" ]]; then
+        CATALOG_ROOT=$(tenant_workspace "$tenant") || error="no workspace for tenant '$tenant'"
+        export CATALOG_ROOT
+        RULE_PACKS=$(jq -r '(.rule_packs // []) | join(" ")' <<< "$settings")
+        export RULE_PACKS
+        [[ -z "$RULE_PACKS" ]] && unset RULE_PACKS
+        token_env=$(jq -r '.token_env // empty' <<< "$settings")
+        if [[ "$token_env" =~ ^[A-Za-z_][A-Za-z0-9_]*$ ]]; then
+            TOKEN="${!token_env:-}"
+        elif [[ -n "$token_env" ]]; then
+            error="tenant '$tenant' has an invalid token_env"
+        fi
+        RESULTS_URL=$(jq -r --arg url "$RESULTS_URL" --arg tenant "$tenant" \
+            '.results_url // (if $url == "" then "" else "\($url | rtrimstr("/"))/\($tenant)" end)' <<< "$settings")
+        run_key="$tenant/$org"
+    fi
+
+    if [[ -n "$error" ]]; then
+        echo "Error: $error" >> "$log"
+    elif [[ "$kind" == "pr" ]]; then
+        run_pr_scan "$job" >> "$log" 2>&1 || error="PR scan failed"
+    elif ! run_target "$job" "$DEFAULT_PROFILE" >> "$log" 2>&1; then
+        error="hunt failed"
+        agent_record_run "$run_key" failed
+    else
+        scan_dir=$(get_latest_scan_dir "$org" || echo "")
+        if [[ -n "$RESULTS_URL" && -n "$scan_dir" ]] && \
+            ! push_scan_results "$RESULTS_URL" "$NODE" "$org" "$scan_dir" "$TOKEN"; then
+            error="push failed"
+            agent_record_run "$run_key" failed
+        else
+            [[ -n "$RESULTS_URL" && -n "$scan_dir" ]] && echo "[$NODE] $org: pushed $(basename "$scan_dir")"
+            agent_record_run "$run_key" ok "$(basename "${scan_dir:-}")"
+        fi
+    fi
+
+    if [[ -z "$error" ]]; then
+        job_finish "$id" ok > /dev/null
+        echo "[$NODE] $id: done"
+        return 0
+    fi
+    state=$(job_finish "$id" failed "$error (see $log)" "$MAX_ATTEMPTS")
+    if [[ "$state" == "dead" ]]; then
+        echo "[$NODE] $id: $error, moved to the dead-letter queue (./scripts/jobs.sh retry $id)"
+    else
+        echo "[$NODE] $id: $error, will retry"
+    fi
+}
+
+# Jobs currently running
+running_jobs() {
+    find "$AGENT_QUEUE_DIR/running" -name '*.json' 2>/dev/null | grep -c . || true
+}
+
+NEXT_POLL=0
+while true; do
+    if [[ "$(date +%s)" -ge "$NEXT_POLL" ]]; then
+        CONFIG=$(fetch_agent_config "$CONFIG_URL" "$TOKEN") || {
+            echo "Error: Could not load agent configuration from $CONFIG_URL"
+            [[ "$RUN_ONCE" == true || "$DRY_RUN" == true ]] && exit 1
+            sleep $((AGENT_DEFAULT_POLL_MINUTES * 60))
+            continue
+        }
+
+        if [[ "$DRY_RUN" == true ]]; then
+            echo "Due targets:"
+            agent_due_targets "$CONFIG" | jq -r --argjson d "$AGENT_DEFAULT_INTERVAL_HOURS" \
+                '"  \(.org) (\(.platform), every \(.interval_hours // $d)h)"'
+            for state in pending running dead; do
+                echo "Jobs $state:"
+                job_list "$state" | jq -r '.[] | "  \(.id) (\(.priority), \(.tenant), \(.attempts) attempts)"'
+            done
+            exit 0
+        fi
+        NEXT_POLL=$(($(date +%s) + $(jq -r --argjson d "$AGENT_DEFAULT_POLL_MINUTES" '.poll_minutes // $d' "$CONFIG") * 60))
+
+        # New releases replace the scripts this loop is running, so restart
+        # from the updated checkout, once no job is running
+        if [[ "$(jq -r '.self_update // false' "$CONFIG")" == true && "$(running_jobs)" -eq 0 ]]; then
+            before=$(git -C "$CATALOG_ROOT" rev-parse HEAD 2>/dev/null || echo "")
+            "$SCRIPT_DIR/self-update.sh" -y || echo "Warning: Self-update failed, continuing on the current release"
+            after=$(git -C "$CATALOG_ROOT" rev-parse HEAD 2>/dev/null || echo "")
+            if [[ -n "$before" && "$before" != "$after" ]]; then
+                rmdir "$LOCK_DIR" 2>/dev/null || true
+                exec "$0" ${ORIGINAL_ARGS[@]+"${ORIGINAL_ARGS[@]}"}
+            fi
+        fi
+
+        # Rule pack allowlist for build_custom_rule_args (empty = all packs)
+        RULE_PACKS=$(jq -r '(.rule_packs // []) | join(" ")' "$CONFIG")
+        export RULE_PACKS
+        [[ -z "$RULE_PACKS" ]] && unset RULE_PACKS
+        DEFAULT_PROFILE=$(jq -r '.profile // ""' "$CONFIG")
+        WORKERS=$(jq -r --argjson d "$AGENT_DEFAULT_WORKERS" '.workers // $d' "$CONFIG")
+        MAX_ATTEMPTS=$(jq -r --argjson d "$AGENT_DEFAULT_MAX_ATTEMPTS" '.max_attempts // $d' "$CONFIG")
+
+        # A due target's job id names the run it follows, so a failing target
+        # stays one job (retried, then dead) instead of a new job each poll
+        queued=0
+        while IFS= read -r job; do
+            [[ -z "$job" ]] && continue
+            # With tenants, a job that doesn't name one would run in no
+            # tenant's namespace
+            tenant=$(jq -r '.tenant // .org // empty' <<< "$job")
+            if ! tenant_settings "$CONFIG" "$tenant" > /dev/null; then
+                echo "Warning: Skipping $(jq -r '.id // .org' <<< "$job"): tenant '$tenant' is not in the config"
+                continue
+            fi
+            job_enqueue "$job" && queued=$((queued + 1))
+        done < <(agent_due_targets "$CONFIG" | jq -c --argjson runs "$(cat "$AGENT_STATE_DIR/state.json" 2>/dev/null || echo '{}')" '
+                    (if .tenant then "\(.tenant)/\(.org)" else .org end) as $key
+                    | . + {id: "hunt-\($key | sub("/"; "-"))-\($runs[$key].last_run // 0)", kind: "hunt",
+                           priority: (.priority // "batch")}'
+                 jq -c '.jobs[]?' "$CONFIG")
+        [[ "$queued" -gt 0 ]] && echo "[$NODE] Queued $queued jobs"
+    fi
+
+    # Start workers while there are free slots and jobs that may run
+    while [[ "$(running_jobs)" -lt "${WORKERS:-$AGENT_DEFAULT_WORKERS}" ]] && job=$(job_claim "$CONFIG"); do
+        # stdin is the agent's; prompts must not consume it
+        run_job "$job" < /dev/null &
+    done
+
+    # With no worker busy, nothing else may run now (retries waiting out
+    # their backoff or quota); --once leaves them for the next run
+    if [[ "$RUN_ONCE" == true && "$(running_jobs)" -eq 0 ]]; then
+        job=$(job_claim "$CONFIG") || break
+        run_job "$job" < /dev/null &
+    fi
+    sleep 5
+done
+wait
@@ -0,0 +1,445 @@
+#!/usr/bin/env bash
+# Apply a rule's semgrep autofix across an organization's repositories
+#
+# Usage: ./scripts/apply-fix.sh <org-name> --rule <rule-id> [options]
+#
+# Fixes are applied in a throwaway git worktree per repo, so the checked-out
+# copy in repos/<org>/ is never modified. With --dry-run the aggregate diff is
+# printed and saved; otherwise the fix is committed to a branch in each repo.
+# With --open-pr the branch is cut from the default branch, pushed, and a pull
+# request is opened with a description built from the rule metadata.
+#
+# Examples:
+#   ./scripts/apply-fix.sh acme-corp --rule go-repo-write-no-symlink-check --dry-run
+#   ./scripts/apply-fix.sh acme-corp --rule go-repo-write-no-symlink-check --branch fix/symlinks
+#   ./scripts/apply-fix.sh acme-corp --rule go-repo-write-no-symlink-check --open-pr
+
+set -euo pipefail
+
+SCRIPT_DIR="$(cd "$(dirname "${BASH_SOURCE[0]}")" && pwd)"
+source "$SCRIPT_DIR/lib/catalog-utils.sh"
+source "$SCRIPT_DIR/lib/rule-utils.sh"
+source "$SCRIPT_DIR/lib/finding-utils.sh"
+
+usage() {
+    cat << EOF
+Usage: $0 <org-name> --rule <rule-id> [options]
+
+Apply the autofix of one custom rule across every repository of an org.
+
+Options:
+    --rule <rule-id>      Rule to apply (required, must define fix: or fix-regex:)
+    --dry-run             Show and save the aggregate diff, commit nothing
+    --branch <name>       Branch to commit fixes to (default: bounty-hunter/fix-<rule-id>)
+    --open-pr             Fix the default branch, push, and open a pull request
+                          (only for rules with metadata confidence: HIGH)
+    --any-confidence      Allow --open-pr for MEDIUM/LOW confidence rules
+    --repos-dir <path>    Directory containing repos (default: repos/<org>, then ./<org>)
+    --output-dir <path>   Directory for the aggregate patch (default: scans/<org>)
+    -q, --quiet           Quiet mode: show progress and final summary only
+    -h, --help            Show this help message
+
+Examples:
+    $0 acme-corp --rule go-repo-write-no-symlink-check --dry-run
+    $0 acme-corp --rule go-repo-write-no-symlink-check --branch fix/symlinks
+    $0 acme-corp --rule go-repo-write-no-symlink-check --open-pr
+EOF
+    exit 1
+}
+
+# Check for help flag first
+for arg in "$@"; do
+    if [[ "$arg" == "-h" || "$arg" == "--help" ]]; then
+        usage
+    fi
+done
+
+if [[ $# -lt 1 ]]; then
+    usage
+fi
+
+ORG="$1"
+shift
+
+RULE_ID=""
+DRY_RUN=""
+BRANCH=""
+OPEN_PR=""
+ANY_CONFIDENCE=""
+REPOS_DIR=""
+OUTPUT_DIR=""
+QUIET_MODE=""
+
+while [[ $# -gt 0 ]]; do
+    case "$1" in
+        --rule)
+            RULE_ID="$2"
+            shift 2
+            ;;
+        --dry-run)
+            DRY_RUN="1"
+            shift
+            ;;
+        --branch)
+            BRANCH="$2"
+            shift 2
+            ;;
+        --open-pr)
+            OPEN_PR="1"
+            shift
+            ;;
+        --any-confidence)
+            ANY_CONFIDENCE="1"
+            shift
+            ;;
+        --repos-dir)
+            REPOS_DIR="$2"
+            shift 2
+            ;;
+        --output-dir)
+            OUTPUT_DIR="$2"
+            shift 2
+            ;;
+        -q|--quiet)
+            QUIET_MODE="1"
+            shift
+            ;;
+        *)
+            echo "Unknown option: $1"
+            usage
+            ;;
+    esac
+done
+
+export QUIET_MODE
+
+if [[ -z "$RULE_ID" ]]; then
+    echo "Error: --rule is required"
+    echo ""
+    usage
+fi
+
+if ! command -v semgrep &> /dev/null; then
+    echo "Error: semgrep is required but not installed."
+    echo "Install: brew install semgrep"
+    exit 1
+fi
+
+# Strip any dotted path prefix semgrep adds to local rule ids
+RULE_ID="${RULE_ID##*.}"
+
+if ! RULE_FILE=$(find_rule_file "$RULE_ID"); then
+    echo "Error: Rule '$RULE_ID' not found under $RULES_ROOT"
+    exit 1
+fi
+
+if ! rule_has_fix "$RULE_ID"; then
+    echo "Error: Rule '$RULE_ID' has no autofix (no fix: or fix-regex: key)"
+    echo "Defined in: $RULE_FILE"
+    exit 1
+fi
+
+RULE_CONFIDENCE=$(rule_field "$RULE_ID" confidence)
+RULE_CONFIDENCE="${RULE_CONFIDENCE:-UNKNOWN}"
+
+if [[ -n "$OPEN_PR" ]]; then
+    if [[ -n "$DRY_RUN" ]]; then
+        echo "Error: --open-pr and --dry-run are mutually exclusive"
+        exit 1
+    fi
+    if ! command -v gh &> /dev/null; then
+        echo "Error: gh (GitHub CLI) is required for --open-pr."
+        echo "Install: brew install gh"
+        exit 1
+    fi
+    # Unattended PRs are only worth a maintainer's time for precise rules
+    if [[ "$RULE_CONFIDENCE" != "HIGH" && -z "$ANY_CONFIDENCE" ]]; then
+        echo "Error: Rule '$RULE_ID' has confidence $RULE_CONFIDENCE; --open-pr requires HIGH"
+        echo "Override with --any-confidence"
+        exit 1
+    fi
+fi
+
+# Default repos dir: catalog location first, then standalone ./<org>
+if [[ -z "$REPOS_DIR" ]]; then
+    if [[ -d "$(get_org_repos_dir "$ORG")" ]]; then
+        REPOS_DIR="$(get_org_repos_dir "$ORG")"
+    else
+        REPOS_DIR="$ORG"
+    fi
+fi
+OUTPUT_DIR="${OUTPUT_DIR:-scans/$ORG}"
+BRANCH="${BRANCH:-bounty-hunter/fix-$RULE_ID}"
+
+if [[ ! -d "$REPOS_DIR" ]]; then
+    echo "Error: Directory '$REPOS_DIR' not found."
+    exit 1
+fi
+
+# Single-rule config so other rules in the same file never rewrite code
+TMP_DIR=$(mktemp -d)
+WORKTREE_REPOS=()
+
+# Worktrees live under TMP_DIR; each repo they were added to forgets them
+# again, however the run ends
+cleanup() {
+    local repo worktree
+
+    for repo in ${WORKTREE_REPOS[@]+"${WORKTREE_REPOS[@]}"}; do
+        worktree="$WORKTREE_ROOT/$(basename "$repo")"
+        [[ -d "$worktree" ]] && git -C "$repo" worktree remove --force "$worktree" > /dev/null 2>&1
+        git -C "$repo" worktree prune > /dev/null 2>&1 || true
+    done
+    rm -rf "$TMP_DIR"
+}
+trap cleanup EXIT
+
+RULE_CONFIG="$TMP_DIR/rule.yaml"
+WORKTREE_ROOT="$TMP_DIR/worktrees"
+mkdir -p "$WORKTREE_ROOT"
+extract_rule "$RULE_ID" "$RULE_FILE" > "$RULE_CONFIG"
+
+mkdir -p "$OUTPUT_DIR/fixes"
+PATCH_FILE="$OUTPUT_DIR/fixes/$RULE_ID.patch"
+: > "$PATCH_FILE"
+
+REPOS=$(get_active_repos "$REPOS_DIR")
+REPO_COUNT=$(echo "$REPOS" | grep -c . || echo 0)
+
+log_header "Apply Fix: $RULE_ID"
+log_verbose "Rule file:    $RULE_FILE"
+log_verbose "Repositories: $REPO_COUNT"
+if [[ -n "$DRY_RUN" ]]; then
+    log_verbose "Mode:         dry run (no commits)"
+else
+    log_verbose "Mode:         commit to branch '$BRANCH'"
+    [[ -n "$OPEN_PR" ]] && log_verbose "              push and open pull requests"
+fi
+log_verbose ""
+
+REPOS_ARRAY=()
+while IFS= read -r repo; do
+    [[ -n "$repo" ]] && REPOS_ARRAY+=("$repo")
+done <<< "$REPOS"
+
+current=0
+fixed_repos=0
+fixed_files=0
+FIXED_SUMMARY=()
+OPENED_PRS=()
+FAILED_REPOS=()
+
+# Pull request description generated from rule metadata
+# Args: $1 = worktree, $2 = base commit sha, $3 = semgrep results file
+render_pr_body() {
+    local worktree="$1"
+    local base_sha="$2"
+    local results="$3"
+    local cwe references
+
+    echo "## Fix \`$RULE_ID\`"
+    echo ""
+    rule_message "$RULE_ID"
+    echo ""
+    echo "| Severity | Confidence | CWE |"
+    echo "|----------|------------|-----|"
+    cwe=$(rule_field "$RULE_ID" cwe)
+    echo "| $(rule_field "$RULE_ID" severity) | $RULE_CONFIDENCE | ${cwe:--} |"
+    echo ""
+    echo "### Findings fixed"
+    echo ""
+    jq -r '.results[] | "\(.path)\t\(.start.line)"' "$results" 2>/dev/null | sort -u | \
+        while IFS=$'\t' read -r path line; do
+            path="${path#"$worktree"/}"
+            url=$(finding_permalink "$worktree" "$base_sha" "$path" "$line" "$ORG" 2>/dev/null || true)
+            if [[ -n "$url" ]]; then
+                echo "- [\`$path:$line\`]($url)"
+            else
+                echo "- \`$path:$line\`"
+            fi
+        done
+
+    references=$(rule_references "$RULE_ID")
+    if [[ -n "$references" ]]; then
+        echo ""
+        echo "### References"
+        echo ""
+        echo "$references" | sed 's/^/- /'
+    fi
+
+    echo ""
+    echo "---"
+    echo "Generated by \`scripts/apply-fix.sh\` from rule \`$RULE_ID\`. Please review before merging."
+}
+
+for repo in "${REPOS_ARRAY[@]}"; do
+    name=$(basename "$repo")
+    current=$((current + 1))
+
+    if [[ -n "$QUIET_MODE" ]]; then
+        log_progress "$current" "$REPO_COUNT" "$name"
+    fi
+
+    if ! git -C "$repo" rev-parse --verify HEAD > /dev/null 2>&1; then
+        log_verbose "[$name] Not a git repository, skipping"
+        continue
+    fi
+
+    # Remediation PRs always target the default branch, not the local checkout
+    base_ref="HEAD"
+    default_branch=""
+    if [[ -n "$OPEN_PR" ]]; then
+        if ! default_branch=$(git -C "$repo" symbolic-ref --short refs/remotes/origin/HEAD 2>/dev/null); then
+            log_verbose "[$name] No origin default branch, skipping"
+            continue
+        fi
+        git -C "$repo" fetch -q origin "${default_branch#origin/}" 2>/dev/null || true
+        base_ref="$default_branch"
+    fi
+    base_sha=$(git -C "$repo" rev-parse "$base_ref")
+
+    worktree="$WORKTREE_ROOT/$name"
+    WORKTREE_REPOS+=("$repo")
+    # A stale worktree or branch fails only this repo, not the sweep
+    if [[ -n "$DRY_RUN" ]]; then
+        git -C "$repo" worktree add --detach "$worktree" "$base_ref" > /dev/null 2>&1 || {
+            echo "[$name] Could not create a worktree at $base_ref, skipping" >&2
+            FAILED_REPOS+=("$name")
+            continue
+        }
+    else
+        if git -C "$repo" show-ref --verify --quiet "refs/heads/$BRANCH"; then
+            log_verbose "[$name] Branch '$BRANCH' already exists, skipping"
+            continue
+        fi
+        git -C "$repo" worktree add -b "$BRANCH" "$worktree" "$base_ref" > /dev/null 2>&1 || {
+            echo "[$name] Could not create branch '$BRANCH' in a worktree, skipping" >&2
+            FAILED_REPOS+=("$name")
+            git -C "$repo" branch -D "$BRANCH" > /dev/null 2>&1 || true
+            continue
+        }
+    fi
+
+    # Results list the pre-fix locations, used for the PR description
+    results_file="$TMP_DIR/$name.json"
+    status=0
+    semgrep scan \
+        --config="$RULE_CONFIG" \
+        --autofix \
+        --metrics=off \
+        --quiet \
+        --json \
+        --output="$results_file" \
+        "$worktree" > /dev/null 2>&1 || status=$?
+
+    # Semgrep exits 1 when it finds something; a failed run may have fixed
+    # only some files, so the repo is left alone
+    errors=""
+    if [[ -s "$results_file" ]]; then
+        errors=$(jq -r '[.errors[]? | select(.level == "error") | .message // .long_msg // "error"] | unique | .[]' "$results_file" 2>/dev/null || echo "unreadable semgrep output")
+    fi
+    if [[ "$status" -gt 1 || ! -s "$results_file" ]]; then
+        errors="semgrep exited $status${errors:+$'\n'$errors}"
+    fi
+    if [[ -n "$errors" ]]; then
+        echo "[$name] Autofix failed, skipping:" >&2
+        sed 's/^/  /' <<< "$errors" >&2
+        FAILED_REPOS+=("$name")
+        git -C "$repo" worktree remove --force "$worktree" > /dev/null 2>&1 || true
+        [[ -z "$DRY_RUN" ]] && { git -C "$repo" branch -D "$BRANCH" > /dev/null 2>&1 || true; }
+        continue
+    fi
+
+    changed=$(git -C "$worktree" diff --name-only | grep -c . || true)
+
+    if [[ "$changed" -gt 0 ]]; then
+        # Prefix paths with the repo name so the aggregate patch is unambiguous
+        git -C "$worktree" diff --src-prefix="a/$name/" --dst-prefix="b/$name/" >> "$PATCH_FILE"
+
+        if [[ -z "$DRY_RUN" ]]; then
+            if git -C "$worktree" commit -q -a -m "Apply $RULE_ID autofix
+
+Automated fix generated by scripts/apply-fix.sh from rule $RULE_ID."; then
+                log_verbose "[$name] Committed $changed files to $BRANCH"
+                if [[ -n "$OPEN_PR" ]]; then
+                    body_file="$TMP_DIR/$name-pr.md"
+                    render_pr_body "$worktree" "$base_sha" "$results_file" > "$body_file"
+                    if git -C "$worktree" push -q -u origin "$BRANCH" 2>/dev/null && \
+                       pr_url=$(cd "$worktree" && gh pr create \
+                           --base "${default_branch#origin/}" \
+                           --head "$BRANCH" \
+                           --title "Fix $RULE_ID findings" \
+                           --body-file "$body_file" 2>/dev/null); then
+                        OPENED_PRS+=("$name: $pr_url")
+                        log_verbose "[$name] Opened $pr_url"
+                    else
+                        echo "[$name] Could not push or open pull request; branch kept locally" >&2
+                    fi
+                fi
+            else
+                echo "[$name] Commit failed, branch '$BRANCH' not created" >&2
+                git -C "$repo" worktree remove --force "$worktree" > /dev/null 2>&1 || true
+                git -C "$repo" branch -D "$BRANCH" > /dev/null 2>&1 || true
+                continue
+            fi
+        else
+            log_verbose "[$name] $changed files would change"
+        fi
+
+        fixed_repos=$((fixed_repos + 1))
+        fixed_files=$((fixed_files + changed))
+        FIXED_SUMMARY+=("$name: $changed files")
+    else
+        log_verbose "[$name] No fixes"
+        if [[ -z "$DRY_RUN" ]]; then
+            # Nothing to commit - drop the empty branch again
+            git -C "$repo" worktree remove --force "$worktree" > /dev/null 2>&1
+            git -C "$repo" branch -D "$BRANCH" > /dev/null 2>&1 || true
+            continue
+        fi
+    fi
+
+    git -C "$repo" worktree remove --force "$worktree" > /dev/null 2>&1 || true
+done
+
+# Clear progress line if in quiet mode
+[[ -n "$QUIET_MODE" ]] && clear_progress
+
+if [[ -n "$DRY_RUN" && -s "$PATCH_FILE" && -z "$QUIET_MODE" ]]; then
+    echo ""
+    log_header "Aggregate Diff"
+    cat "$PATCH_FILE"
+fi
+
+echo ""
+echo "Fix $RULE_ID: $fixed_files files in $fixed_repos repos"
+for line in ${FIXED_SUMMARY[@]+"${FIXED_SUMMARY[@]}"}; do
+    log_verbose "  $line"
+done
+
+if [[ -s "$PATCH_FILE" ]]; then
+    echo "Patch saved to: $PATCH_FILE"
+else
+    rm -f "$PATCH_FILE"
+fi
+
+if [[ ${#OPENED_PRS[@]} -gt 0 ]]; then
+    echo ""
+    echo "Pull requests opened: ${#OPENED_PRS[@]}"
+    for line in "${OPENED_PRS[@]}"; do
+        echo "  $line"
+    done
+fi
+
+if [[ -z "$DRY_RUN" && -z "$OPEN_PR" && "$fixed_repos" -gt 0 ]]; then
+    log_verbose ""
+    log_verbose "Review and push:"
+    log_verbose "  git -C <repo> log -p $BRANCH -1"
+    log_verbose "  git -C <repo> push origin $BRANCH"
+fi
+
+if [[ ${#FAILED_REPOS[@]} -gt 0 ]]; then
+    echo ""
+    echo "Autofix failed in ${#FAILED_REPOS[@]} repos: ${FAILED_REPOS[*]}"
+    exit 1
+fi
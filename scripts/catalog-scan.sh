@@ -9,12 +9,28 @@
 # Examples:
 #   ./scripts/catalog-scan.sh acme-corp              # Catalog scan (tracked org)
 #   ./scripts/catalog-scan.sh acme-corp --skip-kics  # Skip KICS scanner
+#   ./scripts/catalog-scan.sh acme-corp --profile ci # Use the ci scan profile
+#   ./scripts/catalog-scan.sh acme-corp --fail-on high  # Exit 1 on high or critical findings
 #   ./scripts/catalog-scan.sh acme-corp --no-catalog --repos-dir ./acme  # One-off scan
+#   ./scripts/catalog-scan.sh acme-corp --record     # Also write a reproducibility bundle
+#   ./scripts/catalog-scan.sh acme-corp --semgrep --budget 2m  # Quick time-boxed pass
+#   ./scripts/catalog-scan.sh acme-corp --rules category=traversal,secrets --min-severity medium
+#   ./scripts/catalog-scan.sh --replay scans/acme-corp/2026-01-01/repro-bundle.tar.gz
 
 set -euo pipefail
 
 SCRIPT_DIR="$(cd "$(dirname "${BASH_SOURCE[0]}")" && pwd)"
 source "$SCRIPT_DIR/lib/catalog-utils.sh"
+source "$SCRIPT_DIR/lib/profiles.sh"
+source "$SCRIPT_DIR/lib/trace-utils.sh"
+source "$SCRIPT_DIR/lib/provenance-utils.sh"
+source "$SCRIPT_DIR/lib/report-utils.sh"
+source "$SCRIPT_DIR/lib/rule-utils.sh"
+source "$SCRIPT_DIR/lib/usage-utils.sh"
+
+# Scanner settings from .env (e.g. MAX_FILE_SIZE_SEMGREP), exported to scanners;
+# credentials in .env are left out
+load_env_settings 'MAX_FILE_SIZE_[A-Z]+|PARSE_RECOVERY_MAX_FILES|SCAN_SIGNING_KEY|QUOTA_[A-Z_]+|REPORT_LANG'
 
 usage() {
     cat << EOF
@@ -31,9 +47,32 @@ Options:
     --output-dir <path>  Directory for results (default: scans/<org>)
     --no-pull            Skip git pull on repositories (catalog mode only)
     --no-commit          Skip git commit prompt (catalog mode only)
+    --profile <name>     Scan profile (scanners, rulesets, severities, output)
+    --signing-key <path> Sign the scan's provenance.json with this PEM key
+                         (default: SCAN_SIGNING_KEY from .env; catalog mode only)
+    --fail-on <level>    Exit 1 if any scanner finds something at this severity or
+                         above: critical, high, medium, low, info (catalog mode only)
+    --record             Write repro-bundle.tar.gz (engines, rule packs, commits,
+                         config, environment) to the scan (catalog mode only)
+    --replay <bundle>    Re-run a recorded scan (see scripts/replay-scan.sh --help)
+    --rules <selection>  Only semgrep rules matching the selection, e.g.
+                         category=traversal,secrets;confidence=high (keys:
+                         category, pack, cwe, id, confidence)
+    --min-severity <lvl> Only semgrep findings at this severity or above
+    --budget <duration>  Time-box semgrep (e.g. 90s, 2m): HIGH-confidence custom
+                         rules and recently changed files first, skipped files
+                         reported; other scanners are not time-boxed
+    --ignore-quota       Scan even when the org has used up a quota
+                         (see scripts/usage.sh; catalog mode only)
+    --log-level <level>  Log level: debug, info, warn, error (default: info)
+    --log-format <fmt>   Log format: text or json (default: text)
+    --log-file <path>    Append log events to a file instead of the terminal
     -q, --quiet          Quiet mode: show progress and final summary only
     -h, --help           Show this help message
 
+Profiles:
+$(list_profiles | sed 's/^/  /')
+
 Scan selection:
     --semgrep            Run semgrep only
     --secrets            Run trufflehog only
@@ -53,6 +92,9 @@ Examples:
     $0 acme-corp --semgrep --secrets          # Only semgrep and trufflehog
     $0 acme-corp --no-catalog --repos-dir ./my-repos  # One-off scan
     $0 acme-corp --quiet                      # Quiet output with progress only
+    $0 acme-corp --profile secrets-only       # Trufflehog + artifacts only
+    $0 acme-corp --semgrep --budget 2m        # Quick pass within two minutes
+    $0 acme-corp --log-format json --log-file scan.log  # JSON logs for shipping
 EOF
     exit 1
 }
@@ -68,6 +110,11 @@ if [[ $# -lt 1 ]]; then
     usage
 fi
 
+if [[ "$1" == "--replay" ]]; then
+    shift
+    exec "$SCRIPT_DIR/replay-scan.sh" "$@"
+fi
+
 ORG="$1"
 shift
 
@@ -78,6 +125,14 @@ NO_COMMIT=""
 REPOS_DIR=""
 OUTPUT_DIR=""
 QUIET_MODE=""
+PROFILE=""
+SIGNING_KEY="${SCAN_SIGNING_KEY:-}"
+FAIL_ON=""
+RECORD=""
+BUDGET=""
+RULES=""
+MIN_SEV=""
+IGNORE_QUOTA=""
 RUN_SEMGREP=""
 RUN_SECRETS=""
 RUN_ARTIFACTS=""
@@ -115,6 +170,50 @@ while [[ $# -gt 0 ]]; do
             QUIET_MODE="1"
             shift
             ;;
+        --profile)
+            PROFILE="$2"
+            shift 2
+            ;;
+        --signing-key)
+            SIGNING_KEY="$2"
+            shift 2
+            ;;
+        --fail-on)
+            FAIL_ON="$2"
+            shift 2
+            ;;
+        --record)
+            RECORD="1"
+            shift
+            ;;
+        --budget)
+            BUDGET="$2"
+            shift 2
+            ;;
+        --rules)
+            RULES="$2"
+            shift 2
+            ;;
+        --min-severity)
+            MIN_SEV="$2"
+            shift 2
+            ;;
+        --ignore-quota)
+            IGNORE_QUOTA="1"
+            shift
+            ;;
+        --log-level)
+            LOG_LEVEL="$2"
+            shift 2
+            ;;
+        --log-format)
+            LOG_FORMAT="$2"
+            shift 2
+            ;;
+        --log-file)
+            LOG_FILE="$2"
+            shift 2
+            ;;
         --semgrep)
             RUN_SEMGREP="1"
             shift
@@ -165,8 +264,55 @@ while [[ $# -gt 0 ]]; do
     esac
 done
 
+# Profile defaults (explicit scan selection flags still take precedence)
+SEMGREP_ARGS=()
+if [[ -n "$PROFILE" ]]; then
+    load_profile "$PROFILE" || exit 1
+    [[ -n "$PROFILE_QUIET" ]] && QUIET_MODE="1"
+    SEMGREP_ARGS+=("--profile" "$PROFILE")
+fi
+if [[ -n "$BUDGET" ]]; then
+    if ! parse_duration_seconds "$BUDGET" > /dev/null; then
+        echo "Error: Invalid --budget '$BUDGET' (e.g. 90s, 2m, 1h)"
+        exit 1
+    fi
+    SEMGREP_ARGS+=("--budget" "$BUDGET")
+fi
+# Exported like RULE_PACKS, so a --record bundle replays the same slice
+if [[ -n "$RULES" ]]; then
+    validate_rule_selection "$RULES" || exit 1
+    export RULE_SELECTION="$RULES"
+fi
+if [[ -n "$MIN_SEV" ]]; then
+    validate_severity "$MIN_SEV" --min-severity || exit 1
+    export MIN_SEVERITY="$MIN_SEV"
+fi
+
 export QUIET_MODE
 
+case "$LOG_LEVEL" in
+    debug|info|warn|error) ;;
+    *) echo "Error: --log-level must be debug, info, warn, or error"; exit 1 ;;
+esac
+case "$LOG_FORMAT" in
+    text|json) ;;
+    *) echo "Error: --log-format must be text or json"; exit 1 ;;
+esac
+export LOG_LEVEL LOG_FORMAT LOG_FILE
+
+if [[ -n "$FAIL_ON" ]]; then
+    validate_severity "$FAIL_ON" --fail-on || exit 1
+    if [[ -n "$NO_CATALOG" ]]; then
+        echo "Error: --fail-on needs catalog mode (merged results)"
+        exit 1
+    fi
+fi
+
+if [[ -n "$RECORD" && -n "$NO_CATALOG" ]]; then
+    echo "Error: --record needs catalog mode (recorded commits)"
+    exit 1
+fi
+
 # Determine which scans to run
 if [[ -n "$RUN_SEMGREP" || -n "$RUN_SECRETS" || -n "$RUN_ARTIFACTS" || -n "$RUN_KICS" || -n "$RUN_INVENTORY" ]]; then
     # Specific scans requested - only run those
@@ -175,6 +321,19 @@ if [[ -n "$RUN_SEMGREP" || -n "$RUN_SECRETS" || -n "$RUN_ARTIFACTS" || -n "$RUN_
     DO_ARTIFACTS="${RUN_ARTIFACTS:-}"
     DO_KICS="${RUN_KICS:-}"
     DO_INVENTORY="${RUN_INVENTORY:-}"
+elif [[ -n "$PROFILE" ]]; then
+    # Scanners from the profile, respecting skip flags
+    DO_SEMGREP=""; DO_SECRETS=""; DO_ARTIFACTS=""; DO_KICS=""; DO_INVENTORY=""
+    profile_has_scanner semgrep && DO_SEMGREP="1"
+    profile_has_scanner secrets && DO_SECRETS="1"
+    profile_has_scanner artifacts && DO_ARTIFACTS="1"
+    profile_has_scanner kics && DO_KICS="1"
+    profile_has_scanner inventory && DO_INVENTORY="1"
+    [[ -n "$SKIP_SEMGREP" ]] && DO_SEMGREP=""
+    [[ -n "$SKIP_SECRETS" ]] && DO_SECRETS=""
+    [[ -n "$SKIP_ARTIFACTS" ]] && DO_ARTIFACTS=""
+    [[ -n "$SKIP_KICS" ]] && DO_KICS=""
+    [[ -n "$SKIP_INVENTORY" ]] && DO_INVENTORY=""
 else
     # Run all by default, respecting skip flags
     DO_SEMGREP="1"
@@ -230,7 +389,17 @@ if [[ -z "$NO_CATALOG" ]]; then
         exit 1
     fi
 
+    # Orgs that have used up a quota wait for next month (or more quota)
+    if [[ -z "$IGNORE_QUOTA" ]] && ! exhausted=$(usage_quota_check "$ORG"); then
+        echo "Error: '$ORG' is over its quota:"
+        sed 's/^/  /' <<< "$exhausted"
+        echo ""
+        echo "Raise it with ./scripts/usage.sh $ORG --quota-compute <hours>h (etc.), or pass --ignore-quota"
+        exit 1
+    fi
+
     TIMESTAMP=$(get_scan_timestamp)
+    SCAN_ID="$ORG/$TIMESTAMP"
     SCAN_DIR="$CATALOG_ROOT/catalog/tracked/$ORG/scans/$TIMESTAMP"
     mkdir -p "$SCAN_DIR"
 else
@@ -249,6 +418,10 @@ fi
 # Common setup
 # =============================================================================
 
+# Scan id shared by every log event from this run and its scanners
+SCAN_ID="${SCAN_ID:-$ORG/$(get_scan_timestamp)}"
+export SCAN_ID
+
 # Create output directories
 mkdir -p "$OUTPUT_DIR/"{semgrep-results,trufflehog-results,artifact-results,kics-results,inventory}
 
@@ -275,6 +448,7 @@ if [[ -z "$QUIET_MODE" ]]; then
     fi
     echo "========================================"
     [[ -n "$TIMESTAMP" ]] && echo "Timestamp:    $TIMESTAMP"
+    [[ -n "$PROFILE" ]] && echo "Profile:      $PROFILE"
     if [[ ${#GITHUB_ORGS[@]} -eq 1 && "${GITHUB_ORGS[0]}" != "$ORG" ]]; then
         echo "GitHub Org:   ${GITHUB_ORGS[0]}"
     elif [[ ${#GITHUB_ORGS[@]} -gt 1 ]]; then
@@ -328,6 +502,9 @@ if [[ -z "$NO_CATALOG" ]]; then
             if ! git -C "$repo" pull --ff-only 2>/dev/null; then
                 [[ -z "$QUIET_MODE" ]] && echo "  [$name] Pull failed (may have local changes), using current state"
             fi
+            # Move checked-out submodules to the commits the pull recorded
+            # (submodules skipped at clone time stay uninitialized)
+            git -C "$repo" submodule update --recursive --quiet 2>/dev/null || true
         else
             [[ -z "$QUIET_MODE" ]] && echo "  [$name] Skipping pull (--no-pull)"
         fi
@@ -371,13 +548,38 @@ fi
 
 START_TIME=$(date +%s)
 SCAN_RESULTS=()
+SCANNER_SECONDS=()
+
+# The first INT/TERM stops the running scanner and skips the rest; results
+# from completed repos are still stored in the catalog, marked partial.
+# A second signal exits immediately.
+CANCELLED=""
+on_cancel() {
+    if [[ -n "$CANCELLED" ]]; then
+        stop_child_processes
+        exit 130
+    fi
+    CANCELLED="1"
+    echo ""
+    log_warn "Cancel requested, stopping scanners and saving partial results" target="$ORG"
+    stop_child_processes
+}
+trap on_cancel INT TERM
+trace_span_start "scan" org="$ORG" scan_id="$SCAN_ID" profile="${PROFILE:-bounty-recon}"
 
 run_scan() {
     local name="$1"
     local script="$2"
-    local start end duration
+    shift 2
+    local start end duration pid
+    local status=0
     local quiet_arg=""
 
+    if [[ -n "$CANCELLED" ]]; then
+        SCAN_RESULTS+=("$name: skipped (cancelled)")
+        return 0
+    fi
+
     [[ -n "$QUIET_MODE" ]] && quiet_arg="--quiet"
 
     if [[ -z "$QUIET_MODE" ]]; then
@@ -391,19 +593,40 @@ run_scan() {
     fi
 
     start=$(date +%s)
+    log_debug "Scanner started" target="$ORG" scanner="$name"
+    trace_span_start "scan.$(echo "$name" | tr '[:upper:]' '[:lower:]')" scanner="$name"
+
+    # Run in the background so a signal reaches on_cancel right away, then
+    # keep waiting until the scanner has finished its own cleanup
+    "$SCRIPT_DIR/$script" "$ORG" --repos-dir "$REPOS_DIR" --output-dir "$OUTPUT_DIR" $quiet_arg "$@" &
+    pid=$!
+    wait "$pid" || status=$?
+    while kill -0 "$pid" 2>/dev/null; do
+        wait "$pid" || status=$?
+    done
 
-    if "$SCRIPT_DIR/$script" "$ORG" --repos-dir "$REPOS_DIR" --output-dir "$OUTPUT_DIR" $quiet_arg; then
+    if [[ -n "$CANCELLED" ]]; then
+        end=$(date +%s)
+        duration=$((end - start))
+        SCAN_RESULTS+=("$name: cancelled after ${duration}s")
+        trace_span_end error cancelled=true
+    elif [[ "$status" -eq 0 ]]; then
         end=$(date +%s)
         duration=$((end - start))
         SCAN_RESULTS+=("$name: completed in ${duration}s")
+        log_debug "Scanner completed" target="$ORG" scanner="$name" duration_s="$duration"
+        trace_span_end ok
     else
         end=$(date +%s)
         duration=$((end - start))
         SCAN_RESULTS+=("$name: failed after ${duration}s")
+        log_error "Scanner failed" target="$ORG" scanner="$name" duration_s="$duration"
+        trace_span_end error
     fi
+    SCANNER_SECONDS+=("$(echo "$name" | tr '[:upper:]' '[:lower:]')=$duration")
 }
 
-[[ -n "$DO_SEMGREP" ]] && run_scan "Semgrep" "scan-semgrep.sh"
+[[ -n "$DO_SEMGREP" ]] && run_scan "Semgrep" "scan-semgrep.sh" ${SEMGREP_ARGS[@]+"${SEMGREP_ARGS[@]}"}
 [[ -n "$DO_SECRETS" ]] && run_scan "Trufflehog" "scan-secrets.sh"
 [[ -n "$DO_ARTIFACTS" ]] && run_scan "Artifacts" "scan-artifacts.sh"
 [[ -n "$DO_KICS" ]] && run_scan "KICS" "scan-kics.sh"
@@ -412,11 +635,27 @@ run_scan() {
 END_TIME=$(date +%s)
 TOTAL_DURATION=$((END_TIME - START_TIME))
 
+# Metered usage (usage-utils.sh): scanner time, and one verification
+# request per secret trufflehog reports
+if [[ -z "$NO_CATALOG" ]]; then
+    api_calls=0
+    if [[ -n "$DO_SECRETS" ]]; then
+        api_calls=$(for f in "$OUTPUT_DIR/trufflehog-results"/*.json.gz; do
+            [[ -f "$f" && "$f" != *-archives.json.gz ]] && gzip -dc "$f"
+        done | grep -c . || true)
+    fi
+    usage_record "$ORG" scan "$TOTAL_DURATION" "${api_calls:-0}" "$(printf '%s\n' ${SCANNER_SECONDS[@]+"${SCANNER_SECONDS[@]}"} | \
+        jq -R -s -c --arg scan "$TIMESTAMP" --argjson repos "$REPO_COUNT" --arg profile "${PROFILE:-}" '
+            {scan: $scan, repos: $repos, profile: (if $profile == "" then null else $profile end),
+             scanners: (split("\n") | map(select(. != "") | split("=") | {key: .[0], value: (.[1] | tonumber)}) | from_entries)}')"
+fi
+
 # =============================================================================
 # Catalog mode: Merge and normalize results
 # =============================================================================
 
 if [[ -z "$NO_CATALOG" ]]; then
+    trace_span_start "report" scan_dir="$SCAN_DIR"
     if [[ -z "$QUIET_MODE" ]]; then
         echo ""
         echo "========================================"
@@ -440,6 +679,35 @@ if [[ -z "$NO_CATALOG" ]]; then
         fi
     fi
 
+    # Semgrep canary rules - shadow findings, kept apart from semgrep.json.gz
+    # so they reach no report, digest, or --fail-on (gzip compressed)
+    if [[ -d "$OUTPUT_DIR/semgrep-canary" ]]; then
+        shopt -s nullglob
+        canary_files=("$OUTPUT_DIR/semgrep-canary"/*.json.gz)
+        shopt -u nullglob
+
+        if [[ ${#canary_files[@]} -gt 0 ]]; then
+            for f in "${canary_files[@]}"; do
+                gzip -dc "$f" | jq -c --arg repo "$(basename "$f" .json.gz)" '.results[] | . + {repo: $repo}'
+            done | jq -s --sort-keys '{results: sort_by(.repo, .path, .start.line, .check_id)}' | \
+                gzip > "$SCAN_DIR/semgrep-canary.json.gz" 2>/dev/null || true
+            count=$(gzip -dc "$SCAN_DIR/semgrep-canary.json.gz" | jq '.results | length' 2>/dev/null || echo "0")
+            [[ -z "$QUIET_MODE" ]] && echo "  Canary:     $count findings (shadow, not reported)"
+        fi
+    fi
+
+    # Semgrep diagnostics - array of per-repo diagnostics (gzip compressed)
+    if [[ -d "$OUTPUT_DIR/semgrep-diagnostics" ]]; then
+        shopt -s nullglob
+        diagnostics_files=("$OUTPUT_DIR/semgrep-diagnostics"/*.json)
+        shopt -u nullglob
+
+        if [[ ${#diagnostics_files[@]} -gt 0 ]]; then
+            jq -s --sort-keys 'sort_by(.repo)' "${diagnostics_files[@]}" 2>/dev/null | \
+                gzip > "$SCAN_DIR/semgrep-diagnostics.json.gz" 2>/dev/null || true
+        fi
+    fi
+
     # Trufflehog - concatenate NDJSON files, sort, output as NDJSON (gzip compressed)
     if [[ -d "$OUTPUT_DIR/trufflehog-results" ]]; then
         shopt -s nullglob
@@ -453,6 +721,10 @@ if [[ -z "$NO_CATALOG" ]]; then
             count=$(gzip -dc "$SCAN_DIR/trufflehog.json.gz" 2>/dev/null | wc -l | xargs)
             verified=$(gzip -dc "$SCAN_DIR/trufflehog.json.gz" 2>/dev/null | grep -c '"Verified":true' || echo "0")
             [[ -z "$QUIET_MODE" ]] && echo "  Trufflehog: $count findings ($verified verified)"
+            reused=$(secret_reuse "$OUTPUT_DIR/trufflehog-results" | jq 'length' 2>/dev/null || echo "0")
+            if [[ -z "$QUIET_MODE" && "${reused:-0}" -gt 0 ]]; then
+                echo "              $reused secrets reused across repos or commits (./scripts/secret-reuse.sh $ORG)"
+            fi
         fi
     fi
 
@@ -500,9 +772,51 @@ if [[ -z "$NO_CATALOG" ]]; then
         fi
     fi
 
+    # Mark scans stopped by a cancel so extraction can flag the gaps
+    if [[ -n "$CANCELLED" ]]; then
+        printf '%s\n' "${SCAN_RESULTS[@]}" | jq -R . | jq -s --arg at "$(get_iso_timestamp)" \
+            '{partial: true, cancelled_at: $at, scanners: .}' > "$SCAN_DIR/partial.json"
+        echo "  Partial:    scan was cancelled (partial.json)"
+    fi
+
+    # Reproducibility bundle, written first so provenance covers its digest
+    if [[ -n "$RECORD" ]]; then
+        scanners=""
+        [[ -n "$DO_SEMGREP" ]] && scanners+=" semgrep"
+        [[ -n "$DO_SECRETS" ]] && scanners+=" secrets"
+        [[ -n "$DO_ARTIFACTS" ]] && scanners+=" artifacts"
+        [[ -n "$DO_KICS" ]] && scanners+=" kics"
+        [[ -n "$DO_INVENTORY" ]] && scanners+=" inventory"
+        write_repro_bundle "$SCAN_DIR" "$ORG" "$REPOS_DIR" "$OUTPUT_DIR" "$PROFILE" "$scanners" > /dev/null
+        [[ -z "$QUIET_MODE" ]] && echo "  Bundle:     $REPRO_BUNDLE_FILE (replay: $0 --replay $SCAN_DIR/$REPRO_BUNDLE_FILE)"
+    fi
+
+    # Provenance: engine versions, rule pack digests, scanned commits, and
+    # result file digests, signed when a key is configured
+    if write_scan_provenance "$SCAN_DIR" "$ORG" "$SIGNING_KEY"; then
+        if [[ -z "$QUIET_MODE" ]]; then
+            if [[ -n "$SIGNING_KEY" ]]; then
+                echo "  Provenance: provenance.json (signed)"
+            else
+                echo "  Provenance: provenance.json (unsigned, set SCAN_SIGNING_KEY to sign)"
+            fi
+        fi
+    else
+        echo "  Provenance: signing failed, results are unsigned"
+    fi
+
+    # Dispositions whose file or function has since been deleted are
+    # resolved, with the commit that removed the code
+    if [[ -n "$DO_SEMGREP" && -z "$CANCELLED" ]]; then
+        expired=$(triage_expire_removed "$ORG" "$REPOS_DIR" | grep -c . || true)
+        [[ -z "$QUIET_MODE" && "$expired" -gt 0 ]] && echo "  Triage:     $expired findings resolved (code removed)"
+    fi
+
     # Update catalog index
     update_index_scan "$ORG" "$TIMESTAMP"
+    trace_span_end ok
 fi
+trace_span_end ok duration_s="$TOTAL_DURATION"
 
 # =============================================================================
 # Print summary
@@ -581,7 +895,7 @@ if [[ -z "$NO_CATALOG" ]]; then
         echo ""
 
         # Git commit prompt
-        if [[ -z "$NO_COMMIT" ]]; then
+        if [[ -z "$NO_COMMIT" && -z "$CANCELLED" ]]; then
             echo "----------------------------------------"
             read -p "Commit scan results to git? [y/N] " -n 1 -r
             echo ""
@@ -629,3 +943,19 @@ else
     fi
 fi
 echo ""
+
+# Report the cancel to callers (hunt.sh, CI)
+[[ -n "$CANCELLED" ]] && exit 130
+
+# Severity gate: every scanner's findings on the normalized scale
+if [[ -n "$FAIL_ON" ]]; then
+    while read -r subsystem value; do
+        [[ -n "$subsystem" ]] && echo "Warning: $subsystem severity '$value' has no mapping; counted as $(normalize_severity "$subsystem" "$value")" >&2
+    done < <(unmapped_severities "$SCAN_DIR")
+    failing=$(scan_digest "$ORG" "$SCAN_DIR" "" "$FAIL_ON")
+    if [[ "$(jq '.total' <<< "$failing")" -gt 0 ]]; then
+        echo "Failing: $(jq -r '[.counts[] | "\(.count) \(.severity)"] | join(", ")' <<< "$failing") (--fail-on $FAIL_ON)"
+        exit 1
+    fi
+fi
+exit 0
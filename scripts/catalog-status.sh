@@ -12,6 +12,7 @@ set -euo pipefail
 
 SCRIPT_DIR="$(cd "$(dirname "${BASH_SOURCE[0]}")" && pwd)"
 source "$SCRIPT_DIR/lib/catalog-utils.sh"
+source "$SCRIPT_DIR/lib/finding-utils.sh"
 
 usage() {
     cat << EOF
@@ -155,6 +156,15 @@ if [[ -n "$SPECIFIC_ORG" ]]; then
         echo "  Run: ./scripts/catalog-scan.sh $SPECIFIC_ORG"
     fi
     echo ""
+
+    # Disclosure deadlines
+    DISCLOSURES_FILE=$(disclosures_file "$SPECIFIC_ORG")
+    if [[ -f "$DISCLOSURES_FILE" ]]; then
+        open_count=$(jq '[.disclosures[]? | select(.status != "published" and .status != "closed")] | length' "$DISCLOSURES_FILE")
+        echo "Disclosures: $open_count open"
+        disclosure_reminders "$DISCLOSURES_FILE" | jq -r '.[] | "  \(.id)  \(.event) \(.date): \(.title)"'
+        echo ""
+    fi
     exit 0
 fi
 
@@ -281,4 +291,19 @@ if [[ "$stale" -gt 0 || "$never" -gt 0 ]]; then
     fi
 fi
 
+# Disclosure deadlines overdue or due within a week, across all orgs
+deadlines=""
+for org in $(list_tracked_orgs); do
+    reminders=$(disclosure_reminders "$(disclosures_file "$org")")
+    [[ "$reminders" == "[]" ]] && continue
+    deadlines+=$(jq -r --arg org "$org" '.[] | "  \($org) \(.id)  \(.event) \(.date): \(.title)"' <<< "$reminders")$'\n'
+done
+if [[ -n "$deadlines" ]]; then
+    echo ""
+    echo "----------------------------------------"
+    echo "Disclosure deadlines:"
+    echo ""
+    printf '%s' "$deadlines"
+fi
+
 echo ""
@@ -52,6 +52,8 @@ shift
 # Source catalog utilities
 SCRIPT_DIR="$(cd "$(dirname "${BASH_SOURCE[0]}")" && pwd)"
 source "$SCRIPT_DIR/lib/catalog-utils.sh"
+source "$SCRIPT_DIR/lib/trace-utils.sh"
+source "$SCRIPT_DIR/lib/usage-utils.sh"
 
 # Set clone directory and resolve GitHub org name(s)
 if [[ -n "$STANDALONE_MODE" ]]; then
@@ -122,6 +124,11 @@ if ! gh auth status &> /dev/null; then
     exit 1
 fi
 
+# Metered in catalog mode (usage-utils.sh): wall-clock time, and one API
+# call per GitHub repo listing
+CLONE_STARTED=$(date +%s)
+API_CALLS=0
+
 # Check for GNU parallel and determine cloning mode
 USE_PARALLEL=""
 if [[ -z "$FORCE_SERIAL" ]] && command -v parallel &> /dev/null; then
@@ -142,24 +149,32 @@ clone_single_repo() {
     if [[ -d "$clone_dir/$name" ]]; then
         echo "[$name]$archived_tag Already exists, fetching updates..."
         if git -C "$clone_dir/$name" fetch --all 2>&1 | sed 's/^/  /'; then
+            init_submodules "$clone_dir/$name" "$clone_dir/.bountyhunterignore"
             echo "[$name] Done"
         else
             echo "[$name] Fetch failed"
             return 1
         fi
     else
+        # Clone under a hidden .partial name and rename when complete, so an
+        # interrupted clone never looks like a finished repo
+        local partial_dir="$clone_dir/.$name.partial"
+        rm -rf "$partial_dir"
         echo "[$name]$archived_tag Cloning..."
-        if git clone --quiet "$url" "$clone_dir/$name" 2>&1 | sed 's/^/  /'; then
+        if git clone --quiet "$url" "$partial_dir" 2>&1 | sed 's/^/  /' && [[ -d "$partial_dir/.git" ]]; then
             echo "[$name] Fetching all branches..."
-            git -C "$clone_dir/$name" fetch --all --quiet 2>&1 | sed 's/^/  /'
+            git -C "$partial_dir" fetch --all --quiet 2>&1 | sed 's/^/  /'
+            init_submodules "$partial_dir" "$clone_dir/.bountyhunterignore"
+            mv "$partial_dir" "$clone_dir/$name"
             echo "[$name] Done"
         else
+            rm -rf "$partial_dir"
             echo "[$name] Failed to clone"
             return 1
         fi
     fi
 }
-export -f clone_single_repo
+export -f clone_single_repo init_submodules
 
 if [[ ${#SPECIFIC_REPOS[@]} -gt 0 ]]; then
     # Clone only specified repositories
@@ -193,7 +208,8 @@ else
     TOTAL_FORKS=0
     for github_org in "${GITHUB_ORGS[@]}"; do
         echo "  Fetching from: $github_org"
-        ORG_REPOS=$(gh repo list "$github_org" --visibility=public --limit 500 --json name,url,isFork,isArchived 2>&1) || {
+        API_CALLS=$((API_CALLS + 1))
+        ORG_REPOS=$(gh repo list "$github_org" --visibility=public --limit 500 --json name,url,isFork,isArchived,visibility,repositoryTopics 2>&1) || {
             echo "  Warning: Failed to fetch repositories for '$github_org'"
             echo "  $ORG_REPOS"
             continue
@@ -256,6 +272,15 @@ echo "Cloning to: ./$CLONE_DIR"
 echo ""
 
 mkdir -p "$CLONE_DIR"
+
+# Remove partial clones (from an interrupted run, or this one on exit).
+# On INT/TERM the clone jobs are stopped first.
+remove_partial_clones() {
+    find "$CLONE_DIR" -maxdepth 1 -mindepth 1 -type d -name ".*.partial" -exec rm -rf {} + 2>/dev/null || true
+}
+remove_partial_clones
+install_cancel_traps
+trap 'run_cleanup; remove_partial_clones' EXIT
 mkdir -p "scans/$ORG/"{semgrep-results,trufflehog-results,artifact-results,kics-results,inventory}
 
 # Create/update archived repos manifest
@@ -274,7 +299,18 @@ else
     rm -f "$ARCHIVED_MANIFEST"
 fi
 
+# Tag manifest for rule applicability (metadata.applies-when.tags): GitHub
+# visibility, topics, and "archived"; only known when repos were listed
+if [[ ${#SPECIFIC_REPOS[@]} -eq 0 ]]; then
+    echo "$ALL_REPOS" | jq -r '.[] | select(.isFork == false) | [.name,
+        ((.visibility // "") | ascii_downcase),
+        ((.repositoryTopics // [])[] | .name? // .),
+        (if .isArchived then "archived" else empty end)]
+        | map(select(. != "")) | join(" ")' > "$(get_repo_tags_manifest "$CLONE_DIR")"
+fi
+
 # Clone repositories - parallel or serial
+trace_span_start "clone" org="$ORG" repos="$REPO_COUNT"
 if [[ -n "$USE_PARALLEL" ]]; then
     # For large repos, reduce parallelism to avoid file handle exhaustion
     if [[ "$REPO_COUNT" -gt 200 ]]; then
@@ -312,6 +348,10 @@ else
 fi
 
 TOTAL_CLONED=$(ls -d "$CLONE_DIR"/*/ 2>/dev/null | wc -l | xargs)
+trace_span_end ok cloned="$TOTAL_CLONED"
+if [[ -z "$STANDALONE_MODE" ]]; then
+    usage_record "$ORG" clone "$(($(date +%s) - CLONE_STARTED))" "$API_CALLS" "{\"repos\": $TOTAL_CLONED}"
+fi
 ARCHIVED_CLONED=0
 if [[ -f "$ARCHIVED_MANIFEST" ]]; then
     ARCHIVED_CLONED=$(wc -l < "$ARCHIVED_MANIFEST" | xargs)
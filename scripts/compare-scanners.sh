@@ -0,0 +1,248 @@
+#!/usr/bin/env bash
+# Compare semgrep findings with another scanner's SARIF output
+#
+# Usage: ./scripts/compare-scanners.sh import <org> <repo> <sarif-file>
+#        ./scripts/compare-scanners.sh compare <org> <repo> [options]
+#
+# Measures what the custom rule packs add and where they lag: findings are
+# matched by file, line (within a tolerance), and CWE when both sides name
+# one, then reported as shared or unique to each tool.
+#
+# Examples:
+#   ./scripts/compare-scanners.sh import acme-corp api codeql-results.sarif
+#   ./scripts/compare-scanners.sh import acme-corp api gosec.sarif
+#   ./scripts/compare-scanners.sh compare acme-corp api               # Every imported tool
+#   ./scripts/compare-scanners.sh compare acme-corp api --tool codeql --format list
+
+set -euo pipefail
+
+SCRIPT_DIR="$(cd "$(dirname "${BASH_SOURCE[0]}")" && pwd)"
+source "$SCRIPT_DIR/lib/catalog-utils.sh"
+source "$SCRIPT_DIR/lib/finding-utils.sh"
+
+usage() {
+    cat << EOF
+Usage: $0 import <org> <repo> <sarif-file> [options]
+       $0 compare <org> <repo> [options]
+
+Import SARIF results from another scanner (CodeQL, gosec, Semgrep CLI, ...)
+and compare them with this toolkit's semgrep results for the same repo.
+
+Commands:
+    import      Normalize a SARIF file and store it for the repo
+                (scans/<org>/external-results/<repo>/<tool>.json)
+    compare     Compare semgrep results (scans/<org>/semgrep-results/<repo>)
+                with each imported tool
+
+Options:
+    --output-dir <path>  Scan output directory (default: scans/<org>)
+    --tool <name>        Compare with one imported tool only
+    --tolerance <n>      Lines two findings may differ by and still match (default: 3)
+    --format <fmt>       summary (default), list (every unique finding), or json
+    -h, --help           Show this help message
+
+Findings match when the paths agree, the lines are within the tolerance,
+and the CWEs overlap (when both findings name any). Run scan-semgrep.sh on
+the same commit the other tool scanned, or line drift will show up as
+unique findings on both sides.
+EOF
+    exit 1
+}
+
+[[ $# -lt 1 ]] && usage
+COMMAND="$1"
+shift
+
+ORG=""
+REPO=""
+SARIF_FILE=""
+OUTPUT_DIR=""
+TOOL_FILTER=""
+TOLERANCE=3
+FORMAT="summary"
+
+while [[ $# -gt 0 ]]; do
+    case "$1" in
+        --output-dir)
+            OUTPUT_DIR="$2"
+            shift 2
+            ;;
+        --tool)
+            TOOL_FILTER="$2"
+            shift 2
+            ;;
+        --tolerance)
+            TOLERANCE="$2"
+            shift 2
+            ;;
+        --format)
+            FORMAT="$2"
+            shift 2
+            ;;
+        -h|--help)
+            usage
+            ;;
+        -*)
+            echo "Unknown option: $1"
+            usage
+            ;;
+        *)
+            if [[ -z "$ORG" ]]; then
+                ORG="$1"
+            elif [[ -z "$REPO" ]]; then
+                REPO="$1"
+            elif [[ "$COMMAND" == "import" && -z "$SARIF_FILE" ]]; then
+                SARIF_FILE="$1"
+            else
+                echo "Too many arguments"
+                usage
+            fi
+            shift
+            ;;
+    esac
+done
+
+[[ -z "$ORG" || -z "$REPO" ]] && usage
+validate_org_name "$ORG" || exit 1
+require_jq || exit 1
+
+if [[ ! "$TOLERANCE" =~ ^[0-9]+$ ]]; then
+    echo "Error: --tolerance must be a number of lines"
+    exit 1
+fi
+
+OUTPUT_DIR="${OUTPUT_DIR:-scans/$ORG}"
+EXTERNAL_DIR="$OUTPUT_DIR/external-results/$REPO"
+
+# =============================================================================
+# import
+# =============================================================================
+
+if [[ "$COMMAND" == "import" ]]; then
+    [[ -z "$SARIF_FILE" ]] && usage
+    if [[ ! -f "$SARIF_FILE" ]]; then
+        echo "Error: SARIF file not found: $SARIF_FILE"
+        exit 1
+    fi
+    if ! jq -e '.runs | type == "array"' "$SARIF_FILE" &> /dev/null; then
+        echo "Error: Not a SARIF log (no runs array): $SARIF_FILE"
+        exit 1
+    fi
+
+    mkdir -p "$EXTERNAL_DIR"
+    tmp=$(mktemp)
+    sarif_to_findings "$SARIF_FILE" > "$tmp"
+
+    # One file per tool; a SARIF log with several runs may hold several tools
+    while IFS= read -r tool; do
+        [[ -z "$tool" ]] && continue
+        if [[ "$tool" == "semgrep" ]]; then
+            # Keep the imported CLI run apart from this toolkit's results
+            jq --arg t "$tool" 'map(select(.tool == $t) | .tool = "semgrep-sarif")' "$tmp" > "$EXTERNAL_DIR/semgrep-sarif.json"
+            tool="semgrep-sarif"
+        else
+            jq --arg t "$tool" 'map(select(.tool == $t))' "$tmp" > "$EXTERNAL_DIR/$tool.json"
+        fi
+        echo "Imported $(jq length "$EXTERNAL_DIR/$tool.json") $tool findings for $ORG/$REPO"
+    done < <(jq -r 'map(.tool) | unique | .[]' "$tmp")
+
+    if [[ "$(jq length "$tmp")" -eq 0 ]]; then
+        echo "No results in $SARIF_FILE"
+    fi
+    rm -f "$tmp"
+    exit 0
+fi
+
+if [[ "$COMMAND" != "compare" ]]; then
+    echo "Unknown command: $COMMAND"
+    usage
+fi
+
+# =============================================================================
+# compare
+# =============================================================================
+
+SEMGREP_FILE=""
+for candidate in "$OUTPUT_DIR/semgrep-results/$REPO.json.gz" "$OUTPUT_DIR/semgrep-results/$REPO.json"; do
+    if [[ -f "$candidate" ]]; then
+        SEMGREP_FILE="$candidate"
+        break
+    fi
+done
+if [[ -z "$SEMGREP_FILE" ]]; then
+    echo "Error: No semgrep results for $REPO in $OUTPUT_DIR/semgrep-results/"
+    echo "Run: ./scripts/scan-semgrep.sh $ORG"
+    exit 1
+fi
+
+TOOL_FILES=()
+if [[ -n "$TOOL_FILTER" ]]; then
+    if [[ ! -f "$EXTERNAL_DIR/$TOOL_FILTER.json" ]]; then
+        echo "Error: No imported results for '$TOOL_FILTER' (import a SARIF file first)"
+        exit 1
+    fi
+    TOOL_FILES=("$EXTERNAL_DIR/$TOOL_FILTER.json")
+else
+    shopt -s nullglob
+    TOOL_FILES=("$EXTERNAL_DIR"/*.json)
+    shopt -u nullglob
+fi
+if [[ ${#TOOL_FILES[@]} -eq 0 ]]; then
+    echo "Error: No imported results for $ORG/$REPO"
+    echo "Run: $0 import $ORG $REPO <sarif-file>"
+    exit 1
+fi
+
+OURS=$(mktemp)
+trap 'rm -f "$OURS"' EXIT
+semgrep_to_findings "$SEMGREP_FILE" "$REPO" > "$OURS"
+
+# Where a semgrep finding's rule comes from: the custom pack it lives in,
+# or the registry
+RULE_SOURCE='(if (.rule | startswith("custom-rules.")) then (.rule | split(".")[0:2] | join("/")) else "registry" end)'
+
+REPORTS="[]"
+for tool_file in "${TOOL_FILES[@]}"; do
+    tool=$(basename "$tool_file" .json)
+    report=$(compare_findings "$OURS" "$tool_file" "$TOLERANCE" | jq --arg tool "$tool" '. + {tool: $tool}')
+    REPORTS=$(jq -n --argjson all "$REPORTS" --argjson r "$report" '$all + [$r]')
+done
+
+case "$FORMAT" in
+    json)
+        jq -n --argjson reports "$REPORTS" --arg org "$ORG" --arg repo "$REPO" --argjson tol "$TOLERANCE" \
+            '{org: $org, repo: $repo, tolerance: $tol, comparisons: $reports}'
+        ;;
+    summary|list)
+        jq -r -n --argjson reports "$REPORTS" --arg org "$ORG" --arg repo "$REPO" \
+            --argjson tol "$TOLERANCE" --arg format "$FORMAT" '
+            def pad($n): tostring | . + (" " * ([$n - length, 1] | max));
+            def counts: group_by(.) | map({k: .[0], n: length}) | sort_by(-.n) | .[] | "  \(.k | pad(40))\(.n)";
+            $reports[] |
+            "\($org)/\($repo): semgrep vs \(.tool) (±\($tol) lines)",
+            "  \("Shared:" | pad(20))\(.shared | length)",
+            "  \("Only semgrep:" | pad(20))\(.only_a | length)",
+            "  \("Only \(.tool):" | pad(20))\(.only_b | length)",
+            "",
+            (if (.only_a | length) > 0 then
+                "Only semgrep, by rule source:",
+                ([.only_a[] | '"$RULE_SOURCE"'] | counts),
+                ""
+             else empty end),
+            (if (.only_b | length) > 0 then
+                "Only \(.tool), by rule:",
+                ([.only_b[].rule] | counts),
+                ""
+             else empty end),
+            (if $format == "list" then
+                (.only_a[] | "  semgrep  \(.rule)  \(.path):\(.line)"),
+                (.tool as $t | .only_b[] | "  \($t)  \(.rule)  \(.path):\(.line)"),
+                ""
+             else empty end)
+        '
+        ;;
+    *)
+        echo "Unknown format: $FORMAT (use summary, list, or json)"
+        exit 1
+        ;;
+esac
@@ -0,0 +1,200 @@
+#!/usr/bin/env bash
+# Map semgrep findings in handler code to the live URLs that reach them
+#
+# Usage: ./scripts/correlate-endpoints.sh <org> [repo] [options]
+#
+# Each finding in an HTTP handler file, or in a file a handler imports, is
+# linked to the handler's route from scans/<org>/surfaces.json and given a
+# best-guess URL: a request seen in proxy history (import-traffic.sh) when
+# there is one, otherwise the route on a recon or target base URL. The
+# result is stored as extra.endpoint in the repo's semgrep results, so
+# extract-semgrep-findings.sh reports show the URL next to each finding.
+#
+# Examples:
+#   ./scripts/correlate-endpoints.sh acme-corp
+#   ./scripts/correlate-endpoints.sh acme-corp api --base-url https://staging.acme.com
+#   ./scripts/correlate-endpoints.sh acme-corp --format tsv
+
+set -euo pipefail
+
+SCRIPT_DIR="$(cd "$(dirname "${BASH_SOURCE[0]}")" && pwd)"
+source "$SCRIPT_DIR/lib/catalog-utils.sh"
+source "$SCRIPT_DIR/lib/rule-utils.sh"
+source "$SCRIPT_DIR/lib/surface-utils.sh"
+source "$SCRIPT_DIR/lib/severity-utils.sh"
+
+usage() {
+    cat << EOF
+Usage: $0 <org> [repo] [options]
+
+Attach the best-guess live URL to each semgrep finding in handler code.
+
+Options:
+    --base-url <url>    Base URL tried before recon results (repeatable)
+    --format <fmt>      summary (default), json, or tsv
+    -h, --help          Show this help message
+
+URL sources, most trusted first:
+    observed    A request for the route in proxy history (import-traffic.sh)
+    recon       The route on a base URL: --base-url, hosts in proxy history,
+                dynamic-results/targets.txt, then httpx live hosts. A host
+                naming the repo (api -> api.acme.com) is preferred.
+    route       No base URL known: only the route is reported
+
+Run surfaces.sh first; re-run after new scans, traffic imports, or recon.
+
+Examples:
+    $0 acme-corp
+    $0 acme-corp api --base-url https://staging.acme.com
+EOF
+    exit 1
+}
+
+ORG=""
+ONLY_REPO=""
+BASE_URLS=()
+FORMAT="summary"
+
+while [[ $# -gt 0 ]]; do
+    case "$1" in
+        --base-url)
+            BASE_URLS+=("$2")
+            shift 2
+            ;;
+        --format)
+            FORMAT="$2"
+            shift 2
+            ;;
+        -h|--help)
+            usage
+            ;;
+        -*)
+            echo "Unknown option: $1"
+            usage
+            ;;
+        *)
+            if [[ -z "$ORG" ]]; then
+                ORG="$1"
+            elif [[ -z "$ONLY_REPO" ]]; then
+                ONLY_REPO="$1"
+            else
+                echo "Unexpected argument: $1"
+                usage
+            fi
+            shift
+            ;;
+    esac
+done
+
+[[ -z "$ORG" ]] && usage
+
+case "$FORMAT" in
+    summary|json|tsv) ;;
+    *)
+        echo "Error: Unknown format '$FORMAT' (use summary, json, or tsv)"
+        exit 1
+        ;;
+esac
+
+validate_org_name "$ORG" || exit 1
+require_jq || exit 1
+
+SCANS_DIR="scans/$ORG"
+RESULTS_DIR="$SCANS_DIR/semgrep-results"
+SURFACES_FILE="$SCANS_DIR/surfaces.json"
+STORE="$(get_org_catalog_dir "$ORG")/$OBSERVED_ENDPOINTS_FILE"
+REPOS_DIR="$(get_org_repos_dir "$ORG")"
+
+if [[ ! -f "$SURFACES_FILE" ]]; then
+    echo "Error: No entry point inventory at $SURFACES_FILE"
+    echo "Run: ./scripts/surfaces.sh $ORG"
+    exit 1
+fi
+if [[ ! -d "$RESULTS_DIR" ]]; then
+    echo "Error: No semgrep results in $RESULTS_DIR"
+    echo "Run: ./scripts/scan-semgrep.sh $ORG"
+    exit 1
+fi
+
+TMP_DIR=$(mktemp -d)
+register_cleanup "$TMP_DIR"
+install_cancel_traps
+
+BASES="$TMP_DIR/bases"
+{
+    for url in ${BASE_URLS[@]+"${BASE_URLS[@]}"}; do
+        echo "${url%/}"
+    done
+    endpoint_base_urls "$SCANS_DIR" "$STORE"
+} | awk '!seen[$0]++' > "$BASES"
+
+# =============================================================================
+# Correlation
+# =============================================================================
+
+ENDPOINTS="$TMP_DIR/endpoints.jsonl"
+: > "$ENDPOINTS"
+
+shopt -s nullglob
+for results in "$RESULTS_DIR"/*.json.gz "$RESULTS_DIR"/*.json; do
+    name=$(basename "$results")
+    name="${name%.gz}"
+    name="${name%.json}"
+    [[ -n "$ONLY_REPO" && "$name" != "$ONLY_REPO" ]] && continue
+
+    imports="$TMP_DIR/$name.imports"
+    : > "$imports"
+    [[ -d "$REPOS_DIR/$name" ]] && handler_import_map "$REPOS_DIR/$name" "$SURFACES_FILE" "$name" > "$imports"
+
+    work="$TMP_DIR/$name.json"
+    if [[ "$results" == *.gz ]]; then
+        gzip -dc "$results" > "$work"
+    else
+        cp "$results" "$work"
+    fi
+
+    annotate_finding_endpoints "$work" "$name" "$SURFACES_FILE" "$STORE" "$BASES" "$imports" > /dev/null
+
+    if [[ "$results" == *.gz ]]; then
+        gzip -c "$work" > "$results"
+    else
+        cp "$work" "$results"
+    fi
+
+    jq -c --arg repo "$name" '.results[] | select(.extra.endpoint)
+        | {repo: $repo, check_id, path, line: .start.line, severity: .extra.severity} + .extra.endpoint' \
+        "$work" >> "$ENDPOINTS"
+done
+shopt -u nullglob
+
+# =============================================================================
+# Output
+# =============================================================================
+
+case "$FORMAT" in
+    json)
+        jq -s '.' "$ENDPOINTS"
+        ;;
+    tsv)
+        jq -r '[.repo, .severity, .check_id, "\(.path):\(.line)", .method, (.url // .route), .source] | @tsv' "$ENDPOINTS"
+        ;;
+    summary)
+        total=$(grep -c . "$ENDPOINTS" || true)
+        if [[ "$total" -eq 0 ]]; then
+            echo "No findings in handler code for $ORG${ONLY_REPO:+/$ONLY_REPO}"
+            exit 0
+        fi
+        jq -r -s "$SEVERITY_JQ_DEFS"'
+            def pad($n): tostring | . + (" " * ([$n - length, 1] | max));
+            sort_by((.severity | severity_level("semgrep") | severity_rank), .repo, .path, .line)[]
+            | "  \(.severity | pad(8)) \("\(.path):\(.line)" | pad(45)) \(.method) \(.url // .route)  [\(.source)]"
+        ' "$ENDPOINTS"
+        echo ""
+        jq -r -s '
+            "\(length) findings linked to endpoints: "
+            + (group_by(.source) | map("\(length) \(.[0].source)") | join(", "))
+        ' "$ENDPOINTS"
+        [[ ! -s "$BASES" ]] && echo "No base URLs known: add targets (advanced/recon-targets.sh) or pass --base-url"
+        echo "Reports: ./scripts/extract-semgrep-findings.sh $ORG"
+        ;;
+esac
@@ -0,0 +1,301 @@
+#!/usr/bin/env bash
+# Trace service-to-service flows across an org's repositories
+#
+# Usage: ./scripts/cross-repo-flows.sh [options] <org>
+#
+# Builds an index of the API contracts each repo serves (OpenAPI specs and
+# protobuf services), finds the client calls to those contracts in the other
+# repos, and joins each caller -> handler edge with the semgrep findings in
+# the handler's files. A caller that reads request input and reaches a
+# handler with findings is a candidate for taint that crosses a repository
+# boundary, which single-repo scans cannot see.
+#
+# Calls the heuristics miss (generated clients, service meshes, queues) can
+# be declared in catalog/tracked/<org>/service-links.json:
+#   {"links": [{"from": {"repo": "web", "pattern": "billingClient\\.charge\\("},
+#               "to": {"repo": "billing", "operation": "createCharge"},
+#               "note": "Wrapped client in lib/billing.js"}]}
+#
+# Examples:
+#   ./scripts/cross-repo-flows.sh acme-corp
+#   ./scripts/cross-repo-flows.sh --repo billing acme-corp
+#   ./scripts/cross-repo-flows.sh --format json acme-corp
+
+set -euo pipefail
+
+SCRIPT_DIR="$(cd "$(dirname "${BASH_SOURCE[0]}")" && pwd)"
+source "$SCRIPT_DIR/lib/catalog-utils.sh"
+source "$SCRIPT_DIR/lib/contract-utils.sh"
+source "$SCRIPT_DIR/lib/finding-utils.sh"
+
+usage() {
+    cat << EOF
+Usage: $0 [options] <org>
+
+Find client calls from one repo to an API contract served by another, and
+the semgrep findings in the handlers they reach.
+
+Options:
+    --repo <name>       Only flows into this (serving) repo
+    --format <fmt>      summary (default), json, or contracts
+    --all               Include edges whose handlers have no findings
+    -h, --help          Show this help message
+
+Formats:
+    summary     Caller -> operation -> handler findings, riskiest first
+    json        Full flow records (also written to scans/<org>/cross-repo-flows.json)
+    contracts   The contract index: every operation each repo serves
+
+Findings come from scans/<org>/semgrep-results (run scan-semgrep.sh first).
+Declared links are read from catalog/tracked/<org>/service-links.json.
+
+Examples:
+    $0 acme-corp
+    $0 --repo billing --all acme-corp
+    $0 --format contracts acme-corp
+EOF
+    exit 1
+}
+
+ORG=""
+ONLY_REPO=""
+FORMAT="summary"
+INCLUDE_ALL=""
+
+while [[ $# -gt 0 ]]; do
+    case "$1" in
+        --repo)
+            ONLY_REPO="$2"
+            shift 2
+            ;;
+        --format)
+            FORMAT="$2"
+            shift 2
+            ;;
+        --all)
+            INCLUDE_ALL="1"
+            shift
+            ;;
+        -h|--help)
+            usage
+            ;;
+        -*)
+            echo "Unknown option: $1"
+            usage
+            ;;
+        *)
+            ORG="$1"
+            shift
+            ;;
+    esac
+done
+
+if [[ -z "$ORG" ]]; then
+    echo "Error: org name is required"
+    echo ""
+    usage
+fi
+
+case "$FORMAT" in
+    summary|json|contracts) ;;
+    *)
+        echo "Error: Unknown format '$FORMAT' (use summary, json, or contracts)"
+        exit 1
+        ;;
+esac
+
+validate_org_name "$ORG" || exit 1
+require_jq || exit 1
+
+REPOS_DIR="$(get_org_repos_dir "$ORG")"
+if [[ ! -d "$REPOS_DIR" ]]; then
+    echo "Error: No repos found at $REPOS_DIR"
+    echo "Run: ./scripts/clone-org-repos.sh $ORG"
+    exit 1
+fi
+
+RESULTS_DIR="scans/$ORG/semgrep-results"
+LINKS_FILE="$(get_org_catalog_dir "$ORG")/service-links.json"
+OUTPUT_FILE="scans/$ORG/cross-repo-flows.json"
+
+TMP_DIR=$(mktemp -d)
+register_cleanup "$TMP_DIR"
+install_cancel_traps
+
+# =============================================================================
+# Contract index
+# =============================================================================
+
+REPOS=()
+while IFS= read -r repo; do
+    [[ -n "$repo" ]] && REPOS+=("$repo")
+done < <(get_active_repos "$REPOS_DIR")
+
+CONTRACTS="$TMP_DIR/contracts.jsonl"
+: > "$CONTRACTS"
+for repo in ${REPOS[@]+"${REPOS[@]}"}; do
+    repo_contracts "$repo" >> "$CONTRACTS"
+done
+
+if [[ "$FORMAT" == "contracts" ]]; then
+    if [[ ! -s "$CONTRACTS" ]]; then
+        echo "No OpenAPI specs or proto services found in $REPOS_DIR"
+        exit 0
+    fi
+    printf "%-20s  %-7s  %-45s  %s\n" "REPO" "METHOD" "PATH" "OPERATION / SPEC"
+    jq -r '[.repo, .method, .path, ((if .operation != "" then .operation + " " else "" end) + "(" + .file + ")")] | @tsv' \
+        "$CONTRACTS" | sort | while IFS=$'\t' read -r repo method path detail; do
+            printf "%-20s  %-7s  %-45s  %s\n" "$repo" "$method" "$path" "$detail"
+        done
+    exit 0
+fi
+
+# =============================================================================
+# Edges: client call sites in other repos, plus declared links
+# =============================================================================
+
+# Findings for a repo, limited to the given files, as a JSON array
+# Args: $1 = repo name, $2 = newline-separated repo-relative files
+repo_findings_in() {
+    local name="$1"
+    local files="$2"
+    local results="$RESULTS_DIR/$name.json.gz"
+
+    if [[ -z "$files" || ! -f "$results" ]]; then
+        echo "[]"
+        return 0
+    fi
+    gzip -dc "$results" 2>/dev/null | jq -c --arg files "$files" '
+        ($files | split("\n") | map(select(. != ""))) as $targets
+        | [.results[]? | select(.path as $p | $targets | any(. as $t | $p == $t or ($p | endswith("/" + $t))))
+            | {rule: (.check_id | split(".") | last), severity: .extra.severity, path, line: .start.line,
+               taint: (.extra.dataflow_trace != null)}]
+    ' 2>/dev/null || echo "[]"
+}
+
+EDGES="$TMP_DIR/edges.jsonl"
+: > "$EDGES"
+
+# Record an edge: caller site in one repo calling a contract of another
+# Args: $1 = contract JSON, $2 = caller repo dir, $3 = file, $4 = line, $5 = source (detected|declared)
+add_edge() {
+    local contract="$1"
+    local caller_dir="$2"
+    local file="$3"
+    local line="$4"
+    local source="$5"
+    local user_input="false"
+
+    file_reads_request_input "$caller_dir/$file" && user_input="true"
+    jq -nc --argjson contract "$contract" --arg caller "$(basename "$caller_dir")" \
+        --arg file "$file" --arg line "$line" --arg source "$source" --argjson input "$user_input" \
+        '{contract: $contract, caller: {repo: $caller, path: $file, line: ($line | tonumber), reads_request_input: $input}, source: $source}' \
+        >> "$EDGES"
+}
+
+contract_count=$(grep -c . "$CONTRACTS" || true)
+echo "Indexed $contract_count operations across ${#REPOS[@]} repos" >&2
+
+while IFS= read -r contract; do
+    serving=$(echo "$contract" | jq -r '.repo')
+    [[ -n "$ONLY_REPO" && "$serving" != "$ONLY_REPO" ]] && continue
+    kind=$(echo "$contract" | jq -r '.kind')
+    path=$(echo "$contract" | jq -r '.path')
+    operation=$(echo "$contract" | jq -r '.operation')
+
+    for caller_dir in ${REPOS[@]+"${REPOS[@]}"}; do
+        [[ "$(basename "$caller_dir")" == "$serving" ]] && continue
+        while IFS=$'\t' read -r file line; do
+            [[ -z "$file" ]] && continue
+            add_edge "$contract" "$caller_dir" "$file" "$line" "detected"
+        done < <(contract_call_sites "$caller_dir" "$kind" "$path" "$operation")
+    done
+done < "$CONTRACTS"
+
+if [[ -f "$LINKS_FILE" ]]; then
+    while IFS=$'\t' read -r from_repo pattern to_repo operation; do
+        [[ -n "$ONLY_REPO" && "$to_repo" != "$ONLY_REPO" ]] && continue
+        if [[ ! -d "$REPOS_DIR/$from_repo" ]]; then
+            echo "Warning: service-links.json names unknown repo '$from_repo'" >&2
+            continue
+        fi
+        contract=$(jq -c --arg repo "$to_repo" --arg op "$operation" \
+            'select(.repo == $repo and (.operation == $op or .path == $op))' "$CONTRACTS" | head -1)
+        if [[ -z "$contract" ]]; then
+            # Declared operations need not appear in a spec
+            contract=$(jq -nc --arg repo "$to_repo" --arg op "$operation" \
+                '{repo: $repo, kind: (if ($op | startswith("/")) then "openapi" else "grpc" end),
+                  method: "", path: (if ($op | startswith("/")) then $op else "" end), operation: $op, file: ""}')
+        fi
+        while IFS=: read -r file line _; do
+            [[ -z "$file" ]] && continue
+            add_edge "$contract" "$REPOS_DIR/$from_repo" "$file" "$line" "declared"
+        done < <(git -C "$REPOS_DIR/$from_repo" grep -n -E -e "$pattern" 2>/dev/null || true)
+    done < <(jq -r '.links[]? | [.from.repo, .from.pattern, .to.repo, .to.operation] | join("\t")' "$LINKS_FILE")
+fi
+
+# =============================================================================
+# Join with handler findings
+# =============================================================================
+
+[[ -d "$RESULTS_DIR" ]] || echo "Warning: No semgrep results in $RESULTS_DIR; run scan-semgrep.sh $ORG for handler findings" >&2
+
+FLOWS="$TMP_DIR/flows.jsonl"
+: > "$FLOWS"
+HANDLERS_CACHE="$TMP_DIR/handlers"
+mkdir -p "$HANDLERS_CACHE"
+
+while IFS= read -r edge; do
+    serving=$(echo "$edge" | jq -r '.contract.repo')
+    kind=$(echo "$edge" | jq -r '.contract.kind')
+    path=$(echo "$edge" | jq -r '.contract.path')
+    operation=$(echo "$edge" | jq -r '.contract.operation')
+
+    # Handler lookups repeat for every caller of an operation
+    key=$(printf '%s\n%s\n%s' "$serving" "$path" "$operation" | sha256_hex | cut -c1-16)
+    if [[ ! -f "$HANDLERS_CACHE/$key" ]]; then
+        handler_files=""
+        [[ -d "$REPOS_DIR/$serving" ]] && handler_files=$(contract_handler_files "$REPOS_DIR/$serving" "$kind" "$path" "$operation")
+        jq -nc --arg files "$handler_files" --argjson findings "$(repo_findings_in "$serving" "$handler_files")" \
+            '{files: ($files | split("\n") | map(select(. != ""))), findings: $findings}' > "$HANDLERS_CACHE/$key"
+    fi
+
+    jq -c --slurpfile handler "$HANDLERS_CACHE/$key" \
+        '. + {handler: $handler[0]}' <<< "$edge" >> "$FLOWS"
+done < "$EDGES"
+
+mkdir -p "$(dirname "$OUTPUT_FILE")"
+jq -s --arg org "$ORG" '{
+    org: $org,
+    flows: (map(. + {risk: ((if .caller.reads_request_input then 2 else 0 end)
+                          + ([.handler.findings[] | select(.taint)] | length) * 2
+                          + (.handler.findings | length))})
+            | sort_by(-.risk))
+}' "$FLOWS" > "$OUTPUT_FILE"
+
+if [[ "$FORMAT" == "json" ]]; then
+    cat "$OUTPUT_FILE"
+    exit 0
+fi
+
+edge_count=$(jq '.flows | length' "$OUTPUT_FILE")
+if [[ "$edge_count" -eq 0 ]]; then
+    echo "No cross-repo calls found for $ORG"
+    [[ -f "$LINKS_FILE" ]] || echo "Declare calls the heuristics miss in $LINKS_FILE"
+    exit 0
+fi
+
+echo "Cross-repo flows for $ORG ($edge_count call sites)"
+echo ""
+jq -r --arg all "$INCLUDE_ALL" '
+    .flows[] | select($all != "" or (.handler.findings | length) > 0)
+    | "\(.caller.repo)/\(.caller.path):\(.caller.line)\(if .caller.reads_request_input then " [request input]" else "" end)\(if .source == "declared" then " [declared]" else "" end)\n"
+      + "  -> \(.contract.repo) \(if .contract.method != "" then .contract.method + " " else "" end)\(if .contract.path != "" then .contract.path else .contract.operation end)\(if .contract.operation != "" and .contract.path != "" then " (" + .contract.operation + ")" else "" end)\n"
+      + (if (.handler.findings | length) == 0 then "     no findings in \(.handler.files | length) handler files\n"
+         else (.handler.findings | map("     [\(.severity)] \(.rule) \(.path):\(.line)\(if .taint then " (taint)" else "" end)") | join("\n")) + "\n" end)
+' "$OUTPUT_FILE"
+
+with_findings=$(jq '[.flows[] | select((.handler.findings | length) > 0)] | length' "$OUTPUT_FILE")
+echo "$with_findings of $edge_count call sites reach handlers with findings"
+[[ -z "$INCLUDE_ALL" && "$with_findings" -lt "$edge_count" ]] && echo "Use --all to list the rest."
+echo "Full records: $OUTPUT_FILE"
@@ -0,0 +1,301 @@
+#!/usr/bin/env bash
+# Draft a CVE request for a finding in an open-source target
+#
+# Usage: ./scripts/cve-draft.sh <org> <finding-id> [options]
+#
+# Fills in the fields of the MITRE CVE request form (cveform.mitre.org),
+# which most CNAs ask for too, from the finding: vulnerability type and
+# impact from its CWE, the affected component and a permalink, affected
+# versions from release-scan.sh, and vendor acknowledgment from
+# disclosure.sh. The draft is Markdown to review and paste into the form;
+# fields that can't be derived are marked TODO.
+#
+# Examples:
+#   ./scripts/cve-draft.sh acme-corp 3f9a1c2b
+#   ./scripts/cve-draft.sh acme-corp api/app/db.py:42 --discoverer "Jane Doe" --attack-type remote
+#   ./scripts/cve-draft.sh acme-corp 3f9a1c2b --format json
+
+set -euo pipefail
+
+SCRIPT_DIR="$(cd "$(dirname "${BASH_SOURCE[0]}")" && pwd)"
+source "$SCRIPT_DIR/lib/catalog-utils.sh"
+source "$SCRIPT_DIR/lib/finding-utils.sh"
+source "$SCRIPT_DIR/lib/history-utils.sh"
+source "$SCRIPT_DIR/lib/snippet-utils.sh"
+
+usage() {
+    cat << EOF
+Usage: $0 <org> <finding-id> [options]
+
+Generate a CVE request draft (MITRE/CNA form fields) for a finding.
+
+Options:
+    --vendor <name>         Vendor (default: the org's github_org, or the org)
+    --product <name>        Product (default: the repo name)
+    --versions <text>       Affected versions (default: from release-scan.sh results)
+    --attack-type <type>    remote, local, physical, or context-dependent (default: remote)
+    --impact <impact>       code-execution, denial-of-service, escalation-of-privileges,
+                            information-disclosure, or other (default: from the CWE)
+    --discoverer <name>     Credit line (default: \$CVE_DISCOVERER, or git user.name)
+    --reference <url>       Extra reference URL (repeatable)
+    --output <file>         File to write
+                            (default: findings/<org>/reports/cve-<id>.md)
+    --format <fmt>          markdown (default) or json
+    -h, --help              Show this help message
+
+Finding ids are the fingerprints listed by: ./scripts/variants.sh <org> --list
+A <repo>/<path>:<line> location works as an id too.
+
+Examples:
+    $0 acme-corp 3f9a1c2b
+    $0 acme-corp api/app/db.py:42 --discoverer "Jane Doe" --reference https://github.com/acme/api/issues/12
+EOF
+    exit 1
+}
+
+ORG=""
+FINDING_ID=""
+VENDOR=""
+PRODUCT=""
+VERSIONS=""
+ATTACK_TYPE="remote"
+IMPACT=""
+DISCOVERER="${CVE_DISCOVERER:-}"
+REFERENCES=()
+OUTPUT=""
+FORMAT="markdown"
+
+while [[ $# -gt 0 ]]; do
+    case "$1" in
+        --vendor)
+            VENDOR="$2"
+            shift 2
+            ;;
+        --product)
+            PRODUCT="$2"
+            shift 2
+            ;;
+        --versions)
+            VERSIONS="$2"
+            shift 2
+            ;;
+        --attack-type)
+            ATTACK_TYPE="$2"
+            shift 2
+            ;;
+        --impact)
+            IMPACT="$2"
+            shift 2
+            ;;
+        --discoverer)
+            DISCOVERER="$2"
+            shift 2
+            ;;
+        --reference)
+            REFERENCES+=("$2")
+            shift 2
+            ;;
+        --output)
+            OUTPUT="$2"
+            shift 2
+            ;;
+        --format)
+            FORMAT="$2"
+            shift 2
+            ;;
+        -h|--help)
+            usage
+            ;;
+        -*)
+            echo "Unknown option: $1"
+            usage
+            ;;
+        *)
+            if [[ -z "$ORG" ]]; then
+                ORG="$1"
+            elif [[ -z "$FINDING_ID" ]]; then
+                FINDING_ID="$1"
+            else
+                echo "Unexpected argument: $1"
+                usage
+            fi
+            shift
+            ;;
+    esac
+done
+
+[[ -z "$ORG" || -z "$FINDING_ID" ]] && usage
+
+case "$FORMAT" in
+    markdown|json) ;;
+    *)
+        echo "Error: Unknown format '$FORMAT' (use markdown or json)"
+        exit 1
+        ;;
+esac
+
+case "$ATTACK_TYPE" in
+    remote|local|physical|context-dependent) ;;
+    *)
+        echo "Error: Unknown attack type '$ATTACK_TYPE' (use remote, local, physical, or context-dependent)"
+        exit 1
+        ;;
+esac
+
+case "$IMPACT" in
+    ""|code-execution|denial-of-service|escalation-of-privileges|information-disclosure|other) ;;
+    *)
+        echo "Error: Unknown impact '$IMPACT' (use code-execution, denial-of-service, escalation-of-privileges, information-disclosure, or other)"
+        exit 1
+        ;;
+esac
+
+validate_org_name "$ORG" || exit 1
+require_jq || exit 1
+
+# =============================================================================
+# Finding
+# =============================================================================
+
+status=0
+FINDING=$(find_org_finding "$ORG" "$FINDING_ID") || status=$?
+if [[ "$status" -eq 2 ]]; then
+    echo "Error: '$FINDING_ID' matches more than one finding; use a longer id"
+    exit 1
+elif [[ "$status" -ne 0 ]]; then
+    echo "Error: No finding matches '$FINDING_ID'"
+    echo "List ids with: ./scripts/variants.sh $ORG --list"
+    exit 1
+fi
+
+ID=$(jq -r '.id' <<< "$FINDING")
+REPO_NAME=$(jq -r '.repo' <<< "$FINDING")
+REPO="$(get_org_repos_dir "$ORG")/$REPO_NAME"
+META_FILE="$CATALOG_ROOT/catalog/tracked/$ORG/meta.json"
+
+[[ -z "$PRODUCT" ]] && PRODUCT="$REPO_NAME"
+if [[ -z "$VENDOR" ]]; then
+    VENDOR=$(jq -r '.github_org // empty' "$META_FILE" 2>/dev/null || true)
+    VENDOR="${VENDOR:-$ORG}"
+fi
+[[ -z "$DISCOVERER" ]] && DISCOVERER=$(git config user.name 2>/dev/null || true)
+
+# CVEs are for publicly available code; say so when nothing shows a license
+if [[ -d "$REPO" ]] && ! ls "$REPO" 2>/dev/null | grep -qiE '^(license|licence|copying)'; then
+    echo "Warning: $REPO_NAME has no LICENSE or COPYING file; check it is open source before requesting a CVE" >&2
+fi
+
+# Affected versions from release-scan.sh, vendor acknowledgment from disclosure.sh
+AFFECTED="null"
+[[ -z "$VERSIONS" ]] && AFFECTED=$(finding_affected_versions "$ORG" "$FINDING")
+DISCLOSURE=$(finding_disclosure "$ORG" "$ID")
+
+WEB_URL=$(repo_web_url "$REPO" 2>/dev/null || true)
+
+# =============================================================================
+# Draft
+# =============================================================================
+
+DRAFT=$(jq -n \
+    --argjson f "$FINDING" --argjson affected "$AFFECTED" --argjson disclosure "$DISCLOSURE" \
+    --argjson snippet "$(finding_snippet "$ORG" "$FINDING")" \
+    --arg vendor "$VENDOR" --arg product "$PRODUCT" --arg versions "$VERSIONS" \
+    --arg attack_type "$ATTACK_TYPE" --arg impact "$IMPACT" --arg discoverer "$DISCOVERER" \
+    --arg web_url "$WEB_URL" --argjson extra_refs "$(printf '%s\n' ${REFERENCES[@]+"${REFERENCES[@]}"} | jq -R . | jq -s 'map(select(. != ""))')" '
+    def cwe_number: (.cwe[0] // "") | capture("CWE-(?<n>[0-9]+)").n // null;
+    def titlecase: split("-") | map((.[0:1] | ascii_upcase) + .[1:]) | join(" ");
+    ($f | cwe_number) as $cwe
+    | (if ($f.cwe[0] // "") | test(":") then ($f.cwe[0] | sub("^[^:]*:\\s*"; "")) else ($f.check_id | split(".") | last | gsub("-"; " ")) end) as $vuln_type
+    | (if $impact != "" then $impact
+       elif $cwe == null then "other"
+       elif ($cwe | IN("77", "78", "94", "95", "502", "917", "1336")) then "code-execution"
+       elif ($cwe | IN("22", "23", "89", "90", "200", "209", "312", "359", "532", "538", "611", "918")) then "information-disclosure"
+       elif ($cwe | IN("269", "284", "285", "287", "306", "639", "862", "863")) then "escalation-of-privileges"
+       elif ($cwe | IN("400", "770", "1333")) then "denial-of-service"
+       else "other" end) as $impact_value
+    | (if $versions != "" then $versions
+       elif $affected == null then "TODO (run: ./scripts/release-scan.sh <org> \($f.repo))"
+       else ($affected.ranges | map(
+                (if .from | startswith("<=") then "\(.to) and earlier"
+                 elif .from == .to then .from
+                 else "\(.from) through \(.to)" end)
+                + (if .fixed_in then " (fixed in \(.fixed_in))" else " (unfixed)" end)
+            ) | join("; "))
+       end) as $affected_versions
+    | (if $versions == "" and $affected == null then "TODO (affected versions)"
+       else $affected_versions | gsub(" \\((fixed in|unfixed)[^)]*\\)"; "") end) as $description_versions
+    | ($f.path + (if $f.line then ":\($f.line)" else "" end)) as $component
+    | {
+        vulnerability_type: $vuln_type,
+        cwe: (if $cwe then "CWE-\($cwe)" else null end),
+        vendor: $vendor,
+        product: $product,
+        affected_versions: $affected_versions,
+        vendor_acknowledged: (if $disclosure == null then "TODO"
+                              elif $disclosure.acknowledged or ($disclosure.status | IN("acknowledged", "fixed", "published")) then "Yes"
+                              else "No" end),
+        attack_type: ($attack_type | titlecase),
+        impact: ($impact_value | titlecase),
+        affected_component: $component,
+        attack_vector: $f.message,
+        suggested_description: "\($vuln_type) in \($component) in \($vendor) \($product) \($description_versions) allows \(if $attack_type == "remote" then "remote attackers" elif $attack_type == "local" then "local users" else "attackers" end) to TODO (describe the impact) via TODO (describe the input).",
+        discoverer: (if $discoverer == "" then "TODO" else $discoverer end),
+        references: ([$f.permalink // empty, (if $web_url != "" then $web_url else empty end)] + $f.references
+                     + ($f.remediation.references // []) + $extra_refs | unique),
+        remediation: $f.remediation,
+        finding: {id: $f.id, repo: $f.repo, path: $f.path, line: $f.line, check_id: $f.check_id, severity: $f.severity, code: $f.lines,
+                  snippet: $snippet, commit: $f.commit},
+        disclosure: (if $disclosure then {id: $disclosure.id, notified: $disclosure.notified, public_date: $disclosure.public_date} else null end)
+    }
+')
+
+if [[ "$FORMAT" == "json" ]]; then
+    echo "$DRAFT"
+    exit 0
+fi
+
+[[ -z "$OUTPUT" ]] && OUTPUT="$CATALOG_ROOT/findings/$ORG/reports/cve-${ID:0:8}.md"
+mkdir -p "$(dirname "$OUTPUT")"
+
+jq -r --arg code "$(jq '.finding.snippet' <<< "$DRAFT" | snippet_markdown)" "$REMEDIATION_JQ_DEFS"'
+    "# CVE Request Draft: \(.vulnerability_type) in \(.vendor) \(.product)",
+    "",
+    "Finding `\(.finding.id)` (\(.finding.check_id), \(.finding.severity))\(if .finding.commit then " at commit `" + .finding.commit + "`" else "" end).",
+    "Review every field before submitting: the suggested description needs the impact and input filled in.",
+    "",
+    "## Form Fields",
+    "",
+    "- **Vulnerability type:** \(.vulnerability_type)\(if .cwe then " (" + .cwe + ")" else "" end)",
+    "- **Vendor of the product(s):** \(.vendor)",
+    "- **Affected product(s)/code base:** \(.product)",
+    "- **Affected version(s):** \(.affected_versions)",
+    "- **Has vendor confirmed or acknowledged the vulnerability:** \(.vendor_acknowledged)",
+    "- **Attack type:** \(.attack_type)",
+    "- **Impact:** \(.impact)",
+    "- **Affected component(s):** `\(.affected_component)`",
+    "- **Attack vector(s):** \(.attack_vector)",
+    "- **Discoverer(s)/Credits:** \(.discoverer)",
+    "",
+    "## Suggested Description",
+    "",
+    .suggested_description,
+    "",
+    "## References",
+    "",
+    (if (.references | length) == 0 then "- TODO" else (.references[] | "- \(.)") end),
+    "",
+    "## Vulnerable Code",
+    "",
+    $code,
+    (if .remediation then "", "## Remediation", "", (.remediation | remediation_markdown) else empty end),
+    (if .disclosure then
+        "", "## Disclosure", "",
+        "Vendor notified \(.disclosure.notified // "TODO"); public disclosure planned for \(.disclosure.public_date // "TODO") (disclosure \(.disclosure.id))."
+     else empty end)
+' <<< "$DRAFT" > "$OUTPUT"
+
+echo "CVE request draft: $OUTPUT"
+todo=$(grep -c 'TODO' "$OUTPUT" || true)
+if [[ "$todo" -gt 0 ]]; then
+    echo "Lines still marked TODO: $todo"
+fi
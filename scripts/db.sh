@@ -0,0 +1,376 @@
+#!/usr/bin/env bash
+# Export and import the findings store
+#
+# Usage: ./scripts/db.sh <command> [options]
+#
+# `export` packs one or more orgs' stores (tracking data, scan history,
+# triage and its history, suppressions, disclosures, reports, and scanner
+# results) into a versioned archive with a manifest of file digests.
+# `import` verifies an archive and unpacks it into this catalog, merging
+# triage, suppressions, disclosures, and shared links entry by entry, so
+# stores from several machines can be combined. Cloned repos are not
+# included; clone them again after importing. The format is documented in
+# docs/store-archive-format.md. `migrate` copies triage between the
+# triage.json files and a PostgreSQL database (TRIAGE_BACKEND).
+#
+# Examples:
+#   ./scripts/db.sh export acme-corp
+#   ./scripts/db.sh export --all --output backup.tar.gz --no-results
+#   ./scripts/db.sh import laptop-2.tar.gz --dry-run
+#   ./scripts/db.sh inspect backup.tar.gz
+#   ./scripts/db.sh migrate --all --to postgres
+
+set -euo pipefail
+
+SCRIPT_DIR="$(cd "$(dirname "${BASH_SOURCE[0]}")" && pwd)"
+source "$SCRIPT_DIR/lib/catalog-utils.sh"
+source "$SCRIPT_DIR/lib/finding-utils.sh"
+source "$SCRIPT_DIR/lib/store-utils.sh"
+
+# Triage backend settings from .env
+if [[ -f "$CATALOG_ROOT/.env" ]]; then
+    set -a
+    # shellcheck disable=SC1091
+    source "$CATALOG_ROOT/.env"
+    set +a
+fi
+
+# Largest an archive may unpack to (bytes)
+STORE_IMPORT_MAX_SIZE="${STORE_IMPORT_MAX_SIZE:-10737418240}"
+
+usage() {
+    cat << EOF
+Usage: $0 <command> [options]
+
+Export and import the findings store.
+
+Commands:
+    export [org...]     Write an archive of the given orgs (or --all)
+    import <archive>    Verify an archive and merge it into this catalog
+    inspect <archive>   Show an archive's manifest and verify it
+    migrate [org...]    Copy triage dispositions between backends (--to, or --all)
+
+Options:
+    --all               export/migrate: every tracked org
+    --output <file>     export: archive path
+                        (default: bounty-hunter-store-<timestamp>.tar.gz)
+    --no-results        export: leave out scanner results (scans/<org>/)
+    --org <name>        import: only this org from the archive
+    --overwrite         import: replace local files that differ (merged
+                        files are still merged)
+    --to <backend>      migrate: postgres (from triage.json files) or file
+                        (from TRIAGE_DATABASE_URL)
+    --dry-run           import/migrate: show what would change
+    -h, --help          Show this help message
+
+On import, new files are added and identical ones skipped. triage.json,
+suppressions.json, disclosures.json, and shares.json are merged. Any other
+file that differs is kept as it is locally unless --overwrite is given.
+Orgs new to this catalog are added to catalog/index.json.
+
+Examples:
+    $0 export acme-corp other-org
+    $0 export --all --no-results --output backup.tar.gz
+    $0 import laptop-2.tar.gz --dry-run
+    $0 migrate --all --to postgres
+EOF
+    exit 1
+}
+
+COMMAND=""
+ARGS=()
+ALL=false
+OUTPUT=""
+NO_RESULTS=""
+ONLY_ORG=""
+OVERWRITE=false
+DRY_RUN=false
+TO=""
+
+while [[ $# -gt 0 ]]; do
+    case "$1" in
+        --all)
+            ALL=true
+            shift
+            ;;
+        --output)
+            OUTPUT="$2"
+            shift 2
+            ;;
+        --no-results)
+            NO_RESULTS="no-results"
+            shift
+            ;;
+        --org)
+            ONLY_ORG="$2"
+            shift 2
+            ;;
+        --to)
+            TO="$2"
+            shift 2
+            ;;
+        --overwrite)
+            OVERWRITE=true
+            shift
+            ;;
+        --dry-run)
+            DRY_RUN=true
+            shift
+            ;;
+        -h|--help)
+            usage
+            ;;
+        -*)
+            echo "Unknown option: $1"
+            usage
+            ;;
+        *)
+            if [[ -z "$COMMAND" ]]; then
+                COMMAND="$1"
+            else
+                ARGS+=("$1")
+            fi
+            shift
+            ;;
+    esac
+done
+
+[[ -z "$COMMAND" ]] && usage
+
+require_jq || exit 1
+
+TMP_DIR=$(mktemp -d)
+register_cleanup "$TMP_DIR"
+
+# Unpack and verify an archive into $TMP_DIR/archive
+open_archive() {
+    local archive="$1"
+    local size problems
+
+    if [[ ! -f "$archive" ]]; then
+        echo "Error: Archive not found: $archive"
+        exit 1
+    fi
+    size=$(wc -c < "$archive" | tr -d ' ')
+    mkdir -p "$TMP_DIR/archive"
+    if ! SAFE_EXTRACT_MAX_ARCHIVE_SIZE=$((size + 1)) SAFE_EXTRACT_MAX_EXTRACTED_SIZE="$STORE_IMPORT_MAX_SIZE" \
+        SAFE_EXTRACT_MAX_FILE_COUNT=1000000 \
+        "$SCRIPT_DIR/safe-extract-archive.sh" "$archive" "$TMP_DIR/archive" > /dev/null; then
+        echo "Error: Could not extract $archive"
+        exit 1
+    fi
+    if ! problems=$(store_verify "$TMP_DIR/archive"); then
+        echo "Error: $archive failed verification:"
+        echo "$problems" | sed 's/^/  /'
+        exit 1
+    fi
+}
+
+case "$COMMAND" in
+    export)
+        ensure_index_exists || exit 1
+        if [[ "$ALL" == true ]]; then
+            orgs=$(list_tracked_orgs)
+        else
+            orgs=$(printf '%s\n' ${ARGS[@]+"${ARGS[@]}"})
+        fi
+        if [[ -z "$orgs" ]]; then
+            echo "Error: export needs org names or --all"
+            exit 1
+        fi
+
+        mkdir -p "$TMP_DIR/archive/store"
+        org_entries="[]"
+        while IFS= read -r org; do
+            validate_org_name "$org" || exit 1
+            if [[ ! -d "$CATALOG_ROOT/catalog/tracked/$org" ]]; then
+                echo "Error: '$org' is not tracked"
+                exit 1
+            fi
+            dirs=$(store_org_dirs "$org" "$NO_RESULTS")
+            # shellcheck disable=SC2086
+            (cd "$CATALOG_ROOT" && tar -cf - $dirs) | (cd "$TMP_DIR/archive/store" && tar -xf -)
+            # Archives always carry triage as triage.json, whatever the backend
+            if [[ "$TRIAGE_BACKEND" == "postgres" ]]; then
+                triage=$(triage_load "$org")
+                if [[ "$triage" != "{}" ]]; then
+                    jq --sort-keys -n --argjson f "$triage" '{findings: $f}' \
+                        > "$TMP_DIR/archive/store/catalog/tracked/$org/triage.json"
+                fi
+            fi
+            org_entries=$(jq -c --arg org "$org" --slurpfile index "$CATALOG_INDEX" \
+                '. + [{name: $org, index: ($index[0].tracked_orgs | map(select(.name == $org)) | first)}]' \
+                <<< "$org_entries")
+        done <<< "$orgs"
+
+        files=$(store_file_list "$TMP_DIR/archive")
+        jq -n --arg format "$STORE_FORMAT" --argjson version "$STORE_FORMAT_VERSION" \
+            --arg created "$(date -u +%Y-%m-%dT%H:%M:%SZ)" --arg host "$(hostname 2>/dev/null || echo unknown)" \
+            --argjson results "$(if [[ -n "$NO_RESULTS" ]]; then echo false; else echo true; fi)" \
+            --argjson orgs "$org_entries" --argjson files "$files" \
+            '{format: $format, version: $version, created: $created, host: $host,
+              includes_results: $results, orgs: $orgs, files: $files}' > "$TMP_DIR/archive/manifest.json"
+
+        OUTPUT="${OUTPUT:-bounty-hunter-store-$(date +%Y%m%d-%H%M%S).tar.gz}"
+        tar -czf "$OUTPUT" -C "$TMP_DIR/archive" manifest.json store
+        echo "Exported $(grep -c . <<< "$orgs") orgs, $(jq 'length' <<< "$files") files to $OUTPUT"
+        ;;
+
+    inspect)
+        if [[ ${#ARGS[@]} -ne 1 ]]; then
+            echo "Error: inspect needs one archive"
+            exit 1
+        fi
+        open_archive "${ARGS[0]}"
+        jq -r '
+            "Format: \(.format) v\(.version)",
+            "Created: \(.created) on \(.host)",
+            "Scanner results: \(if .includes_results then "included" else "not included" end)",
+            "Orgs:",
+            (.orgs[] as $org
+             | "  \($org.name): \([.files[] | select(.path | test("^[^/]+(/tracked)?/" + $org.name + "/"))] | length) files"),
+            "Files: \(.files | length), \(([.files[].size] | add // 0) / 1048576 * 10 | floor / 10) MB"
+        ' "$TMP_DIR/archive/manifest.json"
+        echo "Verified: all file digests match"
+        ;;
+
+    import)
+        if [[ ${#ARGS[@]} -ne 1 ]]; then
+            echo "Error: import needs one archive"
+            exit 1
+        fi
+        ensure_index_exists || exit 1
+        open_archive "${ARGS[0]}"
+        MANIFEST="$TMP_DIR/archive/manifest.json"
+
+        if [[ -n "$ONLY_ORG" ]] && ! jq -e --arg org "$ONLY_ORG" '.orgs[] | select(.name == $org)' "$MANIFEST" > /dev/null; then
+            echo "Error: '$ONLY_ORG' is not in the archive"
+            exit 1
+        fi
+
+        added=0
+        unchanged=0
+        merged=0
+        replaced=0
+        kept=()
+
+        while IFS= read -r path; do
+            org=$(cut -d/ -f2 <<< "$path")
+            [[ "$path" == catalog/tracked/* ]] && org=$(cut -d/ -f3 <<< "$path")
+            [[ -n "$ONLY_ORG" && "$org" != "$ONLY_ORG" ]] && continue
+            validate_org_name "$org" > /dev/null || continue
+
+            incoming="$TMP_DIR/archive/store/$path"
+            dest="$CATALOG_ROOT/$path"
+            name=$(basename "$path")
+
+            # With the postgres backend, triage merges into the database
+            if [[ "$TRIAGE_BACKEND" == "postgres" && "$path" == catalog/tracked/*/triage.json ]]; then
+                jq -n --argjson f "$(triage_load "$org")" '{findings: $f}' > "$TMP_DIR/local-triage.json"
+                store_merge_json triage.json "$TMP_DIR/local-triage.json" "$incoming" | jq --sort-keys '.' > "$TMP_DIR/merged.json"
+                if cmp -s "$TMP_DIR/merged.json" <(jq --sort-keys '.' "$TMP_DIR/local-triage.json"); then
+                    unchanged=$((unchanged + 1))
+                    continue
+                fi
+                merged=$((merged + 1))
+                [[ "$DRY_RUN" == true ]] && { echo "  merge    $path (into $TRIAGE_BACKEND)"; continue; }
+                triage_store "$org" "$(jq -c '.findings' "$TMP_DIR/merged.json")"
+                continue
+            fi
+
+            if [[ ! -f "$dest" ]]; then
+                added=$((added + 1))
+                [[ "$DRY_RUN" == true ]] && { echo "  add      $path"; continue; }
+                mkdir -p "$(dirname "$dest")"
+                cp "$incoming" "$dest"
+            elif cmp -s "$incoming" "$dest"; then
+                unchanged=$((unchanged + 1))
+            elif [[ "$path" == catalog/tracked/*/"$name" && " $STORE_MERGED_FILES " == *" $name "* ]]; then
+                store_merge_json "$name" "$dest" "$incoming" | jq --sort-keys '.' > "$TMP_DIR/merged.json"
+                if cmp -s "$TMP_DIR/merged.json" <(jq --sort-keys '.' "$dest"); then
+                    unchanged=$((unchanged + 1))
+                    continue
+                fi
+                merged=$((merged + 1))
+                [[ "$DRY_RUN" == true ]] && { echo "  merge    $path"; continue; }
+                mv "$TMP_DIR/merged.json" "$dest"
+            elif [[ "$OVERWRITE" == true ]]; then
+                replaced=$((replaced + 1))
+                [[ "$DRY_RUN" == true ]] && { echo "  replace  $path"; continue; }
+                cp "$incoming" "$dest"
+            else
+                kept+=("$path")
+                [[ "$DRY_RUN" == true ]] && echo "  keep     $path (differs; local copy kept)"
+            fi
+        done < <(jq -r '.files[].path' "$MANIFEST")
+
+        # Orgs new to this catalog get their index entry from the archive
+        new_orgs=0
+        while IFS= read -r entry; do
+            [[ -z "$entry" ]] && continue
+            org=$(jq -r '.name' <<< "$entry")
+            [[ -n "$ONLY_ORG" && "$org" != "$ONLY_ORG" ]] && continue
+            is_org_tracked "$org" && continue
+            new_orgs=$((new_orgs + 1))
+            [[ "$DRY_RUN" == true ]] && { echo "  track    $org"; continue; }
+            jq --argjson entry "$(jq -c --arg org "$org" '.index // {name: $org, status: "active"}' <<< "$entry")" \
+                '.tracked_orgs += [$entry]' "$CATALOG_INDEX" > "${CATALOG_INDEX}.tmp" && \
+                mv "${CATALOG_INDEX}.tmp" "$CATALOG_INDEX"
+        done < <(jq -c '.orgs[]' "$MANIFEST")
+
+        prefix=""
+        [[ "$DRY_RUN" == true ]] && prefix="Would import: "
+        echo "${prefix}$added added, $merged merged, $replaced replaced, $unchanged unchanged, ${#kept[@]} kept locally; $new_orgs new orgs"
+        if [[ ${#kept[@]} -gt 0 && "$DRY_RUN" == false ]]; then
+            echo "Local copies kept for files that differ (use --overwrite to replace them):"
+            printf '  %s\n' "${kept[@]}"
+        fi
+        ;;
+
+    migrate)
+        case "$TO" in
+            postgres) FROM="file" ;;
+            file) FROM="postgres" ;;
+            *)
+                echo "Error: migrate needs --to postgres or --to file"
+                exit 1
+                ;;
+        esac
+        ensure_index_exists || exit 1
+        if [[ "$ALL" == true ]]; then
+            orgs=$(list_tracked_orgs)
+        else
+            orgs=$(printf '%s\n' ${ARGS[@]+"${ARGS[@]}"})
+        fi
+        if [[ -z "$orgs" ]]; then
+            echo "Error: migrate needs org names or --all"
+            exit 1
+        fi
+
+        total=0
+        while IFS= read -r org; do
+            validate_org_name "$org" || exit 1
+            entries=$(TRIAGE_BACKEND="$FROM" triage_load "$org")
+            count=$(jq 'length' <<< "$entries")
+            [[ "$count" -eq 0 ]] && continue
+            if [[ "$DRY_RUN" == true ]]; then
+                echo "  $org: $count dispositions"
+            else
+                TRIAGE_BACKEND="$TO" triage_store "$org" "$entries"
+            fi
+            total=$((total + count))
+        done <<< "$orgs"
+
+        if [[ "$DRY_RUN" == true ]]; then
+            echo "Would copy $total dispositions from $FROM to $TO"
+        else
+            echo "Copied $total dispositions from $FROM to $TO"
+            echo "Set TRIAGE_BACKEND=$TO in .env to use them"
+        fi
+        ;;
+
+    *)
+        echo "Unknown command: $COMMAND"
+        usage
+        ;;
+esac
@@ -0,0 +1,202 @@
+#!/usr/bin/env bash
+# Flag handlers, route files, and exported functions nothing reaches
+#
+# Usage: ./scripts/dead-code.sh [options] <org> [repo]
+#
+# Forgotten debug endpoints and orphaned admin handlers often stay
+# deployable (a catch-all router, a reflection-based dispatcher, an old
+# build) long after the code that referenced them is gone, so they are
+# worth a manual look. Three kinds are reported:
+#   handler  Request-handler-shaped function no other code references
+#   route    File that registers routes but that no other file imports
+#   export   Exported function (Go internal/ and main, JS/TS) never referenced
+# Names matching debug/admin/internal/... are marked as sensitive and listed
+# first.
+#
+# Examples:
+#   ./scripts/dead-code.sh acme-corp
+#   ./scripts/dead-code.sh acme-corp api --kind handler
+#   ./scripts/dead-code.sh --format json acme-corp
+
+set -euo pipefail
+
+SCRIPT_DIR="$(cd "$(dirname "${BASH_SOURCE[0]}")" && pwd)"
+source "$SCRIPT_DIR/lib/catalog-utils.sh"
+source "$SCRIPT_DIR/lib/rule-utils.sh"
+source "$SCRIPT_DIR/lib/surface-utils.sh"
+
+usage() {
+    cat << EOF
+Usage: $0 [options] <org> [repo]
+
+Flag request handlers, route files, and exported functions that appear
+unreferenced or unregistered.
+
+Options:
+    --kind <kind>       Only handler, route, or export results
+    --sensitive         Only names that look like debug/admin/internal code
+    --format <fmt>      summary (default) or json
+    -h, --help          Show this help message
+
+Results are written to scans/<org>/dead-code.json. References are found by
+name, so dynamic dispatch (reflection, string routing tables) can make live
+code look dead; confirm each result by reading the code.
+
+Examples:
+    $0 acme-corp
+    $0 --sensitive acme-corp
+    $0 acme-corp api --kind route
+EOF
+    exit 1
+}
+
+ORG=""
+ONLY_REPO=""
+KIND=""
+SENSITIVE_ONLY=""
+FORMAT="summary"
+
+while [[ $# -gt 0 ]]; do
+    case "$1" in
+        --kind)
+            KIND="$2"
+            shift 2
+            ;;
+        --sensitive)
+            SENSITIVE_ONLY="1"
+            shift
+            ;;
+        --format)
+            FORMAT="$2"
+            shift 2
+            ;;
+        -h|--help)
+            usage
+            ;;
+        -*)
+            echo "Unknown option: $1"
+            usage
+            ;;
+        *)
+            if [[ -z "$ORG" ]]; then
+                ORG="$1"
+            elif [[ -z "$ONLY_REPO" ]]; then
+                ONLY_REPO="$1"
+            else
+                echo "Unexpected argument: $1"
+                usage
+            fi
+            shift
+            ;;
+    esac
+done
+
+if [[ -z "$ORG" ]]; then
+    echo "Error: org name is required"
+    echo ""
+    usage
+fi
+
+case "$KIND" in
+    ""|handler|route|export) ;;
+    *)
+        echo "Error: Unknown kind '$KIND' (use handler, route, or export)"
+        exit 1
+        ;;
+esac
+
+case "$FORMAT" in
+    summary|json) ;;
+    *)
+        echo "Error: Unknown format '$FORMAT' (use summary or json)"
+        exit 1
+        ;;
+esac
+
+validate_org_name "$ORG" || exit 1
+require_jq || exit 1
+
+REPOS_DIR="$(get_org_repos_dir "$ORG")"
+if [[ ! -d "$REPOS_DIR" ]]; then
+    echo "Error: No repos found at $REPOS_DIR"
+    echo "Run: ./scripts/clone-org-repos.sh $ORG"
+    exit 1
+fi
+if [[ -n "$ONLY_REPO" && ! -d "$REPOS_DIR/$ONLY_REPO" ]]; then
+    echo "Error: Repo not found: $REPOS_DIR/$ONLY_REPO"
+    exit 1
+fi
+
+OUTPUT_FILE="scans/$ORG/dead-code.json"
+
+TMP_DIR=$(mktemp -d)
+register_cleanup "$TMP_DIR"
+install_cancel_traps
+
+# =============================================================================
+# Detection
+# =============================================================================
+
+# Rows: repo, kind, framework, name, file, line
+RESULTS="$TMP_DIR/dead-code.tsv"
+: > "$RESULTS"
+
+while IFS= read -r repo; do
+    [[ -z "$repo" ]] && continue
+    name=$(basename "$repo")
+    [[ -n "$ONLY_REPO" && "$name" != "$ONLY_REPO" ]] && continue
+    echo "Checking $name..." >&2
+
+    if [[ -z "$KIND" || "$KIND" == "handler" || "$KIND" == "export" ]]; then
+        unreferenced_functions "$repo" | \
+            awk -F'\t' -v OFS='\t' -v repo="$name" -v kind="$KIND" \
+                'kind == "" || $1 == kind { print repo, $1, $2, $3, $4, $5 }' >> "$RESULTS"
+    fi
+
+    if [[ -z "$KIND" || "$KIND" == "route" ]]; then
+        entries=$(repo_entry_points "$repo")
+        while IFS=$'\t' read -r file count; do
+            [[ -z "$file" ]] && continue
+            # Name the orphan by its routes so sensitive paths are visible
+            routes=$(awk -F'\t' -v f="$file" '$1 == "http" && $5 == f { print $4 }' <<< "$entries" | \
+                sort -u | head -5 | paste -sd ' ' -)
+            printf '%s\troute\t%s routes\t%s\t%s\t1\n' "$name" "$count" "$routes" "$file" >> "$RESULTS"
+        done < <(orphaned_route_files "$repo" "$entries")
+    fi
+done < <(get_active_repos "$REPOS_DIR")
+
+mkdir -p "$(dirname "$OUTPUT_FILE")"
+jq -R -s --arg pattern "$SENSITIVE_NAME_PATTERN" --arg only "$SENSITIVE_ONLY" '
+    split("\n") | map(select(. != "") | split("\t")
+        | {repo: .[0], kind: .[1], detail: .[2], name: .[3], path: .[4], line: (.[5] | tonumber)}
+        | .sensitive = ((.name + " " + .path) | test($pattern; "i")))
+    | map(select($only == "" or .sensitive))
+    | sort_by((if .sensitive then 0 else 1 end), .repo, .kind, .path)
+' "$RESULTS" > "$OUTPUT_FILE"
+
+# =============================================================================
+# Output
+# =============================================================================
+
+if [[ "$FORMAT" == "json" ]]; then
+    cat "$OUTPUT_FILE"
+    exit 0
+fi
+
+total=$(jq 'length' "$OUTPUT_FILE")
+if [[ "$total" -eq 0 ]]; then
+    echo "No unreferenced handlers, route files, or exports found for $ORG${ONLY_REPO:+/$ONLY_REPO}"
+    exit 0
+fi
+
+printf "%-3s  %-20s  %-8s  %-45s  %s\n" "" "REPO" "KIND" "NAME" "LOCATION"
+jq -r '.[] | [.repo, .kind, .name, "\(.path):\(.line) [\(.detail)]", (if .sensitive then "!" else "" end)] | join("\t")' \
+    "$OUTPUT_FILE" | while IFS=$'\t' read -r repo kind name location flag; do
+        printf "%-3s  %-20s  %-8s  %-45s  %s\n" "$flag" "$repo" "$kind" "$name" "$location"
+    done
+
+sensitive=$(jq '[.[] | select(.sensitive)] | length' "$OUTPUT_FILE")
+echo ""
+echo "$total results, $sensitive with debug/admin/internal-looking names (!)"
+echo "References are matched by name: check for reflection or string-based routing before reporting."
+echo "Full list: $OUTPUT_FILE"
@@ -0,0 +1,393 @@
+#!/usr/bin/env bash
+# Track coordinated disclosure of findings for a tracked org
+#
+# Usage: ./scripts/disclosure.sh <org> <command> [options]
+#
+# Each disclosure records a reported finding and its timeline: when the
+# vendor was notified, when an acknowledgment is due, and the public
+# disclosure date. `remind` lists deadlines that are overdue or close, and
+# can post them to a webhook so it can run from cron.
+#
+# Examples:
+#   ./scripts/disclosure.sh acme-corp add --finding 3f9a1c2b4d5e6f70 --repo api \
+#       --title "SQL injection in report export" --contact security@acme.com
+#   ./scripts/disclosure.sh acme-corp notify 1a2b3c4d
+#   ./scripts/disclosure.sh acme-corp remind --days 14
+
+set -euo pipefail
+
+SCRIPT_DIR="$(cd "$(dirname "${BASH_SOURCE[0]}")" && pwd)"
+source "$SCRIPT_DIR/lib/catalog-utils.sh"
+source "$SCRIPT_DIR/lib/finding-utils.sh"
+
+DEFAULT_REMIND_DAYS=7
+
+usage() {
+    cat << EOF
+Usage: $0 <org> <command> [options]
+
+Track disclosure timelines (vendor notified, acknowledgment due, public date)
+for reported findings.
+
+Commands:
+    add                 Record a finding to disclose (--title required)
+    notify <id>         Mark the vendor notified (--date, default today); sets
+                        the acknowledgment due date (+$DISCLOSURE_ACK_DAYS days) and public date
+                        (+$DISCLOSURE_EMBARGO_DAYS days) unless they are already set
+    ack <id>            Mark the report acknowledged (--date, default today)
+    update <id>         Change dates, status, contact, or title; --note appends a note
+    show <id>           Show a disclosure with its notes
+    list                List open disclosures with their next deadline (default)
+    remind              List deadlines overdue or due within --days
+    remove <id>         Delete a disclosure
+
+Options:
+    --finding <id>      Finding fingerprint or other reference
+    --repo <name>       Repo the finding is in
+    --title <text>      Short description of the issue
+    --contact <text>    Vendor contact (email, program URL)
+    --date <date>       Date of the notify/ack event (YYYY-MM-DD)
+    --notified <date>   Date the vendor was notified
+    --ack-due <date>    Date an acknowledgment is due
+    --public <date>     Public disclosure date
+    --status <status>   One of: $DISCLOSURE_STATUSES
+    --note <text>       Note to add
+    --days <n>          remind: look ahead <n> days (default: $DEFAULT_REMIND_DAYS)
+    --webhook <url>     remind: also post reminders to this URL
+                        (default: \$DISCLOSURE_WEBHOOK_URL)
+    --all               list: include published and closed disclosures
+    -h, --help          Show this help message
+
+Disclosures are stored in catalog/tracked/<org>/disclosures.json. Webhook
+reminders are posted as {"text": "..."}, which Slack and most chat
+incoming webhooks accept.
+
+Examples:
+    $0 acme-corp add --finding 3f9a1c2b4d5e6f70 --repo api --title "SQLi in export" --contact security@acme.com
+    $0 acme-corp notify 1a2b3c4d --date 2026-03-02
+    $0 acme-corp update 1a2b3c4d --public 2026-07-01 --note "Vendor asked for 30 more days"
+    $0 acme-corp remind --days 14 --webhook https://hooks.slack.com/services/...
+EOF
+    exit 1
+}
+
+ORG=""
+COMMAND=""
+ID=""
+FINDING=""
+REPO=""
+TITLE=""
+CONTACT=""
+EVENT_DATE=""
+NOTIFIED=""
+ACK_DUE=""
+PUBLIC_DATE=""
+STATUS=""
+NOTE=""
+DAYS="$DEFAULT_REMIND_DAYS"
+WEBHOOK="${DISCLOSURE_WEBHOOK_URL:-}"
+SHOW_ALL=false
+
+while [[ $# -gt 0 ]]; do
+    case "$1" in
+        --finding)
+            FINDING="$2"
+            shift 2
+            ;;
+        --repo)
+            REPO="$2"
+            shift 2
+            ;;
+        --title)
+            TITLE="$2"
+            shift 2
+            ;;
+        --contact)
+            CONTACT="$2"
+            shift 2
+            ;;
+        --date)
+            EVENT_DATE="$2"
+            shift 2
+            ;;
+        --notified)
+            NOTIFIED="$2"
+            shift 2
+            ;;
+        --ack-due)
+            ACK_DUE="$2"
+            shift 2
+            ;;
+        --public)
+            PUBLIC_DATE="$2"
+            shift 2
+            ;;
+        --status)
+            STATUS="$2"
+            shift 2
+            ;;
+        --note)
+            NOTE="$2"
+            shift 2
+            ;;
+        --days)
+            DAYS="$2"
+            shift 2
+            ;;
+        --webhook)
+            WEBHOOK="$2"
+            shift 2
+            ;;
+        --all)
+            SHOW_ALL=true
+            shift
+            ;;
+        -h|--help)
+            usage
+            ;;
+        -*)
+            echo "Unknown option: $1"
+            usage
+            ;;
+        *)
+            if [[ -z "$ORG" ]]; then
+                ORG="$1"
+            elif [[ -z "$COMMAND" ]]; then
+                COMMAND="$1"
+            elif [[ -z "$ID" ]]; then
+                ID="$1"
+            else
+                echo "Unexpected argument: $1"
+                usage
+            fi
+            shift
+            ;;
+    esac
+done
+
+COMMAND="${COMMAND:-list}"
+
+if [[ -z "$ORG" ]]; then
+    echo "Error: org name is required"
+    echo ""
+    usage
+fi
+
+validate_org_name "$ORG" || exit 1
+require_jq || exit 1
+
+if [[ ! -d "$CATALOG_ROOT/catalog/tracked/$ORG" ]]; then
+    echo "Error: '$ORG' is not tracked"
+    exit 1
+fi
+
+for date_arg in "--date:$EVENT_DATE" "--notified:$NOTIFIED" "--ack-due:$ACK_DUE" "--public:$PUBLIC_DATE"; do
+    value="${date_arg#*:}"
+    if [[ -n "$value" && ! "$value" =~ ^[0-9]{4}-[0-9]{2}-[0-9]{2}$ ]]; then
+        echo "Error: ${date_arg%%:*} must be a date like 2026-12-31"
+        exit 1
+    fi
+done
+
+if [[ -n "$STATUS" && ! " $DISCLOSURE_STATUSES " == *" $STATUS "* ]]; then
+    echo "Error: Unknown status '$STATUS' (use: $DISCLOSURE_STATUSES)"
+    exit 1
+fi
+
+if [[ ! "$DAYS" =~ ^[0-9]+$ ]]; then
+    echo "Error: --days must be a number"
+    exit 1
+fi
+
+FILE=$(disclosures_file "$ORG")
+TODAY=$(date +%Y-%m-%d)
+
+# Apply a jq filter to the disclosures file in place
+update_file() {
+    local tmp
+    [[ -f "$FILE" ]] || echo '{"disclosures": []}' > "$FILE"
+    tmp=$(mktemp)
+    jq --sort-keys "$@" "$FILE" > "$tmp" && mv "$tmp" "$FILE"
+}
+
+require_id() {
+    if [[ -z "$ID" ]]; then
+        echo "Error: $COMMAND requires a disclosure id (see: $0 $ORG list)"
+        exit 1
+    fi
+    if ! jq -e --arg id "$ID" '.disclosures[]? | select(.id == $id)' "$FILE" > /dev/null 2>&1; then
+        echo "Error: No disclosure with id '$ID'"
+        exit 1
+    fi
+}
+
+# Append --note to a disclosure
+add_note() {
+    [[ -n "$NOTE" ]] || return 0
+    update_file --arg id "$ID" --arg today "$TODAY" --arg note "$NOTE" '
+        .disclosures |= map(if .id == $id then .notes += [{date: $today, text: $note}] else . end)'
+}
+
+# One line per reminder: "<title> (<repo>, <id>): <event> <date> (<when>)"
+format_reminders() {
+    jq -r '.[] | "\(.title)\(if .repo then " (" + .repo + ", " else " (" end)\(.id)): \(.event) \(.date) ("
+        + (if .days < 0 then "\(-.days) days ago" elif .days == 0 then "today" else "in \(.days) days" end) + ")"'
+}
+
+case "$COMMAND" in
+    add)
+        if [[ -z "$TITLE" ]]; then
+            echo "Error: add requires --title"
+            exit 1
+        fi
+        ID=$(printf '%s\n%s\n%s' "$FINDING" "$REPO" "$TITLE" | sha256_hex | cut -c1-8)
+
+        if [[ -f "$FILE" ]] && jq -e --arg id "$ID" '.disclosures[]? | select(.id == $id)' "$FILE" > /dev/null; then
+            echo "Error: Disclosure $ID already exists for this finding"
+            echo "Change it with: $0 $ORG update $ID"
+            exit 1
+        fi
+
+        # Giving --notified on add records a report sent before it was tracked
+        if [[ -n "$NOTIFIED" ]]; then
+            ACK_DUE="${ACK_DUE:-$(date_after "$NOTIFIED" "$DISCLOSURE_ACK_DAYS")}"
+            PUBLIC_DATE="${PUBLIC_DATE:-$(date_after "$NOTIFIED" "$DISCLOSURE_EMBARGO_DAYS")}"
+        fi
+        STATUS="${STATUS:-$(if [[ -n "$NOTIFIED" ]]; then echo notified; else echo draft; fi)}"
+
+        update_file \
+            --arg id "$ID" --arg finding "$FINDING" --arg repo "$REPO" --arg title "$TITLE" \
+            --arg contact "$CONTACT" --arg status "$STATUS" --arg notified "$NOTIFIED" \
+            --arg ack_due "$ACK_DUE" --arg public "$PUBLIC_DATE" --arg added "$TODAY" '
+            def opt: if . == "" then null else . end;
+            .disclosures += [{
+                id: $id,
+                finding: ($finding | opt),
+                repo: ($repo | opt),
+                title: $title,
+                contact: ($contact | opt),
+                status: $status,
+                added: $added,
+                notified: ($notified | opt),
+                ack_due: ($ack_due | opt),
+                acknowledged: null,
+                public_date: ($public | opt),
+                published: null,
+                notes: []
+            }]'
+        add_note
+        echo "Added disclosure $ID: $TITLE ($STATUS)"
+        if [[ "$STATUS" == "draft" ]]; then
+            echo "Mark it sent with: $0 $ORG notify $ID"
+        fi
+        ;;
+
+    notify)
+        require_id
+        EVENT_DATE="${EVENT_DATE:-$TODAY}"
+        update_file --arg id "$ID" --arg date "$EVENT_DATE" --arg contact "$CONTACT" \
+            --arg ack_due "${ACK_DUE:-$(date_after "$EVENT_DATE" "$DISCLOSURE_ACK_DAYS")}" \
+            --arg public "${PUBLIC_DATE:-$(date_after "$EVENT_DATE" "$DISCLOSURE_EMBARGO_DAYS")}" '
+            .disclosures |= map(if .id == $id then
+                .status = "notified" | .notified = $date
+                | .ack_due = (.ack_due // $ack_due) | .public_date = (.public_date // $public)
+                | if $contact != "" then .contact = $contact else . end
+            else . end)'
+        add_note
+        jq -r --arg id "$ID" '.disclosures[] | select(.id == $id)
+            | "Notified \(.id) on \(.notified): acknowledgment due \(.ack_due), public \(.public_date)"' "$FILE"
+        ;;
+
+    ack)
+        require_id
+        update_file --arg id "$ID" --arg date "${EVENT_DATE:-$TODAY}" '
+            .disclosures |= map(if .id == $id then .status = "acknowledged" | .acknowledged = $date else . end)'
+        add_note
+        echo "Marked $ID acknowledged on ${EVENT_DATE:-$TODAY}"
+        ;;
+
+    update)
+        require_id
+        update_file --arg id "$ID" --arg today "$TODAY" --arg title "$TITLE" --arg contact "$CONTACT" \
+            --arg finding "$FINDING" --arg repo "$REPO" --arg status "$STATUS" --arg notified "$NOTIFIED" \
+            --arg ack_due "$ACK_DUE" --arg public "$PUBLIC_DATE" '
+            def set($key; $value): if $value != "" then .[$key] = $value else . end;
+            .disclosures |= map(if .id == $id then
+                set("title"; $title) | set("contact"; $contact) | set("finding"; $finding) | set("repo"; $repo)
+                | set("status"; $status) | set("notified"; $notified) | set("ack_due"; $ack_due)
+                | set("public_date"; $public)
+                | if $status == "published" and .published == null then .published = $today else . end
+            else . end)'
+        add_note
+        echo "Updated disclosure $ID"
+        ;;
+
+    show)
+        require_id
+        jq -r --arg id "$ID" '.disclosures[] | select(.id == $id) |
+            "\(.title)",
+            "  Id:           \(.id)",
+            "  Status:       \(.status)",
+            "  Finding:      \(.finding // "-")\(if .repo then " (" + .repo + ")" else "" end)",
+            "  Contact:      \(.contact // "-")",
+            "  Notified:     \(.notified // "-")",
+            "  Ack due:      \(.ack_due // "-")\(if .acknowledged then " (acknowledged " + .acknowledged + ")" else "" end)",
+            "  Public date:  \(.public_date // "-")\(if .published then " (published " + .published + ")" else "" end)",
+            (if (.notes | length) > 0 then "", "Notes:", (.notes[] | "  \(.date)  \(.text)") else empty end)
+        ' "$FILE"
+        ;;
+
+    list)
+        if [[ ! -f "$FILE" ]] || [[ "$(jq '.disclosures | length' "$FILE")" -eq 0 ]]; then
+            echo "No disclosures for $ORG"
+            exit 0
+        fi
+        printf "%-8s  %-12s  %-10s  %-10s  %-10s  %s\n" "ID" "STATUS" "NOTIFIED" "ACK DUE" "PUBLIC" "TITLE"
+        jq -r --argjson all "$SHOW_ALL" '
+            .disclosures
+            | map(select($all or (.status != "published" and .status != "closed")))
+            | sort_by(.public_date // "9999")[]
+            | [.id, .status, (.notified // "-"), (if .acknowledged then "done" else .ack_due // "-" end),
+               (.public_date // "-"), (.title + (if .repo then " (" + .repo + ")" else "" end))]
+            | @tsv
+        ' "$FILE" | while IFS=$'\t' read -r id status notified ack_due public title; do
+            printf "%-8s  %-12s  %-10s  %-10s  %-10s  %s\n" "$id" "$status" "$notified" "$ack_due" "$public" "$title"
+        done
+
+        overdue=$(disclosure_reminders "$FILE" 0 | jq '[.[] | select(.days < 0 or .event == "public date reached")] | length')
+        if [[ "$overdue" -gt 0 ]]; then
+            echo ""
+            echo "$overdue passed deadlines. See: $0 $ORG remind"
+        fi
+        ;;
+
+    remind)
+        reminders=$(disclosure_reminders "$FILE" "$DAYS")
+        if [[ "$(jq 'length' <<< "$reminders")" -eq 0 ]]; then
+            echo "No disclosure deadlines for $ORG in the next $DAYS days"
+            exit 0
+        fi
+        echo "Disclosure deadlines for $ORG:"
+        format_reminders <<< "$reminders" | sed 's/^/  /'
+
+        if [[ -n "$WEBHOOK" ]]; then
+            if jq -n --arg text "$(printf 'Disclosure deadlines for %s:\n%s' "$ORG" "$(format_reminders <<< "$reminders" | sed 's/^/• /')")" \
+                '{text: $text}' | curl -sSf -m 10 -o /dev/null -H "Content-Type: application/json" --data @- "$WEBHOOK" 2>/dev/null; then
+                echo "Posted $(jq 'length' <<< "$reminders") reminders to webhook"
+            else
+                echo "Warning: Could not post reminders to webhook" >&2
+            fi
+        fi
+        ;;
+
+    remove)
+        require_id
+        update_file --arg id "$ID" '.disclosures |= map(select(.id != $id))'
+        echo "Removed disclosure $ID"
+        ;;
+
+    *)
+        echo "Unknown command: $COMMAND"
+        usage
+        ;;
+esac
@@ -0,0 +1,315 @@
+#!/usr/bin/env bash
+# Email a digest of an org's latest scan to its configured recipients
+#
+# Usage: ./scripts/email-digest.sh <org> [options]
+#
+# The digest lists the scan's findings at or above a severity, marking the
+# ones new since the previous scan, rendered from the email templates in
+# templates/email/ (see scripts/lib/report-utils.sh for the syntax).
+# Recipients are configured per program in the org's meta.json, one entry
+# per team, each with its own severity threshold:
+#
+#   "email_digests": [
+#     {"team": "appsec", "to": ["appsec@example.com"], "min_severity": "high"},
+#     {"team": "platform", "to": ["ops@example.com"], "min_severity": "critical",
+#      "only_new": false, "template": "digest", "lang": "es"}
+#   ]
+#
+# Digests are written in the entry's "lang", else the org's "report_lang"
+# (meta.json), else REPORT_LANG; translations live in locales/.
+#
+# hunt.sh runs this with --auto after each scan, so scheduled hunts (cron,
+# agent.sh) mail their digests. Delivery uses SMTP_* from .env.
+#
+# Examples:
+#   ./scripts/email-digest.sh acme-corp --dry-run
+#   ./scripts/email-digest.sh acme-corp --to me@example.com --min-severity medium
+
+set -euo pipefail
+
+SCRIPT_DIR="$(cd "$(dirname "${BASH_SOURCE[0]}")" && pwd)"
+source "$SCRIPT_DIR/lib/catalog-utils.sh"
+source "$SCRIPT_DIR/lib/report-utils.sh"
+
+# SMTP settings from .env
+if [[ -f "$CATALOG_ROOT/.env" ]]; then
+    set -a
+    # shellcheck disable=SC1091
+    source "$CATALOG_ROOT/.env"
+    set +a
+fi
+
+DEFAULT_MIN_SEVERITY="high"
+DIGEST_MAX_FINDINGS="${DIGEST_MAX_FINDINGS:-50}"
+
+usage() {
+    cat << EOF
+Usage: $0 <org> [options]
+
+Email a digest of an org's latest scan to the recipients configured in its
+meta.json ("email_digests"), or to --to.
+
+Options:
+    --to <addresses>        Send one digest to these comma-separated addresses
+                            instead of the configured recipients
+    --min-severity <level>  With --to: lowest severity to include
+                            (critical, high, medium, low, info; default: $DEFAULT_MIN_SEVERITY)
+    --all                   With --to: include findings seen in earlier scans too
+    --template <name>       With --to: email template (default: digest)
+    --lang <code>           Language of every digest (default: per digest, then
+                            the org's report_lang, then REPORT_LANG or en)
+    --scan <timestamp>      Scan to report (default: latest)
+    --previous <timestamp>  Scan to compare with (default: the one before --scan)
+    --send-empty            Send even when nothing meets the threshold
+    --dry-run               Print the messages instead of sending them
+    --auto                  Do nothing (quietly) unless SMTP_URL and recipients
+                            are configured; used by hunt.sh after each scan
+    -q, --quiet             Only print errors
+    -h, --help              Show this help message
+
+Digests list at most DIGEST_MAX_FINDINGS findings (default: 50). Templates
+are templates/email/<name>.subject, .txt, and .html (optional); --template
+also accepts a path without the extension.
+
+Examples:
+    $0 acme-corp --dry-run
+    $0 acme-corp --to me@example.com --min-severity medium --all
+EOF
+    exit 1
+}
+
+ORG=""
+TO=""
+MIN_SEVERITY=""
+INCLUDE_SEEN=false
+TEMPLATE=""
+LANG_CODE=""
+SCAN=""
+PREVIOUS=""
+SEND_EMPTY=false
+DRY_RUN=false
+AUTO=false
+QUIET=false
+
+while [[ $# -gt 0 ]]; do
+    case "$1" in
+        --to)
+            TO="$2"
+            shift 2
+            ;;
+        --min-severity)
+            MIN_SEVERITY="$2"
+            shift 2
+            ;;
+        --all)
+            INCLUDE_SEEN=true
+            shift
+            ;;
+        --template)
+            TEMPLATE="$2"
+            shift 2
+            ;;
+        --lang)
+            LANG_CODE="$2"
+            shift 2
+            ;;
+        --scan)
+            SCAN="$2"
+            shift 2
+            ;;
+        --previous)
+            PREVIOUS="$2"
+            shift 2
+            ;;
+        --send-empty)
+            SEND_EMPTY=true
+            shift
+            ;;
+        --dry-run)
+            DRY_RUN=true
+            shift
+            ;;
+        --auto)
+            AUTO=true
+            QUIET=true
+            shift
+            ;;
+        -q|--quiet)
+            QUIET=true
+            shift
+            ;;
+        -h|--help)
+            usage
+            ;;
+        -*)
+            echo "Unknown option: $1"
+            usage
+            ;;
+        *)
+            if [[ -z "$ORG" ]]; then
+                ORG="$1"
+            else
+                echo "Unexpected argument: $1"
+                usage
+            fi
+            shift
+            ;;
+    esac
+done
+
+[[ -z "$ORG" ]] && usage
+
+if [[ -n "$MIN_SEVERITY" && ! " $REPORT_SEVERITIES " == *" $MIN_SEVERITY "* ]]; then
+    echo "Error: Unknown severity '$MIN_SEVERITY' (use: $REPORT_SEVERITIES)"
+    exit 1
+fi
+
+validate_org_name "$ORG" || exit 1
+require_jq || exit 1
+
+if [[ -n "$LANG_CODE" ]] && ! locale_file "$LANG_CODE" > /dev/null; then
+    echo "Error: No translation for '$LANG_CODE' in $LOCALES_DIR"
+    exit 1
+fi
+ORG_LANG=$(org_report_lang "$ORG")
+
+META_FILE="$CATALOG_ROOT/catalog/tracked/$ORG/meta.json"
+
+# Recipients: --to, or the org's configured digests
+if [[ -n "$TO" ]]; then
+    DIGESTS=$(jq -n -c --arg to "$TO" --arg min "${MIN_SEVERITY:-$DEFAULT_MIN_SEVERITY}" \
+        --argjson all "$INCLUDE_SEEN" --arg template "${TEMPLATE:-digest}" \
+        '[{team: null, to: ($to | split(",") | map(gsub("^\\s+|\\s+$"; ""))), min_severity: $min,
+           only_new: ($all | not), template: $template}]')
+else
+    DIGESTS=$(jq -c '.email_digests // []' "$META_FILE" 2>/dev/null || echo "[]")
+fi
+
+if [[ "$AUTO" == true ]]; then
+    [[ -z "${SMTP_URL:-}" || "$DIGESTS" == "[]" ]] && exit 0
+fi
+
+if [[ "$DIGESTS" == "[]" ]]; then
+    echo "Error: No email recipients configured for $ORG"
+    echo "Add \"email_digests\" to $META_FILE, or pass --to <addresses>"
+    exit 1
+fi
+
+if [[ "$DRY_RUN" == false && ( -z "${SMTP_URL:-}" || -z "${SMTP_FROM:-}" ) ]]; then
+    echo "Error: SMTP_URL and SMTP_FROM must be set in .env to send email (or use --dry-run)"
+    exit 1
+fi
+
+# Scans to report and compare
+SCANS_DIR=$(get_org_scans_dir "$ORG")
+if [[ -n "$SCAN" ]]; then
+    SCAN_DIR="$SCANS_DIR/$SCAN"
+else
+    SCAN_DIR=$(get_latest_scan_dir "$ORG" 2>/dev/null || true)
+fi
+if [[ -z "$SCAN_DIR" || ! -d "$SCAN_DIR" ]]; then
+    [[ "$AUTO" == true ]] && exit 0
+    echo "Error: No scan found for $ORG${SCAN:+ at $SCAN}"
+    echo "Run: ./scripts/catalog-scan.sh $ORG"
+    exit 1
+fi
+if [[ -n "$PREVIOUS" ]]; then
+    PREVIOUS_DIR="$SCANS_DIR/$PREVIOUS"
+    if [[ ! -d "$PREVIOUS_DIR" ]]; then
+        echo "Error: No scan found for $ORG at $PREVIOUS"
+        exit 1
+    fi
+else
+    PREVIOUS_DIR=$(list_org_scans "$ORG" | awk -v scan="$(basename "$SCAN_DIR")" '$0 < scan' | tail -n 1)
+    [[ -n "$PREVIOUS_DIR" ]] && PREVIOUS_DIR="$SCANS_DIR/$PREVIOUS_DIR"
+fi
+
+PROGRAM_URL=$(jq -r '.program_url // empty' "$META_FILE" 2>/dev/null || true)
+
+TMP_DIR=$(mktemp -d)
+register_cleanup "$TMP_DIR"
+
+# =============================================================================
+# Delivery
+# =============================================================================
+
+sent=0
+failed=0
+count=$(jq 'length' <<< "$DIGESTS")
+
+for ((i = 0; i < count; i++)); do
+    entry=$(jq -c --argjson i "$i" '.[$i]' <<< "$DIGESTS")
+    team=$(jq -r '.team // .name // empty' <<< "$entry")
+    recipients=$(jq -r '(.to // []) | if type == "array" then join(", ") else . end' <<< "$entry")
+    min_severity=$(jq -r --arg d "$DEFAULT_MIN_SEVERITY" '.min_severity // $d' <<< "$entry")
+    only_new=$(jq -r 'if .only_new == false then "" else "new" end' <<< "$entry")
+    template=$(jq -r '.template // "digest"' <<< "$entry")
+    lang=$(jq -r --arg default "$ORG_LANG" '.lang // $default' <<< "$entry")
+    lang="${LANG_CODE:-$lang}"
+    label="${team:-$recipients}"
+
+    if [[ -z "$recipients" ]]; then
+        echo "Warning: Digest ${team:-#$((i + 1))} has no recipients; skipping" >&2
+        continue
+    fi
+    if [[ ! " $REPORT_SEVERITIES " == *" $min_severity "* ]]; then
+        echo "Warning: Digest $label has unknown min_severity '$min_severity'; skipping" >&2
+        continue
+    fi
+    subject_template=$(email_template_file "$template" subject)
+    text_template=$(email_template_file "$template" txt)
+    html_template=$(email_template_file "$template" html)
+    if [[ ! -f "$subject_template" || ! -f "$text_template" ]]; then
+        echo "Warning: Template '$template' needs $subject_template and $text_template; skipping $label" >&2
+        continue
+    fi
+    if ! locale=$(load_locale "$lang"); then
+        echo "Warning: No translation for '$lang'; sending $label the digest in English" >&2
+        locale=$(load_locale en)
+    fi
+
+    # The first scan has nothing to compare with, so every finding counts
+    [[ -z "$PREVIOUS_DIR" ]] && only_new=""
+    digest=$(scan_digest "$ORG" "$SCAN_DIR" "$PREVIOUS_DIR" "$min_severity" "$only_new")
+    data=$(jq -c --arg team "$team" --arg program_url "$PROGRAM_URL" --argjson max "$DIGEST_MAX_FINDINGS" '
+        . + {team: (if $team == "" then null else $team end),
+             program_url: (if $program_url == "" then null else $program_url end),
+             truncated: (if (.findings | length) > $max then (.findings | length) - $max else null end),
+             findings: .findings[0:$max]}
+    ' <<< "$digest")
+    data=$(localize_data "$data" "$locale")
+
+    if [[ "$(jq '.total' <<< "$data")" -eq 0 && "$SEND_EMPTY" == false ]]; then
+        [[ "$QUIET" == false ]] && echo "Nothing for $label at $min_severity+ severity; not sending"
+        continue
+    fi
+
+    subject=$(render_template "$subject_template" "$data" | head -n 1)
+    render_template "$text_template" "$data" > "$TMP_DIR/body.txt"
+    html_file=""
+    if [[ -f "$html_template" ]]; then
+        render_template "$html_template" "$data" html > "$TMP_DIR/body.html"
+        html_file="$TMP_DIR/body.html"
+    fi
+    build_email "${SMTP_FROM:-bounty-hunter@localhost}" "$recipients" "$subject" "$TMP_DIR/body.txt" "$html_file" \
+        > "$TMP_DIR/message.eml"
+
+    if [[ "$DRY_RUN" == true ]]; then
+        cat "$TMP_DIR/message.eml" | tr -d '\r'
+        echo ""
+        continue
+    fi
+
+    if send_email "$TMP_DIR/message.eml" "$recipients"; then
+        sent=$((sent + 1))
+        [[ "$QUIET" == false ]] && echo "Sent digest to $label ($recipients): $subject"
+    else
+        failed=$((failed + 1))
+        echo "Error: Could not send digest to $label ($recipients)" >&2
+    fi
+done
+
+if [[ "$DRY_RUN" == false && "$QUIET" == false ]]; then
+    echo "Digests sent: $sent$(if [[ "$failed" -gt 0 ]]; then echo ", failed: $failed"; fi)"
+fi
+[[ "$failed" -eq 0 ]]
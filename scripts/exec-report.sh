@@ -0,0 +1,260 @@
+#!/usr/bin/env bash
+# Executive summary of an org's latest scan, rolled up across its repos
+#
+# Usage: ./scripts/exec-report.sh <org> [options]
+#
+# For the end of an engagement: open findings by severity and how they
+# moved since the previous period, the repos carrying the most risk, the
+# spread of findings across vulnerability categories, and the open
+# critical findings. Findings triaged as false-positive, wont-fix, or
+# resolved are not counted. Rendered from templates/report/executive.{md,html}
+# (see scripts/lib/report-utils.sh for the syntax) in the org's report
+# language.
+#
+# Examples:
+#   ./scripts/exec-report.sh acme-corp                          # Markdown to stdout
+#   ./scripts/exec-report.sh acme-corp --format html --output summary.html
+#   ./scripts/exec-report.sh acme-corp --period 90 --top 5
+#   ./scripts/exec-report.sh acme-corp --format json | jq '.repos'
+
+set -euo pipefail
+
+SCRIPT_DIR="$(cd "$(dirname "${BASH_SOURCE[0]}")" && pwd)"
+source "$SCRIPT_DIR/lib/catalog-utils.sh"
+source "$SCRIPT_DIR/lib/report-utils.sh"
+
+DEFAULT_PERIOD_DAYS=30
+DEFAULT_TOP=10
+EXEC_REPORT_MAX_CRITICALS="${EXEC_REPORT_MAX_CRITICALS:-25}"
+
+usage() {
+    cat << EOF
+Usage: $0 <org> [options]
+
+Summarize an org's latest scan across all its repos: open findings and
+their trend, the riskiest repos, categories, and open criticals.
+
+Options:
+    --format <format>       markdown, html, or json (default: markdown)
+    --output <file>         Write the report to a file instead of stdout
+    --scan <timestamp>      Scan to report (default: latest)
+    --previous <timestamp>  Scan to compare with (default: the latest scan at
+                            least --period days before --scan, else the
+                            earliest one before it)
+    --period <days>         Length of the comparison period (default: $DEFAULT_PERIOD_DAYS)
+    --top <n>               Repos to list (default: $DEFAULT_TOP)
+    --lang <code>           Language of the report (default: the org's report_lang,
+                            then REPORT_LANG or en; see locales/)
+    -h, --help              Show this help message
+
+A repo's risk score weights its open findings by severity: critical 10,
+high 5, medium 2, low 1. Reports list at most EXEC_REPORT_MAX_CRITICALS
+critical findings (default: $EXEC_REPORT_MAX_CRITICALS).
+
+Examples:
+    $0 acme-corp
+    $0 acme-corp --format html --output summary.html
+    $0 acme-corp --period 90 --top 5
+EOF
+    exit 1
+}
+
+ORG=""
+FORMAT="markdown"
+OUTPUT=""
+SCAN=""
+PREVIOUS=""
+PERIOD_DAYS="$DEFAULT_PERIOD_DAYS"
+TOP="$DEFAULT_TOP"
+LANG_CODE=""
+
+while [[ $# -gt 0 ]]; do
+    case "$1" in
+        --format)
+            FORMAT="$2"
+            shift 2
+            ;;
+        --output)
+            OUTPUT="$2"
+            shift 2
+            ;;
+        --scan)
+            SCAN="$2"
+            shift 2
+            ;;
+        --previous)
+            PREVIOUS="$2"
+            shift 2
+            ;;
+        --period)
+            PERIOD_DAYS="$2"
+            shift 2
+            ;;
+        --top)
+            TOP="$2"
+            shift 2
+            ;;
+        --lang)
+            LANG_CODE="$2"
+            shift 2
+            ;;
+        -h|--help)
+            usage
+            ;;
+        -*)
+            echo "Unknown option: $1"
+            usage
+            ;;
+        *)
+            if [[ -z "$ORG" ]]; then
+                ORG="$1"
+            else
+                echo "Unexpected argument: $1"
+                usage
+            fi
+            shift
+            ;;
+    esac
+done
+
+[[ -z "$ORG" ]] && usage
+
+case "$FORMAT" in
+    markdown|html|json) ;;
+    *)
+        echo "Error: Unknown format '$FORMAT' (use: markdown, html, json)"
+        exit 1
+        ;;
+esac
+
+if [[ ! "$PERIOD_DAYS" =~ ^[0-9]+$ || ! "$TOP" =~ ^[1-9][0-9]*$ ]]; then
+    echo "Error: --period and --top must be numbers"
+    exit 1
+fi
+
+validate_org_name "$ORG" || exit 1
+require_jq || exit 1
+
+LANG_CODE="${LANG_CODE:-$(org_report_lang "$ORG")}"
+if ! LOCALE=$(load_locale "$LANG_CODE"); then
+    echo "Error: No translation for '$LANG_CODE' in $LOCALES_DIR"
+    exit 1
+fi
+
+# Scans to report and compare
+SCANS_DIR=$(get_org_scans_dir "$ORG")
+if [[ -n "$SCAN" ]]; then
+    SCAN_DIR="$SCANS_DIR/$SCAN"
+else
+    SCAN_DIR=$(get_latest_scan_dir "$ORG" 2>/dev/null || true)
+fi
+if [[ -z "$SCAN_DIR" || ! -d "$SCAN_DIR" ]]; then
+    echo "Error: No scan found for $ORG${SCAN:+ at $SCAN}"
+    echo "Run: ./scripts/catalog-scan.sh $ORG"
+    exit 1
+fi
+SCAN=$(basename "$SCAN_DIR")
+
+if [[ -n "$PREVIOUS" ]]; then
+    if [[ ! -d "$SCANS_DIR/$PREVIOUS" ]]; then
+        echo "Error: No scan found for $ORG at $PREVIOUS"
+        exit 1
+    fi
+else
+    # Scan directories are named by timestamp (YYYY-MM-DD-HHMM)
+    cutoff=$(jq -n -r --arg scan "$SCAN" --argjson days "$PERIOD_DAYS" '
+        ($scan[0:10] + "T00:00:00Z" | fromdateiso8601) - $days * 86400 | strftime("%Y-%m-%d")' 2>/dev/null || true)
+    earlier=$(list_org_scans "$ORG" | awk -v scan="$SCAN" '$0 < scan')
+    PREVIOUS=$(awk -v cutoff="$cutoff" 'cutoff != "" && substr($0, 1, 10) <= cutoff' <<< "$earlier" | tail -n 1)
+    [[ -z "$PREVIOUS" ]] && PREVIOUS=$(head -n 1 <<< "$earlier")
+fi
+
+PROGRAM_URL=$(jq -r '.program_url // empty' "$CATALOG_ROOT/catalog/tracked/$ORG/meta.json" 2>/dev/null || true)
+
+# =============================================================================
+# Report data
+# =============================================================================
+
+CURRENT=$(mktemp)
+BEFORE=$(mktemp)
+register_cleanup "$CURRENT"
+register_cleanup "$BEFORE"
+scan_digest_findings "$SCAN_DIR" "$ORG" > "$CURRENT"
+if [[ -n "$PREVIOUS" ]]; then
+    scan_digest_findings "$SCANS_DIR/$PREVIOUS" "$ORG" > "$BEFORE"
+else
+    echo "[]" > "$BEFORE"
+fi
+
+# Triaged findings that no longer count as open, by rule and location
+CLOSED=$(triage_load "$ORG" | jq -c '[.[] | select(.status == "false-positive" or .status == "wont-fix" or .status == "resolved")
+    | {key: "\(.check_id // "" | split(".") | last)\t\(.repo)\t\(.path)\t\(.line)", value: true}] | from_entries')
+
+DATA=$(jq -n -c --slurpfile current "$CURRENT" --slurpfile before "$BEFORE" --argjson closed "$CLOSED" \
+    --arg org "$ORG" --arg scan "$SCAN" --arg previous "$PREVIOUS" --argjson period "$PERIOD_DAYS" \
+    --argjson top "$TOP" --argjson max "$EXEC_REPORT_MAX_CRITICALS" --arg severities "$REPORT_SEVERITIES" \
+    --arg program_url "$PROGRAM_URL" --arg generated "$(date -u +%Y-%m-%d)" "$SEVERITY_JQ_DEFS"'
+    def open: map(select(.scanner != "semgrep" or ($closed["\(.rule)\t\(.repo)\t\(.path)\t\(.line)"] | not)));
+    def weight: {critical: 10, high: 5, medium: 2, low: 1}[.] // 0;
+    def signed: if . > 0 then "+\(.)" else tostring end;
+
+    ($severities | split(" ")) as $order
+    | ($current[0] | open) as $now
+    | ($before[0] | open) as $earlier
+    | ($earlier | map({key: .key, value: true}) | from_entries) as $seen
+    | ($now | map({key: .key, value: true}) | from_entries) as $still
+    | ($now | map(. + {new: ($previous != "" and ($seen[.key] | not))})) as $now
+    | ($order | map(. as $s | select(any($now[]; .severity == $s)))) as $present
+    | {org: $org, scan: $scan, previous: (if $previous == "" then null else $previous end),
+       period_days: $period, generated: $generated,
+       program_url: (if $program_url == "" then null else $program_url end),
+       total: ($now | length), previous_total: ($earlier | length),
+       delta: (($now | length) - ($earlier | length) | signed),
+       new_count: ([$now[] | select(.new)] | length),
+       fixed_count: ([$earlier[] | select($still[.key] | not)] | length),
+       repo_count: ($now | map(.repo) | unique | length),
+       critical_count: ([$now[] | select(.severity == "critical")] | length),
+       totals: [$order[] as $s
+                | {severity: $s, count: ([$now[] | select(.severity == $s)] | length),
+                   before: ([$earlier[] | select(.severity == $s)] | length)}
+                | . + {change: (.count - .before | signed)}
+                | select(.count > 0 or .before > 0)],
+       severities: [$present[] | {severity: .}],
+       repos: ($now | group_by(.repo)
+               | map(. as $f | {repo: $f[0].repo, total: length,
+                     score: (map(.severity | weight) | add),
+                     counts: [$present[] as $s | [$f[] | select(.severity == $s)] | length],
+                     new: ([$f[] | select(.new)] | length)})
+               | sort_by(-.score, -.total, .repo) | .[:$top]),
+       categories: ($now | group_by(.category)
+                    | map({category: .[0].category, count: length,
+                           percent: ((length * 100 / ($now | length)) | round)})
+                    | sort_by(-.count, .category)),
+       criticals: ([$now[] | select(.severity == "critical") | del(.key)]
+                   | sort_by((if .new then 0 else 1 end), .repo, .path, .line))}
+    | . + {more: ((.criticals | length) - $max | if . > 0 then . else null end),
+           criticals: .criticals[:$max]}
+')
+DATA=$(localize_data "$DATA" "$LOCALE")
+
+# =============================================================================
+# Render
+# =============================================================================
+
+render_report() {
+    case "$FORMAT" in
+        json) jq 'del(.t)' <<< "$DATA" ;;
+        html) render_template "$REPORT_TEMPLATES_DIR/report/executive.html" "$DATA" html ;;
+        *) render_template "$REPORT_TEMPLATES_DIR/report/executive.md" "$DATA" ;;
+    esac
+}
+
+if [[ -n "$OUTPUT" ]]; then
+    mkdir -p "$(dirname "$OUTPUT")"
+    render_report > "$OUTPUT"
+    echo "Report: $OUTPUT"
+    jq -r '"\(.total) open findings in \(.repo_count) repos, \(.critical_count) critical"
+        + (if .previous then " (\(.delta) since \(.previous))" else "" end)' <<< "$DATA"
+else
+    render_report
+fi
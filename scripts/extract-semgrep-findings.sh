@@ -6,12 +6,54 @@
 #   ./scripts/extract-semgrep-findings.sh myorg              # All repos, summary format
 #   ./scripts/extract-semgrep-findings.sh myorg full         # All repos, full format
 #   ./scripts/extract-semgrep-findings.sh myorg summary repo # Specific repo
+#   ./scripts/extract-semgrep-findings.sh myorg --no-collapse # One row per rule hit
+#   ./scripts/extract-semgrep-findings.sh myorg tiers        # Sections by confidence
+#   ./scripts/extract-semgrep-findings.sh myorg --min-confidence medium
+#   ./scripts/extract-semgrep-findings.sh myorg --show-suppressed
+#   ./scripts/extract-semgrep-findings.sh myorg --dedupe-repos # One row per shared root cause
+#   ./scripts/extract-semgrep-findings.sh myorg shared       # Findings copied across repos
+#
+# Findings from several rules on the same line are collapsed into one finding
+# at the highest severity, listing every contributing rule.
+#
+# Each finding gets a confidence (HIGH/MEDIUM/LOW): the rule's
+# metadata.confidence when set, otherwise HIGH for taint findings with a
+# dataflow trace, LOW for audit rules, and MEDIUM for other syntactic matches.
+#
+# The same rule matching the same code in several repos (copied or vendored
+# libraries) shares a cross-repo fingerprint; --dedupe-repos reports it once
+# with the list of affected repos.
+#
+# Findings linked to a live URL by correlate-endpoints.sh show it in the
+# ENDPOINT column (the route alone when no base URL is known).
+#
+# Every format carries the finding's permalink at the scanned commit
+# (extra.permalink, added by scan-semgrep.sh) when the repo has a remote.
+#
+# Findings matching an active suppression (suppress-finding.sh) are hidden;
+# once a suppression expires its findings are shown again. So are findings
+# triaged as false-positive or wont-fix with findings.sh. Entries that
+# recorded the finding's structure (extra.structure) still match it by that
+# once its code is edited or its file renamed, so the finding stays hidden;
+# only in the same file, and only while the original finding is gone.
 
 set -euo pipefail
 
 SCRIPT_DIR="$(cd "$(dirname "${BASH_SOURCE[0]}")" && pwd)"
 # shellcheck source=lib/extract-common.sh
 source "$SCRIPT_DIR/lib/extract-common.sh"
+# shellcheck source=lib/profiles.sh
+source "$SCRIPT_DIR/lib/profiles.sh"
+# shellcheck source=lib/finding-utils.sh
+source "$SCRIPT_DIR/lib/finding-utils.sh"
+
+# Triage backend settings from .env
+if [[ -f "$CATALOG_ROOT/.env" ]]; then
+    set -a
+    # shellcheck disable=SC1091
+    source "$CATALOG_ROOT/.env"
+    set +a
+fi
 
 # Script-specific configuration (used by extract-common.sh)
 # shellcheck disable=SC2034
@@ -27,21 +69,236 @@ AVAILABLE_FORMATS="summary  - One line per finding with key details (default)
   full     - Full JSON for each finding
   count    - Just counts per repo
   jsonl    - One JSON object per line
-  rules    - Top rules by finding count"
+  rules    - Top rules by finding count
+  tiers    - Summary split into HIGH / MEDIUM / LOW confidence sections
+  modules  - Finding counts per Go module / submodule
+  shared   - Findings whose code appears in several repos, with the affected repos
+  diagnostics - Files scanned per language, files skipped and why, files only
+                partly parsed, rule timeouts, failed semgrep passes"
+# shellcheck disable=SC2034
+EXTRA_OPTIONS="  --no-collapse           Keep one finding per rule when several rules hit one line
+  --min-confidence <lvl>  Only show findings at or above high, medium, or low
+  --profile <name>        Use the profile's confidence threshold (e.g. ci = high)
+  --show-suppressed       Include findings hidden by active suppressions
+                          or false-positive/wont-fix triage
+  --dedupe-repos          Report a finding copied across repos once, listing affected repos"
+
+# Script-specific flags (everything else is handled by extract_init)
+COLLAPSE="1"
+MIN_CONFIDENCE=""
+PROFILE=""
+SHOW_SUPPRESSED=""
+DEDUPE_REPOS=""
+ARGS=()
+while [[ $# -gt 0 ]]; do
+    case "$1" in
+        --no-collapse)
+            COLLAPSE=""
+            shift
+            ;;
+        --min-confidence)
+            MIN_CONFIDENCE="${2:-}"
+            [[ -z "$MIN_CONFIDENCE" ]] && MIN_CONFIDENCE="missing"
+            shift 2 || shift
+            ;;
+        --profile)
+            PROFILE="${2:-}"
+            shift 2 || shift
+            ;;
+        --show-suppressed)
+            SHOW_SUPPRESSED="1"
+            shift
+            ;;
+        --dedupe-repos)
+            DEDUPE_REPOS="1"
+            shift
+            ;;
+        *)
+            ARGS+=("$1")
+            shift
+            ;;
+    esac
+done
+
+# An explicit --min-confidence wins over the profile threshold
+if [[ -n "$PROFILE" ]]; then
+    load_profile "$PROFILE" >&2 || exit 1
+    MIN_CONFIDENCE="${MIN_CONFIDENCE:-$PROFILE_MIN_CONFIDENCE}"
+fi
+MIN_CONFIDENCE="${MIN_CONFIDENCE:-low}"
+
+case "$(echo "$MIN_CONFIDENCE" | tr '[:upper:]' '[:lower:]')" in
+    high)   MIN_CONFIDENCE_RANK=1 ;;
+    medium) MIN_CONFIDENCE_RANK=2 ;;
+    low)    MIN_CONFIDENCE_RANK=3 ;;
+    *)
+        err "--min-confidence must be high, medium, or low"
+        exit 1
+        ;;
+esac
 
-extract_init "$@"
+extract_init ${ARGS[@]+"${ARGS[@]}"}
 
 # Build the read_json call
 READ_JSON="$(read_json_opts)"
 
+# Active suppressions, and findings triaged as false-positive or wont-fix
+# (findings.sh), become an inline table; a hit is hidden when its rule
+# matches (full check_id or last segment), the optional repo matches, and
+# it is the entry's finding (extra.fingerprint), or has the entry's
+# structure in the entry's file (or the file it was renamed to) while the
+# entry's finding is no longer reported, or, when either side has no
+# structure, the optional path (file or directory suffix) and line match
+SUPPRESSIONS_CTE=""
+SUPPRESS_FILTER=""
+SUPPRESSIONS_FILE=$(suppressions_file "$ORG")
+if [[ -z "$SHOW_SUPPRESSED" ]]; then
+    SUPPRESSION_ROWS=$({ active_suppressions "$SUPPRESSIONS_FILE"; triage_hidden "$ORG"; } | jq -s -c 'add' | \
+        structure_renamed "$CATALOG_ROOT/repos/$ORG" | jq -r '
+        def sql: if . == null then "NULL" else "\u0027" + (tostring | gsub("\u0027"; "\u0027\u0027")) + "\u0027" end;
+        map("(\(.rule | sql), \(.repo | sql), \(.path | sql), \(.line // null | if . == null then "NULL" else tostring end), \(.structure | sql), \(.finding | sql), \(.renamed | sql))")
+        | join(", ")')
+    if [[ -n "$SUPPRESSION_ROWS" ]]; then
+        SUPPRESSIONS_CTE="suppressions AS (
+        SELECT rule::VARCHAR as rule, repo::VARCHAR as repo, path::VARCHAR as path, line::BIGINT as line,
+               structure::VARCHAR as structure, finding::VARCHAR as finding, renamed::VARCHAR as renamed
+        FROM (VALUES $SUPPRESSION_ROWS) s(rule, repo, path, line, structure, finding, renamed)
+    ),"
+        SUPPRESS_FILTER="AND NOT EXISTS (
+            SELECT 1 FROM suppressions s
+            WHERE (rated.check_id = s.rule OR ends_with(rated.check_id, '.' || s.rule))
+              AND (s.repo IS NULL OR rated.repo = s.repo)
+              AND ((s.finding IS NOT NULL AND rated.fingerprint = s.finding)
+                   OR (s.structure IS NOT NULL AND rated.structure = s.structure
+                       AND (rated.path = s.path OR rated.path = s.renamed)
+                       AND NOT EXISTS (SELECT 1 FROM hits h WHERE s.finding IS NOT NULL AND h.fingerprint = s.finding))
+                   OR ((s.structure IS NULL OR rated.structure IS NULL)
+                       AND (s.path IS NULL OR rated.path = s.path
+                            OR ends_with(rated.path, '/' || s.path)
+                            OR contains(rated.path, '/' || rtrim(s.path, '/') || '/')
+                            OR starts_with(rated.path, rtrim(s.path, '/') || '/'))
+                       AND (s.line IS NULL OR rated.start.line = s.line)))
+        )"
+    fi
+fi
+EXPIRED_COUNT=$(expired_suppressions "$SUPPRESSIONS_FILE" | jq 'length')
+if [[ "$EXPIRED_COUNT" -gt 0 ]]; then
+    echo "Note: $EXPIRED_COUNT suppressions have expired; their findings are shown again" >&2
+    echo "      Re-triage, then renew or remove: ./scripts/suppress-finding.sh $ORG list" >&2
+    echo "" >&2
+fi
+
+# Findings CTE: one row per finding, with the list of rules that hit it
+# Hits below --min-confidence are dropped before collapsing.
+# Collapsed mode keeps the highest-severity hit per repo/path/line;
+# --no-collapse keeps every hit with a single-element rules list
+if [[ -n "$COLLAPSE" ]]; then
+    COLLAPSE_FILTER="WHERE rule_rank = 1"
+    RULES_EXPR="list(check_id) OVER lines"
+else
+    COLLAPSE_FILTER=""
+    RULES_EXPR="[check_id]"
+fi
+
+# Cross-repo grouping: findings with the same shared_fingerprint (rule id plus
+# matched code, whitespace collapsed) are one root cause. Findings without
+# matched code (extra.lines needs semgrep login) never group.
+# --dedupe-repos keeps the first repo's finding per group; REPO_LABEL shows
+# how many other repos it also affects
+if [[ -n "$DEDUPE_REPOS" ]]; then
+    DEDUPE_FILTER="QUALIFY row_number() OVER (PARTITION BY shared_fingerprint ORDER BY repo, path, start.line) = 1"
+    REPO_LABEL="repo || CASE WHEN len(affected_repos) > 1 THEN ' (+' || (len(affected_repos) - 1) || ' repos)' ELSE '' END"
+else
+    DEDUPE_FILTER=""
+    REPO_LABEL="repo"
+fi
+
+FINDINGS="
+    WITH $SUPPRESSIONS_CTE
+    hits AS (
+        SELECT
+            regexp_extract(filename, '([^/]+)\\.json(\\.gz)?\$', 1) as repo,
+            unnest.check_id as check_id,
+            unnest.path as path,
+            unnest.start as start,
+            unnest.\"end\" as \"end\",
+            unnest.extra as extra,
+            CASE unnest.extra.severity
+                WHEN 'ERROR' THEN 1
+                WHEN 'WARNING' THEN 2
+                ELSE 3
+            END as severity_rank,
+            to_json(unnest.extra) as extra_json,
+            coalesce(json_extract_string(to_json(unnest.extra), '\$.module'), '') as module,
+            coalesce(json_extract_string(to_json(unnest.extra), '\$.endpoint.url'),
+                     json_extract_string(to_json(unnest.extra), '\$.endpoint.route'), '') as endpoint,
+            coalesce(json_extract_string(to_json(unnest.extra), '\$.permalink'), '') as permalink,
+            json_extract_string(to_json(unnest.extra), '\$.structure') as structure,
+            json_extract_string(to_json(unnest.extra), '\$.fingerprint') as fingerprint
+        FROM $READ_JSON,
+        UNNEST(results)
+    ),
+    scored AS (
+        SELECT
+            * EXCLUDE (extra_json),
+            CASE
+                WHEN upper(json_extract_string(extra_json, '\$.metadata.confidence')) IN ('HIGH', 'MEDIUM', 'LOW')
+                    THEN upper(json_extract_string(extra_json, '\$.metadata.confidence'))
+                WHEN json_extract(extra_json, '\$.dataflow_trace') IS NOT NULL THEN 'HIGH'
+                WHEN coalesce(json_extract_string(extra_json, '\$.metadata.subcategory'), '') LIKE '%audit%' THEN 'LOW'
+                ELSE 'MEDIUM'
+            END as confidence
+        FROM hits
+    ),
+    rated AS (
+        SELECT
+            *,
+            CASE confidence WHEN 'HIGH' THEN 1 WHEN 'MEDIUM' THEN 2 ELSE 3 END as confidence_rank
+        FROM scored
+    ),
+    ranked AS (
+        SELECT
+            *,
+            row_number() OVER lines as rule_rank,
+            $RULES_EXPR as rules
+        FROM rated
+        WHERE confidence_rank <= $MIN_CONFIDENCE_RANK
+        $SUPPRESS_FILTER
+        WINDOW lines AS (
+            PARTITION BY repo, path, start.line
+            ORDER BY severity_rank, confidence_rank, check_id
+            ROWS BETWEEN UNBOUNDED PRECEDING AND UNBOUNDED FOLLOWING
+        )
+    ),
+    collapsed AS (
+        SELECT
+            * EXCLUDE (rule_rank),
+            CASE
+                WHEN coalesce(trim(extra.lines), '') IN ('', 'requires login')
+                    THEN md5(repo || chr(10) || path || chr(10) || start.line)
+                ELSE md5(check_id || chr(10) || regexp_replace(trim(extra.lines), '\\s+', ' ', 'g'))
+            END as shared_fingerprint
+        FROM ranked $COLLAPSE_FILTER
+    ),
+    shared AS (
+        SELECT shared_fingerprint, list(DISTINCT repo ORDER BY repo) as affected_repos
+        FROM collapsed
+        GROUP BY shared_fingerprint
+    ),
+    findings AS (
+        SELECT * FROM collapsed JOIN shared USING (shared_fingerprint)
+        $DEDUPE_FILTER
+    )
+"
+
 case "$FORMAT" in
     count)
         run_duckdb "
+            $FINDINGS
             SELECT
-                regexp_extract(filename, '([^/]+)\\.json(\\.gz)?\$', 1) as repo,
+                repo,
                 count(*) as findings
-            FROM $READ_JSON,
-            UNNEST(results)
+            FROM findings
             GROUP BY repo
             HAVING findings > 0
             ORDER BY findings DESC
@@ -49,9 +306,8 @@ case "$FORMAT" in
 
         if [[ -z "$REPO" ]]; then
             total=$(duckdb_scalar "
-                SELECT count(*)
-                FROM $READ_JSON,
-                UNNEST(results)
+                $FINDINGS
+                SELECT count(*) FROM findings
             ")
             print_total "findings" "${total:-0}"
         fi
@@ -59,29 +315,27 @@ case "$FORMAT" in
 
     summary)
         run_duckdb "
+            $FINDINGS
             SELECT
-                regexp_extract(filename, '([^/]+)\\.json(\\.gz)?\$', 1) as repo,
-                unnest.extra.severity as severity,
-                unnest.check_id as rule,
-                unnest.path || ':' || unnest.start.line as location,
-                substring(unnest.extra.message, 1, 100) || '...' as message
-            FROM $READ_JSON,
-            UNNEST(results)
+                $REPO_LABEL as repo,
+                extra.severity as severity,
+                confidence,
+                array_to_string(rules, ', ') as rule,
+                path || ':' || start.line as location,
+                endpoint,
+                substring(extra.message, 1, 100) || '...' as message,
+                permalink
+            FROM findings
             ORDER BY
-                CASE unnest.extra.severity
-                    WHEN 'ERROR' THEN 1
-                    WHEN 'WARNING' THEN 2
-                    ELSE 3
-                END,
+                severity_rank,
                 repo,
-                unnest.path, unnest.start.line
+                path, start.line
         " || echo "No findings found."
 
         if [[ -z "$REPO" ]]; then
             total=$(duckdb_scalar "
-                SELECT count(*)
-                FROM $READ_JSON,
-                UNNEST(results)
+                $FINDINGS
+                SELECT count(*) FROM findings
             ")
             print_total "findings" "${total:-0}"
         fi
@@ -89,31 +343,41 @@ case "$FORMAT" in
 
     full)
         duckdb -json -c "
+            $FINDINGS
             SELECT
-                regexp_extract(filename, '([^/]+)\\.json(\\.gz)?\$', 1) as repo,
-                unnest.check_id,
-                unnest.path,
-                unnest.start,
-                unnest.\"end\",
-                unnest.extra
-            FROM $READ_JSON,
-            UNNEST(results)
-            ORDER BY repo, unnest.path, unnest.start.line
+                repo,
+                check_id,
+                rules,
+                confidence,
+                module,
+                affected_repos,
+                path,
+                start,
+                \"end\",
+                permalink,
+                extra
+            FROM findings
+            ORDER BY repo, path, start.line
         " 2>/dev/null | jq '.'
         ;;
 
     jsonl)
         duckdb -json -c "
+            $FINDINGS
             SELECT
-                regexp_extract(filename, '([^/]+)\\.json(\\.gz)?\$', 1) as repo,
-                unnest.check_id,
-                unnest.path,
-                unnest.start,
-                unnest.\"end\",
-                unnest.extra
-            FROM $READ_JSON,
-            UNNEST(results)
-            ORDER BY repo, unnest.path, unnest.start.line
+                repo,
+                check_id,
+                rules,
+                confidence,
+                module,
+                affected_repos,
+                path,
+                start,
+                \"end\",
+                permalink,
+                extra
+            FROM findings
+            ORDER BY repo, path, start.line
         " 2>/dev/null | jq -c '.[]'
         ;;
 
@@ -133,6 +397,112 @@ case "$FORMAT" in
         " || echo "No findings found."
         ;;
 
+    tiers)
+        for level in HIGH MEDIUM LOW; do
+            tier_count=$(duckdb_scalar "
+                $FINDINGS
+                SELECT count(*) FROM findings WHERE confidence = '$level'
+            ")
+            [[ "${tier_count:-0}" -eq 0 ]] && continue
+
+            echo "=== $level confidence (${tier_count}) ==="
+            run_duckdb "
+                $FINDINGS
+                SELECT
+                    $REPO_LABEL as repo,
+                    extra.severity as severity,
+                    array_to_string(rules, ', ') as rule,
+                    path || ':' || start.line as location,
+                    substring(extra.message, 1, 100) || '...' as message,
+                    permalink
+                FROM findings
+                WHERE confidence = '$level'
+                ORDER BY severity_rank, repo, path, start.line
+            " || true
+            echo ""
+        done
+        ;;
+
+    modules)
+        # extra.module / extra.submodule are added by scan-semgrep.sh
+        run_duckdb "
+            $FINDINGS
+            SELECT
+                repo,
+                CASE WHEN module = '' THEN '(root)' ELSE module END as module,
+                count(*) as findings,
+                count(*) FILTER (WHERE extra.severity = 'ERROR') as errors
+            FROM findings
+            GROUP BY repo, module
+            ORDER BY repo, findings DESC
+        " || echo "No findings found."
+        ;;
+
+    shared)
+        # Root causes found in more than one repo, most widespread first
+        run_duckdb "
+            $FINDINGS
+            SELECT
+                len(affected_repos) as repos,
+                CASE min(severity_rank) WHEN 1 THEN 'ERROR' WHEN 2 THEN 'WARNING' ELSE 'INFO' END as severity,
+                array_to_string(first(rules ORDER BY repo), ', ') as rule,
+                first(path || ':' || start.line ORDER BY repo) as example,
+                first(permalink ORDER BY repo) as example_link,
+                array_to_string(affected_repos, ', ') as affected_repos
+            FROM findings
+            WHERE len(affected_repos) > 1
+            GROUP BY shared_fingerprint, affected_repos
+            ORDER BY repos DESC, min(severity_rank), rule
+        " || echo "No findings shared across repos."
+
+        shared_total=$(duckdb_scalar "
+            $FINDINGS
+            SELECT count(*) - count(DISTINCT shared_fingerprint) FROM findings
+        ")
+        print_total "duplicate findings (hidden by --dedupe-repos)" "${shared_total:-0}"
+        ;;
+
+    diagnostics)
+        # Written by scan-semgrep.sh next to the results (merged in catalog scans)
+        if [[ -n "$CATALOG_MODE" ]]; then
+            diagnostics=$(gzip -dc "$RESULTS_DIR/semgrep-diagnostics.json.gz" 2>/dev/null || echo "[]")
+        else
+            shopt -s nullglob
+            diagnostics_files=("${RESULTS_DIR%/semgrep-results}/semgrep-diagnostics"/*.json)
+            shopt -u nullglob
+            if [[ ${#diagnostics_files[@]} -gt 0 ]]; then
+                diagnostics=$(jq -s '.' "${diagnostics_files[@]}")
+            else
+                diagnostics="[]"
+            fi
+        fi
+
+        if [[ -n "$REPO" ]]; then
+            diagnostics=$(echo "$diagnostics" | jq --arg repo "$REPO" 'map(select(.repo == $repo))')
+        fi
+
+        if [[ "$(echo "$diagnostics" | jq 'length')" -eq 0 ]]; then
+            echo "No diagnostics found. Re-run $SCANNER_CMD to generate them."
+            exit 0
+        fi
+
+        echo "$diagnostics" | jq -r '
+            .[] |
+            "\(.repo): \(.scanned_files) files scanned, \(.skipped | length) skipped, \(.partial // [] | length) partly parsed, \(.timeouts | length) rule timeouts",
+            "  Languages: \(.languages | to_entries | sort_by(-.value) | map("\(.key)=\(.value)") | join(", "))",
+            (if (.skipped | length) > 0 then
+                "  Skipped:   \(.skipped | group_by(.reason) | map("\(.[0].reason)=\(length)") | join(", "))"
+            else empty end),
+            (.skipped[] | select(.reason != "ignored") | "    [\(.reason)] \(.path)"),
+            (.partial // [] | .[] | "    [\(.reason)] \(.path)"),
+            (.timeouts[] | "    [timeout] \(.rule_id) on \(.path)"),
+            (.failed_passes // [] | .[] | "    [failed] \(.pass) pass, semgrep exit \(.exit_status)"),
+            ""
+        '
+
+        print_total "files scanned" "$(echo "$diagnostics" | jq 'map(.scanned_files) | add')"
+        ;;
+
     *)
         unknown_format "$FORMAT"
         ;;
@@ -0,0 +1,271 @@
+#!/usr/bin/env bash
+# Triage an org's findings in bulk
+#
+# Usage: ./scripts/findings.sh <org> <command> [filters] [options]
+#
+# Selects findings by rule, repo, path, severity, id, or current status and
+# lists them or sets their disposition in one step, so sweeping decisions
+# ("everything this rule finds in generated code is a false positive") don't
+# need scripting against the results. Dispositions are stored by finding
+# fingerprint and survive rescans; false-positive and wont-fix findings are
+# hidden by extract-semgrep-findings.sh.
+#
+# Examples:
+#   ./scripts/findings.sh acme-corp list --rule go-sql-injection
+#   ./scripts/findings.sh acme-corp update --rule X --repo Y --set fp --reason "generated code"
+#   ./scripts/findings.sh acme-corp update --path vendor/ --set wont-fix --reason "Third-party code" --dry-run
+#   ./scripts/findings.sh acme-corp clear --status needs-review --repo api
+
+set -euo pipefail
+
+SCRIPT_DIR="$(cd "$(dirname "${BASH_SOURCE[0]}")" && pwd)"
+source "$SCRIPT_DIR/lib/catalog-utils.sh"
+source "$SCRIPT_DIR/lib/finding-utils.sh"
+
+# Triage backend settings from .env
+if [[ -f "$CATALOG_ROOT/.env" ]]; then
+    set -a
+    # shellcheck disable=SC1091
+    source "$CATALOG_ROOT/.env"
+    set +a
+fi
+
+usage() {
+    cat << EOF
+Usage: $0 <org> <command> [filters] [options]
+
+List findings or set their triage disposition in bulk.
+
+Commands:
+    list                List matching findings with their status (default)
+    update              Set the status of every matching finding (--set and
+                        --reason required)
+    clear               Remove the disposition of matching findings
+
+Filters (combine to narrow):
+    --rule <id>         Rule id (full check_id or its last segment)
+    --repo <name>       Findings in this repo
+    --path <path>       Findings in this file or directory (repo-relative)
+    --severity <level>  critical, high, medium, low, or info (or semgrep's ERROR,
+                        WARNING, INFO)
+    --id <prefix>       Finding id or id prefix
+    --status <status>   Current status; "untriaged" for findings without one
+
+Options:
+    --set <status>      update: one of $TRIAGE_STATUSES (fp for short)
+    --reason <text>     update: why; recorded with the disposition
+    --by <name>         update: who decided (default: git user.name or \$USER)
+    --dry-run           update/clear: show what would change
+    --format <format>   list: table (default) or json
+    -h, --help          Show this help message
+
+update and clear need at least one filter. Dispositions are stored in
+catalog/tracked/<org>/triage.json, or in PostgreSQL with TRIAGE_BACKEND=postgres
+(see .env.example), with earlier ones kept as history.
+
+Examples:
+    $0 acme-corp list --status untriaged --severity ERROR
+    $0 acme-corp update --rule X --repo Y --set fp --reason "generated code"
+    $0 acme-corp update --path vendor/ --set wont-fix --reason "Third-party code" --dry-run
+EOF
+    exit 1
+}
+
+ORG=""
+COMMAND=""
+RULE=""
+REPO=""
+FILTER_PATH=""
+SEVERITY=""
+ID=""
+STATUS=""
+SET=""
+REASON=""
+BY=""
+DRY_RUN=false
+FORMAT="table"
+
+while [[ $# -gt 0 ]]; do
+    case "$1" in
+        --rule)
+            RULE="$2"
+            shift 2
+            ;;
+        --repo)
+            REPO="$2"
+            shift 2
+            ;;
+        --path)
+            FILTER_PATH="$2"
+            shift 2
+            ;;
+        --severity)
+            SEVERITY="$2"
+            shift 2
+            ;;
+        --id)
+            ID="$2"
+            shift 2
+            ;;
+        --status)
+            STATUS="$2"
+            shift 2
+            ;;
+        --set)
+            SET="$2"
+            shift 2
+            ;;
+        --reason)
+            REASON="$2"
+            shift 2
+            ;;
+        --by)
+            BY="$2"
+            shift 2
+            ;;
+        --dry-run)
+            DRY_RUN=true
+            shift
+            ;;
+        --format)
+            FORMAT="$2"
+            shift 2
+            ;;
+        -h|--help)
+            usage
+            ;;
+        -*)
+            echo "Unknown option: $1"
+            usage
+            ;;
+        *)
+            if [[ -z "$ORG" ]]; then
+                ORG="$1"
+            elif [[ -z "$COMMAND" ]]; then
+                COMMAND="$1"
+            else
+                echo "Unexpected argument: $1"
+                usage
+            fi
+            shift
+            ;;
+    esac
+done
+
+COMMAND="${COMMAND:-list}"
+
+if [[ -z "$ORG" ]]; then
+    echo "Error: org name is required"
+    echo ""
+    usage
+fi
+
+case "$FORMAT" in
+    table|json) ;;
+    *)
+        echo "Error: --format must be table or json"
+        exit 1
+        ;;
+esac
+
+validate_org_name "$ORG" || exit 1
+require_jq || exit 1
+
+if [[ ! -d "$CATALOG_ROOT/catalog/tracked/$ORG" ]]; then
+    echo "Error: '$ORG' is not tracked"
+    exit 1
+fi
+
+if [[ -n "$STATUS" && "$STATUS" != "untriaged" ]]; then
+    status_name=$(triage_status "$STATUS")
+    if [[ -z "$status_name" ]]; then
+        echo "Error: Unknown status '$STATUS' (use: $TRIAGE_STATUSES untriaged)"
+        exit 1
+    fi
+    STATUS="$status_name"
+fi
+
+FILTERS=$(jq -n -c --arg rule "$RULE" --arg repo "$REPO" --arg path "$FILTER_PATH" \
+    --arg severity "$SEVERITY" --arg id "$ID" --arg status "$STATUS" \
+    '{rule: $rule, repo: $repo, path: $path, severity: $severity, id: $id, status: $status}')
+
+if [[ "$COMMAND" == "update" || "$COMMAND" == "clear" ]] && \
+    [[ -z "$RULE$REPO$FILTER_PATH$SEVERITY$ID$STATUS" ]]; then
+    echo "Error: $COMMAND needs at least one filter (--rule, --repo, --path, --severity, --id, --status)"
+    exit 1
+fi
+
+# One line per finding: id, status, severity, location, rule
+print_table() {
+    printf "%-16s  %-14s  %-8s  %-40s  %s\n" "ID" "STATUS" "SEVERITY" "LOCATION" "RULE"
+    jq -r '[.id, (.triage.status // "-"), .severity, "\(.repo)/\(.path):\(.line)", (.check_id | split(".") | last)] | @tsv' | \
+        while IFS=$'\t' read -r id status severity location rule; do
+            printf "%-16s  %-14s  %-8s  %-40s  %s\n" "$id" "$status" "$severity" "$location" "$rule"
+        done
+}
+
+MATCHES=$(triage_matching "$ORG" "$FILTERS")
+COUNT=$(grep -c . <<< "$MATCHES" || true)
+
+case "$COMMAND" in
+    list)
+        if [[ "$FORMAT" == "json" ]]; then
+            jq -s -c '.' <<< "$MATCHES"
+            exit 0
+        fi
+        if [[ "$COUNT" -eq 0 ]]; then
+            echo "No matching findings for $ORG"
+            exit 0
+        fi
+        print_table <<< "$MATCHES"
+        echo ""
+        echo "$COUNT findings"
+        ;;
+
+    update)
+        if [[ -z "$SET" || -z "$REASON" ]]; then
+            echo "Error: update requires --set <status> and --reason <text>"
+            exit 1
+        fi
+        new_status=$(triage_status "$SET")
+        if [[ -z "$new_status" ]]; then
+            echo "Error: Unknown status '$SET' (use: $TRIAGE_STATUSES)"
+            exit 1
+        fi
+        if [[ "$COUNT" -eq 0 ]]; then
+            echo "No matching findings for $ORG"
+            exit 0
+        fi
+        if [[ "$DRY_RUN" == true ]]; then
+            print_table <<< "$MATCHES"
+            echo ""
+            echo "Would set $COUNT findings to $new_status (dry run)"
+            exit 0
+        fi
+        BY="${BY:-$(git config user.name 2>/dev/null || echo "${USER:-unknown}")}"
+        triage_set "$ORG" "$MATCHES" "$new_status" "$REASON" "$BY"
+        echo "Set $COUNT findings to $new_status: $REASON"
+        ;;
+
+    clear)
+        triaged=$(jq -c 'select(.triage != null)' <<< "$MATCHES")
+        cleared=$(grep -c . <<< "$triaged" || true)
+        if [[ "$cleared" -eq 0 ]]; then
+            echo "No matching findings have a disposition"
+            exit 0
+        fi
+        if [[ "$DRY_RUN" == true ]]; then
+            print_table <<< "$triaged"
+            echo ""
+            echo "Would clear $cleared dispositions (dry run)"
+            exit 0
+        fi
+        triage_clear "$ORG" "$(jq -r '.id' <<< "$triaged")"
+        echo "Cleared $cleared dispositions"
+        ;;
+
+    *)
+        echo "Unknown command: $COMMAND"
+        usage
+        ;;
+esac
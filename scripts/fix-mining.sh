@@ -0,0 +1,239 @@
+#!/usr/bin/env bash
+# Mine git history for security fixes and find the vulnerable pattern elsewhere
+#
+# Usage: ./scripts/fix-mining.sh <org> [repo] [options]
+#
+# A fix often patches the one place that was reported while copies of the
+# same code live on. Security fix commits are found by message (CVE/GHSA
+# ids, "xss", "injection", ...) and by diff shape (string-built SQL replaced
+# by placeholders, shell=True dropped, yaml.load replaced by safe_load; see
+# FIX_DIFF_PATTERNS in scripts/lib/history-utils.sh). Each line the fix
+# removed is then searched for in the current code: near-copies (same code
+# with any literals) first, then other lines matching the fix's pattern.
+#
+# Examples:
+#   ./scripts/fix-mining.sh acme-corp
+#   ./scripts/fix-mining.sh acme-corp api --since 2023-01-01
+#   ./scripts/fix-mining.sh acme-corp api --org-wide    # Search every repo for api's fixes
+
+set -euo pipefail
+
+SCRIPT_DIR="$(cd "$(dirname "${BASH_SOURCE[0]}")" && pwd)"
+source "$SCRIPT_DIR/lib/catalog-utils.sh"
+source "$SCRIPT_DIR/lib/finding-utils.sh"
+source "$SCRIPT_DIR/lib/history-utils.sh"
+
+usage() {
+    cat << EOF
+Usage: $0 <org> [repo] [options]
+
+Find security fix commits and check whether the code they removed still
+exists elsewhere.
+
+Options:
+    --since <date>      Only fixes committed after this date (git date syntax)
+    --org-wide          Search every active repo of the org, not just the fixed repo
+    --max-matches <n>   Pattern matches kept per fixed line (default: 20)
+    --all               Also list fixes with no remaining matches
+    --format <fmt>      summary (default) or json
+    -h, --help          Show this help message
+
+Matches:
+    copy        The removed line with only literals, numbers, or spacing changed
+    pattern     Another line matching the fix's vulnerable pattern (diff-matched
+                fixes only) that doesn't also match the fixed form
+
+Results are written to scans/<org>/fix-variants.json. Shallow clones only
+see the history they fetched.
+
+Examples:
+    $0 acme-corp
+    $0 acme-corp api --since "2 years ago"
+EOF
+    exit 1
+}
+
+ORG=""
+ONLY_REPO=""
+SINCE=""
+ORG_WIDE=false
+MAX_MATCHES=20
+SHOW_ALL=false
+FORMAT="summary"
+
+while [[ $# -gt 0 ]]; do
+    case "$1" in
+        --since)
+            SINCE="$2"
+            shift 2
+            ;;
+        --org-wide)
+            ORG_WIDE=true
+            shift
+            ;;
+        --max-matches)
+            MAX_MATCHES="$2"
+            shift 2
+            ;;
+        --all)
+            SHOW_ALL=true
+            shift
+            ;;
+        --format)
+            FORMAT="$2"
+            shift 2
+            ;;
+        -h|--help)
+            usage
+            ;;
+        -*)
+            echo "Unknown option: $1"
+            usage
+            ;;
+        *)
+            if [[ -z "$ORG" ]]; then
+                ORG="$1"
+            elif [[ -z "$ONLY_REPO" ]]; then
+                ONLY_REPO="$1"
+            else
+                echo "Unexpected argument: $1"
+                usage
+            fi
+            shift
+            ;;
+    esac
+done
+
+[[ -z "$ORG" ]] && usage
+
+case "$FORMAT" in
+    summary|json) ;;
+    *)
+        echo "Error: Unknown format '$FORMAT' (use summary or json)"
+        exit 1
+        ;;
+esac
+
+if [[ ! "$MAX_MATCHES" =~ ^[0-9]+$ ]]; then
+    echo "Error: --max-matches must be a number"
+    exit 1
+fi
+
+validate_org_name "$ORG" || exit 1
+require_jq || exit 1
+
+REPOS_DIR="$(get_org_repos_dir "$ORG")"
+OUTPUT_FILE="scans/$ORG/fix-variants.json"
+
+if [[ ! -d "$REPOS_DIR" ]]; then
+    echo "Error: No repos found at $REPOS_DIR"
+    echo "Run: ./scripts/clone-org-repos.sh $ORG"
+    exit 1
+fi
+if [[ -n "$ONLY_REPO" && ! -d "$REPOS_DIR/$ONLY_REPO" ]]; then
+    echo "Error: Repo not found: $REPOS_DIR/$ONLY_REPO"
+    exit 1
+fi
+
+TMP_DIR=$(mktemp -d)
+register_cleanup "$TMP_DIR"
+install_cancel_traps
+
+ALL_REPOS=()
+while IFS= read -r repo; do
+    [[ -n "$repo" ]] && ALL_REPOS+=("$repo")
+done < <(get_active_repos "$REPOS_DIR")
+
+# =============================================================================
+# Mining
+# =============================================================================
+
+FIXES="$TMP_DIR/fixes.jsonl"
+: > "$FIXES"
+
+for repo in ${ALL_REPOS[@]+"${ALL_REPOS[@]}"}; do
+    name=$(basename "$repo")
+    [[ -n "$ONLY_REPO" && "$name" != "$ONLY_REPO" ]] && continue
+
+    search_repos=("$repo")
+    [[ "$ORG_WIDE" == true ]] && search_repos=("${ALL_REPOS[@]}")
+
+    while IFS=$'\t' read -r sha day kind subject; do
+        [[ -z "$sha" ]] && continue
+        fixed_re=""
+        vuln_re=""
+        if [[ "$kind" != "message" ]]; then
+            vuln_re=$(awk -F'\t' -v k="$kind" '$1 == k { print $2 }' <<< "$FIX_DIFF_PATTERNS")
+            fixed_re=$(awk -F'\t' -v k="$kind" '$1 == k { print $3 }' <<< "$FIX_DIFF_PATTERNS")
+        fi
+
+        while IFS=$'\t' read -r file removed; do
+            [[ -z "$file" ]] && continue
+            ext="${file##*.}"
+            [[ "$ext" == "$file" ]] && continue
+            copy_re=$(code_line_regex "$removed")
+
+            matches="$TMP_DIR/matches.tsv"
+            : > "$matches"
+            for target in "${search_repos[@]}"; do
+                target_name=$(basename "$target")
+                pattern_occurrences "$target" "$copy_re" "$ext" | \
+                    awk -F'\t' -v OFS='\t' -v r="$target_name" '{ print r, $1, $2, "copy", $3 }' >> "$matches"
+                if [[ -n "$vuln_re" ]]; then
+                    pattern_occurrences "$target" "$vuln_re" "$ext" | \
+                        FIXED_RE="$fixed_re" awk -F'\t' -v OFS='\t' -v r="$target_name" \
+                            'BEGIN { fixed = ENVIRON["FIXED_RE"] } $3 !~ fixed { print r, $1, $2, "pattern", $3 }' | \
+                        head -n "$MAX_MATCHES" >> "$matches"
+                fi
+            done
+
+            jq -R -s -c --arg repo "$name" --arg sha "$sha" --arg date "$day" --arg kind "$kind" \
+                --arg subject "$subject" --arg file "$file" --arg removed "$removed" \
+                --arg url "$(commit_permalink "$repo" "$sha" "$ORG" 2>/dev/null || echo "")" '
+                split("\n") | map(select(. != "") | split("\t")
+                    | {repo: .[0], file: .[1], line: (.[2] | tonumber), match: .[3], text: .[4]})
+                | unique_by([.repo, .file, .line]) | sort_by((if .match == "copy" then 0 else 1 end), .repo, .file, .line)
+                | {repo: $repo, sha: $sha, date: $date, kind: $kind, subject: $subject,
+                   commit_url: (if $url == "" then null else $url end),
+                   file: $file, removed: $removed, matches: .}
+            ' "$matches" >> "$FIXES"
+        done < <(fix_removed_lines "$repo" "$sha" "$kind")
+    done < <(security_fix_commits "$repo" "$SINCE")
+done
+
+mkdir -p "$(dirname "$OUTPUT_FILE")"
+jq -s '.' "$FIXES" > "$OUTPUT_FILE"
+
+# =============================================================================
+# Output
+# =============================================================================
+
+if [[ "$FORMAT" == "json" ]]; then
+    cat "$OUTPUT_FILE"
+    exit 0
+fi
+
+fix_count=$(jq '[.[] | .sha] | unique | length' "$OUTPUT_FILE")
+if [[ "$fix_count" -eq 0 ]]; then
+    echo "No security fix commits found for $ORG${ONLY_REPO:+/$ONLY_REPO}${SINCE:+ since $SINCE}"
+    exit 0
+fi
+
+jq -r --argjson all "$SHOW_ALL" '
+    group_by(.sha) | sort_by(-([.[].matches[]] | length), .[0].date) | reverse
+    | map(select($all or ([.[].matches[]] | length) > 0))[]
+    | .[0] as $fix
+    | "\($fix.repo) \($fix.sha[0:10]) \($fix.date) [\($fix.kind)] \($fix.subject)",
+      (.[] | "  removed from \(.file): \(.removed | .[0:100])",
+             (if (.matches | length) == 0 then "    no remaining matches"
+              else (.matches[] | "    \(.match | . + " " * (8 - length)) \(.repo)/\(.file):\(.line)  \(.text | .[0:80])") end)),
+      ""
+' "$OUTPUT_FILE"
+
+jq -r '
+    "\([.[].sha] | unique | length) security fixes, "
+    + "\([.[] | select(.matches | length > 0) | .sha] | unique | length) with remaining matches "
+    + "(\([.[].matches[] | select(.match == "copy")] | length) copies, "
+    + "\([.[].matches[] | select(.match == "pattern")] | length) pattern matches)"
+' "$OUTPUT_FILE"
+echo "Results: $OUTPUT_FILE"
@@ -0,0 +1,314 @@
+#!/usr/bin/env bash
+# Create a draft GitHub Security Advisory for a finding
+#
+# Usage: ./scripts/ghsa-draft.sh <org> <finding-id> [options]
+#
+# Builds a repository security advisory from the finding - summary, CWE,
+# severity, the vulnerable code with a permalink, and affected and patched
+# versions from release-scan.sh - and creates it as a draft on the affected
+# repo with `gh api`. Creating advisories needs admin or security manager
+# access to the repo; without it, --report submits the same content through
+# private vulnerability reporting when the repo has it enabled.
+#
+# Examples:
+#   ./scripts/ghsa-draft.sh acme-corp 3f9a1c2b --dry-run
+#   ./scripts/ghsa-draft.sh acme-corp 3f9a1c2b --summary "SQL injection in report export"
+#   ./scripts/ghsa-draft.sh acme-corp api/app/db.py:42 --report
+
+set -euo pipefail
+
+SCRIPT_DIR="$(cd "$(dirname "${BASH_SOURCE[0]}")" && pwd)"
+source "$SCRIPT_DIR/lib/catalog-utils.sh"
+source "$SCRIPT_DIR/lib/finding-utils.sh"
+source "$SCRIPT_DIR/lib/history-utils.sh"
+source "$SCRIPT_DIR/lib/snippet-utils.sh"
+
+usage() {
+    cat << EOF
+Usage: $0 <org> <finding-id> [options]
+
+Create a draft GitHub Security Advisory pre-filled from a finding.
+
+Options:
+    --summary <text>        Advisory title (default: from the rule's CWE and the file)
+    --severity <level>      critical, high, medium, or low (default: from the finding)
+    --ecosystem <name>      Package ecosystem: npm, pip, go, maven, nuget, rubygems,
+                            composer, rust, pub, swift, actions, other
+                            (default: detected from the repo's manifest)
+    --package <name>        Package name (default: from the manifest, or the repo)
+    --github-repo <owner/name>  Repo to create the advisory on (default: origin remote)
+    --report                Submit through private vulnerability reporting instead
+                            (for repos you can't create advisories on)
+    --dry-run               Print the request body without calling GitHub
+    -h, --help              Show this help message
+
+Affected and patched versions come from ./scripts/release-scan.sh <org> <repo>;
+without its results the advisory lists no version range, to fill in on GitHub.
+Tags are converted to versions by dropping a leading "v".
+
+Examples:
+    $0 acme-corp 3f9a1c2b --dry-run
+    $0 acme-corp 3f9a1c2b --severity critical --summary "RCE via template injection"
+EOF
+    exit 1
+}
+
+ORG=""
+FINDING_ID=""
+SUMMARY=""
+SEVERITY=""
+ECOSYSTEM=""
+PACKAGE=""
+GH_REPO=""
+REPORT=false
+DRY_RUN=false
+
+while [[ $# -gt 0 ]]; do
+    case "$1" in
+        --summary)
+            SUMMARY="$2"
+            shift 2
+            ;;
+        --severity)
+            SEVERITY="$2"
+            shift 2
+            ;;
+        --ecosystem)
+            ECOSYSTEM="$2"
+            shift 2
+            ;;
+        --package)
+            PACKAGE="$2"
+            shift 2
+            ;;
+        --github-repo)
+            GH_REPO="$2"
+            shift 2
+            ;;
+        --report)
+            REPORT=true
+            shift
+            ;;
+        --dry-run)
+            DRY_RUN=true
+            shift
+            ;;
+        -h|--help)
+            usage
+            ;;
+        -*)
+            echo "Unknown option: $1"
+            usage
+            ;;
+        *)
+            if [[ -z "$ORG" ]]; then
+                ORG="$1"
+            elif [[ -z "$FINDING_ID" ]]; then
+                FINDING_ID="$1"
+            else
+                echo "Unexpected argument: $1"
+                usage
+            fi
+            shift
+            ;;
+    esac
+done
+
+[[ -z "$ORG" || -z "$FINDING_ID" ]] && usage
+
+case "$SEVERITY" in
+    ""|critical|high|medium|low) ;;
+    *)
+        echo "Error: Unknown severity '$SEVERITY' (use critical, high, medium, or low)"
+        exit 1
+        ;;
+esac
+
+case "$ECOSYSTEM" in
+    ""|npm|pip|go|maven|nuget|rubygems|composer|rust|erlang|pub|swift|actions|other) ;;
+    *)
+        echo "Error: Unknown ecosystem '$ECOSYSTEM'"
+        exit 1
+        ;;
+esac
+
+validate_org_name "$ORG" || exit 1
+require_jq || exit 1
+
+if [[ "$DRY_RUN" == false ]]; then
+    if ! command -v gh &> /dev/null; then
+        echo "Error: GitHub CLI (gh) is required but not installed."
+        echo "Install with: brew install gh"
+        exit 1
+    fi
+    if ! gh auth status &> /dev/null; then
+        echo "Error: Not authenticated with GitHub CLI. Run: gh auth login"
+        exit 1
+    fi
+fi
+
+# =============================================================================
+# Finding
+# =============================================================================
+
+status=0
+FINDING=$(find_org_finding "$ORG" "$FINDING_ID") || status=$?
+if [[ "$status" -eq 2 ]]; then
+    echo "Error: '$FINDING_ID' matches more than one finding; use a longer id"
+    exit 1
+elif [[ "$status" -ne 0 ]]; then
+    echo "Error: No finding matches '$FINDING_ID'"
+    echo "List ids with: ./scripts/variants.sh $ORG --list"
+    exit 1
+fi
+
+ID=$(jq -r '.id' <<< "$FINDING")
+REPO_NAME=$(jq -r '.repo' <<< "$FINDING")
+REPO="$(get_org_repos_dir "$ORG")/$REPO_NAME"
+
+if [[ -z "$GH_REPO" ]]; then
+    web_url=$(repo_web_url "$REPO" 2>/dev/null || true)
+    if [[ "$web_url" != https://github.com/* ]]; then
+        echo "Error: $REPO_NAME is not hosted on github.com${web_url:+ ($web_url)}"
+        echo "Pass the advisory repo with --github-repo <owner/name>"
+        exit 1
+    fi
+    GH_REPO="${web_url#https://github.com/}"
+fi
+
+# Ecosystem and package name from the first manifest found at the repo root
+# Prints: ecosystem, package name (tab-separated; the name may be empty)
+repo_advisory_package() {
+    local repo="$1"
+    local name=""
+
+    if [[ -f "$repo/package.json" ]]; then
+        printf 'npm\t%s\n' "$(jq -r '.name // empty' "$repo/package.json" 2>/dev/null)"
+    elif [[ -f "$repo/go.mod" ]]; then
+        printf 'go\t%s\n' "$(awk '$1 == "module" { print $2; exit }' "$repo/go.mod")"
+    elif [[ -f "$repo/Cargo.toml" ]]; then
+        printf 'rust\t%s\n' "$(sed -n 's/^name *= *"\(.*\)".*/\1/p' "$repo/Cargo.toml" | head -n 1)"
+    elif [[ -f "$repo/pyproject.toml" || -f "$repo/setup.py" || -f "$repo/setup.cfg" ]]; then
+        [[ -f "$repo/pyproject.toml" ]] && name=$(sed -n 's/^name *= *"\(.*\)".*/\1/p' "$repo/pyproject.toml" | head -n 1)
+        printf 'pip\t%s\n' "$name"
+    elif [[ -f "$repo/composer.json" ]]; then
+        printf 'composer\t%s\n' "$(jq -r '.name // empty' "$repo/composer.json" 2>/dev/null)"
+    elif ls "$repo"/*.gemspec > /dev/null 2>&1; then
+        printf 'rubygems\t%s\n' "$(basename "$(ls "$repo"/*.gemspec | head -n 1)" .gemspec)"
+    elif [[ -f "$repo/pom.xml" ]]; then
+        printf 'maven\t\n'
+    elif [[ -f "$repo/pubspec.yaml" ]]; then
+        printf 'pub\t%s\n' "$(sed -n 's/^name: *//p' "$repo/pubspec.yaml" | head -n 1)"
+    elif [[ -f "$repo/Package.swift" ]]; then
+        printf 'swift\t\n'
+    elif [[ -f "$repo/action.yml" || -f "$repo/action.yaml" ]]; then
+        printf 'actions\t\n'
+    else
+        printf 'other\t\n'
+    fi
+}
+
+IFS=$'\t' read -r detected_ecosystem detected_package <<< "$(repo_advisory_package "$REPO")"
+ECOSYSTEM="${ECOSYSTEM:-$detected_ecosystem}"
+PACKAGE="${PACKAGE:-${detected_package:-$GH_REPO}}"
+
+AFFECTED=$(finding_affected_versions "$ORG" "$FINDING")
+if [[ "$AFFECTED" == "null" ]]; then
+    echo "Warning: No affected versions for this finding; run ./scripts/release-scan.sh $ORG $REPO_NAME to fill them in" >&2
+fi
+
+# =============================================================================
+# Advisory
+# =============================================================================
+
+# One vulnerability entry per affected range; tags become versions by
+# dropping a leading "v", and HEAD (unreleased) never counts as a patch
+BODY=$(jq -n --argjson f "$FINDING" --argjson affected "$AFFECTED" \
+    --arg summary "$SUMMARY" --arg severity "$SEVERITY" \
+    --arg ecosystem "$ECOSYSTEM" --arg package "$PACKAGE" \
+    --arg code "$(finding_snippet "$ORG" "$FINDING" | snippet_markdown)" "$SEVERITY_JQ_DEFS$REMEDIATION_JQ_DEFS"'
+    def version: sub("^<="; "") | sub("^[vV](?=[0-9])"; "");
+    def released: . != null and . != "HEAD";
+    (($f.cwe[0] // "") | if test(":") then sub("^[^:]*:\\s*"; "") else ($f.check_id | split(".") | last | gsub("-"; " ")) end) as $type
+    | {
+        summary: (if $summary != "" then $summary else "\($type) in \($f.path)" end)[0:1024],
+        description: ([
+            "### Impact",
+            "",
+            $f.message,
+            "",
+            "### Details",
+            "",
+            "`\($f.path)` line \($f.line)\(if $f.permalink then " ([permalink](" + $f.permalink + "))" else "" end):",
+            "",
+            $code,
+            "",
+            (if $f.remediation then "### Remediation", "", ($f.remediation | remediation_markdown), "" else empty end),
+            (if $affected then
+                "### Affected versions",
+                "",
+                ($affected.ranges[] | "- \(if .from | startswith("<=") then "\(.to) and earlier" elif .from == .to then .from elif .to == "HEAD" then "\(.from) and later" else "\(.from) through \(.to)" end)"
+                    + (if .fixed_in | released then ", fixed in \(.fixed_in)" elif .fixed_in == "HEAD" then ", fixed on the default branch (unreleased)" else ", unfixed" end)),
+                ""
+             else empty end),
+            "### Patches",
+            "",
+            "TODO",
+            "",
+            "### Workarounds",
+            "",
+            "TODO"
+        ] | join("\n")),
+        severity: (if $severity != "" then $severity
+                   else $f.severity | severity_level("semgrep") | if . == "info" then "low" else . end end),
+        cwe_ids: ([$f.cwe[] | capture("(?<id>CWE-[0-9]+)").id] | unique),
+        vulnerabilities: (
+            if $affected == null then [{package: {ecosystem: $ecosystem, name: $package}}]
+            else [$affected.ranges[] | {
+                package: {ecosystem: $ecosystem, name: $package},
+                vulnerable_version_range: (
+                    if .from | startswith("<=") then "<= \(.to | version)"
+                    elif .fixed_in | released then ">= \(.from | version), < \(.fixed_in | version)"
+                    else ">= \(.from | version)" end),
+                patched_versions: (if .fixed_in | released then (.fixed_in | version) else null end)
+            }] end)
+    }
+')
+
+ENDPOINT="repos/$GH_REPO/security-advisories"
+[[ "$REPORT" == true ]] && ENDPOINT="$ENDPOINT/reports"
+
+if [[ "$DRY_RUN" == true ]]; then
+    echo "POST $ENDPOINT"
+    echo "$BODY"
+    exit 0
+fi
+
+if [[ "$REPORT" == false ]]; then
+    permissions=$(gh api "repos/$GH_REPO" --jq '.permissions // {}' 2>/dev/null || echo '{}')
+    if [[ "$(jq -r '.admin // false' <<< "$permissions")" != "true" ]]; then
+        echo "Note: You are not an admin of $GH_REPO; creating the advisory needs admin or security manager access." >&2
+        echo "If it fails, submit a private report instead: $0 $ORG $FINDING_ID --report" >&2
+    fi
+fi
+
+if ! response=$(gh api -X POST "$ENDPOINT" --input - <<< "$BODY" 2>&1); then
+    echo "Error: GitHub rejected the advisory:"
+    echo "$response" | sed 's/^/  /'
+    exit 1
+fi
+
+ADVISORY_URL=$(jq -r '.html_url // empty' <<< "$response")
+GHSA_ID=$(jq -r '.ghsa_id // empty' <<< "$response")
+if [[ "$REPORT" == true ]]; then
+    echo "Submitted private vulnerability report $GHSA_ID: $ADVISORY_URL"
+else
+    echo "Created draft advisory $GHSA_ID: $ADVISORY_URL"
+fi
+
+# Record the advisory on the finding's disclosure, if it has one
+DISCLOSURE_ID=$(finding_disclosure "$ORG" "$ID" | jq -r '.id // empty')
+if [[ -n "$DISCLOSURE_ID" ]]; then
+    "$SCRIPT_DIR/disclosure.sh" "$ORG" update "$DISCLOSURE_ID" --note "GitHub advisory $GHSA_ID: $ADVISORY_URL" > /dev/null
+    echo "Noted on disclosure $DISCLOSURE_ID"
+fi
@@ -0,0 +1,317 @@
+#!/usr/bin/env bash
+# Scan staged changes before they are committed
+#
+# Usage: ./scripts/hook.sh [command] [options]
+#
+# `install` adds a git pre-commit hook to a repository that runs `hook.sh
+# run`. Each commit then gets the staged content (the index, not the
+# working tree) scanned: semgrep with the HIGH-confidence custom rules for
+# the staged languages, and trufflehog without verification, so nothing
+# leaves the machine. Findings print one line each, and the commit is
+# blocked when one is at the --fail-on severity or above.
+#
+# There is no resident scanner to hand the work to, so each run pays
+# semgrep's startup. Commits that stage no source files return before any
+# scanner starts, and a run that passes --timeout lets the commit through
+# with a warning instead of holding it up.
+#
+# Examples:
+#   ./scripts/hook.sh install --repo ~/src/api
+#   ./scripts/hook.sh install --repo ~/src/api --fail-on high --timeout 10s
+#   ./scripts/hook.sh run                       # What the hook runs
+#   BH_HOOK_SKIP=1 git commit ...               # Skip once (or git commit --no-verify)
+
+set -euo pipefail
+
+SCRIPT_DIR="$(cd "$(dirname "${BASH_SOURCE[0]}")" && pwd)"
+source "$SCRIPT_DIR/lib/catalog-utils.sh"
+source "$SCRIPT_DIR/lib/rule-utils.sh"
+source "$SCRIPT_DIR/lib/severity-utils.sh"
+
+usage() {
+    cat << EOF
+Usage: $0 [command] [options]
+
+Scan staged changes for vulnerabilities and secrets, as a git pre-commit hook.
+
+Commands:
+    run                 Scan the staged changes (default; what the hook runs)
+    install             Install the pre-commit hook in a repository
+    uninstall           Remove the pre-commit hook
+
+Options:
+    --repo <path>       Repository (default: the current directory)
+    --fail-on <level>   Block the commit on findings at this severity or above:
+                        $SEVERITY_LEVELS (default: medium)
+    --timeout <dur>     Let the commit through when the scan takes longer
+                        (e.g. 10s, 1m; default: 30s)
+    --all-rules         Run every custom rule for the staged languages, not
+                        only the HIGH-confidence ones
+    --no-secrets        Skip the trufflehog secret scan
+    --force             install: replace an existing pre-commit hook (it is
+                        kept as pre-commit.bak and restored by uninstall)
+    -h, --help          Show this help message
+
+Options given to install are written into the hook. Skip the hook for one
+commit with BH_HOOK_SKIP=1 or git commit --no-verify.
+
+Exit codes:
+    0  Nothing at --fail-on or above (or the scan timed out)
+    1  Findings block the commit (or usage error)
+EOF
+    exit 1
+}
+
+COMMAND=""
+REPO="."
+FAIL_ON="medium"
+TIMEOUT="30s"
+ALL_RULES=false
+USE_SECRETS=true
+FORCE=false
+HOOK_ARGS=()
+
+while [[ $# -gt 0 ]]; do
+    case "$1" in
+        --repo)
+            REPO="$2"
+            shift 2
+            ;;
+        --fail-on)
+            FAIL_ON="$2"
+            HOOK_ARGS+=("$1" "$2")
+            shift 2
+            ;;
+        --timeout)
+            TIMEOUT="$2"
+            HOOK_ARGS+=("$1" "$2")
+            shift 2
+            ;;
+        --all-rules)
+            ALL_RULES=true
+            HOOK_ARGS+=("$1")
+            shift
+            ;;
+        --no-secrets)
+            USE_SECRETS=false
+            HOOK_ARGS+=("$1")
+            shift
+            ;;
+        --force)
+            FORCE=true
+            shift
+            ;;
+        -h|--help)
+            usage
+            ;;
+        -*)
+            echo "Unknown option: $1"
+            usage
+            ;;
+        *)
+            if [[ -z "$COMMAND" ]]; then
+                COMMAND="$1"
+            else
+                echo "Unexpected argument: $1"
+                usage
+            fi
+            shift
+            ;;
+    esac
+done
+
+COMMAND="${COMMAND:-run}"
+
+validate_severity "$FAIL_ON" --fail-on || exit 1
+if ! TIMEOUT_SECONDS=$(parse_duration_seconds "$TIMEOUT"); then
+    echo "Error: Invalid --timeout '$TIMEOUT' (e.g. 10s, 1m)"
+    exit 1
+fi
+
+if ! TOPLEVEL=$(git -C "$REPO" rev-parse --show-toplevel 2>/dev/null); then
+    echo "Error: '$REPO' is not a git repository"
+    exit 1
+fi
+
+HOOK_MARKER="# bounty-hunter pre-commit hook"
+
+# The hook file git runs (honors core.hooksPath)
+# Prints an absolute path
+hook_path() {
+    local path
+    path=$(git -C "$TOPLEVEL" rev-parse --git-path hooks/pre-commit)
+    [[ "$path" == /* ]] || path="$TOPLEVEL/$path"
+    echo "$path"
+}
+
+# =============================================================================
+# Install
+# =============================================================================
+
+case "$COMMAND" in
+    install)
+        HOOK=$(hook_path)
+        mkdir -p "$(dirname "$HOOK")"
+        if [[ -f "$HOOK" ]] && ! grep -qF "$HOOK_MARKER" "$HOOK"; then
+            if [[ "$FORCE" != true ]]; then
+                echo "Error: $HOOK already exists; use --force to replace it (kept as pre-commit.bak)"
+                exit 1
+            fi
+            mv "$HOOK" "$HOOK.bak"
+        fi
+        {
+            echo "#!/usr/bin/env bash"
+            echo "$HOOK_MARKER (installed by scripts/hook.sh install)"
+            echo '[[ -n "${BH_HOOK_SKIP:-}" ]] && exit 0'
+            printf 'exec %q run' "$SCRIPT_DIR/hook.sh"
+            for arg in ${HOOK_ARGS[@]+"${HOOK_ARGS[@]}"}; do
+                printf ' %q' "$arg"
+            done
+            echo ""
+        } > "$HOOK"
+        chmod +x "$HOOK"
+        echo "Installed $HOOK"
+        exit 0
+        ;;
+
+    uninstall)
+        HOOK=$(hook_path)
+        if [[ ! -f "$HOOK" ]] || ! grep -qF "$HOOK_MARKER" "$HOOK"; then
+            echo "Error: No bounty-hunter pre-commit hook in $TOPLEVEL"
+            exit 1
+        fi
+        rm -f "$HOOK"
+        if [[ -f "$HOOK.bak" ]]; then
+            mv "$HOOK.bak" "$HOOK"
+            echo "Removed the hook and restored the previous one"
+        else
+            echo "Removed $HOOK"
+        fi
+        exit 0
+        ;;
+
+    run)
+        ;;
+
+    *)
+        echo "Unknown command: $COMMAND"
+        usage
+        ;;
+esac
+
+# =============================================================================
+# Staged content
+# =============================================================================
+
+require_jq || exit 1
+
+STARTED=$(date +%s)
+WORK=$(mktemp -d)
+register_cleanup "$WORK"
+install_cancel_traps
+
+# The index as it will be committed, not the working tree, so unstaged
+# edits neither hide nor add findings
+STAGED="$WORK/staged"
+mkdir -p "$STAGED"
+git -C "$TOPLEVEL" diff --cached --name-only -z --diff-filter=ACMR | \
+    (cd "$TOPLEVEL" && git checkout-index -z --stdin --prefix="$STAGED/")
+
+if [[ -z "$(ls -A "$STAGED")" ]]; then
+    exit 0
+fi
+
+LANGUAGES=$(detect_repo_languages "$STAGED")
+
+# =============================================================================
+# Scan
+# =============================================================================
+
+FINDINGS="$WORK/findings.jsonl"
+: > "$FINDINGS"
+SCAN_STATUS=0
+
+# Semgrep, when a staged file is in a language the rule packs cover
+# Run from the toolkit root, where check ids are computed (see
+# semgrep_rule_check_id)
+run_semgrep_staged() {
+    semgrep scan \
+        --metrics=off \
+        --quiet \
+        --dataflow-traces \
+        "${CUSTOM_RULE_ARGS[@]}" \
+        ${BUDGET_EXCLUDE_ARGS[@]+"${BUDGET_EXCLUDE_ARGS[@]}"} \
+        --json \
+        --output="$WORK/semgrep.json" \
+        "$STAGED" > /dev/null 2>&1 || true
+}
+
+# Trufflehog without verification: offline, and every match counts
+run_trufflehog_staged() {
+    trufflehog filesystem "$STAGED" --no-verification --no-update --json \
+        > "$WORK/trufflehog.jsonl" 2>/dev/null || true
+}
+
+cd "$CATALOG_ROOT"
+if [[ -n "$LANGUAGES" ]] && command -v semgrep &> /dev/null; then
+    build_routed_rule_args "$RULES_ROOT" "$LANGUAGES"
+    BUDGET_EXCLUDE_ARGS=()
+    [[ "$ALL_RULES" == true ]] || build_budget_rule_excludes "$RULES_ROOT"
+    if [[ ${#CUSTOM_RULE_ARGS[@]} -gt 0 ]]; then
+        run_with_deadline "$TIMEOUT_SECONDS" run_semgrep_staged || SCAN_STATUS=$?
+    fi
+fi
+
+if [[ "$USE_SECRETS" == true && "$SCAN_STATUS" -eq 0 ]] && command -v trufflehog &> /dev/null; then
+    remaining=$((TIMEOUT_SECONDS - ($(date +%s) - STARTED)))
+    if [[ "$remaining" -le 0 ]]; then
+        SCAN_STATUS=124
+    else
+        run_with_deadline "$remaining" run_trufflehog_staged || SCAN_STATUS=$?
+    fi
+fi
+
+if [[ "$SCAN_STATUS" -eq 124 ]]; then
+    echo "bounty-hunter: scan of staged changes took over $TIMEOUT, not blocking the commit" >&2
+    exit 0
+fi
+
+if [[ -s "$WORK/semgrep.json" ]]; then
+    jq -c --arg prefix "$STAGED/" "$SEVERITY_JQ_DEFS"'
+        .results[]? | {
+            path: (.path | ltrimstr($prefix)), line: .start.line,
+            severity: (.extra.severity | severity_level("semgrep")),
+            rule: (.check_id | split(".") | last),
+            message: (.extra.message | split("\n")[0])
+        }' "$WORK/semgrep.json" >> "$FINDINGS"
+fi
+if [[ -s "$WORK/trufflehog.jsonl" ]]; then
+    jq -c --arg prefix "$STAGED/" "$SEVERITY_JQ_DEFS"'
+        select(.DetectorName) | (.SourceMetadata.Data.Filesystem // {}) as $source | {
+            path: ($source.file // "" | ltrimstr($prefix)), line: ($source.line // 1),
+            severity: ((if .Verified then "VERIFIED" else "UNVERIFIED" end) | severity_level("trufflehog")),
+            rule: "secret-\(.DetectorName | ascii_downcase)",
+            message: "\(.DetectorName) credential"
+        }' "$WORK/trufflehog.jsonl" >> "$FINDINGS" 2>/dev/null || true
+fi
+
+# =============================================================================
+# Report
+# =============================================================================
+
+COUNT=$(grep -c . "$FINDINGS" || true)
+[[ "$COUNT" -eq 0 ]] && exit 0
+
+BLOCKING=$(jq -s --arg min "$FAIL_ON" "$SEVERITY_JQ_DEFS"'
+    map(select(.severity | severity_at_least($min))) | length' "$FINDINGS")
+
+echo "bounty-hunter: $COUNT findings in staged changes ($(( $(date +%s) - STARTED ))s)" >&2
+jq -r -s "$SEVERITY_JQ_DEFS"'
+    sort_by([(.severity | severity_rank), .path, .line])[]
+    | "  \(.path):\(.line)  \(.severity)  \(.rule)  \(.message)"' "$FINDINGS" >&2
+
+if [[ "$BLOCKING" -gt 0 ]]; then
+    echo "Commit blocked: $BLOCKING at $FAIL_ON severity or above. Fix them, mark a false positive with a" >&2
+    echo "nosemgrep comment, or skip the check once with git commit --no-verify." >&2
+    exit 1
+fi
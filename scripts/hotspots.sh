@@ -0,0 +1,214 @@
+#!/usr/bin/env bash
+# Rank directories by churn, contributor turnover, and finding density
+#
+# Usage: ./scripts/hotspots.sh <org> [repo] [options]
+#
+# Code that changes often, changes hands, and already has findings is where
+# new bugs are most likely. Each directory (grouped at --depth) is scored
+# against the busiest directory in its repo: 40% churn (commits and lines
+# changed in the window), 20% turnover (share of its recent authors who are
+# new to it), and 40% severity-weighted semgrep findings.
+#
+# Examples:
+#   ./scripts/hotspots.sh acme-corp
+#   ./scripts/hotspots.sh acme-corp api --depth 3 --days 180
+#   ./scripts/hotspots.sh acme-corp --heatmap hotspots.html
+
+set -euo pipefail
+
+SCRIPT_DIR="$(cd "$(dirname "${BASH_SOURCE[0]}")" && pwd)"
+source "$SCRIPT_DIR/lib/catalog-utils.sh"
+source "$SCRIPT_DIR/lib/risk-utils.sh"
+
+usage() {
+    cat << EOF
+Usage: $0 <org> [repo] [options]
+
+Rank directories as bug hotspots from git churn, contributor turnover, and
+semgrep finding density.
+
+Options:
+    --depth <n>         Directory depth to group files at (default: $HOTSPOT_DEPTH)
+    --days <n>          Churn window in days (default: $RISK_CHURN_DAYS)
+    --limit <n>         Hotspots to list (default: 20, 0 = all)
+    --format <fmt>      table (default) or json
+    --heatmap <file>    Also write an HTML heatmap (one row per directory)
+    -h, --help          Show this help message
+
+The full ranking is written to scans/<org>/hotspots.json. Shallow clones
+only see the history they fetched: re-clone with full history for
+accurate turnover.
+
+Examples:
+    $0 acme-corp
+    $0 acme-corp api --depth 3
+    $0 acme-corp --heatmap hotspots.html
+EOF
+    exit 1
+}
+
+ORG=""
+ONLY_REPO=""
+LIMIT=20
+FORMAT="table"
+HEATMAP=""
+
+while [[ $# -gt 0 ]]; do
+    case "$1" in
+        --depth)
+            HOTSPOT_DEPTH="$2"
+            shift 2
+            ;;
+        --days)
+            RISK_CHURN_DAYS="$2"
+            shift 2
+            ;;
+        --limit)
+            LIMIT="$2"
+            shift 2
+            ;;
+        --format)
+            FORMAT="$2"
+            shift 2
+            ;;
+        --heatmap)
+            HEATMAP="$2"
+            shift 2
+            ;;
+        -h|--help)
+            usage
+            ;;
+        -*)
+            echo "Unknown option: $1"
+            usage
+            ;;
+        *)
+            if [[ -z "$ORG" ]]; then
+                ORG="$1"
+            elif [[ -z "$ONLY_REPO" ]]; then
+                ONLY_REPO="$1"
+            else
+                echo "Unexpected argument: $1"
+                usage
+            fi
+            shift
+            ;;
+    esac
+done
+
+[[ -z "$ORG" ]] && usage
+
+case "$FORMAT" in
+    table|json) ;;
+    *)
+        echo "Error: Unknown format '$FORMAT' (use table or json)"
+        exit 1
+        ;;
+esac
+
+for value in "$HOTSPOT_DEPTH" "$RISK_CHURN_DAYS" "$LIMIT"; do
+    if [[ ! "$value" =~ ^[0-9]+$ ]]; then
+        echo "Error: --depth, --days, and --limit must be numbers"
+        exit 1
+    fi
+done
+
+validate_org_name "$ORG" || exit 1
+require_jq || exit 1
+
+REPOS_DIR="$(get_org_repos_dir "$ORG")"
+RESULTS_DIR="scans/$ORG/semgrep-results"
+OUTPUT_FILE="scans/$ORG/hotspots.json"
+
+if [[ ! -d "$REPOS_DIR" ]]; then
+    echo "Error: No repos found at $REPOS_DIR"
+    echo "Run: ./scripts/clone-org-repos.sh $ORG"
+    exit 1
+fi
+if [[ -n "$ONLY_REPO" && ! -d "$REPOS_DIR/$ONLY_REPO" ]]; then
+    echo "Error: Repo not found: $REPOS_DIR/$ONLY_REPO"
+    exit 1
+fi
+
+TMP_DIR=$(mktemp -d)
+register_cleanup "$TMP_DIR"
+install_cancel_traps
+
+# =============================================================================
+# Analysis
+# =============================================================================
+
+HOTSPOTS="[]"
+while IFS= read -r repo; do
+    [[ -z "$repo" ]] && continue
+    name=$(basename "$repo")
+    [[ -n "$ONLY_REPO" && "$name" != "$ONLY_REPO" ]] && continue
+
+    directory_churn "$repo" > "$TMP_DIR/$name.churn"
+    results="$RESULTS_DIR/$name.json.gz"
+    [[ ! -f "$results" ]] && results="$RESULTS_DIR/$name.json"
+    directory_findings "$results" "$name" > "$TMP_DIR/$name.findings"
+
+    repo_hotspots=$(score_hotspots "$name" "$TMP_DIR/$name.churn" "$TMP_DIR/$name.findings")
+    HOTSPOTS=$(jq -n --argjson all "$HOTSPOTS" --argjson h "$repo_hotspots" '$all + $h')
+done < <(get_active_repos "$REPOS_DIR")
+
+HOTSPOTS=$(jq 'sort_by(-.score, .repo, .dir)' <<< "$HOTSPOTS")
+mkdir -p "$(dirname "$OUTPUT_FILE")"
+echo "$HOTSPOTS" > "$OUTPUT_FILE"
+
+# =============================================================================
+# Heatmap
+# =============================================================================
+
+if [[ -n "$HEATMAP" ]]; then
+    jq -r --arg org "$ORG" --argjson days "$RISK_CHURN_DAYS" --argjson depth "$HOTSPOT_DEPTH" '
+        def ratio($a; $b): if $b > 0 then $a / $b else 0 end;
+        def cell($v; $max; $text):
+            (ratio($v; $max) * 0.85 | . * 100 | round / 100) as $a
+            | "<td style=\"background: rgba(220, 38, 38, \($a))\">\($text | tostring | @html)</td>";
+        ([.[].commits] | max // 0) as $mc
+        | ([.[].lines_changed] | max // 0) as $ml
+        | ([.[].weighted_findings] | max // 0) as $mf
+        | "<!DOCTYPE html>",
+          "<html><head><meta charset=\"utf-8\"><title>Hotspots: \($org | @html)</title>",
+          "<style>body{font-family:sans-serif;margin:2em}table{border-collapse:collapse}th,td{padding:4px 10px;border:1px solid #ddd;text-align:right}td.dir,th.dir{text-align:left;font-family:monospace}</style>",
+          "</head><body>",
+          "<h1>Hotspots: \($org | @html)</h1>",
+          "<p>Last \($days) days, directories at depth \($depth). Darker cells are higher relative to the busiest directory.</p>",
+          "<table><tr><th class=\"dir\">Repo / directory</th><th>Score</th><th>Commits</th><th>Lines changed</th><th>Authors</th><th>Turnover</th><th>Findings</th></tr>",
+          (.[] | "<tr><td class=\"dir\">\("\(.repo)/\(.dir)" | @html)</td>"
+              + cell(.score; 100; .score) + cell(.commits; $mc; .commits) + cell(.lines_changed; $ml; .lines_changed)
+              + "<td>\(.authors) (\(.new_authors) new)</td>" + cell(.turnover; 1; "\(.turnover * 100 | round)%")
+              + cell(.weighted_findings; $mf; .findings) + "</tr>"),
+          "</table></body></html>"
+    ' <<< "$HOTSPOTS" > "$HEATMAP"
+fi
+
+# =============================================================================
+# Output
+# =============================================================================
+
+LISTED="$HOTSPOTS"
+[[ "$LIMIT" -gt 0 ]] && LISTED=$(jq --argjson n "$LIMIT" '.[:$n]' <<< "$HOTSPOTS")
+
+if [[ "$FORMAT" == "json" ]]; then
+    echo "$LISTED"
+    exit 0
+fi
+
+total=$(jq length <<< "$HOTSPOTS")
+if [[ "$total" -eq 0 ]]; then
+    echo "No commits in the last $RISK_CHURN_DAYS days and no findings for $ORG${ONLY_REPO:+/$ONLY_REPO}"
+    exit 0
+fi
+
+printf "%5s  %-45s  %7s  %7s  %9s  %8s\n" "SCORE" "DIRECTORY" "COMMITS" "LINES" "TURNOVER" "FINDINGS"
+jq -r '.[] | ["\(.repo)/\(.dir)", .score, .commits, .lines_changed, "\(.turnover * 100 | round)%", .findings] | @tsv' <<< "$LISTED" | \
+    while IFS=$'\t' read -r dir score commits lines turnover findings; do
+        printf "%5s  %-45s  %7s  %7s  %9s  %8s\n" "$score" "$dir" "$commits" "$lines" "$turnover" "$findings"
+    done
+echo ""
+echo "$total directories ranked (last $RISK_CHURN_DAYS days, depth $HOTSPOT_DEPTH): $OUTPUT_FILE"
+[[ -n "$HEATMAP" ]] && echo "Heatmap: $HEATMAP"
+exit 0
@@ -14,6 +14,7 @@ set -euo pipefail
 
 SCRIPT_DIR="$(cd "$(dirname "${BASH_SOURCE[0]}")" && pwd)"
 source "$SCRIPT_DIR/lib/catalog-utils.sh"
+source "$SCRIPT_DIR/lib/trace-utils.sh"
 
 usage() {
     cat << EOF
@@ -41,6 +42,7 @@ Options:
     --skip-clone          Skip cloning (repos already exist)
     --skip-scan           Skip scanning (just track and clone)
     --include-archived    Include archived repos (secrets-only scanning)
+    --profile <name>      Scan profile: bounty-recon, ci, audit, secrets-only
     -h, --help            Show this help message
 
 Scan options (passed to catalog-scan.sh):
@@ -334,6 +336,10 @@ while [[ $# -gt 0 ]]; do
             SCAN_OPTS+=("$1")
             shift
             ;;
+        --profile)
+            SCAN_OPTS+=("$1" "$2")
+            shift 2
+            ;;
         --archive|--unarchive|--force|-f)
             # These should have been handled above, skip
             shift
@@ -375,6 +381,8 @@ fi
 echo "========================================"
 echo ""
 
+trace_span_start "hunt" org="$ORG" platform="$PLATFORM"
+
 # =============================================================================
 # Step 1: Track (if not already tracked)
 # =============================================================================
@@ -462,6 +470,27 @@ else
     "$SCRIPT_DIR/catalog-scan.sh" "$ORG" ${SCAN_OPTS[@]+"${SCAN_OPTS[@]}"}
 fi
 
+trace_span_end ok
+
+# Workspace GC policy from .env (WORKSPACE_GC_MAX_AGE_DAYS / WORKSPACE_GC_MAX_SIZE)
+"$SCRIPT_DIR/workspace-gc.sh" --auto --quiet || true
+
+# Retention policy from .env (RETENTION_SCAN_DAYS / _EVIDENCE_DAYS / _RESOLVED_DAYS)
+"$SCRIPT_DIR/retention.sh" --auto --quiet || true
+
+# Take down shared finding links past their expiry
+"$SCRIPT_DIR/share-finding.sh" "$ORG" prune --quiet || true
+
+# Triage of deprecated rules follows their replacements' new findings
+if [[ -z "$SKIP_SCAN" ]]; then
+    "$SCRIPT_DIR/rules.sh" migrate "$ORG" --quiet > /dev/null || true
+fi
+
+# Scan digests for the recipients in the org's meta.json (needs SMTP_URL)
+if [[ -z "$SKIP_SCAN" ]]; then
+    "$SCRIPT_DIR/email-digest.sh" "$ORG" --auto || true
+fi
+
 # =============================================================================
 # Summary
 # =============================================================================
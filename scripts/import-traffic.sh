@@ -0,0 +1,228 @@
+#!/usr/bin/env bash
+# Import proxy history (HAR or Burp Suite XML) and link live endpoints to
+# their handler code
+#
+# Usage: ./scripts/import-traffic.sh <org> [file...] [options]
+#
+# Endpoints observed during manual testing are added to the org's observed
+# endpoint store (catalog/tracked/<org>/observed-endpoints.json), with IDs
+# in paths collapsed to {id}. Each endpoint is then matched against the
+# HTTP routes in scans/<org>/surfaces.json, so a live request leads to the
+# handler that serves it and its findings, and live endpoints with no known
+# handler stand out.
+#
+# Examples:
+#   ./scripts/import-traffic.sh acme-corp session.har
+#   ./scripts/import-traffic.sh acme-corp burp-history.xml --host api.acme.com
+#   ./scripts/import-traffic.sh acme-corp --format unmatched   # Re-link after surfaces.sh
+
+set -euo pipefail
+
+SCRIPT_DIR="$(cd "$(dirname "${BASH_SOURCE[0]}")" && pwd)"
+source "$SCRIPT_DIR/lib/catalog-utils.sh"
+source "$SCRIPT_DIR/lib/surface-utils.sh"
+
+usage() {
+    cat << EOF
+Usage: $0 <org> [file...] [options]
+
+Add endpoints from proxy history to the org's observed endpoint store and
+correlate them with handler code. With no files, only the correlation is
+refreshed (run after surfaces.sh).
+
+Supported files:
+    HAR                 Browser devtools, Burp, ZAP, mitmproxy (.har)
+    Burp XML            Proxy > HTTP history > Save items (.xml)
+    Burp projects (.burp) can't be read directly: save the items as XML.
+
+Options:
+    --host <hosts>      Only import these hosts (comma-separated; a leading
+                        "." matches subdomains, e.g. .acme.com)
+    --source <label>    Label recorded for this import (default: file name)
+    --format <fmt>      summary (default), json, or unmatched
+    -h, --help          Show this help message
+
+Formats:
+    summary     Import counts, endpoints matched to handlers, unmatched endpoints
+    json        The org's observed endpoint store
+    unmatched   Observed endpoints with no handler, one "METHOD host path" per line
+
+Examples:
+    $0 acme-corp session.har
+    $0 acme-corp burp-history.xml --host .acme.com
+EOF
+    exit 1
+}
+
+ORG=""
+FILES=()
+HOSTS=""
+SOURCE=""
+FORMAT="summary"
+
+while [[ $# -gt 0 ]]; do
+    case "$1" in
+        --host)
+            HOSTS="$2"
+            shift 2
+            ;;
+        --source)
+            SOURCE="$2"
+            shift 2
+            ;;
+        --format)
+            FORMAT="$2"
+            shift 2
+            ;;
+        -h|--help)
+            usage
+            ;;
+        -*)
+            echo "Unknown option: $1"
+            usage
+            ;;
+        *)
+            if [[ -z "$ORG" ]]; then
+                ORG="$1"
+            else
+                FILES+=("$1")
+            fi
+            shift
+            ;;
+    esac
+done
+
+[[ -z "$ORG" ]] && usage
+
+case "$FORMAT" in
+    summary|json|unmatched) ;;
+    *)
+        echo "Error: Unknown format '$FORMAT' (use summary, json, or unmatched)"
+        exit 1
+        ;;
+esac
+
+validate_org_name "$ORG" || exit 1
+require_jq || exit 1
+
+STORE="$(get_org_catalog_dir "$ORG")/$OBSERVED_ENDPOINTS_FILE"
+SURFACES_FILE="scans/$ORG/surfaces.json"
+
+if [[ ${#FILES[@]} -eq 0 && ! -f "$STORE" ]]; then
+    echo "Error: No observed endpoints for $ORG yet, import a HAR or Burp XML file"
+    exit 1
+fi
+
+TMP_DIR=$(mktemp -d)
+register_cleanup "$TMP_DIR"
+install_cancel_traps
+
+# =============================================================================
+# Import
+# =============================================================================
+
+IMPORT_LINES=()
+for file in ${FILES[@]+"${FILES[@]}"}; do
+    if [[ ! -f "$file" ]]; then
+        echo "Error: File not found: $file"
+        exit 1
+    fi
+
+    requests="$TMP_DIR/requests"
+    if jq -e '.log.entries | type == "array"' "$file" &> /dev/null; then
+        har_to_observed "$file" > "$requests"
+    elif grep -q '<items' "$file" 2>/dev/null; then
+        burp_to_observed "$file" > "$requests"
+    else
+        echo "Error: Not a HAR file or Burp XML export: $file"
+        [[ "$file" == *.burp ]] && echo "Save the proxy history as XML: Proxy > HTTP history > select all > Save items"
+        exit 1
+    fi
+
+    # Proxy history also holds third-party traffic (CDNs, analytics)
+    if [[ -n "$HOSTS" ]]; then
+        jq -c --arg hosts "$HOSTS" '
+            ($hosts | ascii_downcase | split(",") | map(select(. != ""))) as $allow
+            | select(.host as $h | any($allow[]; if startswith(".") then ($h | endswith(.)) or $h == ltrimstr(".") else $h == . end))
+        ' "$requests" > "$requests.tmp" && mv "$requests.tmp" "$requests"
+    fi
+
+    total=$(grep -c . "$requests" || true)
+    if [[ "$total" -eq 0 ]]; then
+        IMPORT_LINES+=("$file: no requests${HOSTS:+ for $HOSTS}")
+        continue
+    fi
+
+    mkdir -p "$(dirname "$STORE")"
+    endpoints=$(merge_observed_endpoints "$STORE" "$requests" "${SOURCE:-$(basename "$file")}")
+    IMPORT_LINES+=("$file: $total requests, $endpoints endpoints")
+done
+
+if [[ ! -f "$STORE" ]]; then
+    printf 'Imported %s\n' "${IMPORT_LINES[@]}"
+    exit 0
+fi
+
+# =============================================================================
+# Correlation
+# =============================================================================
+
+CORRELATED=false
+if [[ -f "$SURFACES_FILE" ]]; then
+    correlate_observed_endpoints "$STORE" "$SURFACES_FILE"
+    CORRELATED=true
+fi
+
+# =============================================================================
+# Output
+# =============================================================================
+
+case "$FORMAT" in
+    json)
+        cat "$STORE"
+        ;;
+    unmatched)
+        jq -r '.[] | select(.handler == null) | "\(.method) \(.host) \(.path)"' "$STORE"
+        ;;
+    summary)
+        for line in ${IMPORT_LINES[@]+"${IMPORT_LINES[@]}"}; do
+            echo "Imported $line"
+        done
+        [[ ${#IMPORT_LINES[@]} -gt 0 ]] && echo ""
+
+        total=$(jq length "$STORE")
+        if [[ "$CORRELATED" != true ]]; then
+            echo "$total observed endpoints for $ORG (not linked to handlers: no $SURFACES_FILE)"
+            echo "Run: ./scripts/surfaces.sh $ORG, then $0 $ORG"
+            exit 0
+        fi
+
+        jq -r '
+            def pad($n): tostring | . + (" " * ([$n - length, 1] | max));
+            ([.[] | select(.handler)] | sort_by(-.handler.findings, .path)) as $matched
+            | ([.[] | select(.handler == null)] | sort_by(.host, .path)) as $unmatched
+            | (if ($matched | length) > 0 then
+                  "Observed endpoints with handlers:",
+                  ($matched[] | "  \(.method | pad(7)) \(.path | pad(40)) \(.handler.repo)/\(.handler.path):\(.handler.line)"
+                      + (if .handler.findings > 0 then "  (\(.handler.findings) findings in file)" else "" end)
+                      + (if .handler.match == "suffix" then "  [prefix]" else "" end)),
+                  ""
+               else empty end),
+              (if ($unmatched | length) > 0 then
+                  "Observed endpoints with no handler found:",
+                  ($unmatched[] | "  \(.method | pad(7)) \(.host)\(.path)  (\(.count) requests, status \(.statuses | map(tostring) | join("/")))"),
+                  ""
+               else empty end)
+        ' "$STORE"
+
+        matched=$(jq '[.[] | select(.handler)] | length' "$STORE")
+        unseen=$(jq -n --slurpfile s "$SURFACES_FILE" --slurpfile o "$STORE" '
+            ($o[0] | map(.handler | select(.) | "\(.repo)\t\(.path)\t\(.line)\t\(.route)") | unique) as $seen
+            | [$s[0][] | select(.kind == "http") | "\(.repo)\t\(.path)\t\(.line)\t\(.route)"
+               | select(. as $k | $seen | index($k) | not)] | length')
+        echo "$total observed endpoints, $matched linked to handlers, $((total - matched)) unmatched"
+        echo "$unseen HTTP entry points in $SURFACES_FILE not seen in traffic"
+        echo "Store: $STORE"
+        echo "Link findings to these URLs: ./scripts/correlate-endpoints.sh $ORG"
+        ;;
+esac
@@ -0,0 +1,133 @@
+#!/usr/bin/env bash
+# Merge gosec or staticcheck output into a repo's semgrep results
+#
+# Usage: ./scripts/ingest-go-findings.sh <org> <repo> --gosec <file> | --staticcheck <file> [options]
+#
+# For analyzer runs made outside scan-semgrep.sh (CI, a different Go
+# toolchain). Findings become semgrep results (check_id gosec.G201,
+# staticcheck.SA1019) with severities and CWEs from go_analyzer_rule_map,
+# so extract-semgrep-findings.sh, suppressions, and triage cover them.
+# Re-ingesting an analyzer replaces its earlier findings for the repo.
+#
+# Examples:
+#   gosec -fmt=json -out gosec.json ./...
+#   ./scripts/ingest-go-findings.sh acme-corp api --gosec gosec.json
+#   staticcheck -f json ./... > staticcheck.json
+#   ./scripts/ingest-go-findings.sh acme-corp api --staticcheck staticcheck.json
+
+set -euo pipefail
+
+SCRIPT_DIR="$(cd "$(dirname "${BASH_SOURCE[0]}")" && pwd)"
+source "$SCRIPT_DIR/lib/catalog-utils.sh"
+source "$SCRIPT_DIR/lib/finding-utils.sh"
+
+usage() {
+    cat << EOF
+Usage: $0 <org> <repo> --gosec <file> | --staticcheck <file> [options]
+
+Merge Go analyzer findings into scans/<org>/semgrep-results/<repo>.json.gz.
+
+Options:
+    --gosec <file>        gosec JSON output (gosec -fmt=json)
+    --staticcheck <file>  staticcheck JSON output (staticcheck -f json)
+    --repo-dir <path>     Repo checkout the analyzer ran in
+                          (default: repos/<org>/<repo>)
+    --output-dir <path>   Scan output directory (default: scans/<org>)
+    -h, --help            Show this help message
+
+Run scan-semgrep.sh first: it rewrites the repo's result file. To run the
+analyzers as part of the scan instead, use scan-semgrep.sh --go-analyzers.
+EOF
+    exit 1
+}
+
+ORG=""
+REPO=""
+ANALYZER=""
+ANALYZER_FILE=""
+REPO_DIR=""
+OUTPUT_DIR=""
+
+while [[ $# -gt 0 ]]; do
+    case "$1" in
+        --gosec|--staticcheck)
+            if [[ -n "$ANALYZER" ]]; then
+                echo "Error: Ingest one analyzer at a time"
+                exit 1
+            fi
+            ANALYZER="${1#--}"
+            ANALYZER_FILE="$2"
+            shift 2
+            ;;
+        --repo-dir)
+            REPO_DIR="$2"
+            shift 2
+            ;;
+        --output-dir)
+            OUTPUT_DIR="$2"
+            shift 2
+            ;;
+        -h|--help)
+            usage
+            ;;
+        -*)
+            echo "Unknown option: $1"
+            usage
+            ;;
+        *)
+            if [[ -z "$ORG" ]]; then
+                ORG="$1"
+            elif [[ -z "$REPO" ]]; then
+                REPO="$1"
+            else
+                echo "Too many arguments"
+                usage
+            fi
+            shift
+            ;;
+    esac
+done
+
+[[ -z "$ORG" || -z "$REPO" || -z "$ANALYZER" ]] && usage
+validate_org_name "$ORG" || exit 1
+require_jq || exit 1
+
+if [[ ! -f "$ANALYZER_FILE" ]]; then
+    echo "Error: $ANALYZER output not found: $ANALYZER_FILE"
+    exit 1
+fi
+
+OUTPUT_DIR="${OUTPUT_DIR:-scans/$ORG}"
+REPO_DIR="${REPO_DIR:-$CATALOG_ROOT/repos/$ORG/$REPO}"
+RESULTS_FILE="$OUTPUT_DIR/semgrep-results/$REPO.json.gz"
+[[ ! -f "$RESULTS_FILE" && -f "$OUTPUT_DIR/semgrep-results/$REPO.json" ]] && \
+    RESULTS_FILE="$OUTPUT_DIR/semgrep-results/$REPO.json"
+
+REPO_ABS="$REPO_DIR"
+[[ -d "$REPO_DIR" ]] && REPO_ABS="$(cd "$REPO_DIR" && pwd)"
+
+# Match the path prefix semgrep used for this repo, so ingested findings
+# group with its findings under the same paths
+PATH_PREFIX="$REPO_DIR"
+if [[ -f "$RESULTS_FILE" ]]; then
+    reader="cat"
+    [[ "$RESULTS_FILE" == *.gz ]] && reader="gzip -dc"
+    seen=$($reader "$RESULTS_FILE" | jq -r --arg repo "$REPO" '
+        [.results[]? | select(.extra.metadata.source == null) | .path
+         | select(contains($repo + "/")) | capture("^(?<p>(.*/)?" + $repo + ")/").p] | first // empty
+    ')
+    [[ -n "$seen" ]] && PATH_PREFIX="$seen"
+fi
+
+mkdir -p "$(dirname "$RESULTS_FILE")"
+converted=$(mktemp)
+trap 'rm -f "$converted"' EXIT
+
+if ! go_analyzer_to_semgrep "$ANALYZER" "$ANALYZER_FILE" "$PATH_PREFIX" "$REPO_ABS" > "$converted"; then
+    echo "Error: Could not parse $ANALYZER output: $ANALYZER_FILE"
+    exit 1
+fi
+
+count=$(merge_analyzer_results "$RESULTS_FILE" "$ANALYZER" "$converted")
+echo "Merged $count $ANALYZER findings into $RESULTS_FILE"
+jq -r '.results | group_by(.extra.severity) | map("  \(.[0].extra.severity): \(length)") | .[]' "$converted"
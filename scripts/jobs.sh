@@ -0,0 +1,247 @@
+#!/usr/bin/env bash
+# Inspect and manage the scanner agent's job queue
+#
+# Usage: ./scripts/jobs.sh [command] [options]
+#
+# agent.sh queues a hunt for each due target and every job the central
+# server lists, and its workers take them by priority within per-tenant
+# limits (see scripts/lib/agent-utils.sh). Jobs that keep failing land in
+# the dead-letter queue; this script shows them with their last error and
+# puts them back once the cause is fixed. `add` queues jobs locally, e.g. a
+# batch of hunts, or a PR scan that should jump the queue.
+#
+# Examples:
+#   ./scripts/jobs.sh                                   # Pending, running, dead
+#   ./scripts/jobs.sh list --state dead
+#   ./scripts/jobs.sh retry hunt-acme-corp-0
+#   ./scripts/jobs.sh add --org acme-corp --platform hackerone --priority batch
+#   ./scripts/jobs.sh add --org acme-corp --gh-repo acme/api --pr 42
+
+set -euo pipefail
+
+SCRIPT_DIR="$(cd "$(dirname "${BASH_SOURCE[0]}")" && pwd)"
+source "$SCRIPT_DIR/lib/catalog-utils.sh"
+source "$SCRIPT_DIR/lib/agent-utils.sh"
+
+usage() {
+    cat << EOF
+Usage: $0 [command] [options]
+
+Show the agent's job queue and manage dead-letter jobs.
+
+Commands:
+    list                List jobs (default)
+    add                 Queue a hunt (--org, --platform) or a PR scan
+                        (--org, --gh-repo, --pr)
+    retry <id>          Move a dead job back to the queue (--all: every dead job)
+    drop <id>           Delete a pending or dead job
+
+Options:
+    --state <state>     list: only pending, running, or dead jobs
+    --org <name>        Org the job is for
+    --platform <name>   add: bug bounty platform, for hunts
+    --repos <list>      add: comma-separated repos to hunt
+    --profile <name>    add: hunt profile
+    --gh-repo <o/r>     add: GitHub repository of the PR
+    --pr <number>       add: PR number to review
+    --fail-on <level>   add: pr-review.sh --fail-on level
+    --priority <level>  add: $JOB_PRIORITIES
+                        (default: interactive for PR scans, normal for hunts)
+    --tenant <name>     add: tenant for concurrency limits (default: the org)
+    --all               retry: every dead job
+    -h, --help          Show this help message
+
+The queue is kept in $AGENT_QUEUE_DIR/.
+
+Examples:
+    $0 list --state dead
+    $0 retry --all
+    $0 add --org acme-corp --platform hackerone --priority batch
+EOF
+    exit 1
+}
+
+COMMAND=""
+ID=""
+STATE=""
+ORG=""
+PLATFORM=""
+REPOS=""
+PROFILE=""
+GH_REPO=""
+PR=""
+FAIL_ON=""
+PRIORITY=""
+TENANT=""
+ALL=false
+
+while [[ $# -gt 0 ]]; do
+    case "$1" in
+        --state)
+            STATE="$2"
+            shift 2
+            ;;
+        --org)
+            ORG="$2"
+            shift 2
+            ;;
+        --platform)
+            PLATFORM="$2"
+            shift 2
+            ;;
+        --repos)
+            REPOS="$2"
+            shift 2
+            ;;
+        --profile)
+            PROFILE="$2"
+            shift 2
+            ;;
+        --gh-repo)
+            GH_REPO="$2"
+            shift 2
+            ;;
+        --pr)
+            PR="$2"
+            shift 2
+            ;;
+        --fail-on)
+            FAIL_ON="$2"
+            shift 2
+            ;;
+        --priority)
+            PRIORITY="$2"
+            shift 2
+            ;;
+        --tenant)
+            TENANT="$2"
+            shift 2
+            ;;
+        --all)
+            ALL=true
+            shift
+            ;;
+        -h|--help)
+            usage
+            ;;
+        -*)
+            echo "Unknown option: $1"
+            usage
+            ;;
+        *)
+            if [[ -z "$COMMAND" ]]; then
+                COMMAND="$1"
+            elif [[ -z "$ID" ]]; then
+                ID="$1"
+            else
+                echo "Unexpected argument: $1"
+                usage
+            fi
+            shift
+            ;;
+    esac
+done
+
+COMMAND="${COMMAND:-list}"
+
+require_jq || exit 1
+
+case "$COMMAND" in
+    list)
+        if [[ -n "$STATE" && ! " pending running dead " == *" $STATE "* ]]; then
+            echo "Error: --state must be pending, running, or dead"
+            exit 1
+        fi
+        printf "%-32s  %-8s  %-11s  %-16s  %-8s  %s\n" "ID" "STATE" "PRIORITY" "TENANT" "ATTEMPTS" "LAST ERROR"
+        for state in ${STATE:-pending running dead}; do
+            job_list "$state" | jq -r --arg state "$state" --arg levels "$JOB_PRIORITIES" '
+                ($levels | split(" ")) as $levels
+                | sort_by([(.priority as $p | $levels | index([$p])), .enqueued_at])[]
+                | [.id, $state, .priority, .tenant, (.attempts | tostring), (.last_error // "-")] | @tsv
+            ' | while IFS=$'\t' read -r id state priority tenant attempts error; do
+                printf "%-32s  %-8s  %-11s  %-16s  %-8s  %s\n" "$id" "$state" "$priority" "$tenant" "$attempts" "$error"
+            done
+        done
+        dead=$(job_list dead | jq 'length')
+        if [[ "$dead" -gt 0 ]]; then
+            echo ""
+            echo "$dead dead jobs: fix the cause, then: $0 retry <id> (or --all)"
+        fi
+        ;;
+
+    add)
+        if [[ -z "$ORG" ]]; then
+            echo "Error: add requires --org"
+            exit 1
+        fi
+        validate_org_name "$ORG" || exit 1
+        if [[ -n "$PR" || -n "$GH_REPO" ]]; then
+            if [[ -z "$PR" || -z "$GH_REPO" ]]; then
+                echo "Error: A PR scan needs both --gh-repo and --pr"
+                exit 1
+            fi
+            ID="pr-${GH_REPO//\//-}-$PR"
+            JOB=$(jq -n -c --arg id "$ID" --arg org "$ORG" --arg gh_repo "$GH_REPO" --arg pr "$PR" --arg fail_on "$FAIL_ON" \
+                '{id: $id, kind: "pr", org: $org, gh_repo: $gh_repo, pr: ($pr | tonumber? // $pr),
+                  fail_on: (if $fail_on == "" then null else $fail_on end)}')
+        else
+            if [[ -z "$PLATFORM" ]]; then
+                echo "Error: A hunt needs --platform"
+                exit 1
+            fi
+            ID="hunt-$ORG-manual-$(date +%s)"
+            JOB=$(jq -n -c --arg id "$ID" --arg org "$ORG" --arg platform "$PLATFORM" --arg repos "$REPOS" --arg profile "$PROFILE" \
+                '{id: $id, kind: "hunt", org: $org, platform: $platform,
+                  repos: (if $repos == "" then null else $repos | split(",") end),
+                  profile: (if $profile == "" then null else $profile end)}')
+        fi
+        JOB=$(jq -c --arg priority "$PRIORITY" --arg tenant "$TENANT" '
+            . + (if $priority == "" then {} else {priority: $priority} end)
+              + (if $tenant == "" then {} else {tenant: $tenant} end)' <<< "$JOB")
+        if ! job_enqueue "$JOB"; then
+            echo "Error: Job $ID is already queued, running, dead, or done"
+            exit 1
+        fi
+        echo "Queued $ID ($(jq -r '.priority' "$AGENT_QUEUE_DIR/pending/$ID.json"))"
+        ;;
+
+    retry)
+        if [[ "$ALL" == true ]]; then
+            count=0
+            for id in $(job_list dead | jq -r '.[].id'); do
+                job_retry "$id" && count=$((count + 1))
+            done
+            echo "Requeued $count dead jobs"
+        elif [[ -n "$ID" ]]; then
+            if ! job_retry "$ID"; then
+                echo "Error: No dead job '$ID'"
+                exit 1
+            fi
+            echo "Requeued $ID"
+        else
+            echo "Error: retry requires a job id or --all"
+            exit 1
+        fi
+        ;;
+
+    drop)
+        if [[ -z "$ID" ]]; then
+            echo "Error: drop requires a job id"
+            exit 1
+        fi
+        if [[ -f "$AGENT_QUEUE_DIR/pending/$ID.json" ]]; then
+            rm -f "$AGENT_QUEUE_DIR/pending/$ID.json"
+        elif [[ -f "$AGENT_QUEUE_DIR/dead/$ID.json" ]]; then
+            rm -f "$AGENT_QUEUE_DIR/dead/$ID.json"
+        else
+            echo "Error: No pending or dead job '$ID'"
+            exit 1
+        fi
+        echo "Dropped $ID"
+        ;;
+
+    *)
+        echo "Unknown command: $COMMAND"
+        usage
+        ;;
+esac
@@ -0,0 +1,448 @@
+#!/usr/bin/env bash
+# Scanner Agent Utilities
+# Shared functions for agent.sh: reading the central configuration, working
+# out which targets are due, and pushing scan results back
+#
+# Usage: source this file in other scripts
+#   source "$SCRIPT_DIR/lib/agent-utils.sh"
+#
+# The central configuration is one JSON document served over HTTP(S) (or a
+# local path, for testing and shared mounts):
+#   {
+#     "poll_minutes": 15,
+#     "rule_packs": ["web-vulns", "patterns"],
+#     "profile": "bounty-recon",
+#     "self_update": true,
+#     "workers": 4,
+#     "interactive_slots": 1,
+#     "max_attempts": 3,
+#     "tenant_concurrency": {"default": 2, "acme-corp": 3},
+#     "tenants": {
+#       "client-a": {"rule_packs": ["web-vulns"], "token_env": "CLIENT_A_TOKEN",
+#                    "results_url": "https://hub.example.com/client-a",
+#                    "concurrency": 1, "jobs_per_day": 50}
+#     },
+#     "targets": [
+#       {"org": "acme-corp", "platform": "hackerone", "interval_hours": 24,
+#        "github_orgs": ["acme"], "repos": ["api", "web"], "profile": "ci"}
+#     ],
+#     "jobs": [
+#       {"id": "pr-acme-api-42", "kind": "pr", "org": "acme-corp",
+#        "gh_repo": "acme/api", "pr": 42, "fail_on": "high"}
+#     ]
+#   }
+# Only "targets[].org" and "targets[].platform" are required.
+#
+# Due targets and the server's "jobs" go through one job queue (see Job Queue
+# Functions). Jobs run on up to "workers" at once, highest priority first;
+# "interactive_slots" of them are kept for interactive jobs (PR scans), so a
+# burst of batch hunts can't starve them. A tenant ("tenant", default the
+# org) runs at most its "tenant_concurrency" jobs at once. A job that fails
+# "max_attempts" times is moved to the dead-letter queue until someone
+# retries or drops it with jobs.sh.
+#
+# With "tenants", every target and job must name one of them ("tenant"), and
+# each tenant is isolated (see Tenant Functions): its own catalog, clones,
+# and findings under AGENT_TENANTS_DIR/<tenant>/, its own rule packs
+# (instead of the top-level "rule_packs"), its own bearer token for pushing
+# results (read from the environment variable "token_env"), its own results
+# URL (default <AGENT_RESULTS_URL>/<tenant>), and quotas: "concurrency" jobs
+# at once and "jobs_per_day" jobs started per 24 hours.
+
+# Ensure we're not run directly
+if [[ "${BASH_SOURCE[0]}" == "${0}" ]]; then
+    echo "This script should be sourced, not executed directly."
+    echo "Usage: source ${BASH_SOURCE[0]}"
+    exit 1
+fi
+
+CATALOG_ROOT="${CATALOG_ROOT:-$(cd "$(dirname "${BASH_SOURCE[0]}")/../.." && pwd)}"
+
+AGENT_STATE_DIR="${AGENT_STATE_DIR:-$CATALOG_ROOT/cache/agent}"
+AGENT_DEFAULT_INTERVAL_HOURS=24
+AGENT_DEFAULT_POLL_MINUTES=15
+AGENT_QUEUE_DIR="${AGENT_QUEUE_DIR:-$AGENT_STATE_DIR/queue}"
+AGENT_TENANTS_DIR="${AGENT_TENANTS_DIR:-$CATALOG_ROOT/tenants}"
+AGENT_DEFAULT_WORKERS=1
+AGENT_DEFAULT_MAX_ATTEMPTS=3
+# Seconds before a failed job is retried, times its attempts so far
+AGENT_RETRY_SECONDS=300
+# Seconds after which a state lock is taken over even if its holder's PID
+# is still in use (state updates take well under a second)
+AGENT_LOCK_STALE_SECONDS=60
+
+# Job priorities, most urgent first
+JOB_PRIORITIES="interactive high normal batch"
+
+# =============================================================================
+# Configuration Functions
+# =============================================================================
+
+# Fetch the central configuration into the agent state directory
+# The last good copy is kept, so a node keeps its schedule while the
+# server is unreachable
+# Args: $1 = config URL or path, $2 = bearer token (optional)
+# Prints the path of the config to use; returns 1 if there is none
+fetch_agent_config() {
+    local url="$1"
+    local token="${2:-}"
+    local config="$AGENT_STATE_DIR/config.json"
+    local tmp
+    local auth_args=()
+
+    mkdir -p "$AGENT_STATE_DIR" || return 1
+    tmp=$(mktemp)
+    [[ -n "$token" ]] && auth_args=(-H "Authorization: Bearer $token")
+
+    if [[ "$url" == http://* || "$url" == https://* ]]; then
+        curl -sSf -L -m 30 ${auth_args[@]+"${auth_args[@]}"} -o "$tmp" "$url" 2>/dev/null || : > "$tmp"
+    elif [[ -f "$url" ]]; then
+        cp "$url" "$tmp"
+    fi
+
+    if jq -e '.targets | type == "array"' "$tmp" &> /dev/null; then
+        mv "$tmp" "$config"
+    else
+        rm -f "$tmp"
+        [[ -f "$config" ]] && echo "Warning: Could not load config from $url, using the last good copy" >&2
+    fi
+
+    [[ -f "$config" ]] || return 1
+    echo "$config"
+}
+
+# =============================================================================
+# Schedule Functions
+# =============================================================================
+
+# Unix time of a target's last successful run (0 if never)
+# Args: $1 = org
+agent_last_run() {
+    local state="$AGENT_STATE_DIR/state.json"
+    [[ -f "$state" ]] || { echo 0; return 0; }
+    jq -r --arg org "$1" '.[$org].last_run // 0' "$state"
+}
+
+# Take a lock directory, with the holder's PID in it
+# A lock whose holder is gone (a worker killed mid-update), or that is
+# older than AGENT_LOCK_STALE_SECONDS, is taken over instead of waited on
+# Args: $1 = lock directory, $2 = holder PID (default: this shell's)
+agent_lock() {
+    local lock="$1"
+    local pid="${2:-$$}"
+    local holder
+
+    until mkdir "$lock" 2>/dev/null; do
+        holder=$(cat "$lock/pid" 2>/dev/null || true)
+        if { [[ -n "$holder" ]] && ! kill -0 "$holder" 2>/dev/null; } || \
+            [[ $(( $(date +%s) - $(file_mtime "$lock") )) -gt "$AGENT_LOCK_STALE_SECONDS" ]]; then
+            # Renamed away first; mkdir then decides which waiter gets it
+            mv "$lock" "$lock.stale.$pid" 2>/dev/null && rm -rf "$lock.stale.$pid"
+            continue
+        fi
+        sleep 1
+    done
+    echo "$pid" > "$lock/pid"
+}
+
+# Release a lock taken with agent_lock
+# Args: $1 = lock directory
+agent_unlock() {
+    rm -rf "$1"
+}
+
+# Record a run for a target
+# Args: $1 = org, $2 = status (ok or failed), $3 = scan timestamp (optional)
+agent_record_run() {
+    local org="$1"
+    local status="$2"
+    local scan="${3:-}"
+    local state="$AGENT_STATE_DIR/state.json"
+
+    mkdir -p "$AGENT_STATE_DIR"
+    # Workers finish jobs concurrently; each update holds the lock, and a
+    # subshell's trap releases it however the update ends
+    (
+        pid=$(exec sh -c 'echo "$PPID"')
+        agent_lock "$state.lock" "$pid"
+        trap 'agent_unlock "$state.lock"' EXIT
+        trap 'exit 130' INT
+        trap 'exit 143' TERM
+        [[ -f "$state" ]] || echo '{}' > "$state"
+        now=$(date +%s)
+        # A failed run is retried: only successes move last_run
+        jq --arg org "$org" --arg status "$status" --arg scan "$scan" --argjson now "$now" '
+            .[$org] = ((.[$org] // {}) + {last_attempt: $now, last_status: $status}
+                + (if $status == "ok" then {last_run: $now, last_scan: $scan} else {} end))
+        ' "$state" > "$state.tmp" && mv "$state.tmp" "$state"
+    )
+}
+
+# Targets due for a scan: never run, or last run over interval_hours ago
+# Runs are recorded per org, or per <tenant>/<org> for a tenant's target
+# Args: $1 = config file, $2 = current unix time (default: now)
+# Prints one compact JSON target per line
+agent_due_targets() {
+    local config="$1"
+    local now="${2:-$(date +%s)}"
+    local state="$AGENT_STATE_DIR/state.json"
+    local runs='{}'
+
+    [[ -f "$state" ]] && runs=$(cat "$state")
+    jq -c --argjson runs "$runs" --argjson now "$now" \
+        --argjson default "$AGENT_DEFAULT_INTERVAL_HOURS" '
+        .targets[]
+        | select((.org // "") != "" and (.platform // "") != "")
+        | select(($runs[if .tenant then "\(.tenant)/\(.org)" else .org end].last_run // 0)
+                 + ((.interval_hours // $default) * 3600) <= $now)
+    ' "$config"
+}
+
+# =============================================================================
+# Job Queue Functions
+# =============================================================================
+
+# The queue is a directory per state under AGENT_QUEUE_DIR, one JSON file
+# per job: pending/, running/, dead/ (the dead-letter queue), and done/
+# (markers, so a job the server still lists isn't run twice). A job moves
+# between them with mv, so a claim is atomic.
+#   {id, kind: "hunt" | "pr", tenant, priority, org, ..., attempts,
+#    enqueued_at, not_before, last_error}
+# A hunt job carries a target's fields; a pr job gh_repo, pr, and fail_on.
+# Jobs with the same key (the org for hunts, the GitHub repo for PR scans)
+# never run at the same time, since they share a checkout.
+
+# Add a job unless one with its id is already queued, running, dead, or done
+# Args: $1 = job JSON (id and kind required; priority defaults to
+#       interactive for pr jobs and normal otherwise, tenant to the org)
+# Returns 1 if the job was already known or is invalid
+job_enqueue() {
+    local job="$1"
+    local id state
+
+    id=$(jq -r '.id // empty | select(test("^[A-Za-z0-9._-]+$"))' <<< "$job" 2>/dev/null)
+    if [[ -z "$id" ]] || ! jq -e '.kind == "hunt" or .kind == "pr"' <<< "$job" > /dev/null 2>&1; then
+        echo "Warning: Skipping job without a valid id and kind: $(jq -c . <<< "$job" 2>/dev/null || echo "$job")" >&2
+        return 1
+    fi
+    for state in pending running dead done; do
+        [[ -e "$AGENT_QUEUE_DIR/$state/$id.json" ]] && return 1
+    done
+    if ! jq -e --arg levels "$JOB_PRIORITIES" \
+            '(.priority // "normal") as $p | $levels | split(" ") | index([$p])' <<< "$job" > /dev/null; then
+        echo "Warning: Job $id has an unknown priority (use: $JOB_PRIORITIES)" >&2
+        return 1
+    fi
+
+    mkdir -p "$AGENT_QUEUE_DIR/pending"
+    jq -c --argjson now "$(date +%s)" '
+        {priority: (if .kind == "pr" then "interactive" else "normal" end), tenant: .org}
+        + . + {attempts: 0, enqueued_at: $now, not_before: 0}
+    ' <<< "$job" > "$AGENT_QUEUE_DIR/pending/$id.json.tmp" && \
+        mv "$AGENT_QUEUE_DIR/pending/$id.json.tmp" "$AGENT_QUEUE_DIR/pending/$id.json"
+}
+
+# Jobs in one queue state
+# Args: $1 = state (pending, running, or dead)
+# Prints a JSON array
+job_list() {
+    local dir="$AGENT_QUEUE_DIR/$1"
+
+    if ! ls "$dir"/*.json > /dev/null 2>&1; then
+        echo "[]"
+        return 0
+    fi
+    cat "$dir"/*.json | jq -s -c .
+}
+
+# Claim the next job that may run now: the most urgent, oldest pending job
+# that is past its retry time, whose key isn't running, whose tenant is
+# under its concurrency limit and daily quota, and, unless interactive,
+# that leaves the reserved interactive slots free. The job moves to
+# running/ and counts against its tenant's quota.
+# Args: $1 = config file (workers, interactive_slots, tenant_concurrency,
+#       tenants)
+# Prints the running job's path; returns 1 if no job may run
+job_claim() {
+    local config="$1"
+    local now id
+
+    now=$(date +%s)
+    id=$(jq -r --argjson pending "$(job_list pending)" --argjson running "$(job_list running)" \
+        --argjson started "$(job_starts_since $((now - 86400)))" \
+        --argjson now "$now" --arg levels "$JOB_PRIORITIES" \
+        --argjson workers "$AGENT_DEFAULT_WORKERS" '
+        def key: (if .tenant then "\(.tenant)/" else "" end)
+                 + (if .kind == "pr" then "pr:\(.gh_repo)" else "hunt:\(.org)" end);
+        ($levels | split(" ")) as $levels
+        | (.workers // $workers) as $workers
+        | ([.interactive_slots // (if $workers > 1 then 1 else 0 end), $workers - 1] | min) as $reserved
+        | (.tenant_concurrency // {}) as $limits
+        | (.tenants // {}) as $tenants
+        | ($running | map(key)) as $busy
+        | ($running | map(select(.priority != "interactive")) | length) as $batch
+        | [$pending[]
+           | select(.not_before <= $now)
+           | select(key as $k | $busy | index([$k]) | not)
+           | select(.tenant as $t | ($running | map(select(.tenant == $t)) | length)
+                    < ($tenants[$t].concurrency // $limits[$t] // $limits.default // $workers))
+           | select(.tenant as $t | $tenants[$t].jobs_per_day == null
+                    or ($started[$t] // 0) < $tenants[$t].jobs_per_day)
+           | select(.priority == "interactive" or $batch < $workers - $reserved)]
+        | sort_by([(.priority as $p | $levels | index([$p])), .enqueued_at, .id])
+        | first | .id // empty
+    ' "$config") || return 1
+    [[ -n "$id" ]] || return 1
+
+    mkdir -p "$AGENT_QUEUE_DIR/running"
+    mv "$AGENT_QUEUE_DIR/pending/$id.json" "$AGENT_QUEUE_DIR/running/$id.json" 2>/dev/null || return 1
+    jq -c --argjson now "$now" '{id, tenant, started_at: $now}' "$AGENT_QUEUE_DIR/running/$id.json" \
+        >> "$AGENT_QUEUE_DIR/starts.jsonl"
+    echo "$AGENT_QUEUE_DIR/running/$id.json"
+}
+
+# Jobs started per tenant since a time, for daily quotas
+# Args: $1 = unix time
+# Prints {"<tenant>": <count>}
+job_starts_since() {
+    local log="$AGENT_QUEUE_DIR/starts.jsonl"
+
+    [[ -f "$log" ]] || { echo "{}"; return 0; }
+    jq -s -c --argjson since "$1" \
+        'map(select(.started_at >= $since)) | group_by(.tenant) | map({key: (.[0].tenant // ""), value: length}) | from_entries' "$log"
+}
+
+# Finish a running job: done on success; on failure back to pending with a
+# backoff, or to dead/ once it has failed max_attempts times
+# Args: $1 = job id, $2 = ok or failed, $3 = error (for failures),
+#       $4 = max attempts (default: AGENT_DEFAULT_MAX_ATTEMPTS)
+# Prints the job's new state
+job_finish() {
+    local id="$1"
+    local status="$2"
+    local error="${3:-}"
+    local max="${4:-$AGENT_DEFAULT_MAX_ATTEMPTS}"
+    local file="$AGENT_QUEUE_DIR/running/$id.json"
+    local state
+
+    [[ -f "$file" ]] || return 1
+    if [[ "$status" == "ok" ]]; then
+        mkdir -p "$AGENT_QUEUE_DIR/done"
+        jq -c --argjson now "$(date +%s)" '. + {finished_at: $now}' "$file" > "$AGENT_QUEUE_DIR/done/$id.json"
+        rm -f "$file"
+        echo "done"
+        return 0
+    fi
+
+    state=$(jq -r --argjson max "$max" 'if .attempts + 1 >= $max then "dead" else "pending" end' "$file")
+    mkdir -p "$AGENT_QUEUE_DIR/$state"
+    jq -c --arg error "$error" --argjson now "$(date +%s)" --argjson retry "$AGENT_RETRY_SECONDS" '
+        .attempts += 1 | .last_error = $error | .failed_at = $now
+        | .not_before = $now + ($retry * .attempts)
+    ' "$file" > "$AGENT_QUEUE_DIR/$state/$id.json.tmp" && \
+        mv "$AGENT_QUEUE_DIR/$state/$id.json.tmp" "$AGENT_QUEUE_DIR/$state/$id.json"
+    rm -f "$file"
+    echo "$state"
+}
+
+# Move a dead job back to pending with its attempts reset
+# Args: $1 = job id
+job_retry() {
+    local id="$1"
+    local file="$AGENT_QUEUE_DIR/dead/$id.json"
+
+    [[ -f "$file" ]] || return 1
+    mkdir -p "$AGENT_QUEUE_DIR/pending"
+    jq -c '.attempts = 0 | .not_before = 0' "$file" > "$AGENT_QUEUE_DIR/pending/$id.json" && rm -f "$file"
+}
+
+# Requeue jobs left in running/ by an agent that stopped mid-job, and forget
+# done markers older than a week and quota starts older than a day
+job_recover() {
+    local file log="$AGENT_QUEUE_DIR/starts.jsonl"
+
+    for file in "$AGENT_QUEUE_DIR"/running/*.json; do
+        [[ -f "$file" ]] || continue
+        mkdir -p "$AGENT_QUEUE_DIR/pending"
+        mv "$file" "$AGENT_QUEUE_DIR/pending/"
+    done
+    [[ -d "$AGENT_QUEUE_DIR/done" ]] && find "$AGENT_QUEUE_DIR/done" -name '*.json' -mtime +7 -delete
+    if [[ -f "$log" ]]; then
+        jq -c --argjson since "$(($(date +%s) - 86400))" 'select(.started_at >= $since)' "$log" > "$log.tmp" && \
+            mv "$log.tmp" "$log"
+    fi
+    return 0
+}
+
+# =============================================================================
+# Tenant Functions
+# =============================================================================
+
+# Toolkit files every tenant workspace links to; everything else (the
+# catalog index, tracked orgs, scans, clones, findings) is the tenant's own
+TENANT_SHARED_PATHS="custom-rules templates locales .env catalog/languages.json catalog/platforms catalog/negative-corpus.json"
+
+# Settings for a job's tenant from the config
+# Args: $1 = config file, $2 = tenant
+# Prints the tenant's JSON ({} when the config has no tenants); returns 1
+# when the config has tenants and this isn't one of them
+tenant_settings() {
+    jq -e -c --arg tenant "$2" 'if has("tenants") then .tenants[$tenant] // false else {} end' "$1" 2>/dev/null
+}
+
+# A tenant's workspace: a catalog root of its own that links to the shared
+# toolkit files, so scripts run with CATALOG_ROOT set to it read and write
+# only that tenant's data
+# Args: $1 = tenant
+# Prints the workspace path
+tenant_workspace() {
+    local tenant="$1"
+    local root="$AGENT_TENANTS_DIR/$tenant"
+    local path
+
+    if [[ ! "$tenant" =~ ^[a-zA-Z0-9_-]+$ ]]; then
+        echo "Error: Invalid tenant name '$tenant'" >&2
+        return 1
+    fi
+    mkdir -p "$root/catalog/tracked" "$root/repos" "$root/scans" "$root/findings" || return 1
+    for path in $TENANT_SHARED_PATHS; do
+        [[ -e "$CATALOG_ROOT/$path" && ! -e "$root/$path" ]] && ln -s "$CATALOG_ROOT/$path" "$root/$path"
+    done
+    echo "$root"
+}
+
+# =============================================================================
+# Result Push Functions
+# =============================================================================
+
+# Upload a scan directory to the central server as a tar.gz
+# PUT to <results URL>/<node>/<org>/<scan>.tar.gz; the scan's
+# provenance.json travels with it so the server can verify it
+# Args: $1 = results URL, $2 = node name, $3 = org, $4 = scan directory,
+#       $5 = bearer token (optional)
+push_scan_results() {
+    local url="$1"
+    local node="$2"
+    local org="$3"
+    local scan_dir="$4"
+    local token="${5:-}"
+    local scan archive
+    local auth_args=()
+
+    scan=$(basename "$scan_dir")
+    archive=$(mktemp)
+    [[ -n "$token" ]] && auth_args=(-H "Authorization: Bearer $token")
+
+    if ! tar -czf "$archive" -C "$(dirname "$scan_dir")" "$scan"; then
+        rm -f "$archive"
+        return 1
+    fi
+
+    if ! curl -sSf -m 300 ${auth_args[@]+"${auth_args[@]}"} \
+        -H "Content-Type: application/gzip" \
+        -T "$archive" "${url%/}/$node/$org/$scan.tar.gz" > /dev/null; then
+        rm -f "$archive"
+        return 1
+    fi
+    rm -f "$archive"
+}
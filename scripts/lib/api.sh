@@ -0,0 +1,139 @@
+#!/usr/bin/env bash
+# Library API
+# Stable functions for programs that run rule packs and read findings
+# without going through the CLI scripts and their output
+#
+# Usage: source this file in other scripts
+#   source /path/to/bounty-hunter/scripts/lib/api.sh
+#
+# Everything prefixed bh_ is the supported interface: names, arguments,
+# and the JSON each prints stay compatible within a BH_API_VERSION. Other
+# functions these libraries define are internal and may change. Results
+# are printed as JSON lines on stdout; errors go to stderr with a non-zero
+# return code.
+#
+#   Rules     bh_rule_packs, bh_rules
+#   Scanner   bh_scan
+#   Findings  bh_findings, bh_finding
+#
+# Findings, from bh_scan as well as from the catalog:
+#   {id, repo, path, line, end_line, check_id, severity, message, lines,
+#    structure, cwe, references, remediation, permalink, commit}
+#
+# Programs in other languages run these through bash and read the JSON
+# lines; docs/library-api.md has an example, and why there are no Go
+# packages.
+
+# Ensure we're not run directly
+if [[ "${BASH_SOURCE[0]}" == "${0}" ]]; then
+    echo "This script should be sourced, not executed directly."
+    echo "Usage: source ${BASH_SOURCE[0]}"
+    exit 1
+fi
+
+CATALOG_ROOT="${CATALOG_ROOT:-$(cd "$(dirname "${BASH_SOURCE[0]}")/../.." && pwd)}"
+
+_API_LIB_DIR="$(cd "$(dirname "${BASH_SOURCE[0]}")" && pwd)"
+source "$_API_LIB_DIR/rule-utils.sh"
+source "$_API_LIB_DIR/finding-utils.sh"
+source "$_API_LIB_DIR/provenance-utils.sh"
+
+BH_API_VERSION=1
+
+# =============================================================================
+# Rules Functions
+# =============================================================================
+
+# Rule packs under custom-rules/ as JSON lines:
+#   {name, enabled, digest, rules}
+# enabled follows RULE_PACKS; digest is the pack's provenance digest
+bh_rule_packs() {
+    local digests pack name count
+
+    digests=$(rule_pack_digests "$RULES_ROOT")
+    for pack in "$RULES_ROOT"/*/; do
+        [[ -d "$pack" ]] || continue
+        name=$(basename "$pack")
+        count=$(list_rule_ids "$name" | grep -c . || true)
+        jq -n -c --arg name "$name" --argjson digests "$digests" --argjson rules "$count" \
+            --argjson enabled "$(rule_pack_enabled "$name" && echo true || echo false)" \
+            '{name: $name, enabled: $enabled, digest: ($digests[$name] // null), rules: $rules}'
+    done
+}
+
+# Rules as JSON lines: {id, pack, file}
+# Args: $1 = pack name (optional, default: every pack)
+bh_rules() {
+    local only="${1:-}"
+
+    if [[ -n "$only" && ! -d "$RULES_ROOT/$only" ]]; then
+        echo "bh_rules: no rule pack '$only'" >&2
+        return 1
+    fi
+    grep -rHE "^[[:space:]]*- id:[[:space:]]*" "$RULES_ROOT/$only" \
+        --include='*.yaml' --include='*.yml' 2>/dev/null | \
+        sed -E "s|^$RULES_ROOT/||; s/:[[:space:]]*- id:[[:space:]]*/"$'\t'"/; s/[[:space:]]+$//" | \
+        jq -R -c 'split("\t") | {id: .[1], pack: (.[0] | split("/")[0]), file: .[0]}'
+}
+
+# =============================================================================
+# Scanner Functions
+# =============================================================================
+
+# Scan a directory with custom rule packs and print its findings
+# Runs semgrep directly: no catalog, profile, or registry rulesets.
+# Args: $1 = directory to scan, $2... = rule packs (default: RULE_PACKS,
+#       or every pack)
+# Returns 1 for a missing directory or pack, 2 when semgrep fails
+bh_scan() {
+    local target="$1"
+    shift
+    local packs="$*"
+    local output status=0
+
+    if [[ ! -d "$target" ]]; then
+        echo "bh_scan: not a directory: $target" >&2
+        return 1
+    fi
+    if ! command -v semgrep &> /dev/null; then
+        echo "bh_scan: semgrep is not installed" >&2
+        return 2
+    fi
+
+    RULE_PACKS="${packs:-${RULE_PACKS:-}}" build_custom_rule_args "$RULES_ROOT"
+    if [[ ${#CUSTOM_RULE_ARGS[@]} -eq 0 ]]; then
+        echo "bh_scan: no rule packs match '${packs:-${RULE_PACKS:-}}'" >&2
+        return 1
+    fi
+
+    target="$(cd "$target" && pwd)"
+    output=$(mktemp)
+    semgrep scan --dataflow-traces --quiet "${CUSTOM_RULE_ARGS[@]}" \
+        --json --output="$output" "$target" > /dev/null 2>&1 || status=$?
+
+    # Semgrep exits 1 when it finds something; other codes are failures
+    if [[ "$status" -gt 1 || ! -s "$output" ]]; then
+        echo "bh_scan: semgrep failed (exit $status)" >&2
+        rm -f "$output"
+        return 2
+    fi
+    semgrep_results_findings "$output" "$(basename "$target")" "$target"
+    rm -f "$output"
+}
+
+# =============================================================================
+# Findings Functions
+# =============================================================================
+
+# An org's findings from its latest catalog scan, as JSON lines
+# Args: $1 = org, $2 = repo (optional)
+bh_findings() {
+    org_semgrep_findings "$1" "${2:-}"
+}
+
+# One finding of an org by id (or unique prefix) or <repo>/<path>:<line>
+# Args: $1 = org, $2 = finding id or location
+# Returns 1 when nothing matches, 2 when a prefix matches several findings
+bh_finding() {
+    find_org_finding "$1" "$2"
+}
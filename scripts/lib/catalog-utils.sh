@@ -453,6 +453,24 @@ get_archived_repos() {
     done | sort
 }
 
+# Get path to repo tags manifest ("<repo> <tag> <tag>..." per line, from
+# GitHub visibility and topics, written by clone-org-repos.sh)
+get_repo_tags_manifest() {
+    local repos_dir="$1"
+    echo "$repos_dir/.repo-tags"
+}
+
+# Print a repo's tags, one per line
+get_repo_tags() {
+    local repos_dir="$1"
+    local repo_name="$2"
+    local manifest
+    manifest="$(get_repo_tags_manifest "$repos_dir")"
+
+    [[ -f "$manifest" ]] || return 0
+    awk -v name="$repo_name" '$1 == name { for (i = 2; i <= NF; i++) print $i }' "$manifest"
+}
+
 # Count active repos
 count_active_repos() {
     local repos_dir="$1"
@@ -465,6 +483,279 @@ count_archived_repos() {
     get_archived_repos "$repos_dir" | wc -l | xargs
 }
 
+# =============================================================================
+# Ignore File Functions
+# =============================================================================
+
+# Per-repo ignore file (gitignore syntax, including !negation). Files may be
+# placed in any directory; patterns apply relative to that directory and
+# deeper files override shallower ones, exactly like nested .gitignore files.
+IGNORE_FILE_NAME=".bountyhunterignore"
+
+# List paths in a repo excluded by .bountyhunterignore files
+# Uses git's own ignore matching, so semantics match .gitignore exactly
+# Args: $1 = repo directory, $2 = extra ignore file applied at the repo root (optional)
+# Prints repo-relative paths, one per line; prints nothing for non-git dirs
+list_ignored_paths() {
+    local repo="$1"
+    local extra="${2:-}"
+    local args=(--cached --others --ignored "--exclude-per-directory=$IGNORE_FILE_NAME")
+
+    [[ -d "$repo/.git" || -f "$repo/.git" ]] || return 0
+
+    if [[ -n "$extra" && -f "$extra" ]]; then
+        # git -C changes directory, so make the path absolute first
+        extra="$(cd "$(dirname "$extra")" && pwd)/$(basename "$extra")"
+        args+=("--exclude-from=$extra")
+    fi
+
+    git -C "$repo" ls-files "${args[@]}" 2>/dev/null | sort -u
+}
+
+# Reduce ignored paths to the smallest set of directory and file prefixes
+# A directory is emitted (with trailing /) when every file under it is ignored
+# Args: $1 = repo directory, $2 = extra ignore file (optional)
+# Prints repo-relative prefixes, one per line
+ignored_path_prefixes() {
+    local repo="$1"
+    local extra="${2:-}"
+    local ignored
+
+    ignored=$(list_ignored_paths "$repo" "$extra")
+    [[ -z "$ignored" ]] && return 0
+
+    {
+        echo "$ignored" | sed 's/^/I /'
+        git -C "$repo" ls-files --cached --others 2>/dev/null | sed 's/^/A /'
+    } | awk '
+        {
+            kind = $1
+            path = substr($0, 3)
+            if (kind == "I") { ignored[path] = 1 }
+            n = split(path, parts, "/")
+            dir = ""
+            for (i = 1; i < n; i++) {
+                dir = dir parts[i] "/"
+                if (kind == "A") total[dir]++
+                else ign[dir]++
+            }
+        }
+        END {
+            for (path in ignored) {
+                n = split(path, parts, "/")
+                dir = ""
+                out = path
+                for (i = 1; i < n; i++) {
+                    dir = dir parts[i] "/"
+                    if (total[dir] > 0 && ign[dir] >= total[dir]) { out = dir; break }
+                }
+                print out
+            }
+        }
+    ' | sort -u
+}
+
+# =============================================================================
+# Workspace Functions
+# =============================================================================
+
+# Modification time of a file in epoch seconds (GNU stat, then BSD stat)
+file_mtime() {
+    stat -c %Y "$1" 2>/dev/null || stat -f %m "$1" 2>/dev/null || echo "0"
+}
+
+# When a cloned repo was last used, in epoch seconds
+# Uses the newest of the clone's git metadata (clone, fetch, checkout) and
+# the repo's per-scanner result files
+# Args: $1 = repo directory, $2 = scans directory (scans/<org>)
+repo_last_used() {
+    local repo_dir="$1"
+    local scans_dir="$2"
+    local name newest mtime file
+
+    name=$(basename "$repo_dir")
+    newest=0
+
+    for file in "$repo_dir/.git/HEAD" "$repo_dir/.git/FETCH_HEAD" "$repo_dir/.git/index" \
+        "$scans_dir"/*-results/"$name".json* "$scans_dir/inventory/$name-sbom.json.gz"; do
+        [[ -e "$file" ]] || continue
+        mtime=$(file_mtime "$file")
+        [[ "$mtime" -gt "$newest" ]] && newest="$mtime"
+    done
+
+    echo "$newest"
+}
+
+# Convert a size like 500M, 20G, or 1T (or plain kilobytes) to kilobytes
+# Returns 1 for sizes it cannot parse
+parse_size_kb() {
+    local size="$1"
+    local number="${size%[KkMmGgTt]}"
+    local unit="${size#"$number"}"
+
+    [[ "$number" =~ ^[0-9]+$ ]] || return 1
+
+    case "$unit" in
+        ""|K|k) echo "$number" ;;
+        M|m)    echo $((number * 1024)) ;;
+        G|g)    echo $((number * 1024 * 1024)) ;;
+        T|t)    echo $((number * 1024 * 1024 * 1024)) ;;
+    esac
+}
+
+# Convert a duration like 90s, 2m, or 1h (or plain seconds) to seconds
+# Returns 1 for durations it cannot parse
+parse_duration_seconds() {
+    local duration="$1"
+    local number="${duration%[SsMmHh]}"
+    local unit="${duration#"$number"}"
+
+    [[ "$number" =~ ^[0-9]+$ ]] || return 1
+
+    case "$unit" in
+        ""|S|s) echo "$number" ;;
+        M|m)    echo $((number * 60)) ;;
+        H|h)    echo $((number * 3600)) ;;
+    esac
+}
+
+# Check out a repo's submodules recursively, skipping submodules whose
+# path matches a .bountyhunterignore file (in the repo or, when given, the
+# org-wide file)
+# Args: $1 = repo directory, $2 = org-wide ignore file (optional)
+init_submodules() {
+    local repo_dir="$1"
+    local extra="${2:-}"
+    local name skipped key path
+    local exclude_args=()
+
+    [[ -f "$repo_dir/.gitmodules" ]] || return 0
+    name=$(basename "$repo_dir")
+
+    if [[ -n "$extra" && -f "$extra" ]]; then
+        exclude_args+=("--exclude-from=$(cd "$(dirname "$extra")" && pwd)/$(basename "$extra")")
+    fi
+    skipped=$(git -C "$repo_dir" ls-files --cached --ignored --exclude-per-directory=.bountyhunterignore \
+        ${exclude_args[@]+"${exclude_args[@]}"} 2>/dev/null || true)
+
+    git -C "$repo_dir" config -f .gitmodules --get-regexp '\.path$' 2>/dev/null | \
+        while read -r key path; do
+            if grep -qxF "$path" <<< "$skipped"; then
+                echo "[$name] Skipping submodule $path (.bountyhunterignore)"
+                continue
+            fi
+            if ! git -C "$repo_dir" submodule update --init --recursive --quiet -- "$path" 2>&1 | sed 's/^/  /'; then
+                echo "[$name] Submodule $path failed to check out"
+            fi
+        done
+}
+
+# Paths of checked-out submodules, recursively (relative to the repo)
+# Args: $1 = repo directory
+repo_submodule_paths() {
+    git -C "$1" submodule status --recursive 2>/dev/null | awk '$1 !~ /^-/ { print $2 }'
+}
+
+# Format kilobytes for display (e.g. 1.5G)
+format_size_kb() {
+    awk -v kb="$1" 'BEGIN {
+        if (kb >= 1048576) printf "%.1fG\n", kb / 1048576
+        else if (kb >= 1024) printf "%.1fM\n", kb / 1024
+        else printf "%dK\n", kb
+    }'
+}
+
+# =============================================================================
+# File Classification Functions
+# =============================================================================
+
+# Check a file's content for binary data (a NUL byte in the first 8000
+# bytes, the same test git uses), whatever its extension
+# Args: $1 = file path
+is_binary_file() {
+    local file="$1"
+    local total text
+
+    [[ -f "$file" ]] || return 1
+    total=$(head -c 8000 "$file" | wc -c)
+    text=$(head -c 8000 "$file" | LC_ALL=C tr -d '\000' | wc -c)
+    [[ "$text" -lt "$total" ]]
+}
+
+# Check whether a text file looks minified (a line over 1000 characters in
+# its first 64K)
+# Args: $1 = file path
+is_minified_file() {
+    head -c 65536 "$1" 2>/dev/null | LC_ALL=C awk 'length > 1000 { found = 1; exit } END { exit !found }'
+}
+
+# File size limit for a scanner in bytes (0 = no limit), from
+# MAX_FILE_SIZE_<SCANNER> (sizes like 500K, 1M; 0 disables the limit)
+# Defaults: semgrep 1M, since parsing one large bundle can take longer than
+# the rest of the repo; secrets none, so minified bundles are still searched
+# Args: $1 = scanner name (semgrep, secrets)
+scanner_max_file_size() {
+    local var size kb default=0
+    var="MAX_FILE_SIZE_$(echo "$1" | tr '[:lower:]' '[:upper:]')"
+    [[ "$1" == "semgrep" ]] && default="1M"
+    size="${!var:-$default}"
+
+    if ! kb=$(parse_size_kb "$size"); then
+        echo "Error: $var must be a size like 500K or 1M (got '$size')" >&2
+        return 1
+    fi
+    echo $((kb * 1024))
+}
+
+# Tracked files a scanner should skip, one "<path>\t<reason>" line each.
+# Reasons: binary (by content, from git's index), too_large (over the size
+# limit), minified (JS/CSS with very long lines, only when requested)
+# Args: $1 = repo directory, $2 = size limit in bytes (0 = none),
+#       $3 = "minified" to also list minified JS/CSS (optional)
+repo_skipped_files() {
+    local repo="$1"
+    local max_bytes="${2:-0}"
+    local check_minified="${3:-}"
+    local path
+
+    git -C "$repo" -c core.quotePath=false ls-files --eol 2>/dev/null | \
+        awk -F'\t' '$1 ~ /^i\/-text/ { print $2 "\tbinary" }'
+
+    if [[ "$max_bytes" -gt 0 ]]; then
+        git -C "$repo" -c core.quotePath=false ls-tree -r -l HEAD 2>/dev/null | \
+            awk -F'\t' -v max="$max_bytes" '{ split($1, meta, " ") }
+                meta[2] == "blob" && meta[4] + 0 > max { print $2 "\ttoo_large" }'
+    fi
+
+    if [[ "$check_minified" == "minified" ]]; then
+        git -C "$repo" -c core.quotePath=false ls-files -- '*.js' '*.mjs' '*.cjs' '*.css' 2>/dev/null | \
+            grep -vE '\.min\.(js|css)$|\.bundle\.js$' | \
+            while IFS= read -r path; do
+                is_minified_file "$repo/$path" && printf '%s\tminified\n' "$path"
+            done
+    fi
+    return 0
+}
+
+# A repo's files, most recently changed first: uncommitted changes, then
+# files in the order of their last commit (within the last
+# BUDGET_HISTORY_COMMITS commits), then everything else
+# Args: $1 = repo directory
+repo_files_by_recency() {
+    local repo="$1"
+
+    {
+        git -C "$repo" -c core.quotePath=false status --porcelain 2>/dev/null | \
+            cut -c4- | sed 's/.* -> //'
+        git -C "$repo" -c core.quotePath=false log -n "${BUDGET_HISTORY_COMMITS:-500}" \
+            --name-only --format= 2>/dev/null
+        git -C "$repo" -c core.quotePath=false ls-files 2>/dev/null
+    } | awk 'NF && !seen[$0]++' | while IFS= read -r path; do
+        [[ -f "$repo/$path" ]] && echo "$path"
+    done
+    return 0
+}
+
 # =============================================================================
 # Organization Status Functions
 # =============================================================================
@@ -591,6 +882,83 @@ count_org_scans() {
     fi
 }
 
+# =============================================================================
+# Settings Functions
+# =============================================================================
+
+# Export named settings from .env without sourcing the whole file, so the
+# tokens and passwords kept alongside them don't reach child processes
+# Args: $1 = extended regex of the variable names to read
+#       (e.g. 'MAX_FILE_SIZE_[A-Z]+|REPORT_LANG')
+# Values are taken literally, with one pair of surrounding quotes removed
+load_env_settings() {
+    local pattern="$1"
+    local env_file="$CATALOG_ROOT/.env"
+    local line name value
+
+    [[ -f "$env_file" ]] || return 0
+    while IFS= read -r line; do
+        name="${line%%=*}"
+        value="${line#*=}"
+        if [[ "$value" =~ ^\"(.*)\"$ || "$value" =~ ^\'(.*)\'$ ]]; then
+            value="${BASH_REMATCH[1]}"
+        fi
+        export "$name=$value"
+    done < <(grep -E "^(${pattern})=" "$env_file" || true)
+}
+
+# =============================================================================
+# Release Functions
+# =============================================================================
+
+# Releases are signed git tags on the toolkit's remote: vX.Y.Z on the
+# stable channel, vX.Y.Z-<pre> (rc, beta) on the beta channel
+
+# Pick the newest release tag for a channel from tag names on stdin
+# Args: $1 = channel (stable or beta)
+# Prints the tag; returns 1 if there is none
+latest_release_tag() {
+    local channel="${1:-stable}"
+    local pattern='^v[0-9]+\.[0-9]+\.[0-9]+$'
+    local tag
+
+    [[ "$channel" == "beta" ]] && pattern='^v[0-9]+\.[0-9]+\.[0-9]+(-[0-9A-Za-z.]+)?$'
+    # sort -V orders "~" before the end of a version, so v1.2.0~rc1 sorts
+    # below v1.2.0 (a plain "-" would sort it above)
+    tag=$(grep -E "$pattern" | sed 's/-/~/' | sort -V | tail -1 | sed 's/~/-/')
+    [[ -z "$tag" ]] && return 1
+    echo "$tag"
+}
+
+# Release tag the toolkit checkout is on (or descends from)
+current_release_tag() {
+    git -C "$CATALOG_ROOT" describe --tags --abbrev=0 --match 'v[0-9]*' 2>/dev/null
+}
+
+# Fingerprints of the key that made a good signature, from `git verify-tag
+# --raw` output on stdin: GPG's signing and primary key fingerprints
+# (VALIDSIG), or an SSH key's SHA256:... fingerprint
+release_signer_fingerprints() {
+    awk '
+        $1 == "[GNUPG:]" && $2 == "VALIDSIG" { print toupper($3); if ($NF != $3) print toupper($NF) }
+        /^Good "git" signature/ { for (i = 1; i <= NF; i++) if ($i ~ /^SHA256:/) print $i }
+    '
+}
+
+# Whether a release tag has a good signature by the pinned key
+# Args: $1 = tag, $2 = key fingerprint (GPG, spaces allowed, or SSH SHA256:...)
+release_signed_by() {
+    local tag="$1"
+    local key="$2"
+    local raw
+
+    key=$(printf '%s' "$key" | tr -d ' ')
+    [[ "$key" == SHA256:* ]] || key=$(printf '%s' "$key" | tr '[:lower:]' '[:upper:]')
+    [[ -n "$key" ]] || return 1
+    raw=$(git -C "$CATALOG_ROOT" verify-tag --raw "$tag" 2>&1) || return 1
+    release_signer_fingerprints <<< "$raw" | grep -qxF -- "$key"
+}
+
 # =============================================================================
 # Output Helpers
 # =============================================================================
@@ -624,7 +992,9 @@ QUIET_MODE="${QUIET_MODE:-}"
 
 # Print message only if not in quiet mode
 log_verbose() {
-    [[ -z "$QUIET_MODE" ]] && echo "$@"
+    if [[ -z "$QUIET_MODE" ]]; then
+        echo "$@"
+    fi
 }
 
 # Print progress indicator: [current/total] message
@@ -677,3 +1047,173 @@ print_scan_summary() {
         printf "%-12s %d\n" "$scanner:" "$count"
     fi
 }
+
+# =============================================================================
+# Structured Logging Functions
+# =============================================================================
+
+# Leveled logger with text and JSON output. Settings come from the
+# environment so they carry from catalog-scan.sh into each scanner script:
+#   LOG_LEVEL   debug | info | warn | error (default: info)
+#   LOG_FORMAT  text | json (default: text)
+#   LOG_FILE    Append events to this file instead of stdout/stderr
+#   SCAN_ID     Added to every event when set
+# JSON events also carry trace_id when a trace is active (see trace-utils.sh)
+LOG_LEVEL="${LOG_LEVEL:-info}"
+LOG_FORMAT="${LOG_FORMAT:-text}"
+LOG_FILE="${LOG_FILE:-}"
+SCAN_ID="${SCAN_ID:-}"
+
+# Numeric rank of a log level (unknown levels rank as info)
+log_level_rank() {
+    case "$1" in
+        debug) echo 0 ;;
+        info)  echo 1 ;;
+        warn)  echo 2 ;;
+        error) echo 3 ;;
+        *)     echo 1 ;;
+    esac
+}
+
+# Emit one log event
+# Usage: log_event <level> <message> [key=value ...]
+# Common keys: target (org or repo), rule_id, file
+# Text mode prints "[LEVEL] [target] message key=value ..." and honors
+# QUIET_MODE for debug/info; JSON mode prints one object per line with
+# ts, level, component, scan_id, msg, and the given keys
+log_event() {
+    local level="$1"
+    local message="$2"
+    shift 2
+    local target="" fields="" line field
+    local json_args=()
+
+    [[ $(log_level_rank "$level") -lt $(log_level_rank "$LOG_LEVEL") ]] && return 0
+
+    for field in "$@"; do
+        if [[ "${field%%=*}" == "target" ]]; then
+            target="${field#*=}"
+        else
+            fields+=" $field"
+        fi
+        json_args+=(--arg "${field%%=*}" "${field#*=}")
+    done
+
+    if [[ "$LOG_FORMAT" == "json" ]]; then
+        line=$(jq -nc \
+            --arg ts "$(get_iso_timestamp)" \
+            --arg level "$level" \
+            --arg component "$(basename "$0" .sh)" \
+            --arg scan_id "$SCAN_ID" \
+            --arg trace_id "$(echo "${TRACEPARENT:-}" | cut -s -d- -f2)" \
+            --arg msg "$message" \
+            ${json_args[@]+"${json_args[@]}"} \
+            '$ARGS.named | with_entries(select(.value != ""))')
+    else
+        if [[ -z "$LOG_FILE" && -n "$QUIET_MODE" && $(log_level_rank "$level") -lt 2 ]]; then
+            return 0
+        fi
+        line="[$(echo "$level" | tr '[:lower:]' '[:upper:]')] ${target:+[$target] }$message$fields"
+    fi
+
+    if [[ -n "$LOG_FILE" ]]; then
+        echo "$line" >> "$LOG_FILE"
+    elif [[ "$LOG_FORMAT" == "json" || $(log_level_rank "$level") -ge 2 ]]; then
+        echo "$line" >&2
+    else
+        echo "$line"
+    fi
+}
+
+log_debug() { log_event debug "$@"; }
+log_info() { log_event info "$@"; }
+log_warn() { log_event warn "$@"; }
+log_error() { log_event error "$@"; }
+
+# =============================================================================
+# Cancellation Functions
+# =============================================================================
+
+# Paths removed when the script exits (normally or on INT/TERM)
+CLEANUP_PATHS=()
+
+# Register temp files or directories for removal on exit
+# Args: paths
+register_cleanup() {
+    CLEANUP_PATHS+=("$@")
+}
+
+# Remove registered paths
+run_cleanup() {
+    local path
+    for path in ${CLEANUP_PATHS[@]+"${CLEANUP_PATHS[@]}"}; do
+        rm -rf "$path"
+    done
+    CLEANUP_PATHS=()
+}
+
+# Send TERM to a process and all of its descendants
+# The process is signalled before its children, so a script runs its trap
+# as soon as the command it is waiting on dies instead of carrying on
+# Args: $1 = pid
+kill_tree() {
+    local pid="$1"
+    local child children
+
+    children=$(pgrep -P "$pid" 2>/dev/null || true)
+    kill -TERM "$pid" 2>/dev/null || true
+    for child in $children; do
+        kill_tree "$child"
+    done
+}
+
+# Run a command, stopping it and its children once a number of seconds
+# has passed (GNU timeout isn't available everywhere)
+# Args: $1 = seconds, $2... = command
+# Returns the command's exit code, or 124 when it ran out of time
+run_with_deadline() {
+    local seconds="$1"
+    shift
+    local pid status=0 waited=0
+
+    "$@" &
+    pid=$!
+    while kill -0 "$pid" 2>/dev/null; do
+        if [[ "$waited" -ge "$seconds" ]]; then
+            kill_tree "$pid"
+            wait "$pid" 2>/dev/null || true
+            return 124
+        fi
+        sleep 1
+        waited=$((waited + 1))
+    done
+    wait "$pid" || status=$?
+    return "$status"
+}
+
+# Stop every child process of this script
+stop_child_processes() {
+    local child
+    for child in $(pgrep -P $$ 2>/dev/null); do
+        kill_tree "$child"
+    done
+}
+
+# Clean up registered paths on exit; on INT/TERM stop child processes
+# first and exit 130/143. Scripts that need to keep going after a cancel
+# (catalog-scan.sh flushes partial results) install their own handler.
+install_cancel_traps() {
+    trap run_cleanup EXIT
+    trap 'cancel_and_exit 130' INT
+    trap 'cancel_and_exit 143' TERM
+}
+
+# Signal handler for install_cancel_traps
+# Args: $1 = exit code
+cancel_and_exit() {
+    trap - INT TERM
+    [[ -n "$QUIET_MODE" ]] && clear_progress
+    log_warn "Cancelled, stopping" target="${ORG:-}"
+    stop_child_processes
+    exit "$1"
+}
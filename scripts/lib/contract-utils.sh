@@ -0,0 +1,212 @@
+#!/usr/bin/env bash
+# Service Contract Utilities
+# Shared functions for finding the API contracts a repo serves (OpenAPI
+# specs, protobuf services) and the places other repos call them
+#
+# Usage: source this file in other scripts
+#   source "$SCRIPT_DIR/lib/contract-utils.sh"
+
+# Ensure we're not run directly
+if [[ "${BASH_SOURCE[0]}" == "${0}" ]]; then
+    echo "This script should be sourced, not executed directly."
+    echo "Usage: source ${BASH_SOURCE[0]}"
+    exit 1
+fi
+
+# Request sources that mark a call site as forwarding user input
+REQUEST_SOURCE_PATTERN='req\.(body|query|params|headers)|request\.(args|form|json|GET|POST|data|body)|params\[|r\.URL\.Query|r\.FormValue|c\.(Query|Param|PostForm|Bind)|ctx\.(query|params|request)|@RequestBody|@RequestParam|@PathVariable|\$_(GET|POST|REQUEST)'
+
+# =============================================================================
+# Contract Discovery Functions
+# =============================================================================
+
+# OpenAPI / Swagger spec files tracked in a repo (vendored specs excluded)
+# Args: $1 = repo directory
+openapi_spec_files() {
+    local repo="$1"
+    local file
+
+    git -C "$repo" ls-files -- '*.yaml' '*.yml' '*.json' 2>/dev/null | \
+        grep -vE '(^|/)(node_modules|vendor|third_party|3rdparty)/' | \
+        while IFS= read -r file; do
+            head -c 2048 "$repo/$file" 2>/dev/null | grep -qE '^(openapi|swagger):|"(openapi|swagger)"[[:space:]]*:' && echo "$file"
+        done
+    return 0
+}
+
+# Operations in an OpenAPI spec, one "<METHOD>\t<path>\t<operationId>" line each
+# (operationId is empty when the spec leaves it out)
+# Args: $1 = spec file
+openapi_operations() {
+    local file="$1"
+
+    if [[ "$file" == *.json ]]; then
+        jq -r '(.paths // {}) | to_entries[] | .key as $path | .value | to_entries[]
+            | select(.key | test("^(get|put|post|delete|patch|head|options)$"))
+            | [(.key | ascii_upcase), $path, (.value.operationId // "")] | @tsv' "$file" 2>/dev/null
+        return 0
+    fi
+
+    # YAML: paths at the first indent under "paths:", methods one level deeper
+    awk '
+        function indent_of(line) { return match(line, /[^ ]/) - 1 }
+        function flush() { if (method != "") print toupper(method) "\t" path "\t" op; method = ""; op = "" }
+        /^paths:[[:space:]]*$/ { inside = 1; path_indent = -1; next }
+        inside && /^[^[:space:]#]/ { flush(); inside = 0 }
+        !inside || /^[[:space:]]*(#.*)?$/ { next }
+        {
+            ind = indent_of($0)
+            line = $0
+            sub(/^[[:space:]]+/, "", line)
+            if (path_indent < 0 && line ~ /^["\047]?\//) path_indent = ind
+            if (ind == path_indent) {
+                flush()
+                path = line
+                sub(/:[[:space:]]*$/, "", path)
+                gsub(/["\047]/, "", path)
+                method_indent = -1
+                next
+            }
+            if (ind > path_indent && line ~ /^(get|put|post|delete|patch|head|options):/) {
+                if (method_indent < 0) method_indent = ind
+                if (ind == method_indent) {
+                    flush()
+                    method = line
+                    sub(/:.*/, "", method)
+                    next
+                }
+            }
+            if (method != "" && line ~ /^operationId:/) {
+                op = line
+                sub(/^operationId:[[:space:]]*/, "", op)
+                gsub(/["\047]/, "", op)
+            }
+        }
+        END { flush() }
+    ' "$file"
+}
+
+# RPCs in a .proto file, one "RPC\t/<package>.<Service>/<Method>\t<Service>.<Method>" line each
+# Args: $1 = proto file
+proto_operations() {
+    awk '
+        /^[[:space:]]*package[[:space:]]+/ { pkg = $2; sub(/;.*/, "", pkg) }
+        /^[[:space:]]*service[[:space:]]+/ { service = $2; sub(/\{.*/, "", service) }
+        service != "" && match($0, /rpc[[:space:]]+[A-Za-z0-9_]+/) {
+            method = substr($0, RSTART, RLENGTH)
+            sub(/^rpc[[:space:]]+/, "", method)
+            print "RPC\t/" (pkg != "" ? pkg "." : "") service "/" method "\t" service "." method
+        }
+    ' "$1"
+}
+
+# Contracts a repo serves, one JSON object per line:
+# {repo, kind (openapi|grpc), method, path, operation, file}
+# Args: $1 = repo directory
+repo_contracts() {
+    local repo="$1"
+    local name file method path operation
+
+    name=$(basename "$repo")
+
+    while IFS= read -r file; do
+        [[ -z "$file" ]] && continue
+        while IFS=$'\t' read -r method path operation; do
+            [[ -z "$path" ]] && continue
+            jq -nc --arg repo "$name" --arg method "$method" --arg path "$path" \
+                --arg operation "$operation" --arg file "$file" \
+                '{repo: $repo, kind: "openapi", method: $method, path: $path, operation: $operation, file: $file}'
+        done < <(openapi_operations "$repo/$file")
+    done < <(openapi_spec_files "$repo")
+
+    while IFS= read -r file; do
+        [[ -z "$file" ]] && continue
+        while IFS=$'\t' read -r method path operation; do
+            jq -nc --arg repo "$name" --arg path "$path" --arg operation "$operation" --arg file "$file" \
+                '{repo: $repo, kind: "grpc", method: "RPC", path: $path, operation: $operation, file: $file}'
+        done < <(proto_operations "$repo/$file")
+    done < <(git -C "$repo" ls-files -- '*.proto' 2>/dev/null | grep -vE '(^|/)(vendor|third_party|node_modules)/')
+    return 0
+}
+
+# =============================================================================
+# Call Site Functions
+# =============================================================================
+
+# Regex matching an OpenAPI path template in client code: path parameters
+# become .* so string building ("/users/" + id) still matches
+# Prints nothing for paths without a static segment of 3+ characters
+# Args: $1 = path template (e.g. /v1/users/{id}/orders)
+openapi_path_regex() {
+    local template="$1"
+    local static
+
+    static=$(echo "$template" | sed -E 's/\{[^}]*\}//g' | tr -d '/')
+    [[ ${#static} -lt 3 ]] && return 0
+
+    echo "$template" | sed -E \
+        -e 's/[].[^$()+?|\\]/\\&/g' \
+        -e 's/\{[^}]*\}/.*/g' \
+        -e 's/(\.\*)+$//'
+}
+
+# Call sites of a contract in a repo, one "<file>\t<line>" per line
+# OpenAPI operations match their path in client code; gRPC operations match
+# .<Method>( in files that use the service's generated client
+# Args: $1 = repo directory, $2 = kind (openapi|grpc), $3 = path, $4 = operation
+contract_call_sites() {
+    local repo="$1"
+    local kind="$2"
+    local path="$3"
+    local operation="$4"
+    local regex service method file
+
+    case "$kind" in
+        openapi)
+            regex=$(openapi_path_regex "$path")
+            [[ -z "$regex" ]] && return 0
+            git -C "$repo" grep -n -E -e "[\"'\`]$regex" -- ':!*.yaml' ':!*.yml' ':!*.json' ':!*.md' 2>/dev/null | \
+                awk -F: '{ print $1 "\t" $2 }'
+            ;;
+        grpc)
+            service="${operation%%.*}"
+            method="${operation#*.}"
+            git -C "$repo" grep -l -E -e "${service}(Client|Stub|_Stub|ClientImpl|BlockingStub)\b" -- ':!*.proto' 2>/dev/null | \
+                while IFS= read -r file; do
+                    grep -nE "\.${method}\(" "$repo/$file" 2>/dev/null | awk -F: -v f="$file" '{ print f "\t" $1 }'
+                done
+            ;;
+    esac
+    return 0
+}
+
+# Files in the serving repo that likely implement a contract: they mention
+# the path template or the operation / method name, outside spec files
+# Args: $1 = repo directory, $2 = kind, $3 = path, $4 = operation
+contract_handler_files() {
+    local repo="$1"
+    local kind="$2"
+    local path="$3"
+    local operation="$4"
+    local patterns=()
+    local regex
+
+    if [[ "$kind" == "openapi" ]]; then
+        regex=$(openapi_path_regex "$path")
+        # Route definitions use :id, <id>, or {id} for parameters
+        [[ -n "$regex" ]] && patterns+=(-e "$(echo "$regex" | sed 's/\.\*/[^"'"'"'`]*/g')")
+        [[ -n "$operation" ]] && patterns+=(-e "\\b${operation}\\b")
+    else
+        patterns+=(-e "\\b${operation#*.}\\b")
+    fi
+    [[ ${#patterns[@]} -eq 0 ]] && return 0
+
+    git -C "$repo" grep -l -E "${patterns[@]}" -- ':!*.yaml' ':!*.yml' ':!*.json' ':!*.proto' ':!*.md' \
+        ':!*_test.*' ':!*.pb.go' ':!*_pb2.py' 2>/dev/null || true
+}
+
+# Check whether a file reads request input (REQUEST_SOURCE_PATTERN)
+# Args: $1 = file path
+file_reads_request_input() {
+    grep -qE "$REQUEST_SOURCE_PATTERN" "$1" 2>/dev/null
+}
@@ -80,6 +80,12 @@ EOF
         [[ -n "$fmt" ]] && echo "  $fmt"
     done
     echo ""
+    # EXTRA_OPTIONS may be set by the calling script for script-specific flags
+    if [[ -n "${EXTRA_OPTIONS:-}" ]]; then
+        echo "Options:"
+        echo "$EXTRA_OPTIONS"
+        echo ""
+    fi
     echo "Examples:"
     echo "  $script_name myorg                    # From scans/"
     echo "  $script_name myorg --catalog          # From latest catalog scan"
@@ -175,6 +181,10 @@ extract_init() {
             exit 1
         fi
 
+        if [[ -f "$RESULTS_DIR/partial.json" ]]; then
+            echo "Note: scan $SCAN_TIMESTAMP was cancelled, results are partial" >&2
+        fi
+
         # Check for catalog file (gzipped)
         PATTERN="$RESULTS_DIR/${CATALOG_FILE:-$RESULTS_TYPE.json.gz}"
         if [[ ! -f "$PATTERN" ]]; then
@@ -0,0 +1,353 @@
+#!/usr/bin/env bash
+# History Utilities
+# Shared functions for mining a repo's git history for security fixes,
+# searching the current code for the patterns those fixes removed,
+# generalizing a finding's code into a pattern for variant search, and
+# working out which releases a finding affects
+#
+# Usage: source this file in other scripts
+#   source "$SCRIPT_DIR/lib/history-utils.sh"
+
+# Ensure we're not run directly
+if [[ "${BASH_SOURCE[0]}" == "${0}" ]]; then
+    echo "This script should be sourced, not executed directly."
+    echo "Usage: source ${BASH_SOURCE[0]}"
+    exit 1
+fi
+
+CATALOG_ROOT="${CATALOG_ROOT:-$(cd "$(dirname "${BASH_SOURCE[0]}")/../.." && pwd)}"
+
+_HISTORY_LIB_DIR="$(cd "$(dirname "${BASH_SOURCE[0]}")" && pwd)"
+source "$_HISTORY_LIB_DIR/severity-utils.sh"
+
+# Commit messages that describe a security fix (matched case-insensitively)
+SECURITY_FIX_MESSAGE_RE='(CVE-[0-9]{4}-[0-9]+|GHSA-[a-z0-9]{4}-|security (fix|issue|patch)|vulnerab|\bxss\b|cross.site|sql.?injection|\bsqli\b|command injection|injection|\bcsrf\b|\bssrf\b|path traversal|directory traversal|\brce\b|remote code|auth(entication|orization)? bypass|privilege escalation|\bidor\b|open redirect|sanitiz|escap(e|ing) (user|input|html)|deserializ|\bxxe\b|prototype pollution|timing attack)'
+
+# Diff patterns of common fixes, one per line:
+# kind <tab> ERE of the removed (vulnerable) line <tab> ERE of the added line
+# A commit matches when it removes a matching line and adds one in the same diff
+FIX_DIFF_PATTERNS="$(cat << 'EOF'
+sqli	(query|execute|exec|raw|prepare)\(.*(\+[[:space:]]*[A-Za-z_]|%s|\$\{|f["'])	(\?|\$[0-9]|%s["'][[:space:]]*,|:[a-z_]+|bind|params|placeholder)
+xss	(innerHTML|outerHTML|dangerouslySetInnerHTML|document\.write|\|[[:space:]]*safe|html_safe|mark_safe|v-html|raw\()	(escape|sanitiz|DOMPurify|textContent|innerText|encode)
+cmdi	(shell[[:space:]]*=[[:space:]]*True|os\.system|popen|child_process\.exec\(|exec\([^)]*\+|Runtime\.getRuntime\(\)\.exec|sh -c)	(execFile|spawn\(|shlex|shell[[:space:]]*=[[:space:]]*False|quote\(|exec\.Command\([^,]+,)
+path-traversal	(path\.join|os\.path\.join|filepath\.Join|open\(|readFile|sendFile|File\()	(basename|realpath|normpath|filepath\.Clean|secure_filename|\.\./|startsWith|HasPrefix|within)
+deserialization	(pickle\.loads?|yaml\.load\(|unserialize\(|ObjectInputStream|Marshal\.load|BinaryFormatter)	(safe_load|SafeLoader|json\.|allowlist|whitelist|ValidatingObjectInputStream)
+ssrf	(requests\.(get|post)|http\.Get|fetch\(|urlopen|HttpClient|axios\.(get|post))	(allowlist|whitelist|is_private|isPrivate|ip_address|validate_url|allowed_hosts|AllowedHosts)
+weak-crypto	(md5|sha1|DES|RC4|ECB|Math\.random)	(sha256|sha512|bcrypt|scrypt|argon2|AES|GCM|crypto\.randomBytes|secrets\.|SecureRandom)
+EOF
+)"
+
+# Most commits a history scan looks at per repo (newest first)
+FIX_MINING_MAX_COMMITS="${FIX_MINING_MAX_COMMITS:-5000}"
+
+# Shortest removed line, after trimming, worth searching for
+FIX_PATTERN_MIN_LENGTH=12
+
+# =============================================================================
+# Fix Commit Functions
+# =============================================================================
+
+# Security fix commits in a repo, newest first, one line each:
+# sha <tab> date (YYYY-MM-DD) <tab> kind <tab> subject
+# kind is the FIX_DIFF_PATTERNS kind the diff matched, else "message" for
+# commits found only by their message
+# Args: $1 = repo directory, $2 = since (git date, optional)
+security_fix_commits() {
+    local repo="$1"
+    local since="${2:-}"
+    local since_args=()
+    local kind removed added sha diff
+    local found=""
+
+    [[ -n "$since" ]] && since_args=(--since="$since")
+
+    # Diff heuristics first: -G narrows to commits touching a matching line
+    while IFS=$'\t' read -r kind removed added; do
+        [[ -z "$kind" ]] && continue
+        while IFS= read -r sha; do
+            [[ -z "$sha" ]] && continue
+            grep -qF "$sha" <<< "$found" && continue
+            diff=$(git -C "$repo" show --format= -U0 --no-color "$sha" 2>/dev/null || true)
+            if grep -qE -e "^-[^-].*$removed" <<< "$diff" && grep -qE -e "^\+[^+].*$added" <<< "$diff"; then
+                found+="$sha"$'\t'"$kind"$'\n'
+            fi
+        done < <(git -C "$repo" log --format=%H --no-merges -n "$FIX_MINING_MAX_COMMITS" \
+                    ${since_args[@]+"${since_args[@]}"} -G"$removed" 2>/dev/null)
+    done <<< "$FIX_DIFF_PATTERNS"
+
+    while IFS= read -r sha; do
+        [[ -z "$sha" ]] && continue
+        grep -qF "$sha" <<< "$found" || found+="$sha"$'\t'"message"$'\n'
+    done < <(git -C "$repo" log --format=%H --no-merges -n "$FIX_MINING_MAX_COMMITS" \
+                ${since_args[@]+"${since_args[@]}"} -i -E --grep="$SECURITY_FIX_MESSAGE_RE" 2>/dev/null)
+
+    [[ -z "$found" ]] && return 0
+    while IFS=$'\t' read -r sha kind; do
+        [[ -z "$sha" ]] && continue
+        git -C "$repo" log -1 --date=short --format="%ct%x09%H%x09%cd%x09$kind%x09%s" "$sha"
+    done <<< "$found" | sort -t $'\t' -k1,1nr | cut -f2-
+}
+
+# Vulnerable lines a fix commit removed, one "<file>\t<line>" each
+# For diff-matched commits only lines matching the kind's removed pattern
+# are kept; message-only commits keep every removed code line
+# Args: $1 = repo directory, $2 = commit sha, $3 = kind
+fix_removed_lines() {
+    local repo="$1"
+    local sha="$2"
+    local kind="$3"
+    local removed=""
+
+    [[ "$kind" != "message" ]] && removed=$(awk -F'\t' -v k="$kind" '$1 == k { print $2 }' <<< "$FIX_DIFF_PATTERNS")
+
+    # Patterns pass through the environment: awk -v would eat their backslashes
+    git -C "$repo" show --format= -U0 --no-color --no-renames "$sha" 2>/dev/null | \
+        FIX_REMOVED_RE="$removed" awk -v min="$FIX_PATTERN_MIN_LENGTH" '
+            BEGIN { removed = ENVIRON["FIX_REMOVED_RE"] }
+            /^--- / { file = $2; sub(/^a\//, "", file); next }
+            /^\+\+\+ / { next }
+            /^-/ {
+                line = substr($0, 2)
+                gsub(/^[[:space:]]+|[[:space:]]+$/, "", line)
+                if (length(line) < min || file == "/dev/null") next
+                if (line ~ /^(\/\/|#|\*|\/\*|<!--|--)/) next
+                if (removed != "" && line !~ removed) next
+                print file "\t" line
+            }'
+}
+
+# =============================================================================
+# Pattern Functions
+# =============================================================================
+
+# ERE matching a line of code and its near-copies: whitespace runs match
+# any whitespace, string literals match any string, and numbers any number
+# Args: $1 = line of code
+code_line_regex() {
+    CODE_LINE="$1" awk 'BEGIN {
+        line = ENVIRON["CODE_LINE"]
+        out = ""
+        n = length(line)
+        for (i = 1; i <= n; i++) {
+            c = substr(line, i, 1)
+            if (c == "\"" || c == "\047") {
+                j = i + 1
+                while (j <= n && substr(line, j, 1) != c) { if (substr(line, j, 1) == "\\") j++; j++ }
+                out = out "[\"\047][^\"\047]*[\"\047]"
+                i = j
+            } else if (c ~ /[0-9]/ && (i == 1 || substr(line, i - 1, 1) !~ /[A-Za-z_]/)) {
+                while (i < n && substr(line, i + 1, 1) ~ /[0-9.]/) i++
+                out = out "[0-9.]+"
+            } else if (c ~ /[[:space:]]/) {
+                while (i < n && substr(line, i + 1, 1) ~ /[[:space:]]/) i++
+                out = out "[[:space:]]*"
+            } else if (c ~ /[][\\.^$*+?(){}|\/]/) {
+                out = out "\\" c
+            } else {
+                out = out c
+            }
+        }
+        print out
+    }'
+}
+
+# Current occurrences of a pattern in a repo's HEAD, in files sharing the
+# fixed file's extension, one "<file>\t<line>\t<text>" each
+# Args: $1 = repo directory, $2 = ERE, $3 = extension (without the dot)
+pattern_occurrences() {
+    local repo="$1"
+    local regex="$2"
+    local ext="$3"
+
+    git -C "$repo" grep -n -I -E -e "$regex" -- "*.$ext" 2>/dev/null | \
+        awk '{
+            file = $0; sub(/:.*/, "", file)
+            rest = substr($0, length(file) + 2)
+            line = rest; sub(/:.*/, "", line)
+            text = substr(rest, length(line) + 2)
+            gsub(/^[[:space:]]+|[[:space:]]+$/, "", text)
+            print file "\t" line "\t" text
+        }' || true
+}
+
+# =============================================================================
+# Variant Functions
+# =============================================================================
+
+# Words kept as-is when a line is generalized: language keywords and
+# literals that shape the code rather than name things
+VARIANT_KEYWORDS="if else for while return new await async def func function var let const in of is not and or import from as with try catch except finally raise throw class struct self this nil null None true false True False"
+
+# Tokens of a line of code, space-separated, for similarity scoring:
+# strings become STR, numbers NUM, and local names ID; called functions and
+# member names (foo(…), .bar) and keywords are kept
+# Args: $1 = line of code
+code_tokens() {
+    CODE_LINE="$1" awk -v keywords="$VARIANT_KEYWORDS" "$_VARIANT_AWK_TOKENIZE"'
+        BEGIN { tokenize(ENVIRON["CODE_LINE"]); out = ""; for (i = 1; i <= nt; i++) out = out (i > 1 ? " " : "") norm[i]; print out }'
+}
+
+# ERE matching lines with the same structure as a line of code, with local
+# names, strings, and numbers abstracted (see code_tokens); prints nothing
+# when no called function or member name is left to anchor the search
+# Args: $1 = line of code, $2 = "loose" to match only the called function
+#       and member names, in order, with anything between them
+generalize_code_line() {
+    CODE_LINE="$1" awk -v keywords="$VARIANT_KEYWORDS" -v loose="${2:-}" "$_VARIANT_AWK_TOKENIZE"'
+        BEGIN {
+            tokenize(ENVIRON["CODE_LINE"])
+            out = ""; anchors = 0
+            for (i = 1; i <= nt; i++) {
+                if (loose == "loose") {
+                    if (kept[i]) out = out (anchors++ ? ".*" : "") "(^|[^A-Za-z0-9_$])" raw[i] "([^A-Za-z0-9_$]|$)"
+                    continue
+                }
+                if (norm[i] == "ID") piece = "[A-Za-z_$][A-Za-z0-9_$]*"
+                else if (norm[i] == "STR") piece = "([\"\047`][^\"\047`]*[\"\047`])"
+                else if (norm[i] == "NUM") piece = "[0-9][0-9.xXa-fA-F]*"
+                else {
+                    piece = raw[i]
+                    gsub(/[][\\.^$*+?(){}|\/]/, "\\\\&", piece)
+                    if (kept[i]) anchors++
+                }
+                out = out (i > 1 ? "[[:space:]]*" : "") piece
+            }
+            if (anchors > 0) print out
+        }'
+}
+
+# Similarity of two lines of code (0-1): the longest common token
+# subsequence over the longer token count
+# Args: $1 = line, $2 = line
+code_similarity() {
+    local a b
+
+    a=$(code_tokens "$1")
+    b=$(code_tokens "$2")
+    awk -v a="$a" -v b="$b" 'BEGIN {
+        n = split(a, x, " "); m = split(b, y, " ")
+        if (n == 0 || m == 0) { print 0; exit }
+        for (j = 0; j <= m; j++) prev[j] = 0
+        for (i = 1; i <= n; i++) {
+            cur[0] = 0
+            for (j = 1; j <= m; j++) {
+                if (x[i] == y[j]) cur[j] = prev[j - 1] + 1
+                else cur[j] = (prev[j] > cur[j - 1] ? prev[j] : cur[j - 1])
+            }
+            for (j = 0; j <= m; j++) prev[j] = cur[j]
+        }
+        printf "%.2f\n", prev[m] / (n > m ? n : m)
+    }'
+}
+
+# awk tokenizer shared by the variant functions: fills raw[], norm[], and
+# kept[] (1 for names kept as anchors) for nt tokens
+_VARIANT_AWK_TOKENIZE='
+    function tokenize(line,    n, i, j, c, q, word, k, p, kw) {
+        split(keywords, kw, " "); for (k in kw) keyword[kw[k]] = 1
+        nt = 0; n = length(line); i = 1
+        while (i <= n) {
+            c = substr(line, i, 1)
+            if (c ~ /[[:space:]]/) { i++; continue }
+            if (c == "\"" || c == "\047" || c == "`") {
+                q = c; j = i + 1
+                while (j <= n && substr(line, j, 1) != q) { if (substr(line, j, 1) == "\\") j++; j++ }
+                nt++; raw[nt] = substr(line, i, j - i + 1); norm[nt] = "STR"; kept[nt] = 0
+                i = j + 1; continue
+            }
+            if (c ~ /[0-9]/) {
+                j = i; while (j <= n && substr(line, j, 1) ~ /[0-9.xXa-fA-F]/) j++
+                nt++; raw[nt] = substr(line, i, j - i); norm[nt] = "NUM"; kept[nt] = 0
+                i = j; continue
+            }
+            if (c ~ /[A-Za-z_$]/) {
+                j = i; while (j <= n && substr(line, j, 1) ~ /[A-Za-z0-9_$]/) j++
+                word = substr(line, i, j - i)
+                p = j; while (p <= n && substr(line, p, 1) ~ /[[:space:]]/) p++
+                nt++; raw[nt] = word; kept[nt] = 0
+                if (word in keyword) norm[nt] = word
+                else if (substr(line, p, 1) == "(" || (nt > 1 && raw[nt - 1] ~ /^(\.|->|::)$/)) {
+                    norm[nt] = word; kept[nt] = 1
+                } else norm[nt] = "ID"
+                i = j; continue
+            }
+            if (substr(line, i, 2) ~ /^(->|::)$/) { nt++; raw[nt] = substr(line, i, 2); norm[nt] = raw[nt]; kept[nt] = 0; i += 2; continue }
+            nt++; raw[nt] = c; norm[nt] = c; kept[nt] = 0; i++
+        }
+    }'
+
+# =============================================================================
+# Release Functions
+# =============================================================================
+
+# Releases scanned by release-scan.sh when no --last is given
+RELEASE_SCAN_LAST=5
+
+# A repo's tags in version order, oldest first
+# Args: $1 = repo directory, $2 = tag glob (optional), $3 = keep only the
+#       newest n (optional, 0 = all)
+release_tags() {
+    local repo="$1"
+    local glob="${2:-}"
+    local last="${3:-0}"
+
+    git -C "$repo" tag --list ${glob:+"$glob"} --sort=v:refname 2>/dev/null | \
+        if [[ "$last" -gt 0 ]]; then tail -n "$last"; else cat; fi
+}
+
+# Directory name for a tag (slashes are legal in tags, not in one path segment)
+# Args: $1 = tag
+release_dir_name() {
+    echo "$1" | tr '/' '_'
+}
+
+# Affected release ranges per finding fingerprint
+# A fingerprint present in the oldest scanned release may predate it, so
+# its introduced_in is reported as "<=<tag>"; one present in the newest is
+# unfixed (fixed_in null). A fingerprint that disappears and comes back has
+# several ranges.
+# Args: $1 = file of releases in order (one per line), $2 = presence TSV
+#       (release, fingerprint, check_id, path, line, severity, message)
+# Prints a JSON array sorted by severity then rule
+affected_versions() {
+    local releases="$1"
+    local presence="$2"
+
+    jq -R -s -n --rawfile releases "$releases" --rawfile presence "$presence" "$SEVERITY_JQ_DEFS"'
+        ($releases | split("\n") | map(select(. != ""))) as $order
+        | ($order | to_entries | map({key: .value, value: .key}) | from_entries) as $index
+        | ($presence | split("\n") | map(select(. != "") | split("\t")
+            | {release: .[0], fp: .[1], check_id: .[2], path: .[3], line: (.[4] | tonumber), severity: .[5], message: (.[6] // "")}))
+        | group_by(.fp)
+        | map(
+            (map($index[.release]) | unique) as $present
+            | (reduce $present[] as $i ([]; if length > 0 and last[1] == $i - 1 then .[:-1] + [[last[0], $i]] else . + [[$i, $i]] end)) as $spans
+            | (sort_by($index[.release]) | last) as $latest
+            | {fingerprint: .[0].fp, check_id: $latest.check_id, severity: $latest.severity,
+               path: $latest.path, line: $latest.line, message: $latest.message,
+               introduced_in: (if $spans[0][0] == 0 then "<=" + $order[0] else $order[$spans[0][0]] end),
+               fixed_in: (if $spans[-1][1] == ($order | length) - 1 then null else $order[$spans[-1][1] + 1] end),
+               ranges: ($spans | map({from: $order[.[0]], to: $order[.[1]],
+                   fixed_in: (if .[1] == ($order | length) - 1 then null else $order[.[1] + 1] end)})),
+               releases: ($present | map($order[.]))}
+          )
+        | sort_by((.severity | severity_level("semgrep") | severity_rank), .check_id, .path, .line)
+    '
+}
+
+# A finding's entry in a repo's release-scan.sh report (null when the repo
+# hasn't been scanned by release or the finding isn't in it), matched by
+# fingerprint, then by rule and file
+# Args: $1 = org, $2 = finding JSON (from org_semgrep_findings)
+finding_affected_versions() {
+    local org="$1"
+    local finding="$2"
+    local file
+
+    file="$CATALOG_ROOT/scans/$org/releases/$(jq -r '.repo' <<< "$finding")/affected-versions.json"
+    [[ -f "$file" ]] || { echo "null"; return 0; }
+    jq -c --argjson f "$finding" '
+        [.findings[] | select(.fingerprint == $f.id)] + [.findings[] | select(.check_id == $f.check_id and .path == $f.path)]
+        | first // null
+    ' "$file"
+}
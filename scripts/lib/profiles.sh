@@ -0,0 +1,93 @@
+#!/usr/bin/env bash
+# Scan Profiles
+# Named presets that bundle scanners, semgrep rulesets, custom rule packs,
+# severity filters, and output settings
+#
+# Usage: source this file in other scripts
+#   source "$SCRIPT_DIR/lib/profiles.sh"
+#   load_profile ci || exit 1
+
+# Ensure we're not run directly
+if [[ "${BASH_SOURCE[0]}" == "${0}" ]]; then
+    echo "This script should be sourced, not executed directly."
+    echo "Usage: source ${BASH_SOURCE[0]}"
+    exit 1
+fi
+
+# =============================================================================
+# Profile Functions
+# =============================================================================
+
+# Print available profiles with a one-line description
+list_profiles() {
+    cat << 'EOF_PROFILES'
+  bounty-recon   All scanners, p/default + p/secrets, all custom packs (default)
+  ci             Semgrep + secrets, ERROR only, HIGH confidence, quiet output
+  audit          Semgrep + inventory, adds p/security-audit and INFO, all confidence levels
+  secrets-only   Trufflehog + artifacts, no code scanning
+EOF_PROFILES
+}
+
+# Load a profile into PROFILE_* variables
+# Args: $1 = profile name
+# Sets:
+#   PROFILE_NAME            profile name
+#   PROFILE_SCANNERS        scanners to run (semgrep secrets artifacts kics inventory)
+#   PROFILE_SEMGREP_CONFIGS semgrep registry configs
+#   PROFILE_RULE_PACKS      custom rule packs ("" = all, "none" = no custom rules)
+#   PROFILE_SEVERITIES      semgrep --severity values
+#   PROFILE_MIN_CONFIDENCE  default --min-confidence for extraction
+#   PROFILE_QUIET           "1" for quiet output
+# Returns 1 for an unknown profile
+load_profile() {
+    local name="$1"
+
+    case "$name" in
+        bounty-recon)
+            PROFILE_SCANNERS="semgrep secrets artifacts kics inventory"
+            PROFILE_SEMGREP_CONFIGS="p/default p/secrets"
+            PROFILE_RULE_PACKS=""
+            PROFILE_SEVERITIES="ERROR WARNING"
+            PROFILE_MIN_CONFIDENCE="low"
+            PROFILE_QUIET=""
+            ;;
+        ci)
+            PROFILE_SCANNERS="semgrep secrets"
+            PROFILE_SEMGREP_CONFIGS="p/default"
+            PROFILE_RULE_PACKS="web-vulns patterns"
+            PROFILE_SEVERITIES="ERROR"
+            PROFILE_MIN_CONFIDENCE="high"
+            PROFILE_QUIET="1"
+            ;;
+        audit)
+            PROFILE_SCANNERS="semgrep inventory"
+            PROFILE_SEMGREP_CONFIGS="p/default p/security-audit p/secrets"
+            PROFILE_RULE_PACKS=""
+            PROFILE_SEVERITIES="ERROR WARNING INFO"
+            PROFILE_MIN_CONFIDENCE="low"
+            PROFILE_QUIET=""
+            ;;
+        secrets-only)
+            PROFILE_SCANNERS="secrets artifacts"
+            PROFILE_SEMGREP_CONFIGS=""
+            PROFILE_RULE_PACKS="none"
+            PROFILE_SEVERITIES=""
+            PROFILE_MIN_CONFIDENCE="low"
+            PROFILE_QUIET=""
+            ;;
+        *)
+            echo "Error: Unknown profile '$name'"
+            echo "Available profiles:"
+            list_profiles
+            return 1
+            ;;
+    esac
+
+    PROFILE_NAME="$name"
+}
+
+# Check whether the loaded profile runs a scanner
+# Args: $1 = scanner name (semgrep, secrets, artifacts, kics, inventory)
+profile_has_scanner() {
+    [[ " $PROFILE_SCANNERS " == *" $1 "* ]]
+}
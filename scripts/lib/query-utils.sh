@@ -0,0 +1,254 @@
+#!/usr/bin/env bash
+# Query Utilities
+# Shared functions for running ad-hoc semgrep queries without a rule file
+#
+# Usage: source this file in other scripts
+#   source "$SCRIPT_DIR/lib/query-utils.sh"
+#
+# A query is a JSON object:
+#   {"lang": "python", "pattern": "eval(...)"}              pattern search
+#   {"lang": "python", "regex": "password\\s*="}            regex search
+#   {"lang": "python", "sources": [...], "sinks": [...],    taint search
+#    "sanitizers": [...]}
+# query_rule_yaml turns it into a one-rule semgrep config.
+
+# Ensure we're not run directly
+if [[ "${BASH_SOURCE[0]}" == "${0}" ]]; then
+    echo "This script should be sourced, not executed directly."
+    echo "Usage: source ${BASH_SOURCE[0]}"
+    exit 1
+fi
+
+CATALOG_ROOT="${CATALOG_ROOT:-$(cd "$(dirname "${BASH_SOURCE[0]}")/../.." && pwd)}"
+
+_QUERY_LIB_DIR="$(cd "$(dirname "${BASH_SOURCE[0]}")" && pwd)"
+source "$_QUERY_LIB_DIR/rule-utils.sh"
+
+# Rule id of ad-hoc queries in semgrep results
+QUERY_RULE_ID="adhoc-query"
+
+# =============================================================================
+# Rule Functions
+# =============================================================================
+
+# Kind of a query: pattern, regex, or taint (empty if it has none of them)
+# Args: $1 = query JSON
+query_kind() {
+    jq -r '
+        if ((.sinks // []) | length) > 0 then "taint"
+        elif (.pattern // "") != "" then "pattern"
+        elif (.regex // "") != "" then "regex"
+        else "" end
+    ' <<< "$1"
+}
+
+# One-line description of a query, for prompts and listings
+# Args: $1 = query JSON
+query_summary() {
+    jq -r '
+        def one: gsub("\\s+"; " ") | sub(" $"; "") | .[0:70];
+        "[\(.lang // "generic")] "
+        + if ((.sinks // []) | length) > 0 then
+            "taint \((.sources // []) | map(one) | join(" | ")) -> \(.sinks | map(one) | join(" | "))"
+            + if ((.sanitizers // []) | length) > 0 then " (sanitized by \(.sanitizers | map(one) | join(" | ")))" else "" end
+          elif (.pattern // "") != "" then (.pattern | one)
+          else "regex \(.regex | one)" end
+    ' <<< "$1"
+}
+
+# Semgrep rule YAML for a query
+# Patterns are written as literal block scalars, so they need no quoting
+# Args: $1 = query JSON
+query_rule_yaml() {
+    local query="$1"
+    local kind
+
+    kind=$(query_kind "$query")
+    jq -r --arg id "$QUERY_RULE_ID" --arg kind "$kind" '
+        def block($indent): sub("\\s+$"; "") | split("\n") | map($indent + .) | join("\n");
+        def items($key; $indent):
+            (.[$key] // []) | map("\($indent)- pattern: |-\n" + block($indent + "    ")) | join("\n");
+        "rules:",
+        "  - id: \($id)",
+        "    message: Ad-hoc query match",
+        "    severity: INFO",
+        "    languages: [\(if $kind == "regex" and (.lang // "") == "" then "generic" else .lang end)]",
+        if $kind == "taint" then
+            "    mode: taint",
+            "    pattern-sources:", items("sources"; "      "),
+            "    pattern-sinks:", items("sinks"; "      "),
+            (if ((.sanitizers // []) | length) > 0 then "    pattern-sanitizers:", items("sanitizers"; "      ") else empty end)
+        elif $kind == "pattern" then
+            "    pattern: |-", (.pattern | block("      "))
+        else
+            "    pattern-regex: |-", (.regex | block("      "))
+        end
+    ' <<< "$query"
+}
+
+# Run a query over targets
+# Args: $1 = query JSON, $2 = output file (semgrep JSON), rest = target dirs
+# Returns 1 (with semgrep's errors on stderr) if the rule doesn't parse
+run_query() {
+    local query="$1"
+    local output="$2"
+    shift 2
+    local rule_file errors
+
+    rule_file=$(mktemp)
+    query_rule_yaml "$query" > "$rule_file"
+    rm -f "$output"
+    semgrep scan \
+        --config="$rule_file" \
+        --metrics=off \
+        --quiet \
+        --json \
+        --output="$output" \
+        "$@" > /dev/null 2>&1 || true
+    rm -f "$rule_file"
+
+    if [[ ! -s "$output" ]]; then
+        echo "semgrep produced no output" >&2
+        return 1
+    fi
+    errors=$(jq -r '[.errors[]? | select(.level == "error") | .message // .long_msg // "error"] | unique | .[]' "$output")
+    if [[ -n "$errors" && "$(jq '.results | length' "$output")" -eq 0 ]]; then
+        echo "$errors" >&2
+        return 1
+    fi
+}
+
+# Matches of a query run, one per line: repo, path, line, code
+# Code semgrep withholds without a login is read from the file
+# Args: $1 = semgrep JSON, $2 = repos directory the targets live in
+query_matches() {
+    local results="$1"
+    local repos_dir="$2"
+    local repo path line code
+
+    jq -r --arg root "${repos_dir%/}/" '
+        .results[]?
+        | (.path | ltrimstr($root) | ltrimstr("./")) as $p
+        | [($p | split("/")[0]), ($p | split("/")[1:] | join("/")), .start.line,
+           ((.extra.lines // "") | split("\n")[0] | gsub("\t"; " "))]
+        | @tsv
+    ' "$results" | while IFS=$'\t' read -r repo path line code; do
+        if [[ -z "$code" || "$code" == "requires login" ]]; then
+            code=$(sed -n "${line}p" "$repos_dir/$repo/$path" 2>/dev/null || echo "")
+        fi
+        code=$(sed -E 's/^[[:space:]]+//; s/[[:space:]]+$//' <<< "$code")
+        printf '%s\t%s\t%s\t%s\n' "$repo" "$path" "$line" "$code"
+    done
+}
+
+# =============================================================================
+# Hunt Functions
+# =============================================================================
+
+# Hunts are saved queries with notes, kept as JSON so they can be shared
+# and re-run against other orgs:
+#   {"name": "...", "description": "...", "created": "...",
+#    "queries": [{"query": {...}, "note": "...", "added": "..."}]}
+HUNTS_DIR="${HUNTS_DIR:-$CATALOG_ROOT/hunts}"
+
+# Path of a hunt file from a hunt name (hunts/<name>.json) or a file path
+# Args: $1 = hunt name or path
+hunt_file() {
+    local hunt="$1"
+
+    if [[ "$hunt" == */* || "$hunt" == *.json ]]; then
+        echo "$hunt"
+    else
+        echo "$HUNTS_DIR/$hunt.json"
+    fi
+}
+
+# Add a query to a hunt, creating the hunt if needed
+# Saving a query the hunt already has replaces its note
+# Args: $1 = hunt file, $2 = query JSON, $3 = note (optional)
+hunt_add_query() {
+    local file="$1"
+    local query="$2"
+    local note="${3:-}"
+    local name tmp
+
+    if [[ ! -f "$file" ]]; then
+        name=$(basename "$file" .json)
+        mkdir -p "$(dirname "$file")"
+        jq -n --arg name "$name" --arg now "$(date -u +%Y-%m-%dT%H:%M:%SZ)" \
+            '{name: $name, description: "", created: $now, queries: []}' > "$file"
+    fi
+
+    tmp=$(mktemp)
+    jq --argjson q "$query" --arg note "$note" --arg now "$(date -u +%Y-%m-%dT%H:%M:%SZ)" '
+        if any(.queries[]; .query == $q) then
+            .queries |= map(if .query == $q and $note != "" then .note = $note else . end)
+        else
+            .queries += [{query: $q, note: $note, added: $now}]
+        end
+    ' "$file" > "$tmp" && mv "$tmp" "$file"
+    rm -f "$tmp"
+}
+
+# Set a hunt's description
+# Args: $1 = hunt file, $2 = description
+hunt_describe() {
+    local file="$1"
+    local tmp
+
+    tmp=$(mktemp)
+    jq --arg d "$2" '.description = $d' "$file" > "$tmp" && mv "$tmp" "$file"
+    rm -f "$tmp"
+}
+
+# Saved hunts, one per line: name, query count, description
+list_hunts() {
+    local file
+
+    for file in "$HUNTS_DIR"/*.json; do
+        [[ -f "$file" ]] || continue
+        jq -r '[.name, (.queries | length), .description] | @tsv' "$file"
+    done
+}
+
+# =============================================================================
+# Session Functions
+# =============================================================================
+
+# Console sessions are logged to scans/<org>/query-sessions/<id>.jsonl, one
+# event per line, for session-report.sh:
+#   {"type": "query", "at": "...", "query": {...}, "note": "...", "matches": [...]}
+#   {"type": "note", "at": "...", "text": "..."}
+#   {"type": "disposition", "at": "...", "match": {repo, path, line},
+#    "status": "confirmed", "note": "..."}
+QUERY_SESSIONS_DIR="query-sessions"
+
+# Dispositions a reviewed match can be given
+QUERY_DISPOSITIONS="confirmed false-positive needs-review"
+
+# Session log path
+# Args: $1 = org, $2 = session id
+session_file() {
+    echo "$CATALOG_ROOT/scans/$1/$QUERY_SESSIONS_DIR/$2.jsonl"
+}
+
+# Id of an org's most recent session (empty if none)
+# Args: $1 = org
+latest_session() {
+    local dir="$CATALOG_ROOT/scans/$1/$QUERY_SESSIONS_DIR"
+
+    [[ -d "$dir" ]] || return 0
+    ls -t "$dir" 2>/dev/null | sed -n 's/\.jsonl$//p' | head -n 1
+}
+
+# Append an event to a session log
+# Args: $1 = session file, $2 = event type, $3 = event fields (JSON object)
+session_record() {
+    local file="$1"
+    local type="$2"
+    local fields="${3:-{\}}"
+
+    mkdir -p "$(dirname "$file")"
+    jq -c -n --arg type "$type" --arg now "$(date -u +%Y-%m-%dT%H:%M:%SZ)" --argjson f "$fields" \
+        '{type: $type, at: $now} + $f' >> "$file"
+}
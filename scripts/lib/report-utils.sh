@@ -0,0 +1,368 @@
+#!/usr/bin/env bash
+# Report Utilities
+# Shared functions for rendering report templates and delivering reports
+# by email
+#
+# Usage: source this file in other scripts
+#   source "$SCRIPT_DIR/lib/report-utils.sh"
+#
+# Templates use a small mustache-style syntax, filled from a JSON object:
+#   {{name}}               value of a key (a.b for nested keys, items.0 for
+#                          an array item), escaped in HTML
+#   {{{name}}}             value without HTML escaping
+#   {{#name}}...{{/name}}  repeated for each item of an array (an item's keys
+#                          are visible inside, {{.}} is the item itself), or
+#                          shown once when the value is true or non-empty
+#   {{^name}}...{{/name}}  shown when the value is missing, false, or empty
+
+# Ensure we're not run directly
+if [[ "${BASH_SOURCE[0]}" == "${0}" ]]; then
+    echo "This script should be sourced, not executed directly."
+    echo "Usage: source ${BASH_SOURCE[0]}"
+    exit 1
+fi
+
+CATALOG_ROOT="${CATALOG_ROOT:-$(cd "$(dirname "${BASH_SOURCE[0]}")/../.." && pwd)}"
+
+_REPORT_LIB_DIR="$(cd "$(dirname "${BASH_SOURCE[0]}")" && pwd)"
+source "$_REPORT_LIB_DIR/severity-utils.sh"
+source "$_REPORT_LIB_DIR/finding-utils.sh"
+
+# Report templates; email templates are <dir>/email/<name>.{subject,txt,html}
+REPORT_TEMPLATES_DIR="${REPORT_TEMPLATES_DIR:-$CATALOG_ROOT/templates}"
+
+# Digest severities, most severe first (the normalized levels)
+REPORT_SEVERITIES="$SEVERITY_LEVELS"
+
+# Translation files, one per language: <dir>/<code>.json (see locales/en.json)
+LOCALES_DIR="${LOCALES_DIR:-$CATALOG_ROOT/locales}"
+
+# Language of report boilerplate when a script isn't given --lang
+REPORT_LANG="${REPORT_LANG:-en}"
+
+# =============================================================================
+# Template Functions
+# =============================================================================
+
+# Render a template with data
+# Args: $1 = template file, $2 = data JSON, $3 = "html" to escape values
+render_template() {
+    local template="$1"
+    local data="$2"
+    local mode="${3:-text}"
+
+    jq -r -n --rawfile template "$template" --argjson data "$data" --arg mode "$mode" '
+        def lookup($ctx; $name):
+            if $name == "." then $ctx["."] // $ctx else $ctx | getpath($name | split(".") | map(tonumber? // .)) end;
+        def text: if type == "string" then . elif . == null then "" else tojson end;
+        def vars($ctx):
+            gsub("\\{\\{(?<raw>\\{)?\\s*(?<name>[A-Za-z0-9_.]+)\\s*\\}?\\}\\}";
+                (lookup($ctx; .name) | text) as $value
+                | if $mode == "html" and .raw == null then $value | @html else $value end);
+        def truthy: . != null and . != false and . != "" and . != [] and . != {};
+        def render($ctx):
+            (capture("^(?<pre>.*?)\\{\\{(?<op>[#^])\\s*(?<name>[A-Za-z0-9_.]+)\\s*\\}\\}(?<rest>.*)$"; "m") // null) as $m
+            | if $m == null then vars($ctx)
+              else
+                ("{{/" + $m.name + "}}") as $close
+                | ($m.rest | index($close)) as $stop
+                | if $stop == null then vars($ctx)
+                  else
+                    $m.rest[:$stop] as $inner
+                    | $m.rest[($stop + ($close | length)):] as $after
+                    | lookup($ctx; $m.name) as $value
+                    | ($m.pre | vars($ctx))
+                      + (if $m.op == "^" then
+                            (if $value | truthy then "" else $inner | render($ctx) end)
+                         elif ($value | type) == "array" then
+                            [$value[] | . as $item
+                             | $inner | render($ctx + (if ($item | type) == "object" then $item else {} end) + {".": $item})]
+                            | join("")
+                         elif $value | truthy then
+                            $inner | render($ctx + (if ($value | type) == "object" then $value else {} end))
+                         else "" end)
+                      + ($after | render($ctx))
+                  end
+              end;
+        $template | render($data)
+    '
+}
+
+# Path of an email template part, from the templates directory or a path
+# Args: $1 = template name or directory, $2 = part (subject, txt, html)
+email_template_file() {
+    local template="$1"
+    local part="$2"
+
+    if [[ "$template" == */* ]]; then
+        echo "$template.$part"
+    else
+        echo "$REPORT_TEMPLATES_DIR/email/$template.$part"
+    fi
+}
+
+# =============================================================================
+# Localization Functions
+# =============================================================================
+
+# A translation file holds:
+#   {locale, name,
+#    severity: {<severity>: label},   critical/high/medium/low and semgrep's
+#                                     error/warning/info, lowercase
+#    status: {<triage status>: label},
+#    strings: {digest: {...}, share: {...}, session: {...}, executive: {...}},
+#    rules: {<rule id>: {remediation}}}
+# Strings may use {name} placeholders, filled from the report's data.
+# Anything a translation leaves out falls back to en.json, and rules (full
+# check_id or its last segment) override a rule's metadata.remediation: a
+# string replaces its summary, an object the fields it sets (summary,
+# code-before, code-after, references).
+
+# Path of the translation file for a language: <code>.json, or the file for
+# its primary subtag (pt-BR falls back to pt), or a path to a file
+# Args: $1 = language code or file
+# Returns 1 if there is none
+locale_file() {
+    local lang="$1"
+
+    if [[ "$lang" == */* || "$lang" == *.json ]]; then
+        [[ -f "$lang" ]] && echo "$lang" && return 0
+        return 1
+    fi
+    if [[ ! "$lang" =~ ^[A-Za-z]{2,3}([_-][A-Za-z0-9]{2,8})?$ ]]; then
+        return 1
+    fi
+    if [[ -f "$LOCALES_DIR/$lang.json" ]]; then
+        echo "$LOCALES_DIR/$lang.json"
+    elif [[ -f "$LOCALES_DIR/${lang%%[-_]*}.json" ]]; then
+        echo "$LOCALES_DIR/${lang%%[-_]*}.json"
+    else
+        return 1
+    fi
+}
+
+# Report language of an org: "report_lang" in its meta.json, else REPORT_LANG
+# Args: $1 = org
+org_report_lang() {
+    jq -r --arg default "$REPORT_LANG" '.report_lang // $default' \
+        "$CATALOG_ROOT/catalog/tracked/$1/meta.json" 2>/dev/null || echo "$REPORT_LANG"
+}
+
+# Translation for a language merged over English, as JSON
+# Args: $1 = language code or file (default REPORT_LANG)
+# Returns 1 if the language has no translation file
+load_locale() {
+    local file
+
+    file=$(locale_file "${1:-$REPORT_LANG}") || return 1
+    jq -c -s '.[0] * .[1]' "$LOCALES_DIR/en.json" "$file"
+}
+
+# Languages with a translation file, one "<code>\t<name>" per line
+list_locales() {
+    local file
+
+    for file in "$LOCALES_DIR"/*.json; do
+        [[ -f "$file" ]] || continue
+        jq -r --arg code "$(basename "$file" .json)" '[$code, (.name // $code)] | @tsv' "$file"
+    done
+}
+
+# Report data with its translation: adds lang, t (the strings, placeholders
+# filled from the data's top-level keys), severity_label next to every
+# severity, and the translated remediation of every rule or check_id
+# Args: $1 = data JSON, $2 = translation JSON from load_locale
+localize_data() {
+    jq -c -n --argjson data "$1" --argjson locale "$2" "$REMEDIATION_JQ_DEFS"'
+        ($locale.severity // {}) as $severities
+        | def severity_name: if type == "string" then ($severities[ascii_downcase] // .) else . end;
+          def remediation($id): ($locale.rules // {}) as $rules
+            | ($rules[$id] // $rules[$id | split(".") | last] // {}).remediation;
+          def fill($ctx):
+            gsub("\\{(?<key>[A-Za-z0-9_]+)\\}";
+                .key as $key
+                | ($ctx[$key] // null) as $value
+                | if $value == null then "{\($key)}"
+                  elif ($key | test("severity")) then $value | severity_name | tostring
+                  else $value | tostring end);
+          ($data | walk(
+            if type == "object" then
+                (if (.severity | type) == "string" then . + {severity_label: (.severity | severity_name)} else . end)
+                | ((.check_id // .rule) as $id
+                   | if ($id | type) == "string" and remediation($id) then
+                        . + {remediation: ((.remediation | remediation_guidance // {references: []})
+                                           + (remediation($id) | remediation_overrides))}
+                     else . end)
+            else . end)) as $localized
+        | $localized + {lang: $locale.locale,
+                        t: ($locale.strings // {} | walk(if type == "string" then fill($localized) else . end))}
+    '
+}
+
+# =============================================================================
+# Digest Functions
+# =============================================================================
+
+# Findings of a catalog scan directory in digest form, as a JSON array of
+# {key, scanner, severity, rule, category, repo, path, line, message, url,
+# remediation}; category is the rule's vulnerability class, else its first
+# CWE, for semgrep, "Secrets" for trufflehog, and the query category for KICS
+# Severities are normalized per scanner (severity-utils.sh): semgrep
+# ERROR/WARNING/INFO are high/medium/low, verified secrets critical and
+# unverified ones medium, KICS keeps its own levels. Secret values are never
+# included.
+# Args: $1 = scan directory, $2 = org
+scan_digest_findings() {
+    local scan_dir="$1"
+    local org="$2"
+
+    {
+        if [[ -f "$scan_dir/semgrep.json.gz" ]]; then
+            gzip -dc "$scan_dir/semgrep.json.gz" | jq -c --arg marker "repos/$org/" "$SEVERITY_JQ_DEFS$REMEDIATION_JQ_DEFS"'
+                .results[]?
+                | (.path | if index($marker) then .[(index($marker) + ($marker | length)):] else ltrimstr("./") end) as $p
+                | (if (.extra.lines // "requires login") == "requires login" then "\(.start.line)"
+                   else .extra.lines | gsub("\\s+"; " ") end) as $code
+                | {key: "semgrep\u0000\(.check_id)\u0000\($p)\u0000\($code)", scanner: "semgrep",
+                   severity: (.extra.severity | severity_level("semgrep")),
+                   rule: (.check_id | split(".") | last),
+                   category: ([.extra.metadata.vulnerability_class, .extra.metadata.cwe | arrays[0] // strings][0] // "Other"),
+                   repo: ($p | split("/")[0]),
+                   path: ($p | split("/")[1:] | join("/")), line: .start.line,
+                   message: ((.extra.message // "") | split("\n")[0] | .[0:200]), url: (.extra.permalink // null),
+                   remediation: (.extra.metadata.remediation // null | remediation_guidance)}
+            '
+        fi
+        if [[ -f "$scan_dir/trufflehog.json.gz" ]]; then
+            gzip -dc "$scan_dir/trufflehog.json.gz" | jq -c "$SEVERITY_JQ_DEFS"'
+                (.SourceMetadata.Data.Git // .SourceMetadata.Data.Filesystem // {}) as $src
+                | ($src.repository // "" | sub("\\.git$"; "") | split("/") | last) as $repo
+                | {key: "secret\u0000\(.DetectorName)\u0000\($src.file // "")\u0000\(.Raw // "" | @base64 | .[0:16])",
+                   scanner: "trufflehog", severity: (if .Verified then "verified" else "unverified" end | severity_level("trufflehog")),
+                   rule: "\(.DetectorName) secret\(if .Verified then " (verified)" else "" end)", category: "Secrets", repo: $repo,
+                   path: ($src.file // ""), line: ($src.line // null),
+                   message: "\(.DetectorName) credential\(if .Verified then ", verified live" else "" end)", url: null,
+                   remediation: null}
+            '
+        fi
+        if [[ -f "$scan_dir/kics.json.gz" ]]; then
+            gzip -dc "$scan_dir/kics.json.gz" | jq -c --arg marker "repos/$org/" "$SEVERITY_JQ_DEFS"'
+                .queries[]? as $q | $q.files[]?
+                | (.file_name // "" | if index($marker) then .[(index($marker) + ($marker | length)):] else ltrimstr("./") end) as $p
+                | {key: "kics\u0000\($q.query_id)\u0000\($p)\u0000\(.similarity_id // .line)", scanner: "kics",
+                   severity: ($q.severity | severity_level("kics")), rule: $q.query_name,
+                   category: "Infrastructure: \($q.category // "Other")", repo: ($p | split("/")[0]),
+                   path: ($p | split("/")[1:] | join("/")), line: .line,
+                   message: ((.issue_type // "") + (if .actual_value then ": " + .actual_value else "" end) | .[0:200]),
+                   url: null, remediation: null}
+            '
+        fi
+    } | jq -s -c '.'
+}
+
+# Digest of a scan: its findings at or above a severity, and whether each is
+# new since a previous scan
+# Prints {org, scan, previous, min_severity, total, new_count, counts,
+# findings: [... + {new, severity_rank}]}, new findings first
+# Args: $1 = org, $2 = scan directory, $3 = previous scan directory (optional),
+#       $4 = minimum severity (default low), $5 = "new" to keep only new findings
+scan_digest() {
+    local org="$1"
+    local scan_dir="$2"
+    local previous_dir="${3:-}"
+    local min_severity="${4:-low}"
+    local only_new="${5:-}"
+    local current previous="[]"
+
+    current=$(scan_digest_findings "$scan_dir" "$org")
+    [[ -n "$previous_dir" ]] && previous=$(scan_digest_findings "$previous_dir" "$org")
+
+    jq -n -c --argjson current "$current" --argjson previous "$previous" \
+        --arg org "$org" --arg scan "$(basename "$scan_dir")" \
+        --arg previous_scan "${previous_dir:+$(basename "$previous_dir")}" \
+        --arg min "$min_severity" --arg severities "$REPORT_SEVERITIES" --arg only_new "$only_new" "$SEVERITY_JQ_DEFS"'
+        ($severities | split(" ")) as $order
+        | ($min | severity_rank) as $min_rank
+        | ($previous | map({key: .key, value: true}) | from_entries) as $seen
+        | [$current[]
+           | . + {severity_rank: (.severity | severity_rank),
+                  new: ($previous_scan != "" and ($seen[.key] | not))}
+           | select(.severity_rank <= $min_rank)
+           | select($only_new == "" or .new)
+           | del(.key)]
+        | sort_by((if .new then 0 else 1 end), .severity_rank, .repo, .path, .line) as $findings
+        | {org: $org, scan: $scan, previous: (if $previous_scan == "" then null else $previous_scan end),
+           min_severity: $min, total: ($findings | length),
+           new_count: ([$findings[] | select(.new)] | length),
+           counts: [$order[] as $s | {severity: $s, count: ([$findings[] | select(.severity == $s)] | length)}
+                    | select(.count > 0)],
+           findings: $findings}
+    '
+}
+
+# =============================================================================
+# Email Functions
+# =============================================================================
+
+# SMTP delivery settings (from .env):
+#   SMTP_URL       smtp://host:587 (STARTTLS required) or smtps://host:465
+#   SMTP_USER      login, if the server needs one
+#   SMTP_PASSWORD  password for SMTP_USER
+#   SMTP_FROM      sender address
+
+# Build a MIME message with a text part and an optional HTML part
+# Args: $1 = from, $2 = recipients (comma-separated), $3 = subject,
+#       $4 = text body file, $5 = HTML body file (optional)
+build_email() {
+    local from="$1"
+    local to="$2"
+    local subject="$3"
+    local text_file="$4"
+    local html_file="${5:-}"
+    local boundary
+
+    boundary="bounty-hunter-$(date +%s)-$$"
+    printf 'From: %s\r\n' "$from"
+    printf 'To: %s\r\n' "$to"
+    printf 'Subject: %s\r\n' "$subject"
+    printf 'Date: %s\r\n' "$(LC_ALL=C date -R 2>/dev/null || LC_ALL=C date '+%a, %d %b %Y %H:%M:%S %z')"
+    printf 'MIME-Version: 1.0\r\n'
+    if [[ -n "$html_file" ]]; then
+        printf 'Content-Type: multipart/alternative; boundary="%s"\r\n\r\n' "$boundary"
+        printf -- '--%s\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n' "$boundary"
+        sed 's/$/\r/' "$text_file"
+        printf -- '\r\n--%s\r\nContent-Type: text/html; charset=utf-8\r\n\r\n' "$boundary"
+        sed 's/$/\r/' "$html_file"
+        printf -- '\r\n--%s--\r\n' "$boundary"
+    else
+        printf 'Content-Type: text/plain; charset=utf-8\r\n\r\n'
+        sed 's/$/\r/' "$text_file"
+    fi
+}
+
+# Send a message built by build_email over SMTP with curl
+# Args: $1 = message file, $2 = recipients (comma-separated)
+# Returns 1 (with curl's error on stderr) if delivery fails
+send_email() {
+    local message="$1"
+    local recipients="$2"
+    local rcpt_args=() auth_args=() tls_args=() rcpt
+
+    if [[ -z "${SMTP_URL:-}" || -z "${SMTP_FROM:-}" ]]; then
+        echo "SMTP_URL and SMTP_FROM must be set (see .env.example)" >&2
+        return 1
+    fi
+    while IFS= read -r rcpt; do
+        rcpt=$(echo "$rcpt" | xargs)
+        [[ -n "$rcpt" ]] && rcpt_args+=(--mail-rcpt "$rcpt")
+    done <<< "$(echo "$recipients" | tr ',' '\n')"
+    [[ -n "${SMTP_USER:-}" ]] && auth_args=(--user "$SMTP_USER:${SMTP_PASSWORD:-}")
+    [[ "$SMTP_URL" == smtp://* ]] && tls_args=(--ssl-reqd)
+
+    curl -sS -m 60 --url "$SMTP_URL" \
+        ${tls_args[@]+"${tls_args[@]}"} \
+        ${auth_args[@]+"${auth_args[@]}"} \
+        --mail-from "$SMTP_FROM" \
+        "${rcpt_args[@]}" \
+        --upload-file "$message"
+}
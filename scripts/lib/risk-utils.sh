@@ -0,0 +1,308 @@
+#!/usr/bin/env bash
+# Asset Risk Utilities
+# Shared functions for scoring repos by exposure, recent churn, and finding
+# density, used by targets.sh to pick what to audit next
+#
+# Usage: source this file in other scripts
+#   source "$SCRIPT_DIR/lib/risk-utils.sh"
+#
+# A repo's score (0-100) is the sum of three parts, scaled by how the
+# program pays:
+#   exposure  0-40  public visibility, HTTP entry points, auth/payment/admin
+#                   routes (surfaces.json), routes seen in proxy history
+#   churn     0-30  commits in the last RISK_CHURN_DAYS days
+#   density   0-30  severity-weighted semgrep findings per 100 tracked files
+# Programs with paid scopes keep the full score; programs whose scopes are
+# all unpaid (VDPs) are scaled by RISK_UNPAID_FACTOR.
+
+# Ensure we're not run directly
+if [[ "${BASH_SOURCE[0]}" == "${0}" ]]; then
+    echo "This script should be sourced, not executed directly."
+    echo "Usage: source ${BASH_SOURCE[0]}"
+    exit 1
+fi
+
+CATALOG_ROOT="${CATALOG_ROOT:-$(cd "$(dirname "${BASH_SOURCE[0]}")/../.." && pwd)}"
+
+_RISK_LIB_DIR="$(cd "$(dirname "${BASH_SOURCE[0]}")" && pwd)"
+source "$_RISK_LIB_DIR/surface-utils.sh"
+
+RISK_CHURN_DAYS="${RISK_CHURN_DAYS:-90}"
+
+# Commits in the churn window that earn the full churn score
+RISK_CHURN_FULL=60
+
+# Weighted findings per 100 files that earn the full density score
+RISK_DENSITY_FULL=20
+
+RISK_UNPAID_FACTOR="0.5"
+
+RISK_SCORES_FILE="risk-scores.json"
+
+# =============================================================================
+# Signal Functions
+# =============================================================================
+
+# Recent activity in a repo: "<commits>\t<files changed>" over the churn window
+# Shallow clones only see the commits they fetched, so churn is a lower bound
+# Args: $1 = repo directory, $2 = days (default: RISK_CHURN_DAYS)
+repo_churn() {
+    local repo="$1"
+    local days="${2:-$RISK_CHURN_DAYS}"
+    local commits files
+
+    commits=$(git -C "$repo" rev-list --count --since="$days days ago" HEAD 2>/dev/null || echo 0)
+    files=$(git -C "$repo" log --since="$days days ago" --name-only --pretty=format: 2>/dev/null | \
+        grep -c . || true)
+    printf '%s\t%s\n' "${commits:-0}" "${files:-0}"
+}
+
+# Severity-weighted finding count in a semgrep result file (ERROR 3,
+# WARNING 2, INFO 1); prints nothing when the repo has no results
+# Args: $1 = semgrep JSON file (.json or .json.gz)
+repo_weighted_findings() {
+    local results_file="$1"
+    local reader="cat"
+
+    [[ -f "$results_file" ]] || return 0
+    [[ "$results_file" == *.gz ]] && reader="gzip -dc"
+    $reader "$results_file" 2>/dev/null | jq '
+        {"ERROR": 3, "WARNING": 2} as $w
+        | [.results[]? | $w[.extra.severity // ""] // 1] | add // 0
+    ' 2>/dev/null || true
+}
+
+# Whether an org's bug bounty program pays, from the platform scope lists:
+# prints paid, unpaid, or unknown
+# Scopes are matched on the program URL, else a URL ending in /<org>
+# Args: $1 = org
+org_payout_status() {
+    local org="$1"
+    local platform program_url platform_file
+
+    platform=$(get_org_field "$org" platform 2>/dev/null || echo "")
+    program_url=$(get_org_field "$org" program_url 2>/dev/null || echo "")
+    platform_file="$CATALOG_ROOT/catalog/platforms/$platform.json"
+
+    if [[ -z "$platform" || ! -f "$platform_file" ]]; then
+        echo "unknown"
+        return 0
+    fi
+
+    jq -r --arg url "${program_url%/}" --arg org "$org" '
+        [.scopes[]? | select(.url != null and .url != "")
+         | select(if $url != "" then (.url | rtrimstr("/")) == $url
+                  else (.url | ascii_downcase | endswith("/" + ($org | ascii_downcase))) end)
+         | .paid] as $paid
+        | if ($paid | length) == 0 then "unknown"
+          elif any($paid[]; . == true) then "paid"
+          else "unpaid" end
+    ' "$platform_file"
+}
+
+# =============================================================================
+# Scoring Functions
+# =============================================================================
+
+# Score each active repo of an org
+# Args: $1 = org
+# Prints a JSON array of {org, repo, score, exposure, churn, density,
+# payout, signals} sorted by score, highest first
+score_org_repos() {
+    local org="$1"
+    local repos_dir scans_dir surfaces store payout
+    local repo name tags commits files tracked weighted
+    local rows=""
+
+    repos_dir="$(get_org_repos_dir "$org")"
+    scans_dir="$CATALOG_ROOT/scans/$org"
+    surfaces="$scans_dir/surfaces.json"
+    store="$(get_org_catalog_dir "$org")/$OBSERVED_ENDPOINTS_FILE"
+    payout=$(org_payout_status "$org")
+
+    [[ -d "$repos_dir" ]] || { echo '[]'; return 0; }
+
+    while IFS= read -r repo; do
+        [[ -z "$repo" ]] && continue
+        name=$(basename "$repo")
+        tags=$(get_repo_tags "$repos_dir" "$name" | paste -sd, -)
+        IFS=$'\t' read -r commits files < <(repo_churn "$repo")
+        tracked=$(git -C "$repo" ls-files 2>/dev/null | grep -c . || true)
+        weighted=$(repo_weighted_findings "$scans_dir/semgrep-results/$name.json.gz")
+        [[ -z "$weighted" ]] && weighted=$(repo_weighted_findings "$scans_dir/semgrep-results/$name.json")
+        rows+=$(printf '%s\t%s\t%s\t%s\t%s\t%s' "$name" "$tags" "$commits" "$files" "$tracked" "${weighted:-null}")$'\n'
+    done < <(get_active_repos "$repos_dir")
+
+    local surfaces_json='[]' observed='[]'
+    [[ -f "$surfaces" ]] && surfaces_json=$(cat "$surfaces")
+    [[ -f "$store" ]] && observed=$(cat "$store")
+
+    printf '%s' "$rows" | jq -R -s --arg org "$org" --arg payout "$payout" \
+        --argjson surfaces "$surfaces_json" --argjson observed "$observed" \
+        --arg patterns "$SENSITIVE_CONTEXT_PATTERNS" \
+        --argjson churn_full "$RISK_CHURN_FULL" --argjson density_full "$RISK_DENSITY_FULL" \
+        --argjson unpaid "$RISK_UNPAID_FACTOR" --argjson days "$RISK_CHURN_DAYS" '
+        def cap($n): if . > $n then $n else . end;
+        def round1: . * 10 | round / 10;
+        ($patterns | split("\n") | map(select(. != "") | split("\t") | {context: .[0], re: .[1]})) as $contexts
+        | split("\n") | map(select(. != "") | split("\t")
+            | {repo: .[0], tags: (.[1] | split(",") | map(select(. != ""))),
+               commits: (.[2] | tonumber), files_changed: (.[3] | tonumber),
+               tracked: (.[4] | tonumber), weighted: (.[5] | tonumber? // null)})
+        | map(
+            .repo as $r
+            | [$surfaces[] | select(.repo == $r and .kind == "http")] as $http
+            | ([$http[] | (.route // "" | ascii_downcase) as $route
+                | first($contexts[] | . as $c | select($route | test($c.re)) | $c.context)] | unique) as $sensitive
+            | ([$observed[] | select(.handler.repo? == $r)] | length) as $live
+            | (((if (.tags | index("public")) then 10 else 0 end)
+                + (if ($http | length) > 0 then 5 + (($http | length) / 4 | cap(10)) else 0 end)
+                + ([$sensitive[] | {"auth": 6, "payment": 6, "admin": 3}[.]] | add // 0)
+                + (if $live > 0 then 5 else 0 end)) | cap(40)) as $exposure
+            | (30 * ((.commits / $churn_full) | cap(1))) as $churn
+            | (if .weighted == null then 15
+               elif .tracked == 0 then 0
+               else 30 * ((.weighted * 100 / .tracked / $density_full) | cap(1)) end) as $density
+            | {org: $org, repo: .repo,
+               score: ((($exposure + $churn + $density) * (if $payout == "unpaid" then $unpaid else 1 end)) | round1),
+               exposure: ($exposure | round1), churn: ($churn | round1), density: ($density | round1),
+               payout: $payout,
+               signals: {public: ((.tags | index("public")) != null), http_routes: ($http | length),
+                         sensitive: $sensitive, observed_endpoints: $live,
+                         commits: .commits, files_changed: .files_changed, churn_days: $days,
+                         weighted_findings: .weighted, tracked_files: .tracked,
+                         scanned: (.weighted != null)}})
+        | sort_by(-.score, .repo)
+    '
+}
+
+# One-line reasons for a scored repo, strongest signal first
+# Args: stdin = one score object from score_org_repos
+risk_reasons() {
+    jq -r '
+        .signals as $s
+        | [ (if ($s.sensitive | length) > 0 then "handles \($s.sensitive | join("/"))" else empty end),
+            (if $s.http_routes > 0 then "\($s.http_routes) HTTP routes" else empty end),
+            (if $s.observed_endpoints > 0 then "\($s.observed_endpoints) live endpoints seen" else empty end),
+            (if $s.commits > 0 then "\($s.commits) commits in \($s.churn_days)d" else empty end),
+            (if $s.scanned | not then "not scanned yet"
+             elif $s.weighted_findings > 0 then "\($s.weighted_findings) weighted findings" else empty end),
+            (if $s.public then "public" else empty end),
+            (if .payout == "unpaid" then "no bounty (VDP)" else empty end)
+          ] | join(", ")
+    '
+}
+
+# =============================================================================
+# Hotspot Functions
+# =============================================================================
+
+# Directory depth hotspots are grouped at (src/api/handlers at depth 2 is src/api)
+HOTSPOT_DEPTH="${HOTSPOT_DEPTH:-2}"
+
+# Directory prefix of a repo-relative path at a depth ("." for root files),
+# as an awk function shared by the hotspot functions
+_HOTSPOT_AWK_DIR='
+    function hotspot_dir(path, depth,    n, parts, i, d) {
+        n = split(path, parts, "/")
+        if (n <= 1) return "."
+        d = parts[1]
+        for (i = 2; i < n && i <= depth; i++) d = d "/" parts[i]
+        return d
+    }'
+
+# Git activity per directory over the churn window, one line each:
+# dir <tab> commits <tab> lines changed <tab> authors <tab> new authors
+# New authors made their first commit to the directory inside the window;
+# with shallow clones, "first" means first in the fetched history
+# Args: $1 = repo directory, $2 = days (default: RISK_CHURN_DAYS),
+#       $3 = depth (default: HOTSPOT_DEPTH)
+directory_churn() {
+    local repo="$1"
+    local days="${2:-$RISK_CHURN_DAYS}"
+    local depth="${3:-$HOTSPOT_DEPTH}"
+    local since
+
+    since=$(( $(date +%s) - days * 86400 ))
+    git -C "$repo" log --numstat --no-renames --format='@%at %ae' 2>/dev/null | \
+        awk -F'\t' -v OFS='\t' -v since="$since" -v depth="$depth" "$_HOTSPOT_AWK_DIR"'
+            /^@/ { split(substr($0, 2), h, " "); t = h[1]; author = h[2]; commit++; next }
+            NF == 3 {
+                dir = hotspot_dir($3, depth)
+                key = dir SUBSEP author
+                if (!(key in first) || t < first[key]) first[key] = t
+                if (t < since) next
+                if (!((dir SUBSEP commit) in counted)) { counted[dir, commit] = 1; commits[dir]++ }
+                lines[dir] += ($1 == "-" ? 0 : $1) + ($2 == "-" ? 0 : $2)
+                active[key] = 1
+            }
+            END {
+                for (key in active) {
+                    split(key, k, SUBSEP)
+                    authors[k[1]]++
+                    if (first[key] >= since) fresh[k[1]]++
+                }
+                for (dir in commits) print dir, commits[dir], lines[dir], authors[dir] + 0, fresh[dir] + 0
+            }' | sort
+}
+
+# Semgrep findings per directory, one line each:
+# dir <tab> findings <tab> severity-weighted findings (ERROR 3, WARNING 2, INFO 1)
+# Args: $1 = semgrep JSON file (.json or .json.gz), $2 = repo name,
+#       $3 = depth (default: HOTSPOT_DEPTH)
+directory_findings() {
+    local results_file="$1"
+    local repo="$2"
+    local depth="${3:-$HOTSPOT_DEPTH}"
+    local reader="cat"
+
+    [[ -f "$results_file" ]] || return 0
+    [[ "$results_file" == *.gz ]] && reader="gzip -dc"
+    $reader "$results_file" 2>/dev/null | jq -r --arg repo "$repo" '
+        ("/" + $repo + "/") as $marker
+        | .results[]?
+        | (.path | if startswith($repo + "/") then ltrimstr($repo + "/")
+                   elif index($marker) then .[(index($marker) + ($marker | length)):] else . end)
+          + "\t" + ({"ERROR": "3", "WARNING": "2"}[.extra.severity // ""] // "1")
+    ' 2>/dev/null | \
+        awk -F'\t' -v OFS='\t' -v depth="$depth" "$_HOTSPOT_AWK_DIR"'
+            { dir = hotspot_dir($1, depth); n[dir]++; w[dir] += $2 }
+            END { for (dir in n) print dir, n[dir], w[dir] }' | sort
+}
+
+# Rank a repo's directories as hotspots
+# Each part is relative to the repo's busiest directory:
+#   40% churn (commits and lines changed), 20% contributor turnover (share
+#   of the window's authors who are new to the directory), 40% finding density
+# Args: $1 = repo name, $2 = directory_churn output file,
+#       $3 = directory_findings output file
+# Prints a JSON array of {repo, dir, score, commits, lines_changed, authors,
+# new_authors, turnover, findings, weighted_findings}, highest score first
+score_hotspots() {
+    local repo="$1"
+    local churn_file="$2"
+    local findings_file="$3"
+
+    jq -n --arg repo "$repo" --rawfile churn "$churn_file" --rawfile findings "$findings_file" '
+        def rows: split("\n") | map(select(. != "") | split("\t"));
+        def ratio($a; $b): if $b > 0 then $a / $b else 0 end;
+        (($churn | rows | map({key: .[0], value: {commits: (.[1] | tonumber), lines_changed: (.[2] | tonumber),
+                                                  authors: (.[3] | tonumber), new_authors: (.[4] | tonumber)}}))
+         | from_entries) as $c
+        | (($findings | rows | map({key: .[0], value: {findings: (.[1] | tonumber), weighted_findings: (.[2] | tonumber)}}))
+           | from_entries) as $f
+        | (($c | keys) + ($f | keys) | unique) as $dirs
+        | ([$c[].commits] | max // 0) as $max_commits
+        | ([$c[].lines_changed] | max // 0) as $max_lines
+        | ([$f[].weighted_findings] | max // 0) as $max_weighted
+        | [$dirs[] as $d
+            | ({commits: 0, lines_changed: 0, authors: 0, new_authors: 0} + ($c[$d] // {})
+               + {findings: 0, weighted_findings: 0} + ($f[$d] // {})) as $s
+            | ratio($s.new_authors; $s.authors) as $turnover
+            | {repo: $repo, dir: $d,
+               score: (100 * (0.2 * ratio($s.commits; $max_commits) + 0.2 * ratio($s.lines_changed; $max_lines)
+                              + 0.2 * $turnover + 0.4 * ratio($s.weighted_findings; $max_weighted)) | round)}
+              + $s + {turnover: ($turnover * 100 | round / 100)}]
+        | sort_by(-.score, .dir)
+    '
+}
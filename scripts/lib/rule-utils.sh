@@ -0,0 +1,1881 @@
+#!/usr/bin/env bash
+# Custom Rule Utilities
+# Shared functions for locating and slicing rules in custom-rules/
+#
+# Usage: source this file in other scripts
+#   source "$SCRIPT_DIR/lib/rule-utils.sh"
+
+# Ensure we're not run directly
+if [[ "${BASH_SOURCE[0]}" == "${0}" ]]; then
+    echo "This script should be sourced, not executed directly."
+    echo "Usage: source ${BASH_SOURCE[0]}"
+    exit 1
+fi
+
+CATALOG_ROOT="${CATALOG_ROOT:-$(cd "$(dirname "${BASH_SOURCE[0]}")/../.." && pwd)}"
+RULES_ROOT="${RULES_ROOT:-$CATALOG_ROOT/custom-rules}"
+
+# =============================================================================
+# Rule Lookup Functions
+# =============================================================================
+
+# Find the rule file that defines a rule id
+# Semgrep prefixes local rule ids with their dotted path, so callers may pass
+# either "go-repo-write-no-symlink-check" or
+# "custom-rules.patterns.traversal.go-repo-write-no-symlink-check"
+# Prints the first matching file path, returns 1 if not found
+find_rule_file() {
+    local rule_id="${1##*.}"
+    local match
+
+    match=$(grep -rlE "^[[:space:]]*- id:[[:space:]]*${rule_id}[[:space:]]*$" "$RULES_ROOT" \
+        --include='*.yaml' --include='*.yml' 2>/dev/null | sort | head -1)
+
+    if [[ -z "$match" ]]; then
+        return 1
+    fi
+
+    echo "$match"
+}
+
+# Print a standalone semgrep config containing only one rule
+# Args: $1 = rule id, $2 = rule file (optional, looked up if omitted)
+# Rule files in custom-rules/ use the two-space "  - id:" layout, so a rule
+# block runs until the next list item at the same indentation
+extract_rule() {
+    local rule_id="${1##*.}"
+    local rule_file="${2:-}"
+
+    if [[ -z "$rule_file" ]]; then
+        rule_file=$(find_rule_file "$rule_id") || return 1
+    fi
+
+    echo "rules:"
+    awk -v id="$rule_id" '
+        match($0, /^[[:space:]]*- id:[[:space:]]*/) {
+            indent = index($0, "-") - 1
+            current = substr($0, RLENGTH + 1)
+            sub(/[[:space:]]+$/, "", current)
+            inside = (current == id)
+            if (inside) { block_indent = indent }
+        }
+        inside {
+            if ($0 ~ /^[[:space:]]*- / && index($0, "-") - 1 == block_indent && $0 !~ /- id:/) {
+                inside = 0
+                next
+            }
+            print
+        }
+    ' "$rule_file"
+}
+
+# Check whether a rule defines an autofix (fix: or fix-regex:)
+# Args: $1 = rule id
+rule_has_fix() {
+    local rule_id="$1"
+    extract_rule "$rule_id" | grep -qE '^[[:space:]]+fix(-regex)?:'
+}
+
+# List all rule ids under custom-rules/ (one per line)
+# Args: $1 = subdirectory (optional, e.g. "patterns")
+list_rule_ids() {
+    local subdir="${1:-}"
+    grep -rhE "^[[:space:]]*- id:[[:space:]]*" "$RULES_ROOT/$subdir" \
+        --include='*.yaml' --include='*.yml' 2>/dev/null | \
+        sed -E 's/^[[:space:]]*- id:[[:space:]]*//; s/[[:space:]]+$//' | sort -u
+}
+
+# =============================================================================
+# Semgrep Config Functions
+# =============================================================================
+
+# Check whether a custom rule pack is enabled
+# RULE_PACKS is an optional space-separated allowlist of pack names
+# (0xdea-semgrep-rules open-semgrep-rules web-vulns custom patterns);
+# empty means every pack is enabled
+# Args: $1 = pack name
+rule_pack_enabled() {
+    local pack="$1"
+    [[ -z "${RULE_PACKS:-}" ]] && return 0
+    [[ " $RULE_PACKS " == *" $pack "* ]]
+}
+
+# Build --config arguments for every populated custom rule pack
+# Args: $1 = custom rules directory (defaults to $RULES_ROOT)
+# Sets: CUSTOM_RULE_ARGS (array), CUSTOM_RULES_INFO (space-separated pack names)
+build_custom_rule_args() {
+    local rules_dir="${1:-$RULES_ROOT}"
+    CUSTOM_RULE_ARGS=()
+    CUSTOM_RULES_INFO=""
+
+    if [[ -d "$rules_dir/0xdea-semgrep-rules/rules" ]] && rule_pack_enabled 0xdea-semgrep-rules; then
+        CUSTOM_RULE_ARGS+=("--config=$rules_dir/0xdea-semgrep-rules/rules")
+        CUSTOM_RULES_INFO+="0xdea-semgrep-rules "
+    fi
+    if [[ -d "$rules_dir/open-semgrep-rules" ]] && rule_pack_enabled open-semgrep-rules; then
+        CUSTOM_RULE_ARGS+=("--config=$rules_dir/open-semgrep-rules")
+        CUSTOM_RULES_INFO+="open-semgrep-rules "
+    fi
+
+    local pack
+    for pack in web-vulns custom patterns; do
+        if [[ -d "$rules_dir/$pack" ]] && [[ -n "$(ls -A "$rules_dir/$pack" 2>/dev/null)" ]] && \
+            rule_pack_enabled "$pack"; then
+            CUSTOM_RULE_ARGS+=("--config=$rules_dir/$pack")
+            CUSTOM_RULES_INFO+="$pack "
+        fi
+    done
+}
+
+# =============================================================================
+# Rule Bundle Functions
+# =============================================================================
+
+# Semgrep parses and compiles every --config on each run, and registry
+# configs (p/default, p/secrets) are resolved from semgrep.dev first. On a
+# small repo that setup dominates the scan. Semgrep has no on-disk format
+# for compiled rules, so the bundle is the resolved ruleset YAML: fetched
+# once per semgrep version, revalidated with the registry's ETag, and
+# passed to semgrep as a local file.
+#
+# Semgrep prefixes local rule ids with their directory, so bundled findings
+# come back as "cache.rule-bundles.<version>.<registry id>".
+# restore_bundle_check_ids strips that prefix again, which keeps
+# EXCLUDE_RULES, suppressions, and the catalog on the registry ids.
+
+RULE_BUNDLE_DIR="${RULE_BUNDLE_DIR:-$CATALOG_ROOT/cache/rule-bundles}"
+SEMGREP_REGISTRY_URL="${SEMGREP_REGISTRY_URL:-https://semgrep.dev/c}"
+
+# Directory holding the bundles for the installed semgrep version
+# Dots are replaced so the version is a single check_id segment
+rule_bundle_dir() {
+    local version
+    version=$(semgrep --version 2>/dev/null | head -1 | tr -c 'A-Za-z0-9\n' '_')
+    echo "$RULE_BUNDLE_DIR/${version:-unknown}"
+}
+
+# Local bundle file for a registry config (p/default -> p-default.yaml)
+# Args: $1 = bundle directory, $2 = registry config
+rule_bundle_path() {
+    echo "$1/$(echo "$2" | tr '/:' '--').yaml"
+}
+
+# Fetch a registry config into the bundle directory, reusing the cached
+# copy while the registry reports it unchanged (HTTP 304 on the saved
+# ETag). A stale copy is used when the registry is unreachable.
+# Args: $1 = bundle directory, $2 = registry config
+# Prints the bundle path; returns 1 when no usable copy exists
+fetch_rule_bundle() {
+    local dir="$1"
+    local config="$2"
+    local bundle tmp status
+    local etag_args=()
+
+    bundle=$(rule_bundle_path "$dir" "$config")
+    mkdir -p "$dir" || return 1
+    if [[ -s "$bundle" && -f "$bundle.etag" ]]; then
+        etag_args=(--etag-compare "$bundle.etag")
+    fi
+
+    tmp=$(mktemp)
+    status=$(curl -sS -L -m 60 -o "$tmp" -w '%{http_code}' \
+        ${etag_args[@]+"${etag_args[@]}"} --etag-save "$tmp.etag" \
+        "$SEMGREP_REGISTRY_URL/$config" 2>/dev/null) || status="000"
+
+    if [[ "$status" == "200" ]] && grep -q '^rules:' "$tmp"; then
+        mv "$tmp" "$bundle"
+        mv "$tmp.etag" "$bundle.etag" 2>/dev/null || rm -f "$bundle.etag"
+    fi
+    rm -f "$tmp" "$tmp.etag"
+
+    [[ -s "$bundle" ]] || return 1
+    echo "$bundle"
+}
+
+# Build --config arguments for registry configs, using local bundles where
+# they can be fetched and the registry config itself otherwise
+# Args: $@ = registry configs
+# Sets: SEMGREP_CONFIG_ARGS (array), RULE_BUNDLE_PREFIX (check_id prefix of
+#       bundled rules, empty when nothing was bundled), RULE_BUNDLES_INFO
+#       (space-separated bundled config names)
+build_bundle_config_args() {
+    local dir config bundle
+    SEMGREP_CONFIG_ARGS=()
+    RULE_BUNDLE_PREFIX=""
+    RULE_BUNDLES_INFO=""
+
+    dir=$(rule_bundle_dir)
+    for config in "$@"; do
+        if bundle=$(fetch_rule_bundle "$dir" "$config"); then
+            SEMGREP_CONFIG_ARGS+=("--config=$bundle")
+            RULE_BUNDLES_INFO+="$config "
+            RULE_BUNDLE_PREFIX=$(semgrep_rule_check_id "$bundle" "")
+        else
+            SEMGREP_CONFIG_ARGS+=("--config=$config")
+        fi
+    done
+}
+
+# Strip the bundle directory prefix from check_ids so bundled findings
+# carry the same ids as a direct registry run
+# Args: $1 = semgrep JSON file, $2 = RULE_BUNDLE_PREFIX
+restore_bundle_check_ids() {
+    local results_file="$1"
+    local prefix="$2"
+    local tmp
+
+    [[ -z "$prefix" ]] && return 0
+    tmp=$(mktemp)
+    jq --arg prefix "$prefix" '
+        .results |= map(.check_id |= ltrimstr($prefix))
+    ' "$results_file" > "$tmp" && mv "$tmp" "$results_file"
+    rm -f "$tmp"
+}
+
+# =============================================================================
+# Rule Template Functions
+# =============================================================================
+
+# Rule families in custom-rules/templates/ take per-org settings through
+# __NAME__ placeholders, so they are rendered before a scan instead of being
+# loaded directly (build_custom_rule_args never loads that directory)
+TEMPLATES_DIR_NAME="templates"
+
+# Regex alternation matching a list of field names; snake_case names also
+# match their camelCase spelling (tenant_id -> tenantId)
+# Args: $@ = field names
+field_name_regex() {
+    local field
+    for field in "$@"; do
+        [[ -z "$field" ]] && continue
+        echo "$field"
+        [[ "$field" == *_* ]] && awk -F_ '{
+            out = $1
+            for (i = 2; i <= NF; i++) out = out toupper(substr($i, 1, 1)) substr($i, 2)
+            print out
+        }' <<< "$field"
+    done | sed 's/[][\.^$*+?(){}|/]/\\&/g' | awk '!seen[$0]++' | paste -sd '|' -
+}
+
+# Tenant field names for an org: the comma-separated override if given,
+# otherwise "fields" from catalog/tracked/<org>/tenancy.json
+# Args: $1 = org, $2 = comma-separated override (optional)
+# Prints one field per line; nothing for orgs without a tenant concept
+tenant_fields() {
+    local org="$1"
+    local override="${2:-}"
+    local config
+
+    if [[ -n "$override" ]]; then
+        tr ',' '\n' <<< "$override" | sed 's/^[[:space:]]*//; s/[[:space:]]*$//' | grep -v '^$' || true
+        return 0
+    fi
+    config="$CATALOG_ROOT/catalog/tracked/$org/tenancy.json"
+    [[ -f "$config" ]] || return 0
+    jq -r '(.fields // [])[] | strings' "$config"
+}
+
+# Render a rule template, replacing every __KEY__ with its value
+# Values are inserted literally (no sed/awk escaping rules apply)
+# Args: $1 = template file, $2 = output file, rest = KEY=value pairs
+render_rule_template() {
+    local template="$1"
+    local output="$2"
+    shift 2
+    local pair keys=() values=()
+
+    for pair in "$@"; do
+        keys+=("__${pair%%=*}__")
+        values+=("${pair#*=}")
+    done
+
+    # Passed through the environment: awk -v would interpret backslashes
+    TEMPLATE_KEYS="$(printf '%s\n' ${keys[@]+"${keys[@]}"})" \
+    TEMPLATE_VALUES="$(printf '%s\n' ${values[@]+"${values[@]}"})" awk '
+        BEGIN { n = split(ENVIRON["TEMPLATE_KEYS"], k, "\n"); split(ENVIRON["TEMPLATE_VALUES"], v, "\n") }
+        {
+            line = $0
+            for (i = 1; i <= n; i++) {
+                if (k[i] == "") continue
+                out = ""
+                while ((pos = index(line, k[i])) > 0) {
+                    out = out substr(line, 1, pos - 1) v[i]
+                    line = substr(line, pos + length(k[i]))
+                }
+                line = out line
+            }
+            print line
+        }
+    ' "$template" > "$output"
+}
+
+# Render the rule templates that apply to an org into a directory.
+# tenant-isolation.yaml is rendered when the org has tenant fields.
+# Args: $1 = custom rules directory, $2 = output directory, $3 = org,
+#       $4 = comma-separated tenant field override (optional)
+# Sets: TEMPLATE_RULE_ARGS (array), TEMPLATE_RULES_INFO (space-separated names)
+build_template_rule_args() {
+    local rules_dir="${1:-$RULES_ROOT}"
+    local out_dir="$2"
+    local org="$3"
+    local override="${4:-}"
+    local templates="$rules_dir/$TEMPLATES_DIR_NAME"
+    local fields=()
+    local field
+    TEMPLATE_RULE_ARGS=()
+    TEMPLATE_RULES_INFO=""
+
+    [[ -d "$templates" ]] || return 0
+    mkdir -p "$out_dir"
+
+    while IFS= read -r field; do
+        [[ -n "$field" ]] && fields+=("$field")
+    done < <(tenant_fields "$org" "$override")
+
+    if [[ ${#fields[@]} -gt 0 && -f "$templates/tenant-isolation.yaml" ]]; then
+        render_rule_template "$templates/tenant-isolation.yaml" "$out_dir/tenant-isolation.yaml" \
+            "TENANT_FIELDS=$(field_name_regex "${fields[@]}")" \
+            "TENANT_FIELD_LIST=$(printf '%s\n' "${fields[@]}" | paste -sd ',' - | sed 's/,/, /g')"
+        TEMPLATE_RULE_ARGS+=("--config=$out_dir/tenant-isolation.yaml")
+        TEMPLATE_RULES_INFO+="tenant-isolation "
+    fi
+}
+
+# =============================================================================
+# Rule Metadata Functions
+# =============================================================================
+
+# Print a scalar field from a rule (first occurrence, quotes stripped)
+# Works for top-level keys (severity) and metadata keys (confidence, cwe)
+# Args: $1 = rule id, $2 = field name
+rule_field() {
+    local rule_id="$1"
+    local field="$2"
+    extract_rule "$rule_id" | awk -v key="$field" '
+        $0 ~ "^[[:space:]]+" key ":[[:space:]]*[^[:space:]>|]" {
+            sub("^[[:space:]]+" key ":[[:space:]]*", "")
+            gsub(/^["\047]|["\047][[:space:]]*$/, "")
+            print
+            exit
+        }
+    '
+}
+
+# Print a rule's message as a single line (handles >- and | block scalars)
+# Args: $1 = rule id
+rule_message() {
+    local rule_id="$1"
+    extract_rule "$rule_id" | awk '
+        /^[[:space:]]+message:/ {
+            line = $0
+            sub(/^[[:space:]]+message:[[:space:]]*/, "", line)
+            if (line !~ /^[>|]/) { gsub(/^["\047]|["\047]$/, "", line); print line; exit }
+            indent = match($0, /[^[:space:]]/)
+            inside = 1
+            next
+        }
+        inside {
+            if ($0 ~ /^[[:space:]]*$/) next
+            if (match($0, /[^[:space:]]/) <= indent) exit
+            sub(/^[[:space:]]+/, "")
+            out = out (out == "" ? "" : " ") $0
+        }
+        END { if (out != "") print out }
+    '
+}
+
+# Print a rule's metadata.references URLs (one per line)
+# Args: $1 = rule id
+rule_references() {
+    local rule_id="$1"
+    extract_rule "$rule_id" | awk '
+        /^[[:space:]]+references:/ { indent = match($0, /[^[:space:]]/); inside = 1; next }
+        inside {
+            if (match($0, /[^[:space:]]/) <= indent && $0 !~ /^[[:space:]]*- /) exit
+            if ($0 ~ /^[[:space:]]*- /) { sub(/^[[:space:]]*- /, ""); print }
+        }
+    '
+}
+
+# =============================================================================
+# Language Routing Functions
+# =============================================================================
+
+# Language-specific subdirectories of open-semgrep-rules
+OPEN_RULES_LANGUAGES="csharp go java javascript python scala"
+
+# Language registry: semgrep language name followed by its file extensions
+# Adding a language only needs a line here plus a rule pack; semgrep's
+# tree-sitter parsers do the matching. Languages semgrep cannot parse can
+# still be routed here and matched with "languages: [generic]" rules that
+# restrict paths.include to the same extensions. See docs/language-registry.md
+# for why this, and not a matching engine of our own.
+LANGUAGE_EXTENSIONS="
+go          go
+python      py pyi pyw
+javascript  js jsx mjs cjs ts tsx vue
+java        java jsp
+kotlin      kt kts
+scala       scala sc
+csharp      cs cshtml razor
+c           c h
+cpp         cc cpp cxx hpp hh hxx
+ruby        rb erb rake
+php         php phtml
+rust        rs
+"
+
+# Detect the languages in each top-level directory of a repository from
+# file extensions, for routing packs per directory in monorepos
+# Uses git ls-files when available (respects .gitignore), find otherwise
+# Args: $1 = repo directory
+# Prints "<language>\t<top-level directory>" lines, "." for files at the
+# repo root
+detect_repo_language_roots() {
+    local repo="$1"
+
+    {
+        git -C "$repo" ls-files 2>/dev/null || \
+            (cd "$repo" && find . -type f -not -path './.git/*' 2>/dev/null | sed 's#^\./##')
+    } | awk '
+        NR == FNR { for (i = 2; i <= NF; i++) lang[$i] = $1; next }
+        match($0, /\.[A-Za-z0-9+]+$/) {
+            ext = tolower(substr($0, RSTART + 1))
+            if (!(ext in lang)) next
+            dir = index($0, "/") ? substr($0, 1, index($0, "/") - 1) : "."
+            print lang[ext] "\t" dir
+        }
+    ' <(printf '%s\n' "$LANGUAGE_EXTENSIONS") - | LC_ALL=C sort -u
+}
+
+# Detect the languages present in a repository from file extensions
+# Args: $1 = repo directory
+# Prints semgrep-style language names, one per line
+detect_repo_languages() {
+    detect_repo_language_roots "$1" | cut -f1 | sort -u
+}
+
+# Build --config arguments routed to the languages present in a repo
+# Language-specific packs are only loaded when their language is present;
+# multi-language packs (web-vulns, custom, patterns) are always loaded
+# Args: $1 = custom rules directory, $2 = newline-separated language list
+# Sets: CUSTOM_RULE_ARGS (array), CUSTOM_RULES_INFO (space-separated pack names)
+build_routed_rule_args() {
+    local rules_dir="${1:-$RULES_ROOT}"
+    local languages="$2"
+    local lang pack
+    CUSTOM_RULE_ARGS=()
+    CUSTOM_RULES_INFO=""
+
+    if [[ -d "$rules_dir/0xdea-semgrep-rules/rules" ]] && rule_pack_enabled 0xdea-semgrep-rules && \
+        echo "$languages" | grep -qxE 'c|cpp'; then
+        CUSTOM_RULE_ARGS+=("--config=$rules_dir/0xdea-semgrep-rules/rules")
+        CUSTOM_RULES_INFO+="0xdea-semgrep-rules "
+    fi
+
+    for lang in $OPEN_RULES_LANGUAGES; do
+        rule_pack_enabled open-semgrep-rules || break
+        if [[ -d "$rules_dir/open-semgrep-rules/$lang" ]] && echo "$languages" | grep -qx "$lang"; then
+            CUSTOM_RULE_ARGS+=("--config=$rules_dir/open-semgrep-rules/$lang")
+            CUSTOM_RULES_INFO+="open-semgrep-rules/$lang "
+        fi
+    done
+
+    for pack in web-vulns custom patterns; do
+        if [[ -d "$rules_dir/$pack" ]] && [[ -n "$(ls -A "$rules_dir/$pack" 2>/dev/null)" ]] && \
+            rule_pack_enabled "$pack"; then
+            CUSTOM_RULE_ARGS+=("--config=$rules_dir/$pack")
+            CUSTOM_RULES_INFO+="$pack "
+        fi
+    done
+}
+
+# Scope the language-specific packs build_routed_rule_args chose to the
+# top-level directories that contain their language, so a Go service next
+# to a JS frontend doesn't run the Go packs over the frontend. A pack stays
+# in CUSTOM_RULE_ARGS (the whole repo) when its language has files at the
+# repo root or in every directory that has code
+# Args: $1 = "<language>\t<directory>" lines from detect_repo_language_roots
+# Sets: CUSTOM_RULE_ARGS (without the scoped packs), ROUTED_RULE_ARGS (array,
+#       the scoped --config arguments), ROUTED_RULE_PASSES (one line per
+#       extra semgrep pass: its tab-separated --config and --include
+#       arguments; packs scoped to the same directories share a pass)
+scope_routed_rule_args() {
+    local roots="$1"
+    local arg langs dirs all_dirs scoped=""
+    local keep=()
+    ROUTED_RULE_ARGS=()
+    ROUTED_RULE_PASSES=""
+
+    all_dirs=$(cut -f2 <<< "$roots" | sed '/^$/d' | LC_ALL=C sort -u)
+    for arg in ${CUSTOM_RULE_ARGS[@]+"${CUSTOM_RULE_ARGS[@]}"}; do
+        case "$arg" in
+            */0xdea-semgrep-rules/rules) langs="c cpp" ;;
+            */open-semgrep-rules/*) langs="${arg##*/}" ;;
+            *) keep+=("$arg"); continue ;;
+        esac
+        dirs=$(awk -F'\t' -v langs=" $langs " 'index(langs, " " $1 " ") { print $2 }' <<< "$roots" | LC_ALL=C sort -u)
+        if [[ -z "$dirs" || "$dirs" == "$all_dirs" ]] || grep -qxF . <<< "$dirs"; then
+            keep+=("$arg")
+            continue
+        fi
+        # Directory names can't contain "/", so it joins them into a key
+        scoped+="$(paste -sd/ - <<< "$dirs")"$'\t'"$arg"$'\n'
+        ROUTED_RULE_ARGS+=("$arg")
+    done
+    CUSTOM_RULE_ARGS=(${keep[@]+"${keep[@]}"})
+
+    ROUTED_RULE_PASSES=$(printf '%s' "$scoped" | LC_ALL=C sort | awk -F'\t' '
+        $1 != key {
+            if (key != "") print configs includes
+            key = $1
+            configs = ""
+            includes = ""
+            n = split(key, dirs, "/")
+            for (i = 1; i <= n; i++) includes = includes "\t--include=/" dirs[i]
+        }
+        { configs = configs (configs == "" ? "" : "\t") $2 }
+        END { if (key != "") print configs includes }
+    ')
+}
+
+# =============================================================================
+# Applicability Functions
+# =============================================================================
+
+# Manifests searched by the "imports" predicate
+APPLICABILITY_MANIFESTS="go.mod package.json requirements*.txt pyproject.toml Pipfile setup.py setup.cfg
+Gemfile Gemfile.lock *.gemspec pom.xml build.gradle build.gradle.kts composer.json Cargo.toml *.csproj"
+
+# Applicability predicates declared by custom rules, one
+# "<rule file>\t<rule id>\t<kind>\t<values>" line per predicate
+# Rules declare them under metadata.applies-when, as flow or block lists:
+#   applies-when:
+#     imports: [github.com/gin-gonic/gin]   # a dependency manifest names one
+#     files: [Dockerfile, "**/*.tf"]         # a tracked file matches one
+#     tags: [public]                         # the repo has one of these tags
+# Every listed kind must hold; within a kind, any value is enough
+# Args: $1 = rules directory (defaults to $RULES_ROOT)
+rule_applicability_index() {
+    local rules_dir="${1:-$RULES_ROOT}"
+    local files
+
+    files=$(grep -rlE '^[[:space:]]*applies-when:[[:space:]]*$' "$rules_dir" \
+        --include='*.yaml' --include='*.yml' 2>/dev/null | sort)
+    [[ -z "$files" ]] && return 0
+
+    # shellcheck disable=SC2086
+    awk '
+        function indent_of(line) { return match(line, /[^ ]/) - 1 }
+        # Values for one rule and kind are joined, in first-seen order
+        function emit(key, val) {
+            gsub(/[][,"\047]/, " ", val)
+            gsub(/[[:space:]]+/, " ", val)
+            sub(/^ /, "", val)
+            sub(/ $/, "", val)
+            if (id == "" || key == "" || val == "") return
+            k = FILENAME "\t" id "\t" key
+            if (k in values) {
+                values[k] = values[k] " " val
+            } else {
+                order[++count] = k
+                values[k] = val
+            }
+        }
+        END { for (i = 1; i <= count; i++) print order[i] "\t" values[order[i]] }
+        FNR == 1 { id = ""; inside = 0 }
+        match($0, /^[[:space:]]*- id:[[:space:]]*/) {
+            id = substr($0, RLENGTH + 1)
+            sub(/[[:space:]]+$/, "", id)
+            inside = 0
+            next
+        }
+        /^[[:space:]]*applies-when:[[:space:]]*$/ {
+            inside = 1
+            block_indent = indent_of($0)
+            key = ""
+            next
+        }
+        inside {
+            if ($0 ~ /^[[:space:]]*(#.*)?$/) next
+            if (indent_of($0) <= block_indent) { inside = 0; next }
+            line = $0
+            sub(/^[[:space:]]+/, "", line)
+            sub(/[[:space:]]+#.*$/, "", line)
+            if (line ~ /^- /) { emit(key, substr(line, 3)); next }
+            key = line
+            sub(/:.*/, "", key)
+            val = line
+            sub(/^[^:]*:[[:space:]]*/, "", val)
+            emit(key, val)
+        }
+    ' $files
+}
+
+# Check one applicability predicate against a repo
+# Unknown kinds pass, so a typo never silently disables a rule everywhere
+# Args: $1 = repo directory, $2 = kind (imports, files, tags), rest = values
+repo_satisfies_predicate() {
+    local repo="$1"
+    local kind="$2"
+    shift 2
+    local value manifest
+    local args=() manifests=() pathspecs=()
+
+    case "$kind" in
+        imports)
+            for value in "$@"; do
+                args+=(-e "$value")
+            done
+            read -r -d '' -a manifests <<< "$APPLICABILITY_MANIFESTS" || true
+            for manifest in "${manifests[@]}"; do
+                pathspecs+=(":(glob)**/$manifest")
+            done
+            git -C "$repo" grep -q -F "${args[@]}" -- "${pathspecs[@]}" 2>/dev/null
+            ;;
+        files)
+            for value in "$@"; do
+                [[ "$value" != */* ]] && value="**/$value"
+                if [[ -n "$(git -C "$repo" ls-files -- ":(glob)$value" 2>/dev/null | head -1)" ]]; then
+                    return 0
+                fi
+            done
+            return 1
+            ;;
+        tags)
+            local tags
+            tags=$(get_repo_tags "$(dirname "$repo")" "$(basename "$repo")")
+            for value in "$@"; do
+                grep -qxF -e "$value" <<< "$tags" && return 0
+            done
+            return 1
+            ;;
+        *)
+            return 0
+            ;;
+    esac
+}
+
+# Semgrep's check_id for a rule in a local config: the rule file's directory
+# (relative to the working directory) dotted, then the rule id
+# Args: $1 = rule file, $2 = rule id
+semgrep_rule_check_id() {
+    local dir
+    dir=$(cd "$(dirname "$1")" && pwd)
+    dir="${dir#"$(pwd)"/}"
+    dir="${dir#/}"
+    echo "$(echo "$dir" | tr / .).$2"
+}
+
+# Rules whose applicability predicates fail for a repo
+# Args: $1 = repo directory, $2 = output of rule_applicability_index
+# Sets: APPLICABILITY_EXCLUDE_ARGS (array of --exclude-rule=<check_id>),
+#       INAPPLICABLE_RULES (newline-separated "<rule id>\t<kind>" of the
+#       first failing predicate per rule)
+build_applicability_excludes() {
+    local repo="$1"
+    local index="$2"
+    local file id kind values
+    local failed=""
+    local value_list=()
+    APPLICABILITY_EXCLUDE_ARGS=()
+    INAPPLICABLE_RULES=""
+
+    [[ -z "$index" ]] && return 0
+
+    while IFS=$'\t' read -r file id kind values; do
+        [[ -z "$id" ]] && continue
+        grep -qxF -e "$id" <<< "$failed" && continue
+        read -r -a value_list <<< "$values"
+        if ! repo_satisfies_predicate "$repo" "$kind" "${value_list[@]}"; then
+            failed+="$id"$'\n'
+            INAPPLICABLE_RULES+="$id"$'\t'"$kind"$'\n'
+            APPLICABILITY_EXCLUDE_ARGS+=("--exclude-rule=$(semgrep_rule_check_id "$file" "$id")")
+        fi
+    done <<< "$index"
+}
+
+# Drop findings from rules excluded as inapplicable (matched on the last
+# check_id segment, in case semgrep prefixed the id differently)
+# Args: $1 = semgrep JSON file, $2 = INAPPLICABLE_RULES
+filter_inapplicable_findings() {
+    local results_file="$1"
+    local rules="$2"
+    local tmp
+
+    [[ -z "$rules" ]] && return 0
+    tmp=$(mktemp)
+    jq --arg rules "$rules" '
+        ($rules | split("\n") | map(split("\t")[0] | select(length > 0))) as $skip
+        | .results |= map(select((.check_id | split(".") | last) as $id | $skip | index($id) | not))
+    ' "$results_file" > "$tmp" && mv "$tmp" "$results_file"
+    rm -f "$tmp"
+}
+
+# =============================================================================
+# Rule Lifecycle Functions
+# =============================================================================
+
+# Deprecated custom rules, one
+# "<rule file>\t<rule id>\t<replacement id>\t<removal version>" line each
+# A rule is deprecated by naming the rule that replaces it:
+#   metadata:
+#     deprecated-by: go-sql-injection-taint   # replacement rule id
+#     removal-version: v3.0.0                 # release that deletes it (optional)
+# Deprecated rules keep running until they are removed, so their findings
+# don't disappear before the replacement's findings have been triaged
+# Args: $1 = rules directory (defaults to $RULES_ROOT)
+rule_lifecycle_index() {
+    local rules_dir="${1:-$RULES_ROOT}"
+    local files
+
+    files=$(grep -rlE '^[[:space:]]+deprecated-by:' "$rules_dir" \
+        --include='*.yaml' --include='*.yml' 2>/dev/null | sort)
+    [[ -z "$files" ]] && return 0
+
+    # shellcheck disable=SC2086
+    awk '
+        function flush() {
+            if (id != "" && by != "") print file "\t" id "\t" by "\t" removal
+            id = ""; by = ""; removal = ""
+        }
+        function value(line) {
+            sub(/^[^:]*:[[:space:]]*/, "", line)
+            sub(/[[:space:]]+#.*$/, "", line)
+            gsub(/^["\047]|["\047][[:space:]]*$/, "", line)
+            return line
+        }
+        FNR == 1 { flush(); file = FILENAME }
+        match($0, /^[[:space:]]*- id:[[:space:]]*/) {
+            flush()
+            id = substr($0, RLENGTH + 1)
+            sub(/[[:space:]]+$/, "", id)
+            next
+        }
+        /^[[:space:]]+deprecated-by:/ { by = value($0) }
+        /^[[:space:]]+removal-version:/ { removal = value($0) }
+        END { flush() }
+    ' $files
+}
+
+# The rule that finally replaces a deprecated rule, following chains
+# (a deprecated by b, b by c gives c); prints nothing for a current rule
+# Args: $1 = rule id (or a check_id), $2 = output of rule_lifecycle_index
+# Returns 1 when the chain loops
+rule_replacement() {
+    local id="${1##*.}"
+    local index="$2"
+    local start="$id"
+    local seen=" $id "
+    local next
+
+    while next=$(awk -F'\t' -v id="$id" '$2 == id { print $3; exit }' <<< "$index") && [[ -n "$next" ]]; do
+        [[ "$seen" == *" $next "* ]] && return 1
+        seen+="$next "
+        id="$next"
+    done
+    [[ "$id" != "$start" ]] && echo "$id"
+    return 0
+}
+
+# Deprecated rules mapped to their final replacements, as JSON
+# {<rule id>: <replacement id>} (rules in a loop are left out)
+# Args: $1 = output of rule_lifecycle_index
+rule_replacements_json() {
+    local index="$1"
+    local id replacement
+
+    [[ -z "$index" ]] && { echo "{}"; return 0; }
+    cut -f2 <<< "$index" | sort -u | while IFS= read -r id; do
+        replacement=$(rule_replacement "$id" "$index") || continue
+        [[ -n "$replacement" ]] && printf '%s\t%s\n' "$id" "$replacement"
+    done | jq -R -s -c 'split("\n") | map(select(. != "") | split("\t") | {key: .[0], value: .[1]}) | from_entries'
+}
+
+# Problems with lifecycle metadata: a replacement that isn't defined, a
+# loop of replacements, a removal version that isn't vX.Y.Z, or a rule
+# still shipped in the release it was due to be removed in
+# Args: $1 = output of rule_lifecycle_index, $2 = current release tag
+#       (optional; the removal check is skipped without one)
+# Prints one problem per line, returns 1 when there are any
+rule_lifecycle_problems() {
+    local index="$1"
+    local release="${2:-}"
+    local ids file id by removal problems=""
+
+    [[ -z "$index" ]] && return 0
+    ids=$(list_rule_ids)
+    while IFS=$'\t' read -r file id by removal; do
+        [[ -z "$id" ]] && continue
+        file="${file#"$CATALOG_ROOT"/}"
+        if ! grep -qxF -e "$by" <<< "$ids"; then
+            problems+="$file: $id is deprecated by $by, which no rule defines"$'\n'
+        fi
+        if ! rule_replacement "$id" "$index" > /dev/null; then
+            problems+="$file: $id is in a loop of deprecated-by replacements"$'\n'
+        fi
+        if [[ -n "$removal" && ! "$removal" =~ ^v[0-9]+\.[0-9]+\.[0-9]+$ ]]; then
+            problems+="$file: $id has removal-version '$removal' (use vX.Y.Z)"$'\n'
+        elif [[ -n "$removal" && -n "$release" ]] && \
+            [[ "$(printf '%s\n%s\n' "$removal" "${release%%-*}" | sort -V | head -1)" == "$removal" ]]; then
+            problems+="$file: $id was due for removal in $removal (current release: $release)"$'\n'
+        fi
+    done <<< "$index"
+    [[ -z "$problems" ]] && return 0
+    printf '%s' "$problems"
+    return 1
+}
+
+# Findings from deprecated rules in a semgrep JSON file, one
+# "<rule id>\t<replacement id>\t<removal version>\t<count>" line per rule
+# Args: $1 = semgrep JSON file, $2 = output of rule_lifecycle_index
+deprecated_rule_findings() {
+    local results_file="$1"
+    local index="$2"
+
+    [[ -z "$index" ]] && return 0
+    jq -r --arg index "$index" '
+        ($index | split("\n") | map(select(. != "") | split("\t") | {key: .[1], value: .}) | from_entries) as $deprecated
+        | [.results[]? | .check_id | split(".") | last | select($deprecated[.])]
+        | group_by(.)[] | $deprecated[.[0]] as $d
+        | [.[0], $d[2], ($d[3] // ""), length] | @tsv
+    ' "$results_file"
+}
+
+# Canary custom rules, one "<rule file>\t<rule id>\t<since>" line each
+# A new rule can be rolled out as a canary first:
+#   metadata:
+#     canary: true
+#     canary-since: 2026-10-01     # when it started (optional)
+# Canary rules run with the others, but their findings go to a shadow
+# channel (split_canary_findings) instead of the results, so they don't
+# fail builds, move baselines, or reach digests until `rules.sh promote`
+# Args: $1 = rules directory (defaults to $RULES_ROOT)
+rule_canary_index() {
+    local rules_dir="${1:-$RULES_ROOT}"
+    local files
+
+    files=$(grep -rlE '^[[:space:]]+canary:[[:space:]]*"?true"?' "$rules_dir" \
+        --include='*.yaml' --include='*.yml' 2>/dev/null | sort)
+    [[ -z "$files" ]] && return 0
+
+    # shellcheck disable=SC2086
+    awk '
+        function flush() {
+            if (id != "" && canary == "true") print file "\t" id "\t" since
+            id = ""; canary = ""; since = ""
+        }
+        function value(line) {
+            sub(/^[^:]*:[[:space:]]*/, "", line)
+            sub(/[[:space:]]+#.*$/, "", line)
+            gsub(/^["\047]|["\047][[:space:]]*$/, "", line)
+            return line
+        }
+        FNR == 1 { flush(); file = FILENAME }
+        match($0, /^[[:space:]]*- id:[[:space:]]*/) {
+            flush()
+            id = substr($0, RLENGTH + 1)
+            sub(/[[:space:]]+$/, "", id)
+            next
+        }
+        /^[[:space:]]+canary:/ { canary = value($0) }
+        /^[[:space:]]+canary-since:/ { since = value($0) }
+        END { flush() }
+    ' $files
+}
+
+# Move canary rules' findings out of a semgrep JSON file into a shadow file
+# (matched on the last check_id segment)
+# Args: $1 = semgrep JSON file (updated in place), $2 = output of
+#       rule_canary_index, $3 = shadow file ({"results": [...]})
+# Prints the number of findings moved
+split_canary_findings() {
+    local results_file="$1"
+    local index="$2"
+    local shadow_file="$3"
+    local tmp
+
+    if [[ -z "$index" ]]; then
+        echo 0
+        return 0
+    fi
+    tmp=$(mktemp)
+    jq --arg index "$index" '
+        ($index | split("\n") | map(select(. != "") | split("\t")[1])) as $canary
+        | {results: [.results[]? | select((.check_id | split(".") | last) as $id | $canary | index([$id]))]}
+    ' "$results_file" > "$shadow_file"
+    jq --arg index "$index" '
+        ($index | split("\n") | map(select(. != "") | split("\t")[1])) as $canary
+        | .results |= map(select((.check_id | split(".") | last) as $id | $canary | index([$id]) | not))
+    ' "$results_file" > "$tmp" && mv "$tmp" "$results_file"
+    rm -f "$tmp"
+    jq '.results | length' "$shadow_file"
+}
+
+# Remove the canary metadata from a rule, so its findings are reported
+# Args: $1 = rule id, $2 = output of rule_canary_index
+# Prints the rule file; returns 1 if the rule isn't a canary
+promote_canary_rule() {
+    local id="${1##*.}"
+    local index="$2"
+    local file tmp
+
+    file=$(awk -F'\t' -v id="$id" '$2 == id { print $1; exit }' <<< "$index")
+    [[ -n "$file" ]] || return 1
+    tmp=$(mktemp)
+    # Only the canary lines inside this rule's block
+    awk -v id="$id" '
+        match($0, /^[[:space:]]*- id:[[:space:]]*/) {
+            current = substr($0, RLENGTH + 1)
+            sub(/[[:space:]]+$/, "", current)
+        }
+        current == id && /^[[:space:]]+canary(-since)?:/ { next }
+        { print }
+    ' "$file" > "$tmp" && cat "$tmp" > "$file"
+    rm -f "$tmp"
+    echo "$file"
+}
+
+# =============================================================================
+# Rule Selection Functions
+# =============================================================================
+# A run can slice the custom rules without its own config file
+# (scan-semgrep.sh --rules, or RULE_SELECTION):
+#   category=traversal,ssrf    rules in either category
+#   pack=web-vulns             rules from these packs
+#   cwe=22,918                 rules mapped to these CWEs
+#   id=go-ssrf-taint           these rules
+#   confidence=high,medium     rules rated at these confidences
+# Selectors are joined with ';' and all of them must hold. A rule's
+# categories are the directories it sits in (patterns/traversal/) and its
+# category, subcategory, vulnerability_class, and pattern_class metadata; a
+# value matches a whole category or one of its words, so traversal matches
+# path-traversal. Registry rulesets count as pack "registry" with their name
+# as category (category=secrets keeps p/secrets).
+#
+# A --budget scan runs only the custom rules marked
+#   metadata:
+#     confidence: HIGH
+# so a pre-commit hook or recon pass spends its time on the findings most
+# worth reading. Registry rulesets stay: the profile already tunes them.
+
+RULE_SELECTION_KEYS="category pack cwe id confidence"
+
+# Custom rules with the fields selections match on, one
+# "<check_id>\t<id>\t<pack>\t<confidence>\t<categories>\t<cwes>" line each
+# (categories and CWE numbers lowercased, space-separated). Check ids are
+# built the way semgrep_rule_check_id does, in one pass.
+# Args: rule directories
+rule_selection_index() {
+    local dir
+    local dirs=()
+
+    for dir in "$@"; do
+        [[ -d "$dir" ]] && dirs+=("$(cd "$dir" && pwd)")
+    done
+    [[ ${#dirs[@]} -eq 0 ]] && return 0
+
+    find "${dirs[@]}" -type f \( -name '*.yaml' -o -name '*.yml' \) 2>/dev/null | sort | tr '\n' '\0' | \
+        xargs -0 awk -v cwd="$(pwd)" -v roots="$(printf '%s\n' "${dirs[@]}")" '
+        function unique(list,   n, words, i, seen, out) {
+            n = split(list, words, " ")
+            out = ""
+            for (i = 1; i <= n; i++) if (!seen[words[i]]++) out = out " " words[i]
+            return substr(out, 2)
+        }
+        function flush() {
+            if (id != "") print prefix id "\t" id "\t" pack "\t" toupper(confidence) "\t" unique(cats tags) "\t" unique(cwes)
+            id = ""; confidence = ""; tags = ""; cwes = ""; listkey = ""
+        }
+        function clean(v) {
+            sub(/[[:space:]]+#.*$/, "", v)
+            gsub(/[\[\]"\047]/, "", v)
+            return v
+        }
+        function add(key, v,   n, parts, i) {
+            v = tolower(clean(v))
+            if (key == "cwe") {
+                while (match(v, /cwe-[0-9]+/)) {
+                    cwes = cwes " " substr(v, RSTART + 4, RLENGTH - 4)
+                    v = substr(v, RSTART + RLENGTH)
+                }
+                return
+            }
+            n = split(v, parts, /[,\/]/)
+            for (i = 1; i <= n; i++) {
+                gsub(/^[[:space:]]+|[[:space:]]+$/, "", parts[i])
+                if (parts[i] != "") tags = tags " " parts[i]
+            }
+        }
+        BEGIN { nroots = split(roots, root, "\n") }
+        FNR == 1 {
+            flush()
+            rel = FILENAME
+            base = FILENAME
+            for (i = 1; i <= nroots; i++) {
+                if (root[i] != "" && index(FILENAME, root[i] "/") == 1) {
+                    rel = substr(FILENAME, length(root[i]) + 2)
+                    base = root[i]
+                }
+            }
+            n = split(rel, seg, "/")
+            sub(/.*\//, "", base)
+            pack = n > 1 ? seg[1] : base
+            cats = ""
+            for (i = 1; i < n; i++) cats = cats " " tolower(seg[i])
+            prefix = FILENAME
+            sub(/\/[^\/]*$/, "", prefix)
+            if (index(prefix, cwd "/") == 1) prefix = substr(prefix, length(cwd) + 2)
+            sub(/^\//, "", prefix)
+            gsub(/\//, ".", prefix)
+            prefix = prefix "."
+        }
+        match($0, /^[[:space:]]*- id:[[:space:]]*/) {
+            flush()
+            id = substr($0, RLENGTH + 1)
+            sub(/[[:space:]]+$/, "", id)
+            gsub(/^["\047]|["\047]$/, "", id)
+            next
+        }
+        /^[[:space:]]+(category|subcategory|vulnerability_class|pattern_class|cwe|confidence):/ {
+            key = $0
+            sub(/^[[:space:]]+/, "", key)
+            sub(/:.*/, "", key)
+            value = $0
+            sub(/^[^:]*:[[:space:]]*/, "", value)
+            value = clean(value)
+            match($0, /[^[:space:]]/)
+            keyindent = RSTART
+            listkey = ""
+            if (key == "confidence") {
+                gsub(/[[:space:]]/, "", value)
+                confidence = value
+            } else if (value ~ /^[[:space:]]*$/) {
+                listkey = key
+            } else {
+                add(key, value)
+            }
+            next
+        }
+        listkey != "" && match($0, /^[[:space:]]*- /) && RLENGTH > keyindent {
+            add(listkey, substr($0, RLENGTH + 1))
+            next
+        }
+        { listkey = "" }
+        END { flush() }
+    '
+}
+
+# Check a rule selection and print the problem when it doesn't parse
+# Args: $1 = selection (e.g. "category=traversal,secrets;confidence=high")
+validate_rule_selection() {
+    local selection="$1"
+    local selector key
+
+    IFS=';' read -r -a selectors <<< "$selection"
+    for selector in ${selectors[@]+"${selectors[@]}"}; do
+        key="${selector%%=*}"
+        if [[ "$selector" != *=?* || ! " $RULE_SELECTION_KEYS " == *" $key "* ]]; then
+            echo "Error: Invalid rule selector '$selector' (use <key>=<value>[,<value>] with keys: $RULE_SELECTION_KEYS)" >&2
+            return 1
+        fi
+    done
+}
+
+# Lines of rule_selection_index that a selection matches
+# Args: $1 = selection, $2 = "exclude" to print the lines it doesn't match
+# Reads index lines on stdin
+rule_selection_filter() {
+    awk -F'\t' -v selection="$1" -v invert="${2:-}" '
+        function matches(key, values,   n, v, i, j, k, words, parts, m) {
+            n = split(tolower(values), v, ",")
+            for (i = 1; i <= n; i++) {
+                if (key == "id" && tolower($2) == v[i]) return 1
+                if (key == "pack" && tolower($3) == v[i]) return 1
+                if (key == "confidence" && tolower($4) == v[i]) return 1
+                if (key == "cwe") {
+                    sub(/^cwe-/, "", v[i])
+                    m = split($6, words, " ")
+                    for (j = 1; j <= m; j++) if (words[j] == v[i]) return 1
+                }
+                if (key == "category") {
+                    m = split($5, words, " ")
+                    for (j = 1; j <= m; j++) {
+                        if (words[j] == v[i]) return 1
+                        split(words[j], parts, /[-_]/)
+                        for (k in parts) if (parts[k] == v[i]) return 1
+                    }
+                }
+            }
+            return 0
+        }
+        BEGIN { count = split(selection, selectors, ";") }
+        NF > 1 {
+            keep = 1
+            for (s = 1; s <= count; s++) {
+                if (selectors[s] == "") continue
+                eq = index(selectors[s], "=")
+                if (!matches(substr(selectors[s], 1, eq - 1), substr(selectors[s], eq + 1))) keep = 0
+            }
+            if (keep != (invert == "exclude")) print
+        }
+    '
+}
+
+# Custom rules a selection leaves out
+# Args: $1 = selection, $2... = rule directories
+# Sets: SELECTION_EXCLUDE_ARGS (array of --exclude-rule=<check_id>),
+#       SELECTED_RULE_COUNT (custom rules still in)
+build_rule_selection_excludes() {
+    local selection="$1"
+    shift
+    local index check_id rest
+    SELECTION_EXCLUDE_ARGS=()
+    SELECTED_RULE_COUNT=0
+
+    index=$(rule_selection_index "$@")
+    [[ -z "$index" ]] && return 0
+    SELECTED_RULE_COUNT=$(rule_selection_filter "$selection" <<< "$index" | grep -c . || true)
+    while IFS=$'\t' read -r check_id rest; do
+        [[ -n "$check_id" ]] && SELECTION_EXCLUDE_ARGS+=("--exclude-rule=$check_id")
+    done < <(rule_selection_filter "$selection" exclude <<< "$index")
+}
+
+# Whether a selection keeps a registry ruleset (p/secrets is category and
+# id "secrets" in pack "registry")
+# Args: $1 = selection, $2 = registry config (e.g. p/default)
+registry_config_selected() {
+    local name="${2#p/}"
+    [[ -n "$(printf '%s\t%s\tregistry\t\t%s\t\n' "$2" "$name" "$name" | rule_selection_filter "$1")" ]]
+}
+
+# Custom rules below HIGH confidence (or without one), skipped by --budget
+# Args: rule directories
+# Sets: BUDGET_EXCLUDE_ARGS (array of --exclude-rule=<check_id>),
+#       BUDGET_SKIPPED_RULES (newline-separated rule ids)
+build_budget_rule_excludes() {
+    local check_id id rest
+    BUDGET_EXCLUDE_ARGS=()
+    BUDGET_SKIPPED_RULES=""
+
+    while IFS=$'\t' read -r check_id id rest; do
+        [[ -z "$id" ]] && continue
+        BUDGET_EXCLUDE_ARGS+=("--exclude-rule=$check_id")
+        BUDGET_SKIPPED_RULES+="$id"$'\n'
+    done < <(rule_selection_index "$@" | rule_selection_filter "confidence=high" exclude)
+    BUDGET_SKIPPED_RULES="${BUDGET_SKIPPED_RULES%$'\n'}"
+}
+
+# =============================================================================
+# Rule Fixture Functions
+# =============================================================================
+# A rule file is tested against fixtures named after it, annotated the way
+# `semgrep --test` expects: a `ruleid: <id>` comment marks the next line (or
+# its own line, when trailing code) as one the rule must match, `ok: <id>`
+# one it must not; several ids are separated by commas. todoruleid and todook
+# record known gaps and aren't enforced.
+#   ssrf-taint.yaml          the rules
+#   ssrf-taint.test.go       single-file fixture, run with `semgrep --test`
+#   ssrf-taint.test/         fixture directory: several files, packages, and
+#                            a go.mod, scanned as one project so cross-file
+#                            and cross-package taint is exercised
+
+# Fixtures of a rule file, one path per line: single files first, then the
+# fixture directory
+# Args: $1 = rule file
+rule_fixtures() {
+    local rule_file="$1"
+    local stem="${rule_file%.*}"
+    local fixture
+
+    for fixture in "$stem".*; do
+        [[ -f "$fixture" ]] || continue
+        case "$fixture" in
+            *.yaml|*.yml|*.md|*.fixed|*.fixed.*) continue ;;
+        esac
+        echo "$fixture"
+    done
+    [[ -d "$stem.test" ]] && echo "$stem.test"
+    return 0
+}
+
+# Annotations in a fixture directory, one
+# "<path>\t<line>\t<kind>\t<rule id>" line each, paths relative to the
+# directory; kind is ruleid, ok, todoruleid, or todook
+# Args: $1 = fixture directory
+fixture_annotations() {
+    local dir="$1"
+
+    (cd "$dir" && find . -type f ! -name go.mod ! -name go.sum | sed 's|^\./||' | sort | while IFS= read -r file; do
+        awk -v file="$file" '
+            function emit(kind, ids, line,    n, i, parts) {
+                n = split(ids, parts, /[[:space:]]*,[[:space:]]*/)
+                for (i = 1; i <= n; i++) if (parts[i] != "") print file "\t" line "\t" kind "\t" parts[i]
+            }
+            match($0, /(\/\/|#|<!--|\/\*|--|;)[[:space:]]*(todoruleid|todook|ruleid|ok):[[:space:]]*[A-Za-z0-9_.,[:space:]-]+/) {
+                prefix = substr($0, 1, RSTART - 1)
+                annotation = substr($0, RSTART, RLENGTH)
+                sub(/^[^a-z]*/, "", annotation)
+                kind = annotation; sub(/:.*/, "", kind)
+                ids = annotation; sub(/^[a-z]+:[[:space:]]*/, "", ids); sub(/[[:space:]-]+$/, "", ids)
+                if (prefix ~ /^[[:space:]]*$/) {
+                    pending[++npending] = kind "\t" ids
+                } else {
+                    emit(kind, ids, FNR)
+                }
+                next
+            }
+            npending > 0 {
+                for (i = 1; i <= npending; i++) {
+                    split(pending[i], p, "\t")
+                    emit(p[1], p[2], FNR)
+                }
+                npending = 0
+            }
+        ' "$file"
+    done)
+}
+
+# Compare a fixture directory's annotations with semgrep's results for it:
+# every ruleid line must be matched by that rule, and no rule may match a
+# line that isn't annotated ruleid (or todook) for it
+# Args: $1 = fixture directory, $2 = semgrep JSON output from scanning it
+#       (paths relative to the directory)
+# Prints one problem per line, returns 1 when there are any
+fixture_check() {
+    local dir="$1"
+    local results="$2"
+    local problems
+
+    problems=$(jq -r --arg annotations "$(fixture_annotations "$dir")" '
+        ($annotations | split("\n") | map(select(. != "") | split("\t")
+            | {path: .[0], line: (.[1] | tonumber), kind: .[2], rule: .[3]})) as $expected
+        | [.results[]? | {path: (.path | ltrimstr("./")), line: .start.line, rule: (.check_id | split(".") | last)}]
+          | unique as $found
+        | ($expected[] | select(.kind == "ruleid") as $e
+           | select([$found[] | select(.path == $e.path and .line == $e.line and .rule == $e.rule)] | length == 0)
+           | "\(.path):\(.line): \(.rule) should match but did not"),
+          ($found[] | . as $f
+           | select([$expected[] | select(.path == $f.path and .line == $f.line and .rule == $f.rule
+                                          and (.kind == "ruleid" or .kind == "todook"))] | length == 0)
+           | "\(.path):\(.line): \(.rule) matched but the line is not annotated ruleid")
+    ' <<< "$results")
+    [[ -z "$problems" ]] && return 0
+    echo "$problems"
+    return 1
+}
+
+# Lines of a rule file taken up by one rule: "<first>\t<last>"
+# Args: $1 = rule file, $2 = rule id
+rule_line_range() {
+    awk -v id="$2" '
+        /^[[:space:]]*- id:/ {
+            if (first) { print first "\t" (NR - 1); found = 1; exit }
+            line = $0
+            sub(/^[[:space:]]*- id:[[:space:]]*/, "", line)
+            gsub(/["\047[:space:]]/, "", line)
+            if (line == id) first = NR
+        }
+        END { if (first && !found) print first "\t" NR }
+    ' "$1"
+}
+
+# Clause-by-clause evaluation of a rule at one fixture line, from a scan
+# run with semgrep --matching-explanations: every operator of the rule's
+# pattern tree (And, Or, XPat <pattern>, Inside, Negation, Filter, taint
+# sources and sinks, ...) indented under its parent, with the rule file line
+# it comes from and whether its matches cover the line. A Negation or
+# pattern-not-inside that matches is what removed a result.
+# Args: $1 = rule file, $2 = semgrep JSON, $3 = fixture path as in the
+#       results, $4 = line, $5 = rule id
+fixture_explain() {
+    local rule_file="$1"
+    local results="$2"
+    local path="$3"
+    local line="$4"
+    local rule_id="$5"
+    local range first last
+
+    range=$(rule_line_range "$rule_file" "$rule_id")
+    IFS=$'\t' read -r first last <<< "${range:-0	0}"
+    jq -r --rawfile yaml "$rule_file" --arg path "${path#./}" --argjson line "$line" \
+        --argjson first "$first" --argjson last "$last" '
+        ($yaml | split("\n")) as $source
+        | def opname: if type == "array" then map(if type == "string" then . else tojson end) | join(" ")
+                      else tostring end | gsub("\\s+"; " ") | .[0:60];
+          def here: [.matches[]? | select((.path | ltrimstr("./")) == $path)];
+          def status:
+              (here | map(select(.start.line <= $line and .end.line >= $line)) | length) as $hits
+              | if $hits > 0 then "matched"
+                else "no match" + (here | map(.start.line) | unique
+                    | if length > 0 then " (matched lines \(.[0:5] | map(tostring) | join(", "))\(if length > 5 then ", ..." else "" end))" else "" end)
+                end;
+          def clause: .loc.start.line as $n
+              | if $n then " [line \($n): \($source[$n - 1] // "" | gsub("^\\s+|\\s+$"; "") | .[0:50])]" else "" end;
+          def render($depth): ("  " * $depth) + (.op | opname) + ": " + status + clause,
+              (.children[]? | render($depth + 1));
+          [.explanations[]? | select($first == 0 or ((.loc.start.line // 0) >= $first and (.loc.start.line // 0) <= $last))]
+          | if length == 0 then "(no explanation: semgrep did not evaluate \($path) for this rule)"
+            else .[] | render(0) end
+    ' <<< "$results"
+}
+
+# =============================================================================
+# Rule Playground Functions
+# =============================================================================
+
+# Languages of a rule file's first rule, one per line
+# Handles both "languages: [go, python]" and block lists
+# Args: $1 = rule file
+rule_languages() {
+    awk '
+        /^[[:space:]]*languages:/ {
+            line = $0
+            sub(/^[[:space:]]*languages:[[:space:]]*/, "", line)
+            if (line ~ /^\[/) {
+                gsub(/[][",\047]/, " ", line)
+                n = split(line, langs, " ")
+                for (i = 1; i <= n; i++) print langs[i]
+                exit
+            }
+            inlist = 1
+            next
+        }
+        inlist && /^[[:space:]]*- / {
+            line = $0
+            sub(/^[[:space:]]*-[[:space:]]*/, "", line)
+            gsub(/["\047[:space:]]/, "", line)
+            print line
+            next
+        }
+        inlist { exit }
+    ' "$1"
+}
+
+# File extension semgrep recognizes for a language, for code without a file
+# name (LANGUAGE_EXTENSIONS, then common aliases, then the name itself)
+# Args: $1 = semgrep language name
+language_extension() {
+    local lang
+    lang=$(echo "$1" | tr '[:upper:]' '[:lower:]')
+
+    case "$lang" in
+        golang) lang="go" ;;
+        typescript|ts) echo "ts"; return ;;
+        js) lang="javascript" ;;
+        py|python3) lang="python" ;;
+        c#) lang="csharp" ;;
+        generic|regex|none) echo "txt"; return ;;
+        bash|sh) echo "sh"; return ;;
+        dockerfile) echo "Dockerfile"; return ;;
+    esac
+    awk -v lang="$lang" '$1 == lang { print $2; found = 1; exit } END { if (!found) print lang }' <<< "$LANGUAGE_EXTENSIONS"
+}
+
+# Matches of a semgrep run over one snippet, for reading while writing a
+# rule: each match with its numbered lines marked, taint source when the
+# rule traced one, and every metavariable binding with its position
+# Args: $1 = semgrep JSON, $2 = the scanned snippet file
+playground_report() {
+    local results="$1"
+    local snippet="$2"
+
+    jq -r --rawfile code "$snippet" '
+        ($code | split("\n")) as $lines
+        | .results | sort_by(.start.line, .start.col)[]
+        | . as $r
+        | "\(.check_id | split(".") | last)  line \(.start.line):\(.start.col)-\(.end.line):\(.end.col)",
+          (range(.start.line; .end.line + 1) | "  \(. | tostring | (" " * (4 - length)) + .) > \($lines[. - 1] // "")"),
+          (.extra.dataflow_trace.taint_source // null
+            | if . == null then empty
+              else (if .[0] == "CliLoc" then .[1] else [., ""] end) as [$loc, $content]
+                   | "      source: line \($loc.start.line // "?")  \($content // "" | gsub("\n"; " "))" end),
+          ((.extra.metavars // {}) | to_entries | sort_by(.key)[]
+            | "      \(.key) = \(.value.abstract_content)\(if .value.start.line then "  (line \(.value.start.line):\(.value.start.col))" else "" end)"),
+          ""
+    ' "$results"
+}
+
+# =============================================================================
+# Negative Corpus Functions
+# =============================================================================
+# Fixtures show a rule matches what it should; the negative corpus, a pinned
+# set of well-audited OSS repos (catalog/negative-corpus.json), shows how
+# much it matches where there is little to find. Each custom rule's finding
+# count there is recorded as its baseline, and a rise means lost precision.
+
+# Finding counts per custom rule across a directory of semgrep results, as
+# JSON {<rule id>: <count>}; registry rules are left out, since their
+# counts move with the registry rather than with this repo's rules
+# Args: $1 = directory of <repo>.json.gz semgrep results
+corpus_rule_counts() {
+    local results_dir="$1"
+    local file
+
+    for file in "$results_dir"/*.json.gz; do
+        [[ -f "$file" ]] || continue
+        gzip -dc "$file" | jq -r '.results[]?.check_id | split(".") | last'
+    done | jq -R -s -c --arg ids "$(list_rule_ids)" '
+        ($ids | split("\n") | map(select(. != "") | {key: ., value: true}) | from_entries) as $custom
+        | split("\n") | map(select(. != "" and $custom[.])) | group_by(.)
+        | map({key: .[0], value: length}) | from_entries
+    '
+}
+
+# Rules whose corpus finding count rose above their baseline, one
+# "<rule id>\t<baseline>\t<count>" line each; a rule without a baseline
+# counts from zero, so a new rule's corpus matches are reviewed once
+# Args: $1 = baseline JSON {<rule id>: <count>}, $2 = current counts (same shape)
+corpus_regressions() {
+    jq -r -n --argjson baseline "$1" --argjson counts "$2" '
+        $counts | to_entries[] | select(.value > ($baseline[.key] // 0))
+        | [.key, ($baseline[.key] // 0), .value] | @tsv
+    '
+}
+
+# =============================================================================
+# Diagnostics Functions
+# =============================================================================
+
+# Build a diagnostics summary from a semgrep JSON result file
+# Reports files scanned per language, files skipped and why, files only
+# partly parsed, and rule timeouts
+# Skip reasons: semgrep's own (e.g. exceeded_size_limit, binary), parse_error
+# for files semgrep failed to parse, ignored for .bountyhunterignore paths, and
+# binary / minified for files excluded by content (repo_skipped_files), and
+# budget for files a --budget scan ran out of time for, and scan_failed for
+# files of a pass semgrep failed on (crashed, killed, or a fatal error)
+# Partial reasons: partial_parse where semgrep skipped only the broken
+# regions, recovered where the file failed but a masked copy was scanned
+# (recover_unparseable_files); rules matched the rest of these files
+# Args: $1 = semgrep JSON file, $2 = repo name, $3 = newline-separated ignored paths,
+#       $4 = "<path>\t<reason>" lines from repo_skipped_files (optional),
+#       $5 = "<pass>\t<exit status>" lines for semgrep passes that failed (optional)
+# Prints a single JSON object
+semgrep_diagnostics() {
+    local results_file="$1"
+    local repo_name="$2"
+    local ignored="${3:-}"
+    local classified="${4:-}"
+    local failed="${5:-}"
+
+    jq --arg repo "$repo_name" \
+       --arg registry "$LANGUAGE_EXTENSIONS" \
+       --arg ignored "$ignored" \
+       --arg classified "$classified" \
+       --arg failed "$failed" '
+        ($registry | split("\n") | map(split(" ") | map(select(length > 0)))
+            | map(select(length > 1)) | map(.[0] as $lang | .[1:][] | {key: ., value: $lang})
+            | from_entries) as $ext |
+        def language: ((capture("\\.(?<e>[^./]+)$")? // {e: ""}).e | ascii_downcase) as $e | ($ext[$e] // "other");
+        def error_path: (.path // .spans[0].file // "");
+        (.errors // []) as $errors |
+        (.paths.recovered // []) as $recovered |
+        ($errors | map(select(.type | tostring | test("PartialParsing"))) | map(error_path) | unique) as $partial |
+        {
+            repo: $repo,
+            scanned_files: (.paths.scanned // [] | length),
+            languages: (.paths.scanned // [] | map(language) | group_by(.)
+                | map({key: .[0], value: length}) | from_entries),
+            skipped: (
+                (.paths.skipped // [] | map({path: .path, reason: (.reason // "skipped")}))
+                + ($errors | map(select(.type | tostring | test("Syntax|Parse|Lexical"; "i")))
+                    | map(error_path) | unique
+                    | map(select(. as $p | ($partial + $recovered) | index([$p]) | not))
+                    | map({path: ., reason: "parse_error"}))
+                + ($ignored | split("\n") | map(select(length > 0)) | map({path: ., reason: "ignored"}))
+                + ($classified | split("\n") | map(select(length > 0) | split("\t"))
+                    | map({path: .[0], reason: (.[1] // "skipped")}))
+            ),
+            partial: (($partial | map(select(. as $p | $recovered | index([$p]) | not))
+                       | map({path: ., reason: "partial_parse"}))
+                      + ($recovered | map({path: ., reason: "recovered"}))),
+            timeouts: ($errors | map(select(.type | tostring | test("Timeout"; "i")))
+                | map({rule_id: (.rule_id // ""), path: error_path})),
+            failed_passes: ($failed | split("\n") | map(select(length > 0) | split("\t"))
+                | map({pass: .[0], exit_status: (.[1] | tonumber)})),
+            errors: ($errors | length)
+        }
+    ' "$results_file"
+}
+
+# Files semgrep failed to parse at all, one path per line as semgrep
+# reported it; files it recovered from on its own (PartialParsing) aren't
+# listed, since their valid regions were already matched
+# Args: $1 = semgrep JSON file
+semgrep_parse_failures() {
+    jq -r '
+        (.errors // []) as $errors
+        | ($errors | map(select(.type | tostring | test("PartialParsing"))) | map(.path // .spans[0].file)) as $partial
+        | [$errors[] | select(.type | tostring | test("Syntax|Parse|Lexical"; "i"))
+           | .path // .spans[0].file // empty] | unique[]
+        | select(. as $p | $partial | index([$p]) | not)
+    ' "$1"
+}
+
+# Print a copy of a source file with the constructs that usually break
+# parsing blanked out, keeping every line and column where it was so
+# findings map back unchanged:
+# - template tags: {{ }}, {% %}, {# #}, <% %>, ${{ }}; a tag alone on its
+#   line is dropped, one inside code becomes an identifier of the same width
+# - merge conflicts: the markers and the incoming side, keeping ours
+# Args: $1 = file
+mask_unparseable() {
+    awk '
+        function fill(n, c,    s) { s = ""; while (n-- > 0) s = s c; return s }
+        function mask(line, re,    out) {
+            out = ""
+            while (match(line, re)) {
+                out = out substr(line, 1, RSTART - 1) fill(RLENGTH, "_")
+                line = substr(line, RSTART + RLENGTH)
+            }
+            return out line
+        }
+        /^<<<<<<< / { print ""; next }
+        /^=======$/ { theirs = 1; print ""; next }
+        /^>>>>>>> / { theirs = 0; print ""; next }
+        theirs { print ""; next }
+        /^[[:space:]]*(\{%.*%\}|\{#.*#\}|<%[^=].*%>|\{\{.*\}\})[[:space:]]*$/ { print ""; next }
+        {
+            line = mask($0, "\\$?\\{\\{[^}]*\\}\\}")
+            line = mask(line, "\\{%[^%]*%\\}")
+            line = mask(line, "\\{#[^#]*#\\}")
+            line = mask(line, "<%[^%]*%>")
+            print line
+        }
+    ' "$1"
+}
+
+# Rescan files semgrep couldn't parse from masked copies (mask_unparseable),
+# and merge the matches into the results with the original paths:
+# templated code and files mid-merge otherwise lose all coverage
+# Recovered findings carry extra.partial_parse, and the files are listed
+# in .paths.recovered for semgrep_diagnostics
+# Args: $1 = semgrep JSON file (updated in place), $2 = most files to
+#       rescan, remaining args = semgrep arguments (configs, filters)
+# Prints the number of files recovered
+recover_unparseable_files() {
+    local results_file="$1"
+    local max_files="$2"
+    shift 2
+    local dir index rescan path copy i=0 recovered=0
+
+    dir=$(mktemp -d)
+    index="$dir/index.tsv"
+    : > "$index"
+    while IFS= read -r path; do
+        [[ -f "$path" && "$i" -lt "$max_files" ]] || continue
+        i=$((i + 1))
+        copy="$dir/$i/$(basename "$path")"
+        mkdir -p "$dir/$i"
+        mask_unparseable "$path" > "$copy"
+        # Nothing masked means nothing a rescan could recover
+        if cmp -s "$path" "$copy"; then
+            rm -rf "${dir:?}/$i"
+            continue
+        fi
+        printf '%s\t%s\n' "$copy" "$path" >> "$index"
+    done < <(semgrep_parse_failures "$results_file")
+
+    if [[ -s "$index" ]]; then
+        rescan="$dir/results.json"
+        semgrep scan "$@" --json --output="$rescan" $(cut -f1 "$index") > /dev/null 2>&1 || true
+        if [[ -s "$rescan" ]]; then
+            recovered=$(jq -r --rawfile index "$index" '
+                ($index | split("\n") | map(select(. != "") | split("\t") | {key: .[0], value: .[1]}) | from_entries) as $paths
+                | [.errors[]? | select(.type | tostring | test("Syntax|Parse|Lexical"; "i")) | .path // .spans[0].file] as $failed
+                | [$paths | keys[] | select(. as $p | $failed | index([$p]) | not)] | length
+            ' "$rescan")
+            jq --rawfile index "$index" --slurpfile rescan "$rescan" '
+                ($index | split("\n") | map(select(. != "") | split("\t") | {key: .[0], value: .[1]}) | from_entries) as $paths
+                | ([$rescan[0].errors[]? | select(.type | tostring | test("Syntax|Parse|Lexical"; "i"))
+                    | .path // .spans[0].file]) as $failed
+                | .results += [$rescan[0].results[]? | select($paths[.path])
+                               | .path = $paths[.path] | .extra.partial_parse = true]
+                | .paths.recovered = ((.paths.recovered // [])
+                    + [$paths | to_entries[] | select(.key as $k | $failed | index([$k]) | not) | .value] | unique)
+            ' "$results_file" > "$dir/merged.json" && mv "$dir/merged.json" "$results_file"
+        fi
+    fi
+    rm -rf "$dir"
+    echo "$recovered"
+}
+
+# =============================================================================
+# Module Attribution Functions
+# =============================================================================
+
+# Module roots in a repo, one "<dir>\t<name>" line each (dir relative to the
+# repo, "" for the root). Go modules are named by their go.mod module path;
+# submodules are named by their path.
+# Args: $1 = repo directory
+repo_module_roots() {
+    local repo_dir="$1"
+    local gomod dir name
+
+    find "$repo_dir" -name go.mod -type f -not -path '*/vendor/*' -not -path '*/.git/*' 2>/dev/null | \
+        sort | while IFS= read -r gomod; do
+            dir="${gomod#"$repo_dir"/}"
+            dir="${dir%go.mod}"
+            dir="${dir%/}"
+            name=$(awk '$1 == "module" { print $2; exit }' "$gomod")
+            printf '%s\t%s\n' "$dir" "${name:-${dir:-.}}"
+        done
+
+    repo_submodule_paths "$repo_dir" | while IFS= read -r dir; do
+        printf '%s\t%s\n' "$dir" "$dir"
+    done
+}
+
+# Add extra.module (innermost Go module or submodule) and extra.submodule
+# (innermost submodule) to each finding in a semgrep JSON result file
+# Leaves the file unchanged for repos without modules or submodules
+# Args: $1 = results JSON file, $2 = repo directory (the semgrep target)
+# Requires catalog-utils.sh (repo_submodule_paths)
+annotate_semgrep_modules() {
+    local results_file="$1"
+    local repo_dir="$2"
+    local modules submodules tmp
+
+    modules=$(repo_module_roots "$repo_dir" | jq -R 'split("\t") | {dir: .[0], name: .[1]}' | jq -s '.')
+    [[ "$modules" == "[]" ]] && return 0
+    submodules=$(repo_submodule_paths "$repo_dir" | jq -R . | jq -s '.')
+
+    tmp=$(mktemp)
+    jq --arg root "$repo_dir/" --argjson modules "$modules" --argjson submodules "$submodules" '
+        def inside($p; $dir): $dir == "" or ($p | startswith($dir + "/"));
+        .results |= map(
+            (.path | ltrimstr($root) | ltrimstr("./")) as $p
+            | ([$modules[] | select(inside($p; .dir))] | max_by(.dir | length)) as $owner
+            | ([$submodules[] | select(inside($p; .))] | max_by(length)) as $sub
+            | if $owner then .extra.module = $owner.name else . end
+            | if $sub then .extra.submodule = $sub else . end
+        )
+    ' "$results_file" > "$tmp" && mv "$tmp" "$results_file"
+    rm -f "$tmp"
+}
+
+# =============================================================================
+# Dependency Functions
+# =============================================================================
+
+# Language of a file from its extension (LANGUAGE_EXTENSIONS), empty if unknown
+# Args: $1 = file path
+file_language() {
+    local ext
+    ext=$(echo "${1##*.}" | tr '[:upper:]' '[:lower:]')
+    awk -v ext="$ext" '{ for (i = 2; i <= NF; i++) if ($i == ext) { print $1; exit } }' <<< "$LANGUAGE_EXTENSIONS"
+}
+
+# Collapse "." and ".." segments in a relative path (no filesystem access)
+# Args: $1 = path
+normalize_path() {
+    awk -v path="$1" 'BEGIN {
+        n = split(path, parts, "/")
+        depth = 0
+        for (i = 1; i <= n; i++) {
+            if (parts[i] == "" || parts[i] == ".") continue
+            if (parts[i] == ".." && depth > 0) { depth--; continue }
+            out[++depth] = parts[i]
+        }
+        result = ""
+        for (i = 1; i <= depth; i++) result = result (i > 1 ? "/" : "") out[i]
+        print result
+    }'
+}
+
+# Go module roots in a repo, one "<module path>\t<dir>" line each (module
+# path first so the root module's empty dir survives read)
+# Args: $1 = repo directory
+go_module_roots() {
+    local repo_dir="$1"
+    local line dir
+
+    repo_module_roots "$repo_dir" | while IFS= read -r line; do
+        dir="${line%%$'\t'*}"
+        [[ -f "$repo_dir/${dir:+$dir/}go.mod" ]] && printf '%s\t%s\n' "${line#*$'\t'}" "$dir"
+    done
+    return 0
+}
+
+# Repo files imported by a file, one repo-relative path per line
+# Resolves relative JS/TS imports, Python module imports, Go package imports
+# (against the repo's go.mod module paths) and Java/Kotlin class imports.
+# Imports of third-party code are dropped.
+# Args: $1 = repo directory, $2 = file path relative to the repo
+file_imports() {
+    local repo="$1"
+    local file="$2"
+    local dir spec base candidate ext lang roots
+    local bases=()
+
+    dir=$(dirname "$file")
+    [[ "$dir" == "." ]] && dir=""
+    lang=$(file_language "$file")
+
+    case "$lang" in
+        javascript)
+            grep -oE "(from|import|require\\()[[:space:]]*['\"]\\.{1,2}/[^'\"]+['\"]" "$repo/$file" 2>/dev/null | \
+                sed -E "s/.*['\"](.+)['\"]/\\1/" | sort -u | while IFS= read -r spec; do
+                    base=$(normalize_path "$dir/$spec")
+                    for candidate in "$base" "$base".{js,jsx,mjs,cjs,ts,tsx,vue} "$base"/index.{js,jsx,ts,tsx}; do
+                        if [[ -f "$repo/$candidate" ]]; then
+                            echo "$candidate"
+                            break
+                        fi
+                    done
+                done
+            ;;
+        python)
+            # "from pkg import mod" may name a submodule, so pkg.mod is tried too
+            awk '
+                /^[[:space:]]*from[[:space:]]+[.A-Za-z0-9_]+[[:space:]]+import/ {
+                    line = $0
+                    sub(/^[[:space:]]*from[[:space:]]+/, "", line)
+                    split(line, parts, /[[:space:]]+import[[:space:]]+/)
+                    print parts[1]
+                    gsub(/[()]/, "", parts[2])
+                    n = split(parts[2], names, /[[:space:]]*,[[:space:]]*/)
+                    for (i = 1; i <= n; i++) {
+                        split(names[i], name, /[[:space:]]+/)
+                        if (name[1] ~ /^[A-Za-z0-9_]+$/) print parts[1] (parts[1] ~ /\.$/ ? "" : ".") name[1]
+                    }
+                    next
+                }
+                /^[[:space:]]*import[[:space:]]+[A-Za-z0-9_.]+/ {
+                    line = $0
+                    sub(/^[[:space:]]*import[[:space:]]+/, "", line)
+                    split(line, parts, /[^A-Za-z0-9_.]/)
+                    print parts[1]
+                }
+            ' "$repo/$file" 2>/dev/null | sort -u | while IFS= read -r spec; do
+                    if [[ "$spec" == .* ]]; then
+                        # Relative import: one leading dot is the file's package
+                        base="$dir/"
+                        spec="${spec#.}"
+                        while [[ "$spec" == .* ]]; do
+                            base="$base../"
+                            spec="${spec#.}"
+                        done
+                        bases=("$(normalize_path "$base$(echo "$spec" | tr . /)")")
+                    else
+                        base=$(echo "$spec" | tr . /)
+                        bases=("$base" "src/$base")
+                    fi
+                    for base in "${bases[@]}"; do
+                        for candidate in "$base.py" "$base/__init__.py"; do
+                            if [[ -f "$repo/$candidate" ]]; then
+                                echo "$candidate"
+                                break 2
+                            fi
+                        done
+                    done
+                done
+            ;;
+        go)
+            roots=$(go_module_roots "$repo")
+            [[ -z "$roots" ]] && return 0
+            awk '
+                /^import[[:space:]]*\(/ { block = 1; next }
+                block && /^\)/ { block = 0 }
+                block || /^import[[:space:]]/ { if (match($0, /"[^"]+"/)) print substr($0, RSTART + 1, RLENGTH - 2) }
+            ' "$repo/$file" 2>/dev/null | sort -u | while IFS= read -r spec; do
+                while IFS=$'\t' read -r base candidate; do
+                    [[ "$spec" == "$base" || "$spec" == "$base"/* ]] || continue
+                    candidate=$(normalize_path "$candidate/${spec#"$base"}")
+                    git -C "$repo" ls-files -- "${candidate:-.}" 2>/dev/null | \
+                        awk -v d="$candidate" '{ rest = (d == "" ? $0 : substr($0, length(d) + 2)) }
+                            rest !~ /\// && /\.go$/ && !/_test\.go$/'
+                    break
+                done <<< "$roots"
+            done
+            ;;
+        java|kotlin|scala)
+            sed -nE 's/^[[:space:]]*import[[:space:]]+(static[[:space:]]+)?([A-Za-z0-9_.]+)[[:space:];]*$/\2/p' \
+                "$repo/$file" 2>/dev/null | grep -v '\*$' | sort -u | while IFS= read -r spec; do
+                    base=$(echo "$spec" | tr . /)
+                    for ext in java kt scala; do
+                        git -C "$repo" ls-files 2>/dev/null | grep -E "(^|/)$base\\.$ext\$" | head -1
+                    done
+                done
+            ;;
+    esac
+}
+
+# Files that import a file directly, one repo-relative path per line
+# Candidates are found with git grep on the imported name, then confirmed
+# by resolving their imports, so only real dependents are returned
+# Args: $1 = repo directory, $2 = file path relative to the repo
+reverse_dependencies() {
+    local repo="$1"
+    local file="$2"
+    local lang stem dir root name candidate
+
+    lang=$(file_language "$file")
+    [[ -z "$lang" ]] && return 0
+    stem=$(basename "$file")
+    stem="${stem%.*}"
+    dir=$(dirname "$file")
+
+    case "$lang" in
+        javascript)
+            [[ "$stem" == "index" ]] && stem=$(basename "$dir")
+            ;;
+        python)
+            [[ "$stem" == "__init__" ]] && stem=$(basename "$dir")
+            ;;
+        go)
+            # Importers name the package directory by its import path
+            [[ "$dir" == "." ]] && dir=""
+            while IFS=$'\t' read -r name root; do
+                if [[ -z "$root" ]]; then
+                    stem="$name${dir:+/$dir}"
+                elif [[ "$dir" == "$root" || "$dir" == "$root"/* ]]; then
+                    stem="$name${dir#"$root"}"
+                fi
+            done < <(go_module_roots "$repo" | sort -t$'\t' -k2)
+            ;;
+    esac
+
+    git -C "$repo" grep -l -F -e "$stem" 2>/dev/null | while IFS= read -r candidate; do
+        [[ "$candidate" == "$file" ]] && continue
+        [[ "$(file_language "$candidate")" == "$lang" ]] || continue
+        if file_imports "$repo" "$candidate" | grep -qxF "$file"; then
+            echo "$candidate"
+        fi
+    done
+}
+
+# A changed file plus its reverse dependencies, breadth first, for partial
+# re-analysis (cross-file taint can flow from the changed file into them)
+# Args: $1 = repo directory, $2 = changed file relative to the repo,
+#       $3 = depth (default 1), $4 = max files (default 50)
+# Prints the changed file first, then dependents
+analysis_targets() {
+    local repo="$1"
+    local file="$2"
+    local depth="${3:-1}"
+    local max_files="${4:-50}"
+    local seen frontier next level dep
+
+    seen="$file"
+    frontier="$file"
+    echo "$file"
+
+    for ((level = 0; level < depth; level++)); do
+        next=""
+        while IFS= read -r file; do
+            [[ -z "$file" ]] && continue
+            while IFS= read -r dep; do
+                [[ -z "$dep" ]] && continue
+                grep -qxF "$dep" <<< "$seen" && continue
+                [[ $(grep -c . <<< "$seen") -ge "$max_files" ]] && return 0
+                seen+=$'\n'"$dep"
+                next+="$dep"$'\n'
+                echo "$dep"
+            done < <(reverse_dependencies "$repo" "$file")
+        done <<< "$frontier"
+        [[ -z "$next" ]] && break
+        frontier="$next"
+    done
+}
@@ -0,0 +1,172 @@
+#!/usr/bin/env bash
+# Severity Utilities
+# One severity scale for every scanner, so a threshold like --fail-on high
+# or a digest's min_severity means the same thing for code rules, secrets,
+# dependency advisories, and IaC checks
+#
+# Usage: source this file in other scripts
+#   source "$SCRIPT_DIR/lib/severity-utils.sh"
+#
+# Normalized levels, most severe first: critical high medium low info
+#
+# Each subsystem has a table from its own vocabulary to those levels
+# (SEVERITY_MAPS), matched case-insensitively:
+#   semgrep      ERROR high, WARNING medium, INFO low; rules written with
+#                semgrep's newer CRITICAL/HIGH/MEDIUM/LOW map to themselves
+#   sarif        result level: error high, warning medium, note low
+#   gosec        HIGH/MEDIUM/LOW
+#   staticcheck  error and warning medium, anything else low
+#   trufflehog   verified critical, unverified medium
+#   kics         CRITICAL/HIGH/MEDIUM/LOW, INFO and TRACE info
+#   sca          advisory severity (OSV/GHSA: CRITICAL/HIGH/MODERATE/LOW),
+#                or a CVSS base score: 9.0+ critical, 7.0+ high, 4.0+
+#                medium, above 0 low
+# A value a table doesn't know (and isn't already a level) gets the table's
+# "default", medium unless stated; severity_check lists rules that would.
+
+# Ensure we're not run directly
+if [[ "${BASH_SOURCE[0]}" == "${0}" ]]; then
+    echo "This script should be sourced, not executed directly."
+    echo "Usage: source ${BASH_SOURCE[0]}"
+    exit 1
+fi
+
+CATALOG_ROOT="${CATALOG_ROOT:-$(cd "$(dirname "${BASH_SOURCE[0]}")/../.." && pwd)}"
+
+# Normalized severities, most severe first
+SEVERITY_LEVELS="critical high medium low info"
+
+SEVERITY_MAPS='{
+    "semgrep": {"CRITICAL": "critical", "ERROR": "high", "HIGH": "high", "WARNING": "medium", "MEDIUM": "medium",
+                "INFO": "low", "LOW": "low", "INVENTORY": "info", "EXPERIMENT": "info", "default": "medium"},
+    "sarif": {"ERROR": "high", "WARNING": "medium", "NOTE": "low", "NONE": "info", "default": "medium"},
+    "gosec": {"HIGH": "high", "MEDIUM": "medium", "LOW": "low", "default": "medium"},
+    "staticcheck": {"ERROR": "medium", "WARNING": "medium", "default": "low"},
+    "trufflehog": {"VERIFIED": "critical", "UNVERIFIED": "medium", "default": "medium"},
+    "kics": {"CRITICAL": "critical", "HIGH": "high", "MEDIUM": "medium", "LOW": "low", "INFO": "info",
+             "TRACE": "info", "default": "medium"},
+    "sca": {"CRITICAL": "critical", "HIGH": "high", "MODERATE": "medium", "MEDIUM": "medium", "LOW": "low",
+            "NONE": "info", "default": "medium"}
+}'
+
+# jq definitions for working with severities inside a filter
+# severity_level($subsystem): the level of a raw value from that subsystem
+# severity_rank: a level's position, 0 for critical (unknown sorts last)
+# severity_at_least($min): whether a level is $min or more severe
+# severity_raise: the next level up (critical stays critical)
+# severity_semgrep: a level in semgrep's ERROR/WARNING/INFO, for results
+# written in semgrep's format
+SEVERITY_JQ_DEFS='
+    def severity_levels: ["critical", "high", "medium", "low", "info"];
+    def severity_level($subsystem):
+        ('"$SEVERITY_MAPS"'[$subsystem] // {}) as $map
+        | if $subsystem == "sca" and ([tostring | tonumber?] | length) > 0 then
+            (tostring | tonumber) as $score
+            | if $score >= 9 then "critical" elif $score >= 7 then "high"
+              elif $score >= 4 then "medium" elif $score > 0 then "low" else "info" end
+          elif . == null then $map.default // "medium"
+          else (tostring | ascii_upcase) as $raw
+            | $map[$raw] // (($raw | ascii_downcase) as $l
+                             | if severity_levels | index([$l]) then $l else $map.default // "medium" end) end;
+    def severity_rank: (. as $l | severity_levels | index([$l])) // (severity_levels | length);
+    def severity_at_least($min): severity_rank <= ($min | severity_rank);
+    def severity_raise: severity_levels[[severity_rank - 1, 0] | max] // .;
+    def severity_semgrep: {"critical": "ERROR", "high": "ERROR", "medium": "WARNING"}[.] // "INFO";
+'
+
+# =============================================================================
+# Severity Functions
+# =============================================================================
+
+# Check that a severity is one of the normalized levels
+# Args: $1 = severity, $2 = option name for the error (e.g. --fail-on)
+validate_severity() {
+    local severity="$1"
+    local option="${2:-severity}"
+
+    if [[ ! " $SEVERITY_LEVELS " == *" $severity "* ]]; then
+        echo "Error: Unknown $option '$severity' (use: $SEVERITY_LEVELS)" >&2
+        return 1
+    fi
+}
+
+# Normalize a raw severity from one subsystem
+# Args: $1 = subsystem (semgrep, sarif, gosec, staticcheck, trufflehog,
+#       kics, sca), $2 = raw value
+# Prints the level
+normalize_severity() {
+    jq -n -r --arg subsystem "$1" --arg raw "$2" "$SEVERITY_JQ_DEFS"'$raw | severity_level($subsystem)'
+}
+
+# Check whether a level is at or above a threshold
+# Args: $1 = level, $2 = threshold level
+severity_at_least() {
+    local levels=($SEVERITY_LEVELS)
+    local i rank=${#levels[@]} min=${#levels[@]}
+
+    for i in "${!levels[@]}"; do
+        [[ "${levels[$i]}" == "$1" ]] && rank=$i
+        [[ "${levels[$i]}" == "$2" ]] && min=$i
+    done
+    [[ "$rank" -le "$min" ]]
+}
+
+# Semgrep --severity values for findings at or above a level; semgrep
+# filters on ERROR, WARNING, and INFO, so a critical threshold has none
+# Args: $1 = level
+# Prints one value per line
+semgrep_severities_at_least() {
+    jq -r --arg min "$1" "$SEVERITY_JQ_DEFS"'
+        .semgrep | to_entries[] | select(.key == "ERROR" or .key == "WARNING" or .key == "INFO")
+        | select(.value | severity_at_least($min)) | .key' <<< "$SEVERITY_MAPS"
+}
+
+# Validate the mapping tables and the severities rules are written with
+# Tables must map only to normalized levels; every severity a custom rule
+# uses must be in the semgrep table, or it would silently get the default
+# Args: $1 = rules directory (default: custom-rules/)
+# Prints one problem per line, returns 1 when there are any
+severity_check() {
+    local rules_dir="${1:-$CATALOG_ROOT/custom-rules}"
+    local problems
+
+    problems=$(
+        jq -r --arg levels "$SEVERITY_LEVELS" '
+            ($levels | split(" ")) as $levels
+            | to_entries[] | .key as $table | .value | to_entries[]
+            | select(.value as $v | $levels | index([$v]) | not)
+            | "\($table): \(.key) maps to unknown level \(.value)"
+        ' <<< "$SEVERITY_MAPS"
+        if [[ -d "$rules_dir" ]]; then
+            grep -rnE --include='*.yaml' --include='*.yml' '^    severity: *"?[A-Za-z]+"? *$' "$rules_dir" 2>/dev/null | \
+                sed -E 's/^([^:]+):([0-9]+): *severity: *"?([A-Za-z]+)"? *$/\1:\2\t\3/' | \
+                jq -R -r --argjson maps "$SEVERITY_MAPS" '
+                    split("\t") | select(($maps.semgrep[.[1] | ascii_upcase] // null) == null)
+                    | "\(.[0]): severity \(.[1]) has no semgrep mapping"
+                ' | sed "s|^$CATALOG_ROOT/||"
+        fi
+    )
+    [[ -z "$problems" ]] && return 0
+    echo "$problems"
+    return 1
+}
+
+# Raw severities in a catalog scan that no table maps, which normalize to
+# the table's default
+# Args: $1 = catalog scan directory
+# Prints "<subsystem> <value>" per unmapped value
+unmapped_severities() {
+    local scan_dir="$1"
+
+    {
+        [[ -f "$scan_dir/semgrep.json.gz" ]] && \
+            gzip -dc "$scan_dir/semgrep.json.gz" | jq -r '.results[]?.extra.severity // empty | "semgrep\t\(.)"'
+        [[ -f "$scan_dir/kics.json.gz" ]] && \
+            gzip -dc "$scan_dir/kics.json.gz" | jq -r '.queries[]?.severity // empty | "kics\t\(.)"'
+    } | sort -u | jq -R -r --argjson maps "$SEVERITY_MAPS" --arg levels "$SEVERITY_LEVELS" '
+        split("\t") as [$subsystem, $value]
+        | select(($maps[$subsystem][$value | ascii_upcase] // null) == null
+                 and ($levels | split(" ") | index([$value | ascii_downcase]) | not))
+        | "\($subsystem) \($value)"
+    '
+}
@@ -0,0 +1,431 @@
+#!/usr/bin/env bash
+# Snippet Utilities
+# Shared functions for cutting a finding's code out of its file with the
+# enclosing function around it, and rendering it for reports
+#
+# Usage: source this file in other scripts
+#   source "$SCRIPT_DIR/lib/snippet-utils.sh"
+#
+# The enclosing function is found from the language's syntax, not its
+# layout, so it works whatever formatter (or none) the code went through:
+#   braces    go, javascript/typescript, java, kotlin, scala, csharp, c,
+#             cpp, php, rust - brace blocks counted outside strings and
+#             comments; a block is a function when the text before its "{"
+#             is a function header (signature, lambda, or arrow function)
+#   indent    python - the def's body runs until the indentation returns
+#             to the def's level (decorators included)
+#   keywords  ruby - def ... end, counting nested blocks
+# The innermost function containing the whole match is used. Without one
+# (other languages, top-level code), the snippet is the match with
+# SNIPPET_CONTEXT_LINES on either side. Functions longer than
+# SNIPPET_MAX_LINES keep their first and last lines and the lines around
+# the match, with the rest elided.
+#
+# A snippet is JSON:
+#   {language, kind: "function"|"context"|"match", path, start, end,
+#    match_start, match_end, lines: [{n, text, match} | {gap: true}]}
+# kind "match" means only the matched lines were available (no clone, or
+# the clone no longer matches the scanned code).
+
+# Ensure we're not run directly
+if [[ "${BASH_SOURCE[0]}" == "${0}" ]]; then
+    echo "This script should be sourced, not executed directly."
+    echo "Usage: source ${BASH_SOURCE[0]}"
+    exit 1
+fi
+
+CATALOG_ROOT="${CATALOG_ROOT:-$(cd "$(dirname "${BASH_SOURCE[0]}")/../.." && pwd)}"
+
+_SNIPPET_LIB_DIR="$(cd "$(dirname "${BASH_SOURCE[0]}")" && pwd)"
+source "$_SNIPPET_LIB_DIR/rule-utils.sh"
+
+# Longest snippet before the middle of a function is elided
+SNIPPET_MAX_LINES="${SNIPPET_MAX_LINES:-60}"
+
+# Lines either side of the match when there is no enclosing function
+SNIPPET_CONTEXT_LINES="${SNIPPET_CONTEXT_LINES:-3}"
+
+# =============================================================================
+# Extraction Functions
+# =============================================================================
+
+# Language of a file from its extension: the LANGUAGE_EXTENSIONS name,
+# except typescript for .ts/.tsx; empty when unknown
+# Args: $1 = path
+snippet_language() {
+    local ext
+
+    ext=$(sed -nE 's/.*\.([A-Za-z0-9+]+)$/\1/p' <<< "$1" | tr '[:upper:]' '[:lower:]')
+    case "$ext" in
+        ts|tsx) echo "typescript"; return 0 ;;
+        "") return 0 ;;
+    esac
+    awk -v ext="$ext" '$1 != "" { for (i = 2; i <= NF; i++) if ($i == ext) { print $1; exit } }' <<< "$LANGUAGE_EXTENSIONS"
+}
+
+# Snippet of a file around a line range
+# Args: $1 = file, $2 = first matched line, $3 = last matched line,
+#       $4 = language (default: from the file name), $5 = path to report
+#       (default: the file)
+# Prints snippet JSON; returns 1 if the file can't be read
+code_snippet() {
+    local file="$1"
+    local line="$2"
+    local end_line="${3:-$2}"
+    local language="${4:-$(snippet_language "$1")}"
+    local path="${5:-$1}"
+
+    [[ -r "$file" ]] || return 1
+
+    awk -v target="$line" -v target_end="$end_line" -v lang="$language" \
+        -v max="$SNIPPET_MAX_LINES" -v ctx="$SNIPPET_CONTEXT_LINES" '
+        function word(w) { return "(^|[^A-Za-z0-9_$])" w "([^A-Za-z0-9_$]|$)" }
+
+        # Is the text before a "{" a function header?
+        function isfn(h,   first) {
+            gsub(/[ \t]+/, " ", h)
+            sub(/^ /, "", h)
+            sub(/ $/, "", h)
+            if (lang == "go") return h ~ word("func")
+            if (lang == "rust") return h ~ word("fn")
+            if (lang == "php") return h ~ word("function") || h ~ word("fn")
+            if (lang == "kotlin" && h ~ word("fun")) return 1
+            if (lang == "scala" && h ~ word("def")) return 1
+            if ((lang == "javascript" || lang == "typescript") && h ~ word("function")) return 1
+            if (h ~ /(=>|->)$/) return 1
+            first = h
+            sub(/[^A-Za-z_].*/, "", first)
+            if (first ~ /^(if|else|for|foreach|while|do|switch|case|catch|try|finally|synchronized|using|lock|fixed|unsafe|checked|return|new|when|match|select|with)$/) return 0
+            if (h ~ /(^| )(class|interface|enum|struct|namespace|record|object|trait|impl|union|extern)( |$)/) return 0
+            return h ~ /\)( ?(const|noexcept|override|final|throws [^(){}]*|-> ?[^{}]*|: ?[^{}]*|where [^{}]*))* ?$/
+        }
+
+        function indent(s,   i, n, c) {
+            n = 0
+            for (i = 1; i <= length(s); i++) {
+                c = substr(s, i, 1)
+                if (c == " ") n++
+                else if (c == "\t") n += 8 - n % 8
+                else break
+            }
+            return n
+        }
+
+        function blank(s) { return s ~ /^[ \t]*(#.*)?$/ }
+
+        # End of the python def at line l (0 if it ends before the match)
+        function py_end(l,   k, base, last, depth, t, i, c) {
+            base = indent(lines[l])
+            # The header runs until its brackets close
+            depth = 0
+            for (k = l; k <= NR; k++) {
+                t = lines[k]
+                sub(/#.*/, "", t)
+                for (i = 1; i <= length(t); i++) {
+                    c = substr(t, i, 1)
+                    if (c ~ /[([{]/) depth++
+                    else if (c ~ /[)\]}]/) depth--
+                }
+                if (depth <= 0) break
+            }
+            last = k
+            for (k = k + 1; k <= NR; k++) {
+                if (instr[k]) { last = k; continue }
+                if (blank(lines[k])) continue
+                if (indent(lines[k]) <= base) break
+                last = k
+            }
+            return last
+        }
+
+        # End of the ruby def at line l: the "end" that balances it
+        function rb_end(l,   k, depth, t) {
+            depth = 0
+            for (k = l; k <= NR; k++) {
+                t = lines[k]
+                sub(/(^|[ \t])#.*/, "", t)
+                if (t ~ /^[ \t]*(def|class|module|if|unless|while|until|case|begin|for)([^A-Za-z0-9_]|$)/) depth++
+                if (t ~ /[^A-Za-z0-9_]do([ \t]*\|[^|]*\|)?[ \t]*$/) depth++
+                if (t ~ /^[ \t]*end([^A-Za-z0-9_]|$)/ || (t !~ /^[ \t]*end/ && t ~ /[; \t]end[ \t]*$/)) depth--
+                if (depth <= 0) return k
+            }
+            return NR
+        }
+
+        function reset_header() {
+            hdr = ""
+            hdr_line = 0
+            parens = 0
+            ended = 0
+        }
+
+        function emit(n, kind) { printf "%d\t%s\t%s\n", n, kind, lines[n] }
+
+        {
+            lines[NR] = $0
+        }
+
+        # Triple-quoted strings, so python docstrings never end a body
+        lang == "python" {
+            instr[NR] = in_triple
+            t = $0
+            n = gsub(/"""|\x27\x27\x27/, "", t)
+            if (n % 2 == 1) in_triple = !in_triple
+        }
+
+        # Brace languages: track blocks outside strings and comments
+        lang ~ /^(go|javascript|typescript|java|kotlin|scala|csharp|c|cpp|php|rust)$/ {
+            s = $0
+            if (quote != "`") quote = ""
+            for (i = 1; i <= length(s); i++) {
+                c = substr(s, i, 1)
+                c2 = substr(s, i, 2)
+                if (in_comment) {
+                    if (c2 == "*/") { in_comment = 0; i++ }
+                    continue
+                }
+                if (quote != "") {
+                    if (c == "\\" && quote != "`") i++
+                    else if (c == quote) quote = ""
+                    continue
+                }
+                if (c2 == "/*") { in_comment = 1; i++; continue }
+                if (c2 == "//") break
+                # A statement ended on the previous line unless this line
+                # opens its block (a brace on its own line)
+                if (ended && c !~ /[ \t]/) {
+                    if (c != "{") reset_header()
+                    ended = 0
+                }
+                if (c == "\"" || (c == "`" && lang ~ /^(go|javascript|typescript)$/)) {
+                    quote = c
+                    hdr = hdr "\"\""
+                    if (!hdr_line) hdr_line = NR
+                    continue
+                }
+                # Rust lifetimes (\x27a) are not character literals
+                if (c == "\x27" && (lang != "rust" || substr(s, i + 2, 1) == "\x27" || substr(s, i + 1, 1) == "\\")) {
+                    quote = c
+                    continue
+                }
+                if (c == "{") {
+                    depth++
+                    open_line[depth] = hdr_line ? hdr_line : NR
+                    is_fn[depth] = isfn(hdr)
+                    reset_header()
+                    continue
+                }
+                if (c == "}") {
+                    if (depth > 0) {
+                        if (is_fn[depth] && open_line[depth] <= target && NR >= target_end && open_line[depth] > best_start) {
+                            best_start = open_line[depth]
+                            best_end = NR
+                        }
+                        depth--
+                    }
+                    reset_header()
+                    continue
+                }
+                if (c == ";" && parens <= 0) {
+                    reset_header()
+                    continue
+                }
+                if (c == "(") parens++
+                if (c == ")") parens--
+                if (c !~ /[ \t]/ && !hdr_line) hdr_line = NR
+                hdr = hdr c
+            }
+            # Without semicolons (go, javascript, kotlin, scala), a line that
+            # closes its brackets and does not continue ends the statement
+            if (lang ~ /^(go|javascript|typescript|kotlin|scala)$/ && hdr_line && parens <= 0 && hdr ~ /[^-,(\[{=+*\/&|<>.:?!][ \t]*$/) ended = 1
+            hdr = hdr " "
+        }
+
+        END {
+            if (target > NR) target = NR
+            if (target_end > NR) target_end = NR
+            if (target_end < target) target_end = target
+
+            if (lang == "python" || lang == "ruby") {
+                for (l = target; l >= 1 && !best_start; l--) {
+                    if (lang == "python" && lines[l] ~ /^[ \t]*(async[ \t]+)?def[ \t]/ && !instr[l]) {
+                        e = py_end(l)
+                    } else if (lang == "ruby" && lines[l] ~ /^[ \t]*def[ \t]/) {
+                        e = rb_end(l)
+                    } else {
+                        continue
+                    }
+                    if (e >= target_end) {
+                        best_start = l
+                        best_end = e
+                        while (lang == "python" && best_start > 1 && lines[best_start - 1] ~ /^[ \t]*@/ &&
+                               indent(lines[best_start - 1]) == indent(lines[l])) best_start--
+                    }
+                }
+            }
+
+            if (best_start) {
+                kind = "function"
+                s = best_start
+                e = best_end
+            } else {
+                kind = "context"
+                s = target - ctx
+                e = target_end + ctx
+                if (s < 1) s = 1
+                if (e > NR) e = NR
+            }
+            printf "%s\t%d\t%d\n", kind, s, e
+
+            if (e - s + 1 <= max) {
+                for (n = s; n <= e; n++) emit(n, (n >= target && n <= target_end) ? "m" : "c")
+                exit
+            }
+
+            # Too long: first line, the lines around the match, last line
+            half = int((max - 4 - (target_end - target + 1)) / 2)
+            if (half < 0) half = 0
+            ws = target - half
+            we = target_end + half
+            if (we - ws + 1 > max - 4) we = ws + max - 5
+            if (ws <= s + 1) ws = s + 1
+            if (we >= e - 1) we = e - 1
+            emit(s, (s >= target && s <= target_end) ? "m" : "c")
+            if (ws > s + 1) print "\tg\t"
+            for (n = ws; n <= we; n++) emit(n, (n >= target && n <= target_end) ? "m" : "c")
+            if (we < e - 1) print "\tg\t"
+            emit(e, (e >= target && e <= target_end) ? "m" : "c")
+        }
+    ' "$file" | jq -R -s -c --arg language "$language" --arg path "$path" \
+        --argjson line "$line" --argjson end_line "$end_line" '
+        split("\n") | map(select(. != "") | split("\t")) as $rows
+        | {language: (if $language == "" then null else $language end), kind: $rows[0][0], path: $path,
+           start: ($rows[0][1] | tonumber), end: ($rows[0][2] | tonumber),
+           match_start: $line, match_end: $end_line,
+           lines: [$rows[1:][] | if .[1] == "g" then {gap: true}
+                   else {n: (.[0] | tonumber), text: (.[2:] | join("\t")), match: (.[1] == "m")} end]}'
+}
+
+# Snippet of a finding from org_semgrep_findings
+# Read from the scanned commit when the clone has it, otherwise from the
+# working tree; falls back to just the matched lines when there is no clone
+# or its code no longer matches what was scanned
+# Args: $1 = org, $2 = finding JSON
+# Prints snippet JSON
+finding_snippet() {
+    local org="$1"
+    local finding="$2"
+    local repo path line end_line commit lines language clone tmp snippet=""
+
+    IFS=$'\t' read -r repo path line end_line commit < <(jq -r '[.repo, .path, .line, (.end_line // .line), (.commit // "")] | @tsv' <<< "$finding")
+    lines=$(jq -r '.lines // ""' <<< "$finding")
+    language=$(snippet_language "$path")
+    clone="$CATALOG_ROOT/repos/$org/$repo"
+
+    tmp=$(mktemp)
+    if [[ -n "$commit" ]] && git -C "$clone" cat-file -e "$commit:$path" 2>/dev/null; then
+        git -C "$clone" show "$commit:$path" > "$tmp" 2>/dev/null || true
+    elif [[ -f "$clone/$path" ]]; then
+        cat "$clone/$path" > "$tmp"
+    fi
+
+    if [[ -s "$tmp" ]] && { [[ -z "$lines" ]] || \
+        [[ "$(sed -n "${line},${end_line}p" "$tmp" | tr -s ' \t\n' ' ')" == "$(printf '%s\n' "$lines" | tr -s ' \t\n' ' ')" ]]; }; then
+        snippet=$(code_snippet "$tmp" "$line" "$end_line" "$language" "$path" || true)
+    fi
+    rm -f "$tmp"
+
+    if [[ -n "$snippet" ]]; then
+        echo "$snippet"
+        return 0
+    fi
+    jq -c -n --arg lines "$lines" --arg language "$language" --arg path "$path" \
+        --argjson line "$line" --argjson end_line "$end_line" '
+        {language: (if $language == "" then null else $language end), kind: "match", path: $path,
+         start: $line, end: $end_line, match_start: $line, match_end: $end_line,
+         lines: ($lines | rtrimstr("\n") | split("\n") | to_entries | map({n: ($line + .key), text: .value, match: true}))}'
+}
+
+# Name of the function enclosing a line, from its header (empty at top
+# level or when the header names nothing, like an anonymous lambda)
+# Args: $1 = file, $2 = line, $3 = language (optional, from the path)
+enclosing_function_name() {
+    local file="$1"
+    local line="$2"
+    local language="${3:-$(snippet_language "$1")}"
+
+    code_snippet "$file" "$line" "$line" "$language" "$file" 2>/dev/null | jq -r '
+        select(.kind == "function") | .match_start as $match
+        | [.lines[] | select(.n != null and .n < $match) | .text | select(test("^\\s*@") | not)]
+        | (map(test("[{]|:\\s*$")) | index(true)) as $open | .[:(($open // length) + 1)] | join(" ")
+        | ([scan("([A-Za-z_$][A-Za-z0-9_$]*)\\s*\\(") | .[0]
+            | select(IN("func", "function", "def", "fn", "async", "if", "for", "while", "switch", "catch") | not)]
+           + [scan("([A-Za-z_$][A-Za-z0-9_$]*)\\s*[:=]\\s*(async\\s*)?(function\\b|\\()") | .[0]])
+        | first // empty' || true
+}
+
+# =============================================================================
+# Rendering Functions
+# =============================================================================
+
+# Line comment marker per language, for marking the match in plain code
+snippet_comment_jq='def comment: if . == "python" or . == "ruby" then "#" elif . == null then "#" else "//" end;'
+
+# Snippet as a fenced markdown block tagged with its language (so renderers
+# highlight it), matched lines marked with a trailing comment, elided lines
+# as a comment, after a one-line caption
+# Reads snippet JSON on stdin
+snippet_markdown() {
+    jq -r "$snippet_comment_jq"'
+        (.language | comment) as $c
+        | (if .kind == "function" then "Enclosing function, lines \(.start)-\(.end)"
+           elif .kind == "context" then "Lines \(.start)-\(.end)"
+           else "Matched lines \(.start)-\(.end)" end)
+          + (if .match_start == .match_end then " (finding on line \(.match_start), marked):"
+             else " (finding on lines \(.match_start)-\(.match_end), marked):" end),
+          "",
+          "```\(.language // "")",
+          (.lines[] | if .gap then "\($c) ..." elif .match and .kind != "match" then "\(.text)  \($c) <-- finding" else .text end),
+          "```"'
+}
+
+# Snippet as a self-contained HTML <pre> block: line numbers, matched lines
+# highlighted, and keywords, strings, comments, and numbers colored with
+# inline styles (no scripts or stylesheets to load)
+# Reads snippet JSON on stdin
+snippet_html() {
+    jq -r '
+        def keywords: ["if", "else", "elif", "for", "foreach", "while", "do", "switch", "case", "default", "break",
+            "continue", "return", "func", "function", "def", "fn", "fun", "class", "struct", "interface", "enum",
+            "import", "package", "from", "as", "try", "catch", "except", "finally", "throw", "throws", "raise",
+            "new", "delete", "var", "let", "const", "val", "static", "public", "private", "protected", "void",
+            "true", "false", "nil", "null", "None", "True", "False", "self", "this", "super", "async", "await",
+            "yield", "lambda", "go", "defer", "select", "range", "type", "impl", "pub", "mod", "use", "match",
+            "mut", "where", "in", "is", "not", "and", "or", "end", "begin", "module", "unless", "goto", "typeof",
+            "instanceof", "extends", "implements", "override", "final", "abstract", "object", "when", "with"];
+        def style($kind):
+            {comment: "color:#6b7280;font-style:italic", string: "color:#047857",
+             keyword: "color:#7c3aed;font-weight:bold", number: "color:#b45309"}[$kind];
+        (if .language == "python" or .language == "ruby" or .language == null then "#[^\n]*"
+         else "//[^\n]*|/\\*.*?(\\*/|$)|^\\s*\\*[^\n]*" end) as $comment
+        | "(?<comment>\($comment))|(?<string>\"(\\\\.|[^\"\\\\])*\"|\u0027(\\\\.|[^\u0027\\\\])*\u0027|`[^`]*`)|(?<number>\\b[0-9][0-9A-Fa-fxX_.]*\\b)|(?<word>[A-Za-z_$][A-Za-z0-9_$]*)" as $re
+        | (keywords | map({key: ., value: true}) | from_entries) as $keywords
+        | def highlight:
+            . as $text
+            | reduce ([match($re; "g")][]) as $m ({out: "", at: 0};
+                ($m.captures | map(select(.string != null)) | first | .name) as $kind
+                | (if $kind == "word" and ($keywords[$m.string] | not) then null
+                   elif $kind == "word" then "keyword" else $kind end) as $kind
+                | .out += ($text[.at:$m.offset] | @html)
+                  + (if $kind then "<span style=\"\(style($kind))\">\($m.string | @html)</span>" else ($m.string | @html) end)
+                | .at = $m.offset + $m.length)
+            | .out + ($text[.at:] | @html);
+          (.lines | map(.n // 0) | max | tostring | length) as $width
+        | "<pre style=\"background:#f6f8fa;padding:8px 0;overflow-x:auto;line-height:1.45\"><code>"
+          + (.lines | map(
+                if .gap then "<span style=\"display:block;padding:0 12px;color:#9ca3af\">…</span>"
+                else "<span style=\"display:block;padding:0 12px\(if .match then ";background:#fef3c7;box-shadow:inset 3px 0 #f59e0b" else "" end)\">"
+                     + "<span style=\"color:#9ca3af;user-select:none\">\(.n | tostring | " " * ($width - length) + .)  </span>"
+                     + (.text | highlight) + "</span>" end) | join(""))
+          + "</code></pre>"'
+}
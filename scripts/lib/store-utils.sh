@@ -0,0 +1,148 @@
+#!/usr/bin/env bash
+# Store Utilities
+# Shared functions for exporting and importing an org's findings store
+#
+# Usage: source this file in other scripts
+#   source "$SCRIPT_DIR/lib/store-utils.sh"
+#
+# An org's store is everything the catalog knows about it apart from the
+# cloned repos: catalog/tracked/<org>/ (metadata, scan history, triage,
+# suppressions, disclosures, shares), findings/<org>/ (reports and other
+# attachments), and scans/<org>/ (scanner results). The archive format is
+# documented in docs/store-archive-format.md.
+
+# Ensure we're not run directly
+if [[ "${BASH_SOURCE[0]}" == "${0}" ]]; then
+    echo "This script should be sourced, not executed directly."
+    echo "Usage: source ${BASH_SOURCE[0]}"
+    exit 1
+fi
+
+CATALOG_ROOT="${CATALOG_ROOT:-$(cd "$(dirname "${BASH_SOURCE[0]}")/../.." && pwd)}"
+
+# Archive format name and version written to manifest.json; bump the
+# version when the layout or a merged file's schema changes incompatibly
+STORE_FORMAT="bounty-hunter-store"
+STORE_FORMAT_VERSION=1
+
+# Files merged entry by entry on import instead of being kept or replaced
+STORE_MERGED_FILES="triage.json suppressions.json disclosures.json shares.json"
+
+# =============================================================================
+# Export Functions
+# =============================================================================
+
+# Store directories of an org that exist, relative to CATALOG_ROOT
+# Args: $1 = org, $2 = "no-results" to leave out scans/<org>/
+store_org_dirs() {
+    local org="$1"
+    local dir
+
+    for dir in "catalog/tracked/$org" "findings/$org" "scans/$org"; do
+        [[ "$dir" == scans/* && "${2:-}" == "no-results" ]] && continue
+        [[ -d "$CATALOG_ROOT/$dir" ]] && echo "$dir"
+    done
+    return 0
+}
+
+# Manifest entries for the files under a staging directory, as a JSON array
+# of {path, sha256, size}, sorted by path
+# Args: $1 = staging directory (holding store/)
+store_file_list() {
+    local staging="$1"
+    local file
+
+    (cd "$staging/store" && find . -type f | LC_ALL=C sort | sed 's|^\./||') | \
+        while IFS= read -r file; do
+            printf '%s\t%s\t%s\n' "$file" "$(sha256_hex < "$staging/store/$file")" \
+                "$(wc -c < "$staging/store/$file" | tr -d ' ')"
+        done | jq -R -s -c 'split("\n") | map(select(. != "") | split("\t") | {path: .[0], sha256: .[1], size: (.[2] | tonumber)})'
+}
+
+# =============================================================================
+# Import Functions
+# =============================================================================
+
+# Check an extracted archive: known format, supported version, and every
+# listed file present with its digest
+# Args: $1 = extracted archive directory
+# Prints problems, one per line; returns 1 if there are any
+store_verify() {
+    local dir="$1"
+    local manifest="$dir/manifest.json"
+    local problems="" path digest
+
+    if ! jq -e --arg format "$STORE_FORMAT" '.format == $format' "$manifest" > /dev/null 2>&1; then
+        echo "not a $STORE_FORMAT archive (no valid manifest.json)"
+        return 1
+    fi
+    if [[ "$(jq '.version' "$manifest")" -gt "$STORE_FORMAT_VERSION" ]]; then
+        echo "archive format version $(jq '.version' "$manifest") is newer than this tool supports ($STORE_FORMAT_VERSION)"
+        return 1
+    fi
+
+    while IFS=$'\t' read -r path digest; do
+        # Only paths inside an org's store directories are accepted
+        if [[ "$path" == /* || "$path" == *..* || ! "$path" =~ ^(catalog/tracked|findings|scans)/[^/]+/ ]]; then
+            problems+="unexpected path: $path"$'\n'
+        elif [[ ! -f "$dir/store/$path" ]]; then
+            problems+="missing: $path"$'\n'
+        elif [[ "$(sha256_hex < "$dir/store/$path")" != "$digest" ]]; then
+            problems+="digest mismatch: $path"$'\n'
+        fi
+    done < <(jq -r '.files[] | [.path, .sha256] | @tsv' "$manifest")
+
+    [[ -z "$problems" ]] && return 0
+    printf '%s' "$problems"
+    return 1
+}
+
+# Merge an incoming copy of a mergeable store file into the local one
+# triage.json: per finding, the most recent disposition wins and every other
+# disposition either side knew about goes into its history
+# suppressions.json: union by id; for the same id, the later expiry wins
+# disclosures.json: union by id; for the same id, local fields win and
+# notes are combined
+# shares.json: union by token
+# Args: $1 = file name, $2 = local file, $3 = incoming file
+# Prints the merged JSON
+store_merge_json() {
+    local name="$1"
+    local local_file="$2"
+    local incoming="$3"
+
+    case "$name" in
+        triage.json)
+            jq -n --slurpfile a "$local_file" --slurpfile b "$incoming" '
+                def states: [{status, reason, by, at}] + (.history // []);
+                ($a[0].findings // {}) as $mine
+                | {findings: (reduce (($b[0].findings // {}) | to_entries[]) as $e ($mine;
+                    if .[$e.key] == null then .[$e.key] = $e.value
+                    else
+                        .[$e.key] as $old
+                        | (($old | states) + ($e.value | states) | unique_by([.at, .status, .by, .reason])
+                           | sort_by(.at)) as $all
+                        | .[$e.key] = ((if ($e.value.at // "") > ($old.at // "") then $e.value else $old end)
+                                       + {history: $all[:-1]})
+                    end))}'
+            ;;
+        suppressions.json)
+            jq -n --slurpfile a "$local_file" --slurpfile b "$incoming" '
+                {suppressions: (($a[0].suppressions // []) + ($b[0].suppressions // [])
+                    | group_by(.id) | map(max_by(.expires // "")))}'
+            ;;
+        disclosures.json)
+            jq -n --slurpfile a "$local_file" --slurpfile b "$incoming" '
+                {disclosures: (($a[0].disclosures // []) + ($b[0].disclosures // [])
+                    | group_by(.id)
+                    | map(.[0] + {notes: (map(.notes // []) | add | unique_by([.date, .text]))}))}'
+            ;;
+        shares.json)
+            jq -n --slurpfile a "$local_file" --slurpfile b "$incoming" '
+                {shares: (($a[0].shares // []) + ($b[0].shares // []) | unique_by(.token))}'
+            ;;
+        *)
+            return 1
+            ;;
+    esac
+}
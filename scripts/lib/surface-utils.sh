@@ -0,0 +1,659 @@
+#!/usr/bin/env bash
+# Attack Surface Utilities
+# Shared functions for listing a repo's entry points: HTTP routes, gRPC
+# methods, CLI subcommands, and message consumers
+#
+# Usage: source this file in other scripts
+#   source "$SCRIPT_DIR/lib/surface-utils.sh"
+
+# Ensure we're not run directly
+if [[ "${BASH_SOURCE[0]}" == "${0}" ]]; then
+    echo "This script should be sourced, not executed directly."
+    echo "Usage: source ${BASH_SOURCE[0]}"
+    exit 1
+fi
+
+_SURFACE_LIB_DIR="$(cd "$(dirname "${BASH_SOURCE[0]}")" && pwd)"
+source "$_SURFACE_LIB_DIR/contract-utils.sh"
+
+# Entry point patterns, one per line: kind <tab> framework <tab> pathspec <tab> ERE
+# The route / name is the first quoted string (or Ruby symbol) in the match
+ENTRY_POINT_PATTERNS="$(cat << 'EOF'
+http	express	*.js *.ts *.mjs *.cjs	\b(app|router|server|api|routes)\.(get|post|put|patch|delete|all|head|options)\([[:space:]]*['"`]/[^'"`]*['"`]
+http	flask/fastapi	*.py	@[A-Za-z_][A-Za-z0-9_]*\.(route|get|post|put|patch|delete|api_route)\([[:space:]]*['"][^'"]*['"]
+http	django	*urls.py	\b(path|re_path|url)\([[:space:]]*r?['"][^'"]*['"]
+http	go	*.go	\.(HandleFunc|Handle|GET|POST|PUT|PATCH|DELETE|Get|Post|Put|Patch|Delete|Any)\([[:space:]]*"/[^"]*"
+http	spring	*.java *.kt	@(Get|Post|Put|Patch|Delete|Request)Mapping\((value[[:space:]]*=[[:space:]]*|path[[:space:]]*=[[:space:]]*)?\{?[[:space:]]*"[^"]*"
+http	rails	*routes.rb	^[[:space:]]*(get|post|put|patch|delete|match|resources|resource)[[:space:]]+(['"][^'"]+['"]|:[a-z_]+)
+http	laravel	*.php	Route::(get|post|put|patch|delete|any|match)\([[:space:]]*['"][^'"]*['"]
+cli	cobra	*.go	\bUse:[[:space:]]*"[a-z][^"]*"
+cli	click/typer	*.py	@[A-Za-z_][A-Za-z0-9_]*\.command\([[:space:]]*(name[[:space:]]*=[[:space:]]*)?['"][^'"]+['"]
+cli	argparse	*.py	add_parser\([[:space:]]*['"][^'"]+['"]
+cli	commander	*.js *.ts	\.command\([[:space:]]*['"`][^'"`]+['"`]
+consumer	kafka	*.java *.kt	@KafkaListener\([^)]*topics[[:space:]]*=[[:space:]]*\{?[[:space:]]*"[^"]*"
+consumer	spring-messaging	*.java *.kt	@(RabbitListener|JmsListener|SqsListener)\([^)]*"[^"]*"
+consumer	nestjs	*.ts	@(EventPattern|MessagePattern)\([[:space:]]*['"`][^'"`]*['"`]
+consumer	celery	*.py	@([A-Za-z_]+\.)?(task|shared_task)\b
+consumer	subscribe	*.js *.ts *.py *.go *.rb	\.(subscribe|consume|basic_consume|Subscribe|Consume|QueueSubscribe)\([[:space:]]*(topics?[[:space:]]*[:=][[:space:]]*\[?[[:space:]]*)?(queue[[:space:]]*=[[:space:]]*)?['"`][^'"`]+['"`]
+EOF
+)"
+
+# =============================================================================
+# Entry Point Functions
+# =============================================================================
+
+# Pathspecs excluded from entry point discovery (tests, vendored code)
+_surface_excludes() {
+    printf '%s\n' ':!*_test.*' ':!*.test.*' ':!*.spec.*' ':!test/*' ':!tests/*' ':!*/test/*' \
+        ':!*/tests/*' ':!*/__tests__/*' ':!*/testdata/*' ':!*/fixtures/*' ':!vendor/*' ':!*/vendor/*' \
+        ':!node_modules/*' ':!*/node_modules/*' ':!third_party/*' ':!*/third_party/*' ':!*.min.js'
+}
+
+# Entry points in a repo, one line each:
+# kind <tab> framework <tab> method (- outside http) <tab> route/name <tab> file <tab> line
+# Args: $1 = repo directory
+repo_entry_points() {
+    local repo="$1"
+    local kind framework pathspecs regex file
+    local specs=() excludes=()
+
+    while IFS= read -r file; do
+        excludes+=("$file")
+    done < <(_surface_excludes)
+
+    while IFS=$'\t' read -r kind framework pathspecs regex; do
+        [[ -z "$regex" ]] && continue
+        read -r -a specs <<< "$pathspecs"
+        git -C "$repo" grep -n -o -I -E -e "$regex" -- "${specs[@]}" "${excludes[@]}" 2>/dev/null | \
+            awk -v kind="$kind" -v framework="$framework" '
+                {
+                    file = $0; sub(/:.*/, "", file)
+                    rest = substr($0, length(file) + 2)
+                    line = rest; sub(/:.*/, "", line)
+                    match_text = substr(rest, length(line) + 2)
+
+                    name = ""
+                    if (match(match_text, /["\047`][^"\047`]*["\047`]/)) {
+                        name = substr(match_text, RSTART + 1, RLENGTH - 2)
+                    } else if (match(match_text, /:[a-z_]+/)) {
+                        name = substr(match_text, RSTART + 1, RLENGTH - 1)
+                    }
+
+                    method = "-"
+                    if (kind == "http") {
+                        method = "ANY"
+                        if (match(tolower(match_text), /(^|[^a-z])(get|post|put|patch|delete|head|options)([^a-z]|$)/)) {
+                            method = substr(tolower(match_text), RSTART, RLENGTH)
+                            gsub(/[^a-z]/, "", method)
+                            method = toupper(method)
+                        }
+                        if (match(match_text, /@(Get|Post|Put|Patch|Delete)Mapping/)) method = toupper(substr(match_text, RSTART + 1, RLENGTH - 8))
+                        if (match_text ~ /resources?[[:space:]]/) method = "CRUD"
+                    }
+                    if (kind == "consumer" && name == "") name = "(task)"
+                    print kind "\t" framework "\t" method "\t" name "\t" file "\t" line
+                }' || true
+    done <<< "$ENTRY_POINT_PATTERNS"
+
+    repo_grpc_entry_points "$repo"
+    return 0
+}
+
+# gRPC methods from .proto services with the line implementing each
+# (an unresolved handler is reported against the .proto definition)
+# Args: $1 = repo directory
+repo_grpc_entry_points() {
+    local repo="$1"
+    local proto method path operation rpc impl
+    local excludes=()
+
+    while IFS= read -r proto; do
+        excludes+=("$proto")
+    done < <(_surface_excludes)
+
+    while IFS= read -r proto; do
+        [[ -z "$proto" ]] && continue
+        while IFS=$'\t' read -r method path operation; do
+            rpc="${operation#*.}"
+            # Server implementations: Go methods, Python servicer methods,
+            # Java/Kotlin overrides, Node handler maps
+            impl=$(git -C "$repo" grep -n -I -E \
+                -e "func \([^)]*\) ${rpc}\(ctx" \
+                -e "def ${rpc}\(self, request" \
+                -e "(public|override fun) [^=]*\b${rpc}\(" \
+                -e "^[[:space:]]*${rpc}[[:space:]]*[:(][[:space:]]*(async[[:space:]]*)?\(?call" \
+                -- ':!*.pb.go' ':!*_pb2*.py' ':!*Grpc.java' ':!*_grpc_pb.js' \
+                ${excludes[@]+"${excludes[@]}"} 2>/dev/null | head -1 | awk -F: '{ print $1 "\t" $2 }')
+            if [[ -z "$impl" ]]; then
+                impl="$proto"$'\t'"$(grep -nE "rpc[[:space:]]+${rpc}\b" "$repo/$proto" | head -1 | cut -d: -f1)"
+            fi
+            printf 'grpc\tprotobuf\tRPC\t%s\t%s\n' "$path" "$impl"
+        done < <(proto_operations "$repo/$proto")
+    done < <(git -C "$repo" ls-files -- '*.proto' 2>/dev/null | grep -vE '(^|/)(vendor|third_party|node_modules)/')
+    return 0
+}
+
+# =============================================================================
+# Reachability Functions
+# =============================================================================
+# These use reverse_dependencies from rule-utils.sh; source it first.
+
+# Routes and handler names that deserve a look when they turn up orphaned
+SENSITIVE_NAME_PATTERN='debug|admin|internal|pprof|actuator|graphiql|console|phpinfo|backdoor|impersonat|sudo|superuser|test|dev|staging|legacy|old|tmp|temp'
+
+# Handler signatures and exported function definitions, one per line:
+# kind <tab> framework <tab> pathspec <tab> ERE (name follows the keyword)
+HANDLER_DEFINITION_PATTERNS="$(cat << 'EOF2'
+handler	net/http	*.go	^func (\([^)]*\) )?[A-Za-z_][A-Za-z0-9_]*\([a-z]+ http\.ResponseWriter, [a-z]+ \*http\.Request\)
+handler	gin	*.go	^func (\([^)]*\) )?[A-Za-z_][A-Za-z0-9_]*\([a-z]+ \*gin\.Context\)
+handler	echo/fiber	*.go	^func (\([^)]*\) )?[A-Za-z_][A-Za-z0-9_]*\([a-z]+ (echo\.Context|\*fiber\.Ctx)\) error
+handler	express	*.js *.ts *.mjs *.cjs	^[[:space:]]*(export[[:space:]]+)?(async[[:space:]]+)?function[[:space:]]+[A-Za-z_$][A-Za-z0-9_$]*[[:space:]]*\([[:space:]]*req[[:space:]]*(:[^,]*)?,[[:space:]]*res\b
+handler	express	*.js *.ts *.mjs *.cjs	^[[:space:]]*(export[[:space:]]+)?(const|let|var)[[:space:]]+[A-Za-z_$][A-Za-z0-9_$]*[[:space:]]*=[[:space:]]*(async[[:space:]]*)?(function[[:space:]]*)?\([[:space:]]*req[[:space:]]*(:[^,]*)?,[[:space:]]*res\b
+handler	django	*views*.py	^def [a-z_][A-Za-z0-9_]*\(request\b
+export	go	*.go	^func [A-Z][A-Za-z0-9_]*\(
+export	javascript	*.js *.ts *.mjs	^export[[:space:]]+(default[[:space:]]+)?(async[[:space:]]+)?(function|const|let|class)[[:space:]]+[A-Za-z_$][A-Za-z0-9_$]*
+EOF2
+)"
+
+# Entry files that are reached without being imported (servers, CLIs, WSGI,
+# Django URL confs included by string, Rails routes)
+_is_entry_file() {
+    local base
+    base=$(basename "$1")
+    case "$base" in
+        main.*|index.*|server.*|app.*|wsgi.py|asgi.py|manage.py|urls.py|routes.rb|__main__.py) return 0 ;;
+    esac
+    [[ "$1" == cmd/* || "$1" == */cmd/* || "$1" == bin/* ]]
+}
+
+# Handler-shaped functions and exported functions in a repo, one line each:
+# kind <tab> framework <tab> name <tab> file <tab> line
+# Go exports are limited to internal/ and package main, where nothing
+# outside the module can call them
+# Args: $1 = repo directory
+repo_function_definitions() {
+    local repo="$1"
+    local kind framework pathspecs regex file
+    local specs=() excludes=()
+
+    while IFS= read -r file; do
+        excludes+=("$file")
+    done < <(_surface_excludes)
+
+    while IFS=$'\t' read -r kind framework pathspecs regex; do
+        [[ -z "$regex" ]] && continue
+        read -r -a specs <<< "$pathspecs"
+        git -C "$repo" grep -n -o -I -E -e "$regex" -- "${specs[@]}" "${excludes[@]}" \
+            ':!*.pb.go' ':!*.d.ts' 2>/dev/null | \
+            awk -v kind="$kind" -v framework="$framework" '
+                {
+                    file = $0; sub(/:.*/, "", file)
+                    rest = substr($0, length(file) + 2)
+                    line = rest; sub(/:.*/, "", line)
+                    text = substr(rest, length(line) + 2)
+
+                    sub(/^[[:space:]]*/, "", text)
+                    sub(/^func (\([^)]*\) )?/, "", text)
+                    sub(/^def /, "", text)
+                    sub(/^export[[:space:]]+(default[[:space:]]+)?/, "", text)
+                    sub(/^async[[:space:]]+/, "", text)
+                    sub(/^(function|const|let|var|class)[[:space:]]+/, "", text)
+                    match(text, /^[A-Za-z_$][A-Za-z0-9_$]*/)
+                    name = substr(text, RSTART, RLENGTH)
+                    if (name != "") print kind "\t" framework "\t" name "\t" file "\t" line
+                }' || true
+    done <<< "$HANDLER_DEFINITION_PATTERNS" | while IFS=$'\t' read -r kind framework name file line; do
+        if [[ "$kind" == "export" && "$framework" == "go" && "$file" != internal/* && "$file" != */internal/* ]]; then
+            head -20 "$repo/$file" 2>/dev/null | grep -q '^package main' || continue
+        fi
+        printf '%s\t%s\t%s\t%s\t%s\n' "$kind" "$framework" "$name" "$file" "$line"
+    done
+}
+
+# Functions from repo_function_definitions that nothing else references,
+# in the same format. A name counts as referenced when it appears as a word
+# anywhere in the repo other than its own definition line (tests excluded,
+# so code only tests call is reported)
+# Args: $1 = repo directory
+unreferenced_functions() {
+    local repo="$1"
+    local defs names file
+    local excludes=()
+
+    while IFS= read -r file; do
+        excludes+=("$file")
+    done < <(_surface_excludes)
+
+    # A handler that is also exported is reported once, as a handler
+    defs=$(repo_function_definitions "$repo" | sort -t $'\t' -k1,1r -k4,4 -k5,5n | awk -F'\t' '!seen[$4 ":" $5]++')
+    [[ -z "$defs" ]] && return 0
+    names=$(cut -f3 <<< "$defs" | sort -u)
+
+    # One pass over the repo for every name instead of a grep per function
+    git -C "$repo" grep -n -o -w -I -F -f <(echo "$names") -- "${excludes[@]}" 2>/dev/null | \
+        awk -v defs="$defs" '
+            BEGIN {
+                n = split(defs, rows, "\n")
+                for (i = 1; i <= n; i++) { split(rows[i], d, "\t"); own[d[4] ":" d[5] ":" d[3]] = 1 }
+            }
+            {
+                file = $0; sub(/:.*/, "", file)
+                rest = substr($0, length(file) + 2)
+                line = rest; sub(/:.*/, "", line)
+                name = substr(rest, length(line) + 2)
+                if (!((file ":" line ":" name) in own)) refs[name]++
+            }
+            END {
+                for (i = 1; i <= n; i++) {
+                    split(rows[i], d, "\t")
+                    if (!(d[3] in refs)) print rows[i]
+                }
+            }'
+}
+
+# Files that register HTTP routes but that no other file imports and that
+# are not entry files themselves, one "<file>\t<route count>" line each
+# Only JS/TS and Python are checked: Go registers routes via package imports
+# and Spring/Rails/Laravel discover routes by convention
+# Args: $1 = repo directory, $2 = output of repo_entry_points (optional)
+orphaned_route_files() {
+    local repo="$1"
+    local entries="${2:-}"
+    local file count
+
+    [[ -z "$entries" ]] && entries=$(repo_entry_points "$repo")
+    awk -F'\t' '$1 == "http" && ($2 == "express" || $2 == "flask/fastapi") { print $5 }' <<< "$entries" | \
+        sort | uniq -c | while read -r count file; do
+            _is_entry_file "$file" && continue
+            [[ -n "$(reverse_dependencies "$repo" "$file" | head -1)" ]] && continue
+            printf '%s\t%s\n' "$file" "$count"
+        done
+}
+
+# =============================================================================
+# Sensitivity Functions
+# =============================================================================
+# These use file_imports from rule-utils.sh; source it first.
+
+# Entry points whose route or name marks a high-impact context, one per
+# line: context <tab> ERE (matched case-insensitively, first match wins)
+SENSITIVE_CONTEXT_PATTERNS="$(cat << 'EOF2'
+auth	login|logout|sign-?in|sign-?up|register|auth|oauth|oidc|sso|saml|token|passw|reset|forgot|mfa|2fa|otp|totp|session|verify|impersonat
+payment	pay|billing|checkout|invoice|subscri|charge|refund|stripe|wallet|credit|payout|transfer|coupon
+admin	admin|staff|superuser|internal|manage|moderat|sudo|backoffice|console
+EOF2
+)"
+
+# How many import levels below a sensitive handler file still count as
+# reachable from it
+SENSITIVE_REACH_DEPTH=2
+
+# Entry points in a high-impact context, one line each:
+# context <tab> route/name <tab> file
+# Args: $1 = repo directory, $2 = output of repo_entry_points (optional)
+sensitive_entry_points() {
+    local repo="$1"
+    local entries="${2:-}"
+
+    [[ -z "$entries" ]] && entries=$(repo_entry_points "$repo"; repo_grpc_entry_points "$repo")
+    awk -F'\t' -v OFS='\t' -v patterns="$SENSITIVE_CONTEXT_PATTERNS" '
+        BEGIN {
+            n = split(patterns, rows, "\n")
+            for (i = 1; i <= n; i++) { split(rows[i], p, "\t"); ctx[i] = p[1]; re[i] = p[2] }
+        }
+        $1 != "cli" && $4 != "" {
+            route = tolower($4)
+            for (i = 1; i <= n; i++) if (route ~ re[i]) { print ctx[i], $4, $5; next }
+        }' <<< "$entries"
+}
+
+# Files reachable from high-impact entry points: each sensitive handler
+# file plus the repo files it imports, up to SENSITIVE_REACH_DEPTH levels.
+# One "<file>\t<context>\t<route>\t<entry file>" line per file, attributed
+# to the first entry point that reaches it (auth before payment before admin)
+# Args: $1 = repo directory, $2 = output of repo_entry_points (optional)
+sensitive_reachable_files() {
+    local repo="$1"
+    local entries="${2:-}"
+    local context route entry file dep level seen frontier next
+
+    seen=""
+    while IFS=$'\t' read -r context route entry; do
+        [[ -z "$entry" ]] && continue
+        grep -qxF -e "$entry" <<< "$seen" && continue
+        seen+="$entry"$'\n'
+        printf '%s\t%s\t%s\t%s\n' "$entry" "$context" "$route" "$entry"
+
+        frontier="$entry"
+        for ((level = 0; level < SENSITIVE_REACH_DEPTH; level++)); do
+            next=""
+            while IFS= read -r file; do
+                [[ -z "$file" ]] && continue
+                while IFS= read -r dep; do
+                    [[ -z "$dep" ]] && continue
+                    grep -qxF -e "$dep" <<< "$seen" && continue
+                    seen+="$dep"$'\n'
+                    next+="$dep"$'\n'
+                    printf '%s\t%s\t%s\t%s\n' "$dep" "$context" "$route" "$entry"
+                done < <(file_imports "$repo" "$file" 2>/dev/null)
+            done <<< "$frontier"
+            [[ -z "$next" ]] && break
+            frontier="$next"
+        done
+    done < <(sensitive_entry_points "$repo" "$entries" | \
+        awk -F'\t' 'BEGIN { r["auth"] = 1; r["payment"] = 2; r["admin"] = 3 } { print r[$1] "\t" $0 }' | \
+        sort -t $'\t' -s -n -k1,1 | cut -f2-)
+}
+
+# Raise the severity of findings in files reachable from auth, payment, or
+# admin entry points by one level (INFO -> WARNING -> ERROR). Escalated
+# findings get extra.escalation = {from, context, route, entry_file}
+# Args: $1 = semgrep JSON file, $2 = repo directory (the semgrep target)
+# Prints the number of findings escalated
+escalate_sensitive_findings() {
+    local results_file="$1"
+    local repo_dir="$2"
+    local reachable tmp
+
+    reachable=$(sensitive_reachable_files "$repo_dir" | \
+        jq -R 'split("\t") | {key: .[0], value: {context: .[1], route: .[2], entry_file: .[3]}}' | \
+        jq -s 'from_entries')
+    if [[ "$reachable" == "{}" ]]; then
+        echo 0
+        return 0
+    fi
+
+    tmp=$(mktemp)
+    jq --arg root "$repo_dir/" --argjson reachable "$reachable" '
+        {"INFO": "WARNING", "WARNING": "ERROR"} as $next
+        | .results |= map(
+            (.path | ltrimstr($root) | ltrimstr("./")) as $p
+            | if $reachable[$p] and $next[.extra.severity] and (.extra.escalation | not) then
+                .extra.escalation = ($reachable[$p] + {from: .extra.severity})
+                | .extra.severity = $next[.extra.severity]
+              else . end
+        )
+    ' "$results_file" > "$tmp" && mv "$tmp" "$results_file"
+    rm -f "$tmp"
+    jq '[.results[] | select(.extra.escalation)] | length' "$results_file"
+}
+
+# =============================================================================
+# Observed Traffic Functions
+# =============================================================================
+# Endpoints seen live (proxy history from manual testing) are kept per org in
+# catalog/tracked/<org>/observed-endpoints.json, one entry per method, host,
+# and path template:
+#   {method, host, path, sample, statuses, count, first_seen, last_seen,
+#    sources, handler}
+# handler is the surfaces.json entry point that serves the path, or null.
+
+OBSERVED_ENDPOINTS_FILE="observed-endpoints.json"
+
+# Max segment length of an observed path kept as-is; longer segments are
+# treated as identifiers (tokens, slugs with hashes)
+OBSERVED_SEGMENT_MAX=40
+
+# Requests in a HAR file, one compact JSON object per line:
+# {method, host, path, status}
+# Args: $1 = HAR file
+har_to_observed() {
+    jq -c '
+        .log.entries[]?
+        | (.request.url // "" | capture("^[A-Za-z]+://(?<host>[^/:?#]+)(:[0-9]+)?(?<path>[^?#]*)")?) as $u
+        | select($u != null)
+        | {method: (.request.method // "GET" | ascii_upcase), host: ($u.host | ascii_downcase),
+           path: (if $u.path == "" then "/" else $u.path end), status: (.response.status // 0)}
+    ' "$1"
+}
+
+# Requests in a Burp Suite XML export (Proxy history > Save items), one
+# compact JSON object per line: {method, host, path, status}
+# Request and response bodies are skipped, base64-encoded or not
+# Args: $1 = Burp XML file
+burp_to_observed() {
+    awk '
+        function value(line, tag,    v) {
+            v = line
+            sub("^[[:space:]]*<" tag "[^>]*>", "", v)
+            sub("</" tag ">.*$", "", v)
+            sub(/^<!\[CDATA\[/, "", v)
+            sub(/\]\]>$/, "", v)
+            return v
+        }
+        /<(request|response)[ >]/ && !/<\/(request|response)>/ { body = 1; next }
+        body { if (/<\/(request|response)>/) body = 0; next }
+        /<item>/ { method = host = path = status = "" }
+        /^[[:space:]]*<method>/ { method = value($0, "method") }
+        /^[[:space:]]*<host[ >]/ { host = value($0, "host") }
+        /^[[:space:]]*<path>/ { path = value($0, "path") }
+        /^[[:space:]]*<status>/ { status = value($0, "status") }
+        /<\/item>/ && host != "" { print method "\t" host "\t" path "\t" status }
+    ' "$1" | jq -R -c '
+        split("\t")
+        | {method: (if .[0] == "" then "GET" else .[0] | ascii_upcase end), host: (.[1] | ascii_downcase),
+           path: (.[2] | sub("[?#].*$"; "") | if . == "" then "/" else . end),
+           status: (.[3] | tonumber? // 0)}
+    '
+}
+
+# jq definitions shared by the observed endpoint functions
+# observed_template: numeric, UUID, hex, and long segments become {id}
+# route_regex($anchor): an entry point route as a regex matching request
+# paths (":id", "{id}", "<int:id>" match one segment, "*" any suffix);
+# $anchor false lets the route match the end of a longer path (routers
+# mounted under a prefix)
+_OBSERVED_JQ_DEFS='
+    def observed_template($max):
+        split("/") | map(
+            if test("^[0-9]+$") or test("^[0-9a-fA-F]{8}-([0-9a-fA-F]{4}-){3}[0-9a-fA-F]{12}$")
+               or test("^[0-9a-fA-F]{16,}$") or length > $max then "{id}" else . end
+        ) | join("/") | if . == "" then "/" else . end;
+    def route_static:
+        gsub("\\{[^}]*\\}|:[A-Za-z_][A-Za-z0-9_]*|<[^>]*>|\\*"; "") | gsub("/+"; "/");
+    def route_regex($anchor):
+        (if startswith("^") then ltrimstr("^") | rtrimstr("$")
+         else gsub("(?<c>[.+()|$^])"; "\\\(.c)")
+            | gsub("\\{[^}]*\\}|:[A-Za-z_][A-Za-z0-9_]*\\??|<[^>]*>"; "[^/]+")
+            | gsub("\\*+"; ".*") end)
+        | (if startswith("/") then . else "/" + . end) | rtrimstr("/")
+        | (if $anchor then "^" else "" end) + . + "/?$";
+    def serves($m):
+        .method as $r | ($r == "ANY" or $r == "CRUD" or $r == "-" or $r == $m or ($m == "HEAD" and $r == "GET"));
+'
+
+# Merge observed requests into an org's observed endpoint store
+# Args: $1 = store file (created if missing), $2 = file of observed request
+#       lines (har_to_observed / burp_to_observed), $3 = source label
+# Prints the number of distinct endpoints in the import
+merge_observed_endpoints() {
+    local store="$1"
+    local requests="$2"
+    local source="$3"
+    local now tmp existing='[]'
+
+    now=$(date -u +%Y-%m-%dT%H:%M:%SZ)
+    [[ -f "$store" ]] && existing=$(cat "$store")
+    tmp=$(mktemp)
+
+    jq -s --argjson existing "$existing" --arg now "$now" --arg source "$source" \
+        --argjson max "$OBSERVED_SEGMENT_MAX" "$_OBSERVED_JQ_DEFS"'
+        map(. + {template: (.path | observed_template($max))})
+        | group_by([.method, .host, .template])
+        | map({method: .[0].method, host: .[0].host, path: .[0].template, sample: .[0].path,
+               statuses: (map(.status) | unique), count: length})
+        | . as $new
+        | ($existing + $new)
+        | group_by([.method, .host, .path])
+        | map(
+            (map(select(.first_seen)) | first) as $old
+            | ([.[] | select(.first_seen | not)] | first) as $in
+            | {method: .[0].method, host: .[0].host, path: .[0].path,
+               sample: (($in // $old).sample),
+               statuses: (map(.statuses[]) | unique),
+               count: (map(.count) | add),
+               first_seen: ($old.first_seen // $now),
+               last_seen: (if $in then $now else $old.last_seen end),
+               sources: ((($old.sources // []) + (if $in then [$source] else [] end)) | unique),
+               handler: ($old.handler // null)}
+          )
+        | sort_by(.host, .path, .method)
+    ' "$requests" > "$tmp" || { rm -f "$tmp"; return 1; }
+
+    mv "$tmp" "$store"
+    jq -s --argjson max "$OBSERVED_SEGMENT_MAX" "$_OBSERVED_JQ_DEFS"'
+        map([.method, .host, (.path | observed_template($max))]) | unique | length
+    ' "$requests"
+}
+
+# Link each observed endpoint to the HTTP entry point that serves it
+# An exact route match beats a suffix match (router mounted under a
+# prefix); among those, the route with the most static text wins
+# Sets handler = {repo, framework, route, path, line, findings, match,
+# candidates} on matched endpoints and null on the rest
+# Args: $1 = store file, $2 = surfaces.json from surfaces.sh
+correlate_observed_endpoints() {
+    local store="$1"
+    local surfaces="$2"
+    local tmp
+
+    tmp=$(mktemp)
+    jq --slurpfile surfaces "$surfaces" "$_OBSERVED_JQ_DEFS"'
+        ($surfaces[0] | map(select(.kind == "http" and (.route // "") != "")
+            | . + {static: (if .method == "CRUD" then .route else (.route | route_static) end)})) as $routes
+        | map(
+            . as $ep
+            | [$routes[] | select(serves($ep.method))
+                | if .method == "CRUD" then
+                      ("^(.*/)?" + (.route | ltrimstr("/")) + "(/[^/]+)?(/(edit|new))?/?$") as $crud
+                      | select($ep.sample | test($crud)) | . + {match: "suffix"}
+                  else
+                      (.route | route_regex(true)) as $exact
+                      | (.route | route_regex(false)) as $suffix
+                      | if ($ep.sample | test($exact)) then . + {match: "exact"}
+                        elif (.static | length) > 1 and ($ep.sample | test($suffix)) then . + {match: "suffix"}
+                        else empty end
+                  end
+              ] as $hits
+            | .handler = (if ($hits | length) == 0 then null else
+                ($hits | sort_by((if .match == "exact" then 0 else 1 end), -(.static | length)) | first
+                 | {repo, framework, route, path, line, findings, match})
+                + {candidates: ($hits | length)} end)
+          )
+    ' "$store" > "$tmp" || { rm -f "$tmp"; return 1; }
+    mv "$tmp" "$store"
+}
+
+# =============================================================================
+# Finding Endpoint Functions
+# =============================================================================
+# These use file_imports from rule-utils.sh; source it first.
+
+# Base URLs to build finding URLs from, most trusted first: hosts seen in
+# proxy history, then scans/<org>/dynamic-results/targets.txt and httpx
+# recon results. One scheme://host[:port] per line
+# Args: $1 = scans/<org> directory, $2 = observed endpoint store (optional)
+endpoint_base_urls() {
+    local scans_dir="$1"
+    local store="${2:-}"
+    local file
+
+    {
+        if [[ -n "$store" && -f "$store" ]]; then
+            jq -r '.[].host | "https://" + .' "$store"
+        fi
+        for file in "$scans_dir/dynamic-results/targets.txt" "$scans_dir/dynamic-results/recon/live-urls.txt"; do
+            if [[ -f "$file" ]]; then
+                grep -oE '^https?://[^/?#[:space:]]+' "$file" || true
+            fi
+        done
+    } 2>/dev/null | awk '!seen[$0]++'
+}
+
+# Files that reach an HTTP handler through one import: each handler file's
+# direct repo imports, one "<file>\t<handler file>" per line
+# Args: $1 = repo directory, $2 = surfaces.json, $3 = repo name
+handler_import_map() {
+    local repo_dir="$1"
+    local surfaces="$2"
+    local name="$3"
+    local handler dep
+
+    while IFS= read -r handler; do
+        [[ -z "$handler" ]] && continue
+        while IFS= read -r dep; do
+            [[ -n "$dep" && "$dep" != "$handler" ]] && printf '%s\t%s\n' "$dep" "$handler"
+        done < <(file_imports "$repo_dir" "$handler" 2>/dev/null)
+    done < <(jq -r --arg repo "$name" '[.[] | select(.kind == "http" and .repo == $repo) | .path] | unique | .[]' "$surfaces")
+    return 0
+}
+
+# Attach the best-guess live URL to each finding in a handler's code
+# A finding in a handler file takes the nearest route declared above it;
+# a finding in a file a handler imports takes that handler's first route.
+# The URL is an observed request for that route when proxy history has one,
+# otherwise the route on the base URL whose host names the repo (or the
+# first base URL). Sets extra.endpoint = {method, route, url, source, via,
+# handler} where source is observed, recon, or route (no base URL known)
+# Args: $1 = semgrep JSON file, $2 = repo name, $3 = surfaces.json,
+#       $4 = observed endpoint store (or ""), $5 = file of base URLs,
+#       $6 = handler_import_map output file
+# Prints the number of findings with an endpoint
+annotate_finding_endpoints() {
+    local results_file="$1"
+    local repo="$2"
+    local surfaces="$3"
+    local store="$4"
+    local bases_file="$5"
+    local imports_file="$6"
+    local observed='[]' tmp
+
+    [[ -n "$store" && -f "$store" ]] && observed=$(cat "$store")
+    tmp=$(mktemp)
+
+    jq --arg repo "$repo" --slurpfile surfaces "$surfaces" --argjson observed "$observed" \
+        --rawfile bases "$bases_file" --rawfile imports "$imports_file" '
+        def url_path:
+            if startswith("^") then ltrimstr("^") | rtrimstr("$") else . end
+            | gsub("\\{(?<n>[^}:]*):[^}]*\\}"; "{\(.n)}")
+            | gsub(":(?<n>[A-Za-z_][A-Za-z0-9_]*)\\??"; "{\(.n)}")
+            | gsub("<([^:>]*:)?(?<n>[^>]*)>"; "{\(.n)}")
+            | if startswith("/") then . else "/" + . end;
+        ($surfaces[0] | map(select(.kind == "http" and .repo == $repo and (.route // "") != ""))) as $routes
+        | ($imports | split("\n") | map(select(. != "") | split("\t"))
+            | group_by(.[0]) | map({key: .[0][0], value: map(.[1])}) | from_entries) as $via_imports
+        | ($bases | split("\n") | map(select(. != ""))) as $base_list
+        | ($repo | gsub("(?<c>[.+])"; "\\\(.c)")) as $repo_re
+        | (($base_list | map(select(capture("^[A-Za-z]+://(?<h>[^/:]+)").h | test("(^|[.-])" + $repo_re + "([.-]|$)"; "i")))) + $base_list | first) as $base
+        | ("/" + $repo + "/") as $marker
+        | .results |= map(
+            (.path | if startswith($repo + "/") then ltrimstr($repo + "/")
+                     elif index($marker) then .[(index($marker) + ($marker | length)):] else . end) as $rel
+            | .start.line as $line
+            | ([$routes[] | select(.path == $rel)] | sort_by(.line)) as $here
+            | (if ($here | length) > 0 then
+                   (([$here[] | select(.line <= $line)] | last) // ($here | first)) + {via: "handler"}
+               else
+                   ([$routes[] | select(.path as $p | ($via_imports[$rel] // []) | index($p))]
+                    | sort_by(.path, .line) | first) | if . then . + {via: "imports"} else null end
+               end) as $r
+            | if $r == null then del(.extra.endpoint) else
+                ([$observed[] | select(.handler and .handler.repo == $repo and .handler.path == $r.path
+                    and .handler.line == $r.line)] | sort_by(-.count) | first) as $seen
+                | .extra.endpoint = {
+                    method: (if $seen then $seen.method elif ($r.method | IN("ANY", "CRUD", "-")) then "GET" else $r.method end),
+                    route: $r.route,
+                    url: (if $seen then "https://\($seen.host)\($seen.sample)"
+                          elif $base then ($base | rtrimstr("/")) + ($r.route | url_path)
+                          else null end),
+                    source: (if $seen then "observed" elif $base then "recon" else "route" end),
+                    via: $r.via,
+                    handler: "\($r.path):\($r.line)"
+                  }
+              end
+        )
+    ' "$results_file" > "$tmp" || { rm -f "$tmp"; return 1; }
+
+    mv "$tmp" "$results_file"
+    jq '[.results[] | select(.extra.endpoint)] | length' "$results_file"
+}
@@ -0,0 +1,191 @@
+#!/usr/bin/env bash
+# Trace Utilities
+# OpenTelemetry spans for scan pipelines, exported as OTLP/HTTP JSON
+#
+# Tracing is off unless OTEL_EXPORTER_OTLP_ENDPOINT is set (for example
+# http://localhost:4318). Spans started in one script become parents of
+# spans started in the scripts it runs, through the W3C TRACEPARENT variable.
+#
+# Environment:
+#   OTEL_EXPORTER_OTLP_ENDPOINT  Collector base URL (spans go to /v1/traces)
+#   OTEL_EXPORTER_OTLP_HEADERS   Extra headers as key=value,key=value
+#   OTEL_SERVICE_NAME            Resource service.name (default: bounty-hunter)
+#
+# Usage: source this file in other scripts
+#   source "$SCRIPT_DIR/lib/trace-utils.sh"
+#   trace_span_start "semgrep.repo" repo="$name"
+#   ...
+#   trace_span_end ok findings="$count"
+
+# Ensure we're not run directly
+if [[ "${BASH_SOURCE[0]}" == "${0}" ]]; then
+    echo "This script should be sourced, not executed directly."
+    echo "Usage: source ${BASH_SOURCE[0]}"
+    exit 1
+fi
+
+OTEL_EXPORTER_OTLP_ENDPOINT="${OTEL_EXPORTER_OTLP_ENDPOINT:-}"
+OTEL_SERVICE_NAME="${OTEL_SERVICE_NAME:-bounty-hunter}"
+
+# Open spans, innermost last
+TRACE_SPAN_IDS=()
+TRACE_SPAN_NAMES=()
+TRACE_SPAN_STARTS=()
+TRACE_SPAN_PARENTS=()
+TRACE_SPAN_ATTRS=()
+
+# =============================================================================
+# Helper Functions
+# =============================================================================
+
+# True when spans should be recorded and exported
+trace_enabled() {
+    [[ -n "$OTEL_EXPORTER_OTLP_ENDPOINT" ]]
+}
+
+# Random lowercase hex id
+# Args: $1 = number of bytes (16 for trace ids, 8 for span ids)
+trace_random_id() {
+    od -An -N"$1" -tx1 /dev/urandom | tr -d ' \n'
+}
+
+# Current time in nanoseconds since the epoch (second precision where
+# date has no %N, as on macOS)
+trace_now_ns() {
+    local now
+    now=$(date +%s%N)
+    if [[ "$now" == *N ]]; then
+        now="$(date +%s)000000000"
+    fi
+    echo "$now"
+}
+
+# Trace id from TRACEPARENT (00-<trace_id>-<span_id>-<flags>)
+trace_current_trace_id() {
+    local parts
+    IFS=- read -r -a parts <<< "${TRACEPARENT:-}"
+    echo "${parts[1]:-}"
+}
+
+# Span id from TRACEPARENT
+trace_current_span_id() {
+    local parts
+    IFS=- read -r -a parts <<< "${TRACEPARENT:-}"
+    echo "${parts[2]:-}"
+}
+
+# OTLP attribute list from key=value arguments
+trace_attributes_json() {
+    local field
+    local args=()
+
+    for field in "$@"; do
+        args+=("${field%%=*}" "${field#*=}")
+    done
+
+    jq -nc '$ARGS.positional | [range(0; length; 2) as $i
+        | {key: .[$i], value: {stringValue: .[$i + 1]}}]' \
+        --args ${args[@]+"${args[@]}"}
+}
+
+# =============================================================================
+# Span Functions
+# =============================================================================
+
+# Start a span as a child of the current one
+# Args: $1 = span name, rest = key=value attributes
+trace_span_start() {
+    local name="$1"
+    shift
+
+    trace_enabled || return 0
+
+    local trace_id span_id parent_id
+    trace_id=$(trace_current_trace_id)
+    parent_id=$(trace_current_span_id)
+    [[ -z "$trace_id" ]] && trace_id=$(trace_random_id 16)
+    span_id=$(trace_random_id 8)
+
+    TRACE_SPAN_IDS+=("$span_id")
+    TRACE_SPAN_NAMES+=("$name")
+    TRACE_SPAN_STARTS+=("$(trace_now_ns)")
+    TRACE_SPAN_PARENTS+=("${TRACEPARENT:-}")
+    TRACE_SPAN_ATTRS+=("$(trace_attributes_json "$@")")
+
+    TRACEPARENT="00-$trace_id-$span_id-01"
+    export TRACEPARENT
+}
+
+# End the innermost span and export it
+# Args: $1 = status (ok | error, default ok), rest = key=value attributes
+trace_span_end() {
+    local status="${1:-ok}"
+    [[ $# -gt 0 ]] && shift
+
+    trace_enabled || return 0
+    [[ ${#TRACE_SPAN_IDS[@]} -gt 0 ]] || return 0
+
+    local last=$((${#TRACE_SPAN_IDS[@]} - 1))
+    local trace_id parent_id span_json
+
+    trace_id=$(trace_current_trace_id)
+    TRACEPARENT="${TRACE_SPAN_PARENTS[$last]}"
+    parent_id=$(trace_current_span_id)
+
+    span_json=$(jq -nc \
+        --arg trace_id "$trace_id" \
+        --arg span_id "${TRACE_SPAN_IDS[$last]}" \
+        --arg parent_id "$parent_id" \
+        --arg name "${TRACE_SPAN_NAMES[$last]}" \
+        --arg start_ns "${TRACE_SPAN_STARTS[$last]}" \
+        --arg end_ns "$(trace_now_ns)" \
+        --arg status "$status" \
+        --argjson attrs "${TRACE_SPAN_ATTRS[$last]}" \
+        --argjson end_attrs "$(trace_attributes_json "$@")" \
+        '{
+            traceId: $trace_id,
+            spanId: $span_id,
+            name: $name,
+            kind: 1,
+            startTimeUnixNano: $start_ns,
+            endTimeUnixNano: $end_ns,
+            attributes: ($attrs + $end_attrs),
+            status: {code: (if $status == "error" then 2 else 1 end)}
+        } + (if $parent_id != "" then {parentSpanId: $parent_id} else {} end)')
+
+    unset "TRACE_SPAN_IDS[$last]" "TRACE_SPAN_NAMES[$last]" "TRACE_SPAN_STARTS[$last]" \
+        "TRACE_SPAN_PARENTS[$last]" "TRACE_SPAN_ATTRS[$last]"
+
+    if [[ -n "$TRACEPARENT" ]]; then
+        export TRACEPARENT
+    else
+        unset TRACEPARENT
+    fi
+
+    trace_export_span "$span_json"
+}
+
+# Send one span to the collector (failures never stop a scan)
+# Args: $1 = span JSON
+trace_export_span() {
+    local span_json="$1"
+    local header
+    local header_args=()
+
+    if [[ -n "${OTEL_EXPORTER_OTLP_HEADERS:-}" ]]; then
+        while IFS= read -r header; do
+            [[ -n "$header" ]] && header_args+=(-H "${header%%=*}: ${header#*=}")
+        done <<< "$(echo "$OTEL_EXPORTER_OTLP_HEADERS" | tr ',' '\n')"
+    fi
+
+    jq -nc --arg service "$OTEL_SERVICE_NAME" --argjson span "$span_json" '{
+        resourceSpans: [{
+            resource: {attributes: [{key: "service.name", value: {stringValue: $service}}]},
+            scopeSpans: [{scope: {name: "bounty-hunter"}, spans: [$span]}]
+        }]
+    }' | curl -s -m 5 -o /dev/null \
+        -H "Content-Type: application/json" \
+        ${header_args[@]+"${header_args[@]}"} \
+        --data @- \
+        "${OTEL_EXPORTER_OTLP_ENDPOINT%/}/v1/traces" 2>/dev/null || true
+}
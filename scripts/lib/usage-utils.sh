@@ -0,0 +1,217 @@
+#!/usr/bin/env bash
+# Usage Utilities
+# Metering of compute time, outside API calls, and storage per org
+# (program), for billing or throttling the orgs that use a shared
+# deployment the most
+#
+# Usage: source this file in other scripts
+#   source "$SCRIPT_DIR/lib/usage-utils.sh"
+#
+# Each metered run appends one line to catalog/tracked/<org>/usage.jsonl:
+#   {"at": "2026-10-01T12:00:00Z", "kind": "scan", "compute_s": 312,
+#    "api_calls": 14, "detail": {...}}
+# compute_s is the run's wall-clock seconds. api_calls counts requests to
+# outside services: GitHub repo listings when cloning, and trufflehog's
+# live verification of each secret it finds. Storage isn't logged; it is
+# measured when asked for (repos/, scans/, and the org's catalog).
+#
+# Compute and API calls are budgeted per calendar month (UTC), storage on
+# what is on disk now. The deployment-wide quotas QUOTA_COMPUTE (e.g. 20h),
+# QUOTA_API_CALLS, and QUOTA_STORAGE (e.g. 50G) apply to every org, and an
+# org's meta.json "quota" ({compute_s, api_calls, storage_kb}) overrides
+# them one field at a time. Unset means unlimited.
+#
+# Needs catalog-utils.sh sourced first.
+
+# Ensure we're not run directly
+if [[ "${BASH_SOURCE[0]}" == "${0}" ]]; then
+    echo "This script should be sourced, not executed directly."
+    echo "Usage: source ${BASH_SOURCE[0]}"
+    exit 1
+fi
+
+CATALOG_ROOT="${CATALOG_ROOT:-$(cd "$(dirname "${BASH_SOURCE[0]}")/../.." && pwd)}"
+
+QUOTA_COMPUTE="${QUOTA_COMPUTE:-}"
+QUOTA_API_CALLS="${QUOTA_API_CALLS:-}"
+QUOTA_STORAGE="${QUOTA_STORAGE:-}"
+
+# Quota resources, in report order
+USAGE_RESOURCES="compute_s api_calls storage_kb"
+
+# jq definitions for showing usage
+# usage_resource_name: "compute", "api calls", or "storage"
+# usage_amount($resource): a resource's amount for display (12h05m,
+# 3.2G, 41)
+USAGE_JQ_DEFS='
+    def usage_resource_name: sub("_(s|kb)$"; "") | gsub("_"; " ");
+    def usage_amount($r):
+        if $r == "compute_s" then
+            "\(. / 3600 | floor)h\(. % 3600 / 60 | floor | tostring | if length < 2 then "0" + . else . end)m"
+        elif $r == "storage_kb" then
+            if . >= 1048576 then "\(. / 104857.6 | round / 10)G"
+            elif . >= 1024 then "\(. / 102.4 | round / 10)M" else "\(.)K" end
+        else tostring end;
+'
+
+# =============================================================================
+# Metering Functions
+# =============================================================================
+
+# Usage log for an org
+# Args: $1 = org name
+usage_file() {
+    echo "$CATALOG_ROOT/catalog/tracked/$1/usage.jsonl"
+}
+
+# Record one metered run; orgs that aren't in the catalog aren't metered
+# Args: $1 = org, $2 = kind (scan, clone, ...), $3 = compute seconds,
+#       $4 = API calls, $5 = detail JSON (optional)
+usage_record() {
+    local org="$1"
+    local kind="$2"
+    local compute_s="${3:-0}"
+    local api_calls="${4:-0}"
+    local detail="${5:-"{}"}"
+    local file
+
+    file=$(usage_file "$org")
+    [[ -d "$(dirname "$file")" ]] || return 0
+    jq -n -c --arg at "$(get_iso_timestamp)" --arg kind "$kind" \
+        --argjson compute_s "$compute_s" --argjson api_calls "$api_calls" --argjson detail "$detail" \
+        '{at: $at, kind: $kind, compute_s: $compute_s, api_calls: $api_calls, detail: $detail}' >> "$file"
+}
+
+# Kilobytes an org takes up on disk, as JSON {repos_kb, scans_kb,
+# catalog_kb, total_kb}
+# Args: $1 = org
+usage_storage_kb() {
+    local org="$1"
+    local dir kb
+    local sizes=()
+
+    for dir in "$(get_org_repos_dir "$org")" "$CATALOG_ROOT/scans/$org" "$(get_org_catalog_dir "$org")"; do
+        kb=0
+        [[ -d "$dir" ]] && kb=$(du -sk "$dir" 2>/dev/null | cut -f1)
+        sizes+=("${kb:-0}")
+    done
+    jq -n -c --argjson repos "${sizes[0]}" --argjson scans "${sizes[1]}" --argjson catalog "${sizes[2]}" \
+        '{repos_kb: $repos, scans_kb: $scans, catalog_kb: $catalog, total_kb: ($repos + $scans + $catalog)}'
+}
+
+# =============================================================================
+# Quota Functions
+# =============================================================================
+
+# An org's quotas, as JSON {compute_s, api_calls, storage_kb}, null where
+# unlimited: meta.json "quota" over the QUOTA_* defaults
+# Args: $1 = org
+org_quota() {
+    local org="$1"
+    local compute="null" api_calls="null" storage="null"
+
+    if [[ -n "$QUOTA_COMPUTE" ]]; then
+        compute=$(parse_duration_seconds "$QUOTA_COMPUTE") || {
+            echo "Error: Invalid QUOTA_COMPUTE '$QUOTA_COMPUTE' (e.g. 20h)" >&2
+            return 1
+        }
+    fi
+    if [[ -n "$QUOTA_API_CALLS" ]]; then
+        [[ "$QUOTA_API_CALLS" =~ ^[0-9]+$ ]] || {
+            echo "Error: Invalid QUOTA_API_CALLS '$QUOTA_API_CALLS'" >&2
+            return 1
+        }
+        api_calls="$QUOTA_API_CALLS"
+    fi
+    if [[ -n "$QUOTA_STORAGE" ]]; then
+        storage=$(parse_size_kb "$QUOTA_STORAGE") || {
+            echo "Error: Invalid QUOTA_STORAGE '$QUOTA_STORAGE' (e.g. 50G)" >&2
+            return 1
+        }
+    fi
+
+    jq -c --argjson compute "$compute" --argjson api_calls "$api_calls" --argjson storage "$storage" '
+        {compute_s: $compute, api_calls: $api_calls, storage_kb: $storage} + (.quota // {})
+    ' "$(get_org_catalog_dir "$org")/meta.json" 2>/dev/null || \
+        jq -n -c --argjson compute "$compute" --argjson api_calls "$api_calls" --argjson storage "$storage" \
+            '{compute_s: $compute, api_calls: $api_calls, storage_kb: $storage}'
+}
+
+# Set or clear an org's quotas in its meta.json
+# Args: $1 = org, $2 = JSON of the fields to set ({compute_s, api_calls,
+#       storage_kb}; a null field goes back to the default), or "clear"
+set_org_quota() {
+    local org="$1"
+    local quota="$2"
+    local meta_file
+
+    meta_file="$(get_org_catalog_dir "$org")/meta.json"
+    if [[ ! -f "$meta_file" ]]; then
+        echo "Error: '$org' is not tracked" >&2
+        return 1
+    fi
+
+    if [[ "$quota" == "clear" ]]; then
+        jq 'del(.quota)' "$meta_file" > "${meta_file}.tmp"
+    else
+        jq --argjson quota "$quota" '
+            .quota = ((.quota // {}) + $quota | with_entries(select(.value != null)))
+            | if .quota == {} then del(.quota) else . end
+        ' "$meta_file" > "${meta_file}.tmp"
+    fi && mv "${meta_file}.tmp" "$meta_file"
+}
+
+# =============================================================================
+# Report Functions
+# =============================================================================
+
+# An org's usage for one month against its quotas
+# Prints {org, month, runs, scans, compute_s, api_calls, by_kind: {kind:
+# {runs, compute_s, api_calls}}, storage: usage_storage_kb, quota:
+# org_quota, over: [{resource, used, limit}]}
+# Args: $1 = org, $2 = month as YYYY-MM (default: this month, UTC)
+usage_summary() {
+    local org="$1"
+    local month="${2:-$(date -u +%Y-%m)}"
+    local file storage quota
+
+    file=$(usage_file "$org")
+    storage=$(usage_storage_kb "$org")
+    quota=$(org_quota "$org") || return 1
+
+    { [[ -f "$file" ]] && cat "$file"; true; } | jq -s -c --arg org "$org" --arg month "$month" \
+        --argjson storage "$storage" --argjson quota "$quota" --arg resources "$USAGE_RESOURCES" '
+        map(select(type == "object" and (.at // "" | startswith($month)))) as $runs
+        | {org: $org, month: $month, runs: ($runs | length),
+           scans: ([$runs[] | select(.kind == "scan")] | length),
+           compute_s: ([$runs[].compute_s // 0] | add // 0),
+           api_calls: ([$runs[].api_calls // 0] | add // 0),
+           by_kind: ($runs | group_by(.kind) | map({key: .[0].kind,
+                     value: {runs: length, compute_s: (map(.compute_s // 0) | add),
+                             api_calls: (map(.api_calls // 0) | add)}}) | from_entries),
+           storage: $storage, quota: $quota}
+        | . as $u
+        | . + {over: [$resources | split(" ")[] as $r
+                      | ($quota[$r]) as $limit
+                      | (if $r == "storage_kb" then $u.storage.total_kb else $u[$r] end) as $used
+                      | select($limit != null and $used >= $limit)
+                      | {resource: $r, used: $used, limit: $limit}]}
+    '
+}
+
+# Check an org against its quotas before starting more work
+# Args: $1 = org
+# Prints one line per exhausted quota, returns 1 when there are any
+usage_quota_check() {
+    local summary exhausted
+
+    summary=$(usage_summary "$1") || return 1
+    exhausted=$(jq -r "$USAGE_JQ_DEFS"'
+        .month as $month | .over[] | .resource as $r
+        | "\($r | usage_resource_name): \(.used | usage_amount($r)) used of \(.limit | usage_amount($r))"
+          + (if $r == "storage_kb" then "" else " in \($month)" end)
+    ' <<< "$summary")
+    [[ -z "$exhausted" ]] && return 0
+    echo "$exhausted"
+    return 1
+}
@@ -0,0 +1,226 @@
+#!/usr/bin/env bash
+# Guard rule precision against a pinned corpus of well-audited OSS repos
+#
+# Usage: ./scripts/negative-corpus.sh [command] [options]
+#
+# The repos in catalog/negative-corpus.json are cloned at their pinned refs
+# and scanned as a hunt would scan them. Each custom rule's finding count is
+# compared with the baseline recorded in the same file; a rule that finds
+# more than its baseline has most likely gained false positives that its
+# fixtures don't exercise, and `check` exits 1 so CI fails. After reviewing
+# the new matches, `update` records the current counts as the baseline.
+#
+# Examples:
+#   ./scripts/negative-corpus.sh                # check (CI)
+#   ./scripts/negative-corpus.sh update         # record baselines
+#   ./scripts/negative-corpus.sh list
+
+set -euo pipefail
+
+SCRIPT_DIR="$(cd "$(dirname "${BASH_SOURCE[0]}")" && pwd)"
+source "$SCRIPT_DIR/lib/catalog-utils.sh"
+source "$SCRIPT_DIR/lib/rule-utils.sh"
+
+CORPUS_FILE="${NEGATIVE_CORPUS_FILE:-$CATALOG_ROOT/catalog/negative-corpus.json}"
+CORPUS_DIR="${NEGATIVE_CORPUS_DIR:-$CATALOG_ROOT/cache/negative-corpus}"
+
+usage() {
+    cat << EOF
+Usage: $0 [command] [options]
+
+Scan a pinned set of well-audited OSS repos and fail when any custom rule
+finds more there than its recorded baseline.
+
+Commands:
+    check               Scan and compare with the baselines (default)
+    update              Scan and record the current counts as baselines
+    list                Show the corpus repos and baselines
+
+Options:
+    --no-fetch          Scan the existing clones without fetching
+    -q, --quiet         Only print regressions and the summary
+    -h, --help          Show this help message
+
+The corpus and baselines are in $CORPUS_FILE:
+    repos       [{name, url, ref}], ref a tag, branch, or commit
+    commits     the commit each repo was at when baselines were recorded
+    baseline    {<rule id>: <finding count>}
+
+Clones and results are kept in NEGATIVE_CORPUS_DIR (default:
+cache/negative-corpus/), so CI can cache them between runs.
+
+Examples:
+    $0
+    $0 update
+EOF
+    exit 1
+}
+
+COMMAND=""
+FETCH=true
+QUIET=false
+
+while [[ $# -gt 0 ]]; do
+    case "$1" in
+        --no-fetch)
+            FETCH=false
+            shift
+            ;;
+        -q|--quiet)
+            QUIET=true
+            shift
+            ;;
+        -h|--help)
+            usage
+            ;;
+        -*)
+            echo "Unknown option: $1"
+            usage
+            ;;
+        *)
+            if [[ -z "$COMMAND" ]]; then
+                COMMAND="$1"
+            else
+                echo "Unexpected argument: $1"
+                usage
+            fi
+            shift
+            ;;
+    esac
+done
+
+COMMAND="${COMMAND:-check}"
+
+require_jq || exit 1
+
+if [[ ! -f "$CORPUS_FILE" ]]; then
+    echo "Error: Corpus file not found: $CORPUS_FILE"
+    exit 1
+fi
+
+say() {
+    [[ "$QUIET" == true ]] || echo "$@"
+}
+
+case "$COMMAND" in
+    check|update) ;;
+    list)
+        printf "%-12s  %-10s  %-12s  %s\n" "REPO" "REF" "COMMIT" "URL"
+        jq -r '.commits as $commits | .repos[] | [.name, .ref, (($commits[.name] // "-") | .[0:12]), .url] | @tsv' "$CORPUS_FILE" | \
+            while IFS=$'\t' read -r name ref commit url; do
+                printf "%-12s  %-10s  %-12s  %s\n" "$name" "$ref" "$commit" "$url"
+            done
+        echo ""
+        jq -r '"Baselines: \(.baseline | length) rules, \([.baseline[]] | add // 0) findings"' "$CORPUS_FILE"
+        exit 0
+        ;;
+    *)
+        echo "Unknown command: $COMMAND"
+        usage
+        ;;
+esac
+
+if [[ "$COMMAND" == "check" ]] && [[ "$(jq '.commits | length' "$CORPUS_FILE")" -eq 0 ]]; then
+    echo "Error: No baselines recorded yet; run: $0 update"
+    exit 1
+fi
+
+REPOS_DIR="$CORPUS_DIR/repos"
+RESULTS_DIR="$CORPUS_DIR/scans"
+mkdir -p "$REPOS_DIR"
+
+TMP_DIR=$(mktemp -d)
+register_cleanup "$TMP_DIR"
+
+# =============================================================================
+# Fetch Pinned Repos
+# =============================================================================
+
+# Only the pinned ref is fetched, shallow; the ref a clone holds is kept in
+# its git config so unchanged pins aren't fetched again
+COMMITS="{}"
+while IFS=$'\t' read -r name url ref; do
+    repo="$REPOS_DIR/$name"
+    if [[ "$FETCH" == true && "$(git -C "$repo" config --get corpus.ref 2>/dev/null || true)" != "$ref" ]]; then
+        say "Fetching $name at $ref..."
+        if [[ ! -d "$repo/.git" ]]; then
+            git init -q "$repo"
+            git -C "$repo" remote add origin "$url"
+        fi
+        if ! git -C "$repo" fetch -q --depth 1 origin "$ref" || ! git -C "$repo" checkout -q --detach FETCH_HEAD; then
+            echo "Error: Could not fetch $ref from $url"
+            exit 1
+        fi
+        git -C "$repo" config corpus.ref "$ref"
+    elif [[ ! -d "$repo/.git" ]]; then
+        echo "Error: $name is not cloned; run without --no-fetch"
+        exit 1
+    fi
+    COMMITS=$(jq -c --arg name "$name" --arg commit "$(git -C "$repo" rev-parse HEAD)" '.[$name] = $commit' <<< "$COMMITS")
+done < <(jq -r '.repos[] | [.name, .url, .ref] | @tsv' "$CORPUS_FILE")
+
+# Counts are only comparable at the commits they were recorded at
+if [[ "$COMMAND" == "check" ]]; then
+    moved=$(jq -r -n --argjson now "$COMMITS" --slurpfile corpus "$CORPUS_FILE" '
+        $corpus[0].commits as $recorded | $now | to_entries[]
+        | select($recorded[.key] != .value) | "\(.key): \($recorded[.key] // "none" | .[0:12]) -> \(.value[0:12])"
+    ')
+    if [[ -n "$moved" ]]; then
+        echo "Warning: Repos are not at the commits the baselines were recorded at:"
+        sed 's/^/  /' <<< "$moved"
+        echo "Counts may differ for reasons other than the rules; run: $0 update"
+    fi
+fi
+
+# =============================================================================
+# Scan
+# =============================================================================
+
+say "Scanning $(jq '.repos | length' "$CORPUS_FILE") corpus repos..."
+rm -rf "$RESULTS_DIR"
+"$SCRIPT_DIR/scan-semgrep.sh" negative-corpus --repos-dir "$REPOS_DIR" --output-dir "$RESULTS_DIR" -q \
+    > "$TMP_DIR/scan.log" 2>&1 || true
+if ! ls "$RESULTS_DIR"/semgrep-results/*.json.gz > /dev/null 2>&1; then
+    echo "Error: The corpus scan produced no results (see scan-semgrep.sh output below)"
+    tail -n 5 "$TMP_DIR/scan.log"
+    exit 1
+fi
+
+COUNTS=$(corpus_rule_counts "$RESULTS_DIR/semgrep-results")
+
+# =============================================================================
+# Compare or Record
+# =============================================================================
+
+if [[ "$COMMAND" == "update" ]]; then
+    tmp=$(mktemp)
+    jq --argjson counts "$COUNTS" --argjson commits "$COMMITS" \
+        '.baseline = $counts | .commits = $commits' "$CORPUS_FILE" > "$tmp" && mv "$tmp" "$CORPUS_FILE"
+    echo "Recorded baselines for $(jq 'length' <<< "$COUNTS") rules ($(jq '[.[]] | add // 0' <<< "$COUNTS") findings)"
+    exit 0
+fi
+
+BASELINE=$(jq -c '.baseline' "$CORPUS_FILE")
+REGRESSIONS=$(corpus_regressions "$BASELINE" "$COUNTS")
+lowered=$(jq -n --argjson baseline "$BASELINE" --argjson counts "$COUNTS" \
+    '[$baseline | to_entries[] | select(.value > ($counts[.key] // 0))] | length')
+
+if [[ -n "$REGRESSIONS" ]]; then
+    printf "%-50s  %8s  %8s  %s\n" "RULE" "BASELINE" "NOW" "REPOS"
+    while IFS=$'\t' read -r rule baseline count; do
+        repos=$(for file in "$RESULTS_DIR"/semgrep-results/*.json.gz; do
+            gzip -dc "$file" | jq -e --arg rule "$rule" \
+                'any(.results[]?; .check_id | split(".") | last == $rule)' > /dev/null && basename "$file" .json.gz
+        done | paste -sd, -)
+        printf "%-50s  %8s  %8s  %s\n" "$rule" "$baseline" "$count" "$repos"
+    done <<< "$REGRESSIONS"
+    echo ""
+    echo "$(grep -c . <<< "$REGRESSIONS") rules find more in the negative corpus than their baseline"
+    echo "Review the new matches in $RESULTS_DIR/semgrep-results/; if they're real, run: $0 update"
+    exit 1
+fi
+
+echo "No rule finds more than its baseline in the negative corpus ($(jq '[.[]] | add // 0' <<< "$COUNTS") findings)"
+if [[ "$lowered" -gt 0 ]]; then
+    say "$lowered rules now find less than their baseline; run $0 update to lower it"
+fi
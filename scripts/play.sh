@@ -0,0 +1,190 @@
+#!/usr/bin/env bash
+# Try a rule against a code snippet and see what it matches
+#
+# Usage: ./scripts/play.sh --rule <file|id> [code-file] [options]
+#
+# For iterating on a rule before it has fixtures: paste or pipe a snippet,
+# and every match is shown with its lines, the taint source it was traced
+# from, and what each metavariable bound to. Snippets from stdin get the
+# file extension of the rule's language, so semgrep parses them.
+#
+# Examples:
+#   ./scripts/play.sh --rule custom-rules/web-vulns/ssrf-taint.yaml handler.go
+#   pbpaste | ./scripts/play.sh --rule go-ssrf-taint
+#   ./scripts/play.sh --rule rules.yaml --id py-cmdi --lang python < snippet.txt
+
+set -euo pipefail
+
+SCRIPT_DIR="$(cd "$(dirname "${BASH_SOURCE[0]}")" && pwd)"
+source "$SCRIPT_DIR/lib/catalog-utils.sh"
+source "$SCRIPT_DIR/lib/rule-utils.sh"
+
+usage() {
+    cat << EOF
+Usage: $0 --rule <file|id> [code-file] [options]
+
+Run a rule over a snippet (a file, or stdin) and show its matches with
+their metavariable bindings.
+
+Arguments:
+    code-file           Code to scan (default: read from stdin)
+
+Options:
+    --rule <file|id>    Rule file, or the id of a rule in custom-rules/
+    --id <rule id>      Only this rule of a multi-rule file
+    --lang <language>   Language of code read from stdin
+                        (default: the rule's first language)
+    --json              Print semgrep's JSON results instead
+    -h, --help          Show this help message
+
+Exit codes:
+    0  The rule ran (with or without matches)
+    1  The rule doesn't parse (or usage error)
+
+Examples:
+    $0 --rule custom-rules/web-vulns/ssrf-taint.yaml handler.go
+    pbpaste | $0 --rule go-ssrf-taint
+EOF
+    exit 1
+}
+
+RULE=""
+RULE_ID=""
+LANG_NAME=""
+CODE_FILE=""
+JSON_OUTPUT=false
+
+while [[ $# -gt 0 ]]; do
+    case "$1" in
+        --rule)
+            RULE="$2"
+            shift 2
+            ;;
+        --id)
+            RULE_ID="$2"
+            shift 2
+            ;;
+        --lang)
+            LANG_NAME="$2"
+            shift 2
+            ;;
+        --json)
+            JSON_OUTPUT=true
+            shift
+            ;;
+        -h|--help)
+            usage
+            ;;
+        -*)
+            echo "Unknown option: $1"
+            usage
+            ;;
+        *)
+            if [[ -z "$CODE_FILE" ]]; then
+                CODE_FILE="$1"
+            else
+                echo "Unexpected argument: $1"
+                usage
+            fi
+            shift
+            ;;
+    esac
+done
+
+if [[ -z "$RULE" ]]; then
+    echo "Error: --rule is required"
+    usage
+fi
+
+require_jq || exit 1
+
+if ! command -v semgrep &> /dev/null; then
+    echo "Error: semgrep is not installed"
+    exit 1
+fi
+
+WORK=$(mktemp -d)
+trap 'rm -rf "$WORK"' EXIT
+
+# =============================================================================
+# Rule
+# =============================================================================
+
+RULE_FILE="$WORK/rule.yaml"
+if [[ -f "$RULE" ]]; then
+    if [[ -n "$RULE_ID" ]]; then
+        extract_rule "$RULE_ID" "$RULE" > "$RULE_FILE"
+    else
+        cp "$RULE" "$RULE_FILE"
+    fi
+elif found=$(find_rule_file "$RULE"); then
+    extract_rule "$RULE" "$found" > "$RULE_FILE"
+else
+    echo "Error: No rule file or custom rule '$RULE'"
+    exit 1
+fi
+
+if ! grep -qE '^[[:space:]]*- id:' "$RULE_FILE"; then
+    echo "Error: No rule '${RULE_ID:-$RULE}' in $RULE"
+    exit 1
+fi
+
+# =============================================================================
+# Snippet
+# =============================================================================
+
+if [[ -n "$CODE_FILE" ]]; then
+    if [[ ! -f "$CODE_FILE" ]]; then
+        echo "Error: File not found: $CODE_FILE"
+        exit 1
+    fi
+    SNIPPET="$WORK/$(basename "$CODE_FILE")"
+    cp "$CODE_FILE" "$SNIPPET"
+else
+    LANG_NAME="${LANG_NAME:-$(rule_languages "$RULE_FILE" | head -n 1)}"
+    if [[ -z "$LANG_NAME" ]]; then
+        echo "Error: The rule lists no languages; pass --lang"
+        exit 1
+    fi
+    ext=$(language_extension "$LANG_NAME")
+    [[ "$ext" == "Dockerfile" ]] && SNIPPET="$WORK/Dockerfile" || SNIPPET="$WORK/snippet.$ext"
+    [[ -t 0 ]] && echo "Paste $LANG_NAME code, then Ctrl-D:" >&2
+    cat > "$SNIPPET"
+fi
+
+# =============================================================================
+# Run
+# =============================================================================
+
+RESULTS="$WORK/results.json"
+semgrep scan \
+    --config="$RULE_FILE" \
+    --metrics=off \
+    --quiet \
+    --dataflow-traces \
+    --scan-unknown-extensions \
+    --json \
+    --output="$RESULTS" \
+    "$SNIPPET" > /dev/null 2>&1 || true
+
+if [[ ! -s "$RESULTS" ]]; then
+    echo "Error: semgrep produced no output"
+    exit 1
+fi
+
+errors=$(jq -r '[.errors[]? | select(.level == "error") | .message // .long_msg // "error"] | unique | .[]' "$RESULTS")
+if [[ -n "$errors" && "$(jq '.results | length' "$RESULTS")" -eq 0 ]]; then
+    echo "Error: The rule doesn't run:"
+    sed 's/^/  /' <<< "$errors"
+    exit 1
+fi
+
+if [[ "$JSON_OUTPUT" == true ]]; then
+    jq '.' "$RESULTS"
+    exit 0
+fi
+
+count=$(jq '.results | length' "$RESULTS")
+echo "$count matches in $(wc -l < "$SNIPPET" | tr -d ' ') lines"
+echo ""
+playground_report "$RESULTS" "$SNIPPET"
@@ -0,0 +1,418 @@
+#!/usr/bin/env bash
+# Post semgrep findings introduced by a pull request as inline review comments
+#
+# Usage: ./scripts/pr-review.sh --pr <number> [options]
+#
+# Intended for CI. Only findings that are new relative to the PR base and that
+# land on lines added by the PR are posted. Each comment carries a hidden
+# fingerprint marker, so on later pushes the script edits its own comments
+# instead of adding duplicates (re-posting one whose finding moved to another
+# line), and marks findings the scan no longer reports as resolved. With
+# --fail-on, the script exits 1 when the PR introduces a finding at that
+# normalized severity or above, to fail the CI job. Findings of canary rules
+# (metadata.canary) are counted but neither posted nor failed on.
+#
+# Examples:
+#   ./scripts/pr-review.sh --pr 42                          # Run in a PR checkout
+#   ./scripts/pr-review.sh --pr 42 --dry-run                # Show planned comments
+#   ./scripts/pr-review.sh --pr 42 --results semgrep.json   # Reuse an existing scan
+#   ./scripts/pr-review.sh --pr 42 --fail-on high           # Fail CI on high or critical
+
+set -euo pipefail
+
+SCRIPT_DIR="$(cd "$(dirname "${BASH_SOURCE[0]}")" && pwd)"
+source "$SCRIPT_DIR/lib/catalog-utils.sh"
+source "$SCRIPT_DIR/lib/rule-utils.sh"
+source "$SCRIPT_DIR/lib/finding-utils.sh"
+
+# Marker embedded in every comment this script owns
+MARKER="bounty-hunter:finding"
+RESOLVED_MARKER="<!-- bounty-hunter:resolved -->"
+
+usage() {
+    cat << EOF
+Usage: $0 --pr <number> [options]
+
+Post findings introduced by a pull request as inline review comments.
+
+Options:
+    --pr <number>         Pull request number (required)
+    --gh-repo <owner/repo>  GitHub repository (default: detected by gh)
+    --repo-dir <path>     Local checkout of the PR head (default: .)
+    --base <ref>          Base ref to compare against (default: origin/<PR base branch>)
+    --results <file>      Use existing semgrep JSON instead of scanning
+                          (must be a --baseline-commit scan of the same range)
+    --no-custom-rules     Scan with p/default only
+    --fail-on <level>     Exit 1 if the PR introduces a finding at this severity or
+                          above (critical, high, medium, low, info)
+    --dry-run             Print planned comment changes without calling the API
+    -q, --quiet           Quiet mode: show final summary only
+    -h, --help            Show this help message
+
+Requires: gh (authenticated), git, jq, semgrep (unless --results is given)
+
+Examples:
+    $0 --pr 42
+    $0 --pr 42 --dry-run
+    $0 --pr 42 --gh-repo acme/api --repo-dir ./api
+    $0 --pr 42 --fail-on high
+EOF
+    exit 1
+}
+
+# Check for help flag first
+for arg in "$@"; do
+    if [[ "$arg" == "-h" || "$arg" == "--help" ]]; then
+        usage
+    fi
+done
+
+PR=""
+GH_REPO=""
+REPO_DIR="."
+BASE_REF=""
+RESULTS_FILE=""
+USE_CUSTOM_RULES=true
+FAIL_ON=""
+DRY_RUN=""
+QUIET_MODE=""
+
+while [[ $# -gt 0 ]]; do
+    case "$1" in
+        --pr)
+            PR="$2"
+            shift 2
+            ;;
+        --gh-repo)
+            GH_REPO="$2"
+            shift 2
+            ;;
+        --repo-dir)
+            REPO_DIR="$2"
+            shift 2
+            ;;
+        --base)
+            BASE_REF="$2"
+            shift 2
+            ;;
+        --results)
+            RESULTS_FILE="$2"
+            shift 2
+            ;;
+        --no-custom-rules)
+            USE_CUSTOM_RULES=false
+            shift
+            ;;
+        --fail-on)
+            FAIL_ON="$2"
+            shift 2
+            ;;
+        --dry-run)
+            DRY_RUN="1"
+            shift
+            ;;
+        -q|--quiet)
+            QUIET_MODE="1"
+            shift
+            ;;
+        *)
+            echo "Unknown option: $1"
+            usage
+            ;;
+    esac
+done
+
+export QUIET_MODE
+
+if [[ -z "$PR" ]]; then
+    echo "Error: --pr is required"
+    echo ""
+    usage
+fi
+
+if [[ ! "$PR" =~ ^[0-9]+$ ]]; then
+    echo "Error: --pr must be a pull request number (got '$PR')"
+    exit 1
+fi
+
+require_jq || exit 1
+[[ -z "$FAIL_ON" ]] || validate_severity "$FAIL_ON" --fail-on || exit 1
+
+if ! command -v gh &> /dev/null; then
+    echo "Error: gh (GitHub CLI) is required but not installed."
+    echo "Install: brew install gh"
+    exit 1
+fi
+
+if [[ -z "$RESULTS_FILE" ]] && ! command -v semgrep &> /dev/null; then
+    echo "Error: semgrep is required but not installed."
+    echo "Install: brew install semgrep"
+    exit 1
+fi
+
+if ! git -C "$REPO_DIR" rev-parse --git-dir > /dev/null 2>&1; then
+    echo "Error: '$REPO_DIR' is not a git checkout"
+    exit 1
+fi
+
+REPO_DIR="$(cd "$REPO_DIR" && pwd)"
+
+if [[ -z "$GH_REPO" ]]; then
+    GH_REPO=$(cd "$REPO_DIR" && gh repo view --json nameWithOwner -q .nameWithOwner)
+fi
+
+if [[ -z "$BASE_REF" ]]; then
+    BASE_REF="origin/$(gh pr view "$PR" --repo "$GH_REPO" --json baseRefName -q .baseRefName)"
+fi
+
+HEAD_SHA=$(git -C "$REPO_DIR" rev-parse HEAD)
+if ! BASE_SHA=$(git -C "$REPO_DIR" merge-base "$BASE_REF" HEAD 2>/dev/null); then
+    echo "Error: Cannot find merge base with '$BASE_REF'"
+    echo "Fetch the base branch first (e.g. git fetch origin <base> with full history)."
+    exit 1
+fi
+
+TMP_DIR=$(mktemp -d)
+trap 'rm -rf "$TMP_DIR"' EXIT
+
+log_header "PR Review: $GH_REPO#$PR"
+log_verbose "Base: $BASE_REF (${BASE_SHA:0:8})"
+log_verbose "Head: ${HEAD_SHA:0:8}"
+log_verbose ""
+
+# =============================================================================
+# Scan: only findings introduced since the merge base
+# =============================================================================
+
+if [[ -z "$RESULTS_FILE" ]]; then
+    RESULTS_FILE="$TMP_DIR/semgrep.json"
+
+    CUSTOM_RULE_ARGS=()
+    if [[ "$USE_CUSTOM_RULES" == true ]]; then
+        build_custom_rule_args
+    fi
+
+    log_verbose "Scanning changes with semgrep..."
+    # --autofix --dryrun populates extra.fixed_lines without touching files
+    (
+        cd "$REPO_DIR"
+        semgrep scan \
+            --config=p/default \
+            ${CUSTOM_RULE_ARGS[@]+"${CUSTOM_RULE_ARGS[@]}"} \
+            --baseline-commit="$BASE_SHA" \
+            --autofix --dryrun \
+            --metrics=off \
+            --json \
+            --output="$RESULTS_FILE" \
+            . > /dev/null 2>&1
+    ) || true
+
+    if [[ ! -s "$RESULTS_FILE" ]]; then
+        echo "Error: semgrep produced no output"
+        exit 1
+    fi
+fi
+
+# Canary rules' findings are counted but never posted or failed on
+CANARY_COUNT=0
+if [[ "$USE_CUSTOM_RULES" == true ]]; then
+    CANARY_INDEX=$(rule_canary_index)
+    if [[ -n "$CANARY_INDEX" ]]; then
+        cp "$RESULTS_FILE" "$TMP_DIR/results.json"
+        RESULTS_FILE="$TMP_DIR/results.json"
+        CANARY_COUNT=$(split_canary_findings "$RESULTS_FILE" "$CANARY_INDEX" "$TMP_DIR/canary.json")
+        [[ "$CANARY_COUNT" -gt 0 ]] && log_verbose "Canary rules: $CANARY_COUNT findings held back (not posted)"
+    fi
+fi
+
+# =============================================================================
+# Map findings onto lines added by the PR (GitHub rejects other lines)
+# =============================================================================
+
+ADDED_LINES="$TMP_DIR/added-lines"
+git -C "$REPO_DIR" diff -U0 "$BASE_SHA" HEAD | awk '
+    /^\+\+\+ / {
+        file = substr($0, 5)
+        sub(/^b\//, "", file)
+        next
+    }
+    /^@@/ {
+        match($0, /\+[0-9]+(,[0-9]+)?/)
+        n = split(substr($0, RSTART + 1, RLENGTH - 1), range, ",")
+        count = (n > 1) ? range[2] : 1
+        for (i = 0; i < count; i++) print file ":" (range[1] + i)
+    }
+' | sort -u > "$ADDED_LINES"
+
+CURRENT="$TMP_DIR/current.jsonl"
+: > "$CURRENT"
+# Fingerprints of every finding, on added lines or not: a comment is only
+# resolved when its finding is gone, not when it moved off the diff
+REPORTED="$TMP_DIR/reported"
+: > "$REPORTED"
+
+while IFS= read -r finding; do
+    path=$(jq -r '.path | sub("^\\./"; "")' <<< "$finding")
+    end_line=$(jq -r '.end.line' <<< "$finding")
+    check_id=$(jq -r '.check_id' <<< "$finding")
+    lines=$(jq -r '.extra.lines // ""' <<< "$finding")
+    fp=$(semgrep_fingerprint "$check_id" "$path" "$lines")
+    echo "$fp" >> "$REPORTED"
+
+    grep -qxF "$path:$end_line" "$ADDED_LINES" || continue
+
+    # A suggestion replaces the commented range, so it must cover the whole match
+    start_line=$(jq -r '.start.line' <<< "$finding")
+    full_range=true
+    if [[ "$start_line" != "$end_line" ]] && ! grep -qxF "$path:$start_line" "$ADDED_LINES"; then
+        full_range=false
+    fi
+
+    jq -c --arg fp "$fp" --arg path "$path" --argjson full "$full_range" "$REMEDIATION_JQ_DEFS"'{
+        fp: $fp,
+        path: $path,
+        check_id,
+        start_line: .start.line,
+        end_line: .end.line,
+        severity: .extra.severity,
+        message: .extra.message,
+        remediation: (.extra.metadata.remediation // null | remediation_guidance),
+        fixed_lines: (if $full then .extra.fixed_lines // null else null end)
+    }' <<< "$finding" >> "$CURRENT"
+done < <(jq -c '.results[]?' "$RESULTS_FILE")
+
+# Render the comment body for a current finding
+render_body() {
+    local finding="$1"
+    jq -r --arg marker "$MARKER" "$REMEDIATION_JQ_DEFS"'
+        "<!-- \($marker) \(.fp) -->\n" +
+        "**[\(.severity)] \(.check_id | split(".") | last)**\n\n" +
+        .message +
+        (if .remediation then
+            "\n\n<details><summary>How to fix</summary>\n\n" + (.remediation | remediation_markdown) + "\n\n</details>"
+         else "" end) +
+        (if .fixed_lines then
+            "\n\n```suggestion\n" + (.fixed_lines | join("\n")) + "\n```"
+         else "" end) +
+        "\n\n<sub>Rule: `\(.check_id)`</sub>"
+    ' <<< "$finding"
+}
+
+# =============================================================================
+# Reconcile with comments already posted by this script
+# =============================================================================
+
+EXISTING="$TMP_DIR/existing.json"
+if [[ -n "$DRY_RUN" ]] && ! gh auth status > /dev/null 2>&1; then
+    echo "[]" > "$EXISTING"
+else
+    gh api --paginate "repos/$GH_REPO/pulls/$PR/comments" | \
+        jq -s --arg marker "$MARKER" '
+            add // [] | map(select(.body | contains("<!-- " + $marker + " ")))
+            | map({id, body, path, line, fp: (.body | capture("<!-- " + $marker + " (?<fp>[0-9a-f]+) -->").fp)})
+        ' > "$EXISTING"
+fi
+
+created=0
+updated=0
+resolved=0
+unchanged=0
+
+api_call() {
+    if [[ -n "$DRY_RUN" ]]; then
+        return 0
+    fi
+    gh api "$@" > /dev/null
+}
+
+# Post a comment on a current finding's lines
+# Args: $1 = finding (from CURRENT), $2 = body
+post_comment() {
+    local finding="$1"
+    local body="$2"
+    local path start_line end_line
+    local range_args=()
+
+    path=$(jq -r '.path' <<< "$finding")
+    start_line=$(jq -r '.start_line' <<< "$finding")
+    end_line=$(jq -r '.end_line' <<< "$finding")
+    if [[ "$start_line" != "$end_line" ]] && grep -qxF "$path:$start_line" "$ADDED_LINES"; then
+        range_args=(-F start_line="$start_line" -f start_side=RIGHT)
+    fi
+    api_call -X POST "repos/$GH_REPO/pulls/$PR/comments" \
+        -f body="$body" \
+        -f commit_id="$HEAD_SHA" \
+        -f path="$path" \
+        -F line="$end_line" \
+        -f side=RIGHT \
+        ${range_args[@]+"${range_args[@]}"}
+}
+
+while IFS= read -r finding; do
+    [[ -z "$finding" ]] && continue
+    fp=$(jq -r '.fp' <<< "$finding")
+    body=$(render_body "$finding")
+    existing=$(jq -c --arg fp "$fp" 'map(select(.fp == $fp)) | first // empty' "$EXISTING")
+    where=$(jq -r '"\(.path):\(.end_line) \(.check_id)"' <<< "$finding")
+
+    if [[ -n "$existing" ]]; then
+        existing_id=$(jq -r '.id' <<< "$existing")
+        # A comment can't be moved: when the finding's file or line changed,
+        # replace the comment so it sits on the code it describes
+        if ! jq -e --argjson finding "$finding" '.path == $finding.path and .line == $finding.end_line' \
+            <<< "$existing" > /dev/null; then
+            log_verbose "MOVE    #$existing_id $where"
+            api_call -X DELETE "repos/$GH_REPO/pulls/comments/$existing_id"
+            post_comment "$finding" "$body"
+            updated=$((updated + 1))
+        elif [[ "$(jq -r '.body' <<< "$existing")" == "$body" ]]; then
+            unchanged=$((unchanged + 1))
+        else
+            log_verbose "UPDATE  #$existing_id $where"
+            api_call -X PATCH "repos/$GH_REPO/pulls/comments/$existing_id" -f body="$body"
+            updated=$((updated + 1))
+        fi
+    else
+        log_verbose "CREATE  $where"
+        post_comment "$finding" "$body"
+        created=$((created + 1))
+    fi
+done < "$CURRENT"
+
+# Comments whose finding is no longer reported anywhere: edit to a
+# resolved note (once)
+while IFS= read -r comment; do
+    [[ -z "$comment" ]] && continue
+    fp=$(jq -r '.fp' <<< "$comment")
+    grep -qxF "$fp" "$REPORTED" && continue
+
+    id=$(jq -r '.id' <<< "$comment")
+    body=$(jq -r '.body' <<< "$comment")
+    [[ "$body" == *"$RESOLVED_MARKER"* ]] && continue
+
+    title=$(sed -n '2p' <<< "$body")
+    log_verbose "RESOLVE #$id $title"
+    api_call -X PATCH "repos/$GH_REPO/pulls/comments/$id" \
+        -f body="<!-- $MARKER $fp -->
+$RESOLVED_MARKER
+~~${title}~~
+
+Resolved: no longer reported at ${HEAD_SHA:0:8}."
+    resolved=$((resolved + 1))
+done < <(jq -c '.[]' "$EXISTING")
+
+log_verbose ""
+if [[ -n "$DRY_RUN" ]]; then
+    echo "PR review (dry run): $created new, $updated updated, $resolved resolved, $unchanged unchanged"
+else
+    echo "PR review: $created new, $updated updated, $resolved resolved, $unchanged unchanged"
+fi
+
+if [[ -n "$FAIL_ON" ]]; then
+    failing=$(jq -s --arg min "$FAIL_ON" "$SEVERITY_JQ_DEFS"'
+        [.[] | select(.severity | severity_level("semgrep") | severity_at_least($min))] | length' "$CURRENT")
+    if [[ "$failing" -gt 0 ]]; then
+        echo "Failing: $failing introduced findings at $FAIL_ON severity or above (--fail-on $FAIL_ON)"
+        exit 1
+    fi
+fi
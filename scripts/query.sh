@@ -0,0 +1,592 @@
+#!/usr/bin/env bash
+# Run ad-hoc semgrep pattern and taint queries across an org's repos
+#
+# Usage: ./scripts/query.sh <org> [repo] [query options]
+#
+# For exploratory auditing: try a pattern, a regex, or a source/sink pair
+# without writing a rule file. With a query option it runs once; without
+# one it starts a query console that keeps the language, repo, and taint
+# spec between queries (type :help at the prompt).
+#
+# Queries worth keeping are saved with notes into hunts (hunts/<name>.json),
+# which can be re-run against any org or shared as plain files. Console
+# sessions, with notes and match dispositions, are logged for
+# session-report.sh.
+#
+# Examples:
+#   ./scripts/query.sh acme-corp                                   # Console
+#   ./scripts/query.sh acme-corp api -e 'yaml.load(...)' --lang python
+#   ./scripts/query.sh acme-corp --source 'request.args.get(...)' --sink 'cur.execute(...)' --lang python
+#   ./scripts/query.sh other-corp --hunt yaml-deser                # Re-run a saved hunt
+
+set -euo pipefail
+
+SCRIPT_DIR="$(cd "$(dirname "${BASH_SOURCE[0]}")" && pwd)"
+source "$SCRIPT_DIR/lib/catalog-utils.sh"
+source "$SCRIPT_DIR/lib/finding-utils.sh"
+source "$SCRIPT_DIR/lib/query-utils.sh"
+
+usage() {
+    cat << EOF
+Usage: $0 <org> [repo] [query options]
+       $0 <org> [repo] --hunt <hunt>
+       $0 --hunts
+
+Run an ad-hoc semgrep query over an org's repos, once or from a console.
+
+Query options (none starts the console):
+    -e, --pattern <p>   Semgrep pattern to search for
+    --regex <re>        Regex to search for
+    --source <p>        Taint source pattern (repeatable)
+    --sink <p>          Taint sink pattern (repeatable; makes a taint query)
+    --sanitizer <p>     Taint sanitizer pattern (repeatable)
+
+Hunts:
+    --save <hunt>       Save the query to a hunt (created if needed)
+    --note <text>       Note to save with the query
+    --hunt <hunt>       Run every query saved in a hunt
+    --hunts             List saved hunts
+
+A hunt is a name (saved as hunts/<name>.json) or a path to a hunt file.
+
+Sessions:
+    --session <id>      Log runs to this session (the console starts a new
+                        one by default; see session-report.sh)
+
+Options:
+    --lang <lang>       Query language (default: the repos' only language)
+    --limit <n>         Matches to print (default: 50, 0 = all)
+    --format <fmt>      summary (default) or json (one-shot only)
+    -h, --help          Show this help message
+
+Examples:
+    $0 acme-corp
+    $0 acme-corp api -e 'subprocess.run(..., shell=True, ...)' --lang python
+    $0 acme-corp --regex 'AKIA[0-9A-Z]{16}'
+    $0 acme-corp -e 'yaml.load(...)' --save yaml-deser --note "Check Loader= args"
+    $0 other-corp --hunt yaml-deser
+EOF
+    exit 1
+}
+
+ORG=""
+ONLY_REPO=""
+QUERY_LANG=""
+PATTERN=""
+REGEX=""
+SOURCES=()
+SINKS=()
+SANITIZERS=()
+SAVE_HUNT=""
+NOTE=""
+RUN_HUNT=""
+LIST_HUNTS=false
+SESSION_ID=""
+QUERY_NOTE=""
+LIMIT=50
+FORMAT="summary"
+
+while [[ $# -gt 0 ]]; do
+    case "$1" in
+        -e|--pattern)
+            PATTERN="$2"
+            shift 2
+            ;;
+        --regex)
+            REGEX="$2"
+            shift 2
+            ;;
+        --source)
+            SOURCES+=("$2")
+            shift 2
+            ;;
+        --sink)
+            SINKS+=("$2")
+            shift 2
+            ;;
+        --sanitizer)
+            SANITIZERS+=("$2")
+            shift 2
+            ;;
+        --save)
+            SAVE_HUNT="$2"
+            shift 2
+            ;;
+        --note)
+            NOTE="$2"
+            shift 2
+            ;;
+        --hunt)
+            RUN_HUNT="$2"
+            shift 2
+            ;;
+        --hunts)
+            LIST_HUNTS=true
+            shift
+            ;;
+        --session)
+            SESSION_ID="$2"
+            shift 2
+            ;;
+        --lang)
+            QUERY_LANG="$2"
+            shift 2
+            ;;
+        --limit)
+            LIMIT="$2"
+            shift 2
+            ;;
+        --format)
+            FORMAT="$2"
+            shift 2
+            ;;
+        -h|--help)
+            usage
+            ;;
+        -*)
+            echo "Unknown option: $1"
+            usage
+            ;;
+        *)
+            if [[ -z "$ORG" ]]; then
+                ORG="$1"
+            elif [[ -z "$ONLY_REPO" ]]; then
+                ONLY_REPO="$1"
+            else
+                echo "Unexpected argument: $1"
+                usage
+            fi
+            shift
+            ;;
+    esac
+done
+
+if [[ "$LIST_HUNTS" == true ]]; then
+    require_jq || exit 1
+    hunts=$(list_hunts)
+    if [[ -z "$hunts" ]]; then
+        echo "No saved hunts in $HUNTS_DIR"
+        echo "Save one with: $0 <org> -e '<pattern>' --save <hunt>"
+        exit 0
+    fi
+    printf "%-30s  %7s  %s\n" "HUNT" "QUERIES" "DESCRIPTION"
+    while IFS=$'\t' read -r name count description; do
+        printf "%-30s  %7s  %s\n" "$name" "$count" "$description"
+    done <<< "$hunts"
+    exit 0
+fi
+
+[[ -z "$ORG" ]] && usage
+
+case "$FORMAT" in
+    summary|json) ;;
+    *)
+        echo "Error: Unknown format '$FORMAT' (use summary or json)"
+        exit 1
+        ;;
+esac
+
+if [[ ! "$LIMIT" =~ ^[0-9]+$ ]]; then
+    echo "Error: --limit must be a number"
+    exit 1
+fi
+if [[ -n "$RUN_HUNT" && ! -f "$(hunt_file "$RUN_HUNT")" ]]; then
+    echo "Error: Hunt not found: $(hunt_file "$RUN_HUNT")"
+    echo "List hunts with: $0 --hunts"
+    exit 1
+fi
+
+validate_org_name "$ORG" || exit 1
+require_jq || exit 1
+
+if ! command -v semgrep &> /dev/null; then
+    echo "Error: semgrep is required but not installed."
+    echo "Install with: brew install semgrep"
+    exit 1
+fi
+
+REPOS_DIR="$(get_org_repos_dir "$ORG")"
+
+if [[ ! -d "$REPOS_DIR" ]]; then
+    echo "Error: No repos found at $REPOS_DIR"
+    echo "Run: ./scripts/clone-org-repos.sh $ORG"
+    exit 1
+fi
+
+if [[ -n "$SESSION_ID" && ! "$SESSION_ID" =~ ^[A-Za-z0-9._-]+$ ]]; then
+    echo "Error: Session ids may only contain letters, numbers, '.', '_', and '-'"
+    exit 1
+fi
+SESSION_FILE=""
+[[ -n "$SESSION_ID" ]] && SESSION_FILE=$(session_file "$ORG" "$SESSION_ID")
+
+TMP_DIR=$(mktemp -d)
+register_cleanup "$TMP_DIR"
+install_cancel_traps
+
+# =============================================================================
+# Query Functions
+# =============================================================================
+
+# Repos the next query runs over (ONLY_REPO or every active repo)
+query_targets() {
+    local repo
+
+    while IFS= read -r repo; do
+        [[ -z "$repo" ]] && continue
+        [[ -n "$ONLY_REPO" && "$(basename "$repo")" != "$ONLY_REPO" ]] && continue
+        echo "$repo"
+    done < <(get_active_repos "$REPOS_DIR")
+}
+
+# Query JSON for a pattern or regex
+# Args: $1 = pattern or regex, $2 = value
+simple_query() {
+    jq -n --arg kind "$1" --arg value "$2" --arg lang "$QUERY_LANG" \
+        '{($kind): $value} + (if $lang != "" then {lang: $lang} else {} end)'
+}
+
+# Query JSON for the taint sources, sinks, and sanitizers collected so far
+taint_query() {
+    local sources sinks sanitizers
+
+    sources=$(printf '%s\0' ${SOURCES[@]+"${SOURCES[@]}"} | jq -R -s 'split("\u0000") | map(select(. != ""))')
+    sinks=$(printf '%s\0' ${SINKS[@]+"${SINKS[@]}"} | jq -R -s 'split("\u0000") | map(select(. != ""))')
+    sanitizers=$(printf '%s\0' ${SANITIZERS[@]+"${SANITIZERS[@]}"} | jq -R -s 'split("\u0000") | map(select(. != ""))')
+    jq -n --arg lang "$QUERY_LANG" --argjson sources "$sources" --argjson sinks "$sinks" --argjson sanitizers "$sanitizers" \
+        '{sources: $sources, sinks: $sinks, sanitizers: $sanitizers} + (if $lang != "" then {lang: $lang} else {} end)'
+}
+
+# The targets' only language, for queries that don't name one
+# Returns 1 if that's ambiguous
+default_language() {
+    local languages
+
+    languages=$(query_targets | while IFS= read -r repo; do detect_repo_languages "$repo"; done | sort -u)
+    if [[ $(grep -c . <<< "$languages") -eq 1 ]]; then
+        echo "$languages"
+        return 0
+    fi
+    echo "Set a language (--lang, or :lang in the console); repos contain: $(paste -sd' ' - <<< "$languages")" >&2
+    return 1
+}
+
+# Log a query run and its matches (with permalinks) to the session
+# Args: $1 = query JSON, $2 = matches TSV (repo, path, line, code)
+record_query_run() {
+    local query="$1"
+    local matches="$2"
+    local repo sha template
+
+    : > "$TMP_DIR/links.tsv"
+    for repo in $(cut -f1 "$matches" | sort -u); do
+        sha=$(git -C "$REPOS_DIR/$repo" rev-parse HEAD 2>/dev/null || echo "")
+        template=$(link_template "$REPOS_DIR/$repo" "$ORG" line 2>/dev/null || echo "")
+        [[ -n "$sha" ]] && template="${template//\{sha\}/$sha}"
+        printf '%s\t%s\n' "$repo" "$template" >> "$TMP_DIR/links.tsv"
+    done
+
+    session_record "$SESSION_FILE" query "$(jq -R -s -c --argjson query "$query" --arg note "$QUERY_NOTE" \
+        --rawfile links "$TMP_DIR/links.tsv" '
+        ($links | split("\n") | map(select(. != "") | split("\t") | {key: .[0], value: (.[1] // "")})
+            | from_entries) as $l
+        | {query: $query, note: $note,
+           matches: (split("\n") | map(select(. != "") | split("\t")
+               | {repo: .[0], path: .[1], line: (.[2] | tonumber), code: (.[3] // "")}
+               | (.path) as $p | (.line | tostring) as $n
+               | . + {url: ($l[.repo] // "" | if . == "" or test("\\{sha\\}") then null
+                            else gsub("\\{path\\}"; $p) | gsub("\\{line\\}"; $n) end)}))}
+    ' "$matches")"
+}
+
+# Run a query and print its matches, numbered for :mark
+# Args: $1 = query JSON, $2 = output format (summary or json)
+# Sets: LAST_QUERY (the query as run, with its language)
+run_adhoc_query() {
+    local query="$1"
+    local format="${2:-summary}"
+    local targets=() repo lang total
+
+    if [[ -z "$(query_kind "$query")" ]]; then
+        echo "Nothing to run: give a pattern, a regex, or at least one sink"
+        return 1
+    fi
+    if [[ "$(query_kind "$query")" == "taint" && "$(jq '(.sources // []) | length' <<< "$query")" -eq 0 ]]; then
+        echo "A taint query needs at least one source"
+        return 1
+    fi
+    if [[ "$(jq -r '.lang // ""' <<< "$query")" == "" && "$(query_kind "$query")" != "regex" ]]; then
+        lang=$(default_language) || return 1
+        query=$(jq --arg lang "$lang" '. + {lang: $lang}' <<< "$query")
+    fi
+    LAST_QUERY="$query"
+
+    while IFS= read -r repo; do
+        targets+=("$repo")
+    done < <(query_targets)
+    if [[ ${#targets[@]} -eq 0 ]]; then
+        echo "No active repos to query${ONLY_REPO:+ (repo: $ONLY_REPO)}"
+        return 1
+    fi
+
+    if ! run_query "$query" "$TMP_DIR/last.json" "${targets[@]}"; then
+        echo "Query failed: $(query_summary "$query")"
+        return 1
+    fi
+
+    query_matches "$TMP_DIR/last.json" "$REPOS_DIR" > "$TMP_DIR/last.tsv"
+    [[ -n "$SESSION_FILE" ]] && record_query_run "$query" "$TMP_DIR/last.tsv"
+    if [[ "$format" == "json" ]]; then
+        jq -R -s --argjson query "$query" '{query: $query, matches: (split("\n") | map(select(. != "") | split("\t")
+            | {repo: .[0], path: .[1], line: (.[2] | tonumber), code: (.[3] // "")}))}' "$TMP_DIR/last.tsv"
+        return 0
+    fi
+
+    total=$(grep -c . "$TMP_DIR/last.tsv" || true)
+    if [[ "$LIMIT" -gt 0 ]]; then
+        head -n "$LIMIT" "$TMP_DIR/last.tsv"
+    else
+        cat "$TMP_DIR/last.tsv"
+    fi | awk -F'\t' '{ printf "  %3d  %-50s  %s\n", NR, $1 "/" $2 ":" $3, substr($4, 1, 100) }'
+    if [[ "$LIMIT" -gt 0 && "$total" -gt "$LIMIT" ]]; then
+        echo "  ... $((total - LIMIT)) more (--limit 0 for all)"
+    fi
+    echo "$total matches in ${#targets[@]} repos: $(query_summary "$query")"
+}
+
+# Run every query in a hunt
+# Args: $1 = hunt file, $2 = output format (summary or json)
+run_hunt() {
+    local file="$1"
+    local format="${2:-summary}"
+    local entry note n=0 runs=()
+
+    if [[ "$format" == "summary" ]]; then
+        jq -r '"Hunt: \(.name)\(if .description != "" then " - " + .description else "" end) (\(.queries | length) queries)"' "$file"
+    fi
+    while IFS= read -r entry; do
+        n=$((n + 1))
+        note=$(jq -r '.note' <<< "$entry")
+        QUERY_NOTE="$note"
+        if [[ "$format" == "json" ]]; then
+            run_adhoc_query "$(jq -c '.query' <<< "$entry")" json > "$TMP_DIR/hunt-$n.json" || continue
+            runs+=("$TMP_DIR/hunt-$n.json")
+            jq --arg note "$note" '. + {note: $note}' "$TMP_DIR/hunt-$n.json" > "$TMP_DIR/hunt-$n.tmp"
+            mv "$TMP_DIR/hunt-$n.tmp" "$TMP_DIR/hunt-$n.json"
+        else
+            echo ""
+            echo "[$n] ${note:-$(query_summary "$(jq -c '.query' <<< "$entry")")}"
+            run_adhoc_query "$(jq -c '.query' <<< "$entry")" || true
+        fi
+    done < <(jq -c '.queries[]' "$file")
+    QUERY_NOTE=""
+
+    if [[ "$format" == "json" ]]; then
+        jq -s --slurpfile hunt "$file" '{hunt: $hunt[0].name, runs: .}' ${runs[@]+"${runs[@]}"} < /dev/null
+    fi
+}
+
+# =============================================================================
+# One-shot
+# =============================================================================
+
+if [[ -n "$RUN_HUNT" ]]; then
+    run_hunt "$(hunt_file "$RUN_HUNT")" "$FORMAT"
+    exit 0
+fi
+
+ONE_SHOT=""
+if [[ -n "$PATTERN" ]]; then
+    ONE_SHOT=$(simple_query pattern "$PATTERN")
+elif [[ -n "$REGEX" ]]; then
+    ONE_SHOT=$(simple_query regex "$REGEX")
+elif [[ ${#SINKS[@]} -gt 0 || ${#SOURCES[@]} -gt 0 ]]; then
+    ONE_SHOT=$(taint_query)
+fi
+
+if [[ -n "$ONE_SHOT" ]]; then
+    run_adhoc_query "$ONE_SHOT" "$FORMAT"
+    if [[ -n "$SAVE_HUNT" ]]; then
+        hunt_add_query "$(hunt_file "$SAVE_HUNT")" "$LAST_QUERY" "$NOTE"
+        [[ "$FORMAT" == "summary" ]] && echo "Saved to $(hunt_file "$SAVE_HUNT")"
+    fi
+    exit 0
+fi
+
+# =============================================================================
+# Console
+# =============================================================================
+
+console_help() {
+    cat << EOF
+  <pattern>           Search for a semgrep pattern (end a line with \\ to continue it)
+  :regex <re>         Search for a regex
+  :source <pattern>   Add a taint source
+  :sink <pattern>     Add a taint sink
+  :sanitizer <pat>    Add a taint sanitizer
+  :taint              Run the taint query built from sources, sinks, and sanitizers
+  :clear              Forget the taint sources, sinks, and sanitizers
+  :lang <lang>        Set the query language
+  :repo <name|all>    Query one repo or every active repo
+  :show               Print the rule YAML of the last query
+  :save <hunt> [note] Save the last query to a hunt, with a note
+  :describe <hunt> <t> Set a hunt's description
+  :run <hunt>         Run every query saved in a hunt
+  :hunts              List saved hunts
+  :note <text>        Add a note to the session
+  :mark <n> <status> [note]
+                      Mark match n of the last query as confirmed,
+                      false-positive, or needs-review
+  :help               Show this help
+  :quit               Leave the console
+EOF
+}
+
+if [[ -z "$SESSION_FILE" ]]; then
+    SESSION_ID=$(date -u +%Y%m%d-%H%M%S)
+    SESSION_FILE=$(session_file "$ORG" "$SESSION_ID")
+fi
+
+PROMPT=""
+[[ -t 0 ]] && PROMPT="query> "
+echo "Query console for $ORG (:help for commands, :quit to leave)"
+echo "Session: $SESSION_ID"
+
+while true; do
+    IFS= read -r -e -p "$PROMPT" input || break
+    while [[ "$input" == *'\' ]]; do
+        IFS= read -r -e -p "${PROMPT:+...> }" more || break
+        input="${input%\\}"$'\n'"$more"
+    done
+    [[ -z "${input// /}" ]] && continue
+
+    command="${input%% *}"
+    argument=""
+    [[ "$input" == *" "* ]] && argument="${input#* }"
+
+    case "$command" in
+        :quit|:q|:exit)
+            break
+            ;;
+        :help|:h)
+            console_help
+            ;;
+        :lang)
+            QUERY_LANG="$argument"
+            echo "Language: ${QUERY_LANG:-(auto)}"
+            ;;
+        :repo)
+            if [[ -z "$argument" || "$argument" == "all" ]]; then
+                ONLY_REPO=""
+                echo "Repos: all active"
+            elif [[ -d "$REPOS_DIR/$argument" ]]; then
+                ONLY_REPO="$argument"
+                echo "Repos: $ONLY_REPO"
+            else
+                echo "Repo not found: $REPOS_DIR/$argument"
+            fi
+            ;;
+        :source|:sink|:sanitizer)
+            if [[ -z "$argument" ]]; then
+                echo "Usage: $command <pattern>"
+            else
+                case "$command" in
+                    :source) SOURCES+=("$argument") ;;
+                    :sink) SINKS+=("$argument") ;;
+                    :sanitizer) SANITIZERS+=("$argument") ;;
+                esac
+                echo "Taint: ${#SOURCES[@]} sources, ${#SINKS[@]} sinks, ${#SANITIZERS[@]} sanitizers"
+            fi
+            ;;
+        :clear)
+            SOURCES=()
+            SINKS=()
+            SANITIZERS=()
+            echo "Taint spec cleared"
+            ;;
+        :taint)
+            run_adhoc_query "$(taint_query)" || true
+            ;;
+        :regex)
+            run_adhoc_query "$(simple_query regex "$argument")" || true
+            ;;
+        :show)
+            if [[ -z "${LAST_QUERY:-}" ]]; then
+                echo "No query run yet"
+            else
+                query_rule_yaml "$LAST_QUERY"
+            fi
+            ;;
+        :save)
+            if [[ -z "${LAST_QUERY:-}" ]]; then
+                echo "No query run yet"
+            elif [[ -z "$argument" ]]; then
+                echo "Usage: :save <hunt> [note]"
+            else
+                hunt="${argument%% *}"
+                note=""
+                [[ "$argument" == *" "* ]] && note="${argument#* }"
+                hunt_add_query "$(hunt_file "$hunt")" "$LAST_QUERY" "$note"
+                echo "Saved to $(hunt_file "$hunt")"
+            fi
+            ;;
+        :describe)
+            hunt="${argument%% *}"
+            if [[ -z "$hunt" || "$argument" != *" "* ]]; then
+                echo "Usage: :describe <hunt> <text>"
+            elif [[ ! -f "$(hunt_file "$hunt")" ]]; then
+                echo "Hunt not found: $(hunt_file "$hunt")"
+            else
+                hunt_describe "$(hunt_file "$hunt")" "${argument#* }"
+                echo "Described $hunt"
+            fi
+            ;;
+        :run)
+            if [[ -z "$argument" || ! -f "$(hunt_file "$argument")" ]]; then
+                echo "Hunt not found: $(hunt_file "${argument:-<hunt>}")"
+            else
+                run_hunt "$(hunt_file "$argument")"
+            fi
+            ;;
+        :note)
+            if [[ -z "$argument" ]]; then
+                echo "Usage: :note <text>"
+            else
+                session_record "$SESSION_FILE" note "$(jq -n -c --arg t "$argument" '{text: $t}')"
+                echo "Noted"
+            fi
+            ;;
+        :mark)
+            read -r index status note <<< "$argument" || true
+            match=""
+            if [[ "$index" =~ ^[0-9]+$ && -s "$TMP_DIR/last.tsv" ]]; then
+                match=$(sed -n "${index}p" "$TMP_DIR/last.tsv")
+            fi
+            if [[ -z "$match" ]]; then
+                echo "Usage: :mark <n> <status> [note] (n = a match number from the last query)"
+            elif [[ " $QUERY_DISPOSITIONS " != *" ${status:-none} "* ]]; then
+                echo "Unknown status '${status:-}' (use: $QUERY_DISPOSITIONS)"
+            else
+                session_record "$SESSION_FILE" disposition "$(jq -R -c --arg s "$status" --arg n "${note:-}" \
+                    'split("\t") | {match: {repo: .[0], path: .[1], line: (.[2] | tonumber)}, status: $s, note: $n}' <<< "$match")"
+                echo "Marked $(cut -f1-3 <<< "$match" | awk -F'\t' '{ print $1 "/" $2 ":" $3 }') $status"
+            fi
+            ;;
+        :hunts)
+            list_hunts | while IFS=$'\t' read -r name count description; do
+                printf "  %-30s  %3s queries  %s\n" "$name" "$count" "$description"
+            done
+            ;;
+        :*)
+            echo "Unknown command: $command (:help for commands)"
+            ;;
+        *)
+            run_adhoc_query "$(simple_query pattern "$input")" || true
+            ;;
+    esac
+done
+
+if [[ -s "$SESSION_FILE" ]]; then
+    echo "Session saved: $SESSION_FILE"
+    echo "Report: ./scripts/session-report.sh $ORG $SESSION_ID"
+fi
@@ -0,0 +1,257 @@
+#!/usr/bin/env bash
+# Scan several releases of a repo and report which ones each finding affects
+#
+# Usage: ./scripts/release-scan.sh <org> <repo> [options]
+#
+# Each selected tag is checked out in a throwaway worktree and scanned with
+# scan-semgrep.sh. Findings are matched across releases by fingerprint (rule,
+# path, and matched code, so they survive line moves) and reported with the
+# release they were introduced in and the one that fixed them - the
+# affected-version range maintainers ask for in a report.
+#
+# Examples:
+#   ./scripts/release-scan.sh acme-corp api                    # Last 5 tags
+#   ./scripts/release-scan.sh acme-corp api --tags 'v2.*' --last 0
+#   ./scripts/release-scan.sh acme-corp api --finding 3f9a1c2b  # One finding's range
+
+set -euo pipefail
+
+SCRIPT_DIR="$(cd "$(dirname "${BASH_SOURCE[0]}")" && pwd)"
+source "$SCRIPT_DIR/lib/catalog-utils.sh"
+source "$SCRIPT_DIR/lib/finding-utils.sh"
+source "$SCRIPT_DIR/lib/history-utils.sh"
+
+usage() {
+    cat << EOF
+Usage: $0 <org> <repo> [options]
+
+Scan tagged releases of a repo and report the affected-version range of
+each finding.
+
+Options:
+    --tags <glob>       Only tags matching this glob (e.g. 'v*', 'release-2.*')
+    --last <n>          Newest n matching tags (default: $RELEASE_SCAN_LAST, 0 = all)
+    --no-head           Don't include the current checkout as the newest release
+    --profile <name>    scan-semgrep.sh profile (default: its own default)
+    --rescan            Rescan releases that already have results
+    --finding <id>      Only report this fingerprint (a unique prefix is enough)
+    --format <fmt>      summary (default) or json
+    -h, --help          Show this help message
+
+Per-release results are kept in scans/<org>/releases/<repo>/<tag>/ and
+reused on later runs. The report is written to
+scans/<org>/releases/<repo>/affected-versions.json.
+
+A finding already present in the oldest scanned release is reported as
+introduced in "<=<tag>": scan older tags to pin it down.
+
+Examples:
+    $0 acme-corp api
+    $0 acme-corp api --tags 'v2.*' --last 0
+EOF
+    exit 1
+}
+
+ORG=""
+REPO_NAME=""
+TAG_GLOB=""
+LAST="$RELEASE_SCAN_LAST"
+INCLUDE_HEAD=true
+PROFILE=""
+RESCAN=false
+FINDING=""
+FORMAT="summary"
+
+while [[ $# -gt 0 ]]; do
+    case "$1" in
+        --tags)
+            TAG_GLOB="$2"
+            shift 2
+            ;;
+        --last)
+            LAST="$2"
+            shift 2
+            ;;
+        --no-head)
+            INCLUDE_HEAD=false
+            shift
+            ;;
+        --profile)
+            PROFILE="$2"
+            shift 2
+            ;;
+        --rescan)
+            RESCAN=true
+            shift
+            ;;
+        --finding)
+            FINDING="$2"
+            shift 2
+            ;;
+        --format)
+            FORMAT="$2"
+            shift 2
+            ;;
+        -h|--help)
+            usage
+            ;;
+        -*)
+            echo "Unknown option: $1"
+            usage
+            ;;
+        *)
+            if [[ -z "$ORG" ]]; then
+                ORG="$1"
+            elif [[ -z "$REPO_NAME" ]]; then
+                REPO_NAME="$1"
+            else
+                echo "Unexpected argument: $1"
+                usage
+            fi
+            shift
+            ;;
+    esac
+done
+
+[[ -z "$ORG" || -z "$REPO_NAME" ]] && usage
+
+case "$FORMAT" in
+    summary|json) ;;
+    *)
+        echo "Error: Unknown format '$FORMAT' (use summary or json)"
+        exit 1
+        ;;
+esac
+
+if [[ ! "$LAST" =~ ^[0-9]+$ ]]; then
+    echo "Error: --last must be a number"
+    exit 1
+fi
+
+validate_org_name "$ORG" || exit 1
+require_jq || exit 1
+
+REPO="$(get_org_repos_dir "$ORG")/$REPO_NAME"
+RELEASES_DIR="scans/$ORG/releases/$REPO_NAME"
+OUTPUT_FILE="$RELEASES_DIR/affected-versions.json"
+
+if [[ ! -d "$REPO" ]]; then
+    echo "Error: Repo not found: $REPO"
+    echo "Run: ./scripts/clone-org-repos.sh $ORG"
+    exit 1
+fi
+
+RELEASES=()
+while IFS= read -r tag; do
+    [[ -n "$tag" ]] && RELEASES+=("$tag")
+done < <(release_tags "$REPO" "$TAG_GLOB" "$LAST")
+[[ "$INCLUDE_HEAD" == true ]] && RELEASES+=("HEAD")
+
+if [[ ${#RELEASES[@]} -lt 2 ]]; then
+    echo "Error: Need at least two releases to compare; found ${#RELEASES[@]}${TAG_GLOB:+ matching '$TAG_GLOB'}"
+    if [[ "$(git -C "$REPO" rev-parse --is-shallow-repository 2>/dev/null)" == "true" ]]; then
+        echo "This is a shallow clone; fetch tags with: git -C $REPO fetch --tags --unshallow"
+    fi
+    exit 1
+fi
+
+TMP_DIR=$(mktemp -d)
+register_cleanup "$TMP_DIR"
+install_cancel_traps
+
+# =============================================================================
+# Scanning
+# =============================================================================
+
+PRESENCE="$TMP_DIR/presence.tsv"
+: > "$PRESENCE"
+printf '%s\n' "${RELEASES[@]}" > "$TMP_DIR/releases.txt"
+
+for release in "${RELEASES[@]}"; do
+    out_dir="$RELEASES_DIR/$(release_dir_name "$release")"
+    results="$out_dir/semgrep-results/$REPO_NAME.json.gz"
+    worktree_root="$TMP_DIR/worktrees/$(release_dir_name "$release")"
+    worktree="$worktree_root/$REPO_NAME"
+
+    # HEAD moves, so its results are never reused
+    if [[ "$release" == "HEAD" || "$RESCAN" == true || ! -f "$results" ]]; then
+        [[ "$FORMAT" == "summary" ]] && echo "Scanning $REPO_NAME at $release..."
+        mkdir -p "$worktree_root"
+        if ! git -C "$REPO" worktree add --detach "$worktree" "$release" > /dev/null 2>&1; then
+            echo "Warning: Could not check out $release; skipping" >&2
+            continue
+        fi
+        "$SCRIPT_DIR/scan-semgrep.sh" "$ORG" --repos-dir "$worktree_root" --output-dir "$out_dir" \
+            ${PROFILE:+--profile "$PROFILE"} -q > "$TMP_DIR/scan.log" 2>&1 || true
+        if [[ ! -f "$results" ]]; then
+            echo "Warning: No results for $release (see scan-semgrep.sh output below); skipping" >&2
+            tail -n 5 "$TMP_DIR/scan.log" >&2
+            git -C "$REPO" worktree remove --force "$worktree" > /dev/null 2>&1 || true
+            continue
+        fi
+    fi
+
+    # Fingerprints use the matched code; read it from the checkout when
+    # semgrep withheld it (no login)
+    gzip -dc "$results" | jq -r --arg marker "/$REPO_NAME/" '
+        .results[]?
+        | (.path | if index($marker) then .[(index($marker) + ($marker | length)):] else ltrimstr("./") end) as $rel
+        | [$rel, .start.line, .end.line, .check_id, (.extra.severity // "INFO"),
+           ((.extra.message // "") | gsub("[\t\n]"; " ") | .[0:200]), ((.extra.lines // "") | gsub("[\t\n]"; " "))]
+        | @tsv
+    ' | while IFS=$'\t' read -r rel line end_line check_id severity message lines; do
+        if [[ -z "$lines" || "$lines" == "requires login" ]]; then
+            if [[ -d "$worktree" ]]; then
+                lines=$(sed -n "${line},${end_line}p" "$worktree/$rel" 2>/dev/null | tr '\n' ' ')
+            else
+                lines=$(git -C "$REPO" show "$release:$rel" 2>/dev/null | sed -n "${line},${end_line}p" | tr '\n' ' ')
+            fi
+        fi
+        printf '%s\t%s\t%s\t%s\t%s\t%s\t%s\n' "$release" \
+            "$(semgrep_fingerprint "$check_id" "$REPO_NAME/$rel" "$lines")" "$check_id" "$rel" "$line" "$severity" "$message"
+    done >> "$PRESENCE"
+
+    if [[ -d "$worktree" ]]; then
+        git -C "$REPO" worktree remove --force "$worktree" > /dev/null 2>&1 || true
+    fi
+done
+
+mkdir -p "$RELEASES_DIR"
+affected_versions "$TMP_DIR/releases.txt" "$PRESENCE" | \
+    jq --argjson releases "$(jq -R . "$TMP_DIR/releases.txt" | jq -s .)" --arg repo "$REPO_NAME" \
+        '{repo: $repo, releases: $releases, findings: .}' > "$OUTPUT_FILE"
+
+# =============================================================================
+# Output
+# =============================================================================
+
+REPORT=$(jq --arg id "$FINDING" 'if $id != "" then .findings |= map(select(.fingerprint | startswith($id))) else . end' "$OUTPUT_FILE")
+
+if [[ -n "$FINDING" && "$(jq '.findings | length' <<< "$REPORT")" -eq 0 ]]; then
+    echo "Error: No finding matches '$FINDING' in the scanned releases"
+    exit 1
+fi
+
+if [[ "$FORMAT" == "json" ]]; then
+    echo "$REPORT"
+    exit 0
+fi
+
+echo ""
+echo "Releases: $(jq -r '.releases | join(", ")' <<< "$REPORT")"
+echo ""
+if [[ "$(jq '.findings | length' <<< "$REPORT")" -eq 0 ]]; then
+    echo "No findings in any scanned release"
+    exit 0
+fi
+
+printf "%-16s  %-8s  %-40s  %-14s  %-10s  %s\n" "FINGERPRINT" "SEVERITY" "LOCATION" "INTRODUCED" "FIXED" "RULE"
+jq -r '.findings[] | [.fingerprint, .severity, "\(.path):\(.line)", .introduced_in, (.fixed_in // "unfixed"),
+        (.check_id | split(".") | last),
+        (if (.ranges | length) > 1 then "also: " + (.ranges[1:] | map("\(.from)..\(.to)") | join(", ")) else "" end)] | @tsv' \
+    <<< "$REPORT" | while IFS=$'\t' read -r fp severity location introduced fixed rule extra; do
+        printf "%-16s  %-8s  %-40s  %-14s  %-10s  %s%s\n" "$fp" "$severity" "$location" "$introduced" "$fixed" "$rule" "${extra:+  ($extra)}"
+    done
+echo ""
+jq -r '"\(.findings | length) findings: \([.findings[] | select(.fixed_in == null)] | length) unfixed, \([.findings[] | select(.fixed_in != null)] | length) fixed in a scanned release"' <<< "$REPORT"
+echo "Report: $OUTPUT_FILE"
@@ -0,0 +1,282 @@
+#!/usr/bin/env bash
+# Re-run a recorded scan from its reproducibility bundle
+#
+# Usage: ./scripts/replay-scan.sh <bundle> [options]
+#
+# catalog-scan.sh --record writes repro-bundle.tar.gz into the scan
+# directory: the engine versions, rule pack digests and rule files, each
+# target's commit, the profile and scanners, and the environment. Replay
+# checks out every target at its recorded commit, runs the same scanners
+# with the same rules and settings, and compares the semgrep findings with
+# the recorded ones, so a finding can be re-derived months later.
+#
+# Examples:
+#   ./scripts/replay-scan.sh scans/acme-corp/2026-01-01-1000/repro-bundle.tar.gz
+#   ./scripts/replay-scan.sh bundle.tar.gz --strict          # Fail on engine drift
+#   ./scripts/replay-scan.sh bundle.tar.gz --output-dir /tmp/replay --keep
+
+set -euo pipefail
+
+SCRIPT_DIR="$(cd "$(dirname "${BASH_SOURCE[0]}")" && pwd)"
+source "$SCRIPT_DIR/lib/catalog-utils.sh"
+source "$SCRIPT_DIR/lib/provenance-utils.sh"
+
+usage() {
+    cat << EOF
+Usage: $0 <bundle> [options]
+
+Re-run a scan recorded with catalog-scan.sh --record and compare its
+findings with the recorded ones.
+
+Arguments:
+    bundle      repro-bundle.tar.gz from a scan directory
+
+Options:
+    --output-dir <path>  Directory for the replay's checkouts and results
+                         (default: a temporary directory)
+    --keep               Keep the output directory after the replay
+    --current-toolkit    Run this checkout's scripts instead of the ones at
+                         the recorded toolkit commit
+    --strict             Fail when an engine version or rule pack differs
+                         instead of warning
+    --json               Print the comparison as JSON
+    -h, --help           Show this help message
+
+Engine versions can't be pinned from here: install the recorded versions
+(shown on a mismatch) for an exact replay.
+
+Exit codes:
+    0  Every recorded finding reproduced, and nothing new
+    1  Findings differ (or usage error, or --strict mismatch)
+EOF
+    exit 1
+}
+
+BUNDLE=""
+OUTPUT_DIR=""
+KEEP=false
+CURRENT_TOOLKIT=false
+STRICT=false
+JSON_OUTPUT=false
+
+while [[ $# -gt 0 ]]; do
+    case "$1" in
+        --output-dir)
+            OUTPUT_DIR="$2"
+            shift 2
+            ;;
+        --keep)
+            KEEP=true
+            shift
+            ;;
+        --current-toolkit)
+            CURRENT_TOOLKIT=true
+            shift
+            ;;
+        --strict)
+            STRICT=true
+            shift
+            ;;
+        --json)
+            JSON_OUTPUT=true
+            shift
+            ;;
+        -h|--help)
+            usage
+            ;;
+        -*)
+            echo "Unknown option: $1"
+            usage
+            ;;
+        *)
+            if [[ -z "$BUNDLE" ]]; then
+                BUNDLE="$1"
+            else
+                echo "Unexpected argument: $1"
+                usage
+            fi
+            shift
+            ;;
+    esac
+done
+
+if [[ -z "$BUNDLE" ]]; then
+    usage
+fi
+
+require_jq || exit 1
+
+if [[ ! -f "$BUNDLE" ]]; then
+    echo "Error: Bundle not found: $BUNDLE"
+    exit 1
+fi
+
+if [[ -z "$OUTPUT_DIR" ]]; then
+    OUTPUT_DIR=$(mktemp -d)
+else
+    KEEP=true
+    mkdir -p "$OUTPUT_DIR"
+fi
+OUTPUT_DIR="$(cd "$OUTPUT_DIR" && pwd)"
+
+TOOLKIT_WORKTREE=""
+cleanup() {
+    if [[ -n "$TOOLKIT_WORKTREE" ]]; then
+        git -C "$CATALOG_ROOT" worktree remove --force "$TOOLKIT_WORKTREE" 2>/dev/null || true
+    fi
+    [[ "$KEEP" == true ]] || rm -rf "$OUTPUT_DIR"
+}
+trap cleanup EXIT
+
+# =============================================================================
+# Unpack and check the recorded setup
+# =============================================================================
+
+# Bundles can come from elsewhere, so they're unpacked with the same
+# traversal, link, and size checks as any other archive
+BUNDLE_DIR="$OUTPUT_DIR/bundle/repro"
+MANIFEST="$BUNDLE_DIR/manifest.json"
+if ! "$SCRIPT_DIR/safe-extract-archive.sh" "$BUNDLE" "$OUTPUT_DIR/bundle" > /dev/null 2>&1 || \
+    [[ ! -f "$MANIFEST" || ! -d "$BUNDLE_DIR/custom-rules" ]]; then
+    echo "Error: Not a reproducibility bundle: $BUNDLE"
+    exit 1
+fi
+
+ORG=$(jq -r '.org' "$MANIFEST")
+validate_org_name "$ORG" || exit 1
+
+[[ "$JSON_OUTPUT" == true ]] || echo "Replaying $ORG scan $(jq -r '.scan' "$MANIFEST") (recorded $(jq -r '.recorded_at' "$MANIFEST"))"
+
+MISMATCHES=$(jq -n -c \
+    --argjson recorded "$(jq '.engines' "$MANIFEST")" \
+    --argjson current "$(scanner_versions)" \
+    --argjson recorded_packs "$(jq '.rule_packs' "$MANIFEST")" \
+    --argjson bundled_packs "$(rule_pack_digests "$BUNDLE_DIR/custom-rules")" '
+    [($recorded | to_entries[] | select(.value != $current[.key])
+        | "engine \(.key): recorded \(.value // "missing"), installed \($current[.key] // "missing")"),
+     ($recorded_packs | to_entries[] | select(.value != $bundled_packs[.key])
+        | "rule pack \(.key): bundled rules do not match the recorded digest")]
+')
+
+if [[ "$(jq 'length' <<< "$MISMATCHES")" -gt 0 ]]; then
+    if [[ "$JSON_OUTPUT" != true || "$STRICT" == true ]]; then
+        jq -r '.[] | "  Warning: " + .' <<< "$MISMATCHES" >&2
+    fi
+    if [[ "$STRICT" == true ]]; then
+        echo "Error: Recorded setup differs (--strict)" >&2
+        exit 1
+    fi
+fi
+
+# Scripts at the recorded toolkit commit, so routing, rule selection, and
+# normalization match the original run
+SCAN_SCRIPTS="$SCRIPT_DIR"
+TOOLKIT_COMMIT=$(jq -r '.toolkit.commit // ""' "$MANIFEST")
+if [[ -n "$TOOLKIT_COMMIT" && ! "$TOOLKIT_COMMIT" =~ ^[0-9a-f]{7,64}$ ]]; then
+    echo "Error: Invalid toolkit commit in manifest: '$TOOLKIT_COMMIT'" >&2
+    exit 1
+fi
+if [[ "$CURRENT_TOOLKIT" != true && -n "$TOOLKIT_COMMIT" ]]; then
+    if [[ "$(git -C "$CATALOG_ROOT" rev-parse HEAD 2>/dev/null)" != "$TOOLKIT_COMMIT" ]]; then
+        TOOLKIT_WORKTREE="$OUTPUT_DIR/toolkit"
+        if git -C "$CATALOG_ROOT" worktree add --detach "$TOOLKIT_WORKTREE" "$TOOLKIT_COMMIT" > /dev/null 2>&1; then
+            SCAN_SCRIPTS="$TOOLKIT_WORKTREE/scripts"
+        else
+            TOOLKIT_WORKTREE=""
+            echo "  Warning: toolkit commit ${TOOLKIT_COMMIT:0:12} is not available, using the current scripts" >&2
+        fi
+    fi
+fi
+
+# =============================================================================
+# Check out every target at its recorded commit
+# =============================================================================
+
+REPOS_DIR="$OUTPUT_DIR/repos"
+mkdir -p "$REPOS_DIR"
+FAILED_TARGETS=()
+
+while IFS=$'\t' read -r name commit url; do
+    [[ -z "$name" ]] && continue
+    # Names become paths under REPOS_DIR, and commits and URLs git arguments
+    if [[ ! "$name" =~ ^[A-Za-z0-9._-]+$ || "$name" == *..* ]]; then
+        echo "Error: Invalid target name in manifest: '$name'" >&2
+        exit 1
+    fi
+    if [[ ! "$commit" =~ ^[0-9a-f]{7,64}$ ]]; then
+        echo "Error: Invalid commit for $name in manifest: '$commit'" >&2
+        exit 1
+    fi
+    if [[ -n "$url" && ! "$url" =~ ^(https://|ssh://|git@) ]]; then
+        echo "Error: Invalid URL for $name in manifest: '$url'" >&2
+        exit 1
+    fi
+    local_clone="$CATALOG_ROOT/repos/$ORG/$name"
+    dest="$REPOS_DIR/$name"
+
+    # The local clone when it still has the commit, else the remote
+    if git -C "$local_clone" cat-file -e "$commit^{commit}" 2>/dev/null; then
+        git clone --quiet --no-checkout "$local_clone" "$dest" 2>/dev/null && \
+            git -C "$dest" checkout --quiet "$commit" 2>/dev/null && continue
+    elif [[ -n "$url" ]]; then
+        git init --quiet "$dest" && \
+            git -C "$dest" fetch --quiet --depth 1 "$url" "$commit" 2>/dev/null && \
+            git -C "$dest" checkout --quiet FETCH_HEAD 2>/dev/null && continue
+    fi
+    rm -rf "$dest"
+    FAILED_TARGETS+=("$name")
+    echo "  Warning: $name@${commit:0:12} could not be checked out" >&2
+done < <(jq -r '.targets | to_entries[] | [.key, .value.commit, (.value.url // "")] | @tsv' "$MANIFEST")
+
+# =============================================================================
+# Re-run the recorded scanners
+# =============================================================================
+
+SCAN_ARGS=(--no-catalog --repos-dir "$REPOS_DIR" --output-dir "$OUTPUT_DIR/results" --quiet)
+PROFILE=$(jq -r '.config.profile // ""' "$MANIFEST")
+[[ -n "$PROFILE" ]] && SCAN_ARGS+=(--profile "$PROFILE")
+while IFS= read -r scanner; do
+    [[ -n "$scanner" ]] && SCAN_ARGS+=("--$scanner")
+done < <(jq -r '.config.scanners[]?' "$MANIFEST")
+
+# The recorded settings, exported for the scanners; custom-rules/ is read
+# from the working directory, so the bundled rules are the ones scanned.
+# Only the settings a recording keeps (REPRO_ENV_VARS) are exported, so
+# a bundle can't set PATH, credentials, registry URLs, or anything else
+SCAN_STATUS=0
+(
+    while IFS=$'\t' read -r var value; do
+        export "$var=$value"
+    done < <(jq -r --arg keep "$REPRO_ENV_VARS" '
+        ($keep | split(" ")) as $keep
+        | .environment.variables // {} | to_entries[]
+        | select(.key as $key | $keep | index([$key]))
+        | [.key, (.value | tostring)] | @tsv' "$MANIFEST")
+    cd "$BUNDLE_DIR"
+    "$SCAN_SCRIPTS/catalog-scan.sh" "$ORG" "${SCAN_ARGS[@]}"
+) > "$OUTPUT_DIR/replay.log" 2>&1 || SCAN_STATUS=$?
+
+# =============================================================================
+# Compare findings
+# =============================================================================
+
+RESULT=$(repro_finding_diff "$(jq -c '.findings // []' "$MANIFEST")" \
+    "$(scan_finding_keys "$OUTPUT_DIR/results/semgrep-results")")
+RESULT=$(jq -c --argjson mismatches "$MISMATCHES" --argjson status "$SCAN_STATUS" \
+    --arg failed "${FAILED_TARGETS[*]+"${FAILED_TARGETS[*]}"}" \
+    '. + {mismatches: $mismatches, scan_status: $status,
+          unavailable_targets: ($failed | split(" ") | map(select(. != "")))}' <<< "$RESULT")
+
+if [[ "$JSON_OUTPUT" == true ]]; then
+    jq '.' <<< "$RESULT"
+else
+    [[ "$SCAN_STATUS" -ne 0 ]] && echo "  Scan exited $SCAN_STATUS (log: $OUTPUT_DIR/replay.log)"
+    echo "  Reproduced: $(jq '.reproduced' <<< "$RESULT") findings"
+    jq -r '.missing[] | "  Missing:    " + .' <<< "$RESULT"
+    jq -r '.new[] | "  New:        " + .' <<< "$RESULT"
+    [[ "$KEEP" == true ]] && echo "  Results:    $OUTPUT_DIR/results"
+fi
+
+if jq -e '(.missing | length) > 0 or (.new | length) > 0' <<< "$RESULT" > /dev/null; then
+    exit 1
+fi
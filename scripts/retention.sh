@@ -0,0 +1,309 @@
+#!/usr/bin/env bash
+# Purge old vulnerability data under a retention policy
+#
+# Usage: ./scripts/retention.sh [options]
+#
+# Holding vulnerability data indefinitely is its own risk. This deletes, per
+# org, three kinds of data once they pass their retention period:
+#   scans     catalog scan history (catalog/tracked/<org>/scans/<timestamp>/);
+#             the latest scan is always kept
+#   evidence  raw results with secret values and other evidence:
+#             findings/<org>/*-results/, scans/<org>/trufflehog-results/,
+#             and query console sessions (scans/<org>/query-sessions/)
+#   resolved  triage dispositions of findings no longer in the scan results,
+#             and closed disclosures
+# Anything tied to an open disclosure is kept: the org's scan history, the
+# evidence of the disclosure's repo (all evidence when it names no repo),
+# and the disposition of the disclosed finding. Reports are never touched.
+#
+# Examples:
+#   ./scripts/retention.sh --max-age 180 --dry-run      # Preview
+#   ./scripts/retention.sh --evidence 30 --scans 365    # Per-kind periods
+#   ./scripts/retention.sh --auto                       # Policy from .env
+
+set -euo pipefail
+
+SCRIPT_DIR="$(cd "$(dirname "${BASH_SOURCE[0]}")" && pwd)"
+source "$SCRIPT_DIR/lib/catalog-utils.sh"
+source "$SCRIPT_DIR/lib/finding-utils.sh"
+
+usage() {
+    cat << EOF
+Usage: $0 [options]
+
+Delete scan history, raw evidence, and resolved findings older than their
+retention period. Data tied to open disclosures is kept.
+
+Options:
+    --max-age <days>     Retention period for all three kinds of data
+    --scans <days>       Scan history (the latest scan is always kept)
+    --evidence <days>    Raw results and query sessions
+    --resolved <days>    Dispositions of findings gone from the results,
+                         and closed disclosures
+    --org <name>         Only apply to one org
+    --auto               Apply the policy from RETENTION_SCAN_DAYS,
+                         RETENTION_EVIDENCE_DAYS, and RETENTION_RESOLVED_DAYS
+                         (.env); does nothing if unset
+    --dry-run            Report what would be deleted without deleting
+    -f, --force          Skip confirmation prompt
+    -q, --quiet          Only print the summary line
+    -h, --help           Show this help message
+
+Examples:
+    $0 --max-age 180 --dry-run
+    $0 --evidence 30 --scans 365 --resolved 90
+    $0 --org acme-corp --evidence 14 --force
+EOF
+    exit 1
+}
+
+# Load policy defaults from .env
+if [[ -f "$CATALOG_ROOT/.env" ]]; then
+    set -a
+    # shellcheck disable=SC1091
+    source "$CATALOG_ROOT/.env"
+    set +a
+fi
+
+MAX_AGE_DAYS=""
+SCAN_DAYS=""
+EVIDENCE_DAYS=""
+RESOLVED_DAYS=""
+ORG_FILTER=""
+AUTO_MODE=""
+DRY_RUN=""
+FORCE=""
+QUIET_MODE=""
+
+while [[ $# -gt 0 ]]; do
+    case "$1" in
+        --max-age)
+            MAX_AGE_DAYS="$2"
+            shift 2
+            ;;
+        --scans)
+            SCAN_DAYS="$2"
+            shift 2
+            ;;
+        --evidence)
+            EVIDENCE_DAYS="$2"
+            shift 2
+            ;;
+        --resolved)
+            RESOLVED_DAYS="$2"
+            shift 2
+            ;;
+        --org)
+            ORG_FILTER="$2"
+            shift 2
+            ;;
+        --auto)
+            AUTO_MODE="1"
+            shift
+            ;;
+        --dry-run)
+            DRY_RUN="1"
+            shift
+            ;;
+        -f|--force)
+            FORCE="1"
+            shift
+            ;;
+        -q|--quiet)
+            QUIET_MODE="1"
+            shift
+            ;;
+        -h|--help)
+            usage
+            ;;
+        *)
+            echo "Unknown option: $1"
+            usage
+            ;;
+    esac
+done
+
+if [[ -n "$AUTO_MODE" ]]; then
+    SCAN_DAYS="${SCAN_DAYS:-${RETENTION_SCAN_DAYS:-}}"
+    EVIDENCE_DAYS="${EVIDENCE_DAYS:-${RETENTION_EVIDENCE_DAYS:-}}"
+    RESOLVED_DAYS="${RESOLVED_DAYS:-${RETENTION_RESOLVED_DAYS:-}}"
+    [[ -z "$MAX_AGE_DAYS$SCAN_DAYS$EVIDENCE_DAYS$RESOLVED_DAYS" ]] && exit 0
+    FORCE="1"
+fi
+
+SCAN_DAYS="${SCAN_DAYS:-$MAX_AGE_DAYS}"
+EVIDENCE_DAYS="${EVIDENCE_DAYS:-$MAX_AGE_DAYS}"
+RESOLVED_DAYS="${RESOLVED_DAYS:-$MAX_AGE_DAYS}"
+
+if [[ -z "$SCAN_DAYS$EVIDENCE_DAYS$RESOLVED_DAYS" ]]; then
+    echo "Error: Specify --max-age, --scans, --evidence, or --resolved (or --auto with a policy in .env)"
+    exit 1
+fi
+
+for days_arg in "--scans:$SCAN_DAYS" "--evidence:$EVIDENCE_DAYS" "--resolved:$RESOLVED_DAYS"; do
+    value="${days_arg#*:}"
+    if [[ -n "$value" && ! "$value" =~ ^[0-9]+$ ]]; then
+        echo "Error: ${days_arg%%:*} must be a number of days"
+        exit 1
+    fi
+done
+
+if [[ -n "$ORG_FILTER" ]] && ! validate_org_name "$ORG_FILTER"; then
+    exit 1
+fi
+
+require_jq || exit 1
+
+# =============================================================================
+# Plan: kind, age in days, org, item, path or finding/disclosure id
+# =============================================================================
+
+NOW=$(date +%s)
+PLAN=()
+kept_open=0
+
+# Whole days since a file or directory was last modified
+age_days() {
+    echo $(( (NOW - $(file_mtime "$1")) / 86400 ))
+}
+
+# Whole days since a date (YYYY-MM-DD), GNU or BSD date
+days_since() {
+    echo $(( (NOW - $(date -d "$1" +%s 2>/dev/null || date -j -f %Y-%m-%d "$1" +%s)) / 86400 ))
+}
+
+for org_dir in "$CATALOG_ROOT/catalog/tracked"/*/; do
+    [[ -d "$org_dir" ]] || continue
+    org=$(basename "$org_dir")
+    [[ -n "$ORG_FILTER" && "$org" != "$ORG_FILTER" ]] && continue
+
+    open=$(open_disclosures "$org")
+    open_count=$(jq 'length' <<< "$open")
+    open_repos=$(jq -r '.[] | .repo // "*"' <<< "$open" | sort -u)
+
+    # Scan history, oldest first, never the latest scan
+    if [[ -n "$SCAN_DAYS" ]]; then
+        scans=$(list_org_scans "$org" 2>/dev/null || true)
+        cutoff=$(date_days_ago "$SCAN_DAYS")
+        while IFS= read -r scan; do
+            [[ -z "$scan" || ! "${scan:0:10}" < "$cutoff" ]] && continue
+            if [[ "$open_count" -gt 0 ]]; then
+                kept_open=$((kept_open + 1))
+                continue
+            fi
+            PLAN+=("scans"$'\t'"$(days_since "${scan:0:10}")"$'\t'"$org"$'\t'"scan $scan"$'\t'"$(get_org_scans_dir "$org")/$scan")
+        done <<< "$(sed '$d' <<< "$scans")"
+    fi
+
+    # Raw evidence files
+    if [[ -n "$EVIDENCE_DAYS" ]]; then
+        while IFS= read -r file; do
+            [[ -z "$file" ]] && continue
+            rel="${file#"$CATALOG_ROOT"/}"
+            if [[ "$open_count" -gt 0 ]]; then
+                name=$(basename "$file")
+                name="${name%%.*}"
+                if [[ "$rel" == */query-sessions/* ]] || grep -qxF -e "*" -e "$name" <<< "$open_repos"; then
+                    kept_open=$((kept_open + 1))
+                    continue
+                fi
+            fi
+            PLAN+=("evidence"$'\t'"$(age_days "$file")"$'\t'"$org"$'\t'"$rel"$'\t'"$file")
+        done < <(find "$CATALOG_ROOT/findings/$org"/*-results "$CATALOG_ROOT/scans/$org/trufflehog-results" \
+                      "$CATALOG_ROOT/scans/$org/query-sessions" -type f -mtime +"$EVIDENCE_DAYS" 2>/dev/null | LC_ALL=C sort)
+    fi
+
+    # Dispositions of findings gone from the results, and closed disclosures;
+    # one that still applies to a finding by structure isn't gone
+    if [[ -n "$RESOLVED_DAYS" ]]; then
+        cutoff=$(date_days_ago "$RESOLVED_DAYS")
+        if compgen -G "$CATALOG_ROOT/scans/$org/semgrep-results/*.json*" > /dev/null; then
+            current=$(triage_current "$org")
+            while IFS=$'\t' read -r id at location; do
+                [[ -z "$id" ]] && continue
+                if jq -e --arg id "$id" 'any(.[]; .finding != null and (.finding as $ref | $id | startswith($ref)))' \
+                    <<< "$open" > /dev/null; then
+                    kept_open=$((kept_open + 1))
+                    continue
+                fi
+                PLAN+=("resolved"$'\t'"$(days_since "${at:0:10}")"$'\t'"$org"$'\t'"disposition of $location"$'\t'"triage:$id")
+            done < <(triage_load "$org" | structure_renamed "$CATALOG_ROOT/repos/$org" | \
+                jq -r --argjson current "$current" --arg cutoff "$cutoff" "$TRIAGE_JQ_DEFS"'
+                to_entries[] | select(triage_applies($current) | not)
+                | select((.value.at // "")[0:10] < $cutoff)
+                | [.key, .value.at, "\(.value.repo)/\(.value.path):\(.value.line)"] | @tsv')
+        fi
+
+        file=$(disclosures_file "$org")
+        if [[ -f "$file" ]]; then
+            while IFS=$'\t' read -r id last title; do
+                [[ -z "$id" ]] && continue
+                PLAN+=("resolved"$'\t'"$(days_since "$last")"$'\t'"$org"$'\t'"closed disclosure $id: $title"$'\t'"disclosure:$id")
+            done < <(jq -r --arg cutoff "$cutoff" '
+                .disclosures[]? | select(.status == "closed")
+                | ([.added, .notified, .acknowledged, .public_date, .published] + [.notes[]?.date] | map(select(. != null)) | max) as $last
+                | select($last != null and $last < $cutoff)
+                | [.id, $last, .title] | @tsv' "$file")
+        fi
+    fi
+done
+
+# =============================================================================
+# Report and delete
+# =============================================================================
+
+if [[ ${#PLAN[@]} -eq 0 ]]; then
+    summary="Retention: nothing to delete"
+    [[ "$kept_open" -gt 0 ]] && summary+=" ($kept_open items kept for open disclosures)"
+    echo "$summary"
+    exit 0
+fi
+
+if [[ -z "$QUIET_MODE" ]]; then
+    [[ -n "$DRY_RUN" ]] && echo "Would delete:" || echo "Deleting:"
+    printf "  %-9s %6s  %-20s  %s\n" "KIND" "AGE" "ORG" "ITEM"
+    for entry in "${PLAN[@]}"; do
+        IFS=$'\t' read -r kind age org item target <<< "$entry"
+        printf "  %-9s %5sd  %-20s  %s\n" "$kind" "$age" "$org" "$item"
+    done
+    echo ""
+fi
+
+if [[ -n "$DRY_RUN" ]]; then
+    summary="Retention (dry run): would delete ${#PLAN[@]} items"
+    [[ "$kept_open" -gt 0 ]] && summary+=" ($kept_open kept for open disclosures)"
+    echo "$summary"
+    exit 0
+fi
+
+if [[ -z "$FORCE" ]]; then
+    read -p "Delete ${#PLAN[@]} items? [y/N] " -n 1 -r
+    echo ""
+    if [[ ! $REPLY =~ ^[Yy]$ ]]; then
+        echo "Cancelled."
+        exit 0
+    fi
+fi
+
+for entry in "${PLAN[@]}"; do
+    IFS=$'\t' read -r kind age org item target <<< "$entry"
+    case "$target" in
+        triage:*)
+            triage_clear "$org" "${target#triage:}"
+            ;;
+        disclosure:*)
+            file=$(disclosures_file "$org")
+            tmp=$(mktemp)
+            jq --sort-keys --arg id "${target#disclosure:}" '.disclosures |= map(select(.id != $id))' "$file" > "$tmp" && \
+                mv "$tmp" "$file"
+            ;;
+        *)
+            rm -rf "$target"
+            ;;
+    esac
+    log_verbose "  Deleted $item"
+done
+
+summary="Retention: deleted ${#PLAN[@]} items"
+[[ "$kept_open" -gt 0 ]] && summary+=" ($kept_open kept for open disclosures)"
+echo "$summary"
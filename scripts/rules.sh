@@ -0,0 +1,255 @@
+#!/usr/bin/env bash
+# Manage the lifecycle of custom rules: canary rollout, deprecation, and
+# replacement
+#
+# Usage: ./scripts/rules.sh <command> [options]
+#
+# A rule is retired by adding deprecated-by (its replacement's id) and,
+# optionally, removal-version (the release that deletes it) to its metadata.
+# Until then it keeps running, scans warn about its findings, and `migrate`
+# carries each org's dispositions and suppressions over to the replacement,
+# so deleting the old rule later doesn't resurface triaged findings.
+#
+# A new rule can start as a canary (canary: true in its metadata): its
+# findings go to each scan's shadow channel (semgrep-canary) instead of the
+# results, so `canary` shows how noisy it is on real targets before
+# `promote` lets it fail builds and reach reports.
+#
+# `select` previews which rules a scan-time selection (scan-semgrep.sh
+# --rules) keeps.
+#
+# Examples:
+#   ./scripts/rules.sh canary
+#   ./scripts/rules.sh promote go-ssrf-taint
+#   ./scripts/rules.sh deprecated
+#   ./scripts/rules.sh check
+#   ./scripts/rules.sh migrate acme-corp --dry-run
+#   ./scripts/rules.sh migrate --all
+#   ./scripts/rules.sh select "category=traversal;confidence=high"
+
+set -euo pipefail
+
+SCRIPT_DIR="$(cd "$(dirname "${BASH_SOURCE[0]}")" && pwd)"
+source "$SCRIPT_DIR/lib/catalog-utils.sh"
+source "$SCRIPT_DIR/lib/rule-utils.sh"
+source "$SCRIPT_DIR/lib/finding-utils.sh"
+
+usage() {
+    cat << EOF
+Usage: $0 <command> [options]
+
+Manage canary and deprecated custom rules, and move findings' triage to
+the rules that replace them.
+
+Commands:
+    canary              List canary rules with their shadow findings in each
+                        tracked org's latest scan
+    promote <rule>      Remove a rule's canary metadata so its findings count
+    deprecated          List deprecated rules with their replacements (default)
+    check               Check lifecycle metadata: replacements exist, no loops,
+                        removal versions are vX.Y.Z and not yet reached
+    migrate <org>       Copy dispositions and suppressions of deprecated rules
+                        to the rules that replace them
+    select <selection>  List the custom rules a --rules selection keeps
+                        (e.g. "category=traversal,ssrf;confidence=high")
+
+Options:
+    --all               migrate: every tracked org
+    --dry-run           migrate: show what would be copied without writing
+    -q, --quiet         migrate: only print the summary line
+    -h, --help          Show this help message
+
+Rule metadata:
+    canary: true                    Record findings in the shadow channel only
+    canary-since: YYYY-MM-DD        When the canary started (optional)
+    deprecated-by: <rule id>        The rule that replaces this one
+    removal-version: vX.Y.Z         Release that deletes this rule (optional)
+
+Examples:
+    $0 canary
+    $0 promote go-ssrf-taint
+    $0 deprecated
+    $0 migrate acme-corp --dry-run
+    $0 migrate --all
+    $0 select "pack=web-vulns;cwe=918"
+EOF
+    exit 1
+}
+
+COMMAND=""
+ARG=""
+ALL_ORGS=false
+DRY_RUN=""
+QUIET=false
+
+while [[ $# -gt 0 ]]; do
+    case "$1" in
+        --all)
+            ALL_ORGS=true
+            shift
+            ;;
+        --dry-run)
+            DRY_RUN="dry-run"
+            shift
+            ;;
+        -q|--quiet)
+            QUIET=true
+            shift
+            ;;
+        -h|--help)
+            usage
+            ;;
+        -*)
+            echo "Unknown option: $1"
+            usage
+            ;;
+        *)
+            if [[ -z "$COMMAND" ]]; then
+                COMMAND="$1"
+            elif [[ -z "$ARG" ]]; then
+                ARG="$1"
+            else
+                echo "Unexpected argument: $1"
+                usage
+            fi
+            shift
+            ;;
+    esac
+done
+
+COMMAND="${COMMAND:-deprecated}"
+
+require_jq || exit 1
+
+INDEX=$(rule_lifecycle_index)
+
+case "$COMMAND" in
+    canary)
+        CANARY_INDEX=$(rule_canary_index)
+        if [[ -z "$CANARY_INDEX" ]]; then
+            echo "No canary rules in $RULES_ROOT"
+            exit 0
+        fi
+        # Shadow findings of the latest scan per tracked org, tagged with the org
+        SHADOW=$(while IFS= read -r org; do
+            [[ -z "$org" ]] && continue
+            scan_dir=$(get_latest_scan_dir "$org" 2>/dev/null || true)
+            [[ -n "$scan_dir" && -f "$scan_dir/semgrep-canary.json.gz" ]] || continue
+            gzip -dc "$scan_dir/semgrep-canary.json.gz" | \
+                jq -c --arg org "$org" '.results[]? | {org: $org, repo, rule: (.check_id | split(".") | last)}'
+        done < <(list_tracked_orgs) | jq -s -c '.')
+        printf "%-40s  %-10s  %8s  %6s  %s\n" "RULE" "SINCE" "FINDINGS" "REPOS" "ORGS"
+        while IFS=$'\t' read -r file id since; do
+            jq -r --arg id "$id" '
+                map(select(.rule == $id))
+                | [length, (map("\(.org)/\(.repo)") | unique | length), (map(.org) | unique | join(",") | if . == "" then "-" else . end)]
+                | @tsv
+            ' <<< "$SHADOW" | while IFS=$'\t' read -r findings repos orgs; do
+                printf "%-40s  %-10s  %8s  %6s  %s\n" "$id" "${since:--}" "$findings" "$repos" "$orgs"
+            done
+        done <<< "$CANARY_INDEX"
+        echo ""
+        echo "Review the findings in each scan's semgrep-canary.json.gz, then: $0 promote <rule>"
+        ;;
+
+    promote)
+        if [[ -z "$ARG" ]]; then
+            echo "Error: promote requires a rule id"
+            exit 1
+        fi
+        if ! file=$(promote_canary_rule "$ARG" "$(rule_canary_index)"); then
+            echo "Error: '$ARG' is not a canary rule (see: $0 canary)"
+            exit 1
+        fi
+        echo "Promoted ${ARG##*.}: its findings are reported from the next scan (${file#"$CATALOG_ROOT"/})"
+        ;;
+
+    deprecated)
+        if [[ -z "$INDEX" ]]; then
+            echo "No deprecated rules in $RULES_ROOT"
+            exit 0
+        fi
+        printf "%-40s  %-40s  %s\n" "RULE" "REPLACED BY" "REMOVAL"
+        while IFS=$'\t' read -r file id by removal; do
+            if ! final=$(rule_replacement "$id" "$INDEX"); then
+                by="$by (loop)"
+            elif [[ "$final" != "$by" ]]; then
+                by="$by -> $final"
+            fi
+            printf "%-40s  %-40s  %s\n" "$id" "$by" "${removal:--}"
+        done <<< "$INDEX"
+        ;;
+
+    check)
+        if problems=$(rule_lifecycle_problems "$INDEX" "$(current_release_tag || true)"); then
+            echo "Lifecycle metadata OK ($(grep -c . <<< "$INDEX" || true) deprecated rules)"
+        else
+            echo "$problems"
+            exit 1
+        fi
+        ;;
+
+    migrate)
+        ORG="$ARG"
+        if [[ "$ALL_ORGS" == true && -n "$ORG" ]]; then
+            echo "Error: Use an org or --all, not both"
+            exit 1
+        fi
+        if [[ "$ALL_ORGS" == true ]]; then
+            ORGS=$(list_tracked_orgs)
+        elif [[ -n "$ORG" ]]; then
+            validate_org_name "$ORG" || exit 1
+            if [[ ! -d "$CATALOG_ROOT/catalog/tracked/$ORG" ]]; then
+                echo "Error: '$ORG' is not tracked"
+                exit 1
+            fi
+            ORGS="$ORG"
+        else
+            echo "Error: migrate requires an org or --all"
+            exit 1
+        fi
+
+        REPLACEMENTS=$(rule_replacements_json "$INDEX")
+        triage_count=0
+        suppression_count=0
+        while IFS= read -r org; do
+            [[ -z "$org" ]] && continue
+            while IFS=$'\t' read -r kind old_id new_id old_rule new_rule; do
+                [[ -z "$kind" ]] && continue
+                if [[ "$kind" == "triage" ]]; then
+                    triage_count=$((triage_count + 1))
+                else
+                    suppression_count=$((suppression_count + 1))
+                fi
+                [[ "$QUIET" == true ]] || \
+                    printf "%-12s %-12s %s -> %s  (%s -> %s)\n" "$org" "$kind" "$old_id" "$new_id" "${old_rule##*.}" "${new_rule##*.}"
+            done < <(transfer_rule_baselines "$org" "$REPLACEMENTS" "$DRY_RUN")
+        done <<< "$ORGS"
+
+        verb="Copied"
+        [[ -n "$DRY_RUN" ]] && verb="Would copy"
+        echo "$verb $triage_count dispositions and $suppression_count suppressions to replacement rules"
+        ;;
+
+    select)
+        if [[ -z "$ARG" ]]; then
+            echo "Error: select requires a selection (keys: $RULE_SELECTION_KEYS)"
+            exit 1
+        fi
+        validate_rule_selection "$ARG" || exit 1
+        index=$(rule_selection_index "$RULES_ROOT")
+        selected=$(rule_selection_filter "$ARG" <<< "$index")
+        printf "%-56s  %-20s  %-10s  %s\n" "RULE" "PACK" "CONFIDENCE" "CATEGORIES"
+        while IFS=$'\t' read -r check_id id pack confidence categories cwes; do
+            [[ -z "$id" ]] && continue
+            printf "%-56s  %-20s  %-10s  %s\n" "$id" "$pack" "${confidence:--}" "$categories"
+        done <<< "$selected"
+        echo ""
+        echo "$(grep -c . <<< "$selected" || true) of $(grep -c . <<< "$index" || true) custom rules selected"
+        ;;
+
+    *)
+        echo "Unknown command: $COMMAND"
+        usage
+        ;;
+esac
@@ -68,6 +68,8 @@ mkdir -p "$OUTPUT_DIR"
 SCRIPT_DIR="$(cd "$(dirname "${BASH_SOURCE[0]}")" && pwd)"
 source "$SCRIPT_DIR/lib/catalog-utils.sh"
 
+install_cancel_traps
+
 # Exclusion patterns
 EXCLUDE_PATTERN="node_modules|vendor|3rdparty|\.git|__pycache__|\.venv|venv|dist|build|\.next|coverage|test-data|fixtures|testdata|mocks"
 
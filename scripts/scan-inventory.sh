@@ -15,11 +15,8 @@ readonly SCRIPT_NAME="$(basename "$0")"
 readonly SCRIPT_DIR="$(cd "$(dirname "${BASH_SOURCE[0]}")" && pwd)"
 readonly ROOT_DIR="$(cd "$SCRIPT_DIR/.." && pwd)"
 
-# Colors for output
-RED='\033[0;31m'
-GREEN='\033[0;32m'
-YELLOW='\033[1;33m'
-NC='\033[0m' # No Color
+# Logging (log_info/log_warn/log_error) and archived repo detection
+source "$SCRIPT_DIR/lib/catalog-utils.sh"
 
 usage() {
     cat <<EOF
@@ -52,10 +49,6 @@ EOF
     exit 0
 }
 
-log_info() { echo -e "${GREEN}[INFO]${NC} $*"; }
-log_warn() { echo -e "${YELLOW}[WARN]${NC} $*" >&2; }
-log_error() { echo -e "${RED}[ERROR]${NC} $*" >&2; }
-
 check_dependencies() {
     local missing=()
 
@@ -157,14 +150,7 @@ fi
 
 check_dependencies
 
-# Source utility functions for archived repo detection
-if [[ -f "$SCRIPT_DIR/lib/catalog-utils.sh" ]]; then
-    source "$SCRIPT_DIR/lib/catalog-utils.sh"
-    REPOS=$(get_active_repos "$REPOS_DIR")
-else
-    # Fallback: get all directories
-    REPOS=$(find "$REPOS_DIR" -mindepth 1 -maxdepth 1 -type d | sort)
-fi
+REPOS=$(get_active_repos "$REPOS_DIR")
 
 REPO_COUNT=$(echo "$REPOS" | grep -c . || echo 0)
 
@@ -191,7 +177,8 @@ fi
 
 # Temporary file for collecting language data
 LANG_TEMP=$(mktemp)
-trap 'rm -f "$LANG_TEMP"' EXIT
+register_cleanup "$LANG_TEMP"
+install_cancel_traps
 
 echo "{}" > "$LANG_TEMP"
 
@@ -243,8 +230,11 @@ for repo_path in "${REPOS_ARRAY[@]}"; do
     if [[ "$DO_SBOM" == true ]]; then
         sbom_file="$INVENTORY_DIR/${repo_name}-sbom.json.gz"
 
-        # Run syft and pipe through gzip
-        if syft dir:"$repo_path" -o syft-json 2>/dev/null | gzip > "$sbom_file"; then
+        # Run syft and pipe through gzip (into a temp file until complete)
+        sbom_tmp=$(mktemp)
+        register_cleanup "$sbom_tmp"
+        if syft dir:"$repo_path" -o syft-json 2>/dev/null | gzip > "$sbom_tmp"; then
+            mv "$sbom_tmp" "$sbom_file"
             if [[ -f "$sbom_file" && -s "$sbom_file" ]]; then
                 if [[ -z "$QUIET_MODE" ]]; then
                     pkg_count=$(gzip -dc "$sbom_file" | jq '.artifacts | length' 2>/dev/null || echo "0")
@@ -252,9 +242,7 @@ for repo_path in "${REPOS_ARRAY[@]}"; do
                 fi
             fi
         else
-            if [[ -z "$QUIET_MODE" ]]; then
-                echo "  [$repo_name] SBOM: scan failed"
-            fi
+            log_warn "SBOM scan failed" target="$repo_name"
             rm -f "$sbom_file"
         fi
     fi
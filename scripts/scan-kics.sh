@@ -95,6 +95,8 @@ mkdir -p "$RESULTS_DIR"
 SCRIPT_DIR="$(cd "$(dirname "${BASH_SOURCE[0]}")" && pwd)"
 source "$SCRIPT_DIR/lib/catalog-utils.sh"
 
+install_cancel_traps
+
 # Exclusion directory names - KICS needs paths relative to scan target
 EXCLUDE_DIRS=(
     "test"
@@ -174,6 +176,9 @@ for repo in "${REPOS_ARRAY[@]}"; do
         KICS_QUERY_ARG="-q $KICS_QUERIES_PATH"
     fi
 
+    # A cancelled KICS run can leave a partial report behind
+    register_cleanup "$RESULTS_DIR/${name}.json"
+
     # Build exclude args for directories that exist in this repo
     # KICS needs paths relative to scan target (e.g., "vendor/*" not "**/vendor/**")
     EXCLUDE_ARGS=()
@@ -71,12 +71,18 @@ vendor/
 node_modules/
 3rdparty/
 EOF
-trap "rm -f $EXCLUDE_FILE" EXIT
 
 # Source utility functions for archived repo info
 SCRIPT_DIR="$(cd "$(dirname "${BASH_SOURCE[0]}")" && pwd)"
 source "$SCRIPT_DIR/lib/catalog-utils.sh"
 
+register_cleanup "$EXCLUDE_FILE"
+install_cancel_traps
+
+# No size limit by default: minified bundles skipped by semgrep often
+# embed keys, so trufflehog still reads them (MAX_FILE_SIZE_SECRETS)
+MAX_SECRETS_BYTES=$(scanner_max_file_size secrets) || exit 1
+
 # Scan ALL repos including archived (secrets are the one thing we always scan for)
 REPOS=$(find "$REPOS_DIR" -maxdepth 1 -mindepth 1 -type d ! -name ".*" | sort)
 REPO_COUNT=$(echo "$REPOS" | wc -l | xargs)
@@ -104,14 +110,39 @@ for repo in "${REPOS_ARRAY[@]}"; do
 
     if [[ -n "$QUIET_MODE" ]]; then
         log_progress "$current" "$REPO_COUNT" "$name"
-    else
-        echo "[$name] Scanning..."
+    fi
+    log_info "Scanning" target="$name"
+
+    # Add paths from .bountyhunterignore files as anchored regexes
+    repo_exclude_file=$(mktemp)
+    tmp_output=$(mktemp)
+    register_cleanup "$repo_exclude_file" "$tmp_output"
+    cp "$EXCLUDE_FILE" "$repo_exclude_file"
+    ignored_path_prefixes "$repo" "$REPOS_DIR/$IGNORE_FILE_NAME" | \
+        sed -e 's/[][\.*^$()+?{}|]/\\&/g' -e 's/^/^/' >> "$repo_exclude_file"
+    if [[ "$MAX_SECRETS_BYTES" -gt 0 ]]; then
+        repo_skipped_files "$repo" "$MAX_SECRETS_BYTES" | awk -F'\t' '$2 == "too_large" { print $1 }' | \
+            sed -e 's/[][\.*^$()+?{}|]/\\&/g' -e 's/^/^/' -e 's/$/$/' >> "$repo_exclude_file"
     fi
 
     cd "$repo"
-    # Pipe trufflehog output directly through gzip
-    trufflehog git file://. --results=verified,unknown --exclude-paths="$EXCLUDE_FILE" --json 2>/dev/null | gzip > "$output_file" || true
+    # Pipe trufflehog output through gzip; moved into place only once the
+    # repo finishes, so a cancelled scan never leaves a truncated result
+    trufflehog git file://. --results=verified,unknown --exclude-paths="$repo_exclude_file" --json 2>/dev/null | gzip > "$tmp_output" || true
+    # Submodules have their own history; scan each one and prefix its file
+    # paths so findings point at the right place in the parent repo
+    while IFS= read -r submodule; do
+        [[ -z "$submodule" ]] && continue
+        trufflehog git "file://./$submodule" --results=verified,unknown --exclude-paths="$repo_exclude_file" --json 2>/dev/null | \
+            jq -c --arg prefix "$submodule/" '
+                if .SourceMetadata.Data.Git.file then
+                    .SourceMetadata.Data.Git.file = $prefix + .SourceMetadata.Data.Git.file
+                else . end
+            ' 2>/dev/null | gzip >> "$tmp_output" || true
+    done < <(repo_submodule_paths .)
     cd - > /dev/null
+    mv "$tmp_output" "$output_file"
+    rm -f "$repo_exclude_file"
 
     # Count findings (decompress to count lines)
     finding_count=$(gzip -dc "$output_file" 2>/dev/null | wc -l | xargs)
@@ -119,10 +150,7 @@ for repo in "${REPOS_ARRAY[@]}"; do
     total_findings=$((total_findings + finding_count))
     verified_count=$((verified_count + repo_verified))
 
-    if [[ -z "$QUIET_MODE" ]]; then
-        echo "[$name] Done - $finding_count findings"
-        echo ""
-    fi
+    log_info "Done - $finding_count findings" target="$name" findings="$finding_count" verified="$repo_verified"
 done
 
 # Clear progress line if in quiet mode
@@ -7,20 +7,58 @@ set -euo pipefail
 # - Pro engine: Cross-file and cross-function dataflow/taint analysis
 # - Uses p/default (CI-optimized) instead of p/security-audit (audit-style with many FPs)
 # - Custom rules enabled by default (0xdea-semgrep-rules, open-semgrep-rules, web-vulns)
+# - Language routing: language-specific rule packs load only for repos that
+#   contain that language (detected from file extensions), and in monorepos
+#   run only over the top-level directories that contain it
+# - Profiles: --profile selects rulesets, rule packs, and severities
+#   (see scripts/lib/profiles.sh)
 # - Excludes test files, examples, vendor code, and generated files
+# - Honors .bountyhunterignore files (gitignore syntax, nested, with negation)
+# - Escalates findings reachable from auth, payment, and admin entry points
+#   one severity level (see escalate_sensitive_findings in surface-utils.sh)
+# - Links each finding to its line at the scanned commit (extra.permalink) on
+#   GitHub, GitLab, Bitbucket, or Gitea; see repo_forge in finding-utils.sh
 # - Excludes specific rules known to produce false positives
+# - Optionally merges gosec / staticcheck findings for Go repos into the
+#   results (--go-analyzers), so one triage queue covers every Go analyzer
+# - Caches registry rulesets per semgrep version under cache/rule-bundles/
+#   and revalidates them by ETag (see build_bundle_config_args in rule-utils.sh)
+# - Rescans files semgrep can't parse (templated code, merge conflicts) from
+#   masked copies so rules still match their valid parts (--no-parse-recovery)
+# - Slices rules per run: --rules category=traversal,ssrf / pack= / cwe= / id=
+#   / confidence=, and --min-severity (see Rule Selection in rule-utils.sh)
+# - Time-boxed quick scans (--budget 2m): only HIGH-confidence custom rules,
+#   most recently changed files first, and files left unscanned when time
+#   ran out are reported as skipped (reason "budget")
+# - A semgrep pass that fails (crash, OOM kill, fatal error) is listed in the
+#   repo's diagnostics (failed_passes) and its files as skipped (scan_failed)
 # - Creates .semgrepignore for persistent exclusion configuration
 #
 # Requires: semgrep login (free for up to 10 contributors)
 
 if [[ $# -lt 1 ]]; then
-    echo "Usage: $0 <org-name> [--repos-dir <path>] [--output-dir <path>] [--no-custom-rules] [-q|--quiet]"
+    echo "Usage: $0 <org-name> [--repos-dir <path>] [--output-dir <path>] [--profile <name>] [--no-custom-rules] [--no-routing] [--tenant-fields <list>] [--no-escalation] [--no-rule-cache] [--go-analyzers] [--no-parse-recovery] [--rules <selection>] [--min-severity <level>] [--budget <duration>] [-q|--quiet]"
     echo "Scan all repositories with Semgrep (high-confidence security findings only)."
     echo ""
     echo "Options:"
     echo "  --repos-dir <path>    Directory containing repos to scan"
     echo "  --output-dir <path>   Output directory for results"
     echo "  --no-custom-rules     Disable custom rules from custom-rules/ (enabled by default)"
+    echo "  --no-routing          Load every custom rule pack for every repo (skip language detection)"
+    echo "  --profile <name>      Scan profile: bounty-recon (default), ci, audit"
+    echo "  --tenant-fields <list> Comma-separated tenant column/field names for the tenant"
+    echo "                        isolation rules (default: catalog/tracked/<org>/tenancy.json)"
+    echo "  --no-escalation       Keep rule severities for findings on auth, payment, and admin paths"
+    echo "  --no-rule-cache       Resolve registry rulesets from semgrep.dev on every run"
+    echo "  --go-analyzers        Also run gosec and staticcheck (if installed) on Go repos and merge their findings"
+    echo "  --no-parse-recovery   Don't rescan files semgrep can't parse from copies with template tags"
+    echo "                        and merge conflicts masked"
+    echo "  --rules <selection>   Only rules matching key=value[,value] selectors joined with ';'"
+    echo "                        (keys: category, pack, cwe, id, confidence; e.g. category=traversal,secrets)"
+    echo "  --min-severity <level> Only findings at this severity or above: high, medium, low"
+    echo "                        (overrides the profile's severities)"
+    echo "  --budget <duration>   Quick scan within a wall-clock budget (e.g. 90s, 2m): HIGH-confidence"
+    echo "                        custom rules only, recently changed files first; reports what was skipped"
     echo "  -q, --quiet           Quiet mode: show progress and final summary only"
     exit 1
 fi
@@ -31,6 +69,16 @@ shift
 REPOS_DIR=""
 OUTPUT_DIR=""
 USE_CUSTOM_RULES=true
+USE_ROUTING=true
+PROFILE="bounty-recon"
+TENANT_FIELDS=""
+USE_ESCALATION=true
+USE_RULE_CACHE=true
+USE_GO_ANALYZERS=false
+USE_PARSE_RECOVERY=true
+BUDGET=""
+RULE_SELECTION="${RULE_SELECTION:-}"
+MIN_SEVERITY="${MIN_SEVERITY:-}"
 QUIET_MODE=""
 
 while [[ $# -gt 0 ]]; do
@@ -47,6 +95,46 @@ while [[ $# -gt 0 ]]; do
             USE_CUSTOM_RULES=false
             shift
             ;;
+        --no-routing)
+            USE_ROUTING=false
+            shift
+            ;;
+        --profile)
+            PROFILE="$2"
+            shift 2
+            ;;
+        --tenant-fields)
+            TENANT_FIELDS="$2"
+            shift 2
+            ;;
+        --no-escalation)
+            USE_ESCALATION=false
+            shift
+            ;;
+        --no-rule-cache)
+            USE_RULE_CACHE=false
+            shift
+            ;;
+        --go-analyzers)
+            USE_GO_ANALYZERS=true
+            shift
+            ;;
+        --no-parse-recovery)
+            USE_PARSE_RECOVERY=false
+            shift
+            ;;
+        --budget)
+            BUDGET="$2"
+            shift 2
+            ;;
+        --rules)
+            RULE_SELECTION="$2"
+            shift 2
+            ;;
+        --min-severity)
+            MIN_SEVERITY="$2"
+            shift 2
+            ;;
         -q|--quiet)
             QUIET_MODE="1"
             shift
@@ -89,10 +177,89 @@ else
     RESULTS_DIR="$(pwd)/$OUTPUT_DIR/semgrep-results"
 fi
 mkdir -p "$RESULTS_DIR"
+DIAGNOSTICS_DIR="${RESULTS_DIR%/semgrep-results}/semgrep-diagnostics"
+CANARY_DIR="${RESULTS_DIR%/semgrep-results}/semgrep-canary"
+mkdir -p "$DIAGNOSTICS_DIR"
 
 # Source utility functions for archived repo detection
 SCRIPT_DIR="$(cd "$(dirname "${BASH_SOURCE[0]}")" && pwd)"
 source "$SCRIPT_DIR/lib/catalog-utils.sh"
+source "$SCRIPT_DIR/lib/finding-utils.sh"
+source "$SCRIPT_DIR/lib/rule-utils.sh"
+source "$SCRIPT_DIR/lib/surface-utils.sh"
+source "$SCRIPT_DIR/lib/profiles.sh"
+source "$SCRIPT_DIR/lib/trace-utils.sh"
+
+install_cancel_traps
+
+# A budget counts from here; semgrep's passes are stopped at the deadline
+BUDGET_SECONDS=""
+BUDGET_PRIORITY_FILES="${BUDGET_PRIORITY_FILES:-200}"
+BUDGET_STARTED=$(date +%s)
+if [[ -n "$BUDGET" ]]; then
+    if ! BUDGET_SECONDS=$(parse_duration_seconds "$BUDGET") || [[ "$BUDGET_SECONDS" -le 0 ]]; then
+        echo "Error: Invalid --budget '$BUDGET' (e.g. 90s, 2m, 1h)"
+        exit 1
+    fi
+    BUDGET_DEADLINE=$((BUDGET_STARTED + BUDGET_SECONDS))
+    # Rescanning unparseable files doesn't fit a quick pass
+    USE_PARSE_RECOVERY=false
+fi
+
+load_profile "$PROFILE" || exit 1
+
+if ! profile_has_scanner semgrep; then
+    echo "Profile '$PROFILE' does not include semgrep, nothing to do."
+    exit 0
+fi
+
+validate_rule_selection "$RULE_SELECTION" || exit 1
+if [[ -n "$MIN_SEVERITY" ]]; then
+    validate_severity "$MIN_SEVERITY" --min-severity || exit 1
+    PROFILE_SEVERITIES=$(semgrep_severities_at_least "$MIN_SEVERITY" | tr '\n' ' ')
+    if [[ -z "${PROFILE_SEVERITIES// /}" ]]; then
+        echo "Error: semgrep rules have no '$MIN_SEVERITY' level (ERROR is high); use --min-severity high"
+        exit 1
+    fi
+fi
+
+# Registry rulesets a --rules selection keeps
+if [[ -n "$RULE_SELECTION" ]]; then
+    selected_configs=""
+    for config in $PROFILE_SEMGREP_CONFIGS; do
+        registry_config_selected "$RULE_SELECTION" "$config" && selected_configs+="$config "
+    done
+    PROFILE_SEMGREP_CONFIGS="${selected_configs% }"
+fi
+
+# Registry rulesets and severity filters from the profile
+# Bundled rulesets are local files fetched once per semgrep version
+SEMGREP_CONFIG_ARGS=()
+RULE_BUNDLE_PREFIX=""
+RULE_BUNDLES_INFO=""
+if [[ -z "$PROFILE_SEMGREP_CONFIGS" ]]; then
+    :
+elif [[ "$USE_RULE_CACHE" == true ]]; then
+    build_bundle_config_args $PROFILE_SEMGREP_CONFIGS
+else
+    for config in $PROFILE_SEMGREP_CONFIGS; do
+        SEMGREP_CONFIG_ARGS+=("--config=$config")
+    done
+fi
+SEVERITY_ARGS=()
+for severity in $PROFILE_SEVERITIES; do
+    SEVERITY_ARGS+=("--severity=$severity")
+done
+
+# Files over this size are left to the secret scanner (MAX_FILE_SIZE_SEMGREP)
+MAX_TARGET_BYTES=$(scanner_max_file_size semgrep) || exit 1
+
+# Custom rule packs from the profile ("none" disables custom rules)
+if [[ "$PROFILE_RULE_PACKS" == "none" ]]; then
+    USE_CUSTOM_RULES=false
+else
+    RULE_PACKS="$PROFILE_RULE_PACKS"
+fi
 
 # Create a .semgrepignore if one doesn't exist in the repos directory
 SEMGREPIGNORE="$REPOS_DIR/.semgrepignore"
@@ -187,6 +354,8 @@ EXCLUDE_RULES=(
 EXCLUDE_RULE_ARGS=()
 for rule in "${EXCLUDE_RULES[@]}"; do
     EXCLUDE_RULE_ARGS+=("--exclude-rule=$rule")
+    # Bundled registry rules carry the bundle directory prefix
+    [[ -n "$RULE_BUNDLE_PREFIX" ]] && EXCLUDE_RULE_ARGS+=("--exclude-rule=$RULE_BUNDLE_PREFIX$rule")
 done
 
 # Build custom rules config arguments
@@ -195,26 +364,32 @@ CUSTOM_RULES_INFO=""
 if [[ "$USE_CUSTOM_RULES" == true ]]; then
     CUSTOM_RULES_DIR="$(pwd)/custom-rules"
     if [[ -d "$CUSTOM_RULES_DIR" ]]; then
-        # Add each custom rule directory
-        if [[ -d "$CUSTOM_RULES_DIR/0xdea-semgrep-rules/rules" ]]; then
-            CUSTOM_RULE_ARGS+=("--config=$CUSTOM_RULES_DIR/0xdea-semgrep-rules/rules")
-            CUSTOM_RULES_INFO+="0xdea-semgrep-rules "
-        fi
-        if [[ -d "$CUSTOM_RULES_DIR/open-semgrep-rules" ]]; then
-            CUSTOM_RULE_ARGS+=("--config=$CUSTOM_RULES_DIR/open-semgrep-rules")
-            CUSTOM_RULES_INFO+="open-semgrep-rules "
+        build_custom_rule_args "$CUSTOM_RULES_DIR"
+        [[ "$USE_ROUTING" == true ]] && CUSTOM_RULES_INFO+="(routed by language)"
+        # Templated rule families rendered with this org's settings
+        # (tenant-isolation needs the org's tenant field names)
+        TEMPLATE_OUT_DIR=$(mktemp -d)
+        register_cleanup "$TEMPLATE_OUT_DIR"
+        build_template_rule_args "$CUSTOM_RULES_DIR" "$TEMPLATE_OUT_DIR" "$ORG" "$TENANT_FIELDS"
+        [[ -n "$TEMPLATE_RULES_INFO" ]] && CUSTOM_RULES_INFO+=" + templates: $TEMPLATE_RULES_INFO"
+        # Rules with metadata.applies-when are checked against each repo
+        APPLICABILITY_INDEX=$(rule_applicability_index "$CUSTOM_RULES_DIR")
+        if [[ ${#TEMPLATE_RULE_ARGS[@]} -gt 0 ]]; then
+            APPLICABILITY_INDEX+=$'\n'"$(rule_applicability_index "$TEMPLATE_OUT_DIR")"
         fi
-        if [[ -d "$CUSTOM_RULES_DIR/web-vulns" ]] && [[ -n "$(ls -A "$CUSTOM_RULES_DIR/web-vulns" 2>/dev/null)" ]]; then
-            CUSTOM_RULE_ARGS+=("--config=$CUSTOM_RULES_DIR/web-vulns")
-            CUSTOM_RULES_INFO+="web-vulns "
+        # Rules with metadata.deprecated-by warn when they match
+        LIFECYCLE_INDEX=$(rule_lifecycle_index "$CUSTOM_RULES_DIR")
+        # Rules with metadata.canary report to semgrep-canary/ instead
+        CANARY_INDEX=$(rule_canary_index "$CUSTOM_RULES_DIR")
+        # A --rules selection leaves out the custom rules it doesn't match
+        if [[ -n "$RULE_SELECTION" ]]; then
+            build_rule_selection_excludes "$RULE_SELECTION" "$CUSTOM_RULES_DIR" "$TEMPLATE_OUT_DIR"
+            CUSTOM_RULES_INFO+=" (selected: $SELECTED_RULE_COUNT rules)"
         fi
-        if [[ -d "$CUSTOM_RULES_DIR/custom" ]] && [[ -n "$(ls -A "$CUSTOM_RULES_DIR/custom" 2>/dev/null)" ]]; then
-            CUSTOM_RULE_ARGS+=("--config=$CUSTOM_RULES_DIR/custom")
-            CUSTOM_RULES_INFO+="custom "
-        fi
-        if [[ -d "$CUSTOM_RULES_DIR/patterns" ]] && [[ -n "$(ls -A "$CUSTOM_RULES_DIR/patterns" 2>/dev/null)" ]]; then
-            CUSTOM_RULE_ARGS+=("--config=$CUSTOM_RULES_DIR/patterns")
-            CUSTOM_RULES_INFO+="patterns "
+        # A budget leaves out rules below HIGH confidence
+        if [[ -n "$BUDGET_SECONDS" ]]; then
+            build_budget_rule_excludes "$CUSTOM_RULES_DIR" "$TEMPLATE_OUT_DIR"
+            CUSTOM_RULES_INFO+=" (budget: HIGH confidence only, ${#BUDGET_EXCLUDE_ARGS[@]} rules skipped)"
         fi
     else
         echo "Note: Custom rules directory not found at $CUSTOM_RULES_DIR"
@@ -226,6 +401,11 @@ if [[ "$USE_CUSTOM_RULES" == true ]]; then
     fi
 fi
 
+if [[ -n "$RULE_SELECTION" && ${#SEMGREP_CONFIG_ARGS[@]} -eq 0 && "${SELECTED_RULE_COUNT:-0}" -eq 0 ]]; then
+    echo "Error: --rules '$RULE_SELECTION' matches no rules"
+    exit 1
+fi
+
 # Get only active (non-archived) repos - archived repos are secrets-only
 REPOS=$(get_active_repos "$REPOS_DIR")
 REPO_COUNT=$(echo "$REPOS" | grep -c . || echo 0)
@@ -233,50 +413,47 @@ ARCHIVED_COUNT=$(count_archived_repos "$REPOS_DIR")
 
 log_verbose "Scanning $REPO_COUNT repositories with Semgrep Pro"
 [[ "$ARCHIVED_COUNT" -gt 0 ]] && log_verbose "  (skipping $ARCHIVED_COUNT archived repos - secrets-only)"
-log_verbose "Config: p/default + p/secrets (high-confidence, CI-optimized)"
+log_verbose "Profile: $PROFILE_NAME"
+log_verbose "Config: $(echo ${PROFILE_SEMGREP_CONFIGS:-none} | sed 's/ / + /g')"
+if [[ -n "$RULE_BUNDLES_INFO" ]]; then
+    log_verbose "Rule cache: $RULE_BUNDLES_INFO($(rule_bundle_dir))"
+fi
 if [[ -n "$CUSTOM_RULES_INFO" ]]; then
     log_verbose "Custom: $CUSTOM_RULES_INFO"
 fi
 log_verbose "Engine: Pro (cross-file dataflow analysis enabled)"
-log_verbose "Filters: severity=ERROR,WARNING | excluding tests/examples/vendor"
+log_verbose "Filters: severity=$(echo $PROFILE_SEVERITIES | tr ' ' ',') | excluding tests/examples/vendor"
+log_verbose "File limits: $(format_size_kb $((MAX_TARGET_BYTES / 1024))) max (0 = none), skipping binary and minified files"
 log_verbose "Excluded rules: ${#EXCLUDE_RULES[@]} known false-positive patterns"
+[[ -n "$BUDGET_SECONDS" ]] && log_verbose "Budget: ${BUDGET_SECONDS}s, recently changed files first"
 log_verbose "Results: $RESULTS_DIR/"
 log_verbose ""
 
-# Convert repos to array for counting
-REPOS_ARRAY=()
-while IFS= read -r repo; do
-    [[ -n "$repo" ]] && REPOS_ARRAY+=("$repo")
-done <<< "$REPOS"
-
-current=0
-for repo in "${REPOS_ARRAY[@]}"; do
-    name=$(basename "$repo")
-    current=$((current + 1))
-
-    if [[ -n "$QUIET_MODE" ]]; then
-        log_progress "$current" "$REPO_COUNT" "$name"
-    else
-        echo "[$name] Scanning..."
-    fi
-
-    # Create temp file for semgrep output (will be gzipped)
-    tmp_output=$(mktemp)
-
-    # Run semgrep with Pro engine for cross-file dataflow analysis
-    # - --pro: Enables cross-file, cross-function taint tracking
-    # - p/default: CI-optimized ruleset (replaces p/security-audit which has many FPs)
-    # - p/secrets: Secret detection
-    # - Excludes test/example/vendor paths
-    # - Excludes minified files
-    # - Excludes known false-positive rules
+# Run semgrep with Pro engine for cross-file dataflow analysis
+# - --pro: Enables cross-file, cross-function taint tracking
+# - Registry rulesets from the profile (default: p/default + p/secrets;
+#   p/security-audit only in the audit profile since it has many FPs)
+# - Excludes test/example/vendor paths
+# - Excludes minified files and files detected as binary by content
+# - --max-target-bytes: Skips files over MAX_FILE_SIZE_SEMGREP
+# - Excludes known false-positive rules and rules not applicable here
+# - --dataflow-traces: Taint findings record their source-to-sink path, which
+#   extract-semgrep-findings.sh uses to rate them HIGH confidence
+# - Under --budget, only HIGH-confidence custom rules
+# Args: $1 = output file, $2... = extra semgrep arguments
+# Returns semgrep's exit status when the scan failed (2 and up; 1 only
+# means findings under --error)
+run_semgrep() {
+    local output="$1"
+    local status=0
+    shift
     semgrep scan \
         --pro \
-        --config=p/default \
-        --config=p/secrets \
+        --dataflow-traces \
+        ${SEMGREP_CONFIG_ARGS[@]+"${SEMGREP_CONFIG_ARGS[@]}"} \
         ${CUSTOM_RULE_ARGS[@]+"${CUSTOM_RULE_ARGS[@]}"} \
-        --severity=ERROR \
-        --severity=WARNING \
+        ${TEMPLATE_RULE_ARGS[@]+"${TEMPLATE_RULE_ARGS[@]}"} \
+        ${SEVERITY_ARGS[@]+"${SEVERITY_ARGS[@]}"} \
         --exclude='**/test/**' \
         --exclude='**/tests/**' \
         --exclude='**/__tests__/**' \
@@ -291,22 +468,383 @@ for repo in "${REPOS_ARRAY[@]}"; do
         --exclude='**/*.min.js' \
         --exclude='**/*.min.css' \
         --exclude='**/*.bundle.js' \
+        --max-target-bytes="$MAX_TARGET_BYTES" \
         "${EXCLUDE_RULE_ARGS[@]}" \
+        ${APPLICABILITY_EXCLUDE_ARGS[@]+"${APPLICABILITY_EXCLUDE_ARGS[@]}"} \
+        ${IGNORE_ARGS[@]+"${IGNORE_ARGS[@]}"} \
+        ${SKIP_ARGS[@]+"${SKIP_ARGS[@]}"} \
+        ${SELECTION_EXCLUDE_ARGS[@]+"${SELECTION_EXCLUDE_ARGS[@]}"} \
+        ${BUDGET_EXCLUDE_ARGS[@]+"${BUDGET_EXCLUDE_ARGS[@]}"} \
+        "$@" \
         --json \
-        --output="$tmp_output" \
-        "$repo" 2>&1 | grep -v "^Scanning" | grep -v "^Ran" | grep -v "^Some files" || true
+        --output="$output" \
+        "$repo" ${SUBMODULE_TARGETS[@]+"${SUBMODULE_TARGETS[@]}"} 2>&1 | grep -v "^Scanning" | grep -v "^Ran" | grep -v "^Some files" \
+        || status=${PIPESTATUS[0]}
+    [[ "$status" -le 1 ]] || return "$status"
+}
+
+# Leave the files of a --budget pass that didn't finish in BUDGET_SKIPPED:
+# as "budget" when it ran out of time, or "scan_failed" when semgrep failed,
+# which also adds the pass to FAILED_PASSES ("<pass>\t<exit status>" lines)
+# Args: $1 = pass name, $2 = exit status (124 = out of time), $3 = files
+budget_pass_unscanned() {
+    local reason="budget"
+    if [[ "$2" -ne 124 ]]; then
+        reason="scan_failed"
+        FAILED_PASSES+="$1"$'\t'"$2"$'\n'
+    fi
+    BUDGET_SKIPPED+=$(sed '/^$/d; s/$/\t'"$reason"'/' <<< "$3")$'\n'
+}
+
+# Merge semgrep JSON outputs: results, errors, and scanned and skipped
+# paths of each; files without results (a pass that didn't finish) are
+# left out
+# Args: $1... = semgrep JSON files
+# Prints the merged JSON
+merge_semgrep_outputs() {
+    local file
+    local outputs=()
+
+    for file in "$@"; do
+        jq -e '.results' "$file" > /dev/null 2>&1 && outputs+=("$file")
+    done
+    jq -s '(.[0] // {}) + {
+        results: (map(.results // []) | add // []),
+        errors: (map(.errors // []) | add // []),
+        paths: {scanned: (map(.paths.scanned // []) | add // [] | unique),
+                skipped: (map(.paths.skipped // []) | add // [])}
+    }' /dev/null ${outputs[@]+"${outputs[@]}"}
+}
+
+# Run the language packs scope_routed_rule_args took out of the main pass,
+# each over the directories that contain its language, and merge their
+# findings into the main pass's output. A failed pass is added to
+# FAILED_PASSES as "routed:<packs>"
+# Args: $1 = repo directory, $2 = output file of the main pass
+run_routed_passes() {
+    local repo="$1"
+    local output="$2"
+    local line arg packs pass_output status
+    local pass_args=()
+    local outputs=("$output")
+    local n=0
+
+    while IFS= read -r line; do
+        [[ -z "$line" ]] && continue
+        IFS=$'\t' read -ra pass_args <<< "$line"
+        n=$((n + 1))
+        pass_output="$output.routed$n"
+        register_cleanup "$pass_output"
+        packs=""
+        for arg in "${pass_args[@]}"; do
+            [[ "$arg" == --config=* ]] && packs+="${packs:+,}${arg#--config="$CUSTOM_RULES_DIR"/}"
+        done
+        # Only this pass's packs: registry rules and the multi-language
+        # packs already ran over the whole repo
+        status=0
+        (
+            SEMGREP_CONFIG_ARGS=()
+            CUSTOM_RULE_ARGS=()
+            TEMPLATE_RULE_ARGS=()
+            run_semgrep "$pass_output" "${pass_args[@]}"
+        ) || status=$?
+        if [[ "$status" -ne 0 ]]; then
+            FAILED_PASSES+="routed:$packs"$'\t'"$status"$'\n'
+        else
+            outputs+=("$pass_output")
+        fi
+    done <<< "$ROUTED_RULE_PASSES"
+
+    if [[ "$n" -gt 0 ]]; then
+        merge_semgrep_outputs "${outputs[@]}" > "$output.merged" && mv "$output.merged" "$output"
+        for ((; n > 0; n--)); do
+            rm -f "$output.routed$n"
+        done
+    fi
+}
+
+# Scan a repo within its share of the --budget: recently changed files
+# first, then the rest while time remains. Files in a pass that ran out of
+# time, failed, or never started are left in BUDGET_SKIPPED ("<path>\t<reason>"
+# lines, see budget_pass_unscanned)
+# Args: $1 = repo directory, $2 = output file
+scan_within_budget() {
+    local repo="$1"
+    local output="$2"
+    local now share deadline files recent rest path pass_recent pass_rest
+    local status=0
+    local include_args=()
+    local exclude_args=()
+    BUDGET_SKIPPED=""
+
+    now=$(date +%s)
+    share=$(( (BUDGET_DEADLINE - now) / (REPO_COUNT - current + 1) ))
+    deadline=$((now + share))
+    files=$(repo_files_by_recency "$repo")
+    recent=$(head -n "$BUDGET_PRIORITY_FILES" <<< "$files")
+    rest=$(tail -n +"$((BUDGET_PRIORITY_FILES + 1))" <<< "$files")
+    while IFS= read -r path; do
+        [[ -z "$path" ]] && continue
+        include_args+=("--include=/$path")
+        exclude_args+=("--exclude=/$path")
+    done <<< "$recent"
+
+    pass_recent="$output.recent"
+    pass_rest="$output.rest"
+    register_cleanup "$pass_recent" "$pass_rest"
+    rm -f "$pass_recent" "$pass_rest"
+
+    if [[ "$share" -le 0 ]]; then
+        status=124
+    elif [[ -n "$recent" ]]; then
+        run_with_deadline "$share" run_semgrep "$pass_recent" "${include_args[@]}" || status=$?
+    fi
+    if [[ "$status" -ne 0 ]]; then
+        budget_pass_unscanned recent "$status" "$files"
+        rm -f "$pass_recent"
+    elif [[ -n "$rest" || -z "$recent" ]]; then
+        share=$((deadline - $(date +%s)))
+        if [[ "$share" -le 0 ]]; then
+            status=124
+        else
+            run_with_deadline "$share" run_semgrep "$pass_rest" ${exclude_args[@]+"${exclude_args[@]}"} || status=$?
+        fi
+        if [[ "$status" -ne 0 ]]; then
+            budget_pass_unscanned rest "$status" "$rest"
+            rm -f "$pass_rest"
+        fi
+    fi
+    BUDGET_SKIPPED=$(sed '/^$/d' <<< "$BUDGET_SKIPPED")
+
+    # One result file from the passes that finished
+    merge_semgrep_outputs "$pass_recent" "$pass_rest" > "$output"
+    rm -f "$pass_recent" "$pass_rest"
+}
+
+# Convert repos to array for counting
+REPOS_ARRAY=()
+while IFS= read -r repo; do
+    [[ -n "$repo" ]] && REPOS_ARRAY+=("$repo")
+done <<< "$REPOS"
+
+# Under --budget, the most recently committed repos go first
+if [[ -n "$BUDGET_SECONDS" ]]; then
+    REPOS=$(for repo in ${REPOS_ARRAY[@]+"${REPOS_ARRAY[@]}"}; do
+        printf '%s\t%s\n' "$(git -C "$repo" log -1 --format=%ct 2>/dev/null || echo 0)" "$repo"
+    done | sort -rn | cut -f2-)
+    REPOS_ARRAY=()
+    while IFS= read -r repo; do
+        [[ -n "$repo" ]] && REPOS_ARRAY+=("$repo")
+    done <<< "$REPOS"
+fi
+
+current=0
+BUDGET_REPORT=""
+for repo in "${REPOS_ARRAY[@]}"; do
+    name=$(basename "$repo")
+    current=$((current + 1))
+
+    if [[ -n "$QUIET_MODE" ]]; then
+        log_progress "$current" "$REPO_COUNT" "$name"
+    fi
+    log_info "Scanning" target="$name"
+    trace_span_start "semgrep.repo" repo="$name"
+    scanned=0
+    timeouts=0
+
+    # Route language-specific rule packs to the languages this repo contains,
+    # and in a monorepo to the top-level directories that contain each one
+    # (not under --budget, whose file passes already select with --include)
+    ROUTED_RULE_ARGS=()
+    ROUTED_RULE_PASSES=""
+    if [[ "$USE_CUSTOM_RULES" == true && "$USE_ROUTING" == true && -d "${CUSTOM_RULES_DIR:-}" ]]; then
+        repo_roots=$(detect_repo_language_roots "$repo")
+        repo_languages=$(cut -f1 <<< "$repo_roots" | sort -u)
+        build_routed_rule_args "$CUSTOM_RULES_DIR" "$repo_languages"
+        [[ -z "$BUDGET_SECONDS" ]] && scope_routed_rule_args "$repo_roots"
+        log_info "Languages: $(echo $repo_languages)" target="$name"
+        while IFS= read -r pass; do
+            [[ -z "$pass" ]] && continue
+            log_info "Scoped $(tr '\t' '\n' <<< "$pass" | sed -n "s#^--config=$CUSTOM_RULES_DIR/##p" | paste -sd, -) to $(tr '\t' '\n' <<< "$pass" | sed -n 's#^--include=/##p' | paste -sd, -)" target="$name"
+        done <<< "$ROUTED_RULE_PASSES"
+    fi
+
+    # Skip custom rules whose applicability predicates (imports, files,
+    # tags) don't hold for this repo
+    build_applicability_excludes "$repo" "${APPLICABILITY_INDEX:-}"
+    if [[ ${#APPLICABILITY_EXCLUDE_ARGS[@]} -gt 0 ]]; then
+        log_info "Skipping ${#APPLICABILITY_EXCLUDE_ARGS[@]} rules not applicable to this repo" target="$name"
+        while IFS=$'\t' read -r rule_id kind; do
+            [[ -n "$rule_id" ]] && log_debug "Rule not applicable" target="$name" rule_id="$rule_id" predicate="$kind"
+        done <<< "$INAPPLICABLE_RULES"
+    fi
+
+    # Paths excluded by .bountyhunterignore files (repo-level, nested, and
+    # an org-wide file in the repos directory)
+    IGNORE_ARGS=()
+    ignored_prefixes=$(ignored_path_prefixes "$repo" "$REPOS_DIR/$IGNORE_FILE_NAME")
+    while IFS= read -r prefix; do
+        [[ -z "$prefix" ]] && continue
+        if [[ "$prefix" == */ ]]; then
+            IGNORE_ARGS+=("--exclude=${prefix}**")
+        else
+            IGNORE_ARGS+=("--exclude=$prefix")
+        fi
+    done <<< "$ignored_prefixes"
+    if [[ ${#IGNORE_ARGS[@]} -gt 0 ]]; then
+        log_info "Ignoring ${#IGNORE_ARGS[@]} paths from $IGNORE_FILE_NAME" target="$name"
+    fi
+
+    # Binary files with source extensions (e.g. MPEG-TS video saved as .ts)
+    # and minified JS/CSS without a .min suffix crash or stall the parser
+    SKIP_ARGS=()
+    skipped_files=$(repo_skipped_files "$repo" 0 minified)
+    while IFS=$'\t' read -r path reason; do
+        [[ -z "$path" ]] && continue
+        SKIP_ARGS+=("--exclude=$path")
+    done <<< "$skipped_files"
+    if [[ ${#SKIP_ARGS[@]} -gt 0 ]]; then
+        log_info "Skipping ${#SKIP_ARGS[@]} binary or minified files" target="$name"
+    fi
+
+    # Submodules are scanned as their own targets; semgrep lists files with
+    # git, which leaves submodule contents out of the parent repo
+    SUBMODULE_TARGETS=()
+    while IFS= read -r submodule; do
+        [[ -z "$submodule" ]] && continue
+        grep -qxF -e "$submodule" -e "$submodule/" <<< "$ignored_prefixes" && continue
+        SUBMODULE_TARGETS+=("$repo/$submodule")
+    done < <(repo_submodule_paths "$repo")
+    if [[ ${#SUBMODULE_TARGETS[@]} -gt 0 ]]; then
+        log_info "Including ${#SUBMODULE_TARGETS[@]} submodules" target="$name"
+    fi
+
+    # Create temp file for semgrep output (will be gzipped)
+    tmp_output=$(mktemp)
+    register_cleanup "$tmp_output"
+    BUDGET_SKIPPED=""
+    FAILED_PASSES=""
+
+    if [[ -n "$BUDGET_SECONDS" ]]; then
+        scan_within_budget "$repo" "$tmp_output"
+        if [[ -n "$BUDGET_SKIPPED" ]]; then
+            out_of_time=$(grep -c $'\tbudget$' <<< "$BUDGET_SKIPPED" || true)
+            [[ "$out_of_time" -gt 0 ]] && log_warn "Out of time budget, $out_of_time files not scanned" target="$name"
+            skipped_files+=$'\n'"$BUDGET_SKIPPED"
+            BUDGET_REPORT+="$name"$'\t'"$(grep -c . <<< "$BUDGET_SKIPPED")"$'\n'
+        fi
+    else
+        status=0
+        run_semgrep "$tmp_output" || status=$?
+        [[ "$status" -ne 0 ]] && FAILED_PASSES="full"$'\t'"$status"$'\n'
+        run_routed_passes "$repo" "$tmp_output"
+    fi
+    while IFS=$'\t' read -r pass status; do
+        [[ -n "$pass" ]] && log_warn "Semgrep failed (exit $status), $pass pass not scanned" target="$name" pass="$pass" exit_status="$status"
+    done <<< "$FAILED_PASSES"
 
     # Gzip the output
     if [[ -f "$tmp_output" && -s "$tmp_output" ]]; then
+        # Templated code and files mid-merge fail to parse as a whole; rescan
+        # them with the template tags and conflict sides masked so rules
+        # still match the rest, and report them as partial, not skipped
+        if [[ "$USE_PARSE_RECOVERY" == true ]]; then
+            recovered=$(recover_unparseable_files "$tmp_output" "${PARSE_RECOVERY_MAX_FILES:-50}" \
+                --pro --dataflow-traces \
+                ${SEMGREP_CONFIG_ARGS[@]+"${SEMGREP_CONFIG_ARGS[@]}"} \
+                ${CUSTOM_RULE_ARGS[@]+"${CUSTOM_RULE_ARGS[@]}"} \
+                ${ROUTED_RULE_ARGS[@]+"${ROUTED_RULE_ARGS[@]}"} \
+                ${TEMPLATE_RULE_ARGS[@]+"${TEMPLATE_RULE_ARGS[@]}"} \
+                ${SEVERITY_ARGS[@]+"${SEVERITY_ARGS[@]}"} \
+                --max-target-bytes="$MAX_TARGET_BYTES" \
+                "${EXCLUDE_RULE_ARGS[@]}" \
+                ${APPLICABILITY_EXCLUDE_ARGS[@]+"${APPLICABILITY_EXCLUDE_ARGS[@]}"} \
+                ${SELECTION_EXCLUDE_ARGS[@]+"${SELECTION_EXCLUDE_ARGS[@]}"} \
+                ${BUDGET_EXCLUDE_ARGS[@]+"${BUDGET_EXCLUDE_ARGS[@]}"} 2>/dev/null || echo "0")
+            if [[ "${recovered:-0}" -gt 0 ]]; then
+                log_info "Recovered $recovered files semgrep could not parse" target="$name" recovered_files="$recovered"
+            fi
+        fi
+        restore_bundle_check_ids "$tmp_output" "$RULE_BUNDLE_PREFIX" 2>/dev/null || true
+        # Attribute findings to their Go module / submodule
+        annotate_semgrep_modules "$tmp_output" "$repo" 2>/dev/null || true
+        annotate_semgrep_permalinks "$tmp_output" "$repo" "$ORG" 2>/dev/null || true
+        annotate_semgrep_structure "$tmp_output" "$repo" "$name" 2>/dev/null || true
+        filter_inapplicable_findings "$tmp_output" "$INAPPLICABLE_RULES" 2>/dev/null || true
+        # gosec / staticcheck findings join the same result file
+        if [[ "$USE_GO_ANALYZERS" == true && -f "$repo/go.mod" ]]; then
+            for analyzer in gosec staticcheck; do
+                command -v "$analyzer" &> /dev/null || continue
+                analyzer_out=$(mktemp)
+                analyzer_results=$(mktemp)
+                register_cleanup "$analyzer_out" "$analyzer_results"
+                # Both exit non-zero when they report issues
+                if [[ "$analyzer" == gosec ]]; then
+                    (cd "$repo" && gosec -quiet -fmt=json -out "$analyzer_out" ./... > /dev/null 2>&1) || true
+                else
+                    (cd "$repo" && staticcheck -f json ./... > "$analyzer_out" 2>/dev/null) || true
+                fi
+                if go_analyzer_to_semgrep "$analyzer" "$analyzer_out" "$repo" "$(cd "$repo" && pwd)" \
+                    > "$analyzer_results" 2>/dev/null; then
+                    merged=$(merge_analyzer_results "$tmp_output" "$analyzer" "$analyzer_results")
+                    log_info "Merged $merged $analyzer findings" target="$name" analyzer="$analyzer" findings="$merged"
+                else
+                    log_warn "Could not read $analyzer output" target="$name" analyzer="$analyzer"
+                fi
+                rm -f "$analyzer_out" "$analyzer_results"
+            done
+        fi
+        # Findings reachable from auth, payment, or admin entry points
+        # matter more: raise them one severity level
+        if [[ "$USE_ESCALATION" == true ]]; then
+            escalated=$(escalate_sensitive_findings "$tmp_output" "$repo" 2>/dev/null || echo "0")
+            if [[ "${escalated:-0}" -gt 0 ]]; then
+                log_info "Escalated $escalated findings on auth, payment, or admin paths" target="$name" escalated="$escalated"
+            fi
+        fi
+        # Canary findings are kept in the shadow channel, out of the results
+        # every report, baseline, and exit code is computed from
+        if [[ -n "${CANARY_INDEX:-}" ]]; then
+            mkdir -p "$CANARY_DIR"
+            canary_tmp=$(mktemp)
+            register_cleanup "$canary_tmp"
+            canary=$(split_canary_findings "$tmp_output" "$CANARY_INDEX" "$canary_tmp" 2>/dev/null || echo "0")
+            if [[ "${canary:-0}" -gt 0 ]]; then
+                gzip -c "$canary_tmp" > "$CANARY_DIR/$name.json.gz"
+                log_info "Recorded $canary canary rule findings" target="$name" canary_findings="$canary"
+            else
+                rm -f "$CANARY_DIR/$name.json.gz"
+            fi
+            rm -f "$canary_tmp"
+        fi
         gzip -c "$tmp_output" > "$RESULTS_DIR/$name.json.gz"
         count=$(jq '.results | length' "$tmp_output" 2>/dev/null || echo "0")
-        if [[ -z "$QUIET_MODE" ]]; then
-            echo "[$name] Found $count findings"
+        log_info "Found $count findings" target="$name" findings="$count"
+
+        # One debug event per finding so logs can be joined with results
+        if [[ $(log_level_rank debug) -ge $(log_level_rank "$LOG_LEVEL") ]]; then
+            while IFS=$'\t' read -r rule_id file line; do
+                log_debug "Finding" target="$name" rule_id="$rule_id" file="$file" line="$line"
+            done < <(jq -r '.results[] | [.check_id, .path, .start.line] | @tsv' "$tmp_output" 2>/dev/null)
         fi
-    else
-        if [[ -z "$QUIET_MODE" ]]; then
-            echo "[$name] No results"
+
+        # Diagnostics: what was scanned, skipped, and timed out
+        if semgrep_diagnostics "$tmp_output" "$name" "$ignored_prefixes" "$skipped_files" "$FAILED_PASSES" \
+            > "$DIAGNOSTICS_DIR/$name.json" 2>/dev/null; then
+            read -r scanned skipped partial timeouts < <(jq -r '"\(.scanned_files) \(.skipped | length) \(.partial | length) \(.timeouts | length)"' \
+                "$DIAGNOSTICS_DIR/$name.json")
+            log_info "Diagnostics: $scanned files scanned, $skipped skipped, $partial partly parsed, $timeouts rule timeouts" \
+                target="$name" scanned_files="$scanned" skipped_files="$skipped" partial_files="$partial" timeouts="$timeouts"
+            while IFS=$'\t' read -r rule_id file; do
+                log_warn "Rule timed out" target="$name" rule_id="$rule_id" file="$file"
+            done < <(jq -r '.timeouts[] | [.rule_id, .path] | @tsv' "$DIAGNOSTICS_DIR/$name.json")
+        else
+            rm -f "$DIAGNOSTICS_DIR/$name.json"
         fi
+        trace_span_end "$([[ -z "$FAILED_PASSES" ]] && echo ok || echo error)" findings="$count" scanned_files="$scanned" timeouts="$timeouts"
+    else
+        log_warn "No results" target="$name"
+        trace_span_end error
     fi
     rm -f "$tmp_output"
 done
@@ -333,6 +871,45 @@ done
 
 log_verbose ""
 echo "Semgrep: $total findings"
+if [[ -d "$CANARY_DIR" ]] && ls "$CANARY_DIR"/*.json.gz > /dev/null 2>&1; then
+    echo "Canary rules: $(for f in "$CANARY_DIR"/*.json.gz; do gzip -dc "$f"; done | jq -s 'map(.results | length) | add') shadow findings in $CANARY_DIR (./scripts/rules.sh canary)"
+fi
+
+# Diagnostics totals across repos
+shopt -s nullglob
+diagnostics_files=("$DIAGNOSTICS_DIR"/*.json)
+shopt -u nullglob
+if [[ ${#diagnostics_files[@]} -gt 0 ]]; then
+    jq -rs '"Diagnostics: \(map(.scanned_files) | add) files scanned, \(map(.skipped | length) | add) skipped, \(map(.partial // [] | length) | add) partly parsed, \(map(.timeouts | length) | add) rule timeouts\(map(.failed_passes // [] | length) | add | if . > 0 then ", \(.) failed semgrep passes" else "" end) (extract-semgrep-findings.sh <org> diagnostics)"' \
+        "${diagnostics_files[@]}"
+fi
+
+# What a budgeted scan left out
+if [[ -n "$BUDGET_SECONDS" ]]; then
+    BUDGET_FILE="${RESULTS_DIR%/semgrep-results}/semgrep-budget.json"
+    jq -n --argjson budget "$BUDGET_SECONDS" --argjson used "$(( $(date +%s) - BUDGET_STARTED ))" \
+        --arg rules "${BUDGET_SKIPPED_RULES:-}" --arg repos "$BUDGET_REPORT" '
+        {budget_seconds: $budget, used_seconds: $used,
+         skipped_rules: ($rules | split("\n") | map(select(. != ""))),
+         skipped_files: ($repos | split("\n") | map(select(. != "") | split("\t") | {key: .[0], value: (.[1] | tonumber)}) | from_entries)}
+    ' > "$BUDGET_FILE"
+    jq -r '"Budget: used \(.used_seconds)s of \(.budget_seconds)s; skipped \(.skipped_files | add // 0) files in \(.skipped_files | length) repos and \(.skipped_rules | length) lower-confidence rules (\(input_filename))"' \
+        "$BUDGET_FILE"
+fi
+
+# Migration warnings for deprecated rules that still match
+if [[ "$total" -gt 0 && -n "${LIFECYCLE_INDEX:-}" ]]; then
+    deprecated=$(for f in "$RESULTS_DIR"/*.json.gz; do
+        [[ -f "$f" ]] && gzip -dc "$f"
+    done | jq -s '{results: map(.results // []) | add}' | deprecated_rule_findings /dev/stdin "$LIFECYCLE_INDEX")
+    if [[ -n "$deprecated" ]]; then
+        while IFS=$'\t' read -r rule_id replacement removal count; do
+            log_warn "Deprecated rule $rule_id matched $count times; use $replacement${removal:+ (removed in $removal)}" \
+                rule_id="$rule_id" replacement="$replacement"
+        done <<< "$deprecated"
+        log_verbose "Copy their triage to the replacements: ./scripts/rules.sh migrate $ORG"
+    fi
+fi
 
 # Show top rules if we have findings
 if [[ "$total" -gt 0 ]] && [[ -z "$QUIET_MODE" ]]; then
@@ -0,0 +1,124 @@
+#!/usr/bin/env bash
+# Report secrets reused across an org's repos or commits
+#
+# Usage: ./scripts/secret-reuse.sh <org> [options]
+#
+# The same credential turning up in several repos, or committed again and
+# again in one, points to a shared key that should be one finding and one
+# rotation, not a dozen separate reports. Trufflehog findings are matched
+# by a hash of the secret value, and each reused secret is listed once
+# with every place it was found, a severity level above its single-use
+# severity. Secret values are never printed.
+#
+# Examples:
+#   ./scripts/secret-reuse.sh acme-corp
+#   ./scripts/secret-reuse.sh acme-corp --across-repos
+#   ./scripts/secret-reuse.sh acme-corp --json | jq '.[] | select(.verified)'
+
+set -euo pipefail
+
+SCRIPT_DIR="$(cd "$(dirname "${BASH_SOURCE[0]}")" && pwd)"
+source "$SCRIPT_DIR/lib/catalog-utils.sh"
+source "$SCRIPT_DIR/lib/finding-utils.sh"
+
+usage() {
+    cat << EOF
+Usage: $0 <org> [options]
+
+List secrets found more than once in an org's trufflehog results, each
+with the repos, files, and commits it appears in.
+
+Options:
+    --across-repos      Only secrets found in more than one repo (not the
+                        same repo's history)
+    --json              Print the reused secrets as a JSON array
+    -h, --help          Show this help message
+
+Reads scans/<org>/trufflehog-results, written by catalog-scan.sh or
+scan-secrets.sh. Secrets are matched by the SHA-256 of their value; the
+first 16 hex characters identify each one in the report.
+
+Examples:
+    $0 acme-corp
+    $0 acme-corp --across-repos
+    $0 acme-corp --json
+EOF
+    exit 1
+}
+
+ORG=""
+ACROSS_REPOS=false
+JSON_OUTPUT=false
+
+while [[ $# -gt 0 ]]; do
+    case "$1" in
+        --across-repos)
+            ACROSS_REPOS=true
+            shift
+            ;;
+        --json)
+            JSON_OUTPUT=true
+            shift
+            ;;
+        -h|--help)
+            usage
+            ;;
+        -*)
+            echo "Unknown option: $1"
+            usage
+            ;;
+        *)
+            if [[ -z "$ORG" ]]; then
+                ORG="$1"
+            else
+                echo "Unexpected argument: $1"
+                usage
+            fi
+            shift
+            ;;
+    esac
+done
+
+[[ -z "$ORG" ]] && usage
+
+validate_org_name "$ORG" || exit 1
+require_jq || exit 1
+
+RESULTS_DIR="$CATALOG_ROOT/scans/$ORG/trufflehog-results"
+if [[ ! -d "$RESULTS_DIR" ]]; then
+    echo "Error: No trufflehog results at $RESULTS_DIR"
+    echo "Run: ./scripts/catalog-scan.sh $ORG --secrets"
+    exit 1
+fi
+
+# =============================================================================
+# Analysis
+# =============================================================================
+
+REUSED=$(secret_reuse "$RESULTS_DIR" | jq -c --argjson across "$ACROSS_REPOS" \
+    'map(select(($across | not) or (.repos | length) > 1))')
+COUNT=$(jq 'length' <<< "$REUSED")
+
+if [[ "$JSON_OUTPUT" == true ]]; then
+    jq '.' <<< "$REUSED"
+    exit 0
+fi
+
+if [[ "$COUNT" -eq 0 ]]; then
+    echo "No reused secrets in $ORG"
+    exit 0
+fi
+
+# =============================================================================
+# Report
+# =============================================================================
+
+jq -r '"\(length) reused secrets in \(map(.repos[]) | unique | length) repos (\(map(.occurrences | length) | add) findings)"' <<< "$REUSED"
+jq -r '.[]
+    | "",
+      "[\(.severity)] \(.detectors | join(", ")) \(.hash)  \(.repos | length) repos, \(.commits) commits\(if .verified then ", verified live" else "" end)",
+      "    repos: \(.repos | join(", "))",
+      (.occurrences[] | "    \(.repo)/\(.path):\(.line // "?")\(if .commit then "  (\(.commit[0:12]))" else "" end)")' <<< "$REUSED"
+echo ""
+echo "Rotate each secret once and remove it from every location; history keeps"
+echo "old commits readable, so rotation is what closes the exposure."
@@ -0,0 +1,187 @@
+#!/usr/bin/env bash
+# Update the toolkit to the latest signed release
+#
+# Usage: ./scripts/self-update.sh [options]
+#
+# The toolkit ships as a git checkout, so a release is a signed tag on its
+# remote (vX.Y.Z stable, vX.Y.Z-rcN beta) rather than a per-arch binary.
+# The update fetches the newest tag on the channel, refuses it unless
+# `git verify-tag` accepts the signature and the signing key is the pinned
+# SELF_UPDATE_SIGNING_KEY, and merges it into the current branch. Catalog commits made by catalog-scan.sh are kept; if the merge
+# can't complete, it is aborted and the checkout is left as it was.
+#
+# Examples:
+#   ./scripts/self-update.sh --check            # Report whether an update exists
+#   ./scripts/self-update.sh                    # Update to the latest stable release
+#   ./scripts/self-update.sh --channel beta -y  # Agents: latest pre-release, no prompt
+
+set -euo pipefail
+
+SCRIPT_DIR="$(cd "$(dirname "${BASH_SOURCE[0]}")" && pwd)"
+source "$SCRIPT_DIR/lib/catalog-utils.sh"
+
+# Channel and remote defaults from .env
+if [[ -f "$CATALOG_ROOT/.env" ]]; then
+    set -a
+    # shellcheck disable=SC1091
+    source "$CATALOG_ROOT/.env"
+    set +a
+fi
+
+usage() {
+    cat << EOF
+Usage: $0 [options]
+
+Update the toolkit to the latest signed release on a channel.
+
+Options:
+    --channel <name>     Release channel: stable or beta
+                         (default: SELF_UPDATE_CHANNEL from .env, else stable)
+    --remote <name>      Git remote to read releases from (default: origin)
+    --check              Only report the current and latest release;
+                         exits 10 when an update is available
+    -y, --yes            Skip confirmation prompt
+    -h, --help           Show this help message
+
+Release tags must have a signature git verifies (a GPG key in your
+keyring, or an SSH key listed in gpg.ssh.allowedSignersFile) made by
+the release key pinned in SELF_UPDATE_SIGNING_KEY (.env): its GPG
+fingerprint, or SHA256:... for an SSH key. Any other key is refused,
+even one git trusts.
+
+Exit codes:
+    0   Up to date, or updated
+    1   Error (no release, bad signature, dirty checkout, merge conflict)
+    10  Update available (--check only)
+EOF
+    exit 1
+}
+
+CHANNEL="${SELF_UPDATE_CHANNEL:-stable}"
+SIGNING_KEY="${SELF_UPDATE_SIGNING_KEY:-}"
+REMOTE="origin"
+CHECK_ONLY=false
+ASSUME_YES=false
+
+while [[ $# -gt 0 ]]; do
+    case "$1" in
+        --channel)
+            CHANNEL="$2"
+            shift 2
+            ;;
+        --remote)
+            REMOTE="$2"
+            shift 2
+            ;;
+        --check)
+            CHECK_ONLY=true
+            shift
+            ;;
+        -y|--yes)
+            ASSUME_YES=true
+            shift
+            ;;
+        -h|--help)
+            usage
+            ;;
+        *)
+            echo "Unknown option: $1"
+            usage
+            ;;
+    esac
+done
+
+case "$CHANNEL" in
+    stable|beta) ;;
+    *)
+        echo "Error: Unknown channel '$CHANNEL' (use stable or beta)"
+        exit 1
+        ;;
+esac
+
+if ! git -C "$CATALOG_ROOT" rev-parse --git-dir &> /dev/null; then
+    echo "Error: $CATALOG_ROOT is not a git checkout, update it manually"
+    exit 1
+fi
+
+if ! git -C "$CATALOG_ROOT" remote get-url "$REMOTE" &> /dev/null; then
+    echo "Error: Remote '$REMOTE' not found"
+    exit 1
+fi
+
+CURRENT=$(current_release_tag || echo "")
+
+LATEST=$(git -C "$CATALOG_ROOT" ls-remote --tags --refs "$REMOTE" 'v*' 2>/dev/null | \
+    awk '{sub("refs/tags/", "", $2); print $2}' | latest_release_tag "$CHANNEL") || {
+    echo "Error: No $CHANNEL releases found on $REMOTE"
+    exit 1
+}
+
+echo "Channel: $CHANNEL"
+echo "Current: ${CURRENT:-unreleased}"
+echo "Latest:  $LATEST"
+
+# Fetch the tag itself; a tag already present locally is not replaced
+if ! git -C "$CATALOG_ROOT" fetch --quiet --no-tags "$REMOTE" "refs/tags/$LATEST:refs/tags/$LATEST" 2>/dev/null; then
+    if ! git -C "$CATALOG_ROOT" rev-parse --verify --quiet "refs/tags/$LATEST" > /dev/null; then
+        echo "Error: Could not fetch $LATEST from $REMOTE"
+        exit 1
+    fi
+fi
+
+if git -C "$CATALOG_ROOT" merge-base --is-ancestor "$LATEST^{commit}" HEAD 2>/dev/null; then
+    echo "Up to date"
+    exit 0
+fi
+
+if [[ "$CHECK_ONLY" == true ]]; then
+    echo "Update available: run $0 --channel $CHANNEL to install"
+    exit 10
+fi
+
+# Signature first: an unsigned tag, or one signed by any key but the pinned
+# release key, is never merged; git trusts every key in the keyring
+if [[ -z "$SIGNING_KEY" ]]; then
+    echo "Error: SELF_UPDATE_SIGNING_KEY is not set"
+    echo "Pin the release key's fingerprint in .env before updating"
+    exit 1
+fi
+if ! git -C "$CATALOG_ROOT" verify-tag "$LATEST" 2>/dev/null; then
+    echo "Error: $LATEST is not signed by a trusted key (git verify-tag failed)"
+    echo "Import the release key, or check the tag with: git verify-tag -v $LATEST"
+    exit 1
+fi
+if ! release_signed_by "$LATEST" "$SIGNING_KEY"; then
+    echo "Error: $LATEST is not signed by the pinned release key $SIGNING_KEY"
+    echo "Check the signer with: git verify-tag --raw $LATEST"
+    exit 1
+fi
+echo "Signature: verified ($SIGNING_KEY)"
+
+# Tracked changes would be mixed into the merge; untracked scan output is fine
+if [[ -n "$(git -C "$CATALOG_ROOT" status --porcelain --untracked-files=no)" ]]; then
+    echo "Error: Uncommitted changes in $CATALOG_ROOT, commit or stash them first"
+    exit 1
+fi
+
+if [[ "$ASSUME_YES" != true ]]; then
+    read -r -p "Update ${CURRENT:-this checkout} to $LATEST? [y/N] " answer
+    if [[ ! "$answer" =~ ^[Yy]$ ]]; then
+        echo "Cancelled"
+        exit 0
+    fi
+fi
+
+# The merge either completes or is aborted, so a failed update leaves the
+# previous release checked out
+if ! git -C "$CATALOG_ROOT" merge --quiet --no-edit -m "Update to $LATEST" "$LATEST" 2>/dev/null; then
+    git -C "$CATALOG_ROOT" merge --abort 2>/dev/null || true
+    echo "Error: $LATEST does not merge cleanly with local commits, update aborted"
+    echo "Resolve manually with: git merge $LATEST"
+    exit 1
+fi
+
+git -C "$CATALOG_ROOT" submodule update --init --recursive --quiet 2>/dev/null || \
+    echo "Warning: Could not update rule submodules, run: git submodule update --init --recursive"
+
+echo "Updated to $LATEST"
@@ -0,0 +1,223 @@
+#!/usr/bin/env bash
+# Export a query console session as a single HTML document
+#
+# Usage: ./scripts/session-report.sh <org> [session] [options]
+#
+# Renders a session logged by query.sh - the queries run, their matches
+# with permalinks and their enclosing functions, notes, and the dispositions
+# given to reviewed matches - as one self-contained HTML file for client deliverables or sharing what
+# an audit covered.
+#
+# Examples:
+#   ./scripts/session-report.sh acme-corp                    # Latest session
+#   ./scripts/session-report.sh acme-corp 20260301-141502 --output audit.html
+#   ./scripts/session-report.sh acme-corp --list
+
+set -euo pipefail
+
+SCRIPT_DIR="$(cd "$(dirname "${BASH_SOURCE[0]}")" && pwd)"
+source "$SCRIPT_DIR/lib/catalog-utils.sh"
+source "$SCRIPT_DIR/lib/query-utils.sh"
+source "$SCRIPT_DIR/lib/snippet-utils.sh"
+source "$SCRIPT_DIR/lib/report-utils.sh"
+
+usage() {
+    cat << EOF
+Usage: $0 <org> [session] [options]
+
+Export a query.sh console session (queries, matches, notes, dispositions)
+as a single HTML document.
+
+Options:
+    --output <file>     HTML file to write
+                        (default: scans/<org>/$QUERY_SESSIONS_DIR/<session>.html)
+    --title <text>      Document title (default: "Audit session: <org>")
+    --confirmed-only    Only list matches marked confirmed
+    --lang <code>       Language of the report (default: the org's report_lang,
+                        then REPORT_LANG or en; see locales/)
+    --list              List the org's sessions
+    -h, --help          Show this help message
+
+The session defaults to the org's most recent one.
+
+Examples:
+    $0 acme-corp
+    $0 acme-corp 20260301-141502 --output audit.html
+    $0 acme-corp --list
+EOF
+    exit 1
+}
+
+ORG=""
+SESSION_ID=""
+OUTPUT=""
+TITLE=""
+CONFIRMED_ONLY=false
+LANG_CODE=""
+LIST=false
+
+while [[ $# -gt 0 ]]; do
+    case "$1" in
+        --output)
+            OUTPUT="$2"
+            shift 2
+            ;;
+        --title)
+            TITLE="$2"
+            shift 2
+            ;;
+        --lang)
+            LANG_CODE="$2"
+            shift 2
+            ;;
+        --confirmed-only)
+            CONFIRMED_ONLY=true
+            shift
+            ;;
+        --list)
+            LIST=true
+            shift
+            ;;
+        -h|--help)
+            usage
+            ;;
+        -*)
+            echo "Unknown option: $1"
+            usage
+            ;;
+        *)
+            if [[ -z "$ORG" ]]; then
+                ORG="$1"
+            elif [[ -z "$SESSION_ID" ]]; then
+                SESSION_ID="$1"
+            else
+                echo "Unexpected argument: $1"
+                usage
+            fi
+            shift
+            ;;
+    esac
+done
+
+[[ -z "$ORG" ]] && usage
+
+validate_org_name "$ORG" || exit 1
+require_jq || exit 1
+
+SESSIONS_DIR="$CATALOG_ROOT/scans/$ORG/$QUERY_SESSIONS_DIR"
+
+if [[ "$LIST" == true ]]; then
+    if [[ -z "$(latest_session "$ORG")" ]]; then
+        echo "No query sessions for $ORG"
+        echo "Start one with: ./scripts/query.sh $ORG"
+        exit 0
+    fi
+    printf "%-24s  %-20s  %7s  %7s  %9s\n" "SESSION" "STARTED" "QUERIES" "MATCHES" "CONFIRMED"
+    for file in $(ls -t "$SESSIONS_DIR"/*.jsonl); do
+        jq -r -s --arg id "$(basename "$file" .jsonl)" '
+            [$id, (.[0].at // ""), ([.[] | select(.type == "query")] | length),
+             ([.[] | select(.type == "query") | .matches | length] | add // 0),
+             ([.[] | select(.type == "disposition")] | group_by(.match) | map(last | select(.status == "confirmed")) | length)]
+            | @tsv
+        ' "$file"
+    done | while IFS=$'\t' read -r id started queries matches confirmed; do
+        printf "%-24s  %-20s  %7s  %7s  %9s\n" "$id" "$started" "$queries" "$matches" "$confirmed"
+    done
+    exit 0
+fi
+
+[[ -z "$SESSION_ID" ]] && SESSION_ID=$(latest_session "$ORG")
+if [[ -z "$SESSION_ID" ]]; then
+    echo "Error: No query sessions for $ORG"
+    echo "Start one with: ./scripts/query.sh $ORG"
+    exit 1
+fi
+
+SESSION_FILE=$(session_file "$ORG" "$SESSION_ID")
+if [[ ! -f "$SESSION_FILE" ]]; then
+    echo "Error: Session not found: $SESSION_FILE"
+    echo "List sessions with: $0 $ORG --list"
+    exit 1
+fi
+
+[[ -z "$OUTPUT" ]] && OUTPUT="$SESSIONS_DIR/$SESSION_ID.html"
+LANG_CODE="${LANG_CODE:-$(org_report_lang "$ORG")}"
+if ! LOCALE=$(load_locale "$LANG_CODE"); then
+    echo "Error: No translation for '$LANG_CODE' in $LOCALES_DIR"
+    exit 1
+fi
+[[ -z "$TITLE" ]] && TITLE=$(localize_data "$(jq -n -c --arg org "$ORG" '{org: $org}')" "$LOCALE" | jq -r '.t.session.title')
+
+# =============================================================================
+# Report
+# =============================================================================
+
+# Each match's enclosing function, from the clone, keyed by location
+SNIPPETS=$(mktemp)
+register_cleanup "$SNIPPETS"
+REPOS_DIR=$(get_org_repos_dir "$ORG")
+jq -r -s '[.[] | select(.type == "query") | .matches[] | [.repo, .path, .line] | @tsv] | unique[]' "$SESSION_FILE" | \
+    while IFS=$'\t' read -r repo path line; do
+        snippet=$(code_snippet "$REPOS_DIR/$repo/$path" "$line" "$line" "" "$path" 2>/dev/null) || continue
+        jq -n -c --arg key "$repo/$path:$line" --arg html "$(snippet_html <<< "$snippet")" \
+            --argjson snippet "$snippet" '{key: $key, value: {kind: $snippet.kind, start: $snippet.start, end: $snippet.end, html: $html}}'
+    done | jq -s 'from_entries' > "$SNIPPETS"
+
+mkdir -p "$(dirname "$OUTPUT")"
+jq -r -s --arg title "$TITLE" --arg session "$SESSION_ID" --argjson confirmed_only "$CONFIRMED_ONLY" \
+    --slurpfile snippets "$SNIPPETS" --argjson locale "$LOCALE" '
+    def h: tostring | @html;
+    def tr($name; $vars):
+        reduce ($vars | to_entries[]) as $v ($locale.strings.session[$name]; gsub("\\{\($v.key)\\}"; $v.value | tostring));
+    def status_name: if . == null then null else $locale.status[.] // . end;
+    def key: "\(.repo)/\(.path):\(.line)";
+    def describe:
+        if ((.sinks // []) | length) > 0 then
+            "sources:\n" + ((.sources // []) | map("  " + .) | join("\n"))
+            + "\nsinks:\n" + (.sinks | map("  " + .) | join("\n"))
+            + (if ((.sanitizers // []) | length) > 0 then "\nsanitizers:\n" + (.sanitizers | map("  " + .) | join("\n")) else "" end)
+        elif (.pattern // "") != "" then .pattern
+        else "regex: " + .regex end;
+    def kind: if ((.sinks // []) | length) > 0 then "taint" elif (.pattern // "") != "" then "pattern" else "regex" end;
+
+    . as $events
+    | ([$events[] | select(.type == "disposition")] | group_by(.match | key) | map({key: (.[0].match | key), value: last}) | from_entries) as $marks
+    | ([$events[] | select(.type == "query")]) as $queries
+    | ($marks | [.[].status] | group_by(.) | map({key: .[0], value: length}) | from_entries) as $counts
+    | "<!DOCTYPE html>",
+      "<html lang=\"\($locale.locale | h)\"><head><meta charset=\"utf-8\"><title>\($title | h)</title>",
+      "<style>body{font-family:sans-serif;margin:2em;max-width:1100px}pre{background:#f6f8fa;padding:8px;overflow-x:auto}table{border-collapse:collapse;width:100%}th,td{padding:4px 8px;border:1px solid #ddd;text-align:left;vertical-align:top}td.loc,td.code{font-family:monospace}.note{border-left:4px solid #2563eb;padding:4px 12px;margin:1em 0;background:#eff6ff;white-space:pre-wrap}.confirmed{background:#fee2e2}.false-positive{color:#6b7280}.needs-review{background:#fef9c3}.meta{color:#6b7280}</style>",
+      "</head><body>",
+      "<h1>\($title | h)</h1>",
+      "<p class=\"meta\">\(tr("period"; {session: $session, from: ($events[0].at // ""), to: ($events[-1].at // "")}) | h)</p>",
+      "<p>\(tr("summary"; {queries: ($queries | length), matches: ([$queries[].matches | length] | add // 0), reviewed: ($marks | length),
+                          confirmed: ($counts["confirmed"] // 0), false_positives: ($counts["false-positive"] // 0),
+                          needs_review: ($counts["needs-review"] // 0)}) | h)</p>",
+      (foreach $events[] as $e (0; if $e.type == "query" then . + 1 else . end;
+          . as $n
+          | if $e.type == "note" then "<div class=\"note\">\($e.text | h)</div>"
+            elif $e.type == "query" then
+                ($e.matches | map(. + {mark: $marks[key]}) | map(select(($confirmed_only | not) or .mark.status == "confirmed"))) as $rows
+                | "<h2>\(tr("query"; {n: $n}) | h) <span class=\"meta\">(\($e.query | kind), \($e.query.lang // "generic" | h), \($e.at | h))</span></h2>",
+                  (if ($e.note // "") != "" then "<div class=\"note\">\($e.note | h)</div>" else empty end),
+                  "<pre>\($e.query | describe | h)</pre>",
+                  (if ($rows | length) == 0 then "<p class=\"meta\">\(tr(if $confirmed_only then "no_confirmed" else "no_matches" end; {}) | h)</p>"
+                   else
+                     "<table><tr>\(["location", "code", "disposition", "note"] | map("<th>\(tr(.; {}) | h)</th>") | join(""))</tr>",
+                     ($rows[] | "<tr class=\"\(.mark.status // "" | h)\"><td class=\"loc\">"
+                         + (if .url then "<a href=\"\(.url | h)\">\(key | h)</a>" else (key | h) end)
+                         + "</td><td class=\"code\">\(.code | h)"
+                         + ($snippets[0][key] | if . then "<details><summary>\(tr(if .kind == "function" then "function" else "context" end; {start: .start, end: .end}) | h)</summary>\(.html)</details>" else "" end)
+                         + "</td><td>\(.mark.status | status_name // "" | h)</td><td>\(.mark.note // "" | h)</td></tr>"),
+                     "</table>"
+                   end)
+            else empty end)),
+      "</body></html>"
+' "$SESSION_FILE" > "$OUTPUT"
+
+echo "Report: $OUTPUT"
+jq -r -s '
+    ([.[] | select(.type == "disposition")] | group_by(.match) | map(last)) as $marks
+    | "\([.[] | select(.type == "query")] | length) queries, \([.[] | select(.type == "note")] | length) notes, "
+      + "\($marks | length) reviewed matches (\([$marks[] | select(.status == "confirmed")] | length) confirmed)"
+' "$SESSION_FILE"
@@ -0,0 +1,318 @@
+#!/usr/bin/env bash
+# Share read-only, expiring links to single findings
+#
+# Usage: ./scripts/share-finding.sh <org> <command> [options]
+#
+# `create` renders one finding (rule, location, message, the code in its
+# enclosing function, permalink) with secrets redacted into a standalone
+# page under SHARE_DIR, named by an unguessable token. Publish SHARE_DIR on
+# any static host and the link needs no login, so a program triager can see
+# the evidence without dashboard access. Every link expires; `prune` deletes pages past their expiry or
+# revoked, and hunt.sh runs it after each hunt.
+#
+# Examples:
+#   ./scripts/share-finding.sh acme-corp create 3f9a1c2b --days 7
+#   ./scripts/share-finding.sh acme-corp create api/internal/db/query.go:42 --note "Reachable from /export"
+#   ./scripts/share-finding.sh acme-corp revoke 9c1e4b7a
+#   ./scripts/share-finding.sh acme-corp prune
+
+set -euo pipefail
+
+SCRIPT_DIR="$(cd "$(dirname "${BASH_SOURCE[0]}")" && pwd)"
+source "$SCRIPT_DIR/lib/catalog-utils.sh"
+source "$SCRIPT_DIR/lib/finding-utils.sh"
+source "$SCRIPT_DIR/lib/report-utils.sh"
+source "$SCRIPT_DIR/lib/snippet-utils.sh"
+
+# Publishing settings from .env
+load_env_settings 'SHARE_[A-Z_]+|REPORT_LANG'
+
+DEFAULT_DAYS=7
+SHARE_DIR="${SHARE_DIR:-$CATALOG_ROOT/shares}"
+SHARE_TEMPLATE="$REPORT_TEMPLATES_DIR/share/finding.html"
+
+usage() {
+    cat << EOF
+Usage: $0 <org> <command> [options]
+
+Share read-only, expiring links to single findings, with secrets redacted.
+
+Commands:
+    create <finding>    Publish a finding (id, id prefix, or <repo>/<path>:<line>)
+                        and print its link
+    list                List shared links with days left (default)
+    revoke <token>      Take a link down now
+    prune               Delete expired links
+
+Options:
+    --days <n>          Expire in <n> days (default: $DEFAULT_DAYS, max: $SHARE_MAX_DAYS)
+    --expires <date>    Expire on YYYY-MM-DD instead
+    --note <text>       Context for the reader, shown below the finding
+    --lang <code>       Language of the page (default: the org's report_lang,
+                        then REPORT_LANG or en; see locales/)
+    -q, --quiet         prune: only print errors
+    -h, --help          Show this help message
+
+Pages are written to SHARE_DIR (default: shares/) as <token>/index.html.
+Serve that directory from a static host and set SHARE_BASE_URL to its URL
+to get full links. Links are recorded in catalog/tracked/<org>/shares.json.
+
+Examples:
+    $0 acme-corp create 3f9a1c2b --days 7
+    $0 acme-corp create api/internal/db/query.go:42 --note "Reachable from /export"
+    $0 acme-corp revoke 9c1e4b7a
+EOF
+    exit 1
+}
+
+ORG=""
+COMMAND=""
+ID=""
+DAYS=""
+EXPIRES=""
+NOTE=""
+LANG_CODE=""
+QUIET=false
+
+while [[ $# -gt 0 ]]; do
+    case "$1" in
+        --days)
+            DAYS="$2"
+            shift 2
+            ;;
+        --expires)
+            EXPIRES="$2"
+            shift 2
+            ;;
+        --note)
+            NOTE="$2"
+            shift 2
+            ;;
+        --lang)
+            LANG_CODE="$2"
+            shift 2
+            ;;
+        -q|--quiet)
+            QUIET=true
+            shift
+            ;;
+        -h|--help)
+            usage
+            ;;
+        -*)
+            echo "Unknown option: $1"
+            usage
+            ;;
+        *)
+            if [[ -z "$ORG" ]]; then
+                ORG="$1"
+            elif [[ -z "$COMMAND" ]]; then
+                COMMAND="$1"
+            elif [[ -z "$ID" ]]; then
+                ID="$1"
+            else
+                echo "Unexpected argument: $1"
+                usage
+            fi
+            shift
+            ;;
+    esac
+done
+
+COMMAND="${COMMAND:-list}"
+
+if [[ -z "$ORG" ]]; then
+    echo "Error: org name is required"
+    echo ""
+    usage
+fi
+
+validate_org_name "$ORG" || exit 1
+require_jq || exit 1
+
+if [[ ! -d "$CATALOG_ROOT/catalog/tracked/$ORG" ]]; then
+    echo "Error: '$ORG' is not tracked"
+    exit 1
+fi
+
+FILE=$(shares_file "$ORG")
+TODAY=$(date +%Y-%m-%d)
+
+# Apply a jq filter to the shares file in place
+update_file() {
+    local tmp
+    [[ -f "$FILE" ]] || echo '{"shares": []}' > "$FILE"
+    tmp=$(mktemp)
+    jq --sort-keys "$@" "$FILE" > "$tmp" && mv "$tmp" "$FILE"
+}
+
+require_id() {
+    if [[ -z "$ID" ]]; then
+        echo "Error: $COMMAND requires a share token (see: $0 $ORG list)"
+        exit 1
+    fi
+    if ! jq -e --arg id "$ID" '.shares[]? | select(.token == $id)' "$FILE" > /dev/null 2>&1; then
+        echo "Error: No shared link with token '$ID'"
+        exit 1
+    fi
+}
+
+# Link to a shared page: under SHARE_BASE_URL, or the file itself
+share_link() {
+    if [[ -n "${SHARE_BASE_URL:-}" ]]; then
+        echo "${SHARE_BASE_URL%/}/$1/"
+    else
+        echo "$SHARE_DIR/$1/index.html"
+    fi
+}
+
+# Days from today until a date, counting the expiry day itself
+days_until() {
+    local target
+    target=$(date -d "$1" +%s 2>/dev/null || date -j -f %Y-%m-%d "$1" +%s)
+    echo $(( (target - $(date +%s)) / 86400 + 1 ))
+}
+
+# Delete a shared page; tokens are hex, so the path can't escape SHARE_DIR
+remove_page() {
+    [[ "$1" =~ ^[0-9a-f]+$ ]] || return 0
+    rm -rf "${SHARE_DIR:?}/$1"
+}
+
+case "$COMMAND" in
+    create)
+        if [[ -z "$ID" ]]; then
+            echo "Error: create requires a finding id or <repo>/<path>:<line>"
+            exit 1
+        fi
+        if [[ -n "$EXPIRES" ]]; then
+            if [[ ! "$EXPIRES" =~ ^[0-9]{4}-[0-9]{2}-[0-9]{2}$ ]]; then
+                echo "Error: --expires must be a date like 2026-12-31"
+                exit 1
+            fi
+        else
+            DAYS="${DAYS:-$DEFAULT_DAYS}"
+            if [[ ! "$DAYS" =~ ^[0-9]+$ || "$DAYS" -lt 1 ]]; then
+                echo "Error: --days must be a positive number"
+                exit 1
+            fi
+            EXPIRES=$(date_in_days "$DAYS")
+        fi
+        if [[ "$EXPIRES" < "$TODAY" ]]; then
+            echo "Error: --expires is in the past"
+            exit 1
+        fi
+        if [[ "$EXPIRES" > "$(date_in_days "$SHARE_MAX_DAYS")" ]]; then
+            echo "Error: Links last at most $SHARE_MAX_DAYS days (SHARE_MAX_DAYS)"
+            exit 1
+        fi
+
+        LANG_CODE="${LANG_CODE:-$(org_report_lang "$ORG")}"
+        if ! locale=$(load_locale "$LANG_CODE"); then
+            echo "Error: No translation for '$LANG_CODE' in $LOCALES_DIR"
+            exit 1
+        fi
+
+        status=0
+        finding=$(find_org_finding "$ORG" "$ID") || status=$?
+        if [[ "$status" -eq 1 ]]; then
+            echo "Error: No finding '$ID' in $ORG's semgrep results"
+            exit 1
+        elif [[ "$status" -eq 2 ]]; then
+            echo "Error: '$ID' matches several findings; use more of the id"
+            exit 1
+        fi
+
+        # Everything free-form on the page goes through redaction, not just the
+        # code; the snippet is redacted as a whole so keys spanning lines match
+        token=$(openssl rand -hex 16)
+        snippet=$(finding_snippet "$ORG" "$finding")
+        snippet=$(jq -c --arg code "$(jq -r '[.lines[] | .text // ""] | join("\n")' <<< "$snippet" | redact_secrets "$ORG")" \
+            '($code | split("\n")) as $code | .lines |= [to_entries[] | .value + (if .value.gap then {} else {text: $code[.key]} end)]' \
+            <<< "$snippet")
+        remediation=$(jq -c '.remediation' <<< "$finding")
+        for field in summary before after; do
+            remediation=$(jq -c --arg field "$field" \
+                --arg value "$(jq -r --arg field "$field" '.[$field] // ""' <<< "$remediation" | redact_secrets "$ORG")" \
+                'if . == null or .[$field] == null then . else .[$field] = $value end' <<< "$remediation")
+        done
+        data=$(jq -c --arg org "$ORG" --arg created "$TODAY" --arg expires "$EXPIRES" \
+            --arg lines "$(jq -r '.lines' <<< "$finding" | redact_secrets "$ORG")" \
+            --arg snippet "$(snippet_html <<< "$snippet")" \
+            --arg message "$(jq -r '.message' <<< "$finding" | redact_secrets "$ORG")" \
+            --argjson remediation "$remediation" \
+            --arg note "$(printf '%s' "$NOTE" | redact_secrets "$ORG")" '
+            {org: $org, rule: .check_id, severity: (.severity | ascii_downcase), repo, path, line, end_line,
+             multiline: (.end_line > .line), commit, cwe, references, permalink,
+             message: $message, remediation: $remediation,
+             lines: $lines, snippet: $snippet, note: (if $note == "" then null else $note end),
+             created: $created, expires: $expires}
+        ' <<< "$finding")
+        data=$(localize_data "$data" "$locale")
+
+        mkdir -p "$SHARE_DIR/$token"
+        render_template "$SHARE_TEMPLATE" "$data" html > "$SHARE_DIR/$token/index.html"
+
+        update_file --arg token "$token" --arg created "$TODAY" --arg expires "$EXPIRES" --arg note "$NOTE" \
+            --argjson finding "$finding" '
+            .shares += [{
+                token: $token,
+                finding: $finding.id,
+                repo: $finding.repo,
+                path: $finding.path,
+                line: $finding.line,
+                note: (if $note == "" then null else $note end),
+                created: $created,
+                expires: $expires
+            }]'
+
+        echo "Shared $(jq -r '"\(.check_id | split(".") | last) in \(.repo)/\(.path):\(.line)"' <<< "$finding") until $EXPIRES"
+        share_link "$token"
+        if [[ -z "${SHARE_BASE_URL:-}" ]]; then
+            echo "Publish $SHARE_DIR and set SHARE_BASE_URL for a link others can open"
+        fi
+        ;;
+
+    list)
+        if [[ ! -f "$FILE" ]] || [[ "$(jq '.shares | length' "$FILE")" -eq 0 ]]; then
+            echo "No shared links for $ORG"
+            exit 0
+        fi
+        printf "%-32s  %-10s  %-8s  %s\n" "TOKEN" "EXPIRES" "STATUS" "FINDING"
+        jq -r '.shares | sort_by(.expires)[] | [.token, .expires, "\(.repo)/\(.path):\(.line)"] | @tsv' "$FILE" | \
+            while IFS=$'\t' read -r token expires location; do
+                if [[ "$expires" < "$TODAY" ]]; then
+                    status="expired"
+                else
+                    status="$(days_until "$expires")d left"
+                fi
+                printf "%-32s  %-10s  %-8s  %s\n" "$token" "$expires" "$status" "$location"
+            done
+        ;;
+
+    revoke)
+        require_id
+        remove_page "$ID"
+        update_file --arg id "$ID" '.shares |= map(select(.token != $id))'
+        echo "Revoked $ID"
+        ;;
+
+    prune)
+        [[ -f "$FILE" ]] || exit 0
+        expired=$(jq -r --arg today "$TODAY" '.shares[]? | select(.expires < $today) | .token' "$FILE")
+        if [[ -z "$expired" ]]; then
+            [[ "$QUIET" == true ]] || echo "No expired links for $ORG"
+            exit 0
+        fi
+        while IFS= read -r token; do
+            remove_page "$token"
+        done <<< "$expired"
+        update_file --arg today "$TODAY" '.shares |= map(select(.expires >= $today))'
+        [[ "$QUIET" == true ]] || echo "Removed $(grep -c . <<< "$expired") expired links"
+        ;;
+
+    *)
+        echo "Unknown command: $COMMAND"
+        usage
+        ;;
+esac
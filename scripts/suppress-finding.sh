@@ -0,0 +1,321 @@
+#!/usr/bin/env bash
+# Manage finding suppressions for a tracked org
+#
+# Usage: ./scripts/suppress-finding.sh <org> <command> [options]
+#
+# Suppressions hide a rule's findings (optionally narrowed to a repo, path,
+# or line) from extract-semgrep-findings.sh. Every suppression has an owner
+# and an expiry date; once it expires, its findings show up again until
+# someone re-triages them and renews or removes the entry. A suppression for
+# one line of a scanned finding also records the statement's structure, so it
+# keeps matching after its variables are renamed or its file is.
+#
+# Examples:
+#   ./scripts/suppress-finding.sh acme-corp add --rule go-sql-injection \
+#       --repo api --path internal/db/query.go --reason "Constant table names" --owner alice
+#   ./scripts/suppress-finding.sh acme-corp list
+#   ./scripts/suppress-finding.sh acme-corp prune
+
+set -euo pipefail
+
+SCRIPT_DIR="$(cd "$(dirname "${BASH_SOURCE[0]}")" && pwd)"
+source "$SCRIPT_DIR/lib/catalog-utils.sh"
+source "$SCRIPT_DIR/lib/finding-utils.sh"
+source "$SCRIPT_DIR/lib/rule-utils.sh"
+
+DEFAULT_DAYS=90
+
+usage() {
+    cat << EOF
+Usage: $0 <org> <command> [options]
+
+Manage finding suppressions. Suppressions always expire; expired ones stop
+hiding findings.
+
+Commands:
+    add                 Add a suppression (--rule, --reason, --owner required)
+    list                List suppressions with status and days left (default)
+    renew <id>          Extend a suppression (--days or --expires, --owner optional)
+    remove <id>         Delete a suppression
+    prune               Delete expired suppressions
+
+Options:
+    --rule <id>         Rule id (full check_id or its last segment)
+    --repo <name>       Only findings in this repo
+    --path <path>       Only findings in this file or directory (repo-relative)
+    --line <n>          Only findings starting on this line
+    --reason <text>     Why the finding is not a bug
+    --owner <name>      Who is accountable for re-checking it
+    --days <n>          Expire in <n> days (default: $DEFAULT_DAYS, max: $SUPPRESSION_MAX_DAYS)
+    --expires <date>    Expire on YYYY-MM-DD instead
+    -h, --help          Show this help message
+
+Suppressions are stored in catalog/tracked/<org>/suppressions.json.
+
+Examples:
+    $0 acme-corp add --rule go-sql-injection --repo api --path internal/db --reason "Constant table names" --owner alice
+    $0 acme-corp add --rule missing-csrf --reason "API uses bearer tokens" --owner bob --days 30
+    $0 acme-corp renew 3f9a2c1e --days 60
+    $0 acme-corp list
+EOF
+    exit 1
+}
+
+ORG=""
+COMMAND=""
+ID=""
+RULE=""
+REPO=""
+FILE_PATH=""
+LINE=""
+REASON=""
+OWNER=""
+DAYS=""
+EXPIRES=""
+
+while [[ $# -gt 0 ]]; do
+    case "$1" in
+        --rule)
+            RULE="$2"
+            shift 2
+            ;;
+        --repo)
+            REPO="$2"
+            shift 2
+            ;;
+        --path)
+            FILE_PATH="$2"
+            shift 2
+            ;;
+        --line)
+            LINE="$2"
+            shift 2
+            ;;
+        --reason)
+            REASON="$2"
+            shift 2
+            ;;
+        --owner)
+            OWNER="$2"
+            shift 2
+            ;;
+        --days)
+            DAYS="$2"
+            shift 2
+            ;;
+        --expires)
+            EXPIRES="$2"
+            shift 2
+            ;;
+        -h|--help)
+            usage
+            ;;
+        -*)
+            echo "Unknown option: $1"
+            usage
+            ;;
+        *)
+            if [[ -z "$ORG" ]]; then
+                ORG="$1"
+            elif [[ -z "$COMMAND" ]]; then
+                COMMAND="$1"
+            elif [[ -z "$ID" ]]; then
+                ID="$1"
+            else
+                echo "Unexpected argument: $1"
+                usage
+            fi
+            shift
+            ;;
+    esac
+done
+
+COMMAND="${COMMAND:-list}"
+
+if [[ -z "$ORG" ]]; then
+    echo "Error: org name is required"
+    echo ""
+    usage
+fi
+
+validate_org_name "$ORG" || exit 1
+require_jq || exit 1
+
+if [[ ! -d "$CATALOG_ROOT/catalog/tracked/$ORG" ]]; then
+    echo "Error: '$ORG' is not tracked"
+    exit 1
+fi
+
+FILE=$(suppressions_file "$ORG")
+TODAY=$(date +%Y-%m-%d)
+
+# Expiry date from --days / --expires, within SUPPRESSION_MAX_DAYS
+resolve_expiry() {
+    local latest
+    latest=$(date_in_days "$SUPPRESSION_MAX_DAYS")
+
+    if [[ -n "$DAYS" && -n "$EXPIRES" ]]; then
+        echo "Error: Use --days or --expires, not both" >&2
+        return 1
+    fi
+    if [[ -n "$EXPIRES" ]]; then
+        if [[ ! "$EXPIRES" =~ ^[0-9]{4}-[0-9]{2}-[0-9]{2}$ ]]; then
+            echo "Error: --expires must be a date like 2026-12-31" >&2
+            return 1
+        fi
+    else
+        DAYS="${DAYS:-$DEFAULT_DAYS}"
+        if [[ ! "$DAYS" =~ ^[0-9]+$ || "$DAYS" -lt 1 ]]; then
+            echo "Error: --days must be a positive number" >&2
+            return 1
+        fi
+        if [[ "$DAYS" -gt "$SUPPRESSION_MAX_DAYS" ]]; then
+            echo "Error: Suppressions can last at most $SUPPRESSION_MAX_DAYS days" >&2
+            echo "Set SUPPRESSION_MAX_DAYS to change the limit." >&2
+            return 1
+        fi
+        EXPIRES=$(date_in_days "$DAYS")
+    fi
+    if [[ ! "$EXPIRES" > "$TODAY" ]]; then
+        echo "Error: Expiry must be after today ($TODAY)" >&2
+        return 1
+    fi
+    if [[ "$EXPIRES" > "$latest" ]]; then
+        echo "Error: Suppressions can last at most $SUPPRESSION_MAX_DAYS days (until $latest)" >&2
+        echo "Set SUPPRESSION_MAX_DAYS to change the limit." >&2
+        return 1
+    fi
+    echo "$EXPIRES"
+}
+
+# Apply a jq filter to the suppressions file in place
+update_file() {
+    local tmp
+    [[ -f "$FILE" ]] || echo '{"suppressions": []}' > "$FILE"
+    tmp=$(mktemp)
+    jq --sort-keys "$@" "$FILE" > "$tmp" && mv "$tmp" "$FILE"
+}
+
+require_id() {
+    if [[ -z "$ID" ]]; then
+        echo "Error: $COMMAND requires a suppression id (see: $0 $ORG list)"
+        exit 1
+    fi
+    if ! jq -e --arg id "$ID" '.suppressions[]? | select(.id == $id)' "$FILE" > /dev/null 2>&1; then
+        echo "Error: No suppression with id '$ID'"
+        exit 1
+    fi
+}
+
+case "$COMMAND" in
+    add)
+        if [[ -z "$RULE" || -z "$REASON" || -z "$OWNER" ]]; then
+            echo "Error: add requires --rule, --reason, and --owner"
+            exit 1
+        fi
+        if [[ -n "$LINE" && ! "$LINE" =~ ^[0-9]+$ ]]; then
+            echo "Error: --line must be a line number"
+            exit 1
+        fi
+        EXPIRES=$(resolve_expiry) || exit 1
+        FILE_PATH="${FILE_PATH#./}"
+        ID=$(printf '%s\n%s\n%s\n%s' "$RULE" "$REPO" "$FILE_PATH" "$LINE" | sha256_hex | cut -c1-8)
+
+        if [[ -f "$FILE" ]] && jq -e --arg id "$ID" '.suppressions[]? | select(.id == $id)' "$FILE" > /dev/null; then
+            echo "Error: Suppression $ID already exists for this rule and scope"
+            echo "Extend it with: $0 $ORG renew $ID --days <n>"
+            exit 1
+        fi
+
+        # The id and structural hash of the suppressed statement, when it's
+        # a finding of this rule in the latest scan
+        STRUCTURE=""
+        FINDING=""
+        if [[ -n "$REPO" && -n "$FILE_PATH" && -n "$LINE" ]]; then
+            IFS=$'\t' read -r FINDING STRUCTURE < <(find_org_finding "$ORG" "$REPO/$FILE_PATH:$LINE" 2>/dev/null | \
+                jq -r --arg rule "$RULE" 'select((.check_id == $rule or (.check_id | endswith("." + $rule))) and .structure != null)
+                    | [.id, .structure] | @tsv' | head -1) || true
+        fi
+
+        update_file \
+            --arg id "$ID" --arg rule "$RULE" --arg repo "$REPO" --arg path "$FILE_PATH" \
+            --arg line "$LINE" --arg reason "$REASON" --arg owner "$OWNER" \
+            --arg structure "$STRUCTURE" --arg finding "$FINDING" --arg added "$TODAY" --arg expires "$EXPIRES" '
+            .suppressions += [{
+                id: $id,
+                rule: $rule,
+                repo: (if $repo == "" then null else $repo end),
+                path: (if $path == "" then null else $path end),
+                line: (if $line == "" then null else ($line | tonumber) end),
+                structure: (if $structure == "" then null else $structure end),
+                finding: (if $finding == "" then null else $finding end),
+                reason: $reason,
+                owner: $owner,
+                added: $added,
+                expires: $expires
+            }]'
+        echo "Added suppression $ID for $RULE (owner: $OWNER, expires $EXPIRES)"
+        if [[ -n "$STRUCTURE" ]]; then
+            echo "It follows the statement if its names change or its file is renamed (structure $STRUCTURE)"
+        fi
+        replacement=$(rule_replacement "$RULE" "$(rule_lifecycle_index)" 2> /dev/null || true)
+        if [[ -n "$replacement" ]]; then
+            echo "Warning: $RULE is deprecated in favor of $replacement; copy this suppression with:"
+            echo "  ./scripts/rules.sh migrate $ORG"
+        fi
+        ;;
+
+    renew)
+        require_id
+        EXPIRES=$(resolve_expiry) || exit 1
+        update_file --arg id "$ID" --arg expires "$EXPIRES" --arg owner "$OWNER" --arg today "$TODAY" '
+            .suppressions |= map(if .id == $id then
+                .expires = $expires | .renewed = $today
+                | if $owner != "" then .owner = $owner else . end
+            else . end)'
+        echo "Renewed suppression $ID until $EXPIRES"
+        ;;
+
+    remove)
+        require_id
+        update_file --arg id "$ID" '.suppressions |= map(select(.id != $id))'
+        echo "Removed suppression $ID"
+        ;;
+
+    prune)
+        count=$(expired_suppressions "$FILE" | jq 'length')
+        if [[ "$count" -gt 0 ]]; then
+            update_file --arg today "$TODAY" '.suppressions |= map(select(.expires >= $today))'
+        fi
+        echo "Pruned $count expired suppressions"
+        ;;
+
+    list)
+        if [[ ! -f "$FILE" ]] || [[ "$(jq '.suppressions | length' "$FILE")" -eq 0 ]]; then
+            echo "No suppressions for $ORG"
+            exit 0
+        fi
+        printf "%-8s  %-8s  %-10s  %-12s  %-40s  %s\n" "ID" "STATUS" "EXPIRES" "OWNER" "RULE / SCOPE" "REASON"
+        jq -r --arg today "$TODAY" '
+            .suppressions | sort_by(.expires)[] |
+            [.id, (if .expires < $today then "expired" else "active" end), .expires, .owner,
+             (.rule + (if .repo then " " + .repo else "" end)
+                + (if .path then ":" + .path else "" end)
+                + (if .line then ":" + (.line | tostring) else "" end)),
+             .reason] | @tsv
+        ' "$FILE" | while IFS=$'\t' read -r id status expires owner scope reason; do
+            printf "%-8s  %-8s  %-10s  %-12s  %-40s  %s\n" "$id" "$status" "$expires" "$owner" "$scope" "$reason"
+        done
+
+        expired=$(expired_suppressions "$FILE" | jq 'length')
+        if [[ "$expired" -gt 0 ]]; then
+            echo ""
+            echo "$expired expired: their findings are shown again. Renew or remove them after re-triage."
+        fi
+        ;;
+
+    *)
+        echo "Unknown command: $COMMAND"
+        usage
+        ;;
+esac
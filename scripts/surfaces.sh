@@ -0,0 +1,225 @@
+#!/usr/bin/env bash
+# List the entry points of an org's repositories for manual audit
+#
+# Usage: ./scripts/surfaces.sh [options] <org> [repo]
+#
+# Lists every discovered HTTP route, gRPC method, CLI subcommand, and
+# message consumer with the file and line of its handler, whether or not a
+# scanner reported anything there. The FINDINGS column counts semgrep
+# findings in the handler's file so quiet entry points stand out as places
+# to read by hand.
+#
+# Examples:
+#   ./scripts/surfaces.sh acme-corp
+#   ./scripts/surfaces.sh acme-corp api --kind http
+#   ./scripts/surfaces.sh --format json acme-corp
+
+set -euo pipefail
+
+SCRIPT_DIR="$(cd "$(dirname "${BASH_SOURCE[0]}")" && pwd)"
+source "$SCRIPT_DIR/lib/catalog-utils.sh"
+source "$SCRIPT_DIR/lib/surface-utils.sh"
+
+usage() {
+    cat << EOF
+Usage: $0 [options] <org> [repo]
+
+List entry points per repo (HTTP routes, gRPC methods, CLI subcommands,
+message consumers) with their handler locations.
+
+Options:
+    --kind <kind>       Only http, grpc, cli, or consumer entry points
+    --format <fmt>      summary (default), json, tsv, or count
+    --unreviewed        Only entry points whose handler file has no findings
+    -h, --help          Show this help message
+
+Formats:
+    summary     Entry points grouped by repo
+    json        One JSON array of entry points (also written to scans/<org>/surfaces.json)
+    tsv         repo, kind, framework, method, route, file, line, findings
+    count       Entry points per repo and kind
+
+Detected frameworks: Express/Koa, Flask/FastAPI, Django, Go net/http, gin,
+echo, chi, Spring, Rails, Laravel, gRPC (.proto services), cobra, click,
+argparse, commander, Kafka/RabbitMQ/JMS/SQS listeners, NestJS, Celery.
+
+Examples:
+    $0 acme-corp
+    $0 acme-corp api --kind http
+    $0 --unreviewed acme-corp
+EOF
+    exit 1
+}
+
+ORG=""
+ONLY_REPO=""
+KIND=""
+FORMAT="summary"
+UNREVIEWED=""
+
+while [[ $# -gt 0 ]]; do
+    case "$1" in
+        --kind)
+            KIND="$2"
+            shift 2
+            ;;
+        --format)
+            FORMAT="$2"
+            shift 2
+            ;;
+        --unreviewed)
+            UNREVIEWED="1"
+            shift
+            ;;
+        -h|--help)
+            usage
+            ;;
+        -*)
+            echo "Unknown option: $1"
+            usage
+            ;;
+        *)
+            if [[ -z "$ORG" ]]; then
+                ORG="$1"
+            elif [[ -z "$ONLY_REPO" ]]; then
+                ONLY_REPO="$1"
+            else
+                echo "Unexpected argument: $1"
+                usage
+            fi
+            shift
+            ;;
+    esac
+done
+
+if [[ -z "$ORG" ]]; then
+    echo "Error: org name is required"
+    echo ""
+    usage
+fi
+
+case "$KIND" in
+    ""|http|grpc|cli|consumer) ;;
+    *)
+        echo "Error: Unknown kind '$KIND' (use http, grpc, cli, or consumer)"
+        exit 1
+        ;;
+esac
+
+case "$FORMAT" in
+    summary|json|tsv|count) ;;
+    *)
+        echo "Error: Unknown format '$FORMAT' (use summary, json, tsv, or count)"
+        exit 1
+        ;;
+esac
+
+validate_org_name "$ORG" || exit 1
+require_jq || exit 1
+
+REPOS_DIR="$(get_org_repos_dir "$ORG")"
+if [[ ! -d "$REPOS_DIR" ]]; then
+    echo "Error: No repos found at $REPOS_DIR"
+    echo "Run: ./scripts/clone-org-repos.sh $ORG"
+    exit 1
+fi
+if [[ -n "$ONLY_REPO" && ! -d "$REPOS_DIR/$ONLY_REPO" ]]; then
+    echo "Error: Repo not found: $REPOS_DIR/$ONLY_REPO"
+    exit 1
+fi
+
+RESULTS_DIR="scans/$ORG/semgrep-results"
+OUTPUT_FILE="scans/$ORG/surfaces.json"
+
+TMP_DIR=$(mktemp -d)
+register_cleanup "$TMP_DIR"
+install_cancel_traps
+
+# =============================================================================
+# Discovery
+# =============================================================================
+
+SURFACES="$TMP_DIR/surfaces.tsv"
+: > "$SURFACES"
+
+while IFS= read -r repo; do
+    [[ -z "$repo" ]] && continue
+    name=$(basename "$repo")
+    [[ -n "$ONLY_REPO" && "$name" != "$ONLY_REPO" ]] && continue
+
+    # Findings per file, to show which entry points a scan already covered
+    counts="$TMP_DIR/$name.counts"
+    if [[ -f "$RESULTS_DIR/$name.json.gz" ]]; then
+        gzip -dc "$RESULTS_DIR/$name.json.gz" 2>/dev/null | \
+            jq -r '.results[]?.path' 2>/dev/null | sort | uniq -c | awk '{ print $2 "\t" $1 }' > "$counts" || true
+    else
+        : > "$counts"
+    fi
+
+    repo_entry_points "$repo" | sort -u -t $'\t' -k5,5 -k6,6n -k4,4 | \
+        awk -F'\t' -v OFS='\t' -v repo="$name" -v kind="$KIND" -v counts="$counts" '
+            BEGIN { while ((getline l < counts) > 0) { split(l, p, "\t"); n[p[1]] = p[2] } }
+            kind != "" && $1 != kind { next }
+            {
+                found = 0
+                for (f in n) if (f == $5 || substr(f, length(f) - length($5)) == "/" $5) found += n[f]
+                print repo, $1, $2, $3, $4, $5, $6, found
+            }' >> "$SURFACES"
+done < <(get_active_repos "$REPOS_DIR")
+
+if [[ -n "$UNREVIEWED" ]]; then
+    awk -F'\t' '$8 == 0' "$SURFACES" > "$SURFACES.tmp" && mv "$SURFACES.tmp" "$SURFACES"
+fi
+
+mkdir -p "$(dirname "$OUTPUT_FILE")"
+jq -R -s '
+    split("\n") | map(select(. != "") | split("\t")
+        | {repo: .[0], kind: .[1], framework: .[2], method: .[3], route: .[4],
+           path: .[5], line: (.[6] | tonumber? // null), findings: (.[7] | tonumber)})
+' "$SURFACES" > "$OUTPUT_FILE"
+
+# =============================================================================
+# Output
+# =============================================================================
+
+total=$(grep -c . "$SURFACES" || true)
+
+case "$FORMAT" in
+    json)
+        cat "$OUTPUT_FILE"
+        ;;
+    tsv)
+        cat "$SURFACES"
+        ;;
+    count)
+        printf "%-30s  %6s  %6s  %6s  %8s  %6s\n" "REPO" "HTTP" "GRPC" "CLI" "CONSUMER" "TOTAL"
+        jq -r 'group_by(.repo)[] | [.[0].repo,
+                ([.[] | select(.kind == "http")] | length), ([.[] | select(.kind == "grpc")] | length),
+                ([.[] | select(.kind == "cli")] | length), ([.[] | select(.kind == "consumer")] | length),
+                length] | @tsv' "$OUTPUT_FILE" | \
+            while IFS=$'\t' read -r repo http grpc cli consumer count; do
+                printf "%-30s  %6s  %6s  %6s  %8s  %6s\n" "$repo" "$http" "$grpc" "$cli" "$consumer" "$count"
+            done
+        ;;
+    summary)
+        if [[ "$total" -eq 0 ]]; then
+            echo "No entry points found for $ORG${ONLY_REPO:+/$ONLY_REPO}"
+            exit 0
+        fi
+        current=""
+        while IFS=$'\t' read -r repo kind framework method route file line findings; do
+            if [[ "$repo" != "$current" ]]; then
+                [[ -n "$current" ]] && echo ""
+                echo "$repo"
+                current="$repo"
+            fi
+            note=""
+            [[ "$findings" -gt 0 ]] && note="  ($findings findings in file)"
+            printf "  %-8s  %-7s  %-40s  %s:%s  [%s]%s\n" "$kind" "$method" "$route" "$file" "$line" "$framework" "$note"
+        done < <(sort -t $'\t' -k1,1 -k2,2 -k5,5 "$SURFACES")
+        echo ""
+        unreviewed=$(awk -F'\t' '$8 == 0' "$SURFACES" | grep -c . || true)
+        echo "$total entry points, $unreviewed with no findings in their handler file"
+        echo "Full list: $OUTPUT_FILE"
+        ;;
+esac
@@ -0,0 +1,195 @@
+#!/usr/bin/env bash
+# Score repos by risk and suggest which one to audit next
+#
+# Usage: ./scripts/targets.sh score <org> [options]
+#        ./scripts/targets.sh suggest [org] [options]
+#
+# Scores combine exposure (public, HTTP routes, auth/payment/admin routes,
+# endpoints seen in proxy history), recent churn, and semgrep finding
+# density, scaled down for programs that don't pay bounties (see
+# scripts/lib/risk-utils.sh). `suggest` ranks repos across every active
+# tracked org unless one is given.
+#
+# Examples:
+#   ./scripts/targets.sh suggest                  # Best repos across all active orgs
+#   ./scripts/targets.sh suggest acme-corp --limit 3
+#   ./scripts/targets.sh score acme-corp          # Every repo with its score breakdown
+
+set -euo pipefail
+
+SCRIPT_DIR="$(cd "$(dirname "${BASH_SOURCE[0]}")" && pwd)"
+source "$SCRIPT_DIR/lib/catalog-utils.sh"
+source "$SCRIPT_DIR/lib/risk-utils.sh"
+
+usage() {
+    cat << EOF
+Usage: $0 score <org> [options]
+       $0 suggest [org] [options]
+
+Score repos by exposure, recent churn, and finding density.
+
+Commands:
+    score       Score every active repo of an org
+                (also written to scans/<org>/$RISK_SCORES_FILE)
+    suggest     The highest-scoring repos to audit next, with reasons
+                (all active tracked orgs when no org is given)
+
+Options:
+    --limit <n>         Repos to suggest (default: 5)
+    --days <n>          Churn window in days (default: $RISK_CHURN_DAYS)
+    --format <fmt>      table (default) or json
+    -h, --help          Show this help message
+
+Run surfaces.sh and scan-semgrep.sh first for the exposure and density
+signals; repos without semgrep results get a neutral density score.
+
+Examples:
+    $0 suggest
+    $0 suggest acme-corp --limit 3
+    $0 score acme-corp --format json
+EOF
+    exit 1
+}
+
+[[ $# -lt 1 ]] && usage
+COMMAND="$1"
+shift
+
+ORG=""
+LIMIT=5
+FORMAT="table"
+
+while [[ $# -gt 0 ]]; do
+    case "$1" in
+        --limit)
+            LIMIT="$2"
+            shift 2
+            ;;
+        --days)
+            RISK_CHURN_DAYS="$2"
+            shift 2
+            ;;
+        --format)
+            FORMAT="$2"
+            shift 2
+            ;;
+        -h|--help)
+            usage
+            ;;
+        -*)
+            echo "Unknown option: $1"
+            usage
+            ;;
+        *)
+            if [[ -z "$ORG" ]]; then
+                ORG="$1"
+            else
+                echo "Unexpected argument: $1"
+                usage
+            fi
+            shift
+            ;;
+    esac
+done
+
+case "$COMMAND" in
+    score|suggest) ;;
+    -h|--help) usage ;;
+    *)
+        echo "Unknown command: $COMMAND"
+        usage
+        ;;
+esac
+
+case "$FORMAT" in
+    table|json) ;;
+    *)
+        echo "Error: Unknown format '$FORMAT' (use table or json)"
+        exit 1
+        ;;
+esac
+
+if [[ ! "$LIMIT" =~ ^[0-9]+$ || ! "$RISK_CHURN_DAYS" =~ ^[0-9]+$ ]]; then
+    echo "Error: --limit and --days must be numbers"
+    exit 1
+fi
+
+require_jq || exit 1
+[[ "$COMMAND" == "score" && -z "$ORG" ]] && usage
+
+ORGS=()
+if [[ -n "$ORG" ]]; then
+    validate_org_name "$ORG" || exit 1
+    if ! is_org_tracked "$ORG"; then
+        echo "Error: '$ORG' is not tracked"
+        exit 1
+    fi
+    ORGS=("$ORG")
+else
+    while IFS= read -r org; do
+        [[ -z "$org" ]] && continue
+        is_org_status_archived "$org" && continue
+        [[ -d "$(get_org_repos_dir "$org")" ]] || continue
+        ORGS+=("$org")
+    done < <(list_tracked_orgs)
+fi
+
+if [[ ${#ORGS[@]} -eq 0 ]]; then
+    echo "No active tracked orgs with cloned repos"
+    echo "Run: ./scripts/clone-org-repos.sh <org>"
+    exit 0
+fi
+
+# =============================================================================
+# Scoring
+# =============================================================================
+
+SCORES="[]"
+for org in "${ORGS[@]}"; do
+    org_scores=$(score_org_repos "$org")
+    if [[ "$org_scores" != "[]" ]]; then
+        mkdir -p "$CATALOG_ROOT/scans/$org"
+        echo "$org_scores" > "$CATALOG_ROOT/scans/$org/$RISK_SCORES_FILE"
+    fi
+    SCORES=$(jq -n --argjson all "$SCORES" --argjson s "$org_scores" '$all + $s | sort_by(-.score, .org, .repo)')
+done
+
+if [[ "$(jq length <<< "$SCORES")" -eq 0 ]]; then
+    echo "No active repos to score"
+    exit 0
+fi
+
+# =============================================================================
+# Output
+# =============================================================================
+
+if [[ "$COMMAND" == "suggest" ]]; then
+    SCORES=$(jq --argjson n "$LIMIT" '.[:$n]' <<< "$SCORES")
+fi
+
+if [[ "$FORMAT" == "json" ]]; then
+    echo "$SCORES"
+    exit 0
+fi
+
+if [[ "$COMMAND" == "score" ]]; then
+    printf "%-30s  %6s  %8s  %6s  %7s  %s\n" "REPO" "SCORE" "EXPOSURE" "CHURN" "DENSITY" "PAYOUT"
+    jq -r '.[] | [.repo, .score, .exposure, .churn, .density, .payout] | @tsv' <<< "$SCORES" | \
+        while IFS=$'\t' read -r repo score exposure churn density payout; do
+            printf "%-30s  %6s  %8s  %6s  %7s  %s\n" "$repo" "$score" "$exposure" "$churn" "$density" "$payout"
+        done
+    echo ""
+    echo "Scores: scans/$ORG/$RISK_SCORES_FILE"
+    exit 0
+fi
+
+echo "Suggested targets:"
+rank=0
+while IFS= read -r entry; do
+    rank=$((rank + 1))
+    label=$(jq -r '"\(.org)/\(.repo)"' <<< "$entry")
+    score=$(jq -r '.score' <<< "$entry")
+    printf "  %d. %-40s  %5s  %s\n" "$rank" "$label" "$score" "$(risk_reasons <<< "$entry")"
+done < <(jq -c '.[]' <<< "$SCORES")
+echo ""
+echo "Breakdown: $0 score <org>"
@@ -0,0 +1,241 @@
+#!/usr/bin/env bash
+# Test custom rules against their annotated fixtures
+#
+# Usage: ./scripts/test-rules.sh [options] [path...]
+#
+# Single-file fixtures (rule.test.go next to rule.yaml) go through
+# `semgrep --test`. A fixture directory (rule.test/) holds several files,
+# packages, and a go.mod; it is scanned as one project with the Pro engine,
+# as hunts are, and checked against the same ruleid:/ok: annotations, so
+# rules whose taint crosses files or packages can be tested too.
+#
+# With --explain-failures, each annotation a failing rule gets wrong is
+# followed by semgrep's evaluation of the rule at that line, clause by
+# clause, so the pattern that dropped (or produced) the match is visible.
+#
+# Examples:
+#   ./scripts/test-rules.sh
+#   ./scripts/test-rules.sh custom-rules/web-vulns
+#   ./scripts/test-rules.sh custom-rules/web-vulns/ssrf-taint.yaml
+#   ./scripts/test-rules.sh --explain-failures custom-rules/injection
+
+set -euo pipefail
+
+SCRIPT_DIR="$(cd "$(dirname "${BASH_SOURCE[0]}")" && pwd)"
+source "$SCRIPT_DIR/lib/catalog-utils.sh"
+source "$SCRIPT_DIR/lib/rule-utils.sh"
+
+usage() {
+    cat << EOF
+Usage: $0 [options] [path...]
+
+Run each rule file's fixtures and report rules that miss an annotated
+line or match one they shouldn't.
+
+Arguments:
+    path                Rule files or directories (default: custom-rules/)
+
+Options:
+    -q, --quiet         Only print failures and the summary
+    --explain-failures  After each failing annotation, show how the rule's
+                        clauses evaluated at that line (semgrep
+                        --matching-explanations)
+    -h, --help          Show this help message
+
+Fixtures:
+    <rule>.test.<ext>   Single file, checked with semgrep --test
+    <rule>.test/        Directory scanned as one project (cross-file rules);
+                        add a go.mod for Go packages
+
+Annotate the line before the code (or the code line itself) with
+ruleid: <id> where the rule must match and ok: <id> where it must not.
+
+Examples:
+    $0
+    $0 custom-rules/web-vulns
+    $0 custom-rules/web-vulns/ssrf-taint.yaml
+    $0 --explain-failures custom-rules/injection
+EOF
+    exit 1
+}
+
+PATHS=()
+QUIET=false
+EXPLAIN=false
+
+while [[ $# -gt 0 ]]; do
+    case "$1" in
+        -q|--quiet)
+            QUIET=true
+            shift
+            ;;
+        --explain-failures)
+            EXPLAIN=true
+            shift
+            ;;
+        -h|--help)
+            usage
+            ;;
+        -*)
+            echo "Unknown option: $1"
+            usage
+            ;;
+        *)
+            PATHS+=("$1")
+            shift
+            ;;
+    esac
+done
+
+[[ ${#PATHS[@]} -eq 0 ]] && PATHS=("$RULES_ROOT")
+
+require_jq || exit 1
+
+if ! command -v semgrep &> /dev/null; then
+    echo "Error: semgrep is required but not installed."
+    echo "Install: brew install semgrep"
+    exit 1
+fi
+
+# =============================================================================
+# Collect Rule Files
+# =============================================================================
+
+for path in "${PATHS[@]}"; do
+    if [[ ! -e "$path" ]]; then
+        echo "Error: $path does not exist"
+        exit 1
+    fi
+done
+
+# Rule files, skipping YAML that lives inside a fixture directory or is
+# itself a fixture
+RULE_FILES=$(for path in "${PATHS[@]}"; do
+    find "$path" -type f \( -name '*.yaml' -o -name '*.yml' \) \
+        ! -path '*.test/*' ! -name '*.test.yaml' ! -name '*.test.yml'
+done | sort -u | xargs grep -l '^rules:' 2>/dev/null || true)
+
+if [[ -z "$RULE_FILES" ]]; then
+    echo "No rule files found"
+    exit 1
+fi
+
+# =============================================================================
+# Explain Failures
+# =============================================================================
+
+# Scan a directory again with --matching-explanations and print each
+# mismatch followed by the rule's clause tree at its line. Explanations
+# come from the intra-file engine, so a cross-file match found by --pro
+# shows here as a miss in the file it was reported in.
+# Args: $1 = directory (result paths relative to it), $2 = absolute rule
+#       file, $3 = mismatches as printed by fixture_check
+explain_mismatches() {
+    local dir="$1"
+    local rule_path="$2"
+    local mismatches="$3"
+    local results problem path rest line rule
+
+    if ! results=$(cd "$dir" && semgrep scan --json --quiet --metrics=off --matching-explanations \
+            --config "$rule_path" . 2>/dev/null); then
+        echo "(semgrep failed to explain $dir)"
+        return 0
+    fi
+    while IFS= read -r problem; do
+        [[ -z "$problem" ]] && continue
+        path="${problem%%:*}"
+        rest="${problem#*:}"
+        line="${rest%%:*}"
+        rest="${rest#*: }"
+        rule="${rest%% *}"
+        echo "$problem"
+        fixture_explain "$rule_path" "$results" "$path" "$line" "$rule" | sed 's/^/    /'
+    done <<< "$mismatches"
+}
+
+# Explanations for single-file fixtures: each is copied into a directory of
+# its own and checked like a fixture directory, since semgrep --test
+# reports failures in a form that doesn't name the lines
+# Args: $1 = absolute rule file, $@ = fixture files
+explain_files() {
+    local rule_path="$1"
+    shift
+    local fixture work results mismatches
+
+    for fixture in "$@"; do
+        work=$(mktemp -d)
+        cp "$fixture" "$work/"
+        if results=$(cd "$work" && semgrep scan --json --quiet --metrics=off --config "$rule_path" . 2>/dev/null) \
+                && ! mismatches=$(fixture_check "$work" "$results"); then
+            explain_mismatches "$work" "$rule_path" "$mismatches" | sed "s|^\([^ (]\)|$(dirname "$fixture")/\1|"
+        fi
+        rm -rf "$work"
+    done
+}
+
+# =============================================================================
+# Run Fixtures
+# =============================================================================
+
+passed=0
+failed=0
+untested=0
+
+while IFS= read -r rule_file; do
+    fixtures=$(rule_fixtures "$rule_file")
+    if [[ -z "$fixtures" ]]; then
+        untested=$((untested + 1))
+        continue
+    fi
+
+    problems=""
+    files=()
+    fixture_dir=""
+    while IFS= read -r fixture; do
+        if [[ -d "$fixture" ]]; then
+            fixture_dir="$fixture"
+        else
+            files+=("$fixture")
+        fi
+    done <<< "$fixtures"
+
+    rule_path="$(cd "$(dirname "$rule_file")" && pwd)/$(basename "$rule_file")"
+
+    if [[ ${#files[@]} -gt 0 ]]; then
+        if ! output=$(semgrep --test --metrics=off --config "$rule_file" "${files[@]}" 2>&1); then
+            problems+="$output"$'\n'
+            if [[ "$EXPLAIN" == true ]]; then
+                problems+="$(explain_files "$rule_path" "${files[@]}")"$'\n'
+            fi
+        fi
+    fi
+
+    if [[ -n "$fixture_dir" ]]; then
+        # Scanned from inside the directory so result paths match the
+        # annotations; --pro for the cross-file analysis hunts use
+        if ! results=$(cd "$fixture_dir" && semgrep scan --pro --json --quiet --metrics=off \
+                --config "$rule_path" . 2>/dev/null); then
+            problems+="$fixture_dir: semgrep failed (run: semgrep login)"$'\n'
+        elif ! output=$(fixture_check "$fixture_dir" "$results"); then
+            if [[ "$EXPLAIN" == true ]]; then
+                output=$(explain_mismatches "$fixture_dir" "$rule_path" "$output")
+            fi
+            problems+="$(sed "s|^\([^ (]\)|$fixture_dir/\1|" <<< "$output")"$'\n'
+        fi
+    fi
+
+    name="${rule_file#"$CATALOG_ROOT"/}"
+    if [[ -n "$problems" ]]; then
+        failed=$((failed + 1))
+        echo "FAIL  $name"
+        printf '%s' "$problems" | sed 's/^/      /'
+    else
+        passed=$((passed + 1))
+        [[ "$QUIET" == true ]] || echo "PASS  $name ($(grep -c . <<< "$fixtures") fixtures)"
+    fi
+done <<< "$RULE_FILES"
+
+echo ""
+echo "Rule tests: $passed passed, $failed failed ($untested rule files without fixtures)"
+
+[[ "$failed" -eq 0 ]]
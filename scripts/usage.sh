@@ -0,0 +1,236 @@
+#!/usr/bin/env bash
+# Report scanning usage per org and manage quotas
+#
+# Usage: ./scripts/usage.sh [org] [options]
+#
+# For shared deployments that bill programs or throttle heavy ones: compute
+# time and outside API calls for a month, and storage on disk now, per
+# tracked org, against its quotas. catalog-scan.sh and clone-org-repos.sh
+# record each run; catalog-scan.sh refuses to start for an org that has
+# used up a quota. See scripts/lib/usage-utils.sh for what is metered.
+#
+# Examples:
+#   ./scripts/usage.sh                                  # Every org, this month
+#   ./scripts/usage.sh --month 2026-09 --format csv     # For billing
+#   ./scripts/usage.sh acme-corp --quota-compute 20h --quota-storage 50G
+#   ./scripts/usage.sh acme-corp --clear-quota
+
+set -euo pipefail
+
+SCRIPT_DIR="$(cd "$(dirname "${BASH_SOURCE[0]}")" && pwd)"
+source "$SCRIPT_DIR/lib/catalog-utils.sh"
+source "$SCRIPT_DIR/lib/usage-utils.sh"
+
+usage() {
+    cat << EOF
+Usage: $0 [org] [options]
+
+Show compute time, API calls, and storage for one org, or every tracked
+org, against their quotas; or set an org's quotas.
+
+Options:
+    --month <YYYY-MM>       Month to report (default: this month, UTC)
+    --format <format>       table (default), json, or csv (one row per org)
+    --check                 Exit 2 when a reported org is over a quota
+    --quota-compute <dur>   Set the org's monthly compute quota (e.g. 20h)
+    --quota-api-calls <n>   Set the org's monthly API call quota
+    --quota-storage <size>  Set the org's storage quota (e.g. 50G)
+    --clear-quota           Remove the org's quotas
+    -h, --help              Show this help message
+
+A quota given as "default" goes back to the deployment-wide QUOTA_COMPUTE,
+QUOTA_API_CALLS, or QUOTA_STORAGE from .env; orgs without their own
+quotas use those, and unset means unlimited. Compute is wall-clock scan
+and clone time; API calls are GitHub repo listings and trufflehog's
+verification of each secret it reports.
+
+Examples:
+    $0
+    $0 --month 2026-09 --format csv
+    $0 acme-corp --quota-compute 20h --quota-api-calls 5000
+EOF
+    exit 1
+}
+
+# Deployment-wide quota defaults (QUOTA_*) from .env
+if [[ -f "$CATALOG_ROOT/.env" ]]; then
+    set -a
+    # shellcheck disable=SC1091
+    source "$CATALOG_ROOT/.env"
+    set +a
+fi
+
+ORG=""
+MONTH=""
+FORMAT="table"
+CHECK=false
+QUOTA="{}"
+CLEAR_QUOTA=false
+
+# Add one quota field to QUOTA, "default" as null
+# Args: $1 = field, $2 = value in the field's units, or "default"
+set_quota_field() {
+    local value="$2"
+    [[ "$value" == "default" ]] && value="null"
+    QUOTA=$(jq -c --arg field "$1" --argjson value "$value" '.[$field] = $value' <<< "$QUOTA")
+}
+
+while [[ $# -gt 0 ]]; do
+    case "$1" in
+        --month)
+            MONTH="$2"
+            shift 2
+            ;;
+        --format)
+            FORMAT="$2"
+            shift 2
+            ;;
+        --check)
+            CHECK=true
+            shift
+            ;;
+        --quota-compute)
+            if [[ "$2" == "default" ]]; then
+                set_quota_field compute_s default
+            elif seconds=$(parse_duration_seconds "$2"); then
+                set_quota_field compute_s "$seconds"
+            else
+                echo "Error: Invalid --quota-compute '$2' (e.g. 20h)"
+                exit 1
+            fi
+            shift 2
+            ;;
+        --quota-api-calls)
+            if [[ "$2" == "default" || "$2" =~ ^[0-9]+$ ]]; then
+                set_quota_field api_calls "$2"
+            else
+                echo "Error: Invalid --quota-api-calls '$2'"
+                exit 1
+            fi
+            shift 2
+            ;;
+        --quota-storage)
+            if [[ "$2" == "default" ]]; then
+                set_quota_field storage_kb default
+            elif kb=$(parse_size_kb "$2"); then
+                set_quota_field storage_kb "$kb"
+            else
+                echo "Error: Invalid --quota-storage '$2' (e.g. 50G)"
+                exit 1
+            fi
+            shift 2
+            ;;
+        --clear-quota)
+            CLEAR_QUOTA=true
+            shift
+            ;;
+        -h|--help)
+            usage
+            ;;
+        -*)
+            echo "Unknown option: $1"
+            usage
+            ;;
+        *)
+            if [[ -z "$ORG" ]]; then
+                ORG="$1"
+            else
+                echo "Unexpected argument: $1"
+                usage
+            fi
+            shift
+            ;;
+    esac
+done
+
+case "$FORMAT" in
+    table|json|csv) ;;
+    *)
+        echo "Error: Unknown format '$FORMAT' (use table, json, or csv)"
+        exit 1
+        ;;
+esac
+
+if [[ -n "$MONTH" && ! "$MONTH" =~ ^[0-9]{4}-(0[1-9]|1[0-2])$ ]]; then
+    echo "Error: --month must be YYYY-MM"
+    exit 1
+fi
+MONTH="${MONTH:-$(date -u +%Y-%m)}"
+
+require_jq || exit 1
+
+# =============================================================================
+# Quotas
+# =============================================================================
+
+if [[ "$QUOTA" != "{}" || "$CLEAR_QUOTA" == true ]]; then
+    if [[ -z "$ORG" ]]; then
+        echo "Error: Quotas are set per org; name one"
+        exit 1
+    fi
+    validate_org_name "$ORG" || exit 1
+    if [[ "$CLEAR_QUOTA" == true ]]; then
+        set_org_quota "$ORG" clear || exit 1
+    else
+        set_org_quota "$ORG" "$QUOTA" || exit 1
+    fi
+    org_quota "$ORG" | jq -r --arg org "$ORG" "$USAGE_JQ_DEFS"'
+        to_entries | map(.key as $r | "\($r | usage_resource_name): \(.value | if . == null then "unlimited" else usage_amount($r) end)")
+        | "Quotas for \($org): " + join(", ")'
+    exit 0
+fi
+
+# =============================================================================
+# Report
+# =============================================================================
+
+if [[ -n "$ORG" ]]; then
+    validate_org_name "$ORG" || exit 1
+    if [[ ! -d "$(get_org_catalog_dir "$ORG")" ]]; then
+        echo "Error: '$ORG' is not tracked"
+        exit 1
+    fi
+    ORGS="$ORG"
+else
+    ORGS=$(list_tracked_orgs 2>/dev/null || true)
+fi
+
+SUMMARIES=$(while IFS= read -r org; do
+    [[ -n "$org" ]] && usage_summary "$org" "$MONTH"
+done <<< "$ORGS" | jq -s -c 'sort_by(-.compute_s, .org)')
+
+case "$FORMAT" in
+    json)
+        jq '.' <<< "$SUMMARIES"
+        ;;
+    csv)
+        echo "org,month,scans,runs,compute_s,api_calls,storage_kb,compute_quota_s,api_calls_quota,storage_quota_kb,over_quota"
+        jq -r '.[] | [.org, .month, .scans, .runs, .compute_s, .api_calls, .storage.total_kb,
+                      .quota.compute_s, .quota.api_calls, .quota.storage_kb,
+                      (.over | map(.resource) | join(" "))] | @csv' <<< "$SUMMARIES"
+        ;;
+    *)
+        if [[ "$(jq 'length' <<< "$SUMMARIES")" -eq 0 ]]; then
+            echo "No tracked orgs"
+            exit 0
+        fi
+        echo "Usage for $MONTH (quota in parentheses, ! = over quota)"
+        echo ""
+        jq -r "$USAGE_JQ_DEFS"'
+            def pad($n): tostring | . + (" " * ($n - length));
+            def cell($u; $r): $u.over as $over
+                | (if $r == "storage_kb" then $u.storage.total_kb else $u[$r] end | usage_amount($r))
+                  + (if $u.quota[$r] == null then "" else " (\($u.quota[$r] | usage_amount($r)))" end)
+                  + (if any($over[]; .resource == $r) then " !" else "" end);
+            (([.[].org | length] + [3] | max) + 2) as $w
+            | ("ORG" | pad($w)) + ("SCANS" | pad(7)) + ("COMPUTE" | pad(22)) + ("API CALLS" | pad(18)) + "STORAGE",
+              (.[] | . as $u
+               | (.org | pad($w)) + (.scans | pad(7)) + (cell($u; "compute_s") | pad(22))
+                 + (cell($u; "api_calls") | pad(18)) + cell($u; "storage_kb"))
+        ' <<< "$SUMMARIES"
+        ;;
+esac
+
+if [[ "$CHECK" == true && "$(jq '[.[] | select(.over | length > 0)] | length' <<< "$SUMMARIES")" -gt 0 ]]; then
+    exit 2
+fi
@@ -0,0 +1,292 @@
+#!/usr/bin/env bash
+# Search an org's code for variants of one confirmed finding
+#
+# Usage: ./scripts/variants.sh <org> <finding-id> [options]
+#        ./scripts/variants.sh <org> --list [repo]
+#
+# The seed finding's matched line is generalized: local names, strings, and
+# numbers are abstracted while called functions, member names, and keywords
+# are kept (see generalize_code_line in scripts/lib/history-utils.sh). Every
+# active repo is then searched for lines calling the same functions in files
+# of the same language, ranked by token similarity to the seed; lines with
+# exactly the seed's generalized shape are marked too.
+#
+# Finding ids are semgrep fingerprints of the rule, <repo>/<path>, and
+# matched code; `--list` prints them. A <repo>/<path>:<line> location works
+# as an id too.
+#
+# Examples:
+#   ./scripts/variants.sh acme-corp --list api
+#   ./scripts/variants.sh acme-corp 3f9a1c2b7d4e5f60
+#   ./scripts/variants.sh acme-corp api/app/db.py:42 --min-similarity 0.8
+
+set -euo pipefail
+
+SCRIPT_DIR="$(cd "$(dirname "${BASH_SOURCE[0]}")" && pwd)"
+source "$SCRIPT_DIR/lib/catalog-utils.sh"
+source "$SCRIPT_DIR/lib/finding-utils.sh"
+source "$SCRIPT_DIR/lib/history-utils.sh"
+
+usage() {
+    cat << EOF
+Usage: $0 <org> <finding-id> [options]
+       $0 <org> --list [repo]
+
+Generalize a finding's code and search every repo of the org for
+structurally similar lines.
+
+Options:
+    --list               List finding ids (optionally for one repo)
+    --min-similarity <n> Lowest similarity to report, 0-1 (default: 0.5)
+    --limit <n>          Variants to list (default: 50, 0 = all)
+    --format <fmt>       summary (default) or json
+    -h, --help           Show this help message
+
+Finding ids:
+    <fingerprint>        16-hex id from --list (a unique prefix is enough)
+    <repo>/<path>:<line> The finding at that location
+
+Results are written to scans/<org>/variants-<id>.json. Variants with the
+seed's exact generalized shape are marked [shape], ones semgrep already
+reported [known].
+
+Examples:
+    $0 acme-corp --list api
+    $0 acme-corp 3f9a1c2b
+    $0 acme-corp api/app/db.py:42 --min-similarity 0.8
+EOF
+    exit 1
+}
+
+ORG=""
+SEED=""
+LIST=false
+MIN_SIMILARITY="0.5"
+LIMIT=50
+FORMAT="summary"
+
+while [[ $# -gt 0 ]]; do
+    case "$1" in
+        --list)
+            LIST=true
+            shift
+            ;;
+        --min-similarity)
+            MIN_SIMILARITY="$2"
+            shift 2
+            ;;
+        --limit)
+            LIMIT="$2"
+            shift 2
+            ;;
+        --format)
+            FORMAT="$2"
+            shift 2
+            ;;
+        -h|--help)
+            usage
+            ;;
+        -*)
+            echo "Unknown option: $1"
+            usage
+            ;;
+        *)
+            if [[ -z "$ORG" ]]; then
+                ORG="$1"
+            elif [[ -z "$SEED" ]]; then
+                SEED="$1"
+            else
+                echo "Unexpected argument: $1"
+                usage
+            fi
+            shift
+            ;;
+    esac
+done
+
+[[ -z "$ORG" ]] && usage
+[[ "$LIST" == false && -z "$SEED" ]] && usage
+
+case "$FORMAT" in
+    summary|json) ;;
+    *)
+        echo "Error: Unknown format '$FORMAT' (use summary or json)"
+        exit 1
+        ;;
+esac
+
+if [[ ! "$LIMIT" =~ ^[0-9]+$ ]]; then
+    echo "Error: --limit must be a number"
+    exit 1
+fi
+if [[ ! "$MIN_SIMILARITY" =~ ^(0(\.[0-9]+)?|1(\.0+)?)$ ]]; then
+    echo "Error: --min-similarity must be between 0 and 1"
+    exit 1
+fi
+
+validate_org_name "$ORG" || exit 1
+require_jq || exit 1
+
+REPOS_DIR="$(get_org_repos_dir "$ORG")"
+
+if [[ ! -d "$REPOS_DIR" ]]; then
+    echo "Error: No repos found at $REPOS_DIR"
+    echo "Run: ./scripts/clone-org-repos.sh $ORG"
+    exit 1
+fi
+
+TMP_DIR=$(mktemp -d)
+register_cleanup "$TMP_DIR"
+install_cancel_traps
+
+# =============================================================================
+# Finding Ids
+# =============================================================================
+
+# One line per finding: id, repo, path, line, check_id, matched code
+FINDINGS="$TMP_DIR/findings.tsv"
+
+list_repo=""
+[[ "$LIST" == true ]] && list_repo="$SEED"
+org_semgrep_findings "$ORG" "$list_repo" | \
+    jq -r '[.id, .repo, .path, .line, .check_id, (.lines | gsub("[\t\n]"; " "))] | @tsv' > "$FINDINGS"
+
+if [[ "$LIST" == true ]]; then
+    if [[ ! -s "$FINDINGS" ]]; then
+        echo "No semgrep findings for $ORG${SEED:+/$SEED}"
+        echo "Run: ./scripts/scan-semgrep.sh $ORG"
+        exit 0
+    fi
+    sort -t$'\t' -k2,2 -k3,3 -k4,4n "$FINDINGS" | \
+        while IFS=$'\t' read -r id repo rel line check_id lines; do
+            printf "%s  %-50s  %s\n" "$id" "$repo/$rel:$line" "${check_id##*.}"
+        done
+    exit 0
+fi
+
+if [[ "$SEED" =~ ^(.+):([0-9]+)$ ]]; then
+    matches=$(awk -F'\t' -v loc="${BASH_REMATCH[1]}" -v l="${BASH_REMATCH[2]}" '$2 "/" $3 == loc && $4 == l' "$FINDINGS")
+else
+    matches=$(awk -F'\t' -v id="$SEED" 'index($1, id) == 1' "$FINDINGS")
+    if [[ $(grep -c . <<< "$matches") -gt 1 ]]; then
+        echo "Error: '$SEED' matches more than one finding; use a longer id"
+        exit 1
+    fi
+fi
+
+if [[ -z "$matches" ]]; then
+    echo "Error: No finding matches '$SEED'"
+    echo "List ids with: $0 $ORG --list"
+    exit 1
+fi
+
+IFS=$'\t' read -r SEED_ID SEED_REPO SEED_PATH SEED_LINE SEED_RULE SEED_LINES <<< "$(head -n 1 <<< "$matches")"
+
+# =============================================================================
+# Generalization
+# =============================================================================
+
+# Results scanned without a login have no matched code; read it from the repo
+seed_code="$SEED_LINES"
+if [[ -z "$seed_code" || "$seed_code" == "requires login" ]]; then
+    seed_code=$(sed -n "${SEED_LINE}p" "$REPOS_DIR/$SEED_REPO/$SEED_PATH" 2>/dev/null || echo "")
+fi
+seed_code=$(sed -E 's/^[[:space:]]+//; s/[[:space:]]+$//' <<< "$seed_code")
+
+if [[ -z "$seed_code" ]]; then
+    echo "Error: No code for $SEED_REPO/$SEED_PATH:$SEED_LINE"
+    exit 1
+fi
+
+EXT="${SEED_PATH##*.}"
+if [[ "$EXT" == "$SEED_PATH" ]]; then
+    echo "Error: $SEED_PATH has no extension to match other files by"
+    exit 1
+fi
+
+PATTERN=$(generalize_code_line "$seed_code")
+SEARCH=$(generalize_code_line "$seed_code" loose)
+if [[ -z "$PATTERN" ]]; then
+    echo "Error: The seed code has no function or member names to search for:"
+    echo "  $seed_code"
+    exit 1
+fi
+
+# =============================================================================
+# Search
+# =============================================================================
+
+VARIANTS="$TMP_DIR/variants.tsv"
+: > "$VARIANTS"
+
+while IFS= read -r repo; do
+    [[ -z "$repo" ]] && continue
+    name=$(basename "$repo")
+    template=""
+    if sha=$(git -C "$repo" rev-parse HEAD 2>/dev/null); then
+        template=$(link_template "$repo" "$ORG" line 2>/dev/null || echo "")
+        template="${template//\{sha\}/$sha}"
+    fi
+    while IFS=$'\t' read -r file line text; do
+        [[ -z "$file" ]] && continue
+        [[ "$name" == "$SEED_REPO" && "$file" == "$SEED_PATH" && "$line" == "$SEED_LINE" ]] && continue
+        similarity=$(code_similarity "$seed_code" "$text")
+        awk -v s="$similarity" -v min="$MIN_SIMILARITY" 'BEGIN { exit !(s >= min) }' || continue
+        shape=false
+        if TEXT="$text" PATTERN="$PATTERN" awk 'BEGIN { exit !(ENVIRON["TEXT"] ~ ENVIRON["PATTERN"]) }'; then
+            shape=true
+        fi
+        known=false
+        if awk -F'\t' -v r="$name" -v f="$file" -v l="$line" '$2 == r && $3 == f && $4 == l { found = 1 } END { exit !found }' "$FINDINGS"; then
+            known=true
+        fi
+        url=""
+        if [[ -n "$template" ]]; then
+            url="${template//\{path\}/$file}"
+            url="${url//\{line\}/$line}"
+        fi
+        printf '%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n' "$similarity" "$name" "$file" "$line" "$shape" "$known" "$url" "$text" >> "$VARIANTS"
+    done < <(pattern_occurrences "$repo" "$SEARCH" "$EXT")
+done < <(get_active_repos "$REPOS_DIR")
+
+OUTPUT_FILE="scans/$ORG/variants-$SEED_ID.json"
+mkdir -p "$(dirname "$OUTPUT_FILE")"
+jq -R -s --arg id "$SEED_ID" --arg repo "$SEED_REPO" --arg path "$SEED_PATH" --argjson line "$SEED_LINE" \
+    --arg rule "$SEED_RULE" --arg code "$seed_code" --arg pattern "$PATTERN" '
+    {seed: {id: $id, repo: $repo, path: $path, line: $line, check_id: $rule, code: $code},
+     pattern: $pattern,
+     variants: (split("\n") | map(select(. != "") | split("\t")
+        | {similarity: (.[0] | tonumber), repo: .[1], path: .[2], line: (.[3] | tonumber),
+           shape: (.[4] == "true"), known: (.[5] == "true"), url: (if .[6] == "" then null else .[6] end), text: .[7]})
+        | sort_by(-.similarity, .repo, .path, .line))}
+' "$VARIANTS" > "$OUTPUT_FILE"
+
+# =============================================================================
+# Output
+# =============================================================================
+
+LISTED=$(jq --argjson n "$LIMIT" 'if $n > 0 then .variants |= .[:$n] else . end' "$OUTPUT_FILE")
+
+if [[ "$FORMAT" == "json" ]]; then
+    echo "$LISTED"
+    exit 0
+fi
+
+echo "Seed: $SEED_REPO/$SEED_PATH:$SEED_LINE (${SEED_RULE##*.})"
+echo "  $seed_code"
+echo ""
+
+total=$(jq '.variants | length' "$OUTPUT_FILE")
+if [[ "$total" -eq 0 ]]; then
+    echo "No variants at similarity $MIN_SIMILARITY or above"
+    exit 0
+fi
+
+printf "%4s  %-50s  %s\n" "SIM" "LOCATION" "CODE"
+jq -r '.variants[] | [.similarity, "\(.repo)/\(.path):\(.line)", (if .shape then "[shape] " else "" end) + (if .known then "[known] " else "" end) + (.text | .[0:80])] | @tsv' \
+    <<< "$LISTED" | while IFS=$'\t' read -r similarity location text; do
+        printf "%4s  %-50s  %s\n" "$similarity" "$location" "$text"
+    done
+echo ""
+jq -r '"\(.variants | length) variants (\([.variants[] | select(.shape)] | length) with the same shape, \([.variants[] | select(.known)] | length) already reported)"' "$OUTPUT_FILE"
+echo "Results: $OUTPUT_FILE"
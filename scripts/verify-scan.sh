@@ -0,0 +1,169 @@
+#!/usr/bin/env bash
+# Verify a catalog scan against its signed provenance
+#
+# Usage: ./scripts/verify-scan.sh <org-name> [scan] [options]
+#
+# Examples:
+#   ./scripts/verify-scan.sh acme-corp                              # Latest scan, digests only
+#   ./scripts/verify-scan.sh acme-corp --public-key keys/scan.pub   # Check the signature too
+#   ./scripts/verify-scan.sh acme-corp 2025-01-10-1000 --json       # Machine-readable result
+
+set -euo pipefail
+
+SCRIPT_DIR="$(cd "$(dirname "${BASH_SOURCE[0]}")" && pwd)"
+source "$SCRIPT_DIR/lib/catalog-utils.sh"
+source "$SCRIPT_DIR/lib/provenance-utils.sh"
+
+# Verification key from .env (SCAN_VERIFY_KEY)
+if [[ -f "$CATALOG_ROOT/.env" ]]; then
+    set -a
+    # shellcheck disable=SC1091
+    source "$CATALOG_ROOT/.env"
+    set +a
+fi
+
+usage() {
+    cat << EOF
+Usage: $0 <org-name> [scan] [options]
+
+Check that a scan's result files match the digests in its provenance.json,
+and that provenance.json was signed by the expected key.
+
+Arguments:
+    org-name    Name of the tracked organization
+    scan        Scan timestamp (default: latest scan)
+
+Options:
+    --public-key <path>  PEM public key to check the signature against
+                         (default: SCAN_VERIFY_KEY from .env)
+    --json               Print the result as JSON
+    -h, --help           Show this help message
+
+Without a public key only the digests are checked, which catches
+accidental changes but not a rewritten provenance.json.
+
+Create a signing key pair:
+    openssl genpkey -algorithm EC -pkeyopt ec_paramgen_curve:P-256 -out scan-signing.pem
+    openssl pkey -in scan-signing.pem -pubout -out scan-signing.pub
+
+Exit codes:
+    0  Scan verified
+    1  Verification failed (or usage error)
+EOF
+    exit 1
+}
+
+ORG=""
+SCAN=""
+PUBLIC_KEY="${SCAN_VERIFY_KEY:-}"
+JSON_OUTPUT=false
+
+while [[ $# -gt 0 ]]; do
+    case "$1" in
+        --public-key)
+            PUBLIC_KEY="$2"
+            shift 2
+            ;;
+        --json)
+            JSON_OUTPUT=true
+            shift
+            ;;
+        -h|--help)
+            usage
+            ;;
+        -*)
+            echo "Unknown option: $1"
+            usage
+            ;;
+        *)
+            if [[ -z "$ORG" ]]; then
+                ORG="$1"
+            elif [[ -z "$SCAN" ]]; then
+                SCAN="$1"
+            else
+                echo "Too many arguments"
+                usage
+            fi
+            shift
+            ;;
+    esac
+done
+
+[[ -z "$ORG" ]] && usage
+validate_org_name "$ORG" || exit 1
+require_jq || exit 1
+
+if ! command -v openssl &> /dev/null; then
+    echo "Error: openssl is required"
+    exit 1
+fi
+
+if ! is_org_tracked "$ORG"; then
+    echo "Error: '$ORG' is not tracked"
+    exit 1
+fi
+
+if [[ -n "$SCAN" ]]; then
+    SCAN_DIR="$(get_org_scans_dir "$ORG")/$SCAN"
+    if [[ ! -d "$SCAN_DIR" ]]; then
+        echo "Error: Scan not found: $SCAN"
+        exit 1
+    fi
+else
+    SCAN_DIR=$(get_latest_scan_dir "$ORG") || {
+        echo "Error: No scans found for $ORG"
+        exit 1
+    }
+fi
+
+if [[ -n "$PUBLIC_KEY" && ! -r "$PUBLIC_KEY" ]]; then
+    echo "Error: Public key not readable: $PUBLIC_KEY"
+    exit 1
+fi
+
+status=0
+problems=$(verify_scan_provenance "$SCAN_DIR" "$PUBLIC_KEY") || status=1
+
+signed=false
+signer=""
+if [[ -f "$SCAN_DIR/$PROVENANCE_FILE" ]]; then
+    signer=$(jq -r '.signer // empty' "$SCAN_DIR/$PROVENANCE_FILE" 2>/dev/null || true)
+    [[ -n "$PUBLIC_KEY" && "$status" -eq 0 ]] && signed=true
+fi
+
+if [[ "$JSON_OUTPUT" == true ]]; then
+    printf '%s' "$problems" | jq -R -s \
+        --arg org "$ORG" \
+        --arg scan "$(basename "$SCAN_DIR")" \
+        --arg signer "$signer" \
+        --argjson signature_checked "$signed" \
+        --argjson verified "$([[ "$status" -eq 0 ]] && echo true || echo false)" '
+        {
+            org: $org,
+            scan: $scan,
+            verified: $verified,
+            signature_checked: $signature_checked,
+            signer: (if $signer == "" then null else $signer end),
+            problems: (split("\n") | map(select(length > 0) | split("\t") | {status: .[0], path: .[1]}))
+        }'
+    exit "$status"
+fi
+
+echo "Scan: $ORG/$(basename "$SCAN_DIR")"
+[[ -n "$signer" ]] && echo "Signer: $signer"
+
+if [[ "$status" -eq 0 ]]; then
+    if [[ "$signed" == true ]]; then
+        echo "Verified: signature and all result digests match"
+    else
+        echo "Verified: all result digests match (signature not checked, pass --public-key)"
+    fi
+    exit 0
+fi
+
+echo "Verification failed:"
+while IFS=$'\t' read -r problem path; do
+    [[ -z "$problem" ]] && continue
+    echo "  $problem: $path"
+done <<< "$problems"
+exit 1
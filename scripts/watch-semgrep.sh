@@ -0,0 +1,235 @@
+#!/usr/bin/env bash
+# Re-run semgrep on files as they change, for in-editor feedback
+#
+# Usage: ./scripts/watch-semgrep.sh [options] <repo-dir>
+#
+# Each change re-analyzes only the changed file and the files that import it
+# (its reverse dependencies), so cross-file taint from the change is still
+# caught without rescanning the whole package. Findings are printed as
+# path:line lines, or as one JSON object per analysis with --json for
+# editor integrations.
+#
+# Examples:
+#   ./scripts/watch-semgrep.sh repos/acme-corp/api
+#   ./scripts/watch-semgrep.sh --depth 2 repos/acme-corp/api
+#   ./scripts/watch-semgrep.sh --once src/handler.js --json repos/acme-corp/api
+
+set -euo pipefail
+
+SCRIPT_DIR="$(cd "$(dirname "${BASH_SOURCE[0]}")" && pwd)"
+source "$SCRIPT_DIR/lib/catalog-utils.sh"
+source "$SCRIPT_DIR/lib/rule-utils.sh"
+
+usage() {
+    cat << EOF
+Usage: $0 [options] <repo-dir>
+
+Watch a repository and re-analyze each changed file plus its reverse
+dependencies with semgrep.
+
+Options:
+    --depth <n>         Levels of importers to include (default: 1, 0 = changed file only)
+    --max-files <n>     Cap on files per analysis (default: 50)
+    --interval <secs>   Polling interval when inotifywait/fswatch are missing (default: 1)
+    --once <file>       Analyze one changed file (repo-relative) and exit
+    --json              Print one JSON object per analysis
+    --no-custom-rules   Scan with p/default only
+    -h, --help          Show this help message
+
+Requires: semgrep, git, jq. Uses inotifywait (Linux) or fswatch (macOS)
+when installed, polling otherwise.
+
+Examples:
+    $0 repos/acme-corp/api
+    $0 --depth 2 --max-files 100 repos/acme-corp/api
+    $0 --once src/handler.js --json repos/acme-corp/api
+EOF
+    exit 1
+}
+
+DEPTH=1
+MAX_FILES=50
+INTERVAL=1
+ONCE_FILE=""
+JSON_OUTPUT=""
+USE_CUSTOM_RULES=true
+REPO_DIR=""
+
+while [[ $# -gt 0 ]]; do
+    case "$1" in
+        --depth)
+            DEPTH="$2"
+            shift 2
+            ;;
+        --max-files)
+            MAX_FILES="$2"
+            shift 2
+            ;;
+        --interval)
+            INTERVAL="$2"
+            shift 2
+            ;;
+        --once)
+            ONCE_FILE="$2"
+            shift 2
+            ;;
+        --json)
+            JSON_OUTPUT="1"
+            shift
+            ;;
+        --no-custom-rules)
+            USE_CUSTOM_RULES=false
+            shift
+            ;;
+        -h|--help)
+            usage
+            ;;
+        -*)
+            echo "Unknown option: $1"
+            usage
+            ;;
+        *)
+            REPO_DIR="$1"
+            shift
+            ;;
+    esac
+done
+
+if [[ -z "$REPO_DIR" ]]; then
+    echo "Error: repo directory is required"
+    echo ""
+    usage
+fi
+
+for value in "$DEPTH" "$MAX_FILES" "$INTERVAL"; do
+    if [[ ! "$value" =~ ^[0-9]+$ ]]; then
+        echo "Error: --depth, --max-files and --interval must be whole numbers (got '$value')"
+        exit 1
+    fi
+done
+
+if ! git -C "$REPO_DIR" rev-parse --git-dir > /dev/null 2>&1; then
+    echo "Error: '$REPO_DIR' is not a git checkout"
+    exit 1
+fi
+
+require_jq || exit 1
+
+if ! command -v semgrep &> /dev/null; then
+    echo "Error: semgrep is required but not installed."
+    echo "Install: brew install semgrep"
+    exit 1
+fi
+
+REPO_DIR="$(cd "$REPO_DIR" && pwd)"
+
+CUSTOM_RULE_ARGS=()
+if [[ "$USE_CUSTOM_RULES" == true ]]; then
+    build_custom_rule_args
+fi
+
+TMP_DIR=$(mktemp -d)
+register_cleanup "$TMP_DIR"
+install_cancel_traps
+
+# =============================================================================
+# Analysis
+# =============================================================================
+
+# Milliseconds since the epoch (second precision without %N)
+now_ms() {
+    local now
+    now=$(date +%s%N)
+    if [[ "$now" == *N ]]; then
+        now="$(date +%s)000000000"
+    fi
+    echo $((now / 1000000))
+}
+
+# Scan a changed file and its dependents, then print the findings
+# Args: $1 = changed file relative to the repo
+analyze_change() {
+    local file="$1"
+    local start elapsed_ms target_count
+    local targets=()
+    local results="$TMP_DIR/results.json"
+
+    [[ -f "$REPO_DIR/$file" ]] || return 0
+    [[ -n "$(file_language "$file")" ]] || return 0
+
+    start=$(now_ms)
+    while IFS= read -r target; do
+        [[ -n "$target" ]] && targets+=("$target")
+    done < <(analysis_targets "$REPO_DIR" "$file" "$DEPTH" "$MAX_FILES")
+    target_count=${#targets[@]}
+
+    # File targets keep semgrep from walking the repo; --pro still follows
+    # taint across the files passed in
+    (
+        cd "$REPO_DIR"
+        semgrep scan \
+            --pro \
+            --config=p/default \
+            ${CUSTOM_RULE_ARGS[@]+"${CUSTOM_RULE_ARGS[@]}"} \
+            --metrics=off \
+            --json \
+            --output="$results" \
+            "${targets[@]}" > /dev/null 2>&1
+    ) || true
+    [[ -s "$results" ]] || echo '{"results": []}' > "$results"
+    elapsed_ms=$(( $(now_ms) - start ))
+
+    if [[ -n "$JSON_OUTPUT" ]]; then
+        jq -c --arg file "$file" --argjson elapsed "$elapsed_ms" \
+            --argjson targets "$(printf '%s\n' "${targets[@]}" | jq -R . | jq -s .)" '{
+                file: $file,
+                targets: $targets,
+                elapsed_ms: $elapsed,
+                results: [.results[] | {path, line: .start.line, end_line: .end.line,
+                    check_id, severity: .extra.severity, message: .extra.message}]
+            }' "$results"
+    else
+        echo "[$(date +%H:%M:%S)] $file (+$((target_count - 1)) dependents) in ${elapsed_ms}ms"
+        jq -r '.results[] | "  \(.path):\(.start.line): [\(.extra.severity)] \(.check_id | split(".") | last) \(.extra.message | split("\n")[0])"' \
+            "$results"
+    fi
+    rm -f "$results"
+}
+
+if [[ -n "$ONCE_FILE" ]]; then
+    analyze_change "${ONCE_FILE#./}"
+    exit 0
+fi
+
+# =============================================================================
+# Watch loop
+# =============================================================================
+
+# Print changed files (repo-relative), one per line, as they change
+watch_changes() {
+    local stamp="$TMP_DIR/stamp"
+    local next="$TMP_DIR/stamp.next"
+
+    if command -v inotifywait &> /dev/null; then
+        inotifywait -m -r -q -e close_write,moved_to --exclude '/\.git/' --format '%w%f' "$REPO_DIR"
+    elif command -v fswatch &> /dev/null; then
+        fswatch -r --exclude '/\.git/' "$REPO_DIR"
+    else
+        touch "$stamp"
+        while true; do
+            sleep "$INTERVAL"
+            touch "$next"
+            find "$REPO_DIR" -type f -newer "$stamp" -not -path '*/.git/*' -not -path '*/node_modules/*' 2>/dev/null
+            mv "$next" "$stamp"
+        done
+    fi
+}
+
+[[ -z "$JSON_OUTPUT" ]] && echo "Watching $REPO_DIR (depth $DEPTH, Ctrl-C to stop)"
+
+while IFS= read -r changed; do
+    changed="${changed#"$REPO_DIR"/}"
+    # Skip files git ignores (build output, dependencies)
+    git -C "$REPO_DIR" check-ignore -q -- "$changed" 2>/dev/null && continue
+    analyze_change "$changed"
+done < <(watch_changes)
@@ -0,0 +1,256 @@
+#!/usr/bin/env bash
+# Prune cloned repositories from the workspace (repos/<org>/<repo>)
+#
+# Usage: ./scripts/workspace-gc.sh [options]
+#
+# Removes clones unused for N days and, if a size cap is set, the least
+# recently used clones until the workspace fits. Scan results and catalog
+# data are never touched; a pruned repo is cloned again on the next hunt.
+#
+# Examples:
+#   ./scripts/workspace-gc.sh --max-age 30 --dry-run   # Preview
+#   ./scripts/workspace-gc.sh --max-size 50G           # Cap disk usage
+#   ./scripts/workspace-gc.sh --auto                   # Policy from .env
+
+set -euo pipefail
+
+SCRIPT_DIR="$(cd "$(dirname "${BASH_SOURCE[0]}")" && pwd)"
+source "$SCRIPT_DIR/lib/catalog-utils.sh"
+
+usage() {
+    cat << EOF
+Usage: $0 [options]
+
+Prune cloned repositories that have not been used recently.
+
+A clone counts as used when it is cloned, fetched, or checked out, or when
+a scan writes results for it. Repos with uncommitted changes are kept.
+
+Options:
+    --max-age <days>     Remove clones unused for more than <days> days
+    --max-size <size>    Cap total clone size (e.g. 500M, 50G); removes the
+                         least recently used clones until under the cap
+    --org <name>         Only prune clones for one org
+    --auto               Apply the policy from WORKSPACE_GC_MAX_AGE_DAYS and
+                         WORKSPACE_GC_MAX_SIZE (.env); does nothing if unset
+    --dry-run            Report what would be deleted without deleting
+    -f, --force          Skip confirmation prompt
+    -q, --quiet          Only print the summary line
+    -h, --help           Show this help message
+
+Examples:
+    $0 --max-age 30 --dry-run       # Preview clones unused for 30 days
+    $0 --max-size 50G --force       # Trim the workspace to 50G
+    $0 --org acme-corp --max-age 7  # Prune one org
+EOF
+    exit 1
+}
+
+# Load policy defaults from .env
+if [[ -f "$CATALOG_ROOT/.env" ]]; then
+    set -a
+    # shellcheck disable=SC1091
+    source "$CATALOG_ROOT/.env"
+    set +a
+fi
+
+MAX_AGE_DAYS=""
+MAX_SIZE=""
+ORG_FILTER=""
+AUTO_MODE=""
+DRY_RUN=""
+FORCE=""
+QUIET_MODE=""
+
+while [[ $# -gt 0 ]]; do
+    case "$1" in
+        --max-age)
+            MAX_AGE_DAYS="$2"
+            shift 2
+            ;;
+        --max-size)
+            MAX_SIZE="$2"
+            shift 2
+            ;;
+        --org)
+            ORG_FILTER="$2"
+            shift 2
+            ;;
+        --auto)
+            AUTO_MODE="1"
+            shift
+            ;;
+        --dry-run)
+            DRY_RUN="1"
+            shift
+            ;;
+        -f|--force)
+            FORCE="1"
+            shift
+            ;;
+        -q|--quiet)
+            QUIET_MODE="1"
+            shift
+            ;;
+        -h|--help)
+            usage
+            ;;
+        *)
+            echo "Unknown option: $1"
+            usage
+            ;;
+    esac
+done
+
+if [[ -n "$AUTO_MODE" ]]; then
+    MAX_AGE_DAYS="${MAX_AGE_DAYS:-${WORKSPACE_GC_MAX_AGE_DAYS:-}}"
+    MAX_SIZE="${MAX_SIZE:-${WORKSPACE_GC_MAX_SIZE:-}}"
+    [[ -z "$MAX_AGE_DAYS" && -z "$MAX_SIZE" ]] && exit 0
+    FORCE="1"
+fi
+
+if [[ -z "$MAX_AGE_DAYS" && -z "$MAX_SIZE" ]]; then
+    echo "Error: Specify --max-age and/or --max-size (or --auto with a policy in .env)"
+    exit 1
+fi
+
+if [[ -n "$MAX_AGE_DAYS" && ! "$MAX_AGE_DAYS" =~ ^[0-9]+$ ]]; then
+    echo "Error: --max-age must be a number of days"
+    exit 1
+fi
+
+MAX_SIZE_KB=""
+if [[ -n "$MAX_SIZE" ]]; then
+    if ! MAX_SIZE_KB=$(parse_size_kb "$MAX_SIZE"); then
+        echo "Error: --max-size must be a size like 500M or 50G"
+        exit 1
+    fi
+fi
+
+if [[ -n "$ORG_FILTER" ]] && ! validate_org_name "$ORG_FILTER"; then
+    exit 1
+fi
+
+WORKSPACE_DIR="$CATALOG_ROOT/repos"
+if [[ ! -d "$WORKSPACE_DIR" ]]; then
+    log_verbose "No workspace at $WORKSPACE_DIR, nothing to do."
+    exit 0
+fi
+
+# =============================================================================
+# Collect clones: last used, size, path
+# =============================================================================
+
+NOW=$(date +%s)
+CLONES=$(mktemp)
+register_cleanup "$CLONES"
+install_cancel_traps
+
+for org_dir in "$WORKSPACE_DIR"/*/; do
+    [[ -d "$org_dir" ]] || continue
+    org=$(basename "$org_dir")
+    [[ -n "$ORG_FILTER" && "$org" != "$ORG_FILTER" ]] && continue
+
+    for repo in "$org_dir"*/; do
+        [[ -d "$repo/.git" ]] || continue
+        repo="${repo%/}"
+        last_used=$(repo_last_used "$repo" "$CATALOG_ROOT/scans/$org")
+        size_kb=$(du -sk "$repo" 2>/dev/null | cut -f1)
+        printf '%s\t%s\t%s/%s\t%s\n' "$last_used" "${size_kb:-0}" "$org" "$(basename "$repo")" "$repo" >> "$CLONES"
+    done
+done
+
+TOTAL_KB=$(awk -F'\t' '{ s += $2 } END { print s + 0 }' "$CLONES")
+CLONE_COUNT=$(grep -c . "$CLONES" || true)
+
+# =============================================================================
+# Apply policies (oldest first)
+# =============================================================================
+
+if [[ -z "$QUIET_MODE" ]]; then
+    echo "========================================"
+    echo "Workspace GC"
+    echo "========================================"
+    echo "Workspace:    $WORKSPACE_DIR"
+    echo "Clones:       $CLONE_COUNT ($(format_size_kb "$TOTAL_KB"))"
+    [[ -n "$MAX_AGE_DAYS" ]] && echo "Max age:      $MAX_AGE_DAYS days"
+    [[ -n "$MAX_SIZE" ]] && echo "Max size:     $MAX_SIZE"
+    echo ""
+fi
+
+# Plan lines: reason, last used, size, name, path
+PLAN=()
+remaining_kb="$TOTAL_KB"
+kept_dirty=0
+
+while IFS=$'\t' read -r last_used size_kb name path; do
+    [[ -z "$name" ]] && continue
+    age_days=$(( (NOW - last_used) / 86400 ))
+    reason=""
+
+    if [[ -n "$MAX_AGE_DAYS" && "$age_days" -gt "$MAX_AGE_DAYS" ]]; then
+        reason="unused ${age_days}d"
+    elif [[ -n "$MAX_SIZE_KB" && "$remaining_kb" -gt "$MAX_SIZE_KB" ]]; then
+        reason="size cap"
+    fi
+    [[ -z "$reason" ]] && continue
+
+    # Never delete local work (--no-optional-locks keeps git status from
+    # refreshing .git/index, which would count as a use)
+    if [[ -n "$(git --no-optional-locks -C "$path" status --porcelain 2>/dev/null | head -1)" ]]; then
+        [[ -z "$QUIET_MODE" ]] && echo "  Keeping $name: uncommitted changes"
+        kept_dirty=$((kept_dirty + 1))
+        continue
+    fi
+
+    PLAN+=("$reason"$'\t'"$last_used"$'\t'"$size_kb"$'\t'"$name"$'\t'"$path")
+    remaining_kb=$((remaining_kb - size_kb))
+done < <(sort -n "$CLONES")
+
+# =============================================================================
+# Report and delete
+# =============================================================================
+
+if [[ ${#PLAN[@]} -eq 0 ]]; then
+    echo "Workspace GC: nothing to delete ($CLONE_COUNT clones, $(format_size_kb "$TOTAL_KB"))"
+    exit 0
+fi
+
+freed_kb=0
+if [[ -z "$QUIET_MODE" ]]; then
+    [[ -n "$DRY_RUN" ]] && echo "Would delete:" || echo "Deleting:"
+    printf "  %-40s %8s  %-10s  %s\n" "REPO" "SIZE" "LAST USED" "REASON"
+fi
+for entry in "${PLAN[@]}"; do
+    IFS=$'\t' read -r reason last_used size_kb name path <<< "$entry"
+    freed_kb=$((freed_kb + size_kb))
+    if [[ -z "$QUIET_MODE" ]]; then
+        last_used_date=$(date -d "@$last_used" +%Y-%m-%d 2>/dev/null || date -r "$last_used" +%Y-%m-%d)
+        printf "  %-40s %8s  %-10s  %s\n" "$name" "$(format_size_kb "$size_kb")" "$last_used_date" "$reason"
+    fi
+done
+[[ -z "$QUIET_MODE" ]] && echo ""
+
+if [[ -n "$DRY_RUN" ]]; then
+    echo "Workspace GC (dry run): would delete ${#PLAN[@]} clones, freeing $(format_size_kb "$freed_kb")"
+    exit 0
+fi
+
+if [[ -z "$FORCE" ]]; then
+    read -p "Delete ${#PLAN[@]} clones? [y/N] " -n 1 -r
+    echo ""
+    if [[ ! $REPLY =~ ^[Yy]$ ]]; then
+        echo "Cancelled."
+        exit 0
+    fi
+fi
+
+for entry in "${PLAN[@]}"; do
+    IFS=$'\t' read -r reason last_used size_kb name path <<< "$entry"
+    rm -rf "$path"
+    log_verbose "  Deleted $name"
+done
+
+summary="Workspace GC: deleted ${#PLAN[@]} clones, freed $(format_size_kb "$freed_kb"), $(format_size_kb $((TOTAL_KB - freed_kb))) remaining"
+[[ "$kept_dirty" -gt 0 ]] && summary+=" ($kept_dirty kept with uncommitted changes)"
+echo "$summary"